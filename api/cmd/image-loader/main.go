@@ -16,10 +16,13 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/controller/cluster"
 	containerlinux "github.com/kubermatic/kubermatic/api/pkg/controller/container-linux"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/monitoring"
+	userclusterkonnectivity "github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/konnectivity"
+	userclustermetricsserver "github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/metrics-server"
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	"github.com/kubermatic/kubermatic/api/pkg/docker"
 	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/konnectivity"
 	metricsserver "github.com/kubermatic/kubermatic/api/pkg/resources/metrics-server"
 	ksemver "github.com/kubermatic/kubermatic/api/pkg/semver"
 	kubermaticversion "github.com/kubermatic/kubermatic/api/pkg/version"
@@ -169,8 +172,13 @@ func getImagesFromCreators(templateData *resources.TemplateData) (images []strin
 	deploymentCreators := cluster.GetDeploymentCreators(templateData, false)
 	deploymentCreators = append(deploymentCreators, monitoring.GetDeploymentCreators(templateData)...)
 	deploymentCreators = append(deploymentCreators, containerlinux.GetDeploymentCreators("")...)
-
-	cronjobCreators := cluster.GetCronJobCreators(templateData)
+	// The default cluster uses OpenVPN, the konnectivity images are needed for clusters which use or
+	// get migrated to konnectivity
+	deploymentCreators = append(deploymentCreators,
+		konnectivity.DeploymentCreator(templateData),
+		userclusterkonnectivity.DeploymentCreator("", 0),
+		userclustermetricsserver.DeploymentCreator(),
+	)
 
 	daemonSetCreators := containerlinux.GetDaemonSetCreators("")
 
@@ -192,15 +200,6 @@ func getImagesFromCreators(templateData *resources.TemplateData) (images []strin
 		images = append(images, getImagesFromPodSpec(deployment.Spec.Template.Spec)...)
 	}
 
-	for _, createFunc := range cronjobCreators {
-		_, creator := createFunc()
-		cronJob, err := creator(&batchv1beta1.CronJob{})
-		if err != nil {
-			return nil, err
-		}
-		images = append(images, getImagesFromPodSpec(cronJob.Spec.JobTemplate.Spec.Template.Spec)...)
-	}
-
 	for _, createFunc := range daemonSetCreators {
 		_, creator := createFunc()
 		daemonSet, err := creator(&appsv1.DaemonSet{})
@@ -325,6 +324,8 @@ func getTemplateData(version *kubermaticversion.Version) (*resources.TemplateDat
 		resources.OpenVPNCASecretName,
 		resources.OpenVPNServerCertificatesSecretName,
 		resources.OpenVPNClientCertificatesSecretName,
+		resources.KonnectivityCASecretName,
+		resources.KonnectivityServerCertificatesSecretName,
 		resources.FrontProxyCASecretName,
 		resources.KubeletDnatControllerKubeconfigSecretName,
 		resources.PrometheusApiserverClientCertificateSecretName,
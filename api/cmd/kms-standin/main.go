@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+)
+
+// kms-standin is a KMS plugin for the apiserver of user clusters without a real key management
+// service. It runs as sidecar of the apiserver and wraps the data encryption keys with the keys
+// stored in the cluster namespace.
+func main() {
+	var (
+		listen  string
+		keysDir string
+	)
+	flag.StringVar(&listen, "listen", "/var/run/kmsplugin/socket.sock", "The unix socket to listen on")
+	flag.StringVar(&keysDir, "keys-dir", "/etc/kubernetes/encryption-keys", "The directory with the mounted encryption keys")
+	flag.Parse()
+
+	log := kubermaticlog.Logger.Named("kms-standin")
+
+	// A socket remains when the container gets restarted
+	if err := os.Remove(listen); err != nil && !os.IsNotExist(err) {
+		log.Fatalw("Failed to remove the old socket", zap.Error(err))
+	}
+	listener, err := net.Listen("unix", listen)
+	if err != nil {
+		log.Fatalw("Failed to listen", "socket", listen, zap.Error(err))
+	}
+
+	server := grpc.NewServer()
+	registerKeyManagementServiceServer(server, &standin{keysDir: keysDir})
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-signals
+		server.GracefulStop()
+	}()
+
+	log.Infow("Serving the KMS plugin API", "socket", listen)
+	if err := server.Serve(listener); err != nil {
+		log.Fatalw("Failed to serve", zap.Error(err))
+	}
+}
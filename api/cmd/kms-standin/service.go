@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// The messages and the service are the v1beta1 KMS plugin API of the kube-apiserver, see
+// k8s.io/apiserver/pkg/storage/value/encrypt/envelope/v1beta1/service.proto. They are
+// written by hand because the apiserver packages are not vendored.
+
+const kmsAPIVersion = "v1beta1"
+
+type VersionRequest struct {
+	Version string `protobuf:"bytes,1,opt,name=version,proto3"`
+}
+
+func (m *VersionRequest) Reset()         { *m = VersionRequest{} }
+func (m *VersionRequest) String() string { return proto.CompactTextString(m) }
+func (*VersionRequest) ProtoMessage()    {}
+
+type VersionResponse struct {
+	Version        string `protobuf:"bytes,1,opt,name=version,proto3"`
+	RuntimeName    string `protobuf:"bytes,2,opt,name=runtime_name,json=runtimeName,proto3"`
+	RuntimeVersion string `protobuf:"bytes,3,opt,name=runtime_version,json=runtimeVersion,proto3"`
+}
+
+func (m *VersionResponse) Reset()         { *m = VersionResponse{} }
+func (m *VersionResponse) String() string { return proto.CompactTextString(m) }
+func (*VersionResponse) ProtoMessage()    {}
+
+type DecryptRequest struct {
+	Version string `protobuf:"bytes,1,opt,name=version,proto3"`
+	Cipher  []byte `protobuf:"bytes,2,opt,name=cipher,proto3"`
+}
+
+func (m *DecryptRequest) Reset()         { *m = DecryptRequest{} }
+func (m *DecryptRequest) String() string { return proto.CompactTextString(m) }
+func (*DecryptRequest) ProtoMessage()    {}
+
+type DecryptResponse struct {
+	Plain []byte `protobuf:"bytes,1,opt,name=plain,proto3"`
+}
+
+func (m *DecryptResponse) Reset()         { *m = DecryptResponse{} }
+func (m *DecryptResponse) String() string { return proto.CompactTextString(m) }
+func (*DecryptResponse) ProtoMessage()    {}
+
+type EncryptRequest struct {
+	Version string `protobuf:"bytes,1,opt,name=version,proto3"`
+	Plain   []byte `protobuf:"bytes,2,opt,name=plain,proto3"`
+}
+
+func (m *EncryptRequest) Reset()         { *m = EncryptRequest{} }
+func (m *EncryptRequest) String() string { return proto.CompactTextString(m) }
+func (*EncryptRequest) ProtoMessage()    {}
+
+type EncryptResponse struct {
+	Cipher []byte `protobuf:"bytes,1,opt,name=cipher,proto3"`
+}
+
+func (m *EncryptResponse) Reset()         { *m = EncryptResponse{} }
+func (m *EncryptResponse) String() string { return proto.CompactTextString(m) }
+func (*EncryptResponse) ProtoMessage()    {}
+
+// keyManagementServiceServer is the server API of the KeyManagementService
+type keyManagementServiceServer interface {
+	Version(context.Context, *VersionRequest) (*VersionResponse, error)
+	Decrypt(context.Context, *DecryptRequest) (*DecryptResponse, error)
+	Encrypt(context.Context, *EncryptRequest) (*EncryptResponse, error)
+}
+
+func registerKeyManagementServiceServer(s *grpc.Server, srv keyManagementServiceServer) {
+	s.RegisterService(&keyManagementServiceDesc, srv)
+}
+
+var keyManagementServiceDesc = grpc.ServiceDesc{
+	ServiceName: "v1beta1.KeyManagementService",
+	HandlerType: (*keyManagementServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Version",
+			Handler: unaryHandler("Version", func() interface{} { return &VersionRequest{} }, func(ctx context.Context, srv keyManagementServiceServer, req interface{}) (interface{}, error) {
+				return srv.Version(ctx, req.(*VersionRequest))
+			}),
+		},
+		{
+			MethodName: "Decrypt",
+			Handler: unaryHandler("Decrypt", func() interface{} { return &DecryptRequest{} }, func(ctx context.Context, srv keyManagementServiceServer, req interface{}) (interface{}, error) {
+				return srv.Decrypt(ctx, req.(*DecryptRequest))
+			}),
+		},
+		{
+			MethodName: "Encrypt",
+			Handler: unaryHandler("Encrypt", func() interface{} { return &EncryptRequest{} }, func(ctx context.Context, srv keyManagementServiceServer, req interface{}) (interface{}, error) {
+				return srv.Encrypt(ctx, req.(*EncryptRequest))
+			}),
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "service.proto",
+}
+
+// unaryHandler returns the grpc handler of a method, like the generated code would do
+func unaryHandler(method string, newRequest func() interface{}, call func(context.Context, keyManagementServiceServer, interface{}) (interface{}, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		req := newRequest()
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(ctx, srv.(keyManagementServiceServer), req)
+		}
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/v1beta1.KeyManagementService/" + method,
+		}
+		return interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(ctx, srv.(keyManagementServiceServer), req)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	cryptorand "crypto/rand"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
+)
+
+// standin wraps the data encryption keys of the apiserver with AES-GCM, using the keys which the
+// cluster encryption controller maintains in the cluster namespace. The keys are read from the
+// mounted secret for every request, so that a rotation doesn't need a restart.
+type standin struct {
+	keysDir string
+}
+
+var _ keyManagementServiceServer = &standin{}
+
+func (s *standin) Version(_ context.Context, _ *VersionRequest) (*VersionResponse, error) {
+	return &VersionResponse{
+		Version:        kmsAPIVersion,
+		RuntimeName:    "kubermatic-kms-standin",
+		RuntimeVersion: resources.KUBERMATICGITTAG,
+	}, nil
+}
+
+// Encrypt wraps the data encryption key with the current key. The ciphertext is prefixed with the
+// name of the key, so that it can still be decrypted while the key gets rotated.
+func (s *standin) Encrypt(_ context.Context, req *EncryptRequest) (*EncryptResponse, error) {
+	key, err := s.readKey(resources.EncryptionKeySecretKey)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, errors.New("no encryption key is configured")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	name := apiserver.EncryptionKeyName(key)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := cryptorand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate a nonce: %v", err)
+	}
+	ciphertext := append([]byte{byte(len(name))}, name...)
+	ciphertext = append(ciphertext, nonce...)
+	return &EncryptResponse{Cipher: aead.Seal(ciphertext, nonce, req.Plain, []byte(name))}, nil
+}
+
+// Decrypt unwraps a data encryption key with the key it was wrapped with
+func (s *standin) Decrypt(_ context.Context, req *DecryptRequest) (*DecryptResponse, error) {
+	if len(req.Cipher) == 0 || len(req.Cipher) < 1+int(req.Cipher[0]) {
+		return nil, errors.New("invalid ciphertext")
+	}
+	name := string(req.Cipher[1 : 1+req.Cipher[0]])
+	sealed := req.Cipher[1+len(name):]
+
+	for _, secretKey := range []string{resources.EncryptionKeySecretKey, resources.NextEncryptionKeySecretKey, resources.PreviousEncryptionKeySecretKey} {
+		key, err := s.readKey(secretKey)
+		if err != nil {
+			return nil, err
+		}
+		if key == nil || apiserver.EncryptionKeyName(key) != name {
+			continue
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(sealed) < aead.NonceSize() {
+			return nil, errors.New("invalid ciphertext")
+		}
+		plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt with key %s: %v", name, err)
+		}
+		return &DecryptResponse{Plain: plain}, nil
+	}
+	return nil, fmt.Errorf("the key %s is unknown", name)
+}
+
+// readKey returns the key which is stored under the given secret key, or nil if it doesn't exist
+func (s *standin) readKey(secretKey string) ([]byte, error) {
+	key, err := ioutil.ReadFile(filepath.Join(s.keysDir, secretKey))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the key %s: %v", secretKey, err)
+	}
+	return key, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
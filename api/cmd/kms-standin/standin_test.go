@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+)
+
+func TestStandinRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kms-standin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	writeKey := func(secretKey, key string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, secretKey), []byte(key), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	socket := filepath.Join(dir, "socket.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	registerKeyManagementServiceServer(server, &standin{keysDir: dir})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, socket, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout("unix", addr, timeout)
+	}))
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	call := func(method string, req, resp interface{}) error {
+		return conn.Invoke(ctx, "/v1beta1.KeyManagementService/"+method, req, resp)
+	}
+
+	version := &VersionResponse{}
+	if err := call("Version", &VersionRequest{Version: kmsAPIVersion}, version); err != nil {
+		t.Fatalf("failed to get the version: %v", err)
+	}
+	if version.Version != kmsAPIVersion {
+		t.Errorf("expected the version %s, got %s", kmsAPIVersion, version.Version)
+	}
+
+	dek := []byte("data encryption key")
+	oldKey := "0123456789abcdef0123456789abcdef"
+	newKey := "fedcba9876543210fedcba9876543210"
+	writeKey(resources.EncryptionKeySecretKey, oldKey)
+	encrypted := &EncryptResponse{}
+	if err := call("Encrypt", &EncryptRequest{Version: kmsAPIVersion, Plain: dek}, encrypted); err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+	if bytes.Contains(encrypted.Cipher, dek) {
+		t.Fatalf("the ciphertext contains the plaintext")
+	}
+
+	// The ciphertext of the old key remains readable while the key gets rotated, but not afterwards
+	writeKey(resources.EncryptionKeySecretKey, newKey)
+	writeKey(resources.PreviousEncryptionKeySecretKey, oldKey)
+	decrypted := &DecryptResponse{}
+	if err := call("Decrypt", &DecryptRequest{Version: kmsAPIVersion, Cipher: encrypted.Cipher}, decrypted); err != nil {
+		t.Fatalf("failed to decrypt after the rotation started: %v", err)
+	}
+	if !bytes.Equal(decrypted.Plain, dek) {
+		t.Errorf("expected the plaintext %q, got %q", dek, decrypted.Plain)
+	}
+
+	if err := os.Remove(filepath.Join(dir, resources.PreviousEncryptionKeySecretKey)); err != nil {
+		t.Fatal(err)
+	}
+	if err := call("Decrypt", &DecryptRequest{Version: kmsAPIVersion, Cipher: encrypted.Cipher}, decrypted); err == nil {
+		t.Errorf("expected an error when decrypting with a removed key")
+	}
+}
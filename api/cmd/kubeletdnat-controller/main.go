@@ -29,6 +29,8 @@ func main() {
 	networkFlag := flag.String("node-access-network", "", "The network in CIDR notation to translate to.")
 	chainNameFlag := flag.String("chain-name", "node-access-dnat", "Name of the chain in nat table.")
 	vpnInterfaceFlag := flag.String("vpn-interface", "tun0", "Name of the vpn interface.")
+	backendFlag := flag.String("backend", kubeletdnat.BackendAuto, fmt.Sprintf("Backend to install the rules with. Available are: %v", kubeletdnat.AvailableBackends))
+	verifyIntervalFlag := flag.Duration("verify-interval", time.Minute, "The interval in which the installed rules get verified and restored if they drifted.")
 	logDebugFlag := flag.Bool("log-debug", false, "Enables debug logging")
 	logFormatFlag := flag.String("log-format", string(kubermaticlog.FormatJSON), "Log format. Available are: "+kubermaticlog.AvailableFormats.String())
 
@@ -83,7 +85,7 @@ func main() {
 		log.Fatalw("failed to create mgr", zap.Error(err))
 	}
 
-	if err := kubeletdnat.Add(mgr, *chainNameFlag, nodeAccessNetwork, log, *vpnInterfaceFlag); err != nil {
+	if err := kubeletdnat.Add(mgr, *chainNameFlag, nodeAccessNetwork, log, *vpnInterfaceFlag, *backendFlag, *verifyIntervalFlag); err != nil {
 		log.Fatalw("failed to add the kubelet dnat controller", zap.Error(err))
 	}
 
@@ -181,6 +181,7 @@ func createInitProviders(options serverRunOptions) (providers, error) {
 
 	userMasterLister := kubermaticMasterInformerFactory.Kubermatic().V1().Users().Lister()
 	sshKeyProvider := kubernetesprovider.NewSSHKeyProvider(defaultKubermaticImpersonationClient.CreateImpersonatedKubermaticClientSet, kubermaticMasterInformerFactory.Kubermatic().V1().UserSSHKeys().Lister())
+	clusterTemplateProvider := kubernetesprovider.NewClusterTemplateProvider(defaultKubermaticImpersonationClient.CreateImpersonatedKubermaticClientSet, kubermaticMasterInformerFactory.Kubermatic().V1().ClusterTemplates().Lister())
 	userProvider := kubernetesprovider.NewUserProvider(kubermaticMasterClient, userMasterLister, kubernetesprovider.IsServiceAccount)
 
 	serviceAccountTokenProvider, err := kubernetesprovider.NewServiceAccountTokenProvider(defaultKubernetesImpersonationClient.CreateImpersonatedKubernetesClientSet, kubeMasterInformerFactory.Core().V1().Secrets().Lister())
@@ -211,6 +212,7 @@ func createInitProviders(options serverRunOptions) (providers, error) {
 
 	return providers{
 		sshKey:                                sshKeyProvider,
+		clusterTemplate:                       clusterTemplateProvider,
 		user:                                  userProvider,
 		serviceAccountProvider:                serviceAccountProvider,
 		serviceAccountTokenProvider:           serviceAccountTokenProvider,
@@ -291,6 +293,7 @@ func createAPIHandler(options serverRunOptions, prov providers, oidcIssuerVerifi
 		prov.clusterProviderGetter,
 		prov.addons,
 		prov.sshKey,
+		prov.clusterTemplate,
 		prov.user,
 		prov.serviceAccountProvider,
 		prov.serviceAccountTokenProvider,
@@ -139,6 +139,7 @@ func (o serverRunOptions) validate() error {
 
 type providers struct {
 	sshKey                                provider.SSHKeyProvider
+	clusterTemplate                       provider.ClusterTemplateProvider
 	user                                  provider.UserProvider
 	serviceAccountProvider                provider.ServiceAccountProvider
 	serviceAccountTokenProvider           provider.ServiceAccountTokenProvider
@@ -13,7 +13,12 @@ import (
 	backupcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/backup"
 	cloudcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/cloud"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/cluster"
+	clustercertificates "github.com/kubermatic/kubermatic/api/pkg/controller/cluster-certificates"
+	clusterencryption "github.com/kubermatic/kubermatic/api/pkg/controller/cluster-encryption"
+	clusterhealthprober "github.com/kubermatic/kubermatic/api/pkg/controller/cluster-health-prober"
+	clusterhibernation "github.com/kubermatic/kubermatic/api/pkg/controller/cluster-hibernation"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/clustercomponentdefaulter"
+	etcdmaintenance "github.com/kubermatic/kubermatic/api/pkg/controller/etcd-maintenance"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/monitoring"
 	openshiftcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/openshift"
 	updatecontroller "github.com/kubermatic/kubermatic/api/pkg/controller/update"
@@ -41,12 +46,21 @@ var allControllers = map[string]controllerCreator{
 	openshiftcontroller.ControllerName:       createOpenshiftController,
 	clustercomponentdefaulter.ControllerName: createClusterComponentDefaulter,
 	usersshkeys.ControllerName:               createUserSSHKeyController,
+	clusterhibernation.ControllerName:        createClusterHibernationController,
+	clustercertificates.ControllerName:       createClusterCertificatesController,
+	clusterhealthprober.ControllerName:       createClusterHealthProberController,
+	clusterencryption.ControllerName:         createClusterEncryptionController,
+	etcdmaintenance.ControllerName:           createEtcdMaintenanceController,
 }
 
 type controllerCreator func(*controllerContext) error
 
 func createAllControllers(ctrlCtx *controllerContext) error {
 	for name, create := range allControllers {
+		if ctrlCtx.runOptions.dryRun && !dryRunControllers.Has(name) {
+			ctrlCtx.log.Infow("Skipping controller in dry-run mode", "controller", name)
+			continue
+		}
 		if err := create(ctrlCtx); err != nil {
 			return fmt.Errorf("failed to create %q controller: %v", name, err)
 		}
@@ -140,6 +154,7 @@ func createClusterController(ctrlCtx *controllerContext) error {
 		ctrlCtx.dockerPullConfigJSON,
 		strings.Contains(ctrlCtx.runOptions.kubernetesAddonsList, "nodelocal-dns-cache"),
 		ctrlCtx.runOptions.concurrentClusterUpdate,
+		ctrlCtx.runOptions.dryRun,
 		ctrlCtx.runOptions.oidcCAFile,
 		ctrlCtx.runOptions.oidcIssuerURL,
 		ctrlCtx.runOptions.oidcIssuerClientID,
@@ -303,3 +318,59 @@ func createUserSSHKeyController(ctrlCtx *controllerContext) error {
 		ctrlCtx.runOptions.workerName,
 		ctrlCtx.runOptions.workerCount)
 }
+
+func createClusterHibernationController(ctrlCtx *controllerContext) error {
+	return clusterhibernation.Add(
+		ctrlCtx.mgr,
+		ctrlCtx.log,
+		ctrlCtx.runOptions.workerCount,
+		ctrlCtx.runOptions.workerName,
+		ctrlCtx.clientProvider,
+	)
+}
+
+func createClusterCertificatesController(ctrlCtx *controllerContext) error {
+	return clustercertificates.Add(
+		ctrlCtx.mgr,
+		ctrlCtx.log,
+		ctrlCtx.runOptions.workerCount,
+		ctrlCtx.runOptions.workerName,
+		ctrlCtx.clientProvider,
+		ctrlCtx.runOptions.rootCARotationStageDuration,
+	)
+}
+
+func createClusterEncryptionController(ctrlCtx *controllerContext) error {
+	return clusterencryption.Add(
+		ctrlCtx.mgr,
+		ctrlCtx.log,
+		ctrlCtx.runOptions.workerCount,
+		ctrlCtx.runOptions.workerName,
+		ctrlCtx.clientProvider,
+	)
+}
+
+func createEtcdMaintenanceController(ctrlCtx *controllerContext) error {
+	return etcdmaintenance.Add(
+		ctrlCtx.mgr,
+		ctrlCtx.log,
+		ctrlCtx.runOptions.workerCount,
+		ctrlCtx.runOptions.workerName,
+		ctrlCtx.etcdCollector,
+		ctrlCtx.runOptions.etcdMaintenanceInterval,
+		ctrlCtx.runOptions.etcdDefragmentationWindow,
+		ctrlCtx.runOptions.etcdDefragmentationThreshold,
+	)
+}
+
+func createClusterHealthProberController(ctrlCtx *controllerContext) error {
+	return clusterhealthprober.Add(
+		ctrlCtx.mgr,
+		ctrlCtx.log,
+		ctrlCtx.runOptions.workerCount,
+		ctrlCtx.runOptions.workerName,
+		ctrlCtx.clientProvider,
+		ctrlCtx.runOptions.healthProbeInterval,
+		ctrlCtx.runOptions.healthProbeTimeout,
+	)
+}
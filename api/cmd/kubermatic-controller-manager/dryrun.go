@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	"github.com/kubermatic/kubermatic/api/pkg/controller/cluster"
+	"github.com/kubermatic/kubermatic/api/pkg/controller/monitoring"
+	openshiftcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/openshift"
+	"github.com/kubermatic/kubermatic/api/pkg/controller/usersshkeys"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// dryRunControllers are the controllers which run in the dry-run mode. Their changes to objects get
+// recorded by the dry-run clients instead of being applied, only the events they emit still get created.
+// The cluster controller doesn't scale the etcd in the dry-run mode, as it changes the members through
+// the etcd API. The other controllers talk to cloud providers or etcd, or apply manifests to the user
+// clusters, which can't be done dry.
+var dryRunControllers = sets.NewString(
+	cluster.ControllerName,
+	monitoring.ControllerName,
+	openshiftcontroller.ControllerName,
+	usersshkeys.ControllerName,
+)
+
+// dryRunClientProvider returns clients for the user clusters which record the changes instead of applying them
+type dryRunClientProvider struct {
+	client.UserClusterConnectionProvider
+
+	recorder *reconciling.DryRunRecorder
+}
+
+func (p *dryRunClientProvider) GetClient(c *kubermaticv1.Cluster, options ...client.ConfigOption) (ctrlruntimeclient.Client, error) {
+	userClusterClient, err := p.UserClusterConnectionProvider.GetClient(c, options...)
+	if err != nil {
+		return nil, err
+	}
+	return reconciling.NewDryRunClient(userClusterClient, c.Name, p.recorder), nil
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
+	"time"
 
 	"github.com/go-logr/zapr"
 	"github.com/oklog/run"
@@ -20,6 +22,7 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/metrics"
 	metricserver "github.com/kubermatic/kubermatic/api/pkg/metrics/server"
 	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
 	"github.com/kubermatic/kubermatic/api/pkg/signals"
 	"github.com/kubermatic/kubermatic/api/pkg/util/informer"
 	"github.com/kubermatic/kubermatic/api/pkg/util/restmapper"
@@ -71,7 +74,15 @@ func main() {
 
 	// Create a manager, disable metrics as we have our own handler that exposes
 	// the metrics of both the ctrltuntime registry and the default registry
-	mgr, err := manager.New(config, manager.Options{MetricsBindAddress: "0"})
+	mgrOptions := manager.Options{MetricsBindAddress: "0"}
+	var dryRunRecorder *reconciling.DryRunRecorder
+	if options.dryRun {
+		log.Info("Running in dry-run mode, no object will be changed")
+		dryRunRecorder = reconciling.NewDryRunRecorder(log)
+		prometheus.MustRegister(dryRunRecorder)
+		mgrOptions.NewClient = reconciling.NewDryRunClientFunc("seed", dryRunRecorder)
+	}
+	mgr, err := manager.New(config, mgrOptions)
 	if err != nil {
 		log.Fatalw("Failed to create the manager", zap.Error(err))
 	}
@@ -119,6 +130,9 @@ Please install the VerticalPodAutoscaler according to the documentation: https:/
 	if err != nil {
 		log.Fatalw("Failed to get clientProvider", zap.Error(err))
 	}
+	if options.dryRun {
+		clientProvider = &dryRunClientProvider{UserClusterConnectionProvider: clientProvider, recorder: dryRunRecorder}
+	}
 
 	if options.dynamicDatacenters {
 		restMapperCache := restmapper.New()
@@ -163,6 +177,10 @@ Please install the VerticalPodAutoscaler according to the documentation: https:/
 		}
 	}
 
+	log.Debug("Starting etcd collector")
+	// The etcd maintenance controller gathers the metrics
+	etcdCollector := collectors.MustRegisterEtcdCollector(prometheus.DefaultRegisterer)
+
 	ctrlCtx := &controllerContext{
 		runOptions:           options,
 		mgr:                  mgr,
@@ -170,6 +188,7 @@ Please install the VerticalPodAutoscaler according to the documentation: https:/
 		seedGetter:           seedGetter,
 		dockerPullConfigJSON: dockerPullConfigJSON,
 		log:                  log,
+		etcdCollector:        etcdCollector,
 	}
 
 	if err := createAllControllers(ctrlCtx); err != nil {
@@ -182,6 +201,18 @@ Please install the VerticalPodAutoscaler according to the documentation: https:/
 	log.Debug("Starting addons collector")
 	collectors.MustRegisterAddonCollector(prometheus.DefaultRegisterer, ctrlCtx.mgr.GetClient())
 
+	log.Debug("Starting certificates collector")
+	collectors.MustRegisterCertificateCollector(prometheus.DefaultRegisterer, ctrlCtx.mgr.GetClient())
+
+	log.Debug("Starting seed collector")
+	// Use an uncached client, the seed collector lists the pods of all cluster
+	// namespaces and we don't want to keep all of them in the informer cache
+	seedCollectorClient, err := ctrlruntimeclient.New(mgr.GetConfig(), ctrlruntimeclient.Options{})
+	if err != nil {
+		log.Fatalw("Failed to create the seed collector client", zap.Error(err))
+	}
+	collectors.MustRegisterSeedCollector(prometheus.DefaultRegisterer, seedCollectorClient, seedGetter)
+
 	if err := mgr.Add(metricserver.New(options.internalAddr)); err != nil {
 		log.Fatalw("failed to add the metricsserver", zap.Error(err))
 	}
@@ -203,6 +234,19 @@ Please install the VerticalPodAutoscaler according to the documentation: https:/
 		})
 	}
 
+	if options.dryRunReportAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/", dryRunRecorder)
+		s := &http.Server{Addr: options.dryRunReportAddr, Handler: mux}
+		g.Add(s.ListenAndServe, func(err error) {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			if err := s.Shutdown(shutdownCtx); err != nil {
+				log.Errorw("Failed to shutdown the dry-run report server", zap.Error(err))
+			}
+		})
+	}
+
 	// This group is running the actual controller logic
 	{
 		leaderCtx, stopLeaderElection := context.WithCancel(rootCtx)
@@ -213,13 +257,19 @@ Please install the VerticalPodAutoscaler according to the documentation: https:/
 			if options.workerName != "" {
 				electionName += "-" + options.workerName
 			}
+			// A dry-run must not keep the actual controller-manager from running
+			if options.dryRun {
+				electionName += "-dry-run"
+			}
 
 			return leaderelection.RunAsLeader(leaderCtx, log, config, mgr.GetRecorder(controllerName), electionName, func(ctx context.Context) error {
-				log.Info("Executing migrations...")
-				if err := migrations.RunAll(ctrlCtx.mgr.GetConfig(), options.workerName); err != nil {
-					return fmt.Errorf("failed to run migrations: %v", err)
+				if !options.dryRun {
+					log.Info("Executing migrations...")
+					if err := migrations.RunAll(ctrlCtx.mgr.GetConfig(), options.workerName); err != nil {
+						return fmt.Errorf("failed to run migrations: %v", err)
+					}
+					log.Info("Migrations executed successfully")
 				}
-				log.Info("Migrations executed successfully")
 
 				log.Info("Starting the controller-manager...")
 				if err := mgr.Start(ctx.Done()); err != nil {
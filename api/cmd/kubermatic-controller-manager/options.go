@@ -8,11 +8,14 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	"github.com/kubermatic/kubermatic/api/pkg/collectors"
 	backupcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/backup"
+	etcdmaintenance "github.com/kubermatic/kubermatic/api/pkg/controller/etcd-maintenance"
 	"github.com/kubermatic/kubermatic/api/pkg/features"
 	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
 	"github.com/kubermatic/kubermatic/api/pkg/provider"
@@ -67,6 +70,14 @@ type controllerRunOptions struct {
 	schedulerDefaultReplicas                         int
 	seedValidationHook                               seedvalidation.WebhookOpts
 	concurrentClusterUpdate                          int
+	rootCARotationStageDuration                      time.Duration
+	healthProbeInterval                              time.Duration
+	healthProbeTimeout                               time.Duration
+	etcdMaintenanceInterval                          time.Duration
+	etcdDefragmentationWindow                        etcdmaintenance.DefragmentationWindow
+	etcdDefragmentationThreshold                     float64
+	dryRun                                           bool
+	dryRunReportAddr                                 string
 
 	// OIDC configuration
 	oidcCAFile             string
@@ -81,6 +92,7 @@ func newControllerRunOptions() (controllerRunOptions, error) {
 	c := controllerRunOptions{}
 	var rawFeatureGates string
 	var rawEtcdDiskSize string
+	var rawEtcdDefragmentationWindow string
 
 	flag.StringVar(&c.kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
 	flag.StringVar(&c.masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
@@ -127,6 +139,14 @@ func newControllerRunOptions() (controllerRunOptions, error) {
 	flag.IntVar(&c.controllerManagerDefaultReplicas, "controller-manager-default-replicas", 1, "The default number of replicas for usercluster controller managers")
 	flag.IntVar(&c.schedulerDefaultReplicas, "scheduler-default-replicas", 1, "The default number of replicas for usercluster schedulers")
 	flag.IntVar(&c.concurrentClusterUpdate, "max-parallel-reconcile", 10, "The default number of resources updates per cluster")
+	flag.DurationVar(&c.rootCARotationStageDuration, "root-ca-rotation-stage-duration", time.Hour, "The minimum time each stage of a root CA rotation lasts. A stage only ends once all nodes were replaced since it began.")
+	flag.DurationVar(&c.healthProbeInterval, "health-probe-interval", time.Minute, "The interval in which the control plane components of every cluster get probed.")
+	flag.DurationVar(&c.healthProbeTimeout, "health-probe-timeout", 10*time.Second, "The time after which a single health probe of a control plane component fails.")
+	flag.DurationVar(&c.etcdMaintenanceInterval, "etcd-maintenance-interval", 10*time.Minute, "The interval in which the database sizes and alarms of the etcd members of every cluster get checked.")
+	flag.StringVar(&rawEtcdDefragmentationWindow, "etcd-defragmentation-window", "02:00-05:00", "The daily time range in UTC in which fragmented etcd members get defragmented, in the format HH:MM-HH:MM. Set it to an empty string to disable the scheduled defragmentation.")
+	flag.Float64Var(&c.etcdDefragmentationThreshold, "etcd-defragmentation-threshold", 0.5, "The share of free pages in the database above which an etcd member gets defragmented.")
+	flag.BoolVar(&c.dryRun, "dry-run", false, "Don't change any object. Only the controllers which manage the control plane run, the changes they would make get logged and exposed as metrics instead.")
+	flag.StringVar(&c.dryRunReportAddr, "dry-run-report-address", "", "The address on which the changes of the dry-run mode get served as JSON. Disabled if empty.")
 	c.seedValidationHook.AddFlags(flag.CommandLine)
 	flag.Parse()
 
@@ -142,6 +162,12 @@ func newControllerRunOptions() (controllerRunOptions, error) {
 	}
 	c.etcdDiskSize = etcdDiskSize
 
+	etcdDefragmentationWindow, err := etcdmaintenance.ParseDefragmentationWindow(rawEtcdDefragmentationWindow)
+	if err != nil {
+		return c, fmt.Errorf("failed to parse value of flag etcd-defragmentation-window (%q): %v", rawEtcdDefragmentationWindow, err)
+	}
+	c.etcdDefragmentationWindow = etcdDefragmentationWindow
+
 	if c.overwriteRegistry != "" {
 		c.overwriteRegistry = path.Clean(strings.TrimSpace(c.overwriteRegistry))
 	}
@@ -205,6 +231,21 @@ func (o controllerRunOptions) validate() error {
 	if o.concurrentClusterUpdate < 1 {
 		return fmt.Errorf("--max-parallel-reconcile must be > 0 (was %d)", o.concurrentClusterUpdate)
 	}
+	if o.healthProbeInterval <= 0 {
+		return fmt.Errorf("--health-probe-interval must be > 0 (was %v)", o.healthProbeInterval)
+	}
+	if o.healthProbeTimeout <= 0 || o.healthProbeTimeout > o.healthProbeInterval {
+		return fmt.Errorf("--health-probe-timeout must be > 0 and must not exceed --health-probe-interval (was %v)", o.healthProbeTimeout)
+	}
+	if o.etcdMaintenanceInterval <= 0 {
+		return fmt.Errorf("--etcd-maintenance-interval must be > 0 (was %v)", o.etcdMaintenanceInterval)
+	}
+	if o.etcdDefragmentationThreshold <= 0 || o.etcdDefragmentationThreshold >= 1 {
+		return fmt.Errorf("--etcd-defragmentation-threshold must be between 0 and 1 (was %v)", o.etcdDefragmentationThreshold)
+	}
+	if o.dryRunReportAddr != "" && !o.dryRun {
+		return fmt.Errorf("--dry-run-report-address requires --dry-run")
+	}
 	// Validate OIDC CA file
 	if err := o.validateCABundle(); err != nil {
 		return fmt.Errorf("validation CA bundle file failed: %v", err)
@@ -250,4 +291,5 @@ type controllerContext struct {
 	seedGetter           provider.SeedGetter
 	dockerPullConfigJSON []byte
 	log                  *zap.SugaredLogger
+	etcdCollector        *collectors.EtcdCollector
 }
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	autoscalingv1beta2 "k8s.io/autoscaler/vertical-pod-autoscaler/pkg/apis/autoscaling.k8s.io/v1beta2"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	apiregistrationv1beta1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1beta1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+type objectKey struct {
+	gvk       schema.GroupVersionKind
+	namespace string
+	name      string
+}
+
+// recordingClient is a fake client which remembers the objects that got written to it
+type recordingClient struct {
+	ctrlruntimeclient.Client
+
+	scheme  *runtime.Scheme
+	lock    sync.Mutex
+	objects map[objectKey]runtime.Object
+}
+
+func newRecordingClient() (*recordingClient, error) {
+	scheme := runtime.NewScheme()
+	for _, addToScheme := range []func(*runtime.Scheme) error{
+		clientgoscheme.AddToScheme,
+		apiextensionsv1beta1.AddToScheme,
+		apiregistrationv1beta1.AddToScheme,
+		autoscalingv1beta2.AddToScheme,
+	} {
+		if err := addToScheme(scheme); err != nil {
+			return nil, fmt.Errorf("failed to build the scheme: %v", err)
+		}
+	}
+
+	return &recordingClient{
+		Client:  fake.NewFakeClientWithScheme(scheme),
+		scheme:  scheme,
+		objects: map[objectKey]runtime.Object{},
+	}, nil
+}
+
+func (c *recordingClient) Create(ctx context.Context, obj runtime.Object) error {
+	if err := c.Client.Create(ctx, obj); err != nil {
+		return err
+	}
+	return c.record(obj, false)
+}
+
+func (c *recordingClient) Update(ctx context.Context, obj runtime.Object) error {
+	if err := c.Client.Update(ctx, obj); err != nil {
+		return err
+	}
+	return c.record(obj, false)
+}
+
+func (c *recordingClient) Delete(ctx context.Context, obj runtime.Object, opts ...ctrlruntimeclient.DeleteOptionFunc) error {
+	if err := c.Client.Delete(ctx, obj, opts...); err != nil {
+		return err
+	}
+	return c.record(obj, true)
+}
+
+func (c *recordingClient) record(obj runtime.Object, deleted bool) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	metaObject, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	key := objectKey{gvk: gvk, namespace: metaObject.GetNamespace(), name: metaObject.GetName()}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if deleted {
+		delete(c.objects, key)
+		return nil
+	}
+	c.objects[key] = obj.DeepCopyObject()
+	return nil
+}
+
+// writeObjects writes every recorded object as YAML file to <dir>/<namespace>/<kind>-<name>.yaml,
+// cluster scoped objects get written to <dir>/cluster
+func (c *recordingClient) writeObjects(dir string, showSecrets bool) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	keys := make([]objectKey, 0, len(c.objects))
+	for key := range c.objects {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+	for _, key := range keys {
+		obj := c.objects[key].DeepCopyObject()
+		obj.GetObjectKind().SetGroupVersionKind(key.gvk)
+		metaObject, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+		// The ResourceVersion is an implementation detail of the fake client and would only add noise to diffs
+		metaObject.SetResourceVersion("")
+		if secret, ok := obj.(*corev1.Secret); ok && !showSecrets {
+			redactSecret(secret)
+		}
+
+		raw, err := yaml.Marshal(obj)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %v", key, err)
+		}
+
+		namespace := key.namespace
+		if namespace == "" {
+			namespace = "cluster"
+		}
+		fileName := strings.ToLower(fmt.Sprintf("%s-%s.yaml", key.gvk.Kind, key.name))
+		fileName = strings.Replace(fileName, ":", "_", -1)
+		path := filepath.Join(dir, namespace, fileName)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(path, raw, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+func (k objectKey) String() string {
+	return fmt.Sprintf("%s %s/%s", k.gvk.String(), k.namespace, k.name)
+}
+
+// redactSecret replaces the values of a Secret, the keys and certificates get generated on every run
+// so they would only show up as changes in diffs
+func redactSecret(secret *corev1.Secret) {
+	if len(secret.Data) == 0 {
+		return
+	}
+	secret.StringData = map[string]string{}
+	for key := range secret.Data {
+		secret.StringData[key] = "<redacted>"
+	}
+	secret.Data = nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"go.uber.org/zap"
+
+	clustercontroller "github.com/kubermatic/kubermatic/api/pkg/controller/cluster"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	ksemver "github.com/kubermatic/kubermatic/api/pkg/semver"
+	kubermaticversion "github.com/kubermatic/kubermatic/api/pkg/version"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+type opts struct {
+	clusterFile              string
+	seedFile                 string
+	datacentersFile          string
+	versionsFile             string
+	version                  string
+	outputDir                string
+	overwriteRegistry        string
+	nodePortRange            string
+	nodeAccessNetwork        string
+	etcdDiskSize             string
+	kubermaticImage          string
+	dnatControllerImage      string
+	kubermaticCommit         string
+	dockerPullConfigJSONFile string
+	oidcAuthentication       bool
+	etcdDataCorruptionChecks bool
+	showSecrets              bool
+
+	debug     bool
+	logFormat string
+}
+
+func main() {
+	o := opts{}
+	flag.StringVar(&o.clusterFile, "cluster", "", "Path to the Cluster YAML (Required)")
+	flag.StringVar(&o.seedFile, "seed", "", "Path to a Seed YAML which contains the datacenter of the cluster")
+	flag.StringVar(&o.datacentersFile, "datacenters", "", "Path to a datacenters.yaml which contains the datacenter of the cluster, can be used instead of -seed")
+	flag.StringVar(&o.versionsFile, "versions", "../config/kubermatic/static/master/versions.yaml", "The versions.yaml file path")
+	flag.StringVar(&o.version, "version", "", "The Kubernetes version to render the manifests for, defaults to the version of the cluster")
+	flag.StringVar(&o.outputDir, "output", "manifests", "The directory to write the manifests to")
+	flag.StringVar(&o.overwriteRegistry, "overwrite-registry", "", "registry to use for all images")
+	flag.StringVar(&o.nodePortRange, "nodeport-range", "30000-32767", "NodePort range to use for new clusters")
+	flag.StringVar(&o.nodeAccessNetwork, "node-access-network", "10.254.0.0/16", "A network which allows direct access to nodes via VPN. Uses CIDR notation.")
+	flag.StringVar(&o.etcdDiskSize, "etcd-disk-size", "5Gi", "Size for the etcd PV's")
+	flag.StringVar(&o.kubermaticImage, "kubermatic-image", resources.DefaultKubermaticImage, "The location from which to pull the Kubermatic image")
+	flag.StringVar(&o.dnatControllerImage, "dnatcontroller-image", resources.DefaultDNATControllerImage, "The location of the dnatcontroller-image")
+	flag.StringVar(&o.kubermaticCommit, "kubermatic-commit", resources.KUBERMATICCOMMIT, "The tag of the Kubermatic images, defaults to the commit the renderer was built from")
+	flag.StringVar(&o.dockerPullConfigJSONFile, "docker-pull-config-json-file", "", "The file containing the docker auth config")
+	flag.BoolVar(&o.oidcAuthentication, "oidc-authentication", false, "Whether the KubernetesOIDCAuthentication feature gate is enabled")
+	flag.BoolVar(&o.etcdDataCorruptionChecks, "etcd-data-corruption-checks", false, "Whether the EtcdDataCorruptionChecks feature gate is enabled")
+	flag.BoolVar(&o.showSecrets, "show-secrets", false, "Write the data of Secrets instead of redacting it. The certificates and keys get generated on every run.")
+	flag.BoolVar(&o.debug, "log-debug", false, "Enables debug logging")
+	flag.StringVar(&o.logFormat, "log-format", string(kubermaticlog.FormatConsole), "Log format. Available are: "+kubermaticlog.AvailableFormats.String())
+	flag.Parse()
+
+	log := kubermaticlog.New(o.debug, kubermaticlog.Format(o.logFormat)).Sugar()
+	defer func() {
+		if err := log.Sync(); err != nil {
+			fmt.Println(err)
+		}
+	}()
+
+	if o.clusterFile == "" {
+		log.Fatal("-cluster must be set")
+	}
+	if (o.seedFile == "") == (o.datacentersFile == "") {
+		log.Fatal("Exactly one of -seed and -datacenters must be set")
+	}
+
+	if err := run(context.Background(), log, o); err != nil {
+		log.Fatalw("Failed to render the manifests", zap.Error(err))
+	}
+	log.Infow("Rendered the manifests", "output", o.outputDir)
+}
+
+func run(ctx context.Context, log *zap.SugaredLogger, o opts) error {
+	// The images of the Kubermatic components are tagged with the commit
+	resources.KUBERMATICCOMMIT = o.kubermaticCommit
+
+	cluster, err := loadCluster(o.clusterFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the cluster: %v", err)
+	}
+	// The openshift controller uses its own creators, rendering them would silently produce
+	// manifests which differ from the ones the controller deploys
+	if cluster.Spec.Openshift != nil {
+		return errors.New("openshift clusters are not supported")
+	}
+	if err := setVersion(cluster, o.versionsFile, o.version); err != nil {
+		return err
+	}
+	defaultClusterStatus(log, cluster)
+
+	seed, err := loadSeed(o.seedFile, o.datacentersFile, cluster.Spec.Cloud.DatacenterName)
+	if err != nil {
+		return fmt.Errorf("failed to load the seed: %v", err)
+	}
+	datacenter, found := seed.Spec.Datacenters[cluster.Spec.Cloud.DatacenterName]
+	if !found {
+		return fmt.Errorf("seed %s has no datacenter %s", seed.Name, cluster.Spec.Cloud.DatacenterName)
+	}
+
+	etcdDiskSize, err := resource.ParseQuantity(o.etcdDiskSize)
+	if err != nil {
+		return fmt.Errorf("failed to parse value of flag etcd-disk-size (%q): %v", o.etcdDiskSize, err)
+	}
+	var dockerPullConfigJSON []byte
+	if o.dockerPullConfigJSONFile != "" {
+		if dockerPullConfigJSON, err = ioutil.ReadFile(o.dockerPullConfigJSONFile); err != nil {
+			return fmt.Errorf("failed to read the docker pull config: %v", err)
+		}
+	}
+
+	seedClient, err := newRecordingClient()
+	if err != nil {
+		return err
+	}
+	data := resources.NewTemplateData(
+		ctx,
+		seedClient,
+		cluster,
+		&datacenter,
+		seed,
+		o.overwriteRegistry,
+		o.nodePortRange,
+		o.nodeAccessNetwork,
+		etcdDiskSize,
+		"monitoring.kubermatic.io",
+		"",
+		false,
+		false,
+		"",
+		"",
+		"",
+		"",
+		false,
+		o.kubermaticImage,
+		o.dnatControllerImage,
+		false,
+	)
+
+	log.Info("Rendering the control plane")
+	if err := renderControlPlane(ctx, seedClient, data, dockerPullConfigJSON, clustercontroller.Features{
+		KubernetesOIDCAuthentication: o.oidcAuthentication,
+		EtcdDataCorruptionChecks:     o.etcdDataCorruptionChecks,
+	}); err != nil {
+		return fmt.Errorf("failed to render the control plane: %v", err)
+	}
+
+	log.Info("Rendering the user cluster")
+	userClusterClient, err := newRecordingClient()
+	if err != nil {
+		return err
+	}
+	if err := renderUserCluster(ctx, log, userClusterClient, data); err != nil {
+		return fmt.Errorf("failed to render the user cluster: %v", err)
+	}
+
+	if err := seedClient.writeObjects(o.outputDir+"/seed", o.showSecrets); err != nil {
+		return err
+	}
+	return userClusterClient.writeObjects(o.outputDir+"/usercluster", o.showSecrets)
+}
+
+func loadCluster(path string) (*kubermaticv1.Cluster, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cluster := &kubermaticv1.Cluster{}
+	if err := yaml.Unmarshal(raw, cluster); err != nil {
+		return nil, err
+	}
+	if cluster.Name == "" {
+		return nil, fmt.Errorf("the cluster in %s has no name", path)
+	}
+	return cluster, nil
+}
+
+// setVersion sets the version the manifests get rendered for, it must be a Kubernetes version of the versions file
+func setVersion(cluster *kubermaticv1.Cluster, versionsFile, version string) error {
+	if version == "" {
+		version = cluster.Spec.Version.String()
+	}
+	target, err := ksemver.NewSemver(version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %v", version, err)
+	}
+
+	versions, err := kubermaticversion.LoadVersions(versionsFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the versions: %v", err)
+	}
+	for _, v := range versions {
+		if (v.Type == "" || v.Type == "kubernetes") && v.Version.Equal(target.Version) {
+			cluster.Spec.Version = *target
+			return nil
+		}
+	}
+	return fmt.Errorf("the Kubernetes version %s is not in %s", target, versionsFile)
+}
+
+// defaultClusterStatus fills in the fields the cluster controller sets before it creates the control plane,
+// in case the cluster was not taken from a seed
+func defaultClusterStatus(log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) {
+	if cluster.Status.NamespaceName == "" {
+		cluster.Status.NamespaceName = "cluster-" + cluster.Name
+	}
+	if cluster.Address.IP == "" {
+		log.Warn("The cluster has no address, using 192.0.2.1:30000")
+		cluster.Address.IP = "192.0.2.1"
+		cluster.Address.Port = 30000
+		cluster.Address.ExternalName = "192.0.2.1"
+		cluster.Address.URL = "https://192.0.2.1:30000"
+		cluster.Address.InternalName = fmt.Sprintf("%s.%s.svc.cluster.local.", resources.ApiserverExternalServiceName, cluster.Status.NamespaceName)
+	}
+	// The cluster controller only renders the cloud config once the infrastructure got created
+	cluster.Status.ExtendedHealth.CloudProviderInfrastructure = kubermaticv1.HealthStatusUp
+}
+
+func loadSeed(seedFile, datacentersFile, datacenterName string) (*kubermaticv1.Seed, error) {
+	if seedFile != "" {
+		raw, err := ioutil.ReadFile(seedFile)
+		if err != nil {
+			return nil, err
+		}
+		seed := &kubermaticv1.Seed{}
+		if err := yaml.Unmarshal(raw, seed); err != nil {
+			return nil, err
+		}
+		return seed, nil
+	}
+
+	seeds, err := provider.LoadSeeds(datacentersFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, seed := range seeds {
+		if _, found := seed.Spec.Datacenters[datacenterName]; found {
+			return seed, nil
+		}
+	}
+	return nil, fmt.Errorf("no seed in %s contains the datacenter %s", datacentersFile, datacenterName)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+)
+
+const (
+	testCluster = `
+apiVersion: kubermatic.k8s.io/v1
+kind: Cluster
+metadata:
+  name: de-test-01
+spec:
+  version: 1.15.5
+  humanReadableName: test
+  exposeStrategy: NodePort
+  clusterNetwork:
+    services:
+      cidrBlocks: ["10.240.16.0/20"]
+    pods:
+      cidrBlocks: ["172.25.0.0/16"]
+    dnsDomain: cluster.local
+  cloud:
+    dc: hetzner-fsn1
+    hetzner:
+      token: secret
+`
+	testSeed = `
+apiVersion: kubermatic.k8s.io/v1
+kind: Seed
+metadata:
+  name: europe-west3-c
+  namespace: kubermatic
+spec:
+  country: DE
+  location: Frankfurt
+  kubeconfig: {}
+  datacenters:
+    hetzner-fsn1:
+      country: DE
+      location: Falkenstein
+      spec:
+        hetzner:
+          datacenter: fsn1-dc8
+`
+)
+
+// testOpts writes the cluster and the seed to the directory and returns the options to render them
+func testOpts(t *testing.T, dir, cluster string) opts {
+	o := opts{
+		clusterFile:         filepath.Join(dir, "cluster.yaml"),
+		seedFile:            filepath.Join(dir, "seed.yaml"),
+		versionsFile:        "../../../config/kubermatic/static/master/versions.yaml",
+		version:             "1.16.2",
+		outputDir:           filepath.Join(dir, "manifests"),
+		nodePortRange:       "30000-32767",
+		nodeAccessNetwork:   "10.254.0.0/16",
+		etcdDiskSize:        "5Gi",
+		kubermaticImage:     resources.DefaultKubermaticImage,
+		dnatControllerImage: resources.DefaultDNATControllerImage,
+		kubermaticCommit:    "test",
+	}
+	if err := ioutil.WriteFile(o.clusterFile, []byte(cluster), 0644); err != nil {
+		t.Fatalf("failed to write the cluster: %v", err)
+	}
+	if err := ioutil.WriteFile(o.seedFile, []byte(testSeed), 0644); err != nil {
+		t.Fatalf("failed to write the seed: %v", err)
+	}
+	return o
+}
+
+func TestRender(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest-renderer")
+	if err != nil {
+		t.Fatalf("failed to create the temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	o := testOpts(t, dir, testCluster)
+	log := kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar()
+	if err := run(context.Background(), log, o); err != nil {
+		t.Fatalf("failed to render the manifests: %v", err)
+	}
+
+	for _, file := range []string{
+		"seed/cluster-de-test-01/statefulset-etcd.yaml",
+		"seed/cluster-de-test-01/deployment-apiserver.yaml",
+		"seed/cluster-de-test-01/service-apiserver-external.yaml",
+		"seed/cluster-de-test-01/secret-ca.yaml",
+		"seed/cluster-de-test-01/configmap-cloud-config.yaml",
+		"usercluster/kube-public/configmap-cluster-info.yaml",
+		"usercluster/cluster/clusterrole-system_kubermatic-machine-controller.yaml",
+	} {
+		raw, err := ioutil.ReadFile(filepath.Join(o.outputDir, file))
+		if err != nil {
+			t.Errorf("expected %s to be rendered: %v", file, err)
+			continue
+		}
+		if strings.Contains(string(raw), "resourceVersion") {
+			t.Errorf("expected %s to contain no resourceVersion", file)
+		}
+	}
+
+	apiserver, err := ioutil.ReadFile(filepath.Join(o.outputDir, "seed/cluster-de-test-01/deployment-apiserver.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read the apiserver Deployment: %v", err)
+	}
+	if !strings.Contains(string(apiserver), "hyperkube-amd64:v1.16.2") {
+		t.Errorf("expected the apiserver to be rendered for the version 1.16.2")
+	}
+
+	ca, err := ioutil.ReadFile(filepath.Join(o.outputDir, "seed/cluster-de-test-01/secret-ca.yaml"))
+	if err != nil {
+		t.Fatalf("failed to read the CA Secret: %v", err)
+	}
+	if strings.Contains(string(ca), "BEGIN") || !strings.Contains(string(ca), "<redacted>") {
+		t.Errorf("expected the data of the CA Secret to be redacted, got:\n%s", ca)
+	}
+}
+
+func TestRenderOpenshift(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest-renderer")
+	if err != nil {
+		t.Fatalf("failed to create the temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cluster := testCluster + "  openshift:\n    imagePullSecret: '{}'\n"
+	o := testOpts(t, dir, cluster)
+	log := kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar()
+	err = run(context.Background(), log, o)
+	if expected := "openshift clusters are not supported"; err == nil || err.Error() != expected {
+		t.Fatalf("expected the error %q, got %v", expected, err)
+	}
+	if _, err := os.Stat(o.outputDir); !os.IsNotExist(err) {
+		t.Errorf("expected no manifests to be rendered, got: %v", err)
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"go.uber.org/zap"
+
+	clustercontroller "github.com/kubermatic/kubermatic/api/pkg/controller/cluster"
+	monitoringcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/monitoring"
+	userclustercontroller "github.com/kubermatic/kubermatic/api/pkg/controller/usercluster"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/prometheus"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// renderControlPlane creates all resources the cluster and the monitoring controller create in the seed
+// cluster
+func renderControlPlane(ctx context.Context, client *recordingClient, data *resources.TemplateData, dockerPullConfigJSON []byte, features clustercontroller.Features) error {
+	cluster := data.Cluster()
+	namespace := cluster.Status.NamespaceName
+	ownerRef := reconciling.OwnerRefWrapper(resources.GetClusterRef(cluster))
+
+	serviceCreators := append(clustercontroller.GetServiceCreators(data), monitoringcontroller.GetServiceCreators(data)...)
+	if err := reconciling.ReconcileServices(ctx, serviceCreators, namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the Services: %v", err)
+	}
+	// The Secrets and ConfigMaps contain the addresses of the Services
+	if err := allocateServiceAddresses(ctx, client, namespace, cluster.Address.Port); err != nil {
+		return fmt.Errorf("failed to allocate the Service addresses: %v", err)
+	}
+
+	// The usersshkeys controller creates the Secret from the UserSSHKeys, none of them are given here
+	if err := reconciling.ReconcileSecrets(ctx, []reconciling.NamedSecretCreatorGetter{emptyUserSSHKeysSecretCreator()}, namespace, client); err != nil {
+		return fmt.Errorf("failed to render the user ssh keys Secret: %v", err)
+	}
+	if err := clustercontroller.ReconcileControlPlane(ctx, client, data, dockerPullConfigJSON, features); err != nil {
+		return fmt.Errorf("failed to render the resources of the cluster controller: %v", err)
+	}
+
+	if err := reconciling.ReconcileSecrets(ctx, monitoringcontroller.GetSecretCreatorOperations(data), namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the monitoring Secrets: %v", err)
+	}
+	if err := reconciling.ReconcileConfigMaps(ctx, monitoringcontroller.GetConfigMapCreators(data), namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the monitoring ConfigMaps: %v", err)
+	}
+	if err := reconciling.ReconcileStatefulSets(ctx, monitoringcontroller.GetStatefulSetCreators(data), namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the monitoring StatefulSets: %v", err)
+	}
+	if err := reconciling.ReconcileDeployments(ctx, monitoringcontroller.GetDeploymentCreators(data), namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the monitoring Deployments: %v", err)
+	}
+	if err := reconciling.ReconcileServiceAccounts(ctx, monitoringcontroller.GetServiceAccountCreators(), namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the monitoring ServiceAccounts: %v", err)
+	}
+	roleCreators := []reconciling.NamedRoleCreatorGetter{prometheus.RoleCreator()}
+	if err := reconciling.ReconcileRoles(ctx, roleCreators, namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the Roles: %v", err)
+	}
+	roleBindingCreators := []reconciling.NamedRoleBindingCreatorGetter{prometheus.RoleBindingCreator(namespace)}
+	if err := reconciling.ReconcileRoleBindings(ctx, roleBindingCreators, namespace, client, ownerRef); err != nil {
+		return fmt.Errorf("failed to render the RoleBindings: %v", err)
+	}
+
+	return nil
+}
+
+func emptyUserSSHKeysSecretCreator() reconciling.NamedSecretCreatorGetter {
+	return func() (string, reconciling.SecretCreator) {
+		return resources.UserSSHKeys, func(existing *corev1.Secret) (*corev1.Secret, error) {
+			existing.Type = corev1.SecretTypeOpaque
+			return existing, nil
+		}
+	}
+}
+
+// allocateServiceAddresses does what the apiserver does on the creation of a Service: It assigns
+// ClusterIPs and NodePorts. The addresses get assigned in the order of the names, so they are stable
+// between runs. The external apiserver Service gets the port of the cluster address.
+func allocateServiceAddresses(ctx context.Context, client ctrlruntimeclient.Client, namespace string, apiserverPort int32) error {
+	services := &corev1.ServiceList{}
+	if err := client.List(ctx, ctrlruntimeclient.InNamespace(namespace), services); err != nil {
+		return err
+	}
+	sort.Slice(services.Items, func(i, j int) bool { return services.Items[i].Name < services.Items[j].Name })
+
+	nextIP, nextNodePort := 10, int32(30001)
+	for i := range services.Items {
+		service := &services.Items[i]
+		if service.Spec.ClusterIP == "" {
+			service.Spec.ClusterIP = fmt.Sprintf("192.0.2.%d", nextIP)
+			nextIP++
+		}
+		if service.Spec.Type == corev1.ServiceTypeNodePort || service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			for j := range service.Spec.Ports {
+				if service.Spec.Ports[j].NodePort != 0 {
+					continue
+				}
+				if service.Name == resources.ApiserverExternalServiceName && apiserverPort != 0 {
+					service.Spec.Ports[j].NodePort = apiserverPort
+					continue
+				}
+				service.Spec.Ports[j].NodePort = nextNodePort
+				nextNodePort++
+			}
+		}
+		if err := client.Update(ctx, service); err != nil {
+			return fmt.Errorf("failed to update Service %s: %v", service.Name, err)
+		}
+	}
+	return nil
+}
+
+// renderUserCluster creates all resources the usercluster controller creates in the user cluster
+func renderUserCluster(ctx context.Context, log *zap.SugaredLogger, client *recordingClient, data *resources.TemplateData) error {
+	cluster := data.Cluster()
+
+	caCert, err := data.GetRootCA()
+	if err != nil {
+		return fmt.Errorf("failed to get the root CA: %v", err)
+	}
+	var openVPNCA, konnectivityCA *resources.ECDSAKeyPair
+	var openVPNServerPort, konnectivityServerPort int32
+	if cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		openVPNCA, err = data.GetOpenVPNCA()
+		if err != nil {
+			return fmt.Errorf("failed to get the OpenVPN CA: %v", err)
+		}
+		openVPNServerPort, err = data.GetOpenVPNServerPort()
+		if err != nil {
+			return fmt.Errorf("failed to get the OpenVPN server port: %v", err)
+		}
+	}
+	if cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		konnectivityCA, err = data.GetKonnectivityCA()
+		if err != nil {
+			return fmt.Errorf("failed to get the konnectivity CA: %v", err)
+		}
+		konnectivityServerPort, err = data.GetKonnectivityServerPort()
+		if err != nil {
+			return fmt.Errorf("failed to get the konnectivity server port: %v", err)
+		}
+	}
+	clusterURL, err := url.Parse(cluster.Address.URL)
+	if err != nil {
+		return fmt.Errorf("failed to parse the cluster URL %q: %v", cluster.Address.URL, err)
+	}
+
+	return userclustercontroller.Render(
+		ctx,
+		client,
+		cluster.Spec.Version.String(),
+		cluster.Status.NamespaceName,
+		data.GetKubernetesCloudProviderName(),
+		caCert,
+		clusterURL,
+		int(openVPNServerPort),
+		openVPNCA,
+		cluster.ActiveTunnelingMode(),
+		int(konnectivityServerPort),
+		konnectivityCA,
+		gatekeeper.IsEnabled(cluster),
+		log,
+	)
+}
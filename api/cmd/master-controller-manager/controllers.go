@@ -1,11 +1,16 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 
+	clustermigration "github.com/kubermatic/kubermatic/api/pkg/controller/cluster-migration"
+	clusternotifier "github.com/kubermatic/kubermatic/api/pkg/controller/cluster-notifier"
 	projectlabelsynchronizer "github.com/kubermatic/kubermatic/api/pkg/controller/project-label-synchronizer"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/rbac"
 	seedcontrollerlifecycle "github.com/kubermatic/kubermatic/api/pkg/controller/seed-controller-lifecycle"
@@ -19,8 +24,10 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/provider"
 	"github.com/prometheus/client_golang/prometheus"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
@@ -34,6 +41,10 @@ func createAllControllers(ctrlCtx *controllerContext) error {
 		ctrlCtx.workerCount,
 		ctrlCtx.labelSelectorFunc)
 	projectLabelSynchronizerFactory := projectLabelSynchronizerFactoryCreator(ctrlCtx)
+	clusterNotifierFactory, err := clusterNotifierFactoryCreator(ctrlCtx)
+	if err != nil {
+		return fmt.Errorf("failed to create cluster notifier factory: %v", err)
+	}
 
 	if err := seedcontrollerlifecycle.Add(ctrlCtx.ctx,
 		kubermaticlog.Logger,
@@ -42,7 +53,8 @@ func createAllControllers(ctrlCtx *controllerContext) error {
 		ctrlCtx.seedsGetter,
 		ctrlCtx.seedKubeconfigGetter,
 		rbacControllerFactory,
-		projectLabelSynchronizerFactory); err != nil {
+		projectLabelSynchronizerFactory,
+		clusterNotifierFactory); err != nil {
 		//TODO: Find a better name
 		return fmt.Errorf("failed to create seedcontrollerlifecycle: %v", err)
 	}
@@ -58,9 +70,65 @@ func createAllControllers(ctrlCtx *controllerContext) error {
 	if err := seedproxy.Add(ctrlCtx.mgr, 1, ctrlCtx.log, ctrlCtx.seedsGetter, ctrlCtx.seedKubeconfigGetter); err != nil {
 		return fmt.Errorf("failed to create seedproxy controller: %v", err)
 	}
+	if err := createClusterMigrationController(ctrlCtx); err != nil {
+		return fmt.Errorf("failed to create cluster migration controller: %v", err)
+	}
 	return nil
 }
 
+func createClusterMigrationController(ctrlCtx *controllerContext) error {
+	if ctrlCtx.runOptions.migrationStoreContainerFile == "" || ctrlCtx.runOptions.migrationRestoreContainerFile == "" {
+		ctrlCtx.log.Info("Cluster migration controller is disabled because no store and restore containers are configured")
+		return nil
+	}
+
+	storeContainer, err := getContainerFromFile(ctrlCtx.runOptions.migrationStoreContainerFile)
+	if err != nil {
+		return err
+	}
+	restoreContainer, err := getContainerFromFile(ctrlCtx.runOptions.migrationRestoreContainerFile)
+	if err != nil {
+		return err
+	}
+
+	return clustermigration.Add(
+		ctrlCtx.ctx,
+		ctrlCtx.mgr,
+		1,
+		ctrlCtx.log,
+		ctrlCtx.namespace,
+		ctrlCtx.seedsGetter,
+		ctrlCtx.seedKubeconfigGetter,
+		*storeContainer,
+		*restoreContainer,
+		ctrlCtx.runOptions.migrationEtcdImage,
+	)
+}
+
+func getContainerFromFile(path string) (*corev1.Container, error) {
+	fileContents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	container := &corev1.Container{}
+	manifestReader := bytes.NewReader(fileContents)
+	manifestDecoder := yaml.NewYAMLToJSONDecoder(manifestReader)
+	if err := manifestDecoder.Decode(container); err != nil {
+		return nil, err
+	}
+
+	// Just because its a valid corev1.Container does not mean
+	// the APIServer will accept it, thus we do some additional
+	// checks
+	if container.Name == "" {
+		return nil, fmt.Errorf("container must have a name")
+	}
+	if container.Image == "" {
+		return nil, fmt.Errorf("container must have an image")
+	}
+	return container, nil
+}
+
 func rbacControllerFactoryCreator(
 	mastercfg *rest.Config,
 	seedsGetter provider.SeedsGetter,
@@ -156,34 +224,43 @@ func rbacClusterProvider(cfg *rest.Config, name string, master bool, labelSelect
 	return rbac.NewClusterProvider(fmt.Sprintf("%s/%s", clusterPrefix, name), kubeClient, kubeInformerProvider, kubermaticClient, kubermaticInformerFactory), nil
 }
 
+// createSeedManagers creates a manager for every seed and adds it to the given manager
+func createSeedManagers(ctrlCtx *controllerContext, log *zap.SugaredLogger, mgr manager.Manager) (map[string]manager.Manager, error) {
+	seeds, err := ctrlCtx.seedsGetter()
+	if err != nil {
+		log.Errorw("Failed to get seeds", zap.Error(err))
+		return nil, fmt.Errorf("failed to get seeds: %v", err)
+	}
+
+	seedManagerMap := map[string]manager.Manager{}
+	for seedName, seed := range seeds {
+		log := ctrlCtx.log.With("seed", seed.Name)
+		kubeconfig, err := ctrlCtx.seedKubeconfigGetter(seed)
+		if err != nil {
+			log.Errorw("Failed to get kubeconfig for seed", zap.Error(err))
+			// Don't let one defunct seed break everything. We have a metric for this
+			// in the rbac controller factory, so just log it here
+			continue
+		}
+		seedMgr, err := manager.New(kubeconfig, manager.Options{})
+		if err != nil {
+			log.Errorw("Failed to construct mgr for seed", zap.Error(err))
+			continue
+		}
+		seedManagerMap[seedName] = seedMgr
+		if err := mgr.Add(seedMgr); err != nil {
+			return nil, fmt.Errorf("faild to add controller manager for seed %q to mgr: %v", seedName, err)
+		}
+	}
+	return seedManagerMap, nil
+}
+
 func projectLabelSynchronizerFactoryCreator(ctrlCtx *controllerContext) seedcontrollerlifecycle.ControllerFactory {
 	log := ctrlCtx.log.Named("project-label-synchronizer-factory")
 	factory := func(mgr manager.Manager) error {
-		seeds, err := ctrlCtx.seedsGetter()
+		seedManagerMap, err := createSeedManagers(ctrlCtx, log, mgr)
 		if err != nil {
-			log.Errorw("Failed to get seeds", zap.Error(err))
-			return fmt.Errorf("failed to get seeds: %v", err)
-		}
-
-		seedManagerMap := map[string]manager.Manager{}
-		for seedName, seed := range seeds {
-			log := ctrlCtx.log.With("seed", seed.Name)
-			kubeconfig, err := ctrlCtx.seedKubeconfigGetter(seed)
-			if err != nil {
-				log.Errorw("Failed to get kubeconfig for seed", zap.Error(err))
-				// Don't let one defunct seed break everything. We have a metric for this
-				// in the rbac controller factory, so just log it here
-				continue
-			}
-			seedMgr, err := manager.New(kubeconfig, manager.Options{})
-			if err != nil {
-				log.Errorw("Failed to construct mgr for seed", zap.Error(err))
-				continue
-			}
-			seedManagerMap[seedName] = seedMgr
-			if err := mgr.Add(seedMgr); err != nil {
-				return fmt.Errorf("faild to add controller manager for seed %q to mgr: %v", seedName, err)
-			}
+			return err
 		}
 
 		return projectlabelsynchronizer.Add(
@@ -198,3 +275,38 @@ func projectLabelSynchronizerFactoryCreator(ctrlCtx *controllerContext) seedcont
 		return projectlabelsynchronizer.ControllerName, factory(mgr)
 	}
 }
+
+func clusterNotifierFactoryCreator(ctrlCtx *controllerContext) (seedcontrollerlifecycle.ControllerFactory, error) {
+	smtpSettings := clusternotifier.SMTPSettings{
+		Address:  ctrlCtx.runOptions.smtpAddress,
+		From:     ctrlCtx.runOptions.smtpFrom,
+		Username: ctrlCtx.runOptions.smtpUsername,
+	}
+	if ctrlCtx.runOptions.smtpPasswordFile != "" {
+		password, err := ioutil.ReadFile(ctrlCtx.runOptions.smtpPasswordFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the SMTP password: %v", err)
+		}
+		smtpSettings.Password = strings.TrimSpace(string(password))
+	}
+
+	log := ctrlCtx.log.Named("cluster-notifier-factory")
+	factory := func(mgr manager.Manager) error {
+		seedManagerMap, err := createSeedManagers(ctrlCtx, log, mgr)
+		if err != nil {
+			return err
+		}
+
+		return clusternotifier.Add(
+			ctrlCtx.ctx,
+			mgr,
+			seedManagerMap,
+			ctrlCtx.log,
+			ctrlCtx.workerCount,
+			ctrlCtx.workerNameLabelSelector,
+			smtpSettings)
+	}
+	return func(mgr manager.Manager) (string, error) {
+		return clusternotifier.ControllerName, factory(mgr)
+	}, nil
+}
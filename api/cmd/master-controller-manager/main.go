@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 
+	backupcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/backup"
 	mastermigrations "github.com/kubermatic/kubermatic/api/pkg/crd/migrations/master"
 	"github.com/kubermatic/kubermatic/api/pkg/leaderelection"
 	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
@@ -28,6 +29,8 @@ import (
 	ctrlruntimecache "sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	ctrlruntimelog "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 )
 
 const (
@@ -44,6 +47,15 @@ type controllerRunOptions struct {
 	seedvalidationHook seedvalidation.WebhookOpts
 
 	workerName string
+
+	migrationStoreContainerFile   string
+	migrationRestoreContainerFile string
+	migrationEtcdImage            string
+
+	smtpAddress      string
+	smtpFrom         string
+	smtpUsername     string
+	smtpPasswordFile string
 }
 
 type controllerContext struct {
@@ -57,6 +69,7 @@ type controllerContext struct {
 	seedKubeconfigGetter    provider.SeedKubeconfigGetter
 	labelSelectorFunc       func(*metav1.ListOptions)
 	namespace               string
+	runOptions              controllerRunOptions
 }
 
 func main() {
@@ -73,6 +86,13 @@ func main() {
 	flag.StringVar(&runOpts.internalAddr, "internal-address", "127.0.0.1:8085", "The address on which the /metrics endpoint will be served.")
 	flag.BoolVar(&runOpts.dynamicDatacenters, "dynamic-datacenters", false, "Whether to enable dynamic datacenters. Enabling this and defining the datcenters flag will enable the migration of the datacenters defined in datancenters.yaml to Seed custom resources.")
 	flag.StringVar(&ctrlCtx.namespace, "namespace", "kubermatic", "The namespace kubermatic runs in, uses to determine where to look for datacenter custom resources.")
+	flag.StringVar(&runOpts.migrationStoreContainerFile, "migration-store-container", "", "The path to a file containing the container which uploads etcd snapshots during cluster migrations. The cluster migration controller is disabled if unset.")
+	flag.StringVar(&runOpts.migrationRestoreContainerFile, "migration-restore-container", "", "The path to a file containing the container which downloads etcd snapshots during cluster migrations. The cluster migration controller is disabled if unset.")
+	flag.StringVar(&runOpts.migrationEtcdImage, "migration-etcd-image", backupcontroller.DefaultBackupContainerImage, "The image used for taking and restoring etcd snapshots during cluster migrations.")
+	flag.StringVar(&runOpts.smtpAddress, "smtp-address", "", "The host:port of the SMTP server which sends the notifications of email notification channels. Email notifications are disabled if unset.")
+	flag.StringVar(&runOpts.smtpFrom, "smtp-from", "kubermatic@localhost", "The sender address of email notifications.")
+	flag.StringVar(&runOpts.smtpUsername, "smtp-username", "", "The username to authenticate at the SMTP server.")
+	flag.StringVar(&runOpts.smtpPasswordFile, "smtp-password-file", "", "The path to a file containing the password to authenticate at the SMTP server.")
 	flag.BoolVar(&runOpts.log.Debug, "log-debug", false, "Enables debug logging.")
 	flag.StringVar(&runOpts.log.Format, "log-format", string(kubermaticlog.FormatJSON), "Log format. Available are: "+kubermaticlog.AvailableFormats.String())
 	flag.Parse()
@@ -88,6 +108,7 @@ func main() {
 	kubermaticlog.Logger = log
 	ctrlCtx.log = log
 	ctrlCtx.workerName = runOpts.workerName
+	ctrlCtx.runOptions = runOpts
 
 	selector, err := workerlabel.LabelSelector(runOpts.workerName)
 	if err != nil {
@@ -156,6 +177,10 @@ func main() {
 		log.Fatalw("failed to create Controller Manager instance", zap.Error(err))
 	}
 	ctrlCtx.mgr = mgr
+	// MachineDeployments of user clusters get updated when migrating clusters between seeds
+	if err := clusterv1alpha1.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Fatalw("failed to register scheme", zap.Stringer("api", clusterv1alpha1.SchemeGroupVersion), zap.Error(err))
+	}
 
 	// these two getters rely on the ctrlruntime manager being started; they are
 	// only used inside controllers
@@ -24,10 +24,13 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/controller/ipam"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/nodecsrapprover"
 	rbacusercluster "github.com/kubermatic/kubermatic/api/pkg/controller/rbac-user-cluster"
+	constraintsyncer "github.com/kubermatic/kubermatic/api/pkg/controller/user-cluster-controller-manager/constraint-syncer"
+	machinehealthcheck "github.com/kubermatic/kubermatic/api/pkg/controller/user-cluster-controller-manager/machine-health-check"
 	nodelabeler "github.com/kubermatic/kubermatic/api/pkg/controller/user-cluster-controller-manager/node-labeler"
 	openshiftmasternodelabeler "github.com/kubermatic/kubermatic/api/pkg/controller/user-cluster-controller-manager/openshift-master-node-labeler"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster"
 	machinecontrolerresources "github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/machine-controller"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates/triple"
@@ -36,12 +39,14 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apiextensionv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/klog"
 	apiregistrationv1beta1 "k8s.io/kube-aggregator/pkg/apis/apiregistration/v1beta1"
 	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlruntimemetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	ctrlruntimelog "sigs.k8s.io/controller-runtime/pkg/runtime/log"
 	"sigs.k8s.io/controller-runtime/pkg/runtime/signals"
 )
@@ -59,11 +64,19 @@ type controllerRunOptions struct {
 	openvpnServerPort             int
 	openvpnCACertFilePath         string
 	openvpnCAKeyFilePath          string
+	tunnelingMode                 string
+	konnectivityServerPort        int
+	konnectivityCACertFilePath    string
+	konnectivityCAKeyFilePath     string
 	userSSHKeysDirPath            string
 	overwriteRegistry             string
 	cloudProviderName             string
 	cloudCredentialSecretTemplate string
 	nodelabels                    string
+	opaIntegration                bool
+	projectID                     string
+	dryRun                        bool
+	dryRunReportListenAddr        string
 	log                           kubermaticlog.Options
 }
 
@@ -82,6 +95,10 @@ func main() {
 	flag.IntVar(&runOp.openvpnServerPort, "openvpn-server-port", 0, "OpenVPN server port")
 	flag.StringVar(&runOp.openvpnCACertFilePath, "openvpn-ca-cert-file", "", "Path to the OpenVPN CA cert file")
 	flag.StringVar(&runOp.openvpnCAKeyFilePath, "openvpn-ca-key-file", "", "Path to the OpenVPN CA key file")
+	flag.StringVar(&runOp.tunnelingMode, "tunneling-mode", string(kubermaticv1.TunnelingModeOpenVPN), "The tunneling mode the control plane currently uses to reach the cluster")
+	flag.IntVar(&runOp.konnectivityServerPort, "konnectivity-server-port", 0, "Konnectivity server port. The konnectivity agents only get deployed if set")
+	flag.StringVar(&runOp.konnectivityCACertFilePath, "konnectivity-ca-cert-file", "", "Path to the konnectivity CA cert file")
+	flag.StringVar(&runOp.konnectivityCAKeyFilePath, "konnectivity-ca-key-file", "", "Path to the konnectivity CA key file")
 	flag.StringVar(&runOp.userSSHKeysDirPath, "user-ssh-keys-dir-path", "", "Path to the user ssh keys dir")
 	flag.StringVar(&runOp.overwriteRegistry, "overwrite-registry", "", "registry to use for all images")
 	flag.BoolVar(&runOp.log.Debug, "log-debug", false, "Enables debug logging")
@@ -89,6 +106,10 @@ func main() {
 	flag.StringVar(&runOp.cloudProviderName, "cloud-provider-name", "", "Name of the cloudprovider")
 	flag.StringVar(&runOp.cloudCredentialSecretTemplate, "cloud-credential-secret-template", "", "A serialized Kubernetes secret whose Name and Data fields will be used to create a secret for the openshift cloud credentials operator.")
 	flag.StringVar(&runOp.nodelabels, "node-labels", "", "A json-encoded map of node labels. If set, those labels will be enforced on all nodes.")
+	flag.BoolVar(&runOp.opaIntegration, "opa-integration", false, "Whether OPA Gatekeeper is deployed for the cluster. If set, the constraints of the seed cluster get synced into the cluster, which requires in-cluster access to the seed cluster.")
+	flag.StringVar(&runOp.projectID, "project-id", "", "The ID of the project of the cluster, used to select the constraints of the project")
+	flag.BoolVar(&runOp.dryRun, "dry-run", false, "Don't change any object in the user cluster. The changes the controllers would make get logged and exposed as metrics instead.")
+	flag.StringVar(&runOp.dryRunReportListenAddr, "dry-run-report-listen-address", "", "The address on which the changes of the dry-run mode get served as JSON. Disabled if empty.")
 
 	flag.Parse()
 
@@ -113,8 +134,15 @@ func main() {
 	if err != nil {
 		log.Fatalw("Failed parsing clusterURL", zap.Error(err))
 	}
-	if runOp.openvpnServerPort == 0 {
-		log.Fatal("-openvpn-server-port must be set")
+	if runOp.openvpnServerPort == 0 && runOp.konnectivityServerPort == 0 {
+		log.Fatal("-openvpn-server-port or -konnectivity-server-port must be set")
+	}
+	tunnelingMode := kubermaticv1.TunnelingMode(runOp.tunnelingMode)
+	if !tunnelingMode.IsValid() {
+		log.Fatalw("Invalid -tunneling-mode", "mode", runOp.tunnelingMode)
+	}
+	if runOp.dryRunReportListenAddr != "" && !runOp.dryRun {
+		log.Fatal("-dry-run-report-listen-address requires -dry-run")
 	}
 
 	caBytes, err := ioutil.ReadFile(runOp.caPath)
@@ -125,8 +153,9 @@ func main() {
 	if err != nil {
 		log.Fatalw("Failed to parse certs", zap.Error(err))
 	}
-	if len(certs) != 1 {
-		log.Fatalw("Did not find exactly one certificate in the given CA", "certificates-count", len(certs))
+	// The CA may contain further trusted certificates while it gets rotated
+	if len(certs) == 0 {
+		log.Fatal("Did not find any certificate in the given CA")
 	}
 	caKeyBytes, err := ioutil.ReadFile(runOp.caKeyPath)
 	if err != nil {
@@ -140,32 +169,21 @@ func main() {
 	if !isRSAKey {
 		log.Fatalf("Expected ca-key to be an RSA key, but was a %T", caKey)
 	}
-	caCert := &triple.KeyPair{Cert: certs[0], Key: rsaCAKey}
+	caCert := &triple.KeyPair{Cert: certs[0], Key: rsaCAKey, TrustedCerts: certs[1:]}
 
-	openVPNCACertBytes, err := ioutil.ReadFile(runOp.openvpnCACertFilePath)
-	if err != nil {
-		log.Fatalw("Failed to read openvpn-ca-cert-file", zap.Error(err))
-	}
-	openVPNCACerts, err := certutil.ParseCertsPEM(openVPNCACertBytes)
-	if err != nil {
-		log.Fatalw("Failed to parse openVPN CA file", zap.Error(err))
-	}
-	if certsLen := len(openVPNCACerts); certsLen != 1 {
-		log.Fatalw("Did not find exactly one certificate in the openVPN CA file", "certificates-count", certsLen)
-	}
-	openVPNCAKeyBytes, err := ioutil.ReadFile(runOp.openvpnCAKeyFilePath)
-	if err != nil {
-		log.Fatalw("Failed to read openvpn-ca-key-file", zap.Error(err))
-	}
-	openVPNCAKey, err := certutil.ParsePrivateKeyPEM(openVPNCAKeyBytes)
-	if err != nil {
-		log.Fatalw("Failed to parse openVPN CA key file", zap.Error(err))
+	var openVPNCACert, konnectivityCA *resources.ECDSAKeyPair
+	if runOp.openvpnServerPort != 0 {
+		openVPNCACert, err = readECDSACA(runOp.openvpnCACertFilePath, runOp.openvpnCAKeyFilePath)
+		if err != nil {
+			log.Fatalw("Failed to read the OpenVPN CA", zap.Error(err))
+		}
 	}
-	openVPNECSDAKey, isECDSAKey := openVPNCAKey.(*ecdsa.PrivateKey)
-	if !isECDSAKey {
-		log.Fatal("The openVPN private key is not an ECDSA key")
+	if runOp.konnectivityServerPort != 0 {
+		konnectivityCA, err = readECDSACA(runOp.konnectivityCACertFilePath, runOp.konnectivityCAKeyFilePath)
+		if err != nil {
+			log.Fatalw("Failed to read the konnectivity CA", zap.Error(err))
+		}
 	}
-	openVPNCACert := &resources.ECDSAKeyPair{Cert: openVPNCACerts[0], Key: openVPNECSDAKey}
 	userSSHKeys, err := getUserSSHKeys(runOp.userSSHKeysDirPath)
 	if err != nil {
 		log.Fatalw("Failed reading userSSHKey files", zap.Error(err))
@@ -202,12 +220,22 @@ func main() {
 	done := ctx.Done()
 	ctrlruntimelog.Log = ctrlruntimelog.NewDelegatingLogger(zapr.NewLogger(rawLog).WithName("controller_runtime"))
 
-	mgr, err := manager.New(cfg, manager.Options{
+	mgrOptions := manager.Options{
 		LeaderElection:          true,
 		LeaderElectionNamespace: metav1.NamespaceSystem,
 		LeaderElectionID:        "user-cluster-controller-leader-lock",
 		MetricsBindAddress:      runOp.metricsListenAddr,
-	})
+	}
+	var dryRunRecorder *reconciling.DryRunRecorder
+	if runOp.dryRun {
+		log.Info("Running in dry-run mode, no object in the user cluster will be changed")
+		dryRunRecorder = reconciling.NewDryRunRecorder(log)
+		ctrlruntimemetrics.Registry.MustRegister(dryRunRecorder)
+		mgrOptions.NewClient = reconciling.NewDryRunClientFunc("user-cluster", dryRunRecorder)
+		// A dry-run must not keep the actual controller manager from running
+		mgrOptions.LeaderElectionID += "-dry-run"
+	}
+	mgr, err := manager.New(cfg, mgrOptions)
 	if err != nil {
 		log.Fatalw("Failed creating user cluster controller", zap.Error(err))
 	}
@@ -233,29 +261,38 @@ func main() {
 		userSSHKeys,
 		healthHandler.AddReadinessCheck,
 		openVPNCACert,
+		tunnelingMode,
+		runOp.konnectivityServerPort,
+		konnectivityCA,
 		runOp.userSSHKeysDirPath,
 		cloudCredentialSecretTemplate,
+		runOp.opaIntegration,
 		log); err != nil {
 		log.Fatalw("Failed to register user cluster controller", zap.Error(err))
 	}
 	log.Info("Registered usercluster controller")
 
-	if len(runOp.networks) > 0 {
-		if err := clusterv1alpha1.AddToScheme(mgr.GetScheme()); err != nil {
-			log.Fatalw("Failed to add clusterv1alpha1 scheme", zap.Error(err))
-		}
-		// We need to add the machine CRDs once here, because otherwise the IPAM
-		// controller keeps the manager from starting as it can not establish a
-		// watch for machine CRs, keeping us from creating them
-		creators := []reconciling.NamedCustomResourceDefinitionCreatorGetter{
-			machinecontrolerresources.MachineCRDCreator(),
-		}
+	if err := clusterv1alpha1.AddToScheme(mgr.GetScheme()); err != nil {
+		log.Fatalw("Failed to add clusterv1alpha1 scheme", zap.Error(err))
+	}
+
+	// We need to add the machine CRDs once here, because otherwise the IPAM and
+	// machine health check controllers keep the manager from starting as they can not
+	// establish a watch for machine CRs, keeping us from creating them
+	for _, creator := range []reconciling.NamedCustomResourceDefinitionCreatorGetter{
+		machinecontrolerresources.MachineCRDCreator(),
+		machinecontrolerresources.MachineDeploymentCRDCreator(),
+	} {
+		creators := []reconciling.NamedCustomResourceDefinitionCreatorGetter{creator}
 		if err := reconciling.ReconcileCustomResourceDefinitions(context.Background(), creators, "", mgr.GetClient()); err != nil {
 			// The mgr.Client is uninitianlized here and hence always returns a 404, regardless of the object existing or not
-			if !strings.Contains(err.Error(), `customresourcedefinitions.apiextensions.k8s.io "machines.cluster.k8s.io" already exists`) {
-				log.Fatalw("Failed to initially create the Machine CR", zap.Error(err))
+			if !strings.Contains(err.Error(), "already exists") {
+				log.Fatalw("Failed to initially create the Machine CRs", zap.Error(err))
 			}
 		}
+	}
+
+	if len(runOp.networks) > 0 {
 		if err := ipam.Add(mgr, runOp.networks, log); err != nil {
 			log.Fatalw("Failed to add IPAM controller to mgr", zap.Error(err))
 		}
@@ -267,7 +304,8 @@ func main() {
 	}
 	log.Info("Registered user RBAC controller")
 
-	if runOp.openshift {
+	// The nodecsrapprover approves the CSRs with its own client, which can't be done dry
+	if runOp.openshift && !runOp.dryRun {
 		if err := nodecsrapprover.Add(mgr, 4, cfg, log); err != nil {
 			log.Fatalw("Failed to add nodecsrapprover controller", zap.Error(err))
 		}
@@ -287,6 +325,31 @@ func main() {
 	}
 	log.Info("Registered nodelabel controller")
 
+	if err := machinehealthcheck.Add(ctx, log, mgr); err != nil {
+		log.Fatalw("Failed to register machine health check controller", zap.Error(err))
+	}
+	log.Info("Registered machine health check controller")
+
+	var seedMgr manager.Manager
+	if runOp.opaIntegration {
+		// The constraints are read with the serviceaccount of the pod in the seed cluster
+		seedCfg, err := rest.InClusterConfig()
+		if err != nil {
+			log.Fatalw("Failed getting seed cluster config", zap.Error(err))
+		}
+		// Syncing is idempotent, so the seed manager does not need a leader election of its own
+		seedMgr, err = manager.New(seedCfg, manager.Options{
+			MetricsBindAddress: "0",
+		})
+		if err != nil {
+			log.Fatalw("Failed creating seed cluster manager", zap.Error(err))
+		}
+		if err := constraintsyncer.Add(ctx, log, seedMgr, mgr, runOp.projectID); err != nil {
+			log.Fatalw("Failed to register constraint syncer controller", zap.Error(err))
+		}
+		log.Info("Registered constraint syncer controller")
+	}
+
 	// This group is forever waiting in a goroutine for signals to stop
 	{
 		g.Add(func() error {
@@ -311,6 +374,14 @@ func main() {
 		})
 	}
 
+	if seedMgr != nil {
+		g.Add(func() error {
+			return seedMgr.Start(done)
+		}, func(err error) {
+			log.Infow("stopping seed cluster manager", zap.Error(err))
+		})
+	}
+
 	// This group starts the readiness & liveness http server
 	{
 		h := &http.Server{Addr: runOp.healthListenAddr, Handler: healthHandler}
@@ -324,6 +395,20 @@ func main() {
 		})
 	}
 
+	if runOp.dryRunReportListenAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/", dryRunRecorder)
+		h := &http.Server{Addr: runOp.dryRunReportListenAddr, Handler: mux}
+		g.Add(h.ListenAndServe, func(err error) {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+			defer cancel()
+
+			if err := h.Shutdown(shutdownCtx); err != nil {
+				log.Errorw("Dry-run report handler terminated with an error", zap.Error(err))
+			}
+		})
+	}
+
 	if err := g.Run(); err != nil {
 		log.Fatalw("Failed running user cluster controller", zap.Error(err))
 	}
@@ -358,3 +443,31 @@ func getUserSSHKeys(path string) (map[string][]byte, error) {
 
 	return data, nil
 }
+
+// readECDSACA reads an ECDSA CA, consisting of exactly one certificate and its key, from the given files
+func readECDSACA(certPath, keyPath string) (*resources.ECDSAKeyPair, error) {
+	certBytes, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", certPath, err)
+	}
+	certs, err := certutil.ParseCertsPEM(certBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", certPath, err)
+	}
+	if certsLen := len(certs); certsLen != 1 {
+		return nil, fmt.Errorf("did not find exactly one certificate in %s but %d", certPath, certsLen)
+	}
+	keyBytes, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", keyPath, err)
+	}
+	key, err := certutil.ParsePrivateKeyPEM(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", keyPath, err)
+	}
+	ecdsaKey, isECDSAKey := key.(*ecdsa.PrivateKey)
+	if !isECDSAKey {
+		return nil, fmt.Errorf("the private key in %s is not an ECDSA key", keyPath)
+	}
+	return &resources.ECDSAKeyPair{Cert: certs[0], Key: ecdsaKey}, nil
+}
@@ -125,6 +125,12 @@ func main() {
 				ImportAlias:        "extensionsv1beta1",
 				ResourceImportPath: "k8s.io/api/extensions/v1beta1",
 			},
+			{
+				ResourceName:       "NetworkPolicy",
+				ResourceNamePlural: "NetworkPolicies",
+				ImportAlias:        "networkingv1",
+				ResourceImportPath: "k8s.io/api/networking/v1",
+			},
 			{
 				ResourceName:       "Seed",
 				ImportAlias:        "kubermaticv1",
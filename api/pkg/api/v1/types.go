@@ -9,6 +9,7 @@ import (
 	ksemver "github.com/kubermatic/kubermatic/api/pkg/semver"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	cmdv1 "k8s.io/client-go/tools/clientcmd/api/v1"
 	"sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 )
@@ -121,6 +122,9 @@ type DatacenterSpec struct {
 	Kubevirt     *KubevirtDatacenterSpec      `json:"kubevirt,omitempty"`
 
 	RequiredEmailDomain string `json:"requiredEmailDomain,omitempty"`
+
+	// ComponentFlagPolicy restricts the admission plugins and the extra flags of the clusters in the datacenter
+	ComponentFlagPolicy *kubermaticv1.ComponentFlagPolicy `json:"componentFlagPolicy,omitempty"`
 }
 
 // DatacenterList represents a list of datacenters
@@ -421,8 +425,30 @@ type ServiceAccount struct {
 type PublicServiceAccountToken struct {
 	ObjectMeta
 	// Expiry is a timestamp representing the time when this token will expire.
+	// On creation it defaults to three years from now.
 	// swagger:strfmt date-time
 	Expiry Time `json:"expiry,omitempty"`
+	// Scopes restrict the requests the token is allowed to make. A token without scopes has all the rights of its
+	// service account, a token with scopes may only make the requests at least one of its scopes allows.
+	Scopes []ServiceAccountTokenScope `json:"scopes,omitempty"`
+}
+
+// ServiceAccountTokenScope restricts the requests a service account token is allowed to make
+// swagger:model ServiceAccountTokenScope
+type ServiceAccountTokenScope struct {
+	// ReadOnly only allows requests that don't modify anything
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// Resource only allows requests for the given resource and its subresources, e.g. "nodedeployments"
+	Resource string `json:"resource,omitempty"`
+	// ClusterID only allows requests for the given cluster and its subresources
+	ClusterID string `json:"clusterID,omitempty"`
+}
+
+// ServiceAccountTokenRotation defines how a service account token gets rotated
+// swagger:model ServiceAccountTokenRotation
+type ServiceAccountTokenRotation struct {
+	// GracePeriodSeconds is how long the replaced token stays valid, defaults to one hour
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
 }
 
 // ServiceAccountToken represent an API service account token
@@ -443,6 +469,30 @@ type Project struct {
 	Owners []User `json:"owners,omitempty"`
 }
 
+// NotificationChannel describes where notifications about events of the clusters in a project
+// are sent to. Exactly one of webhook, slack and email must be set.
+// swagger:model NotificationChannel
+type NotificationChannel struct {
+	// Name identifies the channel within the project
+	Name string `json:"name"`
+
+	// Webhook receives the notifications as JSON
+	Webhook *kubermaticv1.WebhookNotificationChannel `json:"webhook,omitempty"`
+	// Slack receives the notifications through a Slack compatible incoming webhook
+	Slack *kubermaticv1.SlackNotificationChannel `json:"slack,omitempty"`
+	// Email receives the notifications through the SMTP server configured by the administrator
+	Email *kubermaticv1.EmailNotificationChannel `json:"email,omitempty"`
+
+	// Events are the events for which notifications are sent, all events if empty. Possible
+	// events are ClusterHealthChanged, ClusterUpgradeFailed, AddonFailed, CertificateExpiring
+	// and ClusterDeleted.
+	Events []kubermaticv1.NotificationEventType `json:"events,omitempty"`
+}
+
+// NotificationChannelList represents a list of notification channels
+// swagger:model NotificationChannelList
+type NotificationChannelList []NotificationChannel
+
 // Kubeconfig is a clusters kubeconfig
 // swagger:model Kubeconfig
 type Kubeconfig struct {
@@ -540,8 +590,15 @@ type MasterVersion struct {
 // CreateClusterSpec is the structure that is used to create cluster with its initial node deployment
 // swagger:model CreateClusterSpec
 type CreateClusterSpec struct {
-	Cluster        Cluster         `json:"cluster"`
+	Cluster Cluster `json:"cluster"`
+	// NodeDeployment is kept for backwards compatibility, it is created along with NodeDeployments
 	NodeDeployment *NodeDeployment `json:"nodeDeployment,omitempty"`
+	// NodeDeployments are created as soon as the cluster is up and running
+	NodeDeployments []NodeDeployment `json:"nodeDeployments,omitempty"`
+	// Addons are installed as soon as the cluster is up and running
+	Addons []Addon `json:"addons,omitempty"`
+	// RollbackOnFailure deletes the cluster when any of the node deployments or addons cannot be created
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
 }
 
 const (
@@ -587,6 +644,31 @@ type ClusterSpec struct {
 	// AuditLogging
 	AuditLogging *kubermaticv1.AuditLoggingSettings `json:"auditLogging,omitempty"`
 
+	// Hibernation scales the cluster down to zero while it is not used, either until it gets disabled
+	// again or during scheduled time windows
+	Hibernation *kubermaticv1.HibernationSettings `json:"hibernation,omitempty"`
+
+	// ClusterAutoscaler configures the cluster-autoscaler, which scales node deployments that have
+	// minimum and maximum replicas set. The autoscaler is deployed if this is set.
+	ClusterAutoscaler *kubermaticv1.ClusterAutoscalerSettings `json:"clusterAutoscaler,omitempty"`
+
+	// EncryptionAtRest enables the encryption of the Secrets in the etcd of the cluster. It can't be
+	// disabled once it was enabled.
+	EncryptionAtRest *kubermaticv1.EncryptionAtRestSettings `json:"encryptionAtRest,omitempty"`
+
+	// AdmissionPlugins are enabled in addition to the admission plugins which are always enabled
+	AdmissionPlugins []kubermaticv1.AdmissionPluginSettings `json:"admissionPlugins,omitempty"`
+
+	// ExtraFlags are passed to the control plane components, the datacenter defines which flags are allowed
+	ExtraFlags *kubermaticv1.ComponentExtraFlags `json:"extraFlags,omitempty"`
+
+	// OPAIntegration deploys OPA Gatekeeper, which enforces the constraints of the seed and the project
+	OPAIntegration *kubermaticv1.OPAIntegrationSettings `json:"opaIntegration,omitempty"`
+
+	// TunnelingMode is the mechanism the control plane uses to reach the nodes, pods and services of the
+	// cluster, either openvpn (default) or konnectivity. Changing it migrates the cluster to the new mode.
+	TunnelingMode kubermaticv1.TunnelingMode `json:"tunnelingMode,omitempty"`
+
 	// Openshift holds all openshift-specific settings
 	Openshift *kubermaticv1.Openshift `json:"openshift,omitempty"`
 }
@@ -595,12 +677,19 @@ type ClusterSpec struct {
 // that will be returned in the API responses (see: PublicCloudSpec struct).
 func (cs *ClusterSpec) MarshalJSON() ([]byte, error) {
 	ret, err := json.Marshal(struct {
-		Cloud                               PublicCloudSpec                        `json:"cloud"`
-		MachineNetworks                     []kubermaticv1.MachineNetworkingConfig `json:"machineNetworks,omitempty"`
-		Version                             ksemver.Semver                         `json:"version"`
-		OIDC                                kubermaticv1.OIDCSettings              `json:"oidc"`
-		UsePodSecurityPolicyAdmissionPlugin bool                                   `json:"usePodSecurityPolicyAdmissionPlugin,omitempty"`
-		AuditLogging                        *kubermaticv1.AuditLoggingSettings     `json:"auditLogging,omitempty"`
+		Cloud                               PublicCloudSpec                         `json:"cloud"`
+		MachineNetworks                     []kubermaticv1.MachineNetworkingConfig  `json:"machineNetworks,omitempty"`
+		Version                             ksemver.Semver                          `json:"version"`
+		OIDC                                kubermaticv1.OIDCSettings               `json:"oidc"`
+		UsePodSecurityPolicyAdmissionPlugin bool                                    `json:"usePodSecurityPolicyAdmissionPlugin,omitempty"`
+		AuditLogging                        *kubermaticv1.AuditLoggingSettings      `json:"auditLogging,omitempty"`
+		Hibernation                         *kubermaticv1.HibernationSettings       `json:"hibernation,omitempty"`
+		ClusterAutoscaler                   *kubermaticv1.ClusterAutoscalerSettings `json:"clusterAutoscaler,omitempty"`
+		EncryptionAtRest                    *kubermaticv1.EncryptionAtRestSettings  `json:"encryptionAtRest,omitempty"`
+		AdmissionPlugins                    []kubermaticv1.AdmissionPluginSettings  `json:"admissionPlugins,omitempty"`
+		ExtraFlags                          *kubermaticv1.ComponentExtraFlags       `json:"extraFlags,omitempty"`
+		OPAIntegration                      *kubermaticv1.OPAIntegrationSettings    `json:"opaIntegration,omitempty"`
+		TunnelingMode                       kubermaticv1.TunnelingMode              `json:"tunnelingMode,omitempty"`
 	}{
 		Cloud: PublicCloudSpec{
 			DatacenterName: cs.Cloud.DatacenterName,
@@ -621,6 +710,13 @@ func (cs *ClusterSpec) MarshalJSON() ([]byte, error) {
 		OIDC:                                cs.OIDC,
 		UsePodSecurityPolicyAdmissionPlugin: cs.UsePodSecurityPolicyAdmissionPlugin,
 		AuditLogging:                        cs.AuditLogging,
+		Hibernation:                         cs.Hibernation,
+		ClusterAutoscaler:                   cs.ClusterAutoscaler,
+		EncryptionAtRest:                    cs.EncryptionAtRest,
+		AdmissionPlugins:                    cs.AdmissionPlugins,
+		ExtraFlags:                          cs.ExtraFlags,
+		OPAIntegration:                      cs.OPAIntegration,
+		TunnelingMode:                       cs.TunnelingMode,
 	})
 
 	return ret, err
@@ -774,6 +870,45 @@ type ClusterStatus struct {
 
 	// URL specifies the address at which the cluster is available
 	URL string `json:"url"`
+
+	// Creation tracks the creation of the node deployments and addons requested along with the cluster
+	Creation *ClusterCreationStatus `json:"creation,omitempty"`
+
+	// HibernationPhase is one of Hibernating, Hibernated or Resuming. It is empty while the cluster is running.
+	HibernationPhase string `json:"hibernationPhase,omitempty"`
+
+	// RootCARotationPhase is one of Trusting, Signing or Completed. It is empty if the root CA was never rotated.
+	RootCARotationPhase string `json:"rootCARotationPhase,omitempty"`
+
+	// EncryptionPhase is one of Preparing, Reencrypting or Encrypted. It is empty if the encryption at rest is disabled.
+	EncryptionPhase string `json:"encryptionPhase,omitempty"`
+
+	// TunnelingPhase is one of Established or Migrating. It is empty for clusters which always used OpenVPN.
+	TunnelingPhase string `json:"tunnelingPhase,omitempty"`
+}
+
+// ClusterCreationStatus tracks the creation of the node deployments and addons requested along with a cluster
+// swagger:model ClusterCreationStatus
+type ClusterCreationStatus struct {
+	// Phase is one of InProgress, Succeeded, Failed or RolledBack
+	Phase string `json:"phase"`
+	// RollbackOnFailure tells whether the cluster gets deleted when any of the resources cannot be created
+	RollbackOnFailure bool                            `json:"rollbackOnFailure,omitempty"`
+	Resources         []ClusterCreationResourceStatus `json:"resources,omitempty"`
+	StartTime         Time                            `json:"startTime"`
+	CompletionTime    *Time                           `json:"completionTime,omitempty"`
+	Message           string                          `json:"message,omitempty"`
+}
+
+// ClusterCreationResourceStatus is the creation status of a single node deployment or addon
+// swagger:model ClusterCreationResourceStatus
+type ClusterCreationResourceStatus struct {
+	// Kind is either NodeDeployment or Addon
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Phase is one of Pending, Created or Failed
+	Phase   string `json:"phase"`
+	Message string `json:"message,omitempty"`
 }
 
 // ClusterHealth stores health information about the cluster's components.
@@ -786,6 +921,24 @@ type ClusterHealth struct {
 	Etcd                         kubermaticv1.HealthStatus `json:"etcd"`
 	CloudProviderInfrastructure  kubermaticv1.HealthStatus `json:"cloudProviderInfrastructure"`
 	UserClusterControllerManager kubermaticv1.HealthStatus `json:"userClusterControllerManager"`
+	// Probes are the results of the periodic health probes of the control plane components
+	Probes []ClusterHealthProbe `json:"probes,omitempty"`
+}
+
+// ClusterHealthProbe is the result of a periodic health probe of a control plane component
+// swagger:model ClusterHealthProbe
+type ClusterHealthProbe struct {
+	// Name of the probe, e.g. ApiserverHealthy or EtcdHealthy
+	Name string `json:"name"`
+	// Status is True if the probe succeeded, False if it failed and Unknown if the component
+	// could not be probed
+	Status string `json:"status"`
+	// Reason is a brief reason for the status, e.g. ChecksFailed
+	Reason string `json:"reason,omitempty"`
+	// Message explains the status, it contains the reasons why the probe failed
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the status last changed
+	LastTransitionTime Time `json:"lastTransitionTime"`
 }
 
 // AccessibleAddons represents an array of addons that can be configured in the user clusters.
@@ -809,6 +962,66 @@ type AddonSpec struct {
 	IsDefault bool `json:"isDefault,omitempty"`
 }
 
+// ClusterTemplate represents a reusable cluster layout with its initial node deployments, addons and SSH keys
+// swagger:model ClusterTemplate
+type ClusterTemplate struct {
+	ObjectMeta `json:",inline"`
+
+	// Global indicates that the template is not owned by any project and is available in all of them
+	Global bool                `json:"global"`
+	Spec   ClusterTemplateSpec `json:"spec"`
+}
+
+// ClusterTemplateSpec cluster template specification
+// swagger:model ClusterTemplateSpec
+type ClusterTemplateSpec struct {
+	Description string `json:"description,omitempty"`
+	// Parameters can be referenced from any string value of the cluster, the node deployments
+	// and the addon variables as ${name}
+	Parameters []ClusterTemplateParameter `json:"parameters,omitempty"`
+	// Cluster is the cluster in the same format as accepted by the createCluster endpoint
+	Cluster map[string]interface{} `json:"cluster"`
+	// NodeDeployments are the node deployments in the same format as accepted by the createNodeDeployment endpoint
+	NodeDeployments []map[string]interface{} `json:"nodeDeployments,omitempty"`
+	// Addons are installed once the cluster is up and running
+	Addons []ClusterTemplateAddon `json:"addons,omitempty"`
+	// SSHKeys are the IDs of the project SSH keys that get assigned to the cluster
+	SSHKeys []string `json:"sshKeys,omitempty"`
+}
+
+// ClusterTemplateParameter is a value that is provided when a template gets instantiated
+// swagger:model ClusterTemplateParameter
+type ClusterTemplateParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Type is one of string (default), integer or boolean. Non-string parameters
+	// must be the only content of the string value that references them.
+	Type     string `json:"type,omitempty"`
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// ClusterTemplateAddon is an addon that gets installed into the clusters created from a template
+// swagger:model ClusterTemplateAddon
+type ClusterTemplateAddon struct {
+	Name string `json:"name"`
+	// Variables is free form data to use for parsing the manifest templates
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// ClusterTemplateList represents a list of cluster templates
+// swagger:model ClusterTemplateList
+type ClusterTemplateList []ClusterTemplate
+
+// ClusterTemplateInstance is the structure that is used to create a cluster from a template
+// swagger:model ClusterTemplateInstance
+type ClusterTemplateInstance struct {
+	// Parameters are the values of the template parameters, the defaults are used for missing ones
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// RollbackOnFailure deletes the cluster when any of the node deployments or addons cannot be created
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+}
+
 // ClusterList represents a list of clusters
 // swagger:model ClusterList
 type ClusterList []Cluster
@@ -1114,6 +1327,36 @@ type NodesMetric struct {
 	CPUUsedPercentage int64 `json:"cpuUsedPercentage,omitempty"`
 }
 
+// ClusterComplianceReport lists the violations of the OPA Gatekeeper constraints found by the audit of the cluster
+// swagger:model ClusterComplianceReport
+type ClusterComplianceReport struct {
+	TotalViolations int64                  `json:"totalViolations"`
+	Constraints     []ConstraintCompliance `json:"constraints"`
+}
+
+// ConstraintCompliance reports the violations of a constraint
+// swagger:model ConstraintCompliance
+type ConstraintCompliance struct {
+	Name              string `json:"name"`
+	Kind              string `json:"kind"`
+	EnforcementAction string `json:"enforcementAction,omitempty"`
+	// AuditTimestamp is the time of the last audit of the constraint
+	AuditTimestamp  string `json:"auditTimestamp,omitempty"`
+	TotalViolations int64  `json:"totalViolations"`
+	// Violations is limited to the first violations found by the audit
+	Violations []ConstraintViolation `json:"violations,omitempty"`
+}
+
+// ConstraintViolation is an object which violates a constraint
+// swagger:model ConstraintViolation
+type ConstraintViolation struct {
+	Kind              string `json:"kind"`
+	Name              string `json:"name"`
+	Namespace         string `json:"namespace,omitempty"`
+	Message           string `json:"message"`
+	EnforcementAction string `json:"enforcementAction,omitempty"`
+}
+
 // NodeMetric defines a metric for the given node
 // swagger:model NodeMetric
 type NodeMetric struct {
@@ -1145,12 +1388,101 @@ type NodeDeployment struct {
 type NodeDeploymentSpec struct {
 	// required: true
 	Replicas int32 `json:"replicas,omitempty"`
+	// MinReplicas is the number of replicas the cluster-autoscaler may scale the node deployment
+	// down to. The node deployment is only autoscaled if both MinReplicas and MaxReplicas are set.
+	// required: false
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// MaxReplicas is the number of replicas the cluster-autoscaler may scale the node deployment up to
+	// required: false
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// MaxSurge is the number or percentage of nodes that may be created above the desired
+	// replicas during a rollout. Defaults to 1.
+	// required: false
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the number or percentage of nodes that may be unavailable during a
+	// rollout. Defaults to 0.
+	// required: false
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+	// MinReadySeconds is the time a new node must be ready before it counts as available
+	// required: false
+	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+	// HealthCheck replaces the machines whose nodes are unhealthy
+	// required: false
+	HealthCheck *MachineHealthCheck `json:"healthCheck,omitempty"`
 	// required: true
 	Template NodeSpec `json:"template"`
 	// required: false
 	Paused *bool `json:"paused,omitempty"`
 }
 
+// MachineHealthCheck defines when the machines of a node deployment are unhealthy and get replaced
+type MachineHealthCheck struct {
+	// UnhealthyConditions are the node conditions which make a machine unhealthy once they
+	// lasted for their timeout. Defaults to the Ready condition being False or Unknown for 5m.
+	UnhealthyConditions []UnhealthyCondition `json:"unhealthyConditions,omitempty"`
+	// MaxUnhealthy is the number or percentage of machines which may be unhealthy at most for
+	// machines to get replaced, percentages are rounded up. This keeps e.g. a network partition
+	// from replacing all machines. Defaults to 40%.
+	MaxUnhealthy *intstr.IntOrString `json:"maxUnhealthy,omitempty"`
+	// NodeStartupTimeout is the time after which a machine whose node didn't join the cluster
+	// is unhealthy. Defaults to 20m.
+	NodeStartupTimeout string `json:"nodeStartupTimeout,omitempty"`
+}
+
+// UnhealthyCondition is a node condition which makes a machine unhealthy once it lasted for the timeout
+type UnhealthyCondition struct {
+	// Type of the node condition, e.g. Ready or DiskPressure
+	Type string `json:"type"`
+	// Status of the node condition, one of True, False or Unknown
+	Status string `json:"status"`
+	// Timeout is a duration like 5m
+	Timeout string `json:"timeout"`
+}
+
+// NodeDeploymentRevision is a past or the current template of a node deployment
+// swagger:model NodeDeploymentRevision
+type NodeDeploymentRevision struct {
+	Revision          int64    `json:"revision"`
+	CreationTimestamp Time     `json:"creationTimestamp"`
+	Current           bool     `json:"current"`
+	Template          NodeSpec `json:"template"`
+}
+
+// NodeDrainPhase describes how far the drain of a node got
+type NodeDrainPhase string
+
+const (
+	// NodeDrainNotStarted means that the node is not cordoned
+	NodeDrainNotStarted NodeDrainPhase = "NotStarted"
+	// NodeDrainInProgress means that the node is cordoned but pods still have to be evicted
+	NodeDrainInProgress NodeDrainPhase = "InProgress"
+	// NodeDrainTimedOut means that not all pods could be evicted before the timeout
+	NodeDrainTimedOut NodeDrainPhase = "TimedOut"
+	// NodeDrainCompleted means that the node is cordoned and all pods got evicted
+	NodeDrainCompleted NodeDrainPhase = "Completed"
+)
+
+// NodeDrainStatus reports the progress of draining a node
+// swagger:model NodeDrainStatus
+type NodeDrainStatus struct {
+	NodeName string         `json:"nodeName"`
+	Phase    NodeDrainPhase `json:"phase"`
+	// RemainingPods are the pods which still have to be evicted. Pods of DaemonSets and
+	// static pods are never evicted.
+	RemainingPods []NodeDrainPod `json:"remainingPods"`
+	// MachineReplaced is set when the machine of the node got deleted after the drain, so
+	// that its node deployment replaces it
+	MachineReplaced bool `json:"machineReplaced,omitempty"`
+}
+
+// NodeDrainPod is a pod which still has to be evicted from a node
+type NodeDrainPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Reason why the last eviction of the pod failed, e.g. a PodDisruptionBudget
+	Reason string `json:"reason,omitempty"`
+}
+
 // Event is a report of an event somewhere in the cluster.
 type Event struct {
 	ObjectMeta `json:",inline"`
@@ -1250,6 +1582,21 @@ type Namespace struct {
 	Name string `json:"name"`
 }
 
+// ClusterAutoscalerStatus reports what the cluster-autoscaler of a cluster is doing
+// swagger:model ClusterAutoscalerStatus
+type ClusterAutoscalerStatus struct {
+	// Enabled is whether the cluster-autoscaler is deployed for the cluster
+	Enabled bool `json:"enabled"`
+	// Status is the status report of the cluster-autoscaler, it is empty until the autoscaler ran for the first time
+	Status string `json:"status,omitempty"`
+	// LastUpdated is the time the cluster-autoscaler wrote its status report
+	LastUpdated *Time `json:"lastUpdated,omitempty"`
+	// ScaleUpEvents are the events of the scale ups the cluster-autoscaler triggered
+	ScaleUpEvents []Event `json:"scaleUpEvents"`
+	// UnschedulablePods are the pods that can't be scheduled on any node, formatted as namespace/name
+	UnschedulablePods []string `json:"unschedulablePods"`
+}
+
 // swagger:model ResourceType
 type ResourceType string
 
@@ -11,6 +11,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
@@ -20,6 +21,7 @@ import (
 // resources inside the user cluster
 type UserClusterConnectionProvider interface {
 	GetClient(*kubermaticv1.Cluster, ...ConfigOption) (ctrlruntimeclient.Client, error)
+	GetK8sClient(*kubermaticv1.Cluster, ...ConfigOption) (kubernetes.Interface, error)
 	GetAdminKubeconfig(c *kubermaticv1.Cluster) ([]byte, error)
 	GetViewerKubeconfig(c *kubermaticv1.Cluster) ([]byte, error)
 	RevokeViewerKubeconfig(c *kubermaticv1.Cluster) error
@@ -154,3 +156,14 @@ func (p *provider) GetClient(c *kubermaticv1.Cluster, options ...ConfigOption) (
 
 	return p.restMapperCache.Client(config)
 }
+
+// GetK8sClient returns a kubernetes client, which is needed for subresources like pod evictions
+// that can't be handled by the dynamic client
+func (p *provider) GetK8sClient(c *kubermaticv1.Cluster, options ...ConfigOption) (kubernetes.Interface, error) {
+	config, err := p.GetClientConfig(c, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return kubernetes.NewForConfig(config)
+}
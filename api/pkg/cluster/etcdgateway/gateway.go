@@ -0,0 +1,89 @@
+// Package etcdgateway talks to the etcd members of the cluster control planes through the gRPC gateway of etcd
+package etcdgateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+)
+
+// Alarm is an alarm raised by an etcd member
+type Alarm struct {
+	MemberID string `json:"memberID"`
+	Alarm    string `json:"alarm"`
+}
+
+// MemberURL returns the client URL of the given etcd member of the cluster
+func MemberURL(cluster *kubermaticv1.Cluster, member int) string {
+	return fmt.Sprintf("https://%s.%s.%s.svc.cluster.local:2379", etcd.MemberName(member), resources.EtcdServiceName, cluster.Status.NamespaceName)
+}
+
+// CheckHealth queries the /health endpoint of an etcd member
+func CheckHealth(ctx context.Context, client *http.Client, memberURL string) error {
+	req, err := http.NewRequest(http.MethodGet, memberURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	health := struct {
+		Health string `json:"health"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return fmt.Errorf("failed to decode the health response with status code %d: %v", resp.StatusCode, err)
+	}
+	if health.Health != "true" {
+		return errors.New("the member reports itself as unhealthy")
+	}
+	return nil
+}
+
+// ListAlarms returns the alarms of the etcd cluster, the alarms are cluster wide so any member can list them
+func ListAlarms(ctx context.Context, client *http.Client, memberURL string) ([]Alarm, error) {
+	response := struct {
+		Alarms []Alarm `json:"alarms"`
+	}{}
+	if err := Request(ctx, client, memberURL+"/v3beta/maintenance/alarm", map[string]string{"action": "GET"}, &response); err != nil {
+		return nil, err
+	}
+	return response.Alarms, nil
+}
+
+// Request posts the JSON encoded request to the given URL of the gateway and decodes the
+// response into the given one, unless it is nil
+func Request(ctx context.Context, client *http.Client, requestURL string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("got status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if response == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,99 @@
+package etcdgateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// idleConnTimeout closes the connections to the etcd members of clusters which aren't reconciled anymore
+const idleConnTimeout = 5 * time.Minute
+
+// TransportCache caches one transport per cluster, so the connections to the etcd members get reused
+// across reconciles. A transport gets replaced when the root CA or the etcd client certificate changed.
+type TransportCache struct {
+	lock       sync.Mutex
+	transports map[string]*cachedTransport
+}
+
+type cachedTransport struct {
+	transport *http.Transport
+	// credentials is the hash of the CA bundle and the client certificate the transport was created with
+	credentials [sha256.Size]byte
+}
+
+// NewTransportCache returns an empty TransportCache
+func NewTransportCache() *TransportCache {
+	return &TransportCache{transports: map[string]*cachedTransport{}}
+}
+
+// Transport returns the transport for the etcd of the cluster, which trusts the root CA of the cluster and
+// authenticates with the etcd client certificate of the apiserver
+func (c *TransportCache) Transport(ctx context.Context, client ctrlruntimeclient.Client, cluster *kubermaticv1.Cluster) (*http.Transport, error) {
+	ca, err := resources.GetClusterRootCA(ctx, cluster, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the root CA of the cluster: %v", err)
+	}
+	caBundle := resources.EncodeCABundlePEM(ca)
+
+	clientCert := &corev1.Secret{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.ApiserverEtcdClientCertificateSecretName}, clientCert); err != nil {
+		return nil, fmt.Errorf("failed to get the etcd client certificate: %v", err)
+	}
+	certPEM := clientCert.Data[resources.ApiserverEtcdClientCertificateCertSecretKey]
+	keyPEM := clientCert.Data[resources.ApiserverEtcdClientCertificateKeySecretKey]
+
+	hash := sha256.New()
+	for _, data := range [][]byte{caBundle, certPEM, keyPEM} {
+		_, _ = hash.Write(data)
+	}
+	var credentials [sha256.Size]byte
+	copy(credentials[:], hash.Sum(nil))
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if cached, exists := c.transports[cluster.Name]; exists {
+		if cached.credentials == credentials {
+			return cached.transport, nil
+		}
+		cached.transport.CloseIdleConnections()
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caBundle) {
+		return nil, errors.New("the root CA of the cluster contains no certificates")
+	}
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the etcd client certificate: %v", err)
+	}
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{certificate}},
+		IdleConnTimeout: idleConnTimeout,
+	}
+	c.transports[cluster.Name] = &cachedTransport{transport: transport, credentials: credentials}
+	return transport, nil
+}
+
+// Forget closes the connections of the transport of the cluster and removes it from the cache
+func (c *TransportCache) Forget(clusterName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if cached, exists := c.transports[clusterName]; exists {
+		cached.transport.CloseIdleConnections()
+		delete(c.transports, clusterName)
+	}
+}
@@ -0,0 +1,71 @@
+package etcdgateway
+
+import (
+	"context"
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates/triple"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	certutil "k8s.io/client-go/util/cert"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func genClientCertSecret(t *testing.T) *corev1.Secret {
+	keyPair, err := triple.NewCA("apiserver-etcd-client")
+	if err != nil {
+		t.Fatalf("failed to create client certificate: %v", err)
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.ApiserverEtcdClientCertificateSecretName},
+		Data: map[string][]byte{
+			resources.ApiserverEtcdClientCertificateCertSecretKey: certutil.EncodeCertPEM(keyPair.Cert),
+			resources.ApiserverEtcdClientCertificateKeySecretKey:  certutil.EncodePrivateKeyPEM(keyPair.Key),
+		},
+	}
+}
+
+func TestTransportCache(t *testing.T) {
+	cluster := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "henrik"},
+		Status:     kubermaticv1.ClusterStatus{NamespaceName: "cluster-henrik"},
+	}
+	caSecret, err := certificates.GetCACreator("root-ca")(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.CASecretName},
+	})
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	client := ctrlruntimefake.NewFakeClient(caSecret, genClientCertSecret(t))
+	cache := NewTransportCache()
+	ctx := context.Background()
+
+	transport, err := cache.Transport(ctx, client, cluster)
+	if err != nil {
+		t.Fatalf("failed to get transport: %v", err)
+	}
+	if cached, err := cache.Transport(ctx, client, cluster); err != nil || cached != transport {
+		t.Fatalf("expected the cached transport to be reused, got %p (error %v) instead of %p", cached, err, transport)
+	}
+
+	// A renewed client certificate replaces the transport
+	if err := client.Update(ctx, genClientCertSecret(t)); err != nil {
+		t.Fatalf("failed to update the client certificate: %v", err)
+	}
+	renewed, err := cache.Transport(ctx, client, cluster)
+	if err != nil {
+		t.Fatalf("failed to get transport: %v", err)
+	}
+	if renewed == transport {
+		t.Fatalf("expected a new transport after the client certificate changed")
+	}
+
+	cache.Forget(cluster.Name)
+	if recreated, err := cache.Transport(ctx, client, cluster); err != nil || recreated == renewed {
+		t.Fatalf("expected a new transport after the cluster got forgotten, got %p (error %v)", recreated, err)
+	}
+}
@@ -0,0 +1,129 @@
+package hibernation
+
+import (
+	"fmt"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+)
+
+const timeOfDayLayout = "15:04"
+
+var weekdays = map[string]time.Weekday{
+	"Sun": time.Sunday,
+	"Mon": time.Monday,
+	"Tue": time.Tuesday,
+	"Wed": time.Wednesday,
+	"Thu": time.Thursday,
+	"Fri": time.Friday,
+	"Sat": time.Saturday,
+}
+
+// Validate validates the given hibernation settings
+func Validate(settings *kubermaticv1.HibernationSettings) error {
+	if settings == nil {
+		return nil
+	}
+	if _, err := time.LoadLocation(settings.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q: %v", settings.TimeZone, err)
+	}
+	for i, schedule := range settings.Schedules {
+		if _, _, err := parseSchedule(schedule); err != nil {
+			return fmt.Errorf("invalid schedule %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// Requested returns whether the cluster should be hibernated at the given time, i.e.
+// if the hibernation is enabled or one of the schedules is active
+func Requested(settings *kubermaticv1.HibernationSettings, now time.Time) (bool, error) {
+	if settings == nil {
+		return false, nil
+	}
+	if settings.Enabled {
+		return true, nil
+	}
+
+	// An empty time zone is UTC
+	location, err := time.LoadLocation(settings.TimeZone)
+	if err != nil {
+		return false, fmt.Errorf("invalid time zone %q: %v", settings.TimeZone, err)
+	}
+	now = now.In(location)
+
+	for i, schedule := range settings.Schedules {
+		active, err := scheduleActive(schedule, now)
+		if err != nil {
+			return false, fmt.Errorf("invalid schedule %d: %v", i, err)
+		}
+		if active {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func scheduleActive(schedule kubermaticv1.HibernationSchedule, now time.Time) (bool, error) {
+	start, duration, err := parseSchedule(schedule)
+	if err != nil {
+		return false, err
+	}
+
+	// Windows are at most one day long, so the window that is active now
+	// started either today or yesterday
+	for _, daysAgo := range []int{0, 1} {
+		day := now.AddDate(0, 0, -daysAgo)
+		if !startsOn(schedule, day.Weekday()) {
+			continue
+		}
+		windowStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, day.Location()).Add(start)
+		if !now.Before(windowStart) && now.Before(windowStart.Add(duration)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func startsOn(schedule kubermaticv1.HibernationSchedule, weekday time.Weekday) bool {
+	if len(schedule.Days) == 0 {
+		return true
+	}
+	for _, day := range schedule.Days {
+		if weekdays[day] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSchedule returns the offset of the start of the window from midnight and the length of the window
+func parseSchedule(schedule kubermaticv1.HibernationSchedule) (time.Duration, time.Duration, error) {
+	for _, day := range schedule.Days {
+		if _, ok := weekdays[day]; !ok {
+			return 0, 0, fmt.Errorf("invalid day %q, must be one of Mon, Tue, Wed, Thu, Fri, Sat or Sun", day)
+		}
+	}
+	start, err := parseTimeOfDay(schedule.Start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid start: %v", err)
+	}
+	end, err := parseTimeOfDay(schedule.End)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid end: %v", err)
+	}
+
+	duration := end - start
+	if duration <= 0 {
+		duration += 24 * time.Hour
+	}
+	return start, duration, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse(timeOfDayLayout, value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
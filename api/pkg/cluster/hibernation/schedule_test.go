@@ -0,0 +1,155 @@
+package hibernation
+
+import (
+	"testing"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+)
+
+func TestRequested(t *testing.T) {
+	nightsAndWeekends := &kubermaticv1.HibernationSettings{
+		TimeZone: "Europe/Berlin",
+		Schedules: []kubermaticv1.HibernationSchedule{
+			{Days: []string{"Mon", "Tue", "Wed", "Thu", "Fri"}, Start: "20:00", End: "07:00"},
+			{Days: []string{"Sat", "Sun"}, Start: "00:00", End: "00:00"},
+		},
+	}
+
+	testCases := []struct {
+		name      string
+		settings  *kubermaticv1.HibernationSettings
+		now       string
+		requested bool
+	}{
+		{
+			name:      "no settings",
+			now:       "2019-10-16T22:00:00+02:00",
+			requested: false,
+		},
+		{
+			name:      "enabled",
+			settings:  &kubermaticv1.HibernationSettings{Enabled: true},
+			now:       "2019-10-16T12:00:00+02:00",
+			requested: true,
+		},
+		{
+			name:      "working hours",
+			settings:  nightsAndWeekends,
+			now:       "2019-10-16T12:00:00+02:00",
+			requested: false,
+		},
+		{
+			name:      "evening",
+			settings:  nightsAndWeekends,
+			now:       "2019-10-16T20:00:00+02:00",
+			requested: true,
+		},
+		{
+			name:      "night that started on the previous day",
+			settings:  nightsAndWeekends,
+			now:       "2019-10-17T06:59:00+02:00",
+			requested: true,
+		},
+		{
+			name:      "morning",
+			settings:  nightsAndWeekends,
+			now:       "2019-10-17T07:00:00+02:00",
+			requested: false,
+		},
+		{
+			name:      "weekend",
+			settings:  nightsAndWeekends,
+			now:       "2019-10-19T15:00:00+02:00",
+			requested: true,
+		},
+		{
+			name:      "night from sunday to monday does not belong to any window",
+			settings:  nightsAndWeekends,
+			now:       "2019-10-21T03:00:00+02:00",
+			requested: false,
+		},
+		{
+			name:      "schedules use the time zone",
+			settings:  nightsAndWeekends,
+			now:       "2019-10-16T18:30:00Z",
+			requested: true,
+		},
+		{
+			name: "schedule without days is active every day",
+			settings: &kubermaticv1.HibernationSettings{
+				Schedules: []kubermaticv1.HibernationSchedule{{Start: "01:00", End: "05:00"}},
+			},
+			now:       "2019-10-19T02:00:00Z",
+			requested: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, tc.now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			requested, err := Requested(tc.settings, now)
+			if err != nil {
+				t.Fatalf("failed to evaluate hibernation settings: %v", err)
+			}
+			if requested != tc.requested {
+				t.Errorf("expected hibernation to be requested: %v, got %v", tc.requested, requested)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		settings *kubermaticv1.HibernationSettings
+		valid    bool
+	}{
+		{
+			name:  "no settings",
+			valid: true,
+		},
+		{
+			name: "valid schedule",
+			settings: &kubermaticv1.HibernationSettings{
+				TimeZone:  "America/New_York",
+				Schedules: []kubermaticv1.HibernationSchedule{{Days: []string{"Fri"}, Start: "18:00", End: "08:00"}},
+			},
+			valid: true,
+		},
+		{
+			name:     "invalid time zone",
+			settings: &kubermaticv1.HibernationSettings{TimeZone: "Mars/Olympus_Mons"},
+			valid:    false,
+		},
+		{
+			name: "invalid day",
+			settings: &kubermaticv1.HibernationSettings{
+				Schedules: []kubermaticv1.HibernationSchedule{{Days: []string{"Friday"}, Start: "18:00", End: "08:00"}},
+			},
+			valid: false,
+		},
+		{
+			name: "invalid time of day",
+			settings: &kubermaticv1.HibernationSettings{
+				Schedules: []kubermaticv1.HibernationSchedule{{Start: "6pm", End: "08:00"}},
+			},
+			valid: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.settings)
+			if tc.valid && err != nil {
+				t.Errorf("expected settings to be valid, got %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("expected settings to be invalid")
+			}
+		})
+	}
+}
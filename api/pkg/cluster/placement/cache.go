@@ -0,0 +1,120 @@
+package placement
+
+import (
+	"sync"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+)
+
+// UtilisationCache keeps a snapshot of the utilisation of every seed, so placing a cluster
+// doesn't need to list all clusters and pods of the seeds on every request. The clusters placed
+// since a snapshot got taken are added to it, so that a burst of creations doesn't overshoot the
+// capacity of a seed.
+type UtilisationCache struct {
+	maxAge time.Duration
+	now    func() time.Time
+
+	lock      sync.Mutex
+	snapshots map[string]*utilisationSnapshot
+	// placements are the times clusters got placed on a seed, which the snapshot of the seed
+	// might not include yet
+	placements map[string][]time.Time
+}
+
+type utilisationSnapshot struct {
+	utilisation *Utilisation
+	takenAt     time.Time
+	refreshing  bool
+}
+
+// NewUtilisationCache returns a cache which refreshes snapshots that are older than maxAge
+func NewUtilisationCache(maxAge time.Duration) *UtilisationCache {
+	return &UtilisationCache{
+		maxAge:     maxAge,
+		now:        time.Now,
+		snapshots:  map[string]*utilisationSnapshot{},
+		placements: map[string][]time.Time{},
+	}
+}
+
+// Getter returns a UtilisationGetter which serves the snapshots of the cache and uses the given
+// getter to take them. Only the first snapshot of a seed gets taken synchronously, outdated snapshots
+// keep getting served while a new one gets taken in the background.
+func (c *UtilisationCache) Getter(getter UtilisationGetter) UtilisationGetter {
+	return func(seed *kubermaticv1.Seed) (*Utilisation, error) {
+		c.lock.Lock()
+		snapshot, exists := c.snapshots[seed.Name]
+		if !exists {
+			c.lock.Unlock()
+			return c.take(seed, getter)
+		}
+		if !snapshot.refreshing && c.now().Sub(snapshot.takenAt) >= c.maxAge {
+			snapshot.refreshing = true
+			go func() {
+				if _, err := c.take(seed, getter); err != nil {
+					utilruntime.HandleError(err)
+				}
+			}()
+		}
+		utilisation := withPlacements(snapshot.utilisation, len(c.placements[seed.Name]))
+		c.lock.Unlock()
+		return utilisation, nil
+	}
+}
+
+// Placed records that a cluster got placed on the seed. It counts towards the utilisation of the
+// seed until a snapshot taken after the placement includes it.
+func (c *UtilisationCache) Placed(seed *kubermaticv1.Seed) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.placements[seed.Name] = append(c.placements[seed.Name], c.now())
+}
+
+func (c *UtilisationCache) take(seed *kubermaticv1.Seed, getter UtilisationGetter) (*Utilisation, error) {
+	// Clusters placed while the snapshot gets taken might be missing in it
+	startedAt := c.now()
+	utilisation, err := getter(seed)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		// Keep serving the last snapshot and retry with the next request
+		if snapshot, exists := c.snapshots[seed.Name]; exists {
+			snapshot.refreshing = false
+		}
+		return nil, err
+	}
+	c.snapshots[seed.Name] = &utilisationSnapshot{utilisation: utilisation, takenAt: startedAt}
+
+	var pending []time.Time
+	for _, placedAt := range c.placements[seed.Name] {
+		if !placedAt.Before(startedAt) {
+			pending = append(pending, placedAt)
+		}
+	}
+	c.placements[seed.Name] = pending
+	return withPlacements(utilisation, len(pending)), nil
+}
+
+// withPlacements returns the utilisation with the given number of clusters added. Their control planes
+// get accounted with the average resource requests of the existing ones, as they might not run yet.
+func withPlacements(utilisation *Utilisation, placements int) *Utilisation {
+	if placements == 0 {
+		return utilisation
+	}
+	result := &Utilisation{
+		Clusters: utilisation.Clusters + placements,
+		CPU:      utilisation.CPU.DeepCopy(),
+		Memory:   utilisation.Memory.DeepCopy(),
+	}
+	if utilisation.Clusters > 0 {
+		clusters := int64(utilisation.Clusters)
+		result.CPU.Add(*resource.NewMilliQuantity(utilisation.CPU.MilliValue()/clusters*int64(placements), utilisation.CPU.Format))
+		result.Memory.Add(*resource.NewQuantity(utilisation.Memory.Value()/clusters*int64(placements), utilisation.Memory.Format))
+	}
+	return result
+}
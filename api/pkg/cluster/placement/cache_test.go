@@ -0,0 +1,144 @@
+package placement
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestUtilisationCache(t *testing.T) {
+	seed := genSeed("europe", "fra", "fra1", nil)
+	var lock sync.Mutex
+	now := time.Now()
+	cache := NewUtilisationCache(time.Minute)
+	cache.now = func() time.Time {
+		lock.Lock()
+		defer lock.Unlock()
+		return now
+	}
+
+	clusters, calls := 1, 0
+	var failure error
+	getter := cache.Getter(func(*kubermaticv1.Seed) (*Utilisation, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		calls++
+		if failure != nil {
+			return nil, failure
+		}
+		return &Utilisation{Clusters: clusters}, nil
+	})
+	get := func() int {
+		utilisation, err := getter(seed)
+		if err != nil {
+			t.Fatalf("failed to get utilisation: %v", err)
+		}
+		return utilisation.Clusters
+	}
+	getCalls := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return calls
+	}
+
+	// The first snapshot gets taken synchronously
+	if got := get(); got != 1 {
+		t.Fatalf("expected 1 cluster, got %d", got)
+	}
+
+	// Fresh snapshots get served without asking the seed
+	lock.Lock()
+	clusters = 2
+	lock.Unlock()
+	if got := get(); got != 1 || getCalls() != 1 {
+		t.Fatalf("expected the cached snapshot with 1 cluster after 1 call, got %d clusters after %d calls", got, getCalls())
+	}
+
+	// Outdated snapshots get served while a new one gets taken in the background
+	lock.Lock()
+	now = now.Add(time.Minute)
+	lock.Unlock()
+	if got := get(); got != 1 {
+		t.Fatalf("expected the outdated snapshot with 1 cluster, got %d", got)
+	}
+	if err := wait.Poll(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return get() == 2, nil
+	}); err != nil {
+		t.Fatalf("the snapshot didn't get refreshed: %v", err)
+	}
+	if calls := getCalls(); calls != 2 {
+		t.Fatalf("expected 2 calls, got %d", calls)
+	}
+
+	// A failed refresh keeps the last snapshot
+	lock.Lock()
+	failure = errors.New("seed unreachable")
+	now = now.Add(time.Minute)
+	lock.Unlock()
+	get()
+	if err := wait.Poll(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return getCalls() == 3, nil
+	}); err != nil {
+		t.Fatalf("the snapshot didn't get refreshed: %v", err)
+	}
+	if got := get(); got != 2 {
+		t.Fatalf("expected the last snapshot with 2 clusters, got %d", got)
+	}
+}
+
+func TestUtilisationCachePlacements(t *testing.T) {
+	seed := genSeed("europe", "fra", "fra1", nil)
+	now := time.Now()
+	cache := NewUtilisationCache(time.Minute)
+	cache.now = func() time.Time {
+		return now
+	}
+
+	clusters := 2
+	getter := cache.Getter(func(*kubermaticv1.Seed) (*Utilisation, error) {
+		return &Utilisation{
+			Clusters: clusters,
+			CPU:      resource.MustParse("2"),
+			Memory:   resource.MustParse("4Gi"),
+		}, nil
+	})
+	get := func() *Utilisation {
+		utilisation, err := getter(seed)
+		if err != nil {
+			t.Fatalf("failed to get utilisation: %v", err)
+		}
+		return utilisation
+	}
+	get()
+
+	// Placed clusters count with the average requests until a snapshot includes them
+	now = now.Add(time.Second)
+	cache.Placed(seed)
+	utilisation := get()
+	if utilisation.Clusters != 3 {
+		t.Errorf("expected the placed cluster to be counted, got %d clusters", utilisation.Clusters)
+	}
+	if expected := resource.MustParse("3"); utilisation.CPU.Cmp(expected) != 0 {
+		t.Errorf("expected %s CPU, got %s", expected.String(), utilisation.CPU.String())
+	}
+	if expected := resource.MustParse("6Gi"); utilisation.Memory.Cmp(expected) != 0 {
+		t.Errorf("expected %s memory, got %s", expected.String(), utilisation.Memory.String())
+	}
+
+	now = now.Add(time.Second)
+	clusters = 3
+	if _, err := cache.take(seed, func(*kubermaticv1.Seed) (*Utilisation, error) {
+		return &Utilisation{Clusters: clusters}, nil
+	}); err != nil {
+		t.Fatalf("failed to take snapshot: %v", err)
+	}
+	if utilisation := get(); utilisation.Clusters != 3 {
+		t.Errorf("expected the placed cluster to be counted once it's in the snapshot, got %d clusters", utilisation.Clusters)
+	}
+}
@@ -0,0 +1,166 @@
+package placement
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Utilisation is the load the cluster control planes put on a seed
+type Utilisation struct {
+	// Clusters is the number of clusters on the seed, including the ones being deleted
+	Clusters int
+	// CPU is the sum of the CPU requests of all pods in cluster namespaces
+	CPU resource.Quantity
+	// Memory is the sum of the memory requests of all pods in cluster namespaces
+	Memory resource.Quantity
+}
+
+// UtilisationGetter returns the utilisation of the given seed
+type UtilisationGetter = func(seed *kubermaticv1.Seed) (*Utilisation, error)
+
+// GetUtilisation sums up the clusters and the resource requests of their control planes
+// using a client for the seed cluster
+func GetUtilisation(ctx context.Context, client ctrlruntimeclient.Reader) (*Utilisation, error) {
+	clusters := &kubermaticv1.ClusterList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, clusters); err != nil {
+		return nil, fmt.Errorf("failed to list clusters: %v", err)
+	}
+
+	utilisation := &Utilisation{Clusters: len(clusters.Items)}
+	for _, cluster := range clusters.Items {
+		if cluster.Status.NamespaceName == "" {
+			continue
+		}
+		pods := &corev1.PodList{}
+		if err := client.List(ctx, &ctrlruntimeclient.ListOptions{Namespace: cluster.Status.NamespaceName}, pods); err != nil {
+			return nil, fmt.Errorf("failed to list pods of cluster %s: %v", cluster.Name, err)
+		}
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+				continue
+			}
+			for _, container := range pod.Spec.Containers {
+				utilisation.CPU.Add(*container.Resources.Requests.Cpu())
+				utilisation.Memory.Add(*container.Resources.Requests.Memory())
+			}
+		}
+	}
+
+	return utilisation, nil
+}
+
+// Unschedulable returns why the seed does not accept further clusters,
+// it returns an empty string if it does
+func Unschedulable(seed *kubermaticv1.Seed, utilisation *Utilisation) string {
+	capacity := seed.Spec.Capacity
+	if capacity == nil {
+		return ""
+	}
+	if capacity.Cordoned {
+		return fmt.Sprintf("seed %s is cordoned", seed.Name)
+	}
+	if capacity.MaxClusters != nil && utilisation.Clusters >= *capacity.MaxClusters {
+		return fmt.Sprintf("seed %s reached its maximum of %d clusters", seed.Name, *capacity.MaxClusters)
+	}
+	if capacity.CPU != nil && utilisation.CPU.Cmp(*capacity.CPU) >= 0 {
+		return fmt.Sprintf("seed %s exhausted its CPU budget of %s", seed.Name, capacity.CPU.String())
+	}
+	if capacity.Memory != nil && utilisation.Memory.Cmp(*capacity.Memory) >= 0 {
+		return fmt.Sprintf("seed %s exhausted its memory budget of %s", seed.Name, capacity.Memory.String())
+	}
+	return ""
+}
+
+// NoCapacityError is returned when neither the requested seed nor any other seed
+// with an equivalent datacenter accepts the cluster
+type NoCapacityError struct {
+	Reason string
+}
+
+func (e *NoCapacityError) Error() string {
+	return fmt.Sprintf("no seed has capacity for the cluster: %s", e.Reason)
+}
+
+// Place chooses the seed the control plane of a cluster in the given datacenter of the given seed runs on.
+// If the seed doesn't accept further clusters, the cluster gets redirected to the least used seed
+// with an equivalent datacenter, i.e. a datacenter with the same cloud provider settings.
+// It returns the chosen seed and the name of the datacenter of that seed.
+func Place(seeds map[string]*kubermaticv1.Seed, seed *kubermaticv1.Seed, datacenterName string, utilisationGetter UtilisationGetter) (*kubermaticv1.Seed, string, error) {
+	reason, err := unschedulable(seed, utilisationGetter)
+	if err != nil {
+		return nil, "", err
+	}
+	if reason == "" {
+		return seed, datacenterName, nil
+	}
+
+	datacenter, exists := seed.Spec.Datacenters[datacenterName]
+	if !exists {
+		return nil, "", fmt.Errorf("datacenter %q does not exist in seed %q", datacenterName, seed.Name)
+	}
+
+	type candidate struct {
+		seed        *kubermaticv1.Seed
+		datacenter  string
+		utilisation *Utilisation
+	}
+	var candidates []candidate
+	for _, other := range seeds {
+		if other.Name == seed.Name {
+			continue
+		}
+		var names []string
+		for name := range other.Spec.Datacenters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !reflect.DeepEqual(datacenter.Spec, other.Spec.Datacenters[name].Spec) {
+				continue
+			}
+			utilisation, err := utilisationGetter(other)
+			if err != nil {
+				// An unreachable seed must not prevent the placement on the other ones
+				utilruntime.HandleError(fmt.Errorf("skipping seed %s for the placement, failed to get its utilisation: %v", other.Name, err))
+				break
+			}
+			if Unschedulable(other, utilisation) != "" {
+				break
+			}
+			candidates = append(candidates, candidate{seed: other, datacenter: name, utilisation: utilisation})
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, "", &NoCapacityError{Reason: reason}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].utilisation.Clusters != candidates[j].utilisation.Clusters {
+			return candidates[i].utilisation.Clusters < candidates[j].utilisation.Clusters
+		}
+		return candidates[i].seed.Name < candidates[j].seed.Name
+	})
+	return candidates[0].seed, candidates[0].datacenter, nil
+}
+
+func unschedulable(seed *kubermaticv1.Seed, utilisationGetter UtilisationGetter) (string, error) {
+	// Seeds without capacity accept everything, there is no need to look at their load
+	if seed.Spec.Capacity == nil {
+		return "", nil
+	}
+	utilisation, err := utilisationGetter(seed)
+	if err != nil {
+		return "", fmt.Errorf("failed to get utilisation of seed %s: %v", seed.Name, err)
+	}
+	return Unschedulable(seed, utilisation), nil
+}
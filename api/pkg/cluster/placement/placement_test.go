@@ -0,0 +1,204 @@
+package placement
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func genSeed(name, datacenter, region string, capacity *kubermaticv1.SeedCapacity) *kubermaticv1.Seed {
+	return &kubermaticv1.Seed{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: kubermaticv1.SeedSpec{
+			Capacity: capacity,
+			Datacenters: map[string]kubermaticv1.Datacenter{
+				datacenter: {
+					Spec: kubermaticv1.DatacenterSpec{
+						Digitalocean: &kubermaticv1.DatacenterSpecDigitalocean{Region: region},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestPlace(t *testing.T) {
+	testCases := []struct {
+		name               string
+		seeds              []*kubermaticv1.Seed
+		utilisation        map[string]int
+		unreachable        []string
+		seed               string
+		datacenter         string
+		expectedSeed       string
+		expectedDatacenter string
+		expectNoCapacity   bool
+	}{
+		{
+			name: "seed without capacity accepts the cluster",
+			seeds: []*kubermaticv1.Seed{
+				genSeed("europe", "do-fra1", "fra1", nil),
+			},
+			seed:               "europe",
+			datacenter:         "do-fra1",
+			expectedSeed:       "europe",
+			expectedDatacenter: "do-fra1",
+		},
+		{
+			name: "seed below its maximum accepts the cluster",
+			seeds: []*kubermaticv1.Seed{
+				genSeed("europe", "do-fra1", "fra1", &kubermaticv1.SeedCapacity{MaxClusters: intPtr(2)}),
+			},
+			utilisation:        map[string]int{"europe": 1},
+			seed:               "europe",
+			datacenter:         "do-fra1",
+			expectedSeed:       "europe",
+			expectedDatacenter: "do-fra1",
+		},
+		{
+			name: "cordoned seed redirects to the least used equivalent datacenter",
+			seeds: []*kubermaticv1.Seed{
+				genSeed("europe", "do-fra1", "fra1", &kubermaticv1.SeedCapacity{Cordoned: true}),
+				genSeed("europe-2", "do-fra1-b", "fra1", nil),
+				genSeed("europe-3", "do-fra1-c", "fra1", nil),
+				genSeed("us", "do-nyc1", "nyc1", nil),
+			},
+			utilisation:        map[string]int{"europe-2": 5, "europe-3": 2, "us": 0},
+			seed:               "europe",
+			datacenter:         "do-fra1",
+			expectedSeed:       "europe-3",
+			expectedDatacenter: "do-fra1-c",
+		},
+		{
+			name: "unreachable equivalent seed gets skipped",
+			seeds: []*kubermaticv1.Seed{
+				genSeed("europe", "do-fra1", "fra1", &kubermaticv1.SeedCapacity{Cordoned: true}),
+				genSeed("europe-2", "do-fra1-b", "fra1", nil),
+				genSeed("europe-3", "do-fra1-c", "fra1", nil),
+			},
+			utilisation:        map[string]int{"europe-3": 5},
+			unreachable:        []string{"europe-2"},
+			seed:               "europe",
+			datacenter:         "do-fra1",
+			expectedSeed:       "europe-3",
+			expectedDatacenter: "do-fra1-c",
+		},
+		{
+			name: "full seed without equivalent datacenters refuses the cluster",
+			seeds: []*kubermaticv1.Seed{
+				genSeed("europe", "do-fra1", "fra1", &kubermaticv1.SeedCapacity{MaxClusters: intPtr(2)}),
+				genSeed("us", "do-nyc1", "nyc1", nil),
+			},
+			utilisation:      map[string]int{"europe": 2},
+			seed:             "europe",
+			datacenter:       "do-fra1",
+			expectNoCapacity: true,
+		},
+		{
+			name: "full seed refuses the cluster if the equivalent seed is full too",
+			seeds: []*kubermaticv1.Seed{
+				genSeed("europe", "do-fra1", "fra1", &kubermaticv1.SeedCapacity{MaxClusters: intPtr(2)}),
+				genSeed("europe-2", "do-fra1-b", "fra1", &kubermaticv1.SeedCapacity{MaxClusters: intPtr(3)}),
+			},
+			utilisation:      map[string]int{"europe": 2, "europe-2": 3},
+			seed:             "europe",
+			datacenter:       "do-fra1",
+			expectNoCapacity: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seeds := map[string]*kubermaticv1.Seed{}
+			for _, seed := range tc.seeds {
+				seeds[seed.Name] = seed
+			}
+			getter := func(seed *kubermaticv1.Seed) (*Utilisation, error) {
+				for _, unreachable := range tc.unreachable {
+					if seed.Name == unreachable {
+						return nil, errors.New("seed unreachable")
+					}
+				}
+				return &Utilisation{Clusters: tc.utilisation[seed.Name]}, nil
+			}
+
+			seed, datacenter, err := Place(seeds, seeds[tc.seed], tc.datacenter, getter)
+			if tc.expectNoCapacity {
+				if _, ok := err.(*NoCapacityError); !ok {
+					t.Fatalf("expected a NoCapacityError, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to place cluster: %v", err)
+			}
+			if seed.Name != tc.expectedSeed {
+				t.Errorf("expected seed %q, got %q", tc.expectedSeed, seed.Name)
+			}
+			if datacenter != tc.expectedDatacenter {
+				t.Errorf("expected datacenter %q, got %q", tc.expectedDatacenter, datacenter)
+			}
+		})
+	}
+}
+
+func TestGetUtilisation(t *testing.T) {
+	pod := func(name, namespace, cpu, memory string, phase corev1.PodPhase) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{
+					Name: "container",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse(cpu),
+							corev1.ResourceMemory: resource.MustParse(memory),
+						},
+					},
+				}},
+			},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	client := ctrlruntimefake.NewFakeClientWithScheme(scheme.Scheme,
+		&kubermaticv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Status:     kubermaticv1.ClusterStatus{NamespaceName: "cluster-a"},
+		},
+		&kubermaticv1.Cluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Status:     kubermaticv1.ClusterStatus{NamespaceName: "cluster-b"},
+		},
+		pod("apiserver", "cluster-a", "500m", "512Mi", corev1.PodRunning),
+		pod("etcd", "cluster-b", "1", "1Gi", corev1.PodRunning),
+		pod("job", "cluster-b", "2", "2Gi", corev1.PodSucceeded),
+		pod("unrelated", "kube-system", "4", "4Gi", corev1.PodRunning),
+	)
+
+	utilisation, err := GetUtilisation(context.Background(), client)
+	if err != nil {
+		t.Fatalf("failed to get utilisation: %v", err)
+	}
+	if utilisation.Clusters != 2 {
+		t.Errorf("expected 2 clusters, got %d", utilisation.Clusters)
+	}
+	if expected := resource.MustParse("1500m"); utilisation.CPU.Cmp(expected) != 0 {
+		t.Errorf("expected CPU requests of %s, got %s", expected.String(), utilisation.CPU.String())
+	}
+	if expected := resource.MustParse("1536Mi"); utilisation.Memory.Cmp(expected) != 0 {
+		t.Errorf("expected memory requests of %s, got %s", expected.String(), utilisation.Memory.String())
+	}
+}
@@ -0,0 +1,108 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	certificatePrefix = "kubermatic_cluster_certificate_"
+)
+
+// CertificateCollector exports the expiry of the certificates of the cluster control planes
+type CertificateCollector struct {
+	client ctrlruntimeclient.Client
+
+	expiryDays    *prometheus.Desc
+	minExpiryDays *prometheus.Desc
+}
+
+// MustRegisterCertificateCollector registers the certificate collector at the given prometheus registry
+func MustRegisterCertificateCollector(registry prometheus.Registerer, client ctrlruntimeclient.Client) {
+	cc := &CertificateCollector{
+		client: client,
+		expiryDays: prometheus.NewDesc(
+			certificatePrefix+"expiry_days",
+			"Days until the certificate expires",
+			[]string{"cluster", "secret", "key", "common_name", "ca"},
+			nil,
+		),
+		minExpiryDays: prometheus.NewDesc(
+			certificatePrefix+"min_expiry_days",
+			"Days until the first certificate of the cluster expires",
+			[]string{"cluster"},
+			nil,
+		),
+	}
+
+	registry.MustRegister(cc)
+}
+
+// Describe returns the metrics descriptors
+func (cc CertificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.expiryDays
+	ch <- cc.minExpiryDays
+}
+
+// Collect gets called by prometheus to collect the metrics
+func (cc CertificateCollector) Collect(ch chan<- prometheus.Metric) {
+	clusters := &kubermaticv1.ClusterList{}
+	if err := cc.client.List(context.Background(), &ctrlruntimeclient.ListOptions{}, clusters); err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to list clusters in CertificateCollector: %v", err))
+		return
+	}
+
+	now := time.Now()
+	for _, cluster := range clusters.Items {
+		if cluster.Status.NamespaceName == "" {
+			continue
+		}
+		secrets := &corev1.SecretList{}
+		if err := cc.client.List(context.Background(), &ctrlruntimeclient.ListOptions{
+			Namespace: cluster.Status.NamespaceName,
+		}, secrets); err != nil {
+			utilruntime.HandleError(fmt.Errorf("failed to list secrets for cluster %s in CertificateCollector: %v", cluster.Name, err))
+			continue
+		}
+		cc.collectCluster(ch, &cluster, secrets.Items, now)
+	}
+}
+
+func (cc *CertificateCollector) collectCluster(ch chan<- prometheus.Metric, c *kubermaticv1.Cluster, secrets []corev1.Secret, now time.Time) {
+	var minExpiryDays *float64
+	for i := range secrets {
+		for _, stored := range certificates.SecretCertificates(&secrets[i]) {
+			days := stored.DaysUntilExpiry(now)
+			ch <- prometheus.MustNewConstMetric(
+				cc.expiryDays,
+				prometheus.GaugeValue,
+				days,
+				c.Name,
+				stored.SecretName,
+				stored.Key,
+				stored.Cert.Subject.CommonName,
+				fmt.Sprintf("%t", stored.Cert.IsCA),
+			)
+			if minExpiryDays == nil || days < *minExpiryDays {
+				minExpiryDays = &days
+			}
+		}
+	}
+
+	if minExpiryDays != nil {
+		ch <- prometheus.MustNewConstMetric(
+			cc.minExpiryDays,
+			prometheus.GaugeValue,
+			*minExpiryDays,
+			c.Name,
+		)
+	}
+}
@@ -0,0 +1,154 @@
+package collectors
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EtcdMemberStats are the database statistics of an etcd member, they get gathered by the etcd maintenance controller
+type EtcdMemberStats struct {
+	Member string
+	// DBSize is the size of the database file including the free pages
+	DBSize int64
+	// DBSizeInUse is the size of the database without the free pages. It is only reported since etcd 3.4, 0 means unknown
+	DBSizeInUse int64
+	// Alarms are the alarms raised by the member, e.g. NOSPACE
+	Alarms []string
+	// LastDefragmentation is the time the member was last defragmented by the etcd maintenance controller
+	LastDefragmentation time.Time
+}
+
+type etcdClusterStats struct {
+	quota   int64
+	members []EtcdMemberStats
+}
+
+// EtcdCollector exports the database statistics of the etcd members of the clusters. Querying every etcd
+// on scrape would be too slow, so the statistics are stored by the etcd maintenance controller.
+type EtcdCollector struct {
+	lock  sync.RWMutex
+	stats map[string]etcdClusterStats
+
+	dbSize              *prometheus.Desc
+	dbSizeInUse         *prometheus.Desc
+	fragmentation       *prometheus.Desc
+	quota               *prometheus.Desc
+	alarm               *prometheus.Desc
+	lastDefragmentation *prometheus.Desc
+}
+
+// MustRegisterEtcdCollector registers the etcd collector at the given prometheus registry
+func MustRegisterEtcdCollector(registry prometheus.Registerer) *EtcdCollector {
+	ec := &EtcdCollector{
+		stats: map[string]etcdClusterStats{},
+		dbSize: prometheus.NewDesc(
+			prefix+"etcd_db_size_bytes",
+			"Size of the etcd database file",
+			[]string{"cluster", "member"},
+			nil,
+		),
+		dbSizeInUse: prometheus.NewDesc(
+			prefix+"etcd_db_size_in_use_bytes",
+			"Size of the etcd database without free pages, only reported since etcd 3.4",
+			[]string{"cluster", "member"},
+			nil,
+		),
+		fragmentation: prometheus.NewDesc(
+			prefix+"etcd_db_fragmentation_ratio",
+			"Share of the etcd database file that consists of free pages, only reported since etcd 3.4",
+			[]string{"cluster", "member"},
+			nil,
+		),
+		quota: prometheus.NewDesc(
+			prefix+"etcd_quota_backend_bytes",
+			"Size of the etcd database at which the NOSPACE alarm gets raised",
+			[]string{"cluster"},
+			nil,
+		),
+		alarm: prometheus.NewDesc(
+			prefix+"etcd_alarm",
+			"Alarms raised by the etcd members",
+			[]string{"cluster", "member", "alarm"},
+			nil,
+		),
+		lastDefragmentation: prometheus.NewDesc(
+			prefix+"etcd_last_defragmentation_timestamp_seconds",
+			"Unix timestamp of the last defragmentation of the etcd member",
+			[]string{"cluster", "member"},
+			nil,
+		),
+	}
+
+	registry.MustRegister(ec)
+	return ec
+}
+
+// SetClusterStats replaces the statistics of the etcd members of a cluster
+func (ec *EtcdCollector) SetClusterStats(cluster string, quota int64, members []EtcdMemberStats) {
+	ec.lock.Lock()
+	defer ec.lock.Unlock()
+	ec.stats[cluster] = etcdClusterStats{quota: quota, members: members}
+}
+
+// DeleteClusterStats removes the statistics of a cluster, e.g. because it got deleted
+func (ec *EtcdCollector) DeleteClusterStats(cluster string) {
+	ec.lock.Lock()
+	defer ec.lock.Unlock()
+	delete(ec.stats, cluster)
+}
+
+// Describe returns the metrics descriptors
+func (ec *EtcdCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ec.dbSize
+	ch <- ec.dbSizeInUse
+	ch <- ec.fragmentation
+	ch <- ec.quota
+	ch <- ec.alarm
+	ch <- ec.lastDefragmentation
+}
+
+// Collect gets called by prometheus to collect the metrics
+func (ec *EtcdCollector) Collect(ch chan<- prometheus.Metric) {
+	ec.lock.RLock()
+	defer ec.lock.RUnlock()
+
+	for cluster, stats := range ec.stats {
+		ch <- prometheus.MustNewConstMetric(ec.quota, prometheus.GaugeValue, float64(stats.quota), cluster)
+		for _, member := range stats.members {
+			ec.collectMember(ch, cluster, member)
+		}
+	}
+}
+
+func (ec *EtcdCollector) collectMember(ch chan<- prometheus.Metric, cluster string, member EtcdMemberStats) {
+	ch <- prometheus.MustNewConstMetric(ec.dbSize, prometheus.GaugeValue, float64(member.DBSize), cluster, member.Member)
+
+	if member.DBSizeInUse > 0 {
+		ch <- prometheus.MustNewConstMetric(ec.dbSizeInUse, prometheus.GaugeValue, float64(member.DBSizeInUse), cluster, member.Member)
+		if member.DBSize > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				ec.fragmentation,
+				prometheus.GaugeValue,
+				float64(member.DBSize-member.DBSizeInUse)/float64(member.DBSize),
+				cluster,
+				member.Member,
+			)
+		}
+	}
+
+	for _, alarm := range member.Alarms {
+		ch <- prometheus.MustNewConstMetric(ec.alarm, prometheus.GaugeValue, 1, cluster, member.Member, alarm)
+	}
+
+	if !member.LastDefragmentation.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			ec.lastDefragmentation,
+			prometheus.GaugeValue,
+			float64(member.LastDefragmentation.Unix()),
+			cluster,
+			member.Member,
+		)
+	}
+}
@@ -0,0 +1,129 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/placement"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/prometheus/client_golang/prometheus"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	seedPrefix = "kubermatic_seed_"
+)
+
+// SeedCollector exports the utilisation and capacity of the seed
+type SeedCollector struct {
+	client     ctrlruntimeclient.Reader
+	seedGetter provider.SeedGetter
+
+	clusters       *prometheus.Desc
+	maxClusters    *prometheus.Desc
+	cpuRequests    *prometheus.Desc
+	cpuBudget      *prometheus.Desc
+	memoryRequests *prometheus.Desc
+	memoryBudget   *prometheus.Desc
+	cordoned       *prometheus.Desc
+}
+
+// MustRegisterSeedCollector registers the seed collector at the given prometheus registry
+func MustRegisterSeedCollector(registry prometheus.Registerer, client ctrlruntimeclient.Reader, seedGetter provider.SeedGetter) {
+	sc := &SeedCollector{
+		client:     client,
+		seedGetter: seedGetter,
+		clusters: prometheus.NewDesc(
+			seedPrefix+"clusters",
+			"Number of clusters on the seed",
+			[]string{"seed"},
+			nil,
+		),
+		maxClusters: prometheus.NewDesc(
+			seedPrefix+"max_clusters",
+			"Maximum number of clusters the seed accepts",
+			[]string{"seed"},
+			nil,
+		),
+		cpuRequests: prometheus.NewDesc(
+			seedPrefix+"cpu_requests_cores",
+			"Sum of the CPU requests of all cluster control planes on the seed",
+			[]string{"seed"},
+			nil,
+		),
+		cpuBudget: prometheus.NewDesc(
+			seedPrefix+"cpu_budget_cores",
+			"CPU budget of the seed for cluster control planes",
+			[]string{"seed"},
+			nil,
+		),
+		memoryRequests: prometheus.NewDesc(
+			seedPrefix+"memory_requests_bytes",
+			"Sum of the memory requests of all cluster control planes on the seed",
+			[]string{"seed"},
+			nil,
+		),
+		memoryBudget: prometheus.NewDesc(
+			seedPrefix+"memory_budget_bytes",
+			"Memory budget of the seed for cluster control planes",
+			[]string{"seed"},
+			nil,
+		),
+		cordoned: prometheus.NewDesc(
+			seedPrefix+"cordoned",
+			"Whether the seed is cordoned and does not accept new clusters",
+			[]string{"seed"},
+			nil,
+		),
+	}
+
+	registry.MustRegister(sc)
+}
+
+// Describe returns the metrics descriptors
+func (sc SeedCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sc.clusters
+	ch <- sc.maxClusters
+	ch <- sc.cpuRequests
+	ch <- sc.cpuBudget
+	ch <- sc.memoryRequests
+	ch <- sc.memoryBudget
+	ch <- sc.cordoned
+}
+
+// Collect gets called by prometheus to collect the metrics
+func (sc SeedCollector) Collect(ch chan<- prometheus.Metric) {
+	seed, err := sc.seedGetter()
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get seed in SeedCollector: %v", err))
+		return
+	}
+
+	utilisation, err := placement.GetUtilisation(context.Background(), sc.client)
+	if err != nil {
+		utilruntime.HandleError(fmt.Errorf("failed to get utilisation of seed %s in SeedCollector: %v", seed.Name, err))
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(sc.clusters, prometheus.GaugeValue, float64(utilisation.Clusters), seed.Name)
+	ch <- prometheus.MustNewConstMetric(sc.cpuRequests, prometheus.GaugeValue, float64(utilisation.CPU.MilliValue())/1000, seed.Name)
+	ch <- prometheus.MustNewConstMetric(sc.memoryRequests, prometheus.GaugeValue, float64(utilisation.Memory.Value()), seed.Name)
+
+	var cordoned float64
+	if capacity := seed.Spec.Capacity; capacity != nil {
+		if capacity.Cordoned {
+			cordoned = 1
+		}
+		if capacity.MaxClusters != nil {
+			ch <- prometheus.MustNewConstMetric(sc.maxClusters, prometheus.GaugeValue, float64(*capacity.MaxClusters), seed.Name)
+		}
+		if capacity.CPU != nil {
+			ch <- prometheus.MustNewConstMetric(sc.cpuBudget, prometheus.GaugeValue, float64(capacity.CPU.MilliValue())/1000, seed.Name)
+		}
+		if capacity.Memory != nil {
+			ch <- prometheus.MustNewConstMetric(sc.memoryBudget, prometheus.GaugeValue, float64(capacity.Memory.Value()), seed.Name)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(sc.cordoned, prometheus.GaugeValue, cordoned, seed.Name)
+}
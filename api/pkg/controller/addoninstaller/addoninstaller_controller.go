@@ -25,7 +25,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
-const ControllerName = "kubermatic_addoninstaller_controller"
+const (
+	ControllerName = "kubermatic_addoninstaller_controller"
+
+	// openVPNAddonName is the name of the addon which deploys the OpenVPN client into the cluster
+	openVPNAddonName = "openvpn"
+)
 
 type Reconciler struct {
 	log              *zap.SugaredLogger
@@ -142,9 +147,46 @@ func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, clus
 		return &reconcile.Result{RequeueAfter: 1 * time.Second}, nil
 	}
 
+	// The OpenVPN client is only needed as long as the control plane uses or migrates from or to OpenVPN
+	if !cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		addonsToInstall = withoutAddon(addonsToInstall, openVPNAddonName)
+		if err := r.deleteAddon(ctx, log, cluster, openVPNAddonName); err != nil {
+			return nil, err
+		}
+	}
+
 	return nil, r.ensureAddons(ctx, log, cluster, addonsToInstall)
 }
 
+func withoutAddon(addons []string, addonName string) []string {
+	var result []string
+	for _, addon := range addons {
+		if addon != addonName {
+			result = append(result, addon)
+		}
+	}
+	return result
+}
+
+func (r *Reconciler) deleteAddon(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster, addonName string) error {
+	addon := &kubermaticv1.Addon{}
+	name := types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: addonName}
+	if err := r.Get(ctx, name, addon); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get addon %q: %v", addonName, err)
+	}
+	if addon.DeletionTimestamp != nil {
+		return nil
+	}
+	if err := r.Delete(ctx, addon); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete addon %q: %v", addonName, err)
+	}
+	log.Infow("Deleted addon which is not needed anymore", "addon", name)
+	return nil
+}
+
 func (r *Reconciler) ensureAddons(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster, addons []string) error {
 	for _, addonName := range addons {
 		addon := &kubermaticv1.Addon{}
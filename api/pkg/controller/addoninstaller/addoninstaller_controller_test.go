@@ -12,20 +12,47 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlruntimefakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
-var addons = []string{"Foo", "Bar"}
+var addons = []string{"Foo", "Bar", "openvpn"}
 
 func truePtr() *bool {
 	b := true
 	return &b
 }
 
+func expectedAddon(name, clusterName string) *kubermaticv1.Addon {
+	return &kubermaticv1.Addon{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "cluster-" + clusterName,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         "kubermatic.k8s.io/v1",
+					Kind:               "Cluster",
+					Name:               clusterName,
+					Controller:         truePtr(),
+					BlockOwnerDeletion: truePtr(),
+				},
+			},
+		},
+		Spec: kubermaticv1.AddonSpec{
+			Name: name,
+			Cluster: corev1.ObjectReference{
+				Kind: "Cluster",
+				Name: clusterName,
+			},
+		},
+	}
+}
+
 func TestCreateAddon(t *testing.T) {
 	name := "test-cluster"
 	tests := []struct {
 		name                  string
+		existingAddons        []runtime.Object
 		expectedClusterAddons []*kubermaticv1.Addon
 		cluster               *kubermaticv1.Cluster
 	}{
@@ -76,6 +103,7 @@ func TestCreateAddon(t *testing.T) {
 						},
 					},
 				},
+				expectedAddon("openvpn", name),
 			},
 			cluster: &kubermaticv1.Cluster{
 				ObjectMeta: metav1.ObjectMeta{
@@ -92,12 +120,67 @@ func TestCreateAddon(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "openvpn addon skipped with konnectivity",
+			expectedClusterAddons: []*kubermaticv1.Addon{
+				expectedAddon("Foo", name),
+				expectedAddon("Bar", name),
+			},
+			cluster: &kubermaticv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: kubermaticv1.ClusterSpec{
+					ClusterNetwork: kubermaticv1.ClusterNetworkingConfig{
+						TunnelingMode: kubermaticv1.TunnelingModeKonnectivity,
+					},
+				},
+				Status: kubermaticv1.ClusterStatus{
+					ExtendedHealth: kubermaticv1.ExtendedClusterHealth{
+						Apiserver: kubermaticv1.HealthStatusUp,
+					},
+					NamespaceName: "cluster-" + name,
+					Tunneling: &kubermaticv1.TunnelingStatus{
+						Mode:  kubermaticv1.TunnelingModeKonnectivity,
+						Phase: kubermaticv1.TunnelingEstablished,
+					},
+				},
+			},
+		},
+		{
+			name:           "openvpn addon deleted after the migration to konnectivity",
+			existingAddons: []runtime.Object{expectedAddon("openvpn", name)},
+			expectedClusterAddons: []*kubermaticv1.Addon{
+				expectedAddon("Foo", name),
+				expectedAddon("Bar", name),
+			},
+			cluster: &kubermaticv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: name,
+				},
+				Spec: kubermaticv1.ClusterSpec{
+					ClusterNetwork: kubermaticv1.ClusterNetworkingConfig{
+						TunnelingMode: kubermaticv1.TunnelingModeKonnectivity,
+					},
+				},
+				Status: kubermaticv1.ClusterStatus{
+					ExtendedHealth: kubermaticv1.ExtendedClusterHealth{
+						Apiserver: kubermaticv1.HealthStatusUp,
+					},
+					NamespaceName: "cluster-" + name,
+					Tunneling: &kubermaticv1.TunnelingStatus{
+						Mode:  kubermaticv1.TunnelingModeKonnectivity,
+						Phase: kubermaticv1.TunnelingEstablished,
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
 
 		t.Run(test.name, func(t *testing.T) {
-			objs := []runtime.Object{test.cluster}
+			objs := append([]runtime.Object{test.cluster}, test.existingAddons...)
 
 			client := ctrlruntimefakeclient.NewFakeClient(objs...)
 
@@ -111,6 +194,14 @@ func TestCreateAddon(t *testing.T) {
 				t.Fatalf("Reconciliation failed: %v", err)
 			}
 
+			addonList := &kubermaticv1.AddonList{}
+			if err := client.List(context.Background(), &ctrlruntimeclient.ListOptions{Namespace: test.cluster.Status.NamespaceName}, addonList); err != nil {
+				t.Fatalf("Failed to list addons: %v", err)
+			}
+			if len(addonList.Items) != len(test.expectedClusterAddons) {
+				t.Fatalf("Expected %d addons, got %d", len(test.expectedClusterAddons), len(addonList.Items))
+			}
+
 			for _, expectedAddon := range test.expectedClusterAddons {
 				addonFromClient := &kubermaticv1.Addon{}
 				if err := client.Get(context.Background(),
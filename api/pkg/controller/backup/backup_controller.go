@@ -359,7 +359,10 @@ func (r *Reconciler) cronjob(cluster *kubermaticv1.Cluster) reconciling.NamedCro
 			cronJob.Spec.Suspend = utilpointer.BoolPtr(false)
 			cronJob.Spec.SuccessfulJobsHistoryLimit = utilpointer.Int32Ptr(0)
 
-			endpoints := etcd.GetClientEndpoints(cluster.Status.NamespaceName)
+			// The labels allow the backup to reach the etcd through its NetworkPolicy
+			cronJob.Spec.JobTemplate.Spec.Template.Labels = resources.AppClusterLabel(resources.EtcdBackupAppLabelValue, cluster.Name, nil)
+
+			endpoints := etcd.GetClientEndpoints(cluster)
 			cronJob.Spec.JobTemplate.Spec.Template.Spec.InitContainers = []corev1.Container{
 				{
 					Name:  "backup-creator",
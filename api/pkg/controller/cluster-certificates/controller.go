@@ -0,0 +1,279 @@
+package clustercertificates
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	controllerutil "github.com/kubermatic/kubermatic/api/pkg/controller/util"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
+
+	corev1 "k8s.io/api/core/v1"
+	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ControllerName is the name of this very controller.
+	ControllerName = "kubermatic_cluster_certificates_controller"
+
+	// AnnotationNameRenewalRequested is set on secrets whose certificates must be renewed. Changing
+	// the secret makes the cluster controller reconcile it, which re-issues expiring certificates.
+	AnnotationNameRenewalRequested = "kubermatic.io/certificate-renewal-requested"
+
+	// renewalRetryPeriod is the time after which the renewal of a certificate gets requested
+	// again if it wasn't renewed yet
+	renewalRetryPeriod = time.Hour
+	// expiryCheckPeriod is the maximum interval in which the certificates of a cluster are checked
+	expiryCheckPeriod = 24 * time.Hour
+	// expiryWarningPeriod is the time before their expiry in which leaf certificates are reported as
+	// expiring, they should have been renewed long before
+	expiryWarningPeriod = 14 * 24 * time.Hour
+
+	reasonCertificatesExpiring = "CertificatesExpiring"
+)
+
+// userClusterConnectionProvider offers functions to retrieve clients for the given user clusters
+type userClusterConnectionProvider interface {
+	GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error)
+}
+
+// Reconciler renews the certificates of the cluster control planes before they expire and
+// rotates their root CAs on request
+type Reconciler struct {
+	ctrlruntimeclient.Client
+	log        *zap.SugaredLogger
+	workerName string
+	recorder   record.EventRecorder
+
+	userClusterConnProvider userClusterConnectionProvider
+
+	// rotationStageDuration is the minimum time each stage of a root CA rotation lasts
+	rotationStageDuration time.Duration
+
+	// now is used to evaluate the certificate expiries, it gets replaced in the tests
+	now func() time.Time
+}
+
+// Add creates a new cluster certificates controller
+func Add(
+	mgr manager.Manager,
+	log *zap.SugaredLogger,
+	numWorkers int,
+	workerName string,
+	userClusterConnProvider userClusterConnectionProvider,
+	rotationStageDuration time.Duration,
+) error {
+	reconciler := &Reconciler{
+		Client:                  mgr.GetClient(),
+		log:                     log.Named(ControllerName),
+		workerName:              workerName,
+		recorder:                mgr.GetRecorder(ControllerName),
+		userClusterConnProvider: userClusterConnProvider,
+		rotationStageDuration:   rotationStageDuration,
+		now:                     time.Now,
+	}
+
+	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: reconciler, MaxConcurrentReconciles: numWorkers})
+	if err != nil {
+		return err
+	}
+
+	if err := c.Watch(&source.Kind{Type: &corev1.Secret{}}, controllerutil.EnqueueClusterForNamespacedObject(mgr.GetClient())); err != nil {
+		return fmt.Errorf("failed to create watcher for secrets: %v", err)
+	}
+
+	return c.Watch(&source.Kind{Type: &kubermaticv1.Cluster{}}, &handler.EnqueueRequestForObject{})
+}
+
+func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := r.log.With("request", request)
+	log.Debug("Processing")
+
+	cluster := &kubermaticv1.Cluster{}
+	if err := r.Get(ctx, request.NamespacedName, cluster); err != nil {
+		if kubeapierrors.IsNotFound(err) {
+			log.Debug("Could not find cluster")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if cluster.Labels[kubermaticv1.WorkerNameLabelKey] != r.workerName {
+		log.Debugw(
+			"Skipping because the cluster has a different worker name set",
+			"cluster-worker-name", cluster.Labels[kubermaticv1.WorkerNameLabelKey],
+		)
+		return reconcile.Result{}, nil
+	}
+
+	if cluster.Spec.Pause {
+		log.Debug("Skipping cluster reconciling because it was set to paused")
+		return reconcile.Result{}, nil
+	}
+
+	// Nobody would re-issue the certificates of hibernated or deleted clusters
+	if cluster.DeletionTimestamp != nil || kubermaticv1helper.IsClusterHibernated(cluster) || cluster.Status.NamespaceName == "" {
+		return reconcile.Result{}, nil
+	}
+
+	// The certificates of openshift clusters are managed by the openshift controller
+	if _, isOpenshift := cluster.Annotations["kubermatic.io/openshift"]; isOpenshift {
+		return reconcile.Result{}, nil
+	}
+
+	result, err := r.reconcile(ctx, log.With("cluster", cluster.Name), cluster)
+	if err != nil {
+		log.Errorw("Reconciling failed", zap.Error(err))
+		r.recorder.Event(cluster, corev1.EventTypeWarning, "ReconcilingError", err.Error())
+	}
+	return result, err
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) (reconcile.Result, error) {
+	rotationRequeue, err := r.reconcileRootCARotation(ctx, log, cluster)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to rotate the root CA: %v", err)
+	}
+
+	renewalRequeue, expiring, err := r.reconcileRenewals(ctx, log, cluster)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to renew certificates: %v", err)
+	}
+
+	if err := r.reconcileCertificatesCondition(ctx, cluster, expiring); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to update the certificates condition: %v", err)
+	}
+
+	requeueAfter := renewalRequeue
+	if rotationRequeue > 0 && rotationRequeue < requeueAfter {
+		requeueAfter = rotationRequeue
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// reconcileRenewals requests the renewal of all leaf certificates that expire within the
+// renewal window. It returns the time after which the certificates must be checked again
+// and the certificates which are about to expire nonetheless.
+func (r *Reconciler) reconcileRenewals(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) (time.Duration, []string, error) {
+	secrets := &corev1.SecretList{}
+	if err := r.List(ctx, &ctrlruntimeclient.ListOptions{Namespace: cluster.Status.NamespaceName}, secrets); err != nil {
+		return 0, nil, fmt.Errorf("failed to list secrets: %v", err)
+	}
+
+	now := r.now()
+	requeueAfter := expiryCheckPeriod
+	var expiring []string
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		for _, storedCert := range certificates.SecretCertificates(secret) {
+			// CAs get replaced through a rotation, the cluster controller never re-issues them
+			if storedCert.Cert.IsCA {
+				if storedCert.Cert.NotAfter.Sub(now) < resources.CertificateRenewalWindow {
+					expiring = append(expiring, fmt.Sprintf("CA %s/%s expires at %s and must be rotated", secret.Name, storedCert.Key, storedCert.Cert.NotAfter.Format(time.RFC3339)))
+				}
+				continue
+			}
+			if storedCert.Cert.NotAfter.Sub(now) < expiryWarningPeriod {
+				expiring = append(expiring, fmt.Sprintf("%s/%s expires at %s", secret.Name, storedCert.Key, storedCert.Cert.NotAfter.Format(time.RFC3339)))
+			}
+
+			renewAt := storedCert.Cert.NotAfter.Add(-resources.CertificateRenewalWindow)
+			if renewAt.After(now) {
+				if until := renewAt.Sub(now); until < requeueAfter {
+					requeueAfter = until
+				}
+				continue
+			}
+
+			requested, err := r.requestRenewal(ctx, secret, now)
+			if err != nil {
+				return 0, nil, fmt.Errorf("failed to request the renewal of secret %s: %v", secret.Name, err)
+			}
+			if requested {
+				log.Infow("Requested certificate renewal", "secret", secret.Name, "key", storedCert.Key, "expiry", storedCert.Cert.NotAfter)
+				r.recorder.Eventf(cluster, corev1.EventTypeNormal, "CertificateRenewal", "Renewing certificate %s/%s which expires at %s", secret.Name, storedCert.Key, storedCert.Cert.NotAfter.Format(time.RFC3339))
+			}
+			if renewalRetryPeriod < requeueAfter {
+				requeueAfter = renewalRetryPeriod
+			}
+			// One request per secret is enough
+			break
+		}
+	}
+
+	return requeueAfter, expiring, nil
+}
+
+// reconcileCertificatesCondition reports the certificates which are about to expire in the
+// CertificatesValid condition of the cluster
+func (r *Reconciler) reconcileCertificatesCondition(ctx context.Context, cluster *kubermaticv1.Cluster, expiring []string) error {
+	status, reason, message := corev1.ConditionTrue, "", ""
+	if len(expiring) > 0 {
+		status, reason, message = corev1.ConditionFalse, reasonCertificatesExpiring, strings.Join(expiring, "; ")
+	}
+	if _, condition := kubermaticv1helper.GetClusterCondition(cluster, kubermaticv1.ClusterConditionCertificatesValid); condition != nil &&
+		condition.Status == status && condition.Reason == reason && condition.Message == message {
+		return nil
+	}
+	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		kubermaticv1helper.SetClusterCondition(c, kubermaticv1.ClusterConditionCertificatesValid, status, reason, message)
+	})
+}
+
+// requestRenewal annotates the secret unless its renewal was requested recently
+func (r *Reconciler) requestRenewal(ctx context.Context, secret *corev1.Secret, now time.Time) (bool, error) {
+	if requestedAt, err := time.Parse(time.RFC3339, secret.Annotations[AnnotationNameRenewalRequested]); err == nil && now.Sub(requestedAt) < renewalRetryPeriod {
+		return false, nil
+	}
+
+	return true, r.updateSecret(ctx, types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}, func(s *corev1.Secret) {
+		if s.Annotations == nil {
+			s.Annotations = map[string]string{}
+		}
+		s.Annotations[AnnotationNameRenewalRequested] = now.Format(time.RFC3339)
+	})
+}
+
+func (r *Reconciler) updateSecret(ctx context.Context, name types.NamespacedName, modify func(*corev1.Secret)) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, name, secret); err != nil {
+			return err
+		}
+		modify(secret)
+		return r.Update(ctx, secret)
+	})
+}
+
+func (r *Reconciler) updateCluster(ctx context.Context, cluster *kubermaticv1.Cluster, modify func(*kubermaticv1.Cluster)) error {
+	// Store it here because it may be unset later on if an update request failed
+	name := cluster.Name
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		//Get latest version
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+			return err
+		}
+		// Apply modifications
+		modify(cluster)
+		// Update the cluster
+		return r.Update(ctx, cluster)
+	})
+}
@@ -0,0 +1,268 @@
+package clustercertificates
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates/triple"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	certutil "k8s.io/client-go/util/cert"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+func init() {
+	log := kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar()
+	if err := clusterv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		log.Fatalw("failed to add clusterv1alpha1 scheme to scheme.Scheme", zap.Error(err))
+	}
+}
+
+type fakeUserClusterConnectionProvider struct {
+	client ctrlruntimeclient.Client
+}
+
+func (p *fakeUserClusterConnectionProvider) GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error) {
+	return p.client, nil
+}
+
+func newTestReconciler(client, userClusterClient ctrlruntimeclient.Client, now *time.Time) *Reconciler {
+	return &Reconciler{
+		Client:                  client,
+		log:                     kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar(),
+		recorder:                record.NewFakeRecorder(10),
+		userClusterConnProvider: &fakeUserClusterConnectionProvider{client: userClusterClient},
+		rotationStageDuration:   time.Hour,
+		now:                     func() time.Time { return *now },
+	}
+}
+
+func TestRootCARotation(t *testing.T) {
+	cluster := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "henrik",
+			Annotations: map[string]string{kubermaticv1.AnnotationNameRootCARotationRequested: "true"},
+		},
+		Status: kubermaticv1.ClusterStatus{NamespaceName: "cluster-henrik"},
+	}
+	caSecret, err := certificates.GetCACreator("root-ca")(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.CASecretName},
+	})
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	oldCACert := caSecret.Data[resources.SigningCACertSecretKey]
+
+	now := time.Now()
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "old-node", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))}}
+	machine := &clusterv1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "old-machine", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))},
+		Status:     clusterv1alpha1.MachineStatus{NodeRef: &corev1.ObjectReference{Name: node.Name}},
+	}
+
+	client := ctrlruntimefake.NewFakeClient(cluster, caSecret)
+	userClusterClient := ctrlruntimefake.NewFakeClient(node, machine)
+	r := newTestReconciler(client, userClusterClient, &now)
+
+	ctx := context.Background()
+	reconcile := func(expectedPhase kubermaticv1.RootCARotationPhase) *corev1.Secret {
+		t.Helper()
+		c := &kubermaticv1.Cluster{}
+		if err := client.Get(ctx, types.NamespacedName{Name: cluster.Name}, c); err != nil {
+			t.Fatalf("failed to get cluster: %v", err)
+		}
+		if _, err := r.reconcile(ctx, r.log, c); err != nil {
+			t.Fatalf("failed to reconcile: %v", err)
+		}
+		if err := client.Get(ctx, types.NamespacedName{Name: cluster.Name}, c); err != nil {
+			t.Fatalf("failed to get cluster: %v", err)
+		}
+		if c.Status.RootCARotation == nil || c.Status.RootCARotation.Phase != expectedPhase {
+			t.Fatalf("expected rotation phase %q, got %+v", expectedPhase, c.Status.RootCARotation)
+		}
+		if _, requested := c.Annotations[kubermaticv1.AnnotationNameRootCARotationRequested]; requested {
+			t.Fatalf("expected the rotation request annotation to be removed")
+		}
+		secret := &corev1.Secret{}
+		if err := client.Get(ctx, types.NamespacedName{Namespace: caSecret.Namespace, Name: caSecret.Name}, secret); err != nil {
+			t.Fatalf("failed to get CA secret: %v", err)
+		}
+		return secret
+	}
+	expectBundle := func(secret *corev1.Secret, expected ...[]byte) {
+		t.Helper()
+		var bundle []byte
+		for _, cert := range expected {
+			bundle = append(bundle, cert...)
+		}
+		if string(secret.Data[resources.CACertSecretKey]) != string(bundle) {
+			t.Fatalf("CA bundle does not contain the expected certificates, got:\n%s", secret.Data[resources.CACertSecretKey])
+		}
+	}
+
+	secret := reconcile(kubermaticv1.RootCARotationTrusting)
+	newCACert := secret.Data[resources.NextCACertSecretKey]
+	if len(newCACert) == 0 {
+		t.Fatalf("expected the next CA to be created")
+	}
+	expectBundle(secret, oldCACert, newCACert)
+
+	// Nothing happens before the stage ends
+	now = now.Add(30 * time.Minute)
+	expectBundle(reconcile(kubermaticv1.RootCARotationTrusting), oldCACert, newCACert)
+
+	// The stage doesn't end before the machine got replaced
+	now = now.Add(time.Hour)
+	reconcile(kubermaticv1.RootCARotationTrusting)
+	if err := userClusterClient.Delete(ctx, node); err != nil {
+		t.Fatalf("failed to delete node: %v", err)
+	}
+	// The machine is still pending without its node
+	reconcile(kubermaticv1.RootCARotationTrusting)
+	if err := userClusterClient.Delete(ctx, machine); err != nil {
+		t.Fatalf("failed to delete machine: %v", err)
+	}
+	node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "new-node", CreationTimestamp: metav1.NewTime(now)}}
+	if err := userClusterClient.Create(ctx, node); err != nil {
+		t.Fatalf("failed to create node: %v", err)
+	}
+
+	secret = reconcile(kubermaticv1.RootCARotationSigning)
+	expectBundle(secret, newCACert, oldCACert)
+	if string(secret.Data[resources.SigningCACertSecretKey]) != string(newCACert) {
+		t.Errorf("expected the new CA to sign certificates")
+	}
+	if _, exists := secret.Data[resources.NextCAKeySecretKey]; exists {
+		t.Errorf("expected the next CA key to be removed")
+	}
+	key, err := certutil.ParsePrivateKeyPEM(secret.Data[resources.CAKeySecretKey])
+	if err != nil {
+		t.Fatalf("failed to parse CA key: %v", err)
+	}
+	certs, err := certutil.ParseCertsPEM(newCACert)
+	if err != nil {
+		t.Fatalf("failed to parse new CA: %v", err)
+	}
+	if publicKey, ok := certs[0].PublicKey.(*rsa.PublicKey); !ok || publicKey.N.Cmp(key.(*rsa.PrivateKey).N) != 0 {
+		t.Errorf("expected the CA key to belong to the new CA")
+	}
+
+	// The node created during the Trusting stage must confirm the new bundle
+	now = now.Add(time.Hour)
+	reconcile(kubermaticv1.RootCARotationSigning)
+	bundleHash := sha256.Sum256(secret.Data[resources.CACertSecretKey])
+	node.Annotations = map[string]string{AnnotationNameRootCABundleHash: hex.EncodeToString(bundleHash[:])}
+	if err := userClusterClient.Update(ctx, node); err != nil {
+		t.Fatalf("failed to update node: %v", err)
+	}
+	expectBundle(reconcile(kubermaticv1.RootCARotationCompleted), newCACert)
+}
+
+func TestRenewal(t *testing.T) {
+	ca, err := triple.NewCA("root-ca")
+	if err != nil {
+		t.Fatalf("failed to create CA: %v", err)
+	}
+	clientKp, err := triple.NewClientKeyPair(ca, "admin", nil)
+	if err != nil {
+		t.Fatalf("failed to create client certificate: %v", err)
+	}
+
+	cluster := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "henrik"},
+		Status:     kubermaticv1.ClusterStatus{NamespaceName: "cluster-henrik"},
+	}
+	caSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.CASecretName},
+		Data:       map[string][]byte{resources.CACertSecretKey: certutil.EncodeCertPEM(ca.Cert)},
+	}
+	clientSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: "client"},
+		Data:       map[string][]byte{"client.crt": certutil.EncodeCertPEM(clientKp.Cert)},
+	}
+	fakeClient := ctrlruntimefake.NewFakeClient(cluster, caSecret, clientSecret)
+
+	ctx := context.Background()
+	now := clientKp.Cert.NotAfter.Add(-resources.CertificateRenewalWindow - 2*time.Hour)
+	r := newTestReconciler(fakeClient, ctrlruntimefake.NewFakeClient(), &now)
+	renewalRequested := func() string {
+		t.Helper()
+		secret := &corev1.Secret{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: clientSecret.Namespace, Name: clientSecret.Name}, secret); err != nil {
+			t.Fatalf("failed to get secret: %v", err)
+		}
+		return secret.Annotations[AnnotationNameRenewalRequested]
+	}
+
+	result, err := r.reconcile(ctx, r.log, cluster)
+	if err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	if requested := renewalRequested(); requested != "" {
+		t.Fatalf("expected no renewal outside of the renewal window, got %q", requested)
+	}
+	if result.RequeueAfter != 2*time.Hour {
+		t.Errorf("expected a requeue when the certificate enters the renewal window, got %v", result.RequeueAfter)
+	}
+
+	now = now.Add(3 * time.Hour)
+	if _, err := r.reconcile(ctx, r.log, cluster); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	if requested := renewalRequested(); requested != now.Format(time.RFC3339) {
+		t.Fatalf("expected the renewal to be requested, got %q", requested)
+	}
+
+	// The renewal is requested again only after the retry period
+	previousRequest := now
+	now = now.Add(renewalRetryPeriod / 2)
+	if _, err := r.reconcile(ctx, r.log, cluster); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	if requested := renewalRequested(); requested != previousRequest.Format(time.RFC3339) {
+		t.Fatalf("expected the renewal not to be requested again, got %q", requested)
+	}
+	certificatesValid := func() corev1.ConditionStatus {
+		t.Helper()
+		c := &kubermaticv1.Cluster{}
+		if err := fakeClient.Get(ctx, types.NamespacedName{Name: cluster.Name}, c); err != nil {
+			t.Fatalf("failed to get cluster: %v", err)
+		}
+		_, condition := kubermaticv1helper.GetClusterCondition(c, kubermaticv1.ClusterConditionCertificatesValid)
+		if condition == nil {
+			t.Fatalf("expected the cluster to have the %s condition", kubermaticv1.ClusterConditionCertificatesValid)
+		}
+		return condition.Status
+	}
+	if status := certificatesValid(); status != corev1.ConditionTrue {
+		t.Errorf("expected the certificates to be valid, got condition status %s", status)
+	}
+
+	// The certificate is reported as expiring when it didn't get renewed in time
+	now = clientKp.Cert.NotAfter.Add(-expiryWarningPeriod / 2)
+	if _, err := r.reconcile(ctx, r.log, cluster); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+	if status := certificatesValid(); status != corev1.ConditionFalse {
+		t.Errorf("expected the certificates to be reported as expiring, got condition status %s", status)
+	}
+}
@@ -0,0 +1,228 @@
+package clustercertificates
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates/triple"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	certutil "k8s.io/client-go/util/cert"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+const (
+	// AnnotationNameRootCABundleHash confirms that a node of the user cluster received the current CA bundle
+	// without being replaced. Its value is the hex encoded SHA-256 hash of the CA bundle. Nothing distributes
+	// the CA bundle to existing nodes, so operators set it by hand after doing so, see docs/root-ca-rotation.md.
+	AnnotationNameRootCABundleHash = "kubermatic.io/root-ca-bundle-hash"
+
+	// nodeCheckPeriod is the interval in which a rotation that waits for nodes checks them again
+	nodeCheckPeriod = 5 * time.Minute
+)
+
+// The root CA is rotated in three stages, so that nothing ever needs to trust a CA it doesn't know yet:
+//   1. Trusting: a new CA gets created and added to the CA bundle, the old CA still signs
+//   2. Signing: the new CA signs and all certificates get re-issued, the old CA is still trusted
+//   3. Completed: the old CA gets removed from the CA bundle
+// Nodes only receive the CA bundle when they get created, so a stage only ends once all nodes
+// were replaced or confirmed the current CA bundle since it began. The stage duration is only the
+// minimum time a stage lasts.
+
+// reconcileRootCARotation advances the rotation of the root CA. It returns the time after which
+// the next stage begins, or zero if no rotation is in progress.
+func (r *Reconciler) reconcileRootCARotation(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) (time.Duration, error) {
+	status := cluster.Status.RootCARotation
+	if !status.InProgress() {
+		if _, requested := cluster.Annotations[kubermaticv1.AnnotationNameRootCARotationRequested]; !requested {
+			return 0, nil
+		}
+		if err := r.startRootCARotation(ctx, cluster); err != nil {
+			return 0, err
+		}
+		log.Info("Started root CA rotation")
+		r.recorder.Event(cluster, corev1.EventTypeNormal, "RootCARotationStarted", "Created a new root CA which is trusted in addition to the old one")
+		return r.rotationStageDuration, nil
+	}
+
+	if remaining := status.LastTransitionTime.Add(r.rotationStageDuration).Sub(r.now()); remaining > 0 {
+		return remaining, nil
+	}
+
+	pending, err := r.pendingNodes(ctx, cluster, status.LastTransitionTime.Time)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check the nodes: %v", err)
+	}
+	if len(pending) > 0 {
+		log.Infow("Root CA rotation: waiting for nodes to be replaced", "phase", status.Phase, "nodes", pending)
+		return nodeCheckPeriod, nil
+	}
+
+	switch status.Phase {
+	case kubermaticv1.RootCARotationTrusting:
+		if err := r.modifyRootCA(ctx, cluster, swapRootCA); err != nil {
+			return 0, err
+		}
+		if err := r.setRootCARotationPhase(ctx, cluster, kubermaticv1.RootCARotationSigning); err != nil {
+			return 0, err
+		}
+		log.Info("Root CA rotation: the new CA signs certificates now")
+		r.recorder.Event(cluster, corev1.EventTypeNormal, "RootCARotationSigning", "The new root CA signs certificates now, nodes must be replaced again")
+		return r.rotationStageDuration, nil
+
+	case kubermaticv1.RootCARotationSigning:
+		if err := r.modifyRootCA(ctx, cluster, dropOldRootCA); err != nil {
+			return 0, err
+		}
+		if err := r.setRootCARotationPhase(ctx, cluster, kubermaticv1.RootCARotationCompleted); err != nil {
+			return 0, err
+		}
+		log.Info("Completed root CA rotation")
+		r.recorder.Event(cluster, corev1.EventTypeNormal, "RootCARotationCompleted", "The old root CA is not trusted anymore")
+	}
+
+	return 0, nil
+}
+
+// pendingNodes returns the Machines and Nodes of the user cluster that were neither created after the
+// given time nor confirmed the current CA bundle. A Machine counts as done when its Node does.
+func (r *Reconciler) pendingNodes(ctx context.Context, cluster *kubermaticv1.Cluster, since time.Time) ([]string, error) {
+	caSecret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.CASecretName}, caSecret); err != nil {
+		return nil, fmt.Errorf("failed to get the CA secret: %v", err)
+	}
+	bundleHash := sha256.Sum256(caSecret.Data[resources.CACertSecretKey])
+	confirmation := hex.EncodeToString(bundleHash[:])
+
+	client, err := r.userClusterConnProvider.GetClient(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user cluster client: %v", err)
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, nodes); err != nil {
+		return nil, fmt.Errorf("failed to list Nodes: %v", err)
+	}
+	nodeNames := sets.NewString()
+	var pending []string
+	for _, node := range nodes.Items {
+		nodeNames.Insert(node.Name)
+		if node.CreationTimestamp.Time.After(since) || node.Annotations[AnnotationNameRootCABundleHash] == confirmation {
+			continue
+		}
+		pending = append(pending, "node/"+node.Name)
+	}
+
+	machines := &clusterv1alpha1.MachineList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, machines); err != nil {
+		return nil, fmt.Errorf("failed to list Machines: %v", err)
+	}
+	for _, machine := range machines.Items {
+		if machine.CreationTimestamp.Time.After(since) {
+			continue
+		}
+		// Machines with a Node depend on the Node, which got checked already
+		if machine.Status.NodeRef != nil && nodeNames.Has(machine.Status.NodeRef.Name) {
+			continue
+		}
+		pending = append(pending, fmt.Sprintf("machine/%s/%s", machine.Namespace, machine.Name))
+	}
+
+	return pending, nil
+}
+
+func (r *Reconciler) startRootCARotation(ctx context.Context, cluster *kubermaticv1.Cluster) error {
+	if err := r.modifyRootCA(ctx, cluster, addNextRootCA); err != nil {
+		return err
+	}
+	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		delete(c.Annotations, kubermaticv1.AnnotationNameRootCARotationRequested)
+		c.Status.RootCARotation = &kubermaticv1.RootCARotationStatus{
+			Phase:              kubermaticv1.RootCARotationTrusting,
+			LastTransitionTime: metav1.NewTime(r.now()),
+		}
+	})
+}
+
+func (r *Reconciler) setRootCARotationPhase(ctx context.Context, cluster *kubermaticv1.Cluster, phase kubermaticv1.RootCARotationPhase) error {
+	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		c.Status.RootCARotation = &kubermaticv1.RootCARotationStatus{
+			Phase:              phase,
+			LastTransitionTime: metav1.NewTime(r.now()),
+		}
+	})
+}
+
+// modifyRootCA applies the given modification to the secret of the root CA. The certificates
+// of the CA bundle are passed with the signing certificate first.
+func (r *Reconciler) modifyRootCA(ctx context.Context, cluster *kubermaticv1.Cluster, modify func(*corev1.Secret, []*x509.Certificate) error) error {
+	var modifyErr error
+	name := types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.CASecretName}
+	err := r.updateSecret(ctx, name, func(secret *corev1.Secret) {
+		var certs []*x509.Certificate
+		certs, modifyErr = certutil.ParseCertsPEM(secret.Data[resources.CACertSecretKey])
+		if modifyErr != nil {
+			modifyErr = fmt.Errorf("failed to parse the CA bundle: %v", modifyErr)
+			return
+		}
+		modifyErr = modify(secret, certs)
+	})
+	if modifyErr != nil {
+		return modifyErr
+	}
+	return err
+}
+
+// addNextRootCA creates the next CA and trusts it in addition to the current one
+func addNextRootCA(secret *corev1.Secret, certs []*x509.Certificate) error {
+	// The next CA might exist already when updating the cluster failed before
+	nextCertPEM, exists := secret.Data[resources.NextCACertSecretKey]
+	if !exists {
+		ca, err := triple.NewCA(certs[0].Subject.CommonName)
+		if err != nil {
+			return fmt.Errorf("failed to create the next CA: %v", err)
+		}
+		nextCertPEM = certutil.EncodeCertPEM(ca.Cert)
+		secret.Data[resources.NextCAKeySecretKey] = certutil.EncodePrivateKeyPEM(ca.Key)
+		secret.Data[resources.NextCACertSecretKey] = nextCertPEM
+	}
+
+	secret.Data[resources.CACertSecretKey] = append(certutil.EncodeCertPEM(certs[0]), nextCertPEM...)
+	return nil
+}
+
+// swapRootCA makes the next CA sign certificates while the old CA stays trusted
+func swapRootCA(secret *corev1.Secret, certs []*x509.Certificate) error {
+	nextKeyPEM, keyExists := secret.Data[resources.NextCAKeySecretKey]
+	nextCertPEM, certExists := secret.Data[resources.NextCACertSecretKey]
+	if !keyExists || !certExists {
+		// Swapped already, but updating the cluster failed afterwards
+		return nil
+	}
+
+	secret.Data[resources.CAKeySecretKey] = nextKeyPEM
+	secret.Data[resources.SigningCACertSecretKey] = nextCertPEM
+	secret.Data[resources.CACertSecretKey] = append(append([]byte{}, nextCertPEM...), certutil.EncodeCertPEM(certs[0])...)
+	delete(secret.Data, resources.NextCAKeySecretKey)
+	delete(secret.Data, resources.NextCACertSecretKey)
+	return nil
+}
+
+// dropOldRootCA stops trusting all CAs besides the signing one
+func dropOldRootCA(secret *corev1.Secret, certs []*x509.Certificate) error {
+	secret.Data[resources.CACertSecretKey] = certutil.EncodeCertPEM(certs[0])
+	return nil
+}
@@ -0,0 +1,365 @@
+package clusterencryption
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	controllerutil "github.com/kubermatic/kubermatic/api/pkg/controller/util"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ControllerName is the name of this very controller.
+	ControllerName = "kubermatic_cluster_encryption_controller"
+
+	// progressCheckPeriod is the interval in which the rollout of the apiserver is checked
+	progressCheckPeriod = 10 * time.Second
+)
+
+// The key is rotated in three phases, so that no apiserver ever needs to read Secrets with a key it
+// doesn't know yet:
+//   1. Preparing: a new key gets added to the configuration, it only decrypts
+//   2. Reencrypting: the new key encrypts and all Secrets get rewritten, the old key still decrypts
+//   3. Encrypted: the old key gets removed from the configuration
+// Enabling the encryption only needs the Reencrypting phase, as the Secrets just have to be rewritten
+// with what the apiservers are configured with.
+
+// userClusterConnectionProvider offers functions to retrieve clients for the given user clusters
+type userClusterConnectionProvider interface {
+	GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error)
+}
+
+// Reconciler encrypts the Secrets of user clusters and rotates their keys
+type Reconciler struct {
+	ctrlruntimeclient.Client
+	log                     *zap.SugaredLogger
+	workerName              string
+	recorder                record.EventRecorder
+	userClusterConnProvider userClusterConnectionProvider
+
+	// now is used for the transition times, it gets replaced in the tests
+	now func() time.Time
+}
+
+// Add creates a new cluster encryption controller
+func Add(
+	mgr manager.Manager,
+	log *zap.SugaredLogger,
+	numWorkers int,
+	workerName string,
+	userClusterConnProvider userClusterConnectionProvider,
+) error {
+	reconciler := &Reconciler{
+		Client:                  mgr.GetClient(),
+		log:                     log.Named(ControllerName),
+		workerName:              workerName,
+		recorder:                mgr.GetRecorder(ControllerName),
+		userClusterConnProvider: userClusterConnProvider,
+		now:                     time.Now,
+	}
+
+	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: reconciler, MaxConcurrentReconciles: numWorkers})
+	if err != nil {
+		return err
+	}
+
+	// The phases advance once the apiservers were rolled out with the new configuration
+	if err := c.Watch(&source.Kind{Type: &appsv1.Deployment{}}, controllerutil.EnqueueClusterForNamespacedObject(mgr.GetClient())); err != nil {
+		return fmt.Errorf("failed to create watcher for deployments: %v", err)
+	}
+
+	return c.Watch(&source.Kind{Type: &kubermaticv1.Cluster{}}, &handler.EnqueueRequestForObject{})
+}
+
+func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := r.log.With("request", request)
+	log.Debug("Processing")
+
+	cluster := &kubermaticv1.Cluster{}
+	if err := r.Get(ctx, request.NamespacedName, cluster); err != nil {
+		if kubeapierrors.IsNotFound(err) {
+			log.Debug("Could not find cluster")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if cluster.Labels[kubermaticv1.WorkerNameLabelKey] != r.workerName {
+		log.Debugw(
+			"Skipping because the cluster has a different worker name set",
+			"cluster-worker-name", cluster.Labels[kubermaticv1.WorkerNameLabelKey],
+		)
+		return reconcile.Result{}, nil
+	}
+
+	if cluster.Spec.Pause {
+		log.Debug("Skipping cluster reconciling because it was set to paused")
+		return reconcile.Result{}, nil
+	}
+
+	// The Secrets can only be rewritten while the apiservers are running
+	if cluster.Spec.EncryptionAtRest == nil || cluster.DeletionTimestamp != nil || kubermaticv1helper.IsClusterHibernated(cluster) || cluster.Status.NamespaceName == "" {
+		return reconcile.Result{}, nil
+	}
+
+	// The apiservers of openshift clusters are not configured by the cluster controller
+	if _, isOpenshift := cluster.Annotations["kubermatic.io/openshift"]; isOpenshift {
+		return reconcile.Result{}, nil
+	}
+
+	result, err := r.reconcile(ctx, log.With("cluster", cluster.Name), cluster)
+	if err != nil {
+		log.Errorw("Reconciling failed", zap.Error(err))
+		r.recorder.Event(cluster, corev1.EventTypeWarning, "ReconcilingError", err.Error())
+	}
+	return result, err
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) (reconcile.Result, error) {
+	status := cluster.Status.EncryptionAtRest
+	_, rotationRequested := cluster.Annotations[kubermaticv1.AnnotationNameEncryptionKeyRotationRequested]
+
+	switch {
+	case status == nil:
+		// The Secrets which were written before the encryption was enabled are stored in plain text
+		log.Info("Encrypting Secrets")
+		return reconcile.Result{Requeue: true}, r.setPhase(ctx, cluster, kubermaticv1.EncryptionReencrypting)
+
+	case status.Phase == kubermaticv1.EncryptionEncrypted && rotationRequested:
+		if err := r.updateKeys(ctx, cluster, addNextKey); err != nil {
+			return reconcile.Result{}, err
+		}
+		log.Info("Started encryption key rotation")
+		r.recorder.Event(cluster, corev1.EventTypeNormal, "EncryptionKeyRotationStarted", "Created a new encryption key which decrypts in addition to the current one")
+		return reconcile.Result{RequeueAfter: progressCheckPeriod}, r.setPhase(ctx, cluster, kubermaticv1.EncryptionPreparing)
+
+	case status.Phase == kubermaticv1.EncryptionPreparing:
+		rolledOut, err := r.apiserverRolledOut(ctx, cluster)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !rolledOut {
+			return reconcile.Result{RequeueAfter: progressCheckPeriod}, nil
+		}
+		if err := r.updateKeys(ctx, cluster, swapKeys); err != nil {
+			return reconcile.Result{}, err
+		}
+		log.Info("Encryption key rotation: the new key encrypts now")
+		return reconcile.Result{RequeueAfter: progressCheckPeriod}, r.setPhase(ctx, cluster, kubermaticv1.EncryptionReencrypting)
+
+	case status.Phase == kubermaticv1.EncryptionReencrypting:
+		rolledOut, err := r.apiserverRolledOut(ctx, cluster)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !rolledOut {
+			return reconcile.Result{RequeueAfter: progressCheckPeriod}, nil
+		}
+		count, err := r.reencryptSecrets(ctx, cluster)
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to re-encrypt the secrets: %v", err)
+		}
+
+		if err := r.updateKeys(ctx, cluster, dropPreviousKey); err != nil {
+			return reconcile.Result{}, err
+		}
+		secret, err := r.getEncryptionSecret(ctx, cluster)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		keyName := apiserver.EncryptionKeyName(secret.Data[resources.EncryptionKeySecretKey])
+		if err := r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+			c.Status.EncryptionAtRest = &kubermaticv1.EncryptionAtRestStatus{
+				Phase:              kubermaticv1.EncryptionEncrypted,
+				KeyName:            keyName,
+				ReencryptedSecrets: count,
+				LastTransitionTime: metav1.NewTime(r.now()),
+			}
+		}); err != nil {
+			return reconcile.Result{}, err
+		}
+		log.Infow("Encrypted Secrets", "secrets", count)
+		r.recorder.Eventf(cluster, corev1.EventTypeNormal, "SecretsEncrypted", "Encrypted %d Secrets with the current key", count)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// reencryptSecrets rewrites all Secrets of the user cluster, so that the apiserver stores them with
+// the current key. It returns the number of rewritten Secrets.
+func (r *Reconciler) reencryptSecrets(ctx context.Context, cluster *kubermaticv1.Cluster) (int, error) {
+	client, err := r.userClusterConnProvider.GetClient(cluster)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user cluster client: %v", err)
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, secrets); err != nil {
+		return 0, fmt.Errorf("failed to list secrets: %v", err)
+	}
+
+	count := 0
+	for i := range secrets.Items {
+		// An unchanged update is enough, the apiserver writes the Secret to etcd again.
+		// Secrets which were changed or deleted in the meantime are encrypted already.
+		if err := client.Update(ctx, &secrets.Items[i]); err != nil {
+			if kubeapierrors.IsConflict(err) || kubeapierrors.IsNotFound(err) {
+				continue
+			}
+			return count, fmt.Errorf("failed to update secret %s/%s: %v", secrets.Items[i].Namespace, secrets.Items[i].Name, err)
+		}
+		count++
+	}
+	return count, nil
+}
+
+// apiserverRolledOut returns whether all apiserver pods run with the current encryption configuration
+func (r *Reconciler) apiserverRolledOut(ctx context.Context, cluster *kubermaticv1.Cluster) (bool, error) {
+	secret, err := r.getEncryptionSecret(ctx, cluster)
+	if err != nil {
+		if kubeapierrors.IsNotFound(err) {
+			// The cluster controller didn't create it yet
+			return false, nil
+		}
+		return false, err
+	}
+
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.ApiserverDeploymentName}, deployment); err != nil {
+		if kubeapierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get the apiserver deployment: %v", err)
+	}
+
+	revisionLabel := fmt.Sprintf("%s-secret-revision", resources.EncryptionConfigurationSecretName)
+	if revision, exists := deployment.Spec.Template.Labels[revisionLabel]; !exists || revision != secret.ResourceVersion {
+		return false, nil
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	return status.ObservedGeneration >= deployment.Generation &&
+		status.UpdatedReplicas == replicas &&
+		status.Replicas == replicas &&
+		status.AvailableReplicas == replicas, nil
+}
+
+func (r *Reconciler) getEncryptionSecret(ctx context.Context, cluster *kubermaticv1.Cluster) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.EncryptionConfigurationSecretName}, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// updateKeys modifies the keys in the encryption secret and renders the configuration of the apiserver for them
+func (r *Reconciler) updateKeys(ctx context.Context, cluster *kubermaticv1.Cluster, modify func(map[string][]byte) error) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		secret, err := r.getEncryptionSecret(ctx, cluster)
+		if err != nil {
+			return fmt.Errorf("failed to get the encryption secret: %v", err)
+		}
+		if secret.Data == nil {
+			secret.Data = map[string][]byte{}
+		}
+		if err := modify(secret.Data); err != nil {
+			return err
+		}
+		config, err := apiserver.EncryptionConfiguration(cluster.Spec.EncryptionAtRest, secret.Data)
+		if err != nil {
+			return err
+		}
+		secret.Data[resources.EncryptionConfigurationSecretKey] = config
+		return r.Update(ctx, secret)
+	})
+}
+
+// addNextKey adds a new key which only decrypts. A next key which exists already is kept, so that
+// apiservers which might have picked it up can still read what they wrote.
+func addNextKey(data map[string][]byte) error {
+	if _, exists := data[resources.NextEncryptionKeySecretKey]; exists {
+		return nil
+	}
+	key, err := apiserver.NewEncryptionKey()
+	if err != nil {
+		return err
+	}
+	data[resources.NextEncryptionKeySecretKey] = key
+	return nil
+}
+
+// swapKeys makes the next key the current one, the current key only decrypts from now on
+func swapKeys(data map[string][]byte) error {
+	next, exists := data[resources.NextEncryptionKeySecretKey]
+	if !exists {
+		// Swapped already
+		return nil
+	}
+	data[resources.PreviousEncryptionKeySecretKey] = data[resources.EncryptionKeySecretKey]
+	data[resources.EncryptionKeySecretKey] = next
+	delete(data, resources.NextEncryptionKeySecretKey)
+	return nil
+}
+
+// dropPreviousKey removes the old key once nothing is encrypted with it anymore
+func dropPreviousKey(data map[string][]byte) error {
+	delete(data, resources.PreviousEncryptionKeySecretKey)
+	return nil
+}
+
+// setPhase sets the encryption phase of the cluster and removes the rotation request. The key name
+// and the number of Secrets keep describing the last completed encryption until the next one completes.
+func (r *Reconciler) setPhase(ctx context.Context, cluster *kubermaticv1.Cluster, phase kubermaticv1.EncryptionPhase) error {
+	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		delete(c.Annotations, kubermaticv1.AnnotationNameEncryptionKeyRotationRequested)
+		status := &kubermaticv1.EncryptionAtRestStatus{}
+		if c.Status.EncryptionAtRest != nil {
+			status = c.Status.EncryptionAtRest.DeepCopy()
+		}
+		status.Phase = phase
+		status.LastTransitionTime = metav1.NewTime(r.now())
+		c.Status.EncryptionAtRest = status
+	})
+}
+
+func (r *Reconciler) updateCluster(ctx context.Context, cluster *kubermaticv1.Cluster, modify func(*kubermaticv1.Cluster)) error {
+	// Store it here because it may be unset later on if an update request failed
+	name := cluster.Name
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		//Get latest version
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+			return err
+		}
+		// Apply modifications
+		modify(cluster)
+		// Update the cluster
+		return r.Update(ctx, cluster)
+	})
+}
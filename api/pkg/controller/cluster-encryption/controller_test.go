@@ -0,0 +1,179 @@
+package clusterencryption
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeUserClusterConnectionProvider struct {
+	client ctrlruntimeclient.Client
+}
+
+func (p *fakeUserClusterConnectionProvider) GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error) {
+	return p.client, nil
+}
+
+// resourceVersioningClient sets new resource versions on updates like the apiserver does, which the
+// rollout checks rely on
+type resourceVersioningClient struct {
+	ctrlruntimeclient.Client
+	version int
+}
+
+func (c *resourceVersioningClient) Update(ctx context.Context, obj runtime.Object) error {
+	if o, ok := obj.(metav1.Object); ok {
+		c.version++
+		o.SetResourceVersion(strconv.Itoa(c.version))
+	}
+	return c.Client.Update(ctx, obj)
+}
+
+func TestEncryptionAndKeyRotation(t *testing.T) {
+	cluster := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "henrik"},
+		Spec: kubermaticv1.ClusterSpec{
+			EncryptionAtRest: &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderAESCBC},
+		},
+		Status: kubermaticv1.ClusterStatus{NamespaceName: "cluster-henrik"},
+	}
+	initialKey, err := apiserver.NewEncryptionKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptionSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.EncryptionConfigurationSecretName},
+		Data:       map[string][]byte{resources.EncryptionKeySecretKey: initialKey},
+	}
+	apiserverDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.ApiserverDeploymentName},
+		Spec:       appsv1.DeploymentSpec{Replicas: utilpointer.Int32Ptr(2)},
+		Status:     appsv1.DeploymentStatus{Replicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+	}
+	userClusterSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "token"},
+	}
+
+	client := &resourceVersioningClient{Client: ctrlruntimefake.NewFakeClient(cluster, encryptionSecret, apiserverDeployment)}
+	userClusterClient := ctrlruntimefake.NewFakeClient(userClusterSecret)
+	r := &Reconciler{
+		Client:                  client,
+		log:                     kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar(),
+		recorder:                record.NewFakeRecorder(10),
+		userClusterConnProvider: &fakeUserClusterConnectionProvider{client: userClusterClient},
+		now:                     time.Now,
+	}
+
+	ctx := context.Background()
+	get := func(key types.NamespacedName, obj runtime.Object) {
+		t.Helper()
+		if err := client.Get(ctx, key, obj); err != nil {
+			t.Fatalf("failed to get %s: %v", key, err)
+		}
+	}
+	// rollOut simulates the cluster controller and the apiserver deployment picking up the secret
+	rollOut := func() {
+		t.Helper()
+		secret := &corev1.Secret{}
+		get(types.NamespacedName{Namespace: encryptionSecret.Namespace, Name: encryptionSecret.Name}, secret)
+		deployment := &appsv1.Deployment{}
+		get(types.NamespacedName{Namespace: apiserverDeployment.Namespace, Name: apiserverDeployment.Name}, deployment)
+		deployment.Spec.Template.Labels = map[string]string{resources.EncryptionConfigurationSecretName + "-secret-revision": secret.ResourceVersion}
+		if err := client.Update(ctx, deployment); err != nil {
+			t.Fatalf("failed to update the apiserver deployment: %v", err)
+		}
+	}
+	reconcile := func(expectedPhase kubermaticv1.EncryptionPhase) (*kubermaticv1.Cluster, *corev1.Secret) {
+		t.Helper()
+		c := &kubermaticv1.Cluster{}
+		get(types.NamespacedName{Name: cluster.Name}, c)
+		if _, err := r.reconcile(ctx, r.log, c); err != nil {
+			t.Fatalf("failed to reconcile: %v", err)
+		}
+		get(types.NamespacedName{Name: cluster.Name}, c)
+		if c.Status.EncryptionAtRest == nil || c.Status.EncryptionAtRest.Phase != expectedPhase {
+			t.Fatalf("expected encryption phase %q, got %+v", expectedPhase, c.Status.EncryptionAtRest)
+		}
+		secret := &corev1.Secret{}
+		get(types.NamespacedName{Namespace: encryptionSecret.Namespace, Name: encryptionSecret.Name}, secret)
+		return c, secret
+	}
+
+	// Enabling the encryption rewrites the Secrets once the apiservers use the configuration
+	reconcile(kubermaticv1.EncryptionReencrypting)
+	reconcile(kubermaticv1.EncryptionReencrypting)
+	rollOut()
+	c, secret := reconcile(kubermaticv1.EncryptionEncrypted)
+	if c.Status.EncryptionAtRest.KeyName != apiserver.EncryptionKeyName(initialKey) {
+		t.Errorf("expected the key name %s, got %s", apiserver.EncryptionKeyName(initialKey), c.Status.EncryptionAtRest.KeyName)
+	}
+	if c.Status.EncryptionAtRest.ReencryptedSecrets != 1 {
+		t.Errorf("expected one re-encrypted secret, got %d", c.Status.EncryptionAtRest.ReencryptedSecrets)
+	}
+
+	// Rotate the key
+	if c.Annotations == nil {
+		c.Annotations = map[string]string{}
+	}
+	c.Annotations[kubermaticv1.AnnotationNameEncryptionKeyRotationRequested] = "true"
+	if err := client.Update(ctx, c); err != nil {
+		t.Fatalf("failed to request the rotation: %v", err)
+	}
+	c, secret = reconcile(kubermaticv1.EncryptionPreparing)
+	if _, requested := c.Annotations[kubermaticv1.AnnotationNameEncryptionKeyRotationRequested]; requested {
+		t.Errorf("expected the rotation request annotation to be removed")
+	}
+	nextKey := secret.Data[resources.NextEncryptionKeySecretKey]
+	if nextKey == nil || !bytes.Equal(secret.Data[resources.EncryptionKeySecretKey], initialKey) {
+		t.Fatalf("expected a next key in addition to the initial key, got the keys %v", keys(secret))
+	}
+	if !bytes.Contains(secret.Data[resources.EncryptionConfigurationSecretKey], []byte(apiserver.EncryptionKeyName(nextKey))) {
+		t.Errorf("expected the next key in the configuration:\n%s", secret.Data[resources.EncryptionConfigurationSecretKey])
+	}
+
+	// The new key must not encrypt before all apiservers can decrypt with it
+	reconcile(kubermaticv1.EncryptionPreparing)
+	rollOut()
+	_, secret = reconcile(kubermaticv1.EncryptionReencrypting)
+	if !bytes.Equal(secret.Data[resources.EncryptionKeySecretKey], nextKey) || !bytes.Equal(secret.Data[resources.PreviousEncryptionKeySecretKey], initialKey) {
+		t.Fatalf("expected the keys to be swapped, got the keys %v", keys(secret))
+	}
+
+	rollOut()
+	c, secret = reconcile(kubermaticv1.EncryptionEncrypted)
+	if _, exists := secret.Data[resources.PreviousEncryptionKeySecretKey]; exists {
+		t.Errorf("expected the previous key to be removed")
+	}
+	if bytes.Contains(secret.Data[resources.EncryptionConfigurationSecretKey], []byte(apiserver.EncryptionKeyName(initialKey))) {
+		t.Errorf("expected the initial key to be removed from the configuration:\n%s", secret.Data[resources.EncryptionConfigurationSecretKey])
+	}
+	if c.Status.EncryptionAtRest.KeyName != apiserver.EncryptionKeyName(nextKey) {
+		t.Errorf("expected the key name %s, got %s", apiserver.EncryptionKeyName(nextKey), c.Status.EncryptionAtRest.KeyName)
+	}
+}
+
+func keys(secret *corev1.Secret) []string {
+	var keys []string
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	return keys
+}
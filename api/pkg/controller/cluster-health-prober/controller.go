@@ -0,0 +1,185 @@
+package clusterhealthprober
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ControllerName is the name of this very controller.
+	ControllerName = "kubermatic_cluster_health_prober_controller"
+)
+
+type userClusterConnectionProvider interface {
+	GetK8sClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (kubernetes.Interface, error)
+}
+
+// probe checks the health of a single component of a cluster, its result is stored as cluster condition
+type probe struct {
+	conditionType kubermaticv1.ClusterConditionType
+	run           func(ctx context.Context, cluster *kubermaticv1.Cluster) probeResult
+}
+
+type probeResult struct {
+	status  corev1.ConditionStatus
+	reason  string
+	message string
+}
+
+// Reconciler periodically probes the control plane components of the clusters and stores the
+// results, including the reasons of failures, as conditions of the clusters
+type Reconciler struct {
+	ctrlruntimeclient.Client
+	log                     *zap.SugaredLogger
+	workerName              string
+	userClusterConnProvider userClusterConnectionProvider
+
+	// interval is the time between two probes of a cluster
+	interval time.Duration
+	// timeout is the time after which a single probe fails
+	timeout time.Duration
+
+	etcdTransports *etcdgateway.TransportCache
+
+	probes []probe
+}
+
+// Add creates a new cluster health prober controller
+func Add(
+	mgr manager.Manager,
+	log *zap.SugaredLogger,
+	numWorkers int,
+	workerName string,
+	userClusterConnProvider userClusterConnectionProvider,
+	interval time.Duration,
+	timeout time.Duration,
+) error {
+	reconciler := &Reconciler{
+		Client:                  mgr.GetClient(),
+		log:                     log.Named(ControllerName),
+		workerName:              workerName,
+		userClusterConnProvider: userClusterConnProvider,
+		interval:                interval,
+		timeout:                 timeout,
+		etcdTransports:          etcdgateway.NewTransportCache(),
+	}
+	reconciler.probes = []probe{
+		{conditionType: kubermaticv1.ClusterConditionApiserverHealthy, run: reconciler.probeApiserver},
+		{conditionType: kubermaticv1.ClusterConditionEtcdHealthy, run: reconciler.probeEtcd},
+		{conditionType: kubermaticv1.ClusterConditionMachineControllerWebhookReachable, run: reconciler.probeMachineControllerWebhook},
+		{conditionType: kubermaticv1.ClusterConditionOpenVPNTunnelConnected, run: reconciler.probeOpenVPNTunnel},
+	}
+
+	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: reconciler, MaxConcurrentReconciles: numWorkers})
+	if err != nil {
+		return err
+	}
+
+	ignoreUpdates := predicate.Funcs{
+		UpdateFunc: func(event.UpdateEvent) bool {
+			return false
+		},
+	}
+	return c.Watch(&source.Kind{Type: &kubermaticv1.Cluster{}}, &handler.EnqueueRequestForObject{}, ignoreUpdates)
+}
+
+func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := r.log.With("request", request)
+	log.Debug("Processing")
+
+	cluster := &kubermaticv1.Cluster{}
+	if err := r.Get(ctx, request.NamespacedName, cluster); err != nil {
+		if kubeapierrors.IsNotFound(err) {
+			log.Debug("Could not find cluster")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if cluster.DeletionTimestamp != nil {
+		r.etcdTransports.Forget(cluster.Name)
+		return reconcile.Result{}, nil
+	}
+
+	result := reconcile.Result{RequeueAfter: r.interval}
+
+	if cluster.Labels[kubermaticv1.WorkerNameLabelKey] != r.workerName {
+		log.Debugw(
+			"Skipping because the cluster has a different worker name set",
+			"cluster-worker-name", cluster.Labels[kubermaticv1.WorkerNameLabelKey],
+		)
+		return result, nil
+	}
+
+	if cluster.Spec.Pause {
+		log.Debug("Skipping cluster reconciling because it was set to paused")
+		return result, nil
+	}
+
+	// There is nothing to probe before the control plane got created and while it is hibernated
+	if cluster.Status.NamespaceName == "" || kubermaticv1helper.IsClusterHibernated(cluster) {
+		return result, nil
+	}
+
+	if err := r.reconcile(ctx, log, cluster); err != nil {
+		log.Errorw("Reconciling failed", zap.Error(err))
+		return reconcile.Result{}, err
+	}
+	return result, nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) error {
+	results := make([]probeResult, len(r.probes))
+	for i, p := range r.probes {
+		probeCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		results[i] = p.run(probeCtx, cluster)
+		cancel()
+		if results[i].status != corev1.ConditionTrue {
+			log.Debugw("Probe failed", "condition", p.conditionType, "reason", results[i].reason, "message", results[i].message)
+		}
+	}
+
+	return r.updateCluster(ctx, cluster.Name, func(c *kubermaticv1.Cluster) {
+		for i, p := range r.probes {
+			kubermaticv1helper.SetClusterCondition(c, p.conditionType, results[i].status, results[i].reason, results[i].message)
+		}
+	})
+}
+
+func (r *Reconciler) updateCluster(ctx context.Context, name string, modify func(*kubermaticv1.Cluster)) error {
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		cluster := &kubermaticv1.Cluster{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+			return err
+		}
+		oldCluster := cluster.DeepCopy()
+		modify(cluster)
+		if apiequality.Semantic.DeepEqual(oldCluster.Status.Conditions, cluster.Status.Conditions) {
+			return nil
+		}
+		return r.Update(ctx, cluster)
+	})
+}
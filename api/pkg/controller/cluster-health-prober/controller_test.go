@@ -0,0 +1,198 @@
+package clusterhealthprober
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcile(t *testing.T) {
+	testCases := []struct {
+		name               string
+		cluster            *kubermaticv1.Cluster
+		expectedConditions []kubermaticv1.ClusterCondition
+	}{
+		{
+			name: "the probe results are stored as conditions",
+			cluster: &kubermaticv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "henrik"},
+				Status:     kubermaticv1.ClusterStatus{NamespaceName: "cluster-henrik"},
+			},
+			expectedConditions: []kubermaticv1.ClusterCondition{
+				{Type: kubermaticv1.ClusterConditionApiserverHealthy, Status: corev1.ConditionTrue, Reason: reasonProbeSucceeded, Message: "All healthz and readyz checks passed"},
+				{Type: kubermaticv1.ClusterConditionEtcdHealthy, Status: corev1.ConditionFalse, Reason: reasonAlarmsRaised, Message: "alarm NOSPACE raised by member 1234"},
+			},
+		},
+		{
+			name: "paused clusters are not probed",
+			cluster: &kubermaticv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "henrik"},
+				Spec:       kubermaticv1.ClusterSpec{Pause: true},
+				Status:     kubermaticv1.ClusterStatus{NamespaceName: "cluster-henrik"},
+			},
+		},
+		{
+			name: "clusters without control plane are not probed",
+			cluster: &kubermaticv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "henrik"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := ctrlruntimefake.NewFakeClient(tc.cluster)
+			r := &Reconciler{
+				Client:   client,
+				log:      kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar(),
+				interval: time.Minute,
+				timeout:  time.Second,
+				probes: []probe{
+					{
+						conditionType: kubermaticv1.ClusterConditionApiserverHealthy,
+						run: func(context.Context, *kubermaticv1.Cluster) probeResult {
+							return succeeded("All healthz and readyz checks passed")
+						},
+					},
+					{
+						conditionType: kubermaticv1.ClusterConditionEtcdHealthy,
+						run: func(context.Context, *kubermaticv1.Cluster) probeResult {
+							return failed(reasonAlarmsRaised, "alarm %s raised by member %s", "NOSPACE", "1234")
+						},
+					},
+				},
+			}
+
+			result, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: tc.cluster.Name}})
+			if err != nil {
+				t.Fatalf("reconciling failed: %v", err)
+			}
+			if result.RequeueAfter != time.Minute {
+				t.Errorf("expected the cluster to be requeued after the probe interval, got %v", result.RequeueAfter)
+			}
+
+			cluster := &kubermaticv1.Cluster{}
+			if err := client.Get(context.Background(), types.NamespacedName{Name: tc.cluster.Name}, cluster); err != nil {
+				t.Fatalf("failed to get cluster: %v", err)
+			}
+			var conditions []kubermaticv1.ClusterCondition
+			for _, conditionType := range kubermaticv1.HealthProbeConditionTypes {
+				if _, condition := kubermaticv1helper.GetClusterCondition(cluster, conditionType); condition != nil {
+					conditions = append(conditions, kubermaticv1.ClusterCondition{
+						Type:    condition.Type,
+						Status:  condition.Status,
+						Reason:  condition.Reason,
+						Message: condition.Message,
+					})
+				}
+			}
+			if diff := deep.Equal(conditions, tc.expectedConditions); diff != nil {
+				t.Errorf("conditions differ from the expected ones, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestParseFailedChecks(t *testing.T) {
+	body := []byte(`[+]ping ok
+[+]log ok
+[-]etcd failed: reason withheld
+[+]poststarthook/generic-apiserver-start-informers ok
+[-]poststarthook/crd-informer-synced failed: reason withheld
+healthz check failed
+`)
+	expected := []string{"etcd failed: reason withheld", "poststarthook/crd-informer-synced failed: reason withheld"}
+	if diff := deep.Equal(parseFailedChecks(body), expected); diff != nil {
+		t.Errorf("failed checks differ from the expected ones, diff: %v", diff)
+	}
+}
+
+func TestEtcdChecks(t *testing.T) {
+	testCases := []struct {
+		name             string
+		healthResponse   string
+		alarmResponse    string
+		expectedHealthy  bool
+		expectedAlarms   []string
+		expectAlarmError bool
+	}{
+		{
+			name:            "healthy member without alarms",
+			healthResponse:  `{"health":"true"}`,
+			alarmResponse:   `{"header":{"cluster_id":"1","member_id":"2"}}`,
+			expectedHealthy: true,
+		},
+		{
+			name:           "unhealthy member with a raised alarm",
+			healthResponse: `{"health":"false"}`,
+			alarmResponse:  `{"header":{"cluster_id":"1","member_id":"2"},"alarms":[{"memberID":"1234","alarm":"NOSPACE"}]}`,
+			expectedAlarms: []string{"alarm NOSPACE raised by member 1234"},
+		},
+		{
+			name:             "the alarms can't be listed",
+			healthResponse:   `{"health":"true"}`,
+			expectedHealthy:  true,
+			expectAlarmError: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(tc.healthResponse))
+			})
+			mux.HandleFunc("/v3beta/maintenance/alarm", func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || tc.alarmResponse == "" {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				_, _ = w.Write([]byte(tc.alarmResponse))
+			})
+			server := httptest.NewTLSServer(mux)
+			defer server.Close()
+			ctx := context.Background()
+
+			err := etcdgateway.CheckHealth(ctx, server.Client(), server.URL)
+			if healthy := err == nil; healthy != tc.expectedHealthy {
+				t.Errorf("expected the member to be healthy: %v, but the check returned: %v", tc.expectedHealthy, err)
+			}
+
+			alarms, err := listEtcdAlarms(ctx, server.Client(), server.URL)
+			if (err != nil) != tc.expectAlarmError {
+				t.Fatalf("expected an error when listing the alarms: %v, got: %v", tc.expectAlarmError, err)
+			}
+			if diff := deep.Equal(alarms, tc.expectedAlarms); diff != nil {
+				t.Errorf("alarms differ from the expected ones, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestCheckWebhook(t *testing.T) {
+	for _, statusCode := range []int{http.StatusBadRequest, http.StatusInternalServerError} {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(statusCode)
+		}))
+		err := checkWebhook(context.Background(), server.Client(), server.URL+"/machines")
+		server.Close()
+		if reachable := err == nil; reachable != (statusCode < http.StatusInternalServerError) {
+			t.Errorf("webhook responding with status code %d: unexpected check result: %v", statusCode, err)
+		}
+	}
+}
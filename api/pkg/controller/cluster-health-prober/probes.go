@@ -0,0 +1,226 @@
+package clusterhealthprober
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	reasonProbeSucceeded   = "ProbeSucceeded"
+	reasonProbeFailed      = "ProbeFailed"
+	reasonUnreachable      = "Unreachable"
+	reasonChecksFailed     = "ChecksFailed"
+	reasonMembersUnhealthy = "MembersUnhealthy"
+	reasonAlarmsRaised     = "AlarmsRaised"
+	reasonNoReadyNodes     = "NoReadyNodes"
+	reasonTunnelDown       = "TunnelDown"
+)
+
+func succeeded(message string) probeResult {
+	return probeResult{status: corev1.ConditionTrue, reason: reasonProbeSucceeded, message: message}
+}
+
+func failed(reason, format string, args ...interface{}) probeResult {
+	return probeResult{status: corev1.ConditionFalse, reason: reason, message: fmt.Sprintf(format, args...)}
+}
+
+// probeApiserver runs the verbose /healthz and /readyz checks of the apiserver and reports the failed ones
+func (r *Reconciler) probeApiserver(ctx context.Context, cluster *kubermaticv1.Cluster) probeResult {
+	client, err := r.userClusterConnProvider.GetK8sClient(cluster)
+	if err != nil {
+		return failed(reasonProbeFailed, "failed to get a client for the apiserver: %v", err)
+	}
+
+	var failures []string
+	for _, path := range []string{"/healthz", "/readyz"} {
+		body, err := client.Discovery().RESTClient().Get().AbsPath(path).Param("verbose", "true").Context(ctx).Do().Raw()
+		if err != nil && path == "/readyz" && kubeapierrors.IsNotFound(err) {
+			// The readyz endpoint only exists since Kubernetes 1.16
+			continue
+		}
+		failedChecks := parseFailedChecks(body)
+		if err != nil && len(failedChecks) == 0 {
+			return failed(reasonUnreachable, "%s: %v", path, err)
+		}
+		for _, check := range failedChecks {
+			failures = append(failures, fmt.Sprintf("%s: %s", path, check))
+		}
+	}
+
+	if len(failures) > 0 {
+		return failed(reasonChecksFailed, "%s", strings.Join(failures, "; "))
+	}
+	return succeeded("All healthz and readyz checks passed")
+}
+
+// parseFailedChecks returns the failed checks of a verbose /healthz or /readyz response,
+// e.g. "etcd failed: reason withheld" for the line "[-]etcd failed: reason withheld"
+func parseFailedChecks(body []byte) []string {
+	var failedChecks []string
+	for _, line := range strings.Split(string(body), "\n") {
+		if check := strings.TrimPrefix(strings.TrimSpace(line), "[-]"); check != strings.TrimSpace(line) {
+			failedChecks = append(failedChecks, check)
+		}
+	}
+	return failedChecks
+}
+
+// probeEtcd checks the health of every etcd member and whether alarms, e.g. for exhausted space, are raised
+func (r *Reconciler) probeEtcd(ctx context.Context, cluster *kubermaticv1.Cluster) probeResult {
+	ns := cluster.Status.NamespaceName
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: ns, Name: resources.EtcdStatefulSetName}, statefulSet); err != nil {
+		return failed(reasonProbeFailed, "failed to get the etcd StatefulSet: %v", err)
+	}
+	members := resources.EtcdClusterSize
+	if statefulSet.Spec.Replicas != nil {
+		members = int(*statefulSet.Spec.Replicas)
+	}
+
+	transport, err := r.etcdTransports.Transport(ctx, r, cluster)
+	if err != nil {
+		return failed(reasonProbeFailed, "%v", err)
+	}
+	client := &http.Client{Transport: transport}
+
+	var unhealthyMembers []string
+	var healthyMemberURL string
+	for i := 0; i < members; i++ {
+		member := etcd.MemberName(i)
+		memberURL := etcdgateway.MemberURL(cluster, i)
+		if err := etcdgateway.CheckHealth(ctx, client, memberURL); err != nil {
+			unhealthyMembers = append(unhealthyMembers, fmt.Sprintf("%s: %v", member, err))
+			continue
+		}
+		if healthyMemberURL == "" {
+			healthyMemberURL = memberURL
+		}
+	}
+	if healthyMemberURL == "" {
+		return failed(reasonMembersUnhealthy, "%s", strings.Join(unhealthyMembers, "; "))
+	}
+
+	// The alarms are cluster wide, so any healthy member can list them
+	alarms, err := listEtcdAlarms(ctx, client, healthyMemberURL)
+	if err != nil {
+		return failed(reasonProbeFailed, "failed to list the etcd alarms: %v", err)
+	}
+	if len(alarms) > 0 {
+		return failed(reasonAlarmsRaised, "%s", strings.Join(append(alarms, unhealthyMembers...), "; "))
+	}
+	if len(unhealthyMembers) > 0 {
+		return failed(reasonMembersUnhealthy, "%s", strings.Join(unhealthyMembers, "; "))
+	}
+	return succeeded(fmt.Sprintf("All %d etcd members are healthy", members))
+}
+
+// listEtcdAlarms returns the alarms raised in an etcd cluster using the gRPC gateway of a member
+func listEtcdAlarms(ctx context.Context, client *http.Client, memberURL string) ([]string, error) {
+	raised, err := etcdgateway.ListAlarms(ctx, client, memberURL)
+	if err != nil {
+		return nil, err
+	}
+	var alarms []string
+	for _, alarm := range raised {
+		alarms = append(alarms, fmt.Sprintf("alarm %s raised by member %s", alarm.Alarm, alarm.MemberID))
+	}
+	return alarms, nil
+}
+
+// probeMachineControllerWebhook checks whether the apiserver could reach the admission webhook of the machine-controller
+func (r *Reconciler) probeMachineControllerWebhook(ctx context.Context, cluster *kubermaticv1.Cluster) probeResult {
+	client, err := r.httpClient(ctx, cluster)
+	if err != nil {
+		return failed(reasonProbeFailed, "%v", err)
+	}
+	// The client is only used once, its connection must not stay open
+	defer client.CloseIdleConnections()
+	webhookURL := fmt.Sprintf("https://%s.%s.svc.cluster.local./machines", resources.MachineControllerWebhookServiceName, cluster.Status.NamespaceName)
+	if err := checkWebhook(ctx, client, webhookURL); err != nil {
+		return failed(reasonUnreachable, "%v", err)
+	}
+	return succeeded("The machine-controller webhook is reachable")
+}
+
+// checkWebhook sends a request without admission review to a webhook, every response which isn't
+// a server error shows that the webhook is up
+func checkWebhook(ctx context.Context, client *http.Client, webhookURL string) error {
+	req, err := http.NewRequest(http.MethodGet, webhookURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("got status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// probeOpenVPNTunnel checks the tunnel between the control plane and the nodes by querying the kubelet
+// of a ready node through the apiserver, which reaches the kubelets through the OpenVPN tunnel
+func (r *Reconciler) probeOpenVPNTunnel(ctx context.Context, cluster *kubermaticv1.Cluster) probeResult {
+	client, err := r.userClusterConnProvider.GetK8sClient(cluster)
+	if err != nil {
+		return failed(reasonProbeFailed, "failed to get a client for the apiserver: %v", err)
+	}
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return failed(reasonProbeFailed, "failed to list nodes: %v", err)
+	}
+
+	var readyNode string
+	for _, node := range nodes.Items {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+				readyNode = node.Name
+				break
+			}
+		}
+		if readyNode != "" {
+			break
+		}
+	}
+	if readyNode == "" {
+		return probeResult{status: corev1.ConditionUnknown, reason: reasonNoReadyNodes, message: "There is no ready node to reach through the tunnel"}
+	}
+
+	if _, err := client.CoreV1().RESTClient().Get().Resource("nodes").Name(readyNode).SubResource("proxy").Suffix("healthz").Context(ctx).Do().Raw(); err != nil {
+		return failed(reasonTunnelDown, "failed to reach the kubelet of node %s: %v", readyNode, err)
+	}
+	return succeeded(fmt.Sprintf("The kubelet of node %s is reachable", readyNode))
+}
+
+// httpClient returns a client which trusts the CA of the cluster
+func (r *Reconciler) httpClient(ctx context.Context, cluster *kubermaticv1.Cluster) (*http.Client, error) {
+	ca, err := resources.GetClusterRootCA(ctx, cluster, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the root CA of the cluster: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(resources.EncodeCABundlePEM(ca)) {
+		return nil, errors.New("the root CA of the cluster contains no certificates")
+	}
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}, nil
+}
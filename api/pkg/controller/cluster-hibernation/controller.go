@@ -0,0 +1,249 @@
+package clusterhibernation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/hibernation"
+	controllerutil "github.com/kubermatic/kubermatic/api/pkg/controller/util"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ControllerName is the name of this very controller.
+	ControllerName = "kubermatic_cluster_hibernation_controller"
+
+	// scheduleCheckPeriod is the interval in which clusters with hibernation schedules
+	// are checked for the start or the end of a time window
+	scheduleCheckPeriod = time.Minute
+	// progressCheckPeriod is the interval in which the scaling of a cluster is checked
+	progressCheckPeriod = 10 * time.Second
+)
+
+// userClusterConnectionProvider offers functions to retrieve clients for the given user clusters
+type userClusterConnectionProvider interface {
+	GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error)
+}
+
+// Reconciler hibernates and resumes clusters
+type Reconciler struct {
+	ctrlruntimeclient.Client
+	log                     *zap.SugaredLogger
+	workerName              string
+	recorder                record.EventRecorder
+	userClusterConnProvider userClusterConnectionProvider
+
+	// now is used to evaluate the hibernation schedules, it gets replaced in the tests
+	now func() time.Time
+}
+
+// Add creates a new cluster hibernation controller
+func Add(
+	mgr manager.Manager,
+	log *zap.SugaredLogger,
+	numWorkers int,
+	workerName string,
+	userClusterConnProvider userClusterConnectionProvider,
+) error {
+	reconciler := &Reconciler{
+		Client:                  mgr.GetClient(),
+		log:                     log.Named(ControllerName),
+		workerName:              workerName,
+		recorder:                mgr.GetRecorder(ControllerName),
+		userClusterConnProvider: userClusterConnProvider,
+		now:                     time.Now,
+	}
+
+	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: reconciler, MaxConcurrentReconciles: numWorkers})
+	if err != nil {
+		return err
+	}
+
+	// Other controllers might scale the control plane up again while we scale it down
+	typesToWatch := []runtime.Object{
+		&appsv1.Deployment{},
+		&appsv1.StatefulSet{},
+	}
+	for _, t := range typesToWatch {
+		if err := c.Watch(&source.Kind{Type: t}, controllerutil.EnqueueClusterForNamespacedObject(mgr.GetClient())); err != nil {
+			return fmt.Errorf("failed to create watcher for %T: %v", t, err)
+		}
+	}
+
+	return c.Watch(&source.Kind{Type: &kubermaticv1.Cluster{}}, &handler.EnqueueRequestForObject{})
+}
+
+func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := r.log.With("request", request)
+	log.Debug("Processing")
+
+	cluster := &kubermaticv1.Cluster{}
+	if err := r.Get(ctx, request.NamespacedName, cluster); err != nil {
+		if kubeapierrors.IsNotFound(err) {
+			log.Debug("Could not find cluster")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if cluster.Labels[kubermaticv1.WorkerNameLabelKey] != r.workerName {
+		log.Debugw(
+			"Skipping because the cluster has a different worker name set",
+			"cluster-worker-name", cluster.Labels[kubermaticv1.WorkerNameLabelKey],
+		)
+		return reconcile.Result{}, nil
+	}
+
+	if cluster.Spec.Pause {
+		log.Debug("Skipping cluster reconciling because it was set to paused")
+		return reconcile.Result{}, nil
+	}
+
+	result, err := r.reconcile(ctx, log.With("cluster", cluster.Name), cluster)
+	if err != nil {
+		log.Errorw("Reconciling failed", zap.Error(err))
+		r.recorder.Event(cluster, corev1.EventTypeWarning, "ReconcilingError", err.Error())
+	}
+	return result, err
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) (reconcile.Result, error) {
+	// Clusters get resumed before they are deleted, as the cleanup needs the user cluster
+	requested := false
+	if cluster.DeletionTimestamp == nil {
+		var err error
+		requested, err = hibernation.Requested(cluster.Spec.Hibernation, r.now())
+		if err != nil {
+			return reconcile.Result{}, fmt.Errorf("invalid hibernation settings: %v", err)
+		}
+	}
+
+	var phase kubermaticv1.ClusterHibernationPhase
+	if cluster.Status.Hibernation != nil {
+		phase = cluster.Status.Hibernation.Phase
+	}
+
+	result, err := r.reconcilePhase(ctx, log, cluster, requested, phase)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Check regularly for the start or the end of a scheduled time window
+	if result.RequeueAfter == 0 && cluster.Spec.Hibernation != nil && len(cluster.Spec.Hibernation.Schedules) > 0 {
+		result.RequeueAfter = scheduleCheckPeriod
+	}
+	return result, nil
+}
+
+func (r *Reconciler) reconcilePhase(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster, requested bool, phase kubermaticv1.ClusterHibernationPhase) (reconcile.Result, error) {
+	switch {
+	case requested && (phase == "" || phase == kubermaticv1.ClusterResuming):
+		log.Info("Hibernating cluster")
+		return reconcile.Result{Requeue: true}, r.setPhase(ctx, cluster, kubermaticv1.ClusterHibernating)
+
+	case requested && phase == kubermaticv1.ClusterHibernating:
+		// The machine-controller runs in the control plane, so the machines must be
+		// gone before the control plane can be scaled down
+		done, err := r.hibernateMachineDeployments(ctx, cluster)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !done {
+			return reconcile.Result{RequeueAfter: progressCheckPeriod}, nil
+		}
+		if err := r.setPhase(ctx, cluster, kubermaticv1.ClusterHibernated); err != nil {
+			return reconcile.Result{}, err
+		}
+		r.recorder.Event(cluster, corev1.EventTypeNormal, "Hibernated", "Scaled the cluster down to zero")
+		return reconcile.Result{Requeue: true}, nil
+
+	case requested && phase == kubermaticv1.ClusterHibernated:
+		// Scaling the control plane down is repeated on every reconciliation, as controllers
+		// that didn't notice the hibernation yet might have scaled it up again
+		return reconcile.Result{}, r.hibernateControlPlane(ctx, cluster)
+
+	case !requested && phase == kubermaticv1.ClusterHibernating:
+		// The control plane is still running, only the MachineDeployments must be restored
+		if err := r.resumeMachineDeployments(ctx, cluster); err != nil {
+			return reconcile.Result{}, err
+		}
+		log.Info("Aborted hibernation of cluster")
+		return reconcile.Result{}, r.setPhase(ctx, cluster, "")
+
+	case !requested && phase == kubermaticv1.ClusterHibernated:
+		log.Info("Resuming cluster")
+		return reconcile.Result{Requeue: true}, r.setPhase(ctx, cluster, kubermaticv1.ClusterResuming)
+
+	case !requested && phase == kubermaticv1.ClusterResuming:
+		if err := r.resumeControlPlane(ctx, cluster); err != nil {
+			return reconcile.Result{}, err
+		}
+		running, err := r.apiserverRunning(ctx, cluster)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !running {
+			return reconcile.Result{RequeueAfter: progressCheckPeriod}, nil
+		}
+		if err := r.resumeMachineDeployments(ctx, cluster); err != nil {
+			return reconcile.Result{}, err
+		}
+		if err := r.setPhase(ctx, cluster, ""); err != nil {
+			return reconcile.Result{}, err
+		}
+		r.recorder.Event(cluster, corev1.EventTypeNormal, "Resumed", "Scaled the cluster back up")
+		return reconcile.Result{}, nil
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// setPhase sets the hibernation phase of the cluster, an empty phase removes the hibernation status
+func (r *Reconciler) setPhase(ctx context.Context, cluster *kubermaticv1.Cluster, phase kubermaticv1.ClusterHibernationPhase) error {
+	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		if phase == "" {
+			c.Status.Hibernation = nil
+			return
+		}
+		c.Status.Hibernation = &kubermaticv1.ClusterHibernationStatus{
+			Phase:              phase,
+			LastTransitionTime: metav1.NewTime(r.now()),
+		}
+	})
+}
+
+func (r *Reconciler) updateCluster(ctx context.Context, cluster *kubermaticv1.Cluster, modify func(*kubermaticv1.Cluster)) error {
+	// Store it here because it may be unset later on if an update request failed
+	name := cluster.Name
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		//Get latest version
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+			return err
+		}
+		// Apply modifications
+		modify(cluster)
+		// Update the cluster
+		return r.Update(ctx, cluster)
+	})
+}
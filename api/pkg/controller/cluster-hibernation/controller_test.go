@@ -0,0 +1,168 @@
+package clusterhibernation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+func init() {
+	// We call this in init because even thought it is possible to register the same
+	// scheme multiple times it is an unprotected concurrent map access and these tests
+	// are very good at making that panic
+	log := kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar()
+	if err := clusterv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		log.Fatalw("failed to add clusterv1alpha1 scheme to scheme.Scheme", zap.Error(err))
+	}
+}
+
+type fakeUserClusterConnectionProvider struct {
+	client ctrlruntimeclient.Client
+}
+
+func (p *fakeUserClusterConnectionProvider) GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error) {
+	return p.client, nil
+}
+
+func TestHibernateAndResume(t *testing.T) {
+	cluster := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "henrik"},
+		Spec: kubermaticv1.ClusterSpec{
+			Hibernation: &kubermaticv1.HibernationSettings{Enabled: true},
+		},
+		Status: kubermaticv1.ClusterStatus{NamespaceName: "cluster-henrik"},
+	}
+	apiserver := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.ApiserverDeploymentName},
+		Spec:       appsv1.DeploymentSpec{Replicas: utilpointer.Int32Ptr(2)},
+		// The fake client doesn't touch the status, so the apiserver looks running once it got scaled up again
+		Status: appsv1.DeploymentStatus{Replicas: 2, ReadyReplicas: 2, UpdatedReplicas: 2},
+	}
+	etcd := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "cluster-henrik", Name: resources.EtcdStatefulSetName},
+		Spec:       appsv1.StatefulSetSpec{Replicas: utilpointer.Int32Ptr(3)},
+	}
+	machineDeployment := &clusterv1alpha1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Name: "workers"},
+		Spec:       clusterv1alpha1.MachineDeploymentSpec{Replicas: utilpointer.Int32Ptr(5)},
+	}
+
+	seedClient := ctrlruntimefake.NewFakeClient(cluster, apiserver, etcd)
+	userClusterClient := ctrlruntimefake.NewFakeClient(machineDeployment)
+	r := &Reconciler{
+		Client:                  seedClient,
+		log:                     kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar(),
+		recorder:                record.NewFakeRecorder(10),
+		userClusterConnProvider: &fakeUserClusterConnectionProvider{client: userClusterClient},
+		now:                     time.Now,
+	}
+
+	ctx := context.Background()
+	reconcileAndExpectPhase := func(expected kubermaticv1.ClusterHibernationPhase) {
+		t.Helper()
+		c := &kubermaticv1.Cluster{}
+		if err := seedClient.Get(ctx, types.NamespacedName{Name: cluster.Name}, c); err != nil {
+			t.Fatalf("failed to get cluster: %v", err)
+		}
+		if _, err := r.reconcile(ctx, r.log, c); err != nil {
+			t.Fatalf("failed to reconcile: %v", err)
+		}
+		if err := seedClient.Get(ctx, types.NamespacedName{Name: cluster.Name}, c); err != nil {
+			t.Fatalf("failed to get cluster: %v", err)
+		}
+		var phase kubermaticv1.ClusterHibernationPhase
+		if c.Status.Hibernation != nil {
+			phase = c.Status.Hibernation.Phase
+		}
+		if phase != expected {
+			t.Fatalf("expected hibernation phase %q, got %q", expected, phase)
+		}
+	}
+	expectReplicas := func(client ctrlruntimeclient.Client, obj interface {
+		runtime.Object
+		metav1.Object
+	}, replicas func() *int32, expected int32) {
+		t.Helper()
+		if err := client.Get(ctx, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}, obj); err != nil {
+			t.Fatalf("failed to get %s: %v", obj.GetName(), err)
+		}
+		if actual := replicas(); actual == nil || *actual != expected {
+			t.Fatalf("expected %s to have %d replicas, got %v", obj.GetName(), expected, actual)
+		}
+	}
+
+	reconcileAndExpectPhase(kubermaticv1.ClusterHibernating)
+	reconcileAndExpectPhase(kubermaticv1.ClusterHibernated)
+	expectReplicas(userClusterClient, machineDeployment, func() *int32 { return machineDeployment.Spec.Replicas }, 0)
+	expectReplicas(seedClient, apiserver, func() *int32 { return apiserver.Spec.Replicas }, 2)
+
+	reconcileAndExpectPhase(kubermaticv1.ClusterHibernated)
+	expectReplicas(seedClient, apiserver, func() *int32 { return apiserver.Spec.Replicas }, 0)
+	expectReplicas(seedClient, etcd, func() *int32 { return etcd.Spec.Replicas }, 0)
+
+	if err := r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		c.Spec.Hibernation.Enabled = false
+	}); err != nil {
+		t.Fatalf("failed to disable hibernation: %v", err)
+	}
+
+	reconcileAndExpectPhase(kubermaticv1.ClusterResuming)
+	reconcileAndExpectPhase("")
+	expectReplicas(seedClient, apiserver, func() *int32 { return apiserver.Spec.Replicas }, 2)
+	expectReplicas(seedClient, etcd, func() *int32 { return etcd.Spec.Replicas }, 3)
+	expectReplicas(userClusterClient, machineDeployment, func() *int32 { return machineDeployment.Spec.Replicas }, 5)
+	resumedMachineDeployment := &clusterv1alpha1.MachineDeployment{}
+	if err := userClusterClient.Get(ctx, types.NamespacedName{Namespace: "kube-system", Name: "workers"}, resumedMachineDeployment); err != nil {
+		t.Fatalf("failed to get MachineDeployment: %v", err)
+	}
+	if _, remembered := resumedMachineDeployment.Annotations[replicasAnnotation]; remembered {
+		t.Errorf("expected the %s annotation to be removed", replicasAnnotation)
+	}
+}
+
+func TestScaleDownKeepsRememberedReplicas(t *testing.T) {
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{replicasAnnotation: "3"}},
+		Spec:       appsv1.DeploymentSpec{Replicas: utilpointer.Int32Ptr(1)},
+	}
+
+	if !scaleDown(deployment, &deployment.Spec.Replicas) {
+		t.Fatal("expected the deployment to be scaled down")
+	}
+	if *deployment.Spec.Replicas != 0 {
+		t.Errorf("expected zero replicas, got %d", *deployment.Spec.Replicas)
+	}
+	if deployment.Annotations[replicasAnnotation] != "3" {
+		t.Errorf("expected the remembered replicas to be kept, got %q", deployment.Annotations[replicasAnnotation])
+	}
+	if scaleDown(deployment, &deployment.Spec.Replicas) {
+		t.Error("expected a scaled down deployment to be left alone")
+	}
+
+	changed, err := scaleUp(deployment, &deployment.Spec.Replicas)
+	if err != nil {
+		t.Fatalf("failed to scale up: %v", err)
+	}
+	if !changed || *deployment.Spec.Replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", *deployment.Spec.Replicas)
+	}
+}
@@ -0,0 +1,217 @@
+package clusterhibernation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+const (
+	// replicasAnnotation remembers the replicas of a scaled down object, so they can be restored on resume
+	replicasAnnotation = "kubermatic.io/hibernation-replicas"
+)
+
+// hibernateMachineDeployments scales all MachineDeployments of the user cluster to zero.
+// It returns whether all machines are gone.
+func (r *Reconciler) hibernateMachineDeployments(ctx context.Context, cluster *kubermaticv1.Cluster) (bool, error) {
+	client, err := r.userClusterConnProvider.GetClient(cluster)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user cluster client: %v", err)
+	}
+
+	machineDeployments := &clusterv1alpha1.MachineDeploymentList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, machineDeployments); err != nil {
+		return false, fmt.Errorf("failed to list MachineDeployments: %v", err)
+	}
+	for i := range machineDeployments.Items {
+		md := &machineDeployments.Items[i]
+		if !scaleDown(md, &md.Spec.Replicas) {
+			continue
+		}
+		if err := client.Update(ctx, md); err != nil {
+			return false, fmt.Errorf("failed to scale down MachineDeployment %s/%s: %v", md.Namespace, md.Name, err)
+		}
+	}
+
+	machines := &clusterv1alpha1.MachineList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, machines); err != nil {
+		return false, fmt.Errorf("failed to list Machines: %v", err)
+	}
+	return len(machines.Items) == 0, nil
+}
+
+// resumeMachineDeployments restores the replicas of all MachineDeployments of the user cluster
+func (r *Reconciler) resumeMachineDeployments(ctx context.Context, cluster *kubermaticv1.Cluster) error {
+	client, err := r.userClusterConnProvider.GetClient(cluster)
+	if err != nil {
+		return fmt.Errorf("failed to get user cluster client: %v", err)
+	}
+
+	machineDeployments := &clusterv1alpha1.MachineDeploymentList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, machineDeployments); err != nil {
+		return fmt.Errorf("failed to list MachineDeployments: %v", err)
+	}
+	for i := range machineDeployments.Items {
+		md := &machineDeployments.Items[i]
+		changed, err := scaleUp(md, &md.Spec.Replicas)
+		if err != nil {
+			return fmt.Errorf("failed to restore MachineDeployment %s/%s: %v", md.Namespace, md.Name, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := client.Update(ctx, md); err != nil {
+			return fmt.Errorf("failed to scale up MachineDeployment %s/%s: %v", md.Namespace, md.Name, err)
+		}
+	}
+	return nil
+}
+
+// hibernateControlPlane scales all Deployments and StatefulSets in the cluster namespace to zero
+func (r *Reconciler) hibernateControlPlane(ctx context.Context, cluster *kubermaticv1.Cluster) error {
+	if cluster.Status.NamespaceName == "" {
+		return nil
+	}
+	listOpts := &ctrlruntimeclient.ListOptions{Namespace: cluster.Status.NamespaceName}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, listOpts, deployments); err != nil {
+		return fmt.Errorf("failed to list Deployments: %v", err)
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		if !scaleDown(deployment, &deployment.Spec.Replicas) {
+			continue
+		}
+		if err := r.Update(ctx, deployment); err != nil {
+			return fmt.Errorf("failed to scale down Deployment %s: %v", deployment.Name, err)
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, listOpts, statefulSets); err != nil {
+		return fmt.Errorf("failed to list StatefulSets: %v", err)
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		if !scaleDown(statefulSet, &statefulSet.Spec.Replicas) {
+			continue
+		}
+		if err := r.Update(ctx, statefulSet); err != nil {
+			return fmt.Errorf("failed to scale down StatefulSet %s: %v", statefulSet.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// resumeControlPlane restores the replicas of all Deployments and StatefulSets in the cluster namespace
+func (r *Reconciler) resumeControlPlane(ctx context.Context, cluster *kubermaticv1.Cluster) error {
+	if cluster.Status.NamespaceName == "" {
+		return nil
+	}
+	listOpts := &ctrlruntimeclient.ListOptions{Namespace: cluster.Status.NamespaceName}
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, listOpts, deployments); err != nil {
+		return fmt.Errorf("failed to list Deployments: %v", err)
+	}
+	for i := range deployments.Items {
+		deployment := &deployments.Items[i]
+		changed, err := scaleUp(deployment, &deployment.Spec.Replicas)
+		if err != nil {
+			return fmt.Errorf("failed to restore Deployment %s: %v", deployment.Name, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := r.Update(ctx, deployment); err != nil {
+			return fmt.Errorf("failed to scale up Deployment %s: %v", deployment.Name, err)
+		}
+	}
+
+	statefulSets := &appsv1.StatefulSetList{}
+	if err := r.List(ctx, listOpts, statefulSets); err != nil {
+		return fmt.Errorf("failed to list StatefulSets: %v", err)
+	}
+	for i := range statefulSets.Items {
+		statefulSet := &statefulSets.Items[i]
+		changed, err := scaleUp(statefulSet, &statefulSet.Spec.Replicas)
+		if err != nil {
+			return fmt.Errorf("failed to restore StatefulSet %s: %v", statefulSet.Name, err)
+		}
+		if !changed {
+			continue
+		}
+		if err := r.Update(ctx, statefulSet); err != nil {
+			return fmt.Errorf("failed to scale up StatefulSet %s: %v", statefulSet.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *Reconciler) apiserverRunning(ctx context.Context, cluster *kubermaticv1.Cluster) (bool, error) {
+	key := types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.ApiserverDeploymentName}
+	status, err := resources.HealthyDeployment(ctx, r, key, 1)
+	if err != nil {
+		return false, fmt.Errorf("failed to get apiserver health: %v", err)
+	}
+	return status == kubermaticv1.HealthStatusUp, nil
+}
+
+// scaleDown remembers the replicas of the given object in its annotations and sets them to zero.
+// It returns whether the object was modified.
+func scaleDown(obj metav1.Object, replicas **int32) bool {
+	// Unset replicas default to one
+	current := int32(1)
+	if *replicas != nil {
+		current = **replicas
+	}
+	if current == 0 {
+		return false
+	}
+
+	// Keep the replicas we remembered first, the object might have been scaled up again
+	// by a controller since then
+	annotations := obj.GetAnnotations()
+	if _, remembered := annotations[replicasAnnotation]; !remembered {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[replicasAnnotation] = strconv.Itoa(int(current))
+		obj.SetAnnotations(annotations)
+	}
+	*replicas = utilpointer.Int32Ptr(0)
+	return true
+}
+
+// scaleUp restores the replicas of the given object that were remembered by scaleDown.
+// It returns whether the object was modified.
+func scaleUp(obj metav1.Object, replicas **int32) (bool, error) {
+	annotations := obj.GetAnnotations()
+	value, remembered := annotations[replicasAnnotation]
+	if !remembered {
+		return false, nil
+	}
+	previous, err := strconv.Atoi(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation %q: %v", replicasAnnotation, value, err)
+	}
+
+	delete(annotations, replicasAnnotation)
+	obj.SetAnnotations(annotations)
+	*replicas = utilpointer.Int32Ptr(int32(previous))
+	return true, nil
+}
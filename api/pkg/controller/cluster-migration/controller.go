@@ -0,0 +1,89 @@
+package clustermigration
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	clusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	backupcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/backup"
+	"github.com/kubermatic/kubermatic/api/pkg/controller/util/predicate"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+
+	corev1 "k8s.io/api/core/v1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ControllerName is the name of this very controller.
+	ControllerName = "cluster-migration-controller"
+)
+
+// Add creates a new ClusterMigration controller. The storeContainer uploads the etcd snapshot
+// from the shared volume, the restoreContainer downloads it on the target seed again.
+func Add(
+	ctx context.Context,
+	mgr manager.Manager,
+	numWorkers int,
+	log *zap.SugaredLogger,
+	namespace string,
+	seedsGetter provider.SeedsGetter,
+	seedKubeconfigGetter provider.SeedKubeconfigGetter,
+	storeContainer corev1.Container,
+	restoreContainer corev1.Container,
+	etcdImage string,
+) error {
+	if err := validateSnapshotContainer(storeContainer); err != nil {
+		return fmt.Errorf("invalid store container: %v", err)
+	}
+	if err := validateSnapshotContainer(restoreContainer); err != nil {
+		return fmt.Errorf("invalid restore container: %v", err)
+	}
+
+	reconciler := &Reconciler{
+		Client:           mgr.GetClient(),
+		ctx:              ctx,
+		recorder:         mgr.GetRecorder(ControllerName),
+		log:              log.Named(ControllerName),
+		seedsGetter:      seedsGetter,
+		seedClientGetter: provider.SeedClientGetterFactory(seedKubeconfigGetter),
+		userClusterClientGetter: func(seedClient ctrlruntimeclient.Client, cluster *kubermaticv1.Cluster) (ctrlruntimeclient.Client, error) {
+			connectionProvider, err := clusterclient.NewExternal(seedClient)
+			if err != nil {
+				return nil, err
+			}
+			return connectionProvider.GetClient(cluster)
+		},
+		storeContainer:   storeContainer,
+		restoreContainer: restoreContainer,
+		etcdImage:        etcdImage,
+	}
+
+	ctrlOptions := controller.Options{Reconciler: reconciler, MaxConcurrentReconciles: numWorkers}
+	c, err := controller.New(ControllerName, mgr, ctrlOptions)
+	if err != nil {
+		return err
+	}
+
+	// watch all migrations in the given namespace
+	if err := c.Watch(&source.Kind{Type: &kubermaticv1.ClusterMigration{}}, &handler.EnqueueRequestForObject{}, predicate.ByNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to create watcher: %v", err)
+	}
+
+	return nil
+}
+
+func validateSnapshotContainer(container corev1.Container) error {
+	for _, volumeMount := range container.VolumeMounts {
+		if volumeMount.Name == backupcontroller.SharedVolumeName {
+			return nil
+		}
+	}
+	return fmt.Errorf("container does not have a mount for the shared volume %s", backupcontroller.SharedVolumeName)
+}
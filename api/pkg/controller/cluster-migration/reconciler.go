@@ -0,0 +1,712 @@
+package clustermigration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/address"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+const (
+	// MigratedToSeedAnnotation is set on the machine template of all MachineDeployments of a migrated cluster.
+	// Changing it rolls all machines, so that the new nodes join the control plane on the target seed.
+	MigratedToSeedAnnotation = "kubermatic.io/migrated-to-seed"
+
+	// requeueInterval is the interval in which progress of running Jobs and the
+	// control plane on the target seed gets checked
+	requeueInterval = 10 * time.Second
+)
+
+// Reconciler moves clusters between seeds as requested by ClusterMigrations.
+// Every phase is idempotent, so that it can be retried until it either succeeds or
+// gets rolled back.
+type Reconciler struct {
+	ctrlruntimeclient.Client
+
+	seedsGetter             provider.SeedsGetter
+	seedClientGetter        provider.SeedClientGetter
+	userClusterClientGetter func(seedClient ctrlruntimeclient.Client, cluster *kubermaticv1.Cluster) (ctrlruntimeclient.Client, error)
+	storeContainer          corev1.Container
+	restoreContainer        corev1.Container
+	etcdImage               string
+	log                     *zap.SugaredLogger
+	ctx                     context.Context
+	recorder                record.EventRecorder
+}
+
+// migrationContext holds the seeds a migration moves a cluster between
+type migrationContext struct {
+	migration    *kubermaticv1.ClusterMigration
+	sourceSeed   *kubermaticv1.Seed
+	targetSeed   *kubermaticv1.Seed
+	sourceClient ctrlruntimeclient.Client
+	targetClient ctrlruntimeclient.Client
+}
+
+func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	logger := r.log.With("migration", request.Name)
+	logger.Debug("Reconciling cluster migration")
+
+	migration := &kubermaticv1.ClusterMigration{}
+	if err := r.Get(r.ctx, request.NamespacedName, migration); err != nil {
+		if kerrors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get migration: %v", err)
+	}
+
+	if migration.Status.IsFinished() {
+		return reconcile.Result{}, nil
+	}
+
+	result, err := r.reconcile(migration, logger.With("cluster", migration.Spec.ClusterName))
+	if err != nil {
+		r.recorder.Eventf(migration, corev1.EventTypeWarning, "ReconcilingFailed", "%v", err)
+		return reconcile.Result{}, fmt.Errorf("failed to reconcile: %v", err)
+	}
+
+	return result, nil
+}
+
+func (r *Reconciler) reconcile(migration *kubermaticv1.ClusterMigration, logger *zap.SugaredLogger) (reconcile.Result, error) {
+	if migration.Status.Phase == "" || migration.Status.Phase == kubermaticv1.ClusterMigrationPending {
+		if migration.Spec.Abort {
+			return reconcile.Result{}, r.finish(migration, kubermaticv1.ClusterMigrationRolledBack, "Migration was aborted before it started")
+		}
+		mc, reason, err := r.validate(migration)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if reason != "" {
+			logger.Infow("Refusing migration", "reason", reason)
+			return reconcile.Result{}, r.finish(migration, kubermaticv1.ClusterMigrationFailed, reason)
+		}
+		return reconcile.Result{}, r.start(mc)
+	}
+
+	mc, err := r.migrationContext(migration)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if migration.Spec.Abort && isAbortable(migration.Status.Phase) {
+		logger.Info("Migration got aborted, rolling back")
+		return reconcile.Result{}, r.fail(migration, "Migration was aborted")
+	}
+
+	switch migration.Status.Phase {
+	case kubermaticv1.ClusterMigrationSnapshotting:
+		return r.snapshot(mc)
+	case kubermaticv1.ClusterMigrationProvisioning:
+		return reconcile.Result{}, r.provision(mc)
+	case kubermaticv1.ClusterMigrationRestoring:
+		return r.restore(mc)
+	case kubermaticv1.ClusterMigrationSwitching:
+		return r.switchAddress(mc)
+	case kubermaticv1.ClusterMigrationRepointing:
+		return r.repoint(mc)
+	case kubermaticv1.ClusterMigrationRollingBack:
+		return reconcile.Result{}, r.rollback(mc)
+	}
+
+	return reconcile.Result{}, fmt.Errorf("unknown phase %q", migration.Status.Phase)
+}
+
+// isAbortable tells whether the cluster is still available on the source seed in the given phase.
+// Once the nodes got re-pointed, a migration can only be finished.
+func isAbortable(phase kubermaticv1.ClusterMigrationPhase) bool {
+	switch phase {
+	case kubermaticv1.ClusterMigrationSnapshotting,
+		kubermaticv1.ClusterMigrationProvisioning,
+		kubermaticv1.ClusterMigrationRestoring,
+		kubermaticv1.ClusterMigrationSwitching:
+		return true
+	}
+	return false
+}
+
+func (r *Reconciler) migrationContext(migration *kubermaticv1.ClusterMigration) (*migrationContext, error) {
+	seeds, err := r.seedsGetter()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get seeds: %v", err)
+	}
+
+	mc := &migrationContext{migration: migration}
+	if mc.sourceSeed = seeds[migration.Spec.SourceSeed]; mc.sourceSeed == nil {
+		return nil, fmt.Errorf("source seed %q not found", migration.Spec.SourceSeed)
+	}
+	if mc.targetSeed = seeds[migration.Spec.TargetSeed]; mc.targetSeed == nil {
+		return nil, fmt.Errorf("target seed %q not found", migration.Spec.TargetSeed)
+	}
+	if mc.sourceClient, err = r.seedClientGetter(mc.sourceSeed); err != nil {
+		return nil, fmt.Errorf("failed to create client for source seed: %v", err)
+	}
+	if mc.targetClient, err = r.seedClientGetter(mc.targetSeed); err != nil {
+		return nil, fmt.Errorf("failed to create client for target seed: %v", err)
+	}
+	return mc, nil
+}
+
+// validate checks whether the migration can be started. It returns the reason why
+// the migration is invalid, the error is only set for failures that are worth a retry.
+func (r *Reconciler) validate(migration *kubermaticv1.ClusterMigration) (*migrationContext, string, error) {
+	spec := migration.Spec
+	if spec.SourceSeed == spec.TargetSeed {
+		return nil, "Source and target seed must differ", nil
+	}
+
+	seeds, err := r.seedsGetter()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get seeds: %v", err)
+	}
+	if seeds[spec.SourceSeed] == nil {
+		return nil, fmt.Sprintf("Source seed %q does not exist", spec.SourceSeed), nil
+	}
+	if seeds[spec.TargetSeed] == nil {
+		return nil, fmt.Sprintf("Target seed %q does not exist", spec.TargetSeed), nil
+	}
+	targetDC, exists := seeds[spec.TargetSeed].Spec.Datacenters[spec.TargetDatacenter]
+	if !exists {
+		return nil, fmt.Sprintf("Datacenter %q does not exist on target seed %q", spec.TargetDatacenter, spec.TargetSeed), nil
+	}
+
+	mc, err := r.migrationContext(migration)
+	if err != nil {
+		return nil, "", err
+	}
+
+	migrations := &kubermaticv1.ClusterMigrationList{}
+	if err := r.List(r.ctx, &ctrlruntimeclient.ListOptions{Namespace: migration.Namespace}, migrations); err != nil {
+		return nil, "", fmt.Errorf("failed to list migrations: %v", err)
+	}
+	for _, other := range migrations.Items {
+		if other.Name != migration.Name && other.Spec.ClusterName == spec.ClusterName && !other.Status.IsFinished() &&
+			other.Status.Phase != "" && other.Status.Phase != kubermaticv1.ClusterMigrationPending {
+			return nil, fmt.Sprintf("Cluster is already being migrated by %q", other.Name), nil
+		}
+	}
+
+	cluster := &kubermaticv1.Cluster{}
+	if err := mc.sourceClient.Get(r.ctx, types.NamespacedName{Name: spec.ClusterName}, cluster); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, fmt.Sprintf("Cluster %q does not exist on source seed", spec.ClusterName), nil
+		}
+		return nil, "", fmt.Errorf("failed to get cluster: %v", err)
+	}
+	if cluster.DeletionTimestamp != nil {
+		return nil, "Cluster is being deleted", nil
+	}
+	if cluster.Spec.Pause {
+		return nil, "Cluster is paused", nil
+	}
+	if cluster.Status.NamespaceName == "" {
+		return nil, "Cluster has no namespace yet", nil
+	}
+
+	clusterProvider, err := provider.ClusterCloudProviderName(cluster.Spec.Cloud)
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to determine cloud provider of cluster: %v", err), nil
+	}
+	targetProvider, err := provider.DatacenterCloudProviderName(&targetDC.Spec)
+	if err != nil {
+		return nil, fmt.Sprintf("Failed to determine cloud provider of datacenter %q: %v", spec.TargetDatacenter, err), nil
+	}
+	if clusterProvider != targetProvider {
+		return nil, fmt.Sprintf("Cluster uses cloud provider %q but datacenter %q uses %q", clusterProvider, spec.TargetDatacenter, targetProvider), nil
+	}
+
+	existing := &kubermaticv1.Cluster{}
+	err = mc.targetClient.Get(r.ctx, types.NamespacedName{Name: spec.ClusterName}, existing)
+	if err == nil {
+		return nil, fmt.Sprintf("Cluster %q already exists on target seed", spec.ClusterName), nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return nil, "", fmt.Errorf("failed to check for cluster on target seed: %v", err)
+	}
+
+	return mc, "", nil
+}
+
+// start pauses the cluster on the source seed, so that none of its resources get
+// changed by the controllers while it is being migrated
+func (r *Reconciler) start(mc *migrationContext) error {
+	cluster, err := r.updateCluster(mc.sourceClient, mc.migration.Spec.ClusterName, func(c *kubermaticv1.Cluster) {
+		c.Spec.Pause = true
+	})
+	if err != nil {
+		return fmt.Errorf("failed to pause source cluster: %v", err)
+	}
+
+	now := metav1.Now()
+	return r.updateMigration(mc.migration, func(m *kubermaticv1.ClusterMigration) {
+		m.Status.Phase = kubermaticv1.ClusterMigrationSnapshotting
+		m.Status.SourceAddress = cluster.Address
+		m.Status.StartTime = &now
+	})
+}
+
+// snapshot stops the apiserver of the source cluster and snapshots its etcd
+func (r *Reconciler) snapshot(mc *migrationContext) (reconcile.Result, error) {
+	cluster, err := r.getCluster(mc.sourceClient, mc.migration.Spec.ClusterName)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Nothing must be written to etcd after the snapshot got taken
+	apiserver := &appsv1.Deployment{}
+	name := types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.ApiserverDeploymentName}
+	if err := mc.sourceClient.Get(r.ctx, name, apiserver); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get apiserver deployment: %v", err)
+	}
+	if apiserver.Spec.Replicas == nil || *apiserver.Spec.Replicas != 0 {
+		apiserver.Spec.Replicas = resources.Int32(0)
+		if err := mc.sourceClient.Update(r.ctx, apiserver); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to scale down apiserver: %v", err)
+		}
+	}
+	if apiserver.Status.Replicas != 0 {
+		return reconcile.Result{RequeueAfter: requeueInterval}, nil
+	}
+
+	finished, failure, err := r.ensureJob(mc.sourceClient, r.snapshotJob(cluster, mc.migration))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if failure != "" {
+		return reconcile.Result{}, r.fail(mc.migration, fmt.Sprintf("Failed to snapshot etcd: %s", failure))
+	}
+	if !finished {
+		return reconcile.Result{RequeueAfter: requeueInterval}, nil
+	}
+
+	return reconcile.Result{}, r.setPhase(mc.migration, kubermaticv1.ClusterMigrationProvisioning)
+}
+
+// provision creates the paused cluster on the target seed, together with its namespace
+// and all secrets of the source cluster. The secrets carry the certificates and
+// keys, so that the nodes and users can keep trusting the cluster.
+func (r *Reconciler) provision(mc *migrationContext) error {
+	source, err := r.getCluster(mc.sourceClient, mc.migration.Spec.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	if err := mc.targetClient.Create(r.ctx, targetCluster(source, mc.migration.Spec.TargetDatacenter)); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create cluster on target seed: %v", err)
+	}
+	target, err := r.getCluster(mc.targetClient, mc.migration.Spec.ClusterName)
+	if err != nil {
+		return err
+	}
+
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            target.Status.NamespaceName,
+			OwnerReferences: []metav1.OwnerReference{resources.GetClusterRef(target)},
+		},
+	}
+	if err := mc.targetClient.Create(r.ctx, namespace); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace on target seed: %v", err)
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := mc.sourceClient.List(r.ctx, &ctrlruntimeclient.ListOptions{Namespace: source.Status.NamespaceName}, secrets); err != nil {
+		return fmt.Errorf("failed to list secrets of source cluster: %v", err)
+	}
+	for i := range secrets.Items {
+		// Tokens get issued by the target seed again
+		if secrets.Items[i].Type == corev1.SecretTypeServiceAccountToken {
+			continue
+		}
+		if err := r.copySecret(mc.targetClient, targetSecret(&secrets.Items[i], source.UID, target)); err != nil {
+			return err
+		}
+	}
+
+	credentials := &corev1.Secret{}
+	name := types.NamespacedName{Namespace: resources.KubermaticNamespace, Name: source.GetSecretName()}
+	if err := mc.sourceClient.Get(r.ctx, name, credentials); err == nil {
+		if err := r.copySecret(mc.targetClient, targetSecret(credentials, source.UID, target)); err != nil {
+			return err
+		}
+	} else if !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get credentials of source cluster: %v", err)
+	}
+
+	return r.setPhase(mc.migration, kubermaticv1.ClusterMigrationRestoring)
+}
+
+func (r *Reconciler) copySecret(client ctrlruntimeclient.Client, secret *corev1.Secret) error {
+	if err := client.Create(r.ctx, secret); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create secret %s/%s on target seed: %v", secret.Namespace, secret.Name, err)
+	}
+	return nil
+}
+
+// restore creates the data volumes of all etcd members on the target seed and restores
+// the snapshot into them. The etcd StatefulSet adopts the volumes once the cluster gets unpaused.
+func (r *Reconciler) restore(mc *migrationContext) (reconcile.Result, error) {
+	target, err := r.getCluster(mc.targetClient, mc.migration.Spec.ClusterName)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	allFinished := true
+	for member := 0; member < etcd.ClusterSize(target); member++ {
+		if err := r.ensureDataVolumeClaim(mc, target, member); err != nil {
+			return reconcile.Result{}, err
+		}
+
+		finished, failure, err := r.ensureJob(mc.targetClient, r.restoreJob(target, mc.migration, member))
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if failure != "" {
+			return reconcile.Result{}, r.fail(mc.migration, fmt.Sprintf("Failed to restore etcd member %s: %s", etcd.MemberName(member), failure))
+		}
+		allFinished = allFinished && finished
+	}
+	if !allFinished {
+		return reconcile.Result{RequeueAfter: requeueInterval}, nil
+	}
+
+	return reconcile.Result{}, r.setPhase(mc.migration, kubermaticv1.ClusterMigrationSwitching)
+}
+
+// ensureDataVolumeClaim creates the volume claim of the given member on the target seed,
+// it requests the same size as the claim on the source seed
+func (r *Reconciler) ensureDataVolumeClaim(mc *migrationContext, target *kubermaticv1.Cluster, member int) error {
+	name := types.NamespacedName{Namespace: target.Status.NamespaceName, Name: etcd.DataVolumeClaimName(member)}
+	source := &corev1.PersistentVolumeClaim{}
+	if err := mc.sourceClient.Get(r.ctx, name, source); err != nil {
+		return fmt.Errorf("failed to get volume claim %s on source seed: %v", name.Name, err)
+	}
+
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name.Name,
+			Namespace:       name.Namespace,
+			Labels:          source.Labels,
+			OwnerReferences: []metav1.OwnerReference{resources.GetClusterRef(target)},
+		},
+		Spec: etcd.DataVolumeClaimSpec(source.Spec.Resources.Requests[corev1.ResourceStorage]),
+	}
+	if err := mc.targetClient.Create(r.ctx, claim); err != nil && !kerrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create volume claim %s on target seed: %v", name.Name, err)
+	}
+	return nil
+}
+
+// switchAddress starts the control plane on the target seed and waits until it is healthy
+// and reachable under the address of the target seed
+func (r *Reconciler) switchAddress(mc *migrationContext) (reconcile.Result, error) {
+	target, err := r.getCluster(mc.targetClient, mc.migration.Spec.ClusterName)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if target.Spec.Pause {
+		if _, err := r.updateCluster(mc.targetClient, target.Name, func(c *kubermaticv1.Cluster) {
+			c.Spec.Pause = false
+		}); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to unpause target cluster: %v", err)
+		}
+		return reconcile.Result{RequeueAfter: requeueInterval}, nil
+	}
+
+	if !isSwitched(target, mc.targetSeed) {
+		return reconcile.Result{RequeueAfter: requeueInterval}, nil
+	}
+
+	return reconcile.Result{}, r.updateMigration(mc.migration, func(m *kubermaticv1.ClusterMigration) {
+		m.Status.Phase = kubermaticv1.ClusterMigrationRepointing
+		m.Status.TargetAddress = target.Address
+	})
+}
+
+// isSwitched tells whether the control plane on the target seed is up and uses its address
+func isSwitched(cluster *kubermaticv1.Cluster, seed *kubermaticv1.Seed) bool {
+	health := cluster.Status.ExtendedHealth
+	if health.Apiserver != kubermaticv1.HealthStatusUp || health.Etcd != kubermaticv1.HealthStatusUp {
+		return false
+	}
+	if cluster.Address.URL == "" {
+		return false
+	}
+	if cluster.Spec.ExposeStrategy == corev1.ServiceTypeLoadBalancer {
+		return true
+	}
+	return strings.HasPrefix(cluster.Address.ExternalName, fmt.Sprintf("%s.%s.", cluster.Name, address.Subdomain(seed)))
+}
+
+// repoint makes everything inside the user cluster connect to the control plane on the target seed
+// and removes the cluster from the source seed once all machines got replaced
+func (r *Reconciler) repoint(mc *migrationContext) (reconcile.Result, error) {
+	target, err := r.getCluster(mc.targetClient, mc.migration.Spec.ClusterName)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	userClusterClient, err := r.userClusterClientGetter(mc.targetClient, target)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to get user cluster client: %v", err)
+	}
+
+	machineDeployments := &clusterv1alpha1.MachineDeploymentList{}
+	if err := userClusterClient.List(r.ctx, &ctrlruntimeclient.ListOptions{Namespace: metav1.NamespaceSystem}, machineDeployments); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list MachineDeployments: %v", err)
+	}
+	var outdated []*clusterv1alpha1.MachineDeployment
+	for i := range machineDeployments.Items {
+		if machineDeployments.Items[i].Spec.Template.Annotations[MigratedToSeedAnnotation] != mc.targetSeed.Name {
+			outdated = append(outdated, &machineDeployments.Items[i])
+		}
+	}
+
+	// The openvpn clients only get restarted along with the first update of the MachineDeployments,
+	// not on every check of their rollout
+	if len(outdated) > 0 || len(machineDeployments.Items) == 0 {
+		// The config of the openvpn client gets updated with the new address by the usercluster
+		// controller, but the client only reads it on startup
+		pods := &corev1.PodList{}
+		listOpts := &ctrlruntimeclient.ListOptions{Namespace: metav1.NamespaceSystem}
+		if err := listOpts.SetLabelSelector(fmt.Sprintf("%s=%s", resources.AppLabelKey, openVPNClientPodLabel)); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to build label selector: %v", err)
+		}
+		if err := userClusterClient.List(r.ctx, listOpts, pods); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to list openvpn client pods: %v", err)
+		}
+		for i := range pods.Items {
+			if err := userClusterClient.Delete(r.ctx, &pods.Items[i]); err != nil && !kerrors.IsNotFound(err) {
+				return reconcile.Result{}, fmt.Errorf("failed to delete openvpn client pod %s: %v", pods.Items[i].Name, err)
+			}
+		}
+	}
+
+	// The kubelets were bootstrapped with the old address, new machines get the new one
+	for _, md := range outdated {
+		if md.Spec.Template.Annotations == nil {
+			md.Spec.Template.Annotations = map[string]string{}
+		}
+		md.Spec.Template.Annotations[MigratedToSeedAnnotation] = mc.targetSeed.Name
+		if err := userClusterClient.Update(r.ctx, md); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to update MachineDeployment %s: %v", md.Name, err)
+		}
+	}
+	if len(outdated) > 0 {
+		return reconcile.Result{RequeueAfter: requeueInterval}, nil
+	}
+
+	// The old machines keep using the control plane on the source seed until they got replaced
+	for i := range machineDeployments.Items {
+		if !isRolledOut(&machineDeployments.Items[i]) {
+			return reconcile.Result{RequeueAfter: requeueInterval}, nil
+		}
+	}
+
+	// The finalizers would clean up the cloud resources, which are still used by the target cluster
+	if err := r.removeCluster(mc.sourceClient, mc.migration.Spec.ClusterName); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to remove cluster from source seed: %v", err)
+	}
+
+	return reconcile.Result{}, r.finish(mc.migration, kubermaticv1.ClusterMigrationCompleted, "")
+}
+
+// isRolledOut tells whether all machines of the MachineDeployment got replaced with ones
+// using its latest template and are available
+func isRolledOut(md *clusterv1alpha1.MachineDeployment) bool {
+	replicas := int32(1)
+	if md.Spec.Replicas != nil {
+		replicas = *md.Spec.Replicas
+	}
+	return md.Status.ObservedGeneration >= md.Generation &&
+		md.Status.Replicas == replicas &&
+		md.Status.UpdatedReplicas == replicas &&
+		md.Status.AvailableReplicas == replicas
+}
+
+// rollback removes everything the migration created on the target seed and resumes
+// the cluster on the source seed
+func (r *Reconciler) rollback(mc *migrationContext) error {
+	if err := r.removeCluster(mc.targetClient, mc.migration.Spec.ClusterName); err != nil {
+		return fmt.Errorf("failed to remove cluster from target seed: %v", err)
+	}
+
+	source, err := r.updateCluster(mc.sourceClient, mc.migration.Spec.ClusterName, func(c *kubermaticv1.Cluster) {
+		c.Spec.Pause = false
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unpause source cluster: %v", err)
+	}
+
+	// The apiserver got scaled down for the snapshot
+	apiserver := &appsv1.Deployment{}
+	name := types.NamespacedName{Namespace: source.Status.NamespaceName, Name: resources.ApiserverDeploymentName}
+	if err := mc.sourceClient.Get(r.ctx, name, apiserver); err == nil {
+		replicas := apiserverReplicas(source)
+		if apiserver.Spec.Replicas == nil || *apiserver.Spec.Replicas != replicas {
+			apiserver.Spec.Replicas = resources.Int32(replicas)
+			if err := mc.sourceClient.Update(r.ctx, apiserver); err != nil {
+				return fmt.Errorf("failed to scale up apiserver: %v", err)
+			}
+		}
+	} else if !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get apiserver deployment: %v", err)
+	}
+
+	job := &batchv1.Job{}
+	name = types.NamespacedName{Namespace: source.Status.NamespaceName, Name: snapshotJobName}
+	if err := mc.sourceClient.Get(r.ctx, name, job); err == nil {
+		if err := mc.sourceClient.Delete(r.ctx, job, ctrlruntimeclient.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete snapshot job: %v", err)
+		}
+	} else if !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get snapshot job: %v", err)
+	}
+
+	return r.finish(mc.migration, kubermaticv1.ClusterMigrationRolledBack, mc.migration.Status.Message)
+}
+
+// apiserverReplicas returns the replicas the apiserver Deployment of the cluster runs with
+func apiserverReplicas(cluster *kubermaticv1.Cluster) int32 {
+	if cluster.Spec.ComponentsOverride.Apiserver.Replicas != nil {
+		return *cluster.Spec.ComponentsOverride.Apiserver.Replicas
+	}
+	return 1
+}
+
+// removeCluster deletes the cluster and its credentials from the given seed without cleaning
+// up anything the cluster uses outside of the seed. Its namespace gets garbage collected.
+func (r *Reconciler) removeCluster(client ctrlruntimeclient.Client, name string) error {
+	cluster, err := r.updateCluster(client, name, func(c *kubermaticv1.Cluster) {
+		c.Finalizers = nil
+	})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	credentials := &corev1.Secret{}
+	credentials.Namespace = resources.KubermaticNamespace
+	credentials.Name = cluster.GetSecretName()
+	if err := client.Delete(r.ctx, credentials); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete credentials: %v", err)
+	}
+
+	if err := client.Delete(r.ctx, cluster); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete cluster: %v", err)
+	}
+	return nil
+}
+
+// ensureJob creates the given Job if it doesn't exist yet. It returns whether the Job finished and
+// the reason if it failed.
+func (r *Reconciler) ensureJob(client ctrlruntimeclient.Client, job *batchv1.Job) (bool, string, error) {
+	existing := &batchv1.Job{}
+	err := client.Get(r.ctx, types.NamespacedName{Namespace: job.Namespace, Name: job.Name}, existing)
+	if kerrors.IsNotFound(err) {
+		if err := client.Create(r.ctx, job); err != nil {
+			return false, "", fmt.Errorf("failed to create job %s: %v", job.Name, err)
+		}
+		return false, "", nil
+	}
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get job %s: %v", job.Name, err)
+	}
+
+	for _, condition := range existing.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, "", nil
+		case batchv1.JobFailed:
+			return true, fmt.Sprintf("job %s failed: %s", job.Name, condition.Message), nil
+		}
+	}
+	return false, "", nil
+}
+
+func (r *Reconciler) getCluster(client ctrlruntimeclient.Client, name string) (*kubermaticv1.Cluster, error) {
+	cluster := &kubermaticv1.Cluster{}
+	if err := client.Get(r.ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+		return nil, fmt.Errorf("failed to get cluster: %v", err)
+	}
+	return cluster, nil
+}
+
+func (r *Reconciler) updateCluster(client ctrlruntimeclient.Client, name string, modify func(*kubermaticv1.Cluster)) (*kubermaticv1.Cluster, error) {
+	cluster := &kubermaticv1.Cluster{}
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := client.Get(r.ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+			return err
+		}
+		modify(cluster)
+		return client.Update(r.ctx, cluster)
+	})
+	return cluster, err
+}
+
+func (r *Reconciler) setPhase(migration *kubermaticv1.ClusterMigration, phase kubermaticv1.ClusterMigrationPhase) error {
+	return r.updateMigration(migration, func(m *kubermaticv1.ClusterMigration) {
+		m.Status.Phase = phase
+	})
+}
+
+// fail records why the migration failed and starts the rollback
+func (r *Reconciler) fail(migration *kubermaticv1.ClusterMigration, message string) error {
+	r.recorder.Event(migration, corev1.EventTypeWarning, "MigrationFailed", message)
+	return r.updateMigration(migration, func(m *kubermaticv1.ClusterMigration) {
+		m.Status.FailedPhase = m.Status.Phase
+		m.Status.Phase = kubermaticv1.ClusterMigrationRollingBack
+		m.Status.Message = message
+	})
+}
+
+func (r *Reconciler) finish(migration *kubermaticv1.ClusterMigration, phase kubermaticv1.ClusterMigrationPhase, message string) error {
+	now := metav1.Now()
+	return r.updateMigration(migration, func(m *kubermaticv1.ClusterMigration) {
+		if phase == kubermaticv1.ClusterMigrationFailed {
+			m.Status.FailedPhase = m.Status.Phase
+		}
+		m.Status.Phase = phase
+		m.Status.Message = message
+		m.Status.CompletionTime = &now
+	})
+}
+
+// updateMigration applies the modify func to the latest version of the migration
+func (r *Reconciler) updateMigration(migration *kubermaticv1.ClusterMigration, modify func(*kubermaticv1.ClusterMigration)) error {
+	name := types.NamespacedName{Namespace: migration.Namespace, Name: migration.Name}
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := r.Get(r.ctx, name, migration); err != nil {
+			return err
+		}
+		modify(migration)
+		return r.Update(r.ctx, migration)
+	})
+}
@@ -0,0 +1,406 @@
+package clustermigration
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+func init() {
+	log := kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar()
+	if err := clusterv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		log.Fatalw("failed to add clusterv1alpha1 scheme to scheme.Scheme", zap.Error(err))
+	}
+}
+
+const (
+	testClusterName = "henrik1"
+	testNamespace   = "cluster-henrik1"
+)
+
+func testSeeds() map[string]*kubermaticv1.Seed {
+	return map[string]*kubermaticv1.Seed{
+		"europe": {
+			ObjectMeta: metav1.ObjectMeta{Name: "europe", Namespace: "kubermatic"},
+			Spec: kubermaticv1.SeedSpec{
+				Datacenters: map[string]kubermaticv1.Datacenter{
+					"fra1": {Spec: kubermaticv1.DatacenterSpec{Digitalocean: &kubermaticv1.DatacenterSpecDigitalocean{Region: "fra1"}}},
+				},
+			},
+		},
+		"us": {
+			ObjectMeta: metav1.ObjectMeta{Name: "us", Namespace: "kubermatic"},
+			Spec: kubermaticv1.SeedSpec{
+				Datacenters: map[string]kubermaticv1.Datacenter{
+					"nyc1":      {Spec: kubermaticv1.DatacenterSpec{Digitalocean: &kubermaticv1.DatacenterSpecDigitalocean{Region: "nyc1"}}},
+					"us-east-1": {Spec: kubermaticv1.DatacenterSpec{AWS: &kubermaticv1.DatacenterSpecAWS{Region: "us-east-1"}}},
+				},
+			},
+		},
+	}
+}
+
+func testMigration(phase kubermaticv1.ClusterMigrationPhase, targetDatacenter string) *kubermaticv1.ClusterMigration {
+	return &kubermaticv1.ClusterMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "move-henrik1", Namespace: "kubermatic"},
+		Spec: kubermaticv1.ClusterMigrationSpec{
+			ClusterName:      testClusterName,
+			SourceSeed:       "europe",
+			TargetSeed:       "us",
+			TargetDatacenter: targetDatacenter,
+		},
+		Status: kubermaticv1.ClusterMigrationStatus{Phase: phase},
+	}
+}
+
+func testCluster(datacenter string, paused bool) *kubermaticv1.Cluster {
+	return &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       testClusterName,
+			UID:        types.UID("source-uid"),
+			Finalizers: []string{"kubermatic.io/cleanup-digitalocean"},
+		},
+		Spec: kubermaticv1.ClusterSpec{
+			Cloud: kubermaticv1.CloudSpec{
+				DatacenterName: datacenter,
+				Digitalocean:   &kubermaticv1.DigitaloceanCloudSpec{},
+			},
+			Pause: paused,
+		},
+		Address: kubermaticv1.ClusterAddress{
+			URL:          "https://henrik1.europe.dev.kubermatic.io:31000",
+			ExternalName: "henrik1.europe.dev.kubermatic.io",
+			AdminToken:   "admintoken",
+		},
+		Status: kubermaticv1.ClusterStatus{
+			NamespaceName: testNamespace,
+			ExtendedHealth: kubermaticv1.ExtendedClusterHealth{
+				Apiserver: kubermaticv1.HealthStatusUp,
+				Etcd:      kubermaticv1.HealthStatusUp,
+			},
+		},
+	}
+}
+
+func testMachineDeployment(migratedToSeed string, rolledOut bool) *clusterv1alpha1.MachineDeployment {
+	md := &clusterv1alpha1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "workers", Namespace: metav1.NamespaceSystem, Generation: 2},
+		Spec:       clusterv1alpha1.MachineDeploymentSpec{Replicas: resources.Int32(3)},
+		Status: clusterv1alpha1.MachineDeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           4,
+			UpdatedReplicas:    1,
+			AvailableReplicas:  3,
+		},
+	}
+	if migratedToSeed != "" {
+		md.Spec.Template.Annotations = map[string]string{MigratedToSeedAnnotation: migratedToSeed}
+	}
+	if rolledOut {
+		md.Status.Replicas = 3
+		md.Status.UpdatedReplicas = 3
+	}
+	return md
+}
+
+func TestReconcilingClusterMigration(t *testing.T) {
+	tests := []struct {
+		name            string
+		migration       *kubermaticv1.ClusterMigration
+		sourceObjects   []runtime.Object
+		targetObjects   []runtime.Object
+		userObjects     []runtime.Object
+		expectedPhase   kubermaticv1.ClusterMigrationPhase
+		validate        func(t *testing.T, migration *kubermaticv1.ClusterMigration, sourceClient, targetClient ctrlruntimeclient.Client)
+		expectedFailure kubermaticv1.ClusterMigrationPhase
+	}{
+		{
+			name:          "pending migration pauses the source cluster",
+			migration:     testMigration(kubermaticv1.ClusterMigrationPending, "nyc1"),
+			sourceObjects: []runtime.Object{testCluster("fra1", false)},
+			expectedPhase: kubermaticv1.ClusterMigrationSnapshotting,
+			validate: func(t *testing.T, migration *kubermaticv1.ClusterMigration, sourceClient, _ ctrlruntimeclient.Client) {
+				cluster := getCluster(t, sourceClient)
+				if !cluster.Spec.Pause {
+					t.Error("expected source cluster to be paused")
+				}
+				if migration.Status.SourceAddress != cluster.Address {
+					t.Errorf("expected source address %+v to be recorded, got %+v", cluster.Address, migration.Status.SourceAddress)
+				}
+				if migration.Status.StartTime == nil {
+					t.Error("expected start time to be set")
+				}
+			},
+		},
+		{
+			name:            "migration to a datacenter of another provider gets refused",
+			migration:       testMigration(kubermaticv1.ClusterMigrationPending, "us-east-1"),
+			sourceObjects:   []runtime.Object{testCluster("fra1", false)},
+			expectedPhase:   kubermaticv1.ClusterMigrationFailed,
+			expectedFailure: kubermaticv1.ClusterMigrationPending,
+			validate: func(t *testing.T, _ *kubermaticv1.ClusterMigration, sourceClient, _ ctrlruntimeclient.Client) {
+				if getCluster(t, sourceClient).Spec.Pause {
+					t.Error("expected refused migration to leave the source cluster untouched")
+				}
+			},
+		},
+		{
+			name:            "migration of a cluster that exists on the target seed gets refused",
+			migration:       testMigration(kubermaticv1.ClusterMigrationPending, "nyc1"),
+			sourceObjects:   []runtime.Object{testCluster("fra1", false)},
+			targetObjects:   []runtime.Object{testCluster("nyc1", false)},
+			expectedPhase:   kubermaticv1.ClusterMigrationFailed,
+			expectedFailure: kubermaticv1.ClusterMigrationPending,
+		},
+		{
+			name:      "finished snapshot job continues with provisioning",
+			migration: testMigration(kubermaticv1.ClusterMigrationSnapshotting, "nyc1"),
+			sourceObjects: []runtime.Object{
+				testCluster("fra1", true),
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: resources.ApiserverDeploymentName, Namespace: testNamespace},
+					Spec:       appsv1.DeploymentSpec{Replicas: resources.Int32(0)},
+				},
+				&batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{Name: snapshotJobName, Namespace: testNamespace},
+					Status: batchv1.JobStatus{
+						Conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+					},
+				},
+			},
+			expectedPhase: kubermaticv1.ClusterMigrationProvisioning,
+		},
+		{
+			name:      "failed snapshot job triggers a rollback",
+			migration: testMigration(kubermaticv1.ClusterMigrationSnapshotting, "nyc1"),
+			sourceObjects: []runtime.Object{
+				testCluster("fra1", true),
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: resources.ApiserverDeploymentName, Namespace: testNamespace},
+					Spec:       appsv1.DeploymentSpec{Replicas: resources.Int32(0)},
+				},
+				&batchv1.Job{
+					ObjectMeta: metav1.ObjectMeta{Name: snapshotJobName, Namespace: testNamespace},
+					Status: batchv1.JobStatus{
+						Conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}},
+					},
+				},
+			},
+			expectedPhase:   kubermaticv1.ClusterMigrationRollingBack,
+			expectedFailure: kubermaticv1.ClusterMigrationSnapshotting,
+		},
+		{
+			name:      "provisioning creates the paused cluster with its secrets on the target seed",
+			migration: testMigration(kubermaticv1.ClusterMigrationProvisioning, "nyc1"),
+			sourceObjects: []runtime.Object{
+				testCluster("fra1", true),
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            resources.CASecretName,
+						Namespace:       testNamespace,
+						OwnerReferences: []metav1.OwnerReference{{Kind: "Cluster", Name: testClusterName, UID: "source-uid"}},
+					},
+					Data: map[string][]byte{resources.CACertSecretKey: []byte("cert")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "default-token-abcde", Namespace: testNamespace},
+					Type:       corev1.SecretTypeServiceAccountToken,
+				},
+			},
+			expectedPhase: kubermaticv1.ClusterMigrationRestoring,
+			validate: func(t *testing.T, _ *kubermaticv1.ClusterMigration, _, targetClient ctrlruntimeclient.Client) {
+				cluster := getCluster(t, targetClient)
+				if !cluster.Spec.Pause {
+					t.Error("expected target cluster to be paused")
+				}
+				if cluster.Spec.Cloud.DatacenterName != "nyc1" {
+					t.Errorf("expected target cluster to be in datacenter nyc1, got %q", cluster.Spec.Cloud.DatacenterName)
+				}
+				if cluster.Address.URL != "" || cluster.Address.AdminToken != "admintoken" {
+					t.Errorf("expected only the admin token to be kept from the address, got %+v", cluster.Address)
+				}
+				if len(cluster.Finalizers) != 0 {
+					t.Errorf("expected target cluster to have no finalizers, got %v", cluster.Finalizers)
+				}
+
+				namespace := &corev1.Namespace{}
+				if err := targetClient.Get(context.Background(), types.NamespacedName{Name: testNamespace}, namespace); err != nil {
+					t.Fatalf("failed to get namespace on target seed: %v", err)
+				}
+
+				secret := &corev1.Secret{}
+				if err := targetClient.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: resources.CASecretName}, secret); err != nil {
+					t.Fatalf("failed to get ca secret on target seed: %v", err)
+				}
+				if string(secret.Data[resources.CACertSecretKey]) != "cert" {
+					t.Error("expected ca secret data to be copied")
+				}
+				if len(secret.OwnerReferences) != 1 || secret.OwnerReferences[0].UID != cluster.UID {
+					t.Errorf("expected ca secret to be owned by the target cluster, got %v", secret.OwnerReferences)
+				}
+
+				err := targetClient.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: "default-token-abcde"}, &corev1.Secret{})
+				if !kerrors.IsNotFound(err) {
+					t.Errorf("expected service account token not to be copied, got %v", err)
+				}
+			},
+		},
+		{
+			name: "abort triggers a rollback",
+			migration: func() *kubermaticv1.ClusterMigration {
+				migration := testMigration(kubermaticv1.ClusterMigrationRestoring, "nyc1")
+				migration.Spec.Abort = true
+				return migration
+			}(),
+			sourceObjects:   []runtime.Object{testCluster("fra1", true)},
+			targetObjects:   []runtime.Object{testCluster("nyc1", true)},
+			expectedPhase:   kubermaticv1.ClusterMigrationRollingBack,
+			expectedFailure: kubermaticv1.ClusterMigrationRestoring,
+		},
+		{
+			name:          "repointing updates the MachineDeployments and keeps the source cluster until they rolled out",
+			migration:     testMigration(kubermaticv1.ClusterMigrationRepointing, "nyc1"),
+			sourceObjects: []runtime.Object{testCluster("fra1", true)},
+			targetObjects: []runtime.Object{testCluster("nyc1", false)},
+			userObjects:   []runtime.Object{testMachineDeployment("", false)},
+			expectedPhase: kubermaticv1.ClusterMigrationRepointing,
+			validate: func(t *testing.T, _ *kubermaticv1.ClusterMigration, sourceClient, _ ctrlruntimeclient.Client) {
+				getCluster(t, sourceClient)
+			},
+		},
+		{
+			name:          "repointing waits for the rollout of the MachineDeployments",
+			migration:     testMigration(kubermaticv1.ClusterMigrationRepointing, "nyc1"),
+			sourceObjects: []runtime.Object{testCluster("fra1", true)},
+			targetObjects: []runtime.Object{testCluster("nyc1", false)},
+			userObjects:   []runtime.Object{testMachineDeployment("us", false)},
+			expectedPhase: kubermaticv1.ClusterMigrationRepointing,
+			validate: func(t *testing.T, _ *kubermaticv1.ClusterMigration, sourceClient, _ ctrlruntimeclient.Client) {
+				getCluster(t, sourceClient)
+			},
+		},
+		{
+			name:          "repointing removes the source cluster once the MachineDeployments rolled out",
+			migration:     testMigration(kubermaticv1.ClusterMigrationRepointing, "nyc1"),
+			sourceObjects: []runtime.Object{testCluster("fra1", true)},
+			targetObjects: []runtime.Object{testCluster("nyc1", false)},
+			userObjects:   []runtime.Object{testMachineDeployment("us", true)},
+			expectedPhase: kubermaticv1.ClusterMigrationCompleted,
+			validate: func(t *testing.T, _ *kubermaticv1.ClusterMigration, sourceClient, _ ctrlruntimeclient.Client) {
+				err := sourceClient.Get(context.Background(), types.NamespacedName{Name: testClusterName}, &kubermaticv1.Cluster{})
+				if !kerrors.IsNotFound(err) {
+					t.Errorf("expected source cluster to be deleted, got %v", err)
+				}
+			},
+		},
+		{
+			name:      "rollback removes the target cluster and resumes the source cluster",
+			migration: testMigration(kubermaticv1.ClusterMigrationRollingBack, "nyc1"),
+			sourceObjects: []runtime.Object{
+				testCluster("fra1", true),
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: resources.ApiserverDeploymentName, Namespace: testNamespace},
+					Spec:       appsv1.DeploymentSpec{Replicas: resources.Int32(0)},
+				},
+			},
+			targetObjects: []runtime.Object{testCluster("nyc1", true)},
+			expectedPhase: kubermaticv1.ClusterMigrationRolledBack,
+			validate: func(t *testing.T, migration *kubermaticv1.ClusterMigration, sourceClient, targetClient ctrlruntimeclient.Client) {
+				if getCluster(t, sourceClient).Spec.Pause {
+					t.Error("expected source cluster to be unpaused")
+				}
+				apiserver := &appsv1.Deployment{}
+				if err := sourceClient.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: resources.ApiserverDeploymentName}, apiserver); err != nil {
+					t.Fatalf("failed to get apiserver deployment: %v", err)
+				}
+				if apiserver.Spec.Replicas == nil || *apiserver.Spec.Replicas != 1 {
+					t.Errorf("expected the apiserver to be scaled up to 1 replica, got %v", apiserver.Spec.Replicas)
+				}
+				err := targetClient.Get(context.Background(), types.NamespacedName{Name: testClusterName}, &kubermaticv1.Cluster{})
+				if !kerrors.IsNotFound(err) {
+					t.Errorf("expected target cluster to be deleted, got %v", err)
+				}
+				if migration.Status.CompletionTime == nil {
+					t.Error("expected completion time to be set")
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			masterClient := ctrlruntimefake.NewFakeClient(test.migration)
+			sourceClient := ctrlruntimefake.NewFakeClient(test.sourceObjects...)
+			targetClient := ctrlruntimefake.NewFakeClient(test.targetObjects...)
+			userClusterClient := ctrlruntimefake.NewFakeClient(test.userObjects...)
+			seeds := testSeeds()
+
+			reconciler := &Reconciler{
+				Client:   masterClient,
+				ctx:      ctx,
+				log:      zap.NewNop().Sugar(),
+				recorder: record.NewFakeRecorder(10),
+				seedsGetter: func() (map[string]*kubermaticv1.Seed, error) {
+					return seeds, nil
+				},
+				seedClientGetter: func(seed *kubermaticv1.Seed) (ctrlruntimeclient.Client, error) {
+					if seed.Name == "europe" {
+						return sourceClient, nil
+					}
+					return targetClient, nil
+				},
+				userClusterClientGetter: func(ctrlruntimeclient.Client, *kubermaticv1.Cluster) (ctrlruntimeclient.Client, error) {
+					return userClusterClient, nil
+				},
+				etcdImage: "etcd",
+			}
+
+			migration := test.migration.DeepCopy()
+			if _, err := reconciler.reconcile(migration, reconciler.log); err != nil {
+				t.Fatalf("reconciling failed: %v", err)
+			}
+
+			result := &kubermaticv1.ClusterMigration{}
+			if err := masterClient.Get(ctx, types.NamespacedName{Namespace: migration.Namespace, Name: migration.Name}, result); err != nil {
+				t.Fatalf("failed to get migration: %v", err)
+			}
+			if result.Status.Phase != test.expectedPhase {
+				t.Fatalf("expected phase %q, got %q (message: %q)", test.expectedPhase, result.Status.Phase, result.Status.Message)
+			}
+			if result.Status.FailedPhase != test.expectedFailure {
+				t.Errorf("expected failed phase %q, got %q", test.expectedFailure, result.Status.FailedPhase)
+			}
+			if test.validate != nil {
+				test.validate(t, result, sourceClient, targetClient)
+			}
+		})
+	}
+}
+
+func getCluster(t *testing.T, client ctrlruntimeclient.Client) *kubermaticv1.Cluster {
+	cluster := &kubermaticv1.Cluster{}
+	if err := client.Get(context.Background(), types.NamespacedName{Name: testClusterName}, cluster); err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
+	return cluster
+}
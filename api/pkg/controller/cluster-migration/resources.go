@@ -0,0 +1,247 @@
+package clustermigration
+
+import (
+	"fmt"
+	"strings"
+
+	backupcontroller "github.com/kubermatic/kubermatic/api/pkg/controller/backup"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+const (
+	snapshotJobName       = "etcd-migration-snapshot"
+	restoreJobNamePrefix  = "etcd-migration-restore"
+	snapshotMountPath     = "/backup"
+	snapshotPath          = snapshotMountPath + "/snapshot.db"
+	etcdDataVolumeName    = "data"
+	clusterEnvVarKey      = "CLUSTER"
+	migrationEnvVarKey    = "MIGRATION"
+	jobBackoffLimit       = 3
+	jobActiveDeadline     = 60 * 60
+	etcdCAMountPath       = "/etc/etcd/pki/ca"
+	etcdClientMountPath   = "/etc/etcd/pki/client"
+	openVPNClientPodLabel = "openvpn-client"
+)
+
+func restoreJobName(member int) string {
+	return fmt.Sprintf("%s-%d", restoreJobNamePrefix, member)
+}
+
+func snapshotEnv(cluster *kubermaticv1.Cluster, migration *kubermaticv1.ClusterMigration) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{
+			Name:  clusterEnvVarKey,
+			Value: cluster.Name,
+		},
+		{
+			Name:  migrationEnvVarKey,
+			Value: migration.Name,
+		},
+	}
+}
+
+// snapshotJob returns the Job that takes a snapshot of the etcd of the source cluster
+// and hands it to the store container
+func (r *Reconciler) snapshotJob(cluster *kubermaticv1.Cluster, migration *kubermaticv1.ClusterMigration) *batchv1.Job {
+	storeContainer := r.storeContainer.DeepCopy()
+	storeContainer.Env = append(storeContainer.Env, snapshotEnv(cluster, migration)...)
+
+	endpoints := etcd.GetClientEndpoints(cluster)
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            snapshotJobName,
+			Namespace:       cluster.Status.NamespaceName,
+			OwnerReferences: []metav1.OwnerReference{resources.GetClusterRef(cluster)},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          utilpointer.Int32Ptr(jobBackoffLimit),
+			ActiveDeadlineSeconds: resources.Int64(jobActiveDeadline),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					// The etcd NetworkPolicy allows the pods with this label to reach the clients port
+					Labels: resources.BaseAppLabel(resources.EtcdMigrationSnapshotAppLabelValue, nil),
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{
+						{
+							Name:  "snapshot-creator",
+							Image: r.etcdImage,
+							Env: []corev1.EnvVar{
+								{
+									Name:  "ETCDCTL_API",
+									Value: "3",
+								},
+							},
+							Command: []string{
+								"/usr/local/bin/etcdctl",
+								"--endpoints", strings.Join(endpoints, ","),
+								"--cacert", etcdCAMountPath + "/" + resources.CACertSecretKey,
+								"--cert", etcdClientMountPath + "/" + resources.ApiserverEtcdClientCertificateCertSecretKey,
+								"--key", etcdClientMountPath + "/" + resources.ApiserverEtcdClientCertificateKeySecretKey,
+								"snapshot", "save", snapshotPath,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      backupcontroller.SharedVolumeName,
+									MountPath: snapshotMountPath,
+								},
+								{
+									Name:      resources.CASecretName,
+									MountPath: etcdCAMountPath,
+									ReadOnly:  true,
+								},
+								{
+									Name:      resources.ApiserverEtcdClientCertificateSecretName,
+									MountPath: etcdClientMountPath,
+									ReadOnly:  true,
+								},
+							},
+						},
+					},
+					Containers: []corev1.Container{*storeContainer},
+					Volumes: []corev1.Volume{
+						sharedVolume(),
+						{
+							Name: resources.CASecretName,
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: resources.CASecretName,
+									Items: []corev1.KeyToPath{
+										{
+											Path: resources.CACertSecretKey,
+											Key:  resources.CACertSecretKey,
+										},
+									},
+								},
+							},
+						},
+						{
+							Name: resources.ApiserverEtcdClientCertificateSecretName,
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: resources.ApiserverEtcdClientCertificateSecretName,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// restoreJob returns the Job that fetches the snapshot with the restore container and
+// restores it into the data volume of the given etcd member
+func (r *Reconciler) restoreJob(cluster *kubermaticv1.Cluster, migration *kubermaticv1.ClusterMigration, member int) *batchv1.Job {
+	restoreContainer := r.restoreContainer.DeepCopy()
+	restoreContainer.Env = append(restoreContainer.Env, snapshotEnv(cluster, migration)...)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            restoreJobName(member),
+			Namespace:       cluster.Status.NamespaceName,
+			OwnerReferences: []metav1.OwnerReference{resources.GetClusterRef(cluster)},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          utilpointer.Int32Ptr(jobBackoffLimit),
+			ActiveDeadlineSeconds: resources.Int64(jobActiveDeadline),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy:  corev1.RestartPolicyNever,
+					InitContainers: []corev1.Container{*restoreContainer},
+					Containers: []corev1.Container{
+						{
+							Name:    "snapshot-restorer",
+							Image:   r.etcdImage,
+							Env:     []corev1.EnvVar{{Name: "ETCDCTL_API", Value: "3"}},
+							Command: etcd.RestoreCommand(cluster.Name, cluster.Status.NamespaceName, etcd.ClusterSize(cluster), member, snapshotPath),
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      backupcontroller.SharedVolumeName,
+									MountPath: snapshotMountPath,
+								},
+								{
+									Name:      etcdDataVolumeName,
+									MountPath: etcd.DataVolumeMountPath(),
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						sharedVolume(),
+						{
+							Name: etcdDataVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: etcd.DataVolumeClaimName(member),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func sharedVolume() corev1.Volume {
+	return corev1.Volume{
+		Name: backupcontroller.SharedVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+}
+
+// targetCluster returns the copy of the source cluster that gets created on the target seed.
+// It is paused until its etcd got restored and carries over everything but the seed specific state.
+func targetCluster(source *kubermaticv1.Cluster, datacenter string) *kubermaticv1.Cluster {
+	target := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        source.Name,
+			Labels:      source.Labels,
+			Annotations: source.Annotations,
+		},
+		Spec:   *source.Spec.DeepCopy(),
+		Status: *source.Status.DeepCopy(),
+	}
+	target.Spec.Cloud.DatacenterName = datacenter
+	target.Spec.Pause = true
+	target.Address = kubermaticv1.ClusterAddress{AdminToken: source.Address.AdminToken}
+	target.Status.Conditions = nil
+	target.Status.ExtendedHealth = kubermaticv1.ExtendedClusterHealth{}
+	return target
+}
+
+// targetSecret returns the copy of a secret of the source cluster namespace,
+// owner references to the source cluster are rewritten to the target cluster
+func targetSecret(secret *corev1.Secret, sourceUID types.UID, target *kubermaticv1.Cluster) *corev1.Secret {
+	var ownerRefs []metav1.OwnerReference
+	for _, ref := range secret.OwnerReferences {
+		if ref.UID == sourceUID {
+			ref = resources.GetClusterRef(target)
+		}
+		ownerRefs = append(ownerRefs, ref)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            secret.Name,
+			Namespace:       secret.Namespace,
+			Labels:          secret.Labels,
+			Annotations:     secret.Annotations,
+			OwnerReferences: ownerRefs,
+		},
+		Type: secret.Type,
+		Data: secret.Data,
+	}
+}
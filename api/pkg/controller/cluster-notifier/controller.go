@@ -0,0 +1,453 @@
+package clusternotifier
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/util/retry"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ControllerName is the name of this very controller.
+	ControllerName = "kubermatic_cluster_notifier"
+
+	// AnnotationNameNotifiedState is set on clusters and holds the state of the cluster at the
+	// time of the last notifications, notifications are only sent when the state changes
+	AnnotationNameNotifiedState = "kubermatic.io/notified-state"
+	// AnnotationNamePendingNotifications is set on clusters and holds the notifications which couldn't be
+	// delivered yet, per channel and event. They get retried until they are delivered or replaced by a
+	// newer notification of the same event.
+	AnnotationNamePendingNotifications = "kubermatic.io/pending-notifications"
+
+	stateHealthy   = "Healthy"
+	stateUnhealthy = "Unhealthy"
+	stateFailed    = "Failed"
+	stateExpiring  = "Expiring"
+	stateDeleted   = "Deleted"
+)
+
+// Notification is sent to the notification channels of a project, webhooks receive it as JSON
+type Notification struct {
+	Event       kubermaticv1.NotificationEventType `json:"event"`
+	ProjectID   string                             `json:"projectID"`
+	ClusterID   string                             `json:"clusterID"`
+	ClusterName string                             `json:"clusterName"`
+	Seed        string                             `json:"seed"`
+	Summary     string                             `json:"summary"`
+	Message     string                             `json:"message,omitempty"`
+	Time        time.Time                          `json:"time"`
+}
+
+// pendingNotifications are the undelivered notifications per channel name and event
+type pendingNotifications map[string]map[kubermaticv1.NotificationEventType]Notification
+
+type notificationSender interface {
+	Send(ctx context.Context, channel kubermaticv1.NotificationChannel, notification Notification) error
+}
+
+// observation is the state of a cluster regarding a single event type, notifications are
+// sent when it differs from the notified one
+type observation struct {
+	state   string
+	summary string
+	message string
+}
+
+type reconciler struct {
+	ctx                     context.Context
+	log                     *zap.SugaredLogger
+	masterClient            ctrlruntimeclient.Client
+	seedClients             map[string]ctrlruntimeclient.Client
+	workerNameLabelSelector labels.Selector
+	sender                  notificationSender
+	now                     func() time.Time
+}
+
+// requestFromCluster returns a reconcile.Request for the given cluster, the name of the seed
+// is passed as namespace of the cluster scoped object
+func requestFromCluster(log *zap.SugaredLogger, seedName string) *handler.EnqueueRequestsFromMapFunc {
+	toRequestFunc := handler.ToRequestsFunc(func(mo handler.MapObject) []reconcile.Request {
+		cluster, ok := mo.Object.(*kubermaticv1.Cluster)
+		if !ok {
+			err := fmt.Errorf("Object was not a cluster but a %T", mo.Object)
+			log.Error(err)
+			utilruntime.HandleError(err)
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: seedName, Name: cluster.Name}}}
+	})
+	return &handler.EnqueueRequestsFromMapFunc{ToRequests: toRequestFunc}
+}
+
+// Add creates a new cluster notifier controller, which sends notifications to the channels of
+// the projects when the health or the conditions of their clusters change
+func Add(
+	ctx context.Context,
+	masterManager manager.Manager,
+	seedManagers map[string]manager.Manager,
+	log *zap.SugaredLogger,
+	numWorkers int,
+	workerNameLabelSelector labels.Selector,
+	smtpSettings SMTPSettings) error {
+
+	log = log.Named(ControllerName)
+	r := &reconciler{
+		ctx:                     ctx,
+		log:                     log,
+		masterClient:            masterManager.GetClient(),
+		seedClients:             map[string]ctrlruntimeclient.Client{},
+		workerNameLabelSelector: workerNameLabelSelector,
+		sender:                  newChannelSender(smtpSettings),
+		now:                     time.Now,
+	}
+
+	ctrlOpts := controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: numWorkers,
+	}
+	c, err := controller.New(ControllerName, masterManager, ctrlOpts)
+	if err != nil {
+		return fmt.Errorf("failed to construct controller: %v", err)
+	}
+
+	for seedName, seedManager := range seedManagers {
+		r.seedClients[seedName] = seedManager.GetClient()
+
+		seedClusterWatch := &source.Kind{Type: &kubermaticv1.Cluster{}}
+		if err := seedClusterWatch.InjectCache(seedManager.GetCache()); err != nil {
+			return fmt.Errorf("failed to inject cache for seed %q into watch: %v", seedName, err)
+		}
+		if err := c.Watch(seedClusterWatch, requestFromCluster(log, seedName)); err != nil {
+			return fmt.Errorf("failed to watch clusters in seed %q: %v", seedName, err)
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.With("seed", request.Namespace, "cluster", request.Name)
+	log.Debug("Processing")
+
+	err := r.reconcile(log, request.Namespace, request.Name)
+	if err != nil {
+		log.Errorw("ReconcilingError", zap.Error(err))
+	}
+	return reconcile.Result{}, err
+}
+
+func (r *reconciler) reconcile(log *zap.SugaredLogger, seedName, clusterName string) error {
+	seedClient, ok := r.seedClients[seedName]
+	if !ok {
+		return fmt.Errorf("no client for seed %q", seedName)
+	}
+
+	cluster := &kubermaticv1.Cluster{}
+	if err := seedClient.Get(r.ctx, types.NamespacedName{Name: clusterName}, cluster); err != nil {
+		if kerrors.IsNotFound(err) {
+			log.Debug("Didn't find cluster, returning")
+			return nil
+		}
+		return fmt.Errorf("failed to get cluster: %v", err)
+	}
+	if !r.workerNameLabelSelector.Matches(labels.Set(cluster.Labels)) {
+		log.Debug("Skipping because the cluster has a different worker name set")
+		return nil
+	}
+	projectID := cluster.Labels[kubermaticv1.ProjectIDLabelKey]
+	if projectID == "" {
+		log.Debug("Cluster has no project label")
+		return nil
+	}
+
+	rawNotifiedState, notifiedBefore := cluster.Annotations[AnnotationNameNotifiedState]
+	notifiedState := map[kubermaticv1.NotificationEventType]string{}
+	if notifiedBefore {
+		if err := json.Unmarshal([]byte(rawNotifiedState), &notifiedState); err != nil {
+			log.Warnw("Ignoring invalid notified state", zap.Error(err))
+		}
+	}
+
+	newState := map[kubermaticv1.NotificationEventType]string{}
+	var notifications []Notification
+	for eventType, current := range observe(cluster) {
+		previous, known := notifiedState[eventType]
+		var changed bool
+		switch eventType {
+		case kubermaticv1.NotificationEventClusterHealthChanged:
+			// Clusters are unhealthy while they get created, their health only matters after
+			// they were healthy once
+			if !known && current.state == stateUnhealthy {
+				continue
+			}
+			changed = known && previous != current.state
+		case kubermaticv1.NotificationEventClusterDeleted:
+			changed = previous != current.state
+		default:
+			// Only failures are notified. The initial state of a cluster doesn't trigger notifications,
+			// e.g. for failures which existed before the notifications got configured.
+			changed = notifiedBefore && current.state != "" && previous != current.state
+		}
+		if changed {
+			notifications = append(notifications, Notification{
+				Event:       eventType,
+				ProjectID:   projectID,
+				ClusterID:   cluster.Name,
+				ClusterName: cluster.Spec.HumanReadableName,
+				Seed:        seedName,
+				Summary:     current.summary,
+				Message:     current.message,
+				Time:        r.now(),
+			})
+		}
+		newState[eventType] = current.state
+	}
+
+	// Hibernated clusters keep the health state they had before
+	healthState, known := notifiedState[kubermaticv1.NotificationEventClusterHealthChanged]
+	if _, observed := newState[kubermaticv1.NotificationEventClusterHealthChanged]; known && !observed && cluster.DeletionTimestamp == nil {
+		newState[kubermaticv1.NotificationEventClusterHealthChanged] = healthState
+	}
+
+	rawPending, pendingBefore := cluster.Annotations[AnnotationNamePendingNotifications]
+	pending := pendingNotifications{}
+	if pendingBefore {
+		if err := json.Unmarshal([]byte(rawPending), &pending); err != nil {
+			log.Warnw("Ignoring invalid pending notifications", zap.Error(err))
+		}
+	}
+
+	// Failed deliveries don't prevent storing the new state, they are kept as pending notifications and
+	// only get retried for the channels they failed for
+	var notifyErr error
+	if len(notifications) > 0 || len(pending) > 0 {
+		sort.Slice(notifications, func(i, j int) bool {
+			return notifications[i].Event < notifications[j].Event
+		})
+		pending, notifyErr = r.notify(log, projectID, pending, notifications)
+		if notifyErr != nil && pending == nil {
+			// Nothing was sent, the state is not updated so all notifications get sent again
+			return notifyErr
+		}
+	}
+
+	rawNewState, err := json.Marshal(newState)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the notified state: %v", err)
+	}
+	var rawNewPending []byte
+	if len(pending) > 0 {
+		if rawNewPending, err = json.Marshal(pending); err != nil {
+			return fmt.Errorf("failed to marshal the pending notifications: %v", err)
+		}
+	}
+	if notifiedBefore && rawNotifiedState == string(rawNewState) && rawPending == string(rawNewPending) {
+		return notifyErr
+	}
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := seedClient.Get(r.ctx, types.NamespacedName{Name: clusterName}, cluster); err != nil {
+			return err
+		}
+		if cluster.Annotations == nil {
+			cluster.Annotations = map[string]string{}
+		}
+		cluster.Annotations[AnnotationNameNotifiedState] = string(rawNewState)
+		if len(rawNewPending) > 0 {
+			cluster.Annotations[AnnotationNamePendingNotifications] = string(rawNewPending)
+		} else {
+			delete(cluster.Annotations, AnnotationNamePendingNotifications)
+		}
+		return seedClient.Update(r.ctx, cluster)
+	})
+	if err != nil {
+		return err
+	}
+	// The error requeues the cluster to retry the pending notifications
+	return notifyErr
+}
+
+// notify sends the pending notifications and the new ones to all channels of the project which subscribed
+// to their events. It returns the notifications which couldn't be delivered, or nil if nothing was sent.
+func (r *reconciler) notify(log *zap.SugaredLogger, projectID string, pending pendingNotifications, notifications []Notification) (pendingNotifications, error) {
+	project := &kubermaticv1.Project{}
+	if err := r.masterClient.Get(r.ctx, types.NamespacedName{Name: projectID}, project); err != nil {
+		if kerrors.IsNotFound(err) {
+			log.Debug("Didn't find project, nobody gets notified")
+			return pendingNotifications{}, nil
+		}
+		return nil, fmt.Errorf("failed to get project %s: %v", projectID, err)
+	}
+
+	// We use an error aggregate to make sure we return an error if we encountered one but
+	// still notify all channels we can. Pending notifications of removed channels are dropped.
+	var errs []error
+	undelivered := pendingNotifications{}
+	for _, channel := range project.Spec.NotificationChannels {
+		deliveries := map[kubermaticv1.NotificationEventType]Notification{}
+		for eventType, notification := range pending[channel.Name] {
+			deliveries[eventType] = notification
+		}
+		for _, notification := range notifications {
+			if subscribed(channel, notification.Event) {
+				deliveries[notification.Event] = notification
+			}
+		}
+
+		eventTypes := make([]string, 0, len(deliveries))
+		for eventType := range deliveries {
+			eventTypes = append(eventTypes, string(eventType))
+		}
+		sort.Strings(eventTypes)
+		for _, eventType := range eventTypes {
+			notification := deliveries[kubermaticv1.NotificationEventType(eventType)]
+			log.Debugw("Sending notification", "channel", channel.Name, "event", notification.Event)
+			if err := r.sender.Send(r.ctx, channel, notification); err != nil {
+				errs = append(errs, fmt.Errorf("failed to send %s notification to channel %q: %v", notification.Event, channel.Name, err))
+				if undelivered[channel.Name] == nil {
+					undelivered[channel.Name] = map[kubermaticv1.NotificationEventType]Notification{}
+				}
+				undelivered[channel.Name][notification.Event] = notification
+			}
+		}
+	}
+	return undelivered, utilerrors.NewAggregate(errs)
+}
+
+func subscribed(channel kubermaticv1.NotificationChannel, eventType kubermaticv1.NotificationEventType) bool {
+	if len(channel.Events) == 0 {
+		return true
+	}
+	for _, subscribedType := range channel.Events {
+		if subscribedType == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// observe returns the current state of the cluster for every event type which can be observed
+func observe(cluster *kubermaticv1.Cluster) map[kubermaticv1.NotificationEventType]observation {
+	name := cluster.Spec.HumanReadableName
+	if cluster.DeletionTimestamp != nil {
+		return map[kubermaticv1.NotificationEventType]observation{
+			kubermaticv1.NotificationEventClusterDeleted: {state: stateDeleted, summary: fmt.Sprintf("Cluster %s is being deleted", name)},
+		}
+	}
+
+	observations := map[kubermaticv1.NotificationEventType]observation{}
+	// Hibernated clusters are unhealthy on purpose, they keep their last health state
+	if !kubermaticv1helper.IsClusterHibernated(cluster) {
+		if problems := healthProblems(cluster); len(problems) > 0 {
+			observations[kubermaticv1.NotificationEventClusterHealthChanged] = observation{
+				state:   stateUnhealthy,
+				summary: fmt.Sprintf("Cluster %s is unhealthy", name),
+				message: strings.Join(problems, "; "),
+			}
+		} else {
+			observations[kubermaticv1.NotificationEventClusterHealthChanged] = observation{
+				state:   stateHealthy,
+				summary: fmt.Sprintf("Cluster %s is healthy again", name),
+			}
+		}
+	}
+
+	if message, failed := reconcilingFailed(cluster, kubermaticv1.ClusterConditionUpdateControllerReconcilingSuccess); failed {
+		observations[kubermaticv1.NotificationEventClusterUpgradeFailed] = observation{
+			state:   stateFailed,
+			summary: fmt.Sprintf("Upgrading cluster %s failed", name),
+			message: message,
+		}
+	} else {
+		observations[kubermaticv1.NotificationEventClusterUpgradeFailed] = observation{}
+	}
+
+	var addonFailures []string
+	for _, conditionType := range []kubermaticv1.ClusterConditionType{
+		kubermaticv1.ClusterConditionAddonControllerReconcilingSuccess,
+		kubermaticv1.ClusterConditionAddonInstallerControllerReconcilingSuccess,
+	} {
+		if message, failed := reconcilingFailed(cluster, conditionType); failed {
+			addonFailures = append(addonFailures, message)
+		}
+	}
+	if len(addonFailures) > 0 {
+		observations[kubermaticv1.NotificationEventAddonFailed] = observation{
+			state:   stateFailed,
+			summary: fmt.Sprintf("Installing the addons of cluster %s failed", name),
+			message: strings.Join(addonFailures, "; "),
+		}
+	} else {
+		observations[kubermaticv1.NotificationEventAddonFailed] = observation{}
+	}
+
+	if _, condition := kubermaticv1helper.GetClusterCondition(cluster, kubermaticv1.ClusterConditionCertificatesValid); condition != nil && condition.Status == corev1.ConditionFalse {
+		observations[kubermaticv1.NotificationEventCertificateExpiring] = observation{
+			state:   stateExpiring,
+			summary: fmt.Sprintf("Certificates of cluster %s are about to expire", name),
+			message: condition.Message,
+		}
+	} else {
+		observations[kubermaticv1.NotificationEventCertificateExpiring] = observation{}
+	}
+
+	return observations
+}
+
+// healthProblems returns the unhealthy control plane components and the failed health probes
+func healthProblems(cluster *kubermaticv1.Cluster) []string {
+	var problems []string
+	health := cluster.Status.ExtendedHealth
+	for _, component := range []struct {
+		name   string
+		status kubermaticv1.HealthStatus
+	}{
+		{name: "apiserver", status: health.Apiserver},
+		{name: "scheduler", status: health.Scheduler},
+		{name: "controller-manager", status: health.Controller},
+		{name: "machine-controller", status: health.MachineController},
+		{name: "etcd", status: health.Etcd},
+		{name: "cloud provider infrastructure", status: health.CloudProviderInfrastructure},
+		{name: "user cluster controller manager", status: health.UserClusterControllerManager},
+	} {
+		if component.status != kubermaticv1.HealthStatusUp {
+			problems = append(problems, fmt.Sprintf("%s is down", component.name))
+		}
+	}
+
+	for _, conditionType := range kubermaticv1.HealthProbeConditionTypes {
+		if _, condition := kubermaticv1helper.GetClusterCondition(cluster, conditionType); condition != nil && condition.Status == corev1.ConditionFalse {
+			problems = append(problems, fmt.Sprintf("%s: %s", conditionType, condition.Message))
+		}
+	}
+	return problems
+}
+
+// reconcilingFailed returns the error of a controller whose last reconciling failed. Controllers
+// which only requeue the cluster, e.g. while waiting for the control plane, are not reported.
+func reconcilingFailed(cluster *kubermaticv1.Cluster, conditionType kubermaticv1.ClusterConditionType) (string, bool) {
+	_, condition := kubermaticv1helper.GetClusterCondition(cluster, conditionType)
+	if condition == nil || condition.Status != corev1.ConditionFalse || condition.Reason != kubermaticv1.ReasonReconcilingError {
+		return "", false
+	}
+	return condition.Message, true
+}
@@ -0,0 +1,314 @@
+package clusternotifier
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var testNow = time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+type sentNotification struct {
+	channel string
+	event   kubermaticv1.NotificationEventType
+	message string
+}
+
+type fakeSender struct {
+	sent []sentNotification
+	err  error
+	// failingChannel restricts the error to a single channel
+	failingChannel string
+}
+
+func (s *fakeSender) Send(_ context.Context, channel kubermaticv1.NotificationChannel, notification Notification) error {
+	if s.err != nil && (s.failingChannel == "" || s.failingChannel == channel.Name) {
+		return s.err
+	}
+	s.sent = append(s.sent, sentNotification{channel: channel.Name, event: notification.Event, message: notification.Message})
+	return nil
+}
+
+func healthyStatus() kubermaticv1.ClusterStatus {
+	return kubermaticv1.ClusterStatus{
+		ExtendedHealth: kubermaticv1.ExtendedClusterHealth{
+			Apiserver:                    kubermaticv1.HealthStatusUp,
+			Scheduler:                    kubermaticv1.HealthStatusUp,
+			Controller:                   kubermaticv1.HealthStatusUp,
+			MachineController:            kubermaticv1.HealthStatusUp,
+			Etcd:                         kubermaticv1.HealthStatusUp,
+			CloudProviderInfrastructure:  kubermaticv1.HealthStatusUp,
+			UserClusterControllerManager: kubermaticv1.HealthStatusUp,
+		},
+	}
+}
+
+func genCluster(notifiedState string, modify func(*kubermaticv1.Cluster)) *kubermaticv1.Cluster {
+	cluster := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "henrik",
+			Labels: map[string]string{kubermaticv1.ProjectIDLabelKey: "my-project"},
+		},
+		Spec:   kubermaticv1.ClusterSpec{HumanReadableName: "production"},
+		Status: healthyStatus(),
+	}
+	if notifiedState != "" {
+		cluster.Annotations = map[string]string{AnnotationNameNotifiedState: notifiedState}
+	}
+	if modify != nil {
+		modify(cluster)
+	}
+	return cluster
+}
+
+const allFineState = `{"AddonFailed":"","CertificateExpiring":"","ClusterHealthChanged":"Healthy","ClusterUpgradeFailed":""}`
+
+const pendingHealthNotification = `{"all":{"ClusterHealthChanged":{"event":"ClusterHealthChanged","projectID":"my-project","clusterID":"henrik","clusterName":"production","seed":"europe","summary":"Cluster production is unhealthy","message":"apiserver is down","time":"2019-10-01T12:00:00Z"}}}`
+
+func withPendingNotifications(pending string) func(*kubermaticv1.Cluster) {
+	return func(c *kubermaticv1.Cluster) {
+		c.Annotations[AnnotationNamePendingNotifications] = pending
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	project := &kubermaticv1.Project{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-project"},
+		Spec: kubermaticv1.ProjectSpec{
+			Name: "my project",
+			NotificationChannels: []kubermaticv1.NotificationChannel{
+				{Name: "all", Webhook: &kubermaticv1.WebhookNotificationChannel{URL: "https://example.com"}},
+				{Name: "deletions", Slack: &kubermaticv1.SlackNotificationChannel{URL: "https://example.com"}, Events: []kubermaticv1.NotificationEventType{kubermaticv1.NotificationEventClusterDeleted}},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name                  string
+		cluster               *kubermaticv1.Cluster
+		sendErr               error
+		failingChannel        string
+		expectedNotifications []sentNotification
+		expectedState         string
+		expectedPending       string
+		expectErr             bool
+	}{
+		{
+			name: "the initial state is stored without notifications",
+			cluster: genCluster("", func(c *kubermaticv1.Cluster) {
+				c.Status.Conditions = []kubermaticv1.ClusterCondition{
+					{Type: kubermaticv1.ClusterConditionAddonControllerReconcilingSuccess, Status: corev1.ConditionFalse, Reason: kubermaticv1.ReasonReconcilingError, Message: "failed"},
+				}
+			}),
+			expectedState: `{"AddonFailed":"Failed","CertificateExpiring":"","ClusterHealthChanged":"Healthy","ClusterUpgradeFailed":""}`,
+		},
+		{
+			name: "the health of new clusters is only stored once they are healthy",
+			cluster: genCluster("", func(c *kubermaticv1.Cluster) {
+				c.Status.ExtendedHealth.Etcd = kubermaticv1.HealthStatusProvisioning
+			}),
+			expectedState: `{"AddonFailed":"","CertificateExpiring":"","ClusterUpgradeFailed":""}`,
+		},
+		{
+			name: "unhealthy clusters are notified",
+			cluster: genCluster(allFineState, func(c *kubermaticv1.Cluster) {
+				c.Status.ExtendedHealth.Etcd = kubermaticv1.HealthStatusDown
+				c.Status.Conditions = []kubermaticv1.ClusterCondition{
+					{Type: kubermaticv1.ClusterConditionEtcdHealthy, Status: corev1.ConditionFalse, Message: "alarm NOSPACE raised by member 1234"},
+				}
+			}),
+			expectedNotifications: []sentNotification{
+				{channel: "all", event: kubermaticv1.NotificationEventClusterHealthChanged, message: "etcd is down; EtcdHealthy: alarm NOSPACE raised by member 1234"},
+			},
+			expectedState: `{"AddonFailed":"","CertificateExpiring":"","ClusterHealthChanged":"Unhealthy","ClusterUpgradeFailed":""}`,
+		},
+		{
+			name:    "clusters which are healthy again are notified",
+			cluster: genCluster(`{"ClusterHealthChanged":"Unhealthy"}`, nil),
+			expectedNotifications: []sentNotification{
+				{channel: "all", event: kubermaticv1.NotificationEventClusterHealthChanged},
+			},
+			expectedState: allFineState,
+		},
+		{
+			name: "failed upgrades and expiring certificates are notified",
+			cluster: genCluster(allFineState, func(c *kubermaticv1.Cluster) {
+				c.Status.Conditions = []kubermaticv1.ClusterCondition{
+					{Type: kubermaticv1.ClusterConditionUpdateControllerReconcilingSuccess, Status: corev1.ConditionFalse, Reason: kubermaticv1.ReasonReconcilingError, Message: "no version available"},
+					{Type: kubermaticv1.ClusterConditionCertificatesValid, Status: corev1.ConditionFalse, Message: "apiserver-tls/apiserver-tls.crt expires soon"},
+				}
+			}),
+			expectedNotifications: []sentNotification{
+				{channel: "all", event: kubermaticv1.NotificationEventCertificateExpiring, message: "apiserver-tls/apiserver-tls.crt expires soon"},
+				{channel: "all", event: kubermaticv1.NotificationEventClusterUpgradeFailed, message: "no version available"},
+			},
+			expectedState: `{"AddonFailed":"","CertificateExpiring":"Expiring","ClusterHealthChanged":"Healthy","ClusterUpgradeFailed":"Failed"}`,
+		},
+		{
+			name: "controllers which only requeue the cluster are not notified",
+			cluster: genCluster(allFineState, func(c *kubermaticv1.Cluster) {
+				c.Status.Conditions = []kubermaticv1.ClusterCondition{
+					{Type: kubermaticv1.ClusterConditionAddonInstallerControllerReconcilingSuccess, Status: corev1.ConditionFalse},
+				}
+			}),
+			expectedState: allFineState,
+		},
+		{
+			name: "deleted clusters are notified",
+			cluster: genCluster(allFineState, func(c *kubermaticv1.Cluster) {
+				now := metav1.NewTime(testNow)
+				c.DeletionTimestamp = &now
+			}),
+			expectedNotifications: []sentNotification{
+				{channel: "all", event: kubermaticv1.NotificationEventClusterDeleted},
+				{channel: "deletions", event: kubermaticv1.NotificationEventClusterDeleted},
+			},
+			expectedState: `{"ClusterDeleted":"Deleted"}`,
+		},
+		{
+			name: "notifications which can't be sent are kept as pending",
+			cluster: genCluster(allFineState, func(c *kubermaticv1.Cluster) {
+				c.Status.ExtendedHealth.Apiserver = kubermaticv1.HealthStatusDown
+			}),
+			sendErr:         errors.New("connection refused"),
+			expectedState:   `{"AddonFailed":"","CertificateExpiring":"","ClusterHealthChanged":"Unhealthy","ClusterUpgradeFailed":""}`,
+			expectedPending: pendingHealthNotification,
+			expectErr:       true,
+		},
+		{
+			name: "a failing channel doesn't prevent the delivery to the other channels",
+			cluster: genCluster(allFineState, func(c *kubermaticv1.Cluster) {
+				now := metav1.NewTime(testNow)
+				c.DeletionTimestamp = &now
+			}),
+			sendErr:        errors.New("connection refused"),
+			failingChannel: "deletions",
+			expectedNotifications: []sentNotification{
+				{channel: "all", event: kubermaticv1.NotificationEventClusterDeleted},
+			},
+			expectedState:   `{"ClusterDeleted":"Deleted"}`,
+			expectedPending: `{"deletions":{"ClusterDeleted":{"event":"ClusterDeleted","projectID":"my-project","clusterID":"henrik","clusterName":"production","seed":"europe","summary":"Cluster production is being deleted","time":"2019-10-01T12:00:00Z"}}}`,
+			expectErr:       true,
+		},
+		{
+			name:    "pending notifications are only retried for their channel",
+			cluster: genCluster(allFineState, withPendingNotifications(pendingHealthNotification)),
+			expectedNotifications: []sentNotification{
+				{channel: "all", event: kubermaticv1.NotificationEventClusterHealthChanged, message: "apiserver is down"},
+			},
+			expectedState: allFineState,
+		},
+		{
+			name:          "pending notifications of removed channels are dropped",
+			cluster:       genCluster(allFineState, withPendingNotifications(`{"removed":{"ClusterHealthChanged":{"event":"ClusterHealthChanged"}}}`)),
+			expectedState: allFineState,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seedClient := ctrlruntimefake.NewFakeClient(tc.cluster)
+			sender := &fakeSender{err: tc.sendErr, failingChannel: tc.failingChannel}
+			r := &reconciler{
+				ctx:                     context.Background(),
+				log:                     kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar(),
+				masterClient:            ctrlruntimefake.NewFakeClient(project),
+				seedClients:             map[string]ctrlruntimeclient.Client{"europe": seedClient},
+				workerNameLabelSelector: labels.Everything(),
+				sender:                  sender,
+				now:                     func() time.Time { return testNow },
+			}
+
+			err := r.reconcile(r.log, "europe", tc.cluster.Name)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected an error: %v, got: %v", tc.expectErr, err)
+			}
+			if diff := deep.Equal(sender.sent, tc.expectedNotifications); diff != nil {
+				t.Errorf("sent notifications differ from the expected ones, diff: %v", diff)
+			}
+
+			cluster := &kubermaticv1.Cluster{}
+			if err := seedClient.Get(context.Background(), types.NamespacedName{Name: tc.cluster.Name}, cluster); err != nil {
+				t.Fatalf("failed to get cluster: %v", err)
+			}
+			if state := cluster.Annotations[AnnotationNameNotifiedState]; state != tc.expectedState {
+				t.Errorf("expected the notified state %s, got %s", tc.expectedState, state)
+			}
+			if pending := cluster.Annotations[AnnotationNamePendingNotifications]; pending != tc.expectedPending {
+				t.Errorf("expected the pending notifications %s, got %s", tc.expectedPending, pending)
+			}
+		})
+	}
+}
+
+func TestSendWebhook(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received = string(body)
+		if strings.Contains(received, "fail") {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	sender := newChannelSender(SMTPSettings{})
+	channel := kubermaticv1.NotificationChannel{Name: "slack", Slack: &kubermaticv1.SlackNotificationChannel{URL: server.URL, Channel: "#alerts"}}
+	notification := Notification{Event: kubermaticv1.NotificationEventClusterDeleted, Summary: "Cluster production is being deleted"}
+	if err := sender.Send(context.Background(), channel, notification); err == nil || received != "" {
+		t.Fatalf("expected the test server on the loopback address to be refused")
+	}
+
+	// The test server listens on the loopback address, which is only allowed here
+	sender.httpClient = server.Client()
+	if err := sender.Send(context.Background(), channel, notification); err != nil {
+		t.Fatalf("failed to send notification: %v", err)
+	}
+	if expected := `{"channel":"#alerts","text":"*Cluster production is being deleted*"}`; received != expected {
+		t.Errorf("expected the Slack message %s, got %s", expected, received)
+	}
+
+	notification.Summary = "fail"
+	if err := sender.Send(context.Background(), channel, notification); err == nil {
+		t.Errorf("expected an error when the webhook rejects the notification")
+	}
+
+	email := kubermaticv1.NotificationChannel{Name: "email", Email: &kubermaticv1.EmailNotificationChannel{To: []string{"ops@example.com"}}}
+	if err := sender.Send(context.Background(), email, notification); err == nil {
+		t.Errorf("expected an error when sending emails without SMTP server")
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	notification := Notification{
+		Event:       kubermaticv1.NotificationEventClusterDeleted,
+		ClusterName: "prod\r\nBcc: victim@example.com",
+		Summary:     "Cluster prod\r\nBcc: victim@example.com is being deleted",
+		Time:        testNow,
+	}
+	message := string(emailMessage("kubermatic@example.com", []string{"ops@example.com"}, notification))
+	headers := strings.SplitN(message, "\r\n\r\n", 2)[0]
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Fatalf("the summary injected a header:\n%s", headers)
+	}
+	if expected := "Subject: [Kubermatic] Cluster prod  Bcc: victim@example.com is being deleted\r\n"; !strings.Contains(headers, expected) {
+		t.Errorf("expected the header %q, got:\n%s", expected, headers)
+	}
+}
@@ -0,0 +1,150 @@
+package clusternotifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"syscall"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/util/network"
+)
+
+// SMTPSettings configure the SMTP server which sends the notifications of email channels
+type SMTPSettings struct {
+	// Address is the host:port of the SMTP server, email notifications are disabled if empty
+	Address  string
+	From     string
+	Username string
+	Password string
+}
+
+// denyInternalAddresses refuses connections to internal IPs. It runs after the host name got resolved,
+// hence it also applies to host names pointing to internal IPs and to redirects.
+func denyInternalAddresses(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || network.IsInternalIP(ip) {
+		return fmt.Errorf("connecting to the internal address %s is not allowed", host)
+	}
+	return nil
+}
+
+// channelSender sends notifications to the webhook, Slack and email channels
+type channelSender struct {
+	httpClient *http.Client
+	smtp       SMTPSettings
+}
+
+func newChannelSender(smtpSettings SMTPSettings) *channelSender {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: denyInternalAddresses,
+	}
+	return &channelSender{
+		httpClient: &http.Client{
+			// No proxy is used, the address of every connection must be checked
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			Timeout: 10 * time.Second,
+		},
+		smtp: smtpSettings,
+	}
+}
+
+func (s *channelSender) Send(ctx context.Context, channel kubermaticv1.NotificationChannel, notification Notification) error {
+	switch {
+	case channel.Webhook != nil:
+		return s.post(ctx, channel.Webhook.URL, notification)
+	case channel.Slack != nil:
+		return s.post(ctx, channel.Slack.URL, slackMessage(channel.Slack, notification))
+	case channel.Email != nil:
+		return s.sendEmail(channel.Email, notification)
+	}
+	return errors.New("the channel has no webhook, Slack or email configuration")
+}
+
+// slackMessage returns the payload of a Slack incoming webhook for the notification
+func slackMessage(slack *kubermaticv1.SlackNotificationChannel, notification Notification) interface{} {
+	text := fmt.Sprintf("*%s*", notification.Summary)
+	if notification.Message != "" {
+		text = fmt.Sprintf("%s\n%s", text, notification.Message)
+	}
+	return struct {
+		Channel string `json:"channel,omitempty"`
+		Text    string `json:"text"`
+	}{
+		Channel: slack.Channel,
+		Text:    text,
+	}
+}
+
+func (s *channelSender) post(ctx context.Context, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the payload: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("got status code %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+func (s *channelSender) sendEmail(email *kubermaticv1.EmailNotificationChannel, notification Notification) error {
+	if s.smtp.Address == "" {
+		return errors.New("no SMTP server is configured")
+	}
+	var auth smtp.Auth
+	if s.smtp.Username != "" {
+		host, _, err := net.SplitHostPort(s.smtp.Address)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address: %v", err)
+		}
+		auth = smtp.PlainAuth("", s.smtp.Username, s.smtp.Password, host)
+	}
+	return smtp.SendMail(s.smtp.Address, auth, s.smtp.From, email.To, emailMessage(s.smtp.From, email.To, notification))
+}
+
+// emailMessage returns the RFC 822 message for the notification
+func emailMessage(from string, to []string, notification Notification) []byte {
+	message := &bytes.Buffer{}
+	fmt.Fprintf(message, "From: %s\r\n", from)
+	fmt.Fprintf(message, "To: %s\r\n", strings.Join(to, ", "))
+	// The summary contains the name of the cluster, which is chosen by the user. Line breaks are removed
+	// and the remaining non-ASCII characters get encoded, so it can't inject further headers.
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(notification.Summary)
+	fmt.Fprintf(message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[Kubermatic] "+subject))
+	fmt.Fprintf(message, "Date: %s\r\n", notification.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(message, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	fmt.Fprintf(message, "%s\r\n\r\n", notification.Summary)
+	if notification.Message != "" {
+		fmt.Fprintf(message, "%s\r\n\r\n", notification.Message)
+	}
+	fmt.Fprintf(message, "Project: %s\r\nCluster: %s (%s)\r\nSeed: %s\r\nEvent: %s\r\n",
+		notification.ProjectID, notification.ClusterName, notification.ClusterID, notification.Seed, notification.Event)
+	return message.Bytes()
+}
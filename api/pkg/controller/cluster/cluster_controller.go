@@ -3,11 +3,13 @@ package cluster
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"go.uber.org/zap"
 
 	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
 	"github.com/kubermatic/kubermatic/api/pkg/clusterdeletion"
 	controllerutil "github.com/kubermatic/kubermatic/api/pkg/controller/util"
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
@@ -15,8 +17,8 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/provider"
 
 	appsv1 "k8s.io/api/apps/v1"
-	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -43,6 +45,12 @@ type userClusterConnectionProvider interface {
 	GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error)
 }
 
+// etcdTransportCache offers the transports to talk to the etcd members of the clusters
+type etcdTransportCache interface {
+	Transport(context.Context, ctrlruntimeclient.Client, *kubermaticv1.Cluster) (*http.Transport, error)
+	Forget(clusterName string)
+}
+
 type Features struct {
 	VPA                          bool
 	EtcdDataCorruptionChecks     bool
@@ -61,6 +69,9 @@ type Reconciler struct {
 
 	recorder record.EventRecorder
 
+	// etcdTransports keeps the connections to the etcd members while the etcd gets scaled
+	etcdTransports etcdTransportCache
+
 	overwriteRegistry                                string
 	nodePortRange                                    string
 	nodeAccessNetwork                                string
@@ -75,6 +86,8 @@ type Reconciler struct {
 	kubermaticImage                                  string
 	dnatControllerImage                              string
 	concurrentClusterUpdates                         int
+	// dryRun skips everything that changes more than objects, the dry-run clients record their changes
+	dryRun bool
 
 	oidcCAFile         string
 	oidcIssuerURL      string
@@ -104,6 +117,7 @@ func Add(
 	dockerPullConfigJSON []byte,
 	nodeLocalDNSCacheEnabled bool,
 	concurrentClusterUpdates int,
+	dryRun bool,
 
 	oidcCAFile string,
 	oidcIssuerURL string,
@@ -118,7 +132,8 @@ func Add(
 		userClusterConnProvider: userClusterConnProvider,
 		workerName:              workerName,
 
-		recorder: mgr.GetRecorder(ControllerName),
+		recorder:       mgr.GetRecorder(ControllerName),
+		etcdTransports: etcdgateway.NewTransportCache(),
 
 		overwriteRegistry:                      overwriteRegistry,
 		nodePortRange:                          nodePortRange,
@@ -134,6 +149,7 @@ func Add(
 		kubermaticImage:                                  kubermaticImage,
 		dnatControllerImage:                              dnatControllerImage,
 		concurrentClusterUpdates:                         concurrentClusterUpdates,
+		dryRun:                                           dryRun,
 
 		externalURL: externalURL,
 		seedGetter:  seedGetter,
@@ -157,8 +173,8 @@ func Add(
 		&corev1.Namespace{},
 		&appsv1.StatefulSet{},
 		&appsv1.Deployment{},
-		&batchv1beta1.CronJob{},
 		&policyv1beta1.PodDisruptionBudget{},
+		&networkingv1.NetworkPolicy{},
 		&autoscalingv1beta2.VerticalPodAutoscaler{},
 	}
 
@@ -226,6 +242,7 @@ func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, err
 func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) (*reconcile.Result, error) {
 	if cluster.DeletionTimestamp != nil {
 		log.Debug("Cleaning up cluster")
+		r.etcdTransports.Forget(cluster.Name)
 
 		// Defer getting the client to make sure we only request it if we actually need it
 		userClusterClientGetter := func() (ctrlruntimeclient.Client, error) {
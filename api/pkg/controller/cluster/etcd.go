@@ -0,0 +1,236 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// etcdScalingCheckPeriod is the interval in which the membership gets checked while the etcd gets scaled
+	etcdScalingCheckPeriod = 10 * time.Second
+	etcdRequestTimeout     = 10 * time.Second
+)
+
+// etcdMember is a member of the etcd cluster as returned by the gRPC gateway of etcd
+type etcdMember struct {
+	ID       string   `json:"ID"`
+	Name     string   `json:"name"`
+	PeerURLs []string `json:"peerURLs"`
+}
+
+// started tells if the member started, members that were added but didn't start have no name
+func (m etcdMember) started() bool {
+	return m.Name != ""
+}
+
+// podName returns the name of the pod of the member, which is the first label of its peer URL
+func (m etcdMember) podName() string {
+	for _, peerURL := range m.PeerURLs {
+		if u, err := url.Parse(peerURL); err == nil && u.Hostname() != "" {
+			return strings.Split(u.Hostname(), ".")[0]
+		}
+	}
+	return m.Name
+}
+
+// reconcileEtcdClusterSize removes the members of the etcd cluster that exceed the configured size and
+// reports the membership in the cluster status. The etcd StatefulSet gets scaled by one pod at a time
+// based on this status, and the launch script of new pods adds their member.
+func (r *Reconciler) reconcileEtcdClusterSize(ctx context.Context, cluster *kubermaticv1.Cluster) (*reconcile.Result, error) {
+	// The dry-run clients only record the changes of objects, the members would get removed for real
+	if r.dryRun {
+		return nil, nil
+	}
+
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.EtcdStatefulSetName}, statefulSet); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get the etcd StatefulSet: %v", err)
+	}
+	if statefulSet.Spec.Replicas == nil {
+		return nil, nil
+	}
+	replicas := int(*statefulSet.Spec.Replicas)
+	size := etcd.ClusterSize(cluster)
+	sizeErr := etcd.ValidateClusterSize(cluster.Spec.ComponentsOverride.Etcd.ClusterSize)
+	if status := cluster.Status.Etcd; sizeErr == nil && status != nil && status.Phase == kubermaticv1.EtcdClusterRunning &&
+		replicas == size && len(status.Members) == size {
+		return nil, nil
+	}
+
+	transport, err := r.etcdTransports.Transport(ctx, r, cluster)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport, Timeout: etcdRequestTimeout}
+	var memberURLs []string
+	for i := 0; i < replicas; i++ {
+		memberURLs = append(memberURLs, etcdgateway.MemberURL(cluster, i))
+	}
+	members, err := listEtcdMembers(ctx, client, memberURLs)
+	if err != nil {
+		// No member might be up yet, e.g. while the cluster gets created
+		r.log.Debugw("Failed to list the etcd members", "cluster", cluster.Name, "error", err)
+		return &reconcile.Result{RequeueAfter: etcdScalingCheckPeriod}, nil
+	}
+
+	status := &kubermaticv1.EtcdClusterStatus{}
+	if replicas > size {
+		status.Message, err = r.removeLastEtcdMember(ctx, cluster, client, replicas, members)
+		if err != nil {
+			return nil, err
+		}
+		if status.Message == "" {
+			// The member of the last pod is gone, the StatefulSet gets scaled down next
+			if members, err = listEtcdMembers(ctx, client, memberURLs); err != nil {
+				return nil, fmt.Errorf("failed to list the etcd members: %v", err)
+			}
+		}
+	} else if replicas < size && cluster.Status.ExtendedHealth.Etcd != kubermaticv1.HealthStatusUp {
+		status.Message = "Waiting for all etcd members to be ready before adding a member"
+	}
+	if sizeErr != nil {
+		status.Message = fmt.Sprintf("Using the default size of %d members: %v", size, sizeErr)
+	}
+	for _, member := range members {
+		status.Members = append(status.Members, member.podName())
+	}
+	status.Phase = etcdClusterPhase(size, replicas, len(members))
+
+	if err := r.updateEtcdClusterStatus(ctx, cluster, status); err != nil {
+		return nil, err
+	}
+	if status.Phase != kubermaticv1.EtcdClusterRunning {
+		return &reconcile.Result{RequeueAfter: etcdScalingCheckPeriod}, nil
+	}
+	return nil, nil
+}
+
+// etcdClusterPhase returns the phase of an etcd cluster that has the given number of pods and members
+func etcdClusterPhase(size, replicas, members int) kubermaticv1.EtcdClusterPhase {
+	switch {
+	case replicas > size || members > size:
+		return kubermaticv1.EtcdClusterScalingDown
+	case replicas < size || members < size:
+		return kubermaticv1.EtcdClusterScalingUp
+	default:
+		return kubermaticv1.EtcdClusterRunning
+	}
+}
+
+// removeLastEtcdMember removes the member of the last pod of the StatefulSet. To not lose the quorum,
+// the member only gets removed while all members are started and healthy. It returns why the member
+// wasn't removed yet.
+func (r *Reconciler) removeLastEtcdMember(ctx context.Context, cluster *kubermaticv1.Cluster, client *http.Client, replicas int, members []etcdMember) (string, error) {
+	lastMember := etcd.MemberName(replicas - 1)
+	var toRemove *etcdMember
+	for i := range members {
+		if members[i].podName() == lastMember {
+			toRemove = &members[i]
+		}
+	}
+	if toRemove == nil {
+		// The member was removed already, but deleting its volume claim might have failed
+		return "", r.deleteEtcdDataVolumeClaim(ctx, cluster, replicas-1)
+	}
+
+	if message := checkEtcdQuorumSafety(replicas, members); message != "" {
+		return message, nil
+	}
+	for i := 0; i < replicas; i++ {
+		if err := etcdgateway.CheckHealth(ctx, client, etcdgateway.MemberURL(cluster, i)); err != nil {
+			return fmt.Sprintf("Waiting for member %s to be healthy before removing member %s: %v", etcd.MemberName(i), lastMember, err), nil
+		}
+	}
+
+	if err := removeEtcdMember(ctx, client, etcdgateway.MemberURL(cluster, 0), toRemove.ID); err != nil {
+		return "", fmt.Errorf("failed to remove etcd member %s: %v", lastMember, err)
+	}
+	r.recorder.Eventf(cluster, corev1.EventTypeNormal, "EtcdMemberRemoved", "Removed etcd member %s to scale the etcd down to %d members", lastMember, etcd.ClusterSize(cluster))
+	return "", r.deleteEtcdDataVolumeClaim(ctx, cluster, replicas-1)
+}
+
+// deleteEtcdDataVolumeClaim deletes the data volume of a removed member, so that a member which gets added
+// later on doesn't start with its data. The claim is only gone once the pod got deleted by the scale down.
+func (r *Reconciler) deleteEtcdDataVolumeClaim(ctx context.Context, cluster *kubermaticv1.Cluster, member int) error {
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Status.NamespaceName, Name: etcd.DataVolumeClaimName(member)},
+	}
+	if err := r.Delete(ctx, claim); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete the volume claim of etcd member %s: %v", etcd.MemberName(member), err)
+	}
+	return nil
+}
+
+// checkEtcdQuorumSafety tells why removing a member could make the cluster lose its quorum,
+// which is the case unless every pod of the StatefulSet is a started member
+func checkEtcdQuorumSafety(replicas int, members []etcdMember) string {
+	if len(members) != replicas {
+		return fmt.Sprintf("Waiting for the etcd to have %d members before removing one, it has %d", replicas, len(members))
+	}
+	for _, member := range members {
+		if !member.started() {
+			return fmt.Sprintf("Waiting for member %s to start before removing a member", member.podName())
+		}
+	}
+	return ""
+}
+
+func (r *Reconciler) updateEtcdClusterStatus(ctx context.Context, cluster *kubermaticv1.Cluster, status *kubermaticv1.EtcdClusterStatus) error {
+	current := cluster.Status.Etcd
+	if current != nil && current.Phase == status.Phase {
+		status.LastTransitionTime = current.LastTransitionTime
+	} else {
+		status.LastTransitionTime = metav1.Now()
+	}
+	if current != nil && current.Phase == status.Phase && current.Message == status.Message &&
+		strings.Join(current.Members, ",") == strings.Join(status.Members, ",") {
+		return nil
+	}
+
+	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		c.Status.Etcd = status
+	})
+}
+
+// listEtcdMembers lists the members through the gRPC gateway of the first member that answers
+func listEtcdMembers(ctx context.Context, client *http.Client, memberURLs []string) ([]etcdMember, error) {
+	var errs []string
+	for _, memberURL := range memberURLs {
+		memberList := struct {
+			Members []etcdMember `json:"members"`
+		}{}
+		if err := etcdgateway.Request(ctx, client, memberURL+"/v3beta/cluster/member/list", struct{}{}, &memberList); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", memberURL, err))
+			continue
+		}
+		return memberList.Members, nil
+	}
+	return nil, errors.New(strings.Join(errs, "; "))
+}
+
+func removeEtcdMember(ctx context.Context, client *http.Client, memberURL, id string) error {
+	request := struct {
+		ID string `json:"ID"`
+	}{ID: id}
+	return etcdgateway.Request(ctx, client, memberURL+"/v3beta/cluster/member/remove", request, nil)
+}
@@ -0,0 +1,141 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeEtcdTransportCache sends the requests to all etcd members to the same server
+type fakeEtcdTransportCache struct {
+	addr string
+}
+
+func (c *fakeEtcdTransportCache) Transport(context.Context, ctrlruntimeclient.Client, *kubermaticv1.Cluster) (*http.Transport, error) {
+	return &http.Transport{
+		// The server speaks plain HTTP, the connection gets used as if it was a TLS connection
+		DialTLS: func(network, _ string) (net.Conn, error) {
+			return net.Dial(network, c.addr)
+		},
+	}, nil
+}
+
+func (c *fakeEtcdTransportCache) Forget(string) {}
+
+// fakeEtcdGateway serves the gRPC gateway of an etcd cluster whose members are all healthy
+type fakeEtcdGateway struct {
+	members []etcdMember
+
+	lock    sync.Mutex
+	removed []string
+}
+
+func (g *fakeEtcdGateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var response interface{}
+	switch req.URL.Path {
+	case "/health":
+		response = map[string]string{"health": "true"}
+	case "/v3beta/cluster/member/list":
+		response = map[string][]etcdMember{"members": g.members}
+	case "/v3beta/cluster/member/remove":
+		request := struct {
+			ID string `json:"ID"`
+		}{}
+		if err := json.NewDecoder(req.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		g.lock.Lock()
+		g.removed = append(g.removed, request.ID)
+		g.lock.Unlock()
+		response = struct{}{}
+	default:
+		http.NotFound(w, req)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func TestReconcileEtcdClusterSizeDryRun(t *testing.T) {
+	const replicas = 5
+
+	testCases := []struct {
+		name            string
+		dryRun          bool
+		expectedRemoved []string
+	}{
+		{
+			name:            "scaling down removes the last member",
+			expectedRemoved: []string{"4"},
+		},
+		{
+			name:   "dry-run doesn't remove a member",
+			dryRun: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := &kubermaticv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "lg69pmx8wf"},
+				Spec: kubermaticv1.ClusterSpec{
+					ComponentsOverride: kubermaticv1.ComponentSettings{
+						Etcd: kubermaticv1.EtcdStatefulSetSettings{ClusterSize: 3},
+					},
+				},
+				Status: kubermaticv1.ClusterStatus{NamespaceName: "cluster-lg69pmx8wf"},
+			}
+			statefulSet := &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: cluster.Status.NamespaceName, Name: resources.EtcdStatefulSetName},
+				Spec:       appsv1.StatefulSetSpec{Replicas: utilpointer.Int32Ptr(replicas)},
+			}
+
+			gateway := &fakeEtcdGateway{}
+			for i := 0; i < replicas; i++ {
+				gateway.members = append(gateway.members, etcdMember{
+					ID:       fmt.Sprintf("%d", i),
+					Name:     etcd.MemberName(i),
+					PeerURLs: []string{fmt.Sprintf("http://%s.etcd.%s.svc.cluster.local:2380", etcd.MemberName(i), cluster.Status.NamespaceName)},
+				})
+			}
+			server := httptest.NewServer(gateway)
+			defer server.Close()
+
+			r := &Reconciler{
+				Client:         ctrlruntimefake.NewFakeClient(cluster.DeepCopy(), statefulSet),
+				log:            kubermaticlog.Logger,
+				recorder:       record.NewFakeRecorder(10),
+				etcdTransports: &fakeEtcdTransportCache{addr: server.Listener.Addr().String()},
+				dryRun:         tc.dryRun,
+			}
+			if _, err := r.reconcileEtcdClusterSize(context.Background(), cluster); err != nil {
+				t.Fatalf("failed to reconcile the etcd cluster size: %v", err)
+			}
+
+			gateway.lock.Lock()
+			defer gateway.lock.Unlock()
+			if fmt.Sprint(gateway.removed) != fmt.Sprint(tc.expectedRemoved) {
+				t.Errorf("expected the members %v to be removed, got %v", tc.expectedRemoved, gateway.removed)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// gatekeeperRemovalCheckPeriod is the interval in which the user cluster gets checked for the Gatekeeper
+// webhook while Gatekeeper waits for its removal
+const gatekeeperRemovalCheckPeriod = 10 * time.Second
+
+// removeGatekeeper removes Gatekeeper from the control plane once the OPA integration got disabled. The
+// usercluster-controller removes the webhook configuration from the user cluster first, Gatekeeper only
+// gets removed once it is gone, as the API server would keep calling the webhook until then.
+func (r *Reconciler) removeGatekeeper(ctx context.Context, cluster *kubermaticv1.Cluster) (*reconcile.Result, error) {
+	if gatekeeper.IsEnabled(cluster) {
+		return nil, nil
+	}
+
+	ns := cluster.Status.NamespaceName
+	candidates := map[types.NamespacedName]runtime.Object{
+		{Namespace: ns, Name: resources.GatekeeperDeploymentName}:               &appsv1.Deployment{},
+		{Namespace: ns, Name: resources.GatekeeperWebhookServiceName}:           &corev1.Service{},
+		{Namespace: ns, Name: resources.GatekeeperWebhookServingCertSecretName}: &corev1.Secret{},
+	}
+	leftovers := map[types.NamespacedName]runtime.Object{}
+	for name, obj := range candidates {
+		if err := r.Get(ctx, name, obj); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get %T %s: %v", obj, name.String(), err)
+		}
+		leftovers[name] = obj
+	}
+	if len(leftovers) == 0 {
+		return nil, nil
+	}
+
+	client, err := r.userClusterConnProvider.GetClient(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user cluster client: %v", err)
+	}
+	webhookConfiguration := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+	if err := client.Get(ctx, types.NamespacedName{Name: resources.GatekeeperValidatingWebhookConfigurationName}, webhookConfiguration); err == nil {
+		r.log.Debugw("Waiting for the Gatekeeper webhook to be removed from the user cluster", "cluster", cluster.Name)
+		return &reconcile.Result{RequeueAfter: gatekeeperRemovalCheckPeriod}, nil
+	} else if !kerrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get the Gatekeeper ValidatingWebhookConfiguration: %v", err)
+	}
+
+	for name, obj := range leftovers {
+		if err := r.Delete(ctx, obj); err != nil && !kerrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to delete %T %s: %v", obj, name.String(), err)
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,101 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	k8cuserclusterclient "github.com/kubermatic/kubermatic/api/pkg/cluster/client"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+type fakeUserClusterConnectionProvider struct {
+	client ctrlruntimeclient.Client
+}
+
+func (p *fakeUserClusterConnectionProvider) GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error) {
+	return p.client, nil
+}
+
+func TestRemoveGatekeeper(t *testing.T) {
+	const ns = "cluster-test"
+	gatekeeperObjects := func() []runtime.Object {
+		return []runtime.Object{
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: resources.GatekeeperDeploymentName}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: resources.GatekeeperWebhookServiceName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: resources.GatekeeperWebhookServingCertSecretName}},
+		}
+	}
+	webhookConfiguration := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: resources.GatekeeperValidatingWebhookConfigurationName},
+	}
+
+	testCases := []struct {
+		name               string
+		enabled            bool
+		userObjects        []runtime.Object
+		expectedRequeue    bool
+		expectedGatekeeper bool
+	}{
+		{
+			name:               "enabled integration keeps Gatekeeper",
+			enabled:            true,
+			userObjects:        []runtime.Object{webhookConfiguration.DeepCopy()},
+			expectedGatekeeper: true,
+		},
+		{
+			name:               "Gatekeeper stays until the webhook is gone",
+			userObjects:        []runtime.Object{webhookConfiguration.DeepCopy()},
+			expectedRequeue:    true,
+			expectedGatekeeper: true,
+		},
+		{
+			name: "Gatekeeper gets removed once the webhook is gone",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cluster := &kubermaticv1.Cluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec:       kubermaticv1.ClusterSpec{OPAIntegration: &kubermaticv1.OPAIntegrationSettings{Enabled: tc.enabled}},
+				Status:     kubermaticv1.ClusterStatus{NamespaceName: ns},
+			}
+			r := &Reconciler{
+				Client:                  ctrlruntimefake.NewFakeClient(gatekeeperObjects()...),
+				log:                     kubermaticlog.Logger,
+				userClusterConnProvider: &fakeUserClusterConnectionProvider{client: ctrlruntimefake.NewFakeClient(tc.userObjects...)},
+			}
+
+			result, err := r.removeGatekeeper(context.Background(), cluster)
+			if err != nil {
+				t.Fatalf("failed to remove Gatekeeper: %v", err)
+			}
+			if requeue := result != nil && result.RequeueAfter > 0; requeue != tc.expectedRequeue {
+				t.Errorf("expected a requeue: %t, got %t", tc.expectedRequeue, requeue)
+			}
+
+			for _, obj := range gatekeeperObjects() {
+				accessor := obj.(metav1.Object)
+				err := r.Get(context.Background(), types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}, obj)
+				if err != nil && !kerrors.IsNotFound(err) {
+					t.Fatalf("failed to get %T %s: %v", obj, accessor.GetName(), err)
+				}
+				if exists := err == nil; exists != tc.expectedGatekeeper {
+					t.Errorf("expected %T %s to exist: %t, got %t", obj, accessor.GetName(), tc.expectedGatekeeper, exists)
+				}
+			}
+		})
+	}
+}
@@ -24,8 +24,9 @@ func (r *Reconciler) clusterHealth(ctx context.Context, cluster *kubermaticv1.Cl
 		resources.ControllerManagerDeploymentName:     {healthStatus: &extendedHealth.Controller, minReady: 1},
 		resources.SchedulerDeploymentName:             {healthStatus: &extendedHealth.Scheduler, minReady: 1},
 		resources.MachineControllerDeploymentName:     {healthStatus: &extendedHealth.MachineController, minReady: 1},
-		resources.OpenVPNServerDeploymentName:         {healthStatus: &extendedHealth.OpenVPN, minReady: 1},
 		resources.UserClusterControllerDeploymentName: {healthStatus: &extendedHealth.UserClusterControllerManager, minReady: 1},
+		// The OpenVPN health reflects the server of the tunneling mode the control plane currently uses
+		tunnelingServerDeploymentNames[cluster.ActiveTunnelingMode()]: {healthStatus: &extendedHealth.OpenVPN, minReady: 1},
 	}
 
 	for name := range healthMapping {
@@ -40,7 +41,7 @@ func (r *Reconciler) clusterHealth(ctx context.Context, cluster *kubermaticv1.Cl
 	var err error
 	key := types.NamespacedName{Namespace: ns, Name: resources.EtcdStatefulSetName}
 
-	etcdHealthStatus, err := resources.HealthyStatefulSet(ctx, r, key, 2)
+	etcdHealthStatus, err := resources.HealthyEtcdStatefulSet(ctx, r, key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get etcd health: %v", err)
 	}
@@ -10,6 +10,8 @@ import (
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	kuberneteshelper "github.com/kubermatic/kubermatic/api/pkg/kubernetes"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
@@ -37,6 +39,12 @@ func (r *Reconciler) reconcileCluster(ctx context.Context, cluster *kubermaticv1
 		return nil, err
 	}
 
+	// add or remove etcd members until the etcd has the configured size
+	etcdResult, err := r.reconcileEtcdClusterSize(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+
 	if cluster.Status.ExtendedHealth.Apiserver == kubermaticv1.HealthStatusUp {
 		// Controlling of user-cluster resources
 		reachable, err := r.clusterIsReachable(ctx, cluster)
@@ -59,6 +67,24 @@ func (r *Reconciler) reconcileCluster(ctx context.Context, cluster *kubermaticv1
 			}
 		}
 
+		// remove Gatekeeper once the OPA integration got disabled and its webhook is gone
+		gatekeeperResult, err := r.removeGatekeeper(ctx, cluster)
+		if err != nil {
+			return nil, err
+		}
+		if gatekeeperResult != nil {
+			return gatekeeperResult, nil
+		}
+
+		// migrate the control plane to the configured tunneling mode once its agents are ready
+		tunnelingResult, err := r.reconcileTunneling(ctx, cluster)
+		if err != nil {
+			return nil, err
+		}
+		if tunnelingResult != nil {
+			return tunnelingResult, nil
+		}
+
 	}
 
 	if !cluster.Status.ExtendedHealth.AllHealthy() {
@@ -66,6 +92,9 @@ func (r *Reconciler) reconcileCluster(ctx context.Context, cluster *kubermaticv1
 		return &reconcile.Result{RequeueAfter: reachableCheckPeriod}, nil
 	}
 
+	if etcdResult != nil {
+		return etcdResult, nil
+	}
 	return &reconcile.Result{}, nil
 }
 
@@ -101,6 +130,26 @@ func (r *Reconciler) ensureClusterNetworkDefaults(ctx context.Context, cluster *
 		modifiers = append(modifiers, setProxyMode)
 	}
 
+	if cluster.Spec.ClusterNetwork.TunnelingMode == "" {
+		setTunnelingMode := func(c *kubermaticv1.Cluster) {
+			c.Spec.ClusterNetwork.TunnelingMode = kubermaticv1.TunnelingModeOpenVPN
+		}
+		modifiers = append(modifiers, setTunnelingMode)
+	}
+
+	// New clusters start with the configured tunneling mode right away, the control plane gets
+	// only deployed once the cluster has an address
+	if cluster.Status.Tunneling == nil && cluster.Address.IP == "" {
+		setTunnelingStatus := func(c *kubermaticv1.Cluster) {
+			c.Status.Tunneling = &kubermaticv1.TunnelingStatus{
+				Mode:               c.TunnelingMode(),
+				Phase:              kubermaticv1.TunnelingEstablished,
+				LastTransitionTime: metav1.Now(),
+			}
+		}
+		modifiers = append(modifiers, setTunnelingStatus)
+	}
+
 	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
 		for _, modify := range modifiers {
 			modify(c)
@@ -6,6 +6,7 @@ import (
 
 	controllerutil "github.com/kubermatic/kubermatic/api/pkg/controller/util"
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
@@ -14,6 +15,8 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/resources/controllermanager"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/dns"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/konnectivity"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/kubernetes-dashboard"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/machinecontroller"
 	metricsserver "github.com/kubermatic/kubermatic/api/pkg/resources/metrics-server"
@@ -24,9 +27,11 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/resources/usercluster"
 
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func (r *Reconciler) ensureResourcesAreDeployed(ctx context.Context, cluster *kubermaticv1.Cluster) error {
@@ -54,13 +59,22 @@ func (r *Reconciler) ensureResourcesAreDeployed(ctx context.Context, cluster *ku
 		return nil
 	}
 
+	return ReconcileControlPlane(ctx, r.Client, data, r.dockerPullConfigJSON, r.features)
+}
+
+// ReconcileControlPlane creates and updates the resources of the control plane in the cluster namespace. The
+// Services must have got their addresses before, as the certificates and kubeconfigs contain them. Both the
+// cluster controller and the manifest-renderer use it, so that the rendered manifests match the deployed ones.
+func ReconcileControlPlane(ctx context.Context, client ctrlruntimeclient.Client, data *resources.TemplateData, dockerPullConfigJSON []byte, features Features) error {
+	cluster := data.Cluster()
+
 	// check that all secrets are available // New way of handling secrets
-	if err := r.ensureSecrets(ctx, cluster, data); err != nil {
+	if err := ensureSecrets(ctx, client, cluster, data, dockerPullConfigJSON); err != nil {
 		return err
 	}
 
 	// check that all StatefulSets are created
-	if err := r.ensureStatefulSets(ctx, cluster, data); err != nil {
+	if err := ensureStatefulSets(ctx, client, cluster, data, features.EtcdDataCorruptionChecks); err != nil {
 		return err
 	}
 
@@ -75,32 +89,42 @@ func (r *Reconciler) ensureResourcesAreDeployed(ctx context.Context, cluster *ku
 	}
 
 	// check that all ConfigMaps are available
-	if err := r.ensureConfigMaps(ctx, cluster, data); err != nil {
+	if err := ensureConfigMaps(ctx, client, cluster, data); err != nil {
+		return err
+	}
+
+	// check that the usercluster-controller can access the seed cluster before it gets deployed
+	if err := ensureUserClusterControllerSeedAccess(ctx, client, cluster); err != nil {
 		return err
 	}
 
 	// check that all Deployments are available
-	if err := r.ensureDeployments(ctx, cluster, data); err != nil {
+	if err := ensureDeployments(ctx, client, cluster, data, features.KubernetesOIDCAuthentication); err != nil {
 		return err
 	}
 
-	// check that all CronJobs are created
-	if err := r.ensureCronJobs(ctx, cluster, data); err != nil {
+	// the etcd gets defragmented by the etcd maintenance controller
+	if err := etcd.DeleteDefraggerCronJob(ctx, client, cluster.Status.NamespaceName); err != nil {
 		return err
 	}
 
 	// check that all PodDisruptionBudgets are created
-	if err := r.ensurePodDisruptionBudgets(ctx, cluster, data); err != nil {
+	if err := ensurePodDisruptionBudgets(ctx, client, cluster, data); err != nil {
+		return err
+	}
+
+	// check that all NetworkPolicies are created
+	if err := ensureNetworkPolicies(ctx, client, cluster, data); err != nil {
 		return err
 	}
 
 	// check that all VerticalPodAutoscalers are created
-	if err := r.ensureVerticalPodAutoscalers(ctx, cluster, data); err != nil {
+	if err := ensureVerticalPodAutoscalers(ctx, client, cluster, features.VPA); err != nil {
 		return err
 	}
 
 	if cluster.Spec.ExposeStrategy == corev1.ServiceTypeLoadBalancer {
-		if err := nodeportproxy.EnsureResources(ctx, r.Client, data); err != nil {
+		if err := nodeportproxy.EnsureResources(ctx, client, data); err != nil {
 			return fmt.Errorf("failed to ensure NodePortProxy resources: %v", err)
 		}
 	}
@@ -178,17 +202,30 @@ func GetServiceCreators(data *resources.TemplateData) []reconciling.NamedService
 	creators := []reconciling.NamedServiceCreatorGetter{
 		apiserver.InternalServiceCreator(),
 		apiserver.ExternalServiceCreator(data.Cluster().Spec.ExposeStrategy),
-		openvpn.ServiceCreator(data.Cluster().Spec.ExposeStrategy),
 		etcd.ServiceCreator(data),
 		dns.ServiceCreator(),
 		machinecontroller.ServiceCreator(),
 		metricsserver.ServiceCreator(),
 	}
 
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		creators = append(creators, openvpn.ServiceCreator(data.Cluster().Spec.ExposeStrategy))
+	}
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		creators = append(creators,
+			konnectivity.ServiceCreator(data.Cluster().Spec.ExposeStrategy),
+			konnectivity.InternalServiceCreator(),
+		)
+	}
+
 	if data.Cluster().Spec.ExposeStrategy == corev1.ServiceTypeLoadBalancer {
 		creators = append(creators, nodeportproxy.FrontLoadBalancerServiceCreator())
 	}
 
+	if gatekeeper.IsEnabled(data.Cluster()) {
+		creators = append(creators, gatekeeper.ServiceCreator())
+	}
+
 	return creators
 }
 
@@ -200,45 +237,52 @@ func (r *Reconciler) ensureServices(ctx context.Context, c *kubermaticv1.Cluster
 // GetDeploymentCreators returns all DeploymentCreators that are currently in use
 func GetDeploymentCreators(data *resources.TemplateData, enableAPIserverOIDCAuthentication bool) []reconciling.NamedDeploymentCreatorGetter {
 	deployments := []reconciling.NamedDeploymentCreatorGetter{
-		openvpn.DeploymentCreator(data),
 		dns.DeploymentCreator(data),
 		apiserver.DeploymentCreator(data, enableAPIserverOIDCAuthentication),
 		scheduler.DeploymentCreator(data),
 		controllermanager.DeploymentCreator(data),
 		machinecontroller.DeploymentCreator(data),
 		machinecontroller.WebhookDeploymentCreator(data),
-		metricsserver.DeploymentCreator(data),
 		usercluster.DeploymentCreator(data, false),
 		kubernetesdashboard.DeploymentCreator(data),
 	}
-	if data.Cluster().Annotations[kubermaticv1.AnnotationNameClusterAutoscalerEnabled] != "" &&
-		data.Cluster().Spec.Version.Minor() > 13 {
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		deployments = append(deployments, openvpn.DeploymentCreator(data))
+	}
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		deployments = append(deployments, konnectivity.DeploymentCreator(data))
+	}
+	// The metrics-server needs to reach the kubelets, with konnectivity it runs inside the user cluster
+	if data.Cluster().ActiveTunnelingMode() == kubermaticv1.TunnelingModeOpenVPN {
+		deployments = append(deployments, metricsserver.DeploymentCreator(data))
+	}
+	if kubermaticv1helper.IsClusterAutoscalerEnabled(data.Cluster()) && data.Cluster().Spec.Version.Minor() > 13 {
 		deployments = append(deployments, clusterautoscaler.DeploymentCreator(data))
 	}
+	if gatekeeper.IsEnabled(data.Cluster()) {
+		deployments = append(deployments, gatekeeper.DeploymentCreator(data))
+	}
 
 	return deployments
 }
 
-func (r *Reconciler) ensureDeployments(ctx context.Context, cluster *kubermaticv1.Cluster, data *resources.TemplateData) error {
-	creators := GetDeploymentCreators(data, r.features.KubernetesOIDCAuthentication)
-	return reconciling.ReconcileDeployments(ctx, creators, cluster.Status.NamespaceName, r, reconciling.OwnerRefWrapper(resources.GetClusterRef(cluster)))
+func ensureDeployments(ctx context.Context, client ctrlruntimeclient.Client, cluster *kubermaticv1.Cluster, data *resources.TemplateData, enableAPIserverOIDCAuthentication bool) error {
+	creators := GetDeploymentCreators(data, enableAPIserverOIDCAuthentication)
+	return reconciling.ReconcileDeployments(ctx, creators, cluster.Status.NamespaceName, client, reconciling.OwnerRefWrapper(resources.GetClusterRef(cluster)))
 }
 
 // GetSecretCreators returns all SecretCreators that are currently in use
-func (r *Reconciler) GetSecretCreators(data *resources.TemplateData) []reconciling.NamedSecretCreatorGetter {
+func GetSecretCreators(data *resources.TemplateData, dockerPullConfigJSON []byte) []reconciling.NamedSecretCreatorGetter {
 	creators := []reconciling.NamedSecretCreatorGetter{
 		certificates.RootCACreator(data),
-		openvpn.CACreator(),
 		certificates.FrontProxyCACreator(),
-		resources.ImagePullSecretCreator(r.dockerPullConfigJSON),
+		resources.ImagePullSecretCreator(dockerPullConfigJSON),
 		apiserver.FrontProxyClientCertificateCreator(data),
 		etcd.TLSCertificateCreator(data),
 		apiserver.EtcdClientCertificateCreator(data),
 		apiserver.TLSServingCertificateCreator(data),
 		apiserver.KubeletClientCertificateCreator(data),
 		apiserver.ServiceAccountKeyCreator(),
-		openvpn.TLSServingCertificateCreator(data),
-		openvpn.InternalClientCertificateCreator(data),
 		machinecontroller.TLSServingCertificateCreator(data),
 		metricsserver.TLSServingCertSecretCreator(data.GetRootCA),
 
@@ -257,6 +301,22 @@ func (r *Reconciler) GetSecretCreators(data *resources.TemplateData) []reconcili
 		resources.ViewerKubeconfigCreator(data),
 	}
 
+	// The CAs are kept when switching the tunneling mode, so that the
+	// certificates stay valid when switching back
+	creators = append(creators, openvpn.CACreator(), konnectivity.CACreator())
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		creators = append(creators,
+			openvpn.TLSServingCertificateCreator(data),
+			openvpn.InternalClientCertificateCreator(data),
+		)
+	}
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		creators = append(creators,
+			konnectivity.TLSServingCertificateCreator(data),
+			konnectivity.ApiserverClientCertificateCreator(data),
+		)
+	}
+
 	if data.Cluster().Spec.Version.Minor() > 13 {
 		creators = append(creators, resources.GetInternalKubeconfigCreator(resources.ClusterAutoscalerKubeconfigSecretName, resources.ClusterAutoscalerCertUsername, nil, data))
 	}
@@ -269,33 +329,87 @@ func (r *Reconciler) GetSecretCreators(data *resources.TemplateData) []reconcili
 		creators = append(creators, resources.ServiceAccountSecretCreator(data))
 	}
 
+	if data.Cluster().Spec.EncryptionAtRest != nil {
+		creators = append(creators, apiserver.EncryptionConfigurationCreator(data))
+	}
+
+	if gatekeeper.IsEnabled(data.Cluster()) {
+		creators = append(creators, gatekeeper.TLSServingCertificateCreator(data))
+	}
+
 	return creators
 }
 
-func (r *Reconciler) ensureSecrets(ctx context.Context, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
-	namedSecretCreatorGetters := r.GetSecretCreators(data)
+func ensureSecrets(ctx context.Context, client ctrlruntimeclient.Client, c *kubermaticv1.Cluster, data *resources.TemplateData, dockerPullConfigJSON []byte) error {
+	namedSecretCreatorGetters := GetSecretCreators(data, dockerPullConfigJSON)
 
-	if err := reconciling.ReconcileSecrets(ctx, namedSecretCreatorGetters, c.Status.NamespaceName, r.Client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
+	if err := reconciling.ReconcileSecrets(ctx, namedSecretCreatorGetters, c.Status.NamespaceName, client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
 		return fmt.Errorf("failed to ensure that the Secret exists: %v", err)
 	}
 
 	return nil
 }
 
+// ensureUserClusterControllerSeedAccess grants the usercluster-controller read access to the constraints
+// in the seed cluster while the OPA integration is enabled. Once it got disabled, the access gets revoked.
+func ensureUserClusterControllerSeedAccess(ctx context.Context, client ctrlruntimeclient.Client, c *kubermaticv1.Cluster) error {
+	serviceAccountCreators := []reconciling.NamedServiceAccountCreatorGetter{usercluster.ServiceAccountCreator()}
+	if err := reconciling.ReconcileServiceAccounts(ctx, serviceAccountCreators, c.Status.NamespaceName, client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
+		return fmt.Errorf("failed to ensure that the ServiceAccount exists: %v", err)
+	}
+
+	if !gatekeeper.IsEnabled(c) {
+		name := usercluster.SeedReaderClusterRoleBindingName(c.Status.NamespaceName)
+		clusterRoleBinding := &rbacv1.ClusterRoleBinding{}
+		if err := client.Get(ctx, types.NamespacedName{Name: name}, clusterRoleBinding); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get ClusterRoleBinding %s: %v", name, err)
+		}
+		if err := client.Delete(ctx, clusterRoleBinding); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ClusterRoleBinding %s: %v", name, err)
+		}
+		return nil
+	}
+
+	clusterRoleCreators := []reconciling.NamedClusterRoleCreatorGetter{usercluster.SeedReaderClusterRoleCreator()}
+	if err := reconciling.ReconcileClusterRoles(ctx, clusterRoleCreators, "", client); err != nil {
+		return fmt.Errorf("failed to ensure that the ClusterRole exists: %v", err)
+	}
+
+	// ClusterRoleBindings don't get removed with the cluster namespace, so the cluster owns them
+	clusterRoleBindingCreators := []reconciling.NamedClusterRoleBindingCreatorGetter{usercluster.SeedReaderClusterRoleBindingCreator(c.Status.NamespaceName)}
+	if err := reconciling.ReconcileClusterRoleBindings(ctx, clusterRoleBindingCreators, "", client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
+		return fmt.Errorf("failed to ensure that the ClusterRoleBinding exists: %v", err)
+	}
+
+	return nil
+}
+
 // GetConfigMapCreators returns all ConfigMapCreators that are currently in use
 func GetConfigMapCreators(data *resources.TemplateData) []reconciling.NamedConfigMapCreatorGetter {
-	return []reconciling.NamedConfigMapCreatorGetter{
+	creators := []reconciling.NamedConfigMapCreatorGetter{
 		cloudconfig.ConfigMapCreator(data),
-		openvpn.ServerClientConfigsConfigMapCreator(data),
 		dns.ConfigMapCreator(data),
 		apiserver.AuditConfigMapCreator(),
+		apiserver.AdmissionConfigurationCreator(data),
 	}
+
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		creators = append(creators, openvpn.ServerClientConfigsConfigMapCreator(data))
+	}
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		creators = append(creators, apiserver.EgressSelectorConfigCreator(data))
+	}
+
+	return creators
 }
 
-func (r *Reconciler) ensureConfigMaps(ctx context.Context, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
+func ensureConfigMaps(ctx context.Context, client ctrlruntimeclient.Client, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
 	creators := GetConfigMapCreators(data)
 
-	if err := reconciling.ReconcileConfigMaps(ctx, creators, c.Status.NamespaceName, r.Client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
+	if err := reconciling.ReconcileConfigMaps(ctx, creators, c.Status.NamespaceName, client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
 		return fmt.Errorf("failed to ensure that the ConfigMap exists: %v", err)
 	}
 
@@ -311,63 +425,86 @@ func GetStatefulSetCreators(data *resources.TemplateData, enableDataCorruptionCh
 
 // GetPodDisruptionBudgetCreators returns all PodDisruptionBudgetCreators that are currently in use
 func GetPodDisruptionBudgetCreators(data *resources.TemplateData) []reconciling.NamedPodDisruptionBudgetCreatorGetter {
-	return []reconciling.NamedPodDisruptionBudgetCreatorGetter{
+	creators := []reconciling.NamedPodDisruptionBudgetCreatorGetter{
 		etcd.PodDisruptionBudgetCreator(data),
 		apiserver.PodDisruptionBudgetCreator(),
-		metricsserver.PodDisruptionBudgetCreator(),
 		dns.PodDisruptionBudgetCreator(),
 	}
+
+	if data.Cluster().ActiveTunnelingMode() == kubermaticv1.TunnelingModeOpenVPN {
+		creators = append(creators, metricsserver.PodDisruptionBudgetCreator())
+	}
+
+	return creators
 }
 
-func (r *Reconciler) ensurePodDisruptionBudgets(ctx context.Context, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
+func ensurePodDisruptionBudgets(ctx context.Context, client ctrlruntimeclient.Client, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
 	creators := GetPodDisruptionBudgetCreators(data)
 
-	if err := reconciling.ReconcilePodDisruptionBudgets(ctx, creators, c.Status.NamespaceName, r.Client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
+	if err := reconciling.ReconcilePodDisruptionBudgets(ctx, creators, c.Status.NamespaceName, client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
 		return fmt.Errorf("failed to ensure that the PodDisruptionBudget exists: %v", err)
 	}
 
 	return nil
 }
 
-// GetCronJobCreators returns all CronJobCreators that are currently in use
-func GetCronJobCreators(data *resources.TemplateData) []reconciling.NamedCronJobCreatorGetter {
-	return []reconciling.NamedCronJobCreatorGetter{
-		etcd.CronJobCreator(data),
+// GetNetworkPolicyCreators returns all NetworkPolicyCreators that are currently in use. They isolate the
+// components of the control plane which must not be reachable from the other cluster namespaces.
+func GetNetworkPolicyCreators(data *resources.TemplateData) []reconciling.NamedNetworkPolicyCreatorGetter {
+	creators := []reconciling.NamedNetworkPolicyCreatorGetter{
+		etcd.NetworkPolicyCreator(data),
+		apiserver.NetworkPolicyCreator(),
+	}
+
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		creators = append(creators, openvpn.NetworkPolicyCreator())
 	}
+	if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		creators = append(creators, konnectivity.NetworkPolicyCreator())
+	}
+
+	return creators
 }
 
-func (r *Reconciler) ensureCronJobs(ctx context.Context, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
-	creators := GetCronJobCreators(data)
+func ensureNetworkPolicies(ctx context.Context, client ctrlruntimeclient.Client, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
+	creators := GetNetworkPolicyCreators(data)
 
-	if err := reconciling.ReconcileCronJobs(ctx, creators, c.Status.NamespaceName, r.Client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
-		return fmt.Errorf("failed to ensure that the CronJobs exists: %v", err)
+	if err := reconciling.ReconcileNetworkPolicies(ctx, creators, c.Status.NamespaceName, client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c))); err != nil {
+		return fmt.Errorf("failed to ensure that the NetworkPolicy exists: %v", err)
 	}
 
 	return nil
 }
 
-func (r *Reconciler) ensureVerticalPodAutoscalers(ctx context.Context, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
+func ensureVerticalPodAutoscalers(ctx context.Context, client ctrlruntimeclient.Client, c *kubermaticv1.Cluster, enableVPA bool) error {
 	controlPlaneDeploymentNames := []string{
 		resources.DNSResolverDeploymentName,
 		resources.MachineControllerDeploymentName,
 		resources.MachineControllerWebhookDeploymentName,
-		resources.OpenVPNServerDeploymentName,
 		resources.ApiserverDeploymentName,
 		resources.ControllerManagerDeploymentName,
 		resources.SchedulerDeploymentName,
-		resources.MetricsServerDeploymentName,
+	}
+	if c.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		controlPlaneDeploymentNames = append(controlPlaneDeploymentNames, resources.OpenVPNServerDeploymentName)
+	}
+	if c.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		controlPlaneDeploymentNames = append(controlPlaneDeploymentNames, resources.KonnectivityServerDeploymentName)
+	}
+	if c.ActiveTunnelingMode() == kubermaticv1.TunnelingModeOpenVPN {
+		controlPlaneDeploymentNames = append(controlPlaneDeploymentNames, resources.MetricsServerDeploymentName)
 	}
 
-	creators, err := resources.GetVerticalPodAutoscalersForAll(ctx, r.Client, controlPlaneDeploymentNames, []string{resources.EtcdStatefulSetName}, c.Status.NamespaceName, r.features.VPA)
+	creators, err := resources.GetVerticalPodAutoscalersForAll(ctx, client, controlPlaneDeploymentNames, []string{resources.EtcdStatefulSetName}, c.Status.NamespaceName, enableVPA)
 	if err != nil {
 		return fmt.Errorf("failed to create the functions to handle VPA resources: %v", err)
 	}
 
-	return reconciling.ReconcileVerticalPodAutoscalers(ctx, creators, c.Status.NamespaceName, r.Client)
+	return reconciling.ReconcileVerticalPodAutoscalers(ctx, creators, c.Status.NamespaceName, client)
 }
 
-func (r *Reconciler) ensureStatefulSets(ctx context.Context, c *kubermaticv1.Cluster, data *resources.TemplateData) error {
-	creators := GetStatefulSetCreators(data, r.features.EtcdDataCorruptionChecks)
+func ensureStatefulSets(ctx context.Context, client ctrlruntimeclient.Client, c *kubermaticv1.Cluster, data *resources.TemplateData, enableDataCorruptionChecks bool) error {
+	creators := GetStatefulSetCreators(data, enableDataCorruptionChecks)
 
-	return reconciling.ReconcileStatefulSets(ctx, creators, c.Status.NamespaceName, r.Client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c)))
+	return reconciling.ReconcileStatefulSets(ctx, creators, c.Status.NamespaceName, client, reconciling.OwnerRefWrapper(resources.GetClusterRef(c)))
 }
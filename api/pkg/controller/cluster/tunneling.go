@@ -0,0 +1,159 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// tunnelingCheckPeriod is the interval in which the agents get checked while the tunneling mode gets migrated
+const tunnelingCheckPeriod = 10 * time.Second
+
+// tunnelingServerDeploymentNames are the names of the server deployments in the cluster namespace per tunneling mode
+var tunnelingServerDeploymentNames = map[kubermaticv1.TunnelingMode]string{
+	kubermaticv1.TunnelingModeOpenVPN:      resources.OpenVPNServerDeploymentName,
+	kubermaticv1.TunnelingModeKonnectivity: resources.KonnectivityServerDeploymentName,
+}
+
+// tunnelingAgentDeploymentNames are the names of the agent deployments in the kube-system namespace of the
+// user cluster per tunneling mode
+var tunnelingAgentDeploymentNames = map[kubermaticv1.TunnelingMode]string{
+	kubermaticv1.TunnelingModeOpenVPN:      resources.OpenVPNClientDeploymentName,
+	kubermaticv1.TunnelingModeKonnectivity: resources.KonnectivityAgentDeploymentName,
+}
+
+// reconcileTunneling migrates the control plane to the configured tunneling mode. While the mode gets
+// migrated, the servers and agents of both modes are deployed and the control plane keeps using the
+// previous one. Once the server and the agents of the configured mode are ready, the control plane
+// switches over and the resources of the previous mode get removed.
+func (r *Reconciler) reconcileTunneling(ctx context.Context, cluster *kubermaticv1.Cluster) (*reconcile.Result, error) {
+	desired, active := cluster.TunnelingMode(), cluster.ActiveTunnelingMode()
+
+	if desired == active {
+		// A migration got reverted by configuring the previous mode again
+		if cluster.Status.Tunneling != nil && cluster.Status.Tunneling.Phase != kubermaticv1.TunnelingEstablished {
+			if err := r.updateTunnelingStatus(ctx, cluster, &kubermaticv1.TunnelingStatus{Mode: active, Phase: kubermaticv1.TunnelingEstablished}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, r.deleteTunnelingLeftovers(ctx, cluster)
+	}
+
+	message, err := r.tunnelNotReadyReason(ctx, cluster, desired)
+	if err != nil {
+		return nil, err
+	}
+	if message != "" {
+		status := &kubermaticv1.TunnelingStatus{Mode: active, Phase: kubermaticv1.TunnelingMigrating, Message: message}
+		if err := r.updateTunnelingStatus(ctx, cluster, status); err != nil {
+			return nil, err
+		}
+		return &reconcile.Result{RequeueAfter: tunnelingCheckPeriod}, nil
+	}
+
+	r.log.Infow("Switching the control plane to the new tunneling mode", "cluster", cluster.Name, "mode", desired)
+	if err := r.updateTunnelingStatus(ctx, cluster, &kubermaticv1.TunnelingStatus{Mode: desired, Phase: kubermaticv1.TunnelingEstablished}); err != nil {
+		return nil, err
+	}
+	// The control plane gets switched over by the next reconciliation
+	return &reconcile.Result{Requeue: true}, nil
+}
+
+// tunnelNotReadyReason returns why the tunnel of the given mode can't be used yet, or an empty string if it
+// is ready
+func (r *Reconciler) tunnelNotReadyReason(ctx context.Context, cluster *kubermaticv1.Cluster, mode kubermaticv1.TunnelingMode) (string, error) {
+	serverName := tunnelingServerDeploymentNames[mode]
+	health, err := resources.HealthyDeployment(ctx, r, types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: serverName}, 1)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the health of the %s deployment: %v", serverName, err)
+	}
+	if health != kubermaticv1.HealthStatusUp {
+		return fmt.Sprintf("waiting for the %s deployment to become ready", serverName), nil
+	}
+
+	client, err := r.userClusterConnProvider.GetClient(cluster)
+	if err != nil {
+		return "", fmt.Errorf("failed to get user cluster client: %v", err)
+	}
+	agentName := tunnelingAgentDeploymentNames[mode]
+	health, err = resources.HealthyDeployment(ctx, client, types.NamespacedName{Namespace: metav1.NamespaceSystem, Name: agentName}, 1)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the health of the %s deployment in the user cluster: %v", agentName, err)
+	}
+	if health != kubermaticv1.HealthStatusUp {
+		return fmt.Sprintf("waiting for the %s deployment in the user cluster to become ready", agentName), nil
+	}
+
+	return "", nil
+}
+
+func (r *Reconciler) updateTunnelingStatus(ctx context.Context, cluster *kubermaticv1.Cluster, status *kubermaticv1.TunnelingStatus) error {
+	current := cluster.Status.Tunneling
+	if current != nil && current.Mode == status.Mode && current.Phase == status.Phase {
+		status.LastTransitionTime = current.LastTransitionTime
+	} else {
+		status.LastTransitionTime = metav1.Now()
+	}
+	if current != nil && current.Mode == status.Mode && current.Phase == status.Phase && current.Message == status.Message {
+		return nil
+	}
+
+	return r.updateCluster(ctx, cluster, func(c *kubermaticv1.Cluster) {
+		c.Status.Tunneling = status
+	})
+}
+
+// deleteTunnelingLeftovers removes the resources of the tunneling modes which are not deployed anymore
+func (r *Reconciler) deleteTunnelingLeftovers(ctx context.Context, cluster *kubermaticv1.Cluster) error {
+	ns := cluster.Status.NamespaceName
+	leftovers := map[types.NamespacedName]runtime.Object{}
+
+	if !cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.OpenVPNServerDeploymentName}] = &appsv1.Deployment{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.OpenVPNServerServiceName}] = &corev1.Service{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.OpenVPNServerCertificatesSecretName}] = &corev1.Secret{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.OpenVPNClientCertificatesSecretName}] = &corev1.Secret{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.OpenVPNClientConfigsConfigMapName}] = &corev1.ConfigMap{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.OpenVPNServerNetworkPolicyName}] = &networkingv1.NetworkPolicy{}
+	}
+	if !cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.KonnectivityServerDeploymentName}] = &appsv1.Deployment{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.KonnectivityServerServiceName}] = &corev1.Service{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.KonnectivityServerInternalServiceName}] = &corev1.Service{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.KonnectivityServerCertificatesSecretName}] = &corev1.Secret{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.KonnectivityApiserverClientCertificateSecretName}] = &corev1.Secret{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.EgressSelectorConfigConfigMapName}] = &corev1.ConfigMap{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.KonnectivityServerNetworkPolicyName}] = &networkingv1.NetworkPolicy{}
+	}
+	// With konnectivity, the metrics-server runs inside the user cluster
+	if cluster.ActiveTunnelingMode() != kubermaticv1.TunnelingModeOpenVPN {
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.MetricsServerDeploymentName}] = &appsv1.Deployment{}
+		leftovers[types.NamespacedName{Namespace: ns, Name: resources.MetricsServerPodDisruptionBudgetName}] = &policyv1beta1.PodDisruptionBudget{}
+	}
+
+	for name, obj := range leftovers {
+		if err := r.Get(ctx, name, obj); err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get %T %s: %v", obj, name.String(), err)
+		}
+		if err := r.Delete(ctx, obj); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T %s: %v", obj, name.String(), err)
+		}
+	}
+	return nil
+}
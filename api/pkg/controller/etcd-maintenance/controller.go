@@ -0,0 +1,425 @@
+package etcdmaintenance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
+	"github.com/kubermatic/kubermatic/api/pkg/collectors"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kubeapierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// ControllerName is the name of this very controller.
+	ControllerName = "kubermatic_etcd_maintenance_controller"
+
+	// QuotaBackendBytes is the size of the database at which etcd raises the NOSPACE alarm and stops
+	// accepting writes. The etcd StatefulSet doesn't override the default of etcd.
+	QuotaBackendBytes = 2 * 1024 * 1024 * 1024
+	// minDefragmentationSize is the database size below which defragmenting a member isn't worth it
+	minDefragmentationSize = QuotaBackendBytes / 10
+	// defragmentationPeriod is the minimum time between two defragmentations of a member whose fragmentation
+	// is unknown, which is the case before etcd 3.4. They get defragmented about once per window.
+	defragmentationPeriod = 20 * time.Hour
+	// nextMemberDelay is the time after a defragmentation before the next member gets defragmented
+	nextMemberDelay = time.Minute
+
+	requestTimeout         = 10 * time.Second
+	defragmentationTimeout = 5 * time.Minute
+
+	alarmNoSpace = "NOSPACE"
+)
+
+// statsRecorder stores the database statistics of the etcd members for the metrics
+type statsRecorder interface {
+	SetClusterStats(cluster string, quota int64, members []collectors.EtcdMemberStats)
+	DeleteClusterStats(cluster string)
+}
+
+// Reconciler periodically checks the database size of every etcd member against its quota. It defragments
+// fragmented members one at a time within the defragmentation window and recovers the etcd from exhausted
+// space by compacting, defragmenting and clearing the NOSPACE alarms.
+type Reconciler struct {
+	ctrlruntimeclient.Client
+	log        *zap.SugaredLogger
+	workerName string
+	recorder   record.EventRecorder
+	stats      statsRecorder
+
+	// interval is the time between two checks of a cluster
+	interval time.Duration
+	window   DefragmentationWindow
+	// fragmentationThreshold is the share of free pages in the database above which a member gets defragmented
+	fragmentationThreshold float64
+
+	now func() time.Time
+	// etcdTransports keeps the connections to the etcd members between the checks
+	etcdTransports *etcdgateway.TransportCache
+
+	lock sync.Mutex
+	// lastDefragmentations are the times the members were defragmented by this controller, keyed by cluster and member
+	lastDefragmentations map[string]time.Time
+}
+
+// Add creates a new etcd maintenance controller
+func Add(
+	mgr manager.Manager,
+	log *zap.SugaredLogger,
+	numWorkers int,
+	workerName string,
+	stats statsRecorder,
+	interval time.Duration,
+	window DefragmentationWindow,
+	fragmentationThreshold float64,
+) error {
+	reconciler := &Reconciler{
+		Client:                 mgr.GetClient(),
+		log:                    log.Named(ControllerName),
+		workerName:             workerName,
+		recorder:               mgr.GetRecorder(ControllerName),
+		stats:                  stats,
+		interval:               interval,
+		window:                 window,
+		fragmentationThreshold: fragmentationThreshold,
+		now:                    time.Now,
+		etcdTransports:         etcdgateway.NewTransportCache(),
+		lastDefragmentations:   map[string]time.Time{},
+	}
+
+	c, err := controller.New(ControllerName, mgr, controller.Options{Reconciler: reconciler, MaxConcurrentReconciles: numWorkers})
+	if err != nil {
+		return err
+	}
+
+	// Every cluster gets requeued after the check interval, reacting to updates would only check more often
+	ignoreUpdates := predicate.Funcs{
+		UpdateFunc: func(event.UpdateEvent) bool {
+			return false
+		},
+	}
+	return c.Watch(&source.Kind{Type: &kubermaticv1.Cluster{}}, &handler.EnqueueRequestForObject{}, ignoreUpdates)
+}
+
+func (r *Reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := r.log.With("request", request)
+	log.Debug("Processing")
+
+	cluster := &kubermaticv1.Cluster{}
+	if err := r.Get(ctx, request.NamespacedName, cluster); err != nil {
+		if kubeapierrors.IsNotFound(err) {
+			log.Debug("Could not find cluster")
+			r.forgetCluster(request.Name)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	if cluster.DeletionTimestamp != nil {
+		r.forgetCluster(cluster.Name)
+		return reconcile.Result{}, nil
+	}
+
+	// The clusters are requeued by the check interval only, so skipped clusters must be requeued as well
+	result := reconcile.Result{RequeueAfter: r.interval}
+
+	if cluster.Labels[kubermaticv1.WorkerNameLabelKey] != r.workerName {
+		log.Debugw(
+			"Skipping because the cluster has a different worker name set",
+			"cluster-worker-name", cluster.Labels[kubermaticv1.WorkerNameLabelKey],
+		)
+		return result, nil
+	}
+
+	if cluster.Spec.Pause {
+		log.Debug("Skipping cluster reconciling because it was set to paused")
+		return result, nil
+	}
+
+	// There is no etcd before the control plane got created and while it is hibernated
+	if cluster.Status.NamespaceName == "" || kubermaticv1helper.IsClusterHibernated(cluster) {
+		r.forgetCluster(cluster.Name)
+		return result, nil
+	}
+
+	// The members change while the etcd gets scaled, the cluster controller takes care of it meanwhile
+	if cluster.Status.Etcd != nil && cluster.Status.Etcd.Phase != kubermaticv1.EtcdClusterRunning {
+		log.Debugw("Skipping because the etcd gets scaled", "phase", cluster.Status.Etcd.Phase)
+		return result, nil
+	}
+
+	maintenanceResult, err := r.reconcile(ctx, log, cluster)
+	if err != nil {
+		log.Errorw("Reconciling failed", zap.Error(err))
+		r.recorder.Event(cluster, corev1.EventTypeWarning, "EtcdMaintenanceFailed", err.Error())
+		return reconcile.Result{}, err
+	}
+	if maintenanceResult != nil {
+		return *maintenanceResult, nil
+	}
+	return result, nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster) (*reconcile.Result, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: resources.EtcdStatefulSetName}, statefulSet); err != nil {
+		return nil, fmt.Errorf("failed to get the etcd StatefulSet: %v", err)
+	}
+	members := resources.EtcdClusterSize
+	if statefulSet.Spec.Replicas != nil {
+		members = int(*statefulSet.Spec.Replicas)
+	}
+
+	transport, err := r.etcdTransports.Transport(ctx, r, cluster)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Transport: transport}
+
+	memberURLs := make([]string, members)
+	for i := range memberURLs {
+		memberURLs[i] = etcdgateway.MemberURL(cluster, i)
+	}
+	return r.maintain(ctx, log, cluster, client, memberURLs)
+}
+
+// maintain checks the members and runs at most one maintenance step. The members are only touched when
+// all of them are reachable, as a defragmentation takes a member down until it finished.
+func (r *Reconciler) maintain(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster, client *http.Client, memberURLs []string) (*reconcile.Result, error) {
+	statuses, alarms, err := r.checkMembers(ctx, cluster, client, memberURLs)
+	if err != nil {
+		log.Debugw("Skipping the etcd maintenance", zap.Error(err))
+		return nil, nil
+	}
+
+	if noSpaceAlarms := filterAlarms(alarms, alarmNoSpace); len(noSpaceAlarms) > 0 {
+		return nil, r.recoverFromNoSpace(ctx, log, cluster, client, memberURLs, statuses, noSpaceAlarms)
+	}
+
+	if !r.window.Contains(r.now()) {
+		return nil, nil
+	}
+	member := r.nextMemberToDefragment(cluster, statuses)
+	if member < 0 {
+		return nil, nil
+	}
+
+	log.Infow("Defragmenting etcd member", "member", etcd.MemberName(member), "db-size", statuses[member].dbSize())
+	if err := r.defragment(ctx, cluster, client, memberURLs[member], member); err != nil {
+		return nil, err
+	}
+	r.recorder.Eventf(cluster, corev1.EventTypeNormal, "EtcdDefragmented", "Defragmented etcd member %s", etcd.MemberName(member))
+
+	// Give the member some time to catch up before the next one gets defragmented
+	return &reconcile.Result{RequeueAfter: nextMemberDelay}, nil
+}
+
+// checkMembers gets the status of every member and the alarms of the etcd, and stores them for the metrics
+func (r *Reconciler) checkMembers(ctx context.Context, cluster *kubermaticv1.Cluster, client *http.Client, memberURLs []string) ([]*memberStatus, []etcdgateway.Alarm, error) {
+	statuses := make([]*memberStatus, len(memberURLs))
+	var errs []error
+	for i, memberURL := range memberURLs {
+		requestCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		status, err := getMemberStatus(requestCtx, client, memberURL)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get the status of etcd member %s: %v", etcd.MemberName(i), err))
+			continue
+		}
+		statuses[i] = status
+	}
+
+	var alarms []etcdgateway.Alarm
+	var alarmsErr error
+	for i, status := range statuses {
+		if status == nil {
+			continue
+		}
+		requestCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		alarms, alarmsErr = etcdgateway.ListAlarms(requestCtx, client, memberURLs[i])
+		cancel()
+		break
+	}
+	if alarmsErr != nil {
+		errs = append(errs, fmt.Errorf("failed to list the etcd alarms: %v", alarmsErr))
+	}
+
+	r.recordStats(cluster, statuses, alarms)
+
+	if len(errs) > 0 {
+		return nil, nil, errs[0]
+	}
+	return statuses, alarms, nil
+}
+
+// recoverFromNoSpace frees the space in the etcd by compacting the history and defragmenting every member.
+// The NOSPACE alarms get cleared once all databases are below the quota again, etcd accepts writes afterwards.
+func (r *Reconciler) recoverFromNoSpace(ctx context.Context, log *zap.SugaredLogger, cluster *kubermaticv1.Cluster, client *http.Client, memberURLs []string, statuses []*memberStatus, noSpaceAlarms []etcdgateway.Alarm) error {
+	var revision int64
+	for _, status := range statuses {
+		if status.revision() > revision {
+			revision = status.revision()
+		}
+	}
+
+	log.Infow("Compacting the etcd to free space", "revision", revision)
+	compactCtx, cancel := context.WithTimeout(ctx, defragmentationTimeout)
+	err := compact(compactCtx, client, memberURLs[0], revision)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to compact the etcd to revision %d: %v", revision, err)
+	}
+
+	for member, memberURL := range memberURLs {
+		log.Infow("Defragmenting etcd member to free space", "member", etcd.MemberName(member))
+		if err := r.defragment(ctx, cluster, client, memberURL, member); err != nil {
+			return err
+		}
+	}
+
+	statuses, _, err = r.checkMembers(ctx, cluster, client, memberURLs)
+	if err != nil {
+		return err
+	}
+	for member, status := range statuses {
+		if status.dbSize() >= QuotaBackendBytes {
+			r.recorder.Eventf(cluster, corev1.EventTypeWarning, "EtcdNoSpace",
+				"The database of etcd member %s still exceeds the quota of %d bytes after compaction and defragmentation", etcd.MemberName(member), QuotaBackendBytes)
+			return nil
+		}
+	}
+
+	for _, a := range noSpaceAlarms {
+		requestCtx, cancel := context.WithTimeout(ctx, requestTimeout)
+		err := disarmAlarm(requestCtx, client, memberURLs[0], a)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to clear the %s alarm of member %s: %v", a.Alarm, a.MemberID, err)
+		}
+	}
+	r.recorder.Event(cluster, corev1.EventTypeNormal, "EtcdAlarmCleared", "Cleared the NOSPACE alarm after compacting and defragmenting the etcd")
+
+	// Refresh the metrics, so they don't report the cleared alarms until the next check
+	_, _, err = r.checkMembers(ctx, cluster, client, memberURLs)
+	return err
+}
+
+// nextMemberToDefragment returns the member that gets defragmented next or -1 if none needs to be.
+// The leader gets defragmented last, blocking it triggers a leader election.
+func (r *Reconciler) nextMemberToDefragment(cluster *kubermaticv1.Cluster, statuses []*memberStatus) int {
+	var candidates []int
+	for member, status := range statuses {
+		if needsDefragmentation(status, r.lastDefragmentation(cluster, member), r.now(), r.fragmentationThreshold) {
+			candidates = append(candidates, member)
+		}
+	}
+	if len(candidates) == 0 {
+		return -1
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return !statuses[candidates[i]].isLeader() && statuses[candidates[j]].isLeader()
+	})
+	return candidates[0]
+}
+
+// needsDefragmentation tells if the share of free pages in the database of a member exceeds the threshold.
+// Before etcd 3.4 the free pages are unknown, the members get defragmented periodically then.
+func needsDefragmentation(status *memberStatus, lastDefragmentation, now time.Time, threshold float64) bool {
+	if status.dbSize() < minDefragmentationSize {
+		return false
+	}
+	if status.dbSizeInUse() > 0 {
+		return float64(status.dbSize()-status.dbSizeInUse())/float64(status.dbSize()) >= threshold
+	}
+	return now.Sub(lastDefragmentation) >= defragmentationPeriod
+}
+
+func (r *Reconciler) defragment(ctx context.Context, cluster *kubermaticv1.Cluster, client *http.Client, memberURL string, member int) error {
+	defragmentCtx, cancel := context.WithTimeout(ctx, defragmentationTimeout)
+	defer cancel()
+	if err := defragment(defragmentCtx, client, memberURL); err != nil {
+		return fmt.Errorf("failed to defragment etcd member %s: %v", etcd.MemberName(member), err)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.lastDefragmentations[defragmentationKey(cluster.Name, member)] = r.now()
+	return nil
+}
+
+func (r *Reconciler) lastDefragmentation(cluster *kubermaticv1.Cluster, member int) time.Time {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.lastDefragmentations[defragmentationKey(cluster.Name, member)]
+}
+
+func (r *Reconciler) recordStats(cluster *kubermaticv1.Cluster, statuses []*memberStatus, alarms []etcdgateway.Alarm) {
+	var members []collectors.EtcdMemberStats
+	for member, status := range statuses {
+		if status == nil {
+			continue
+		}
+		stats := collectors.EtcdMemberStats{
+			Member:              etcd.MemberName(member),
+			DBSize:              status.dbSize(),
+			DBSizeInUse:         status.dbSizeInUse(),
+			LastDefragmentation: r.lastDefragmentation(cluster, member),
+		}
+		for _, a := range alarms {
+			if a.MemberID == status.Header.MemberID {
+				stats.Alarms = append(stats.Alarms, a.Alarm)
+			}
+		}
+		members = append(members, stats)
+	}
+	r.stats.SetClusterStats(cluster.Name, QuotaBackendBytes, members)
+}
+
+// forgetCluster removes the metrics and the defragmentation times of a cluster without etcd
+func (r *Reconciler) forgetCluster(name string) {
+	r.stats.DeleteClusterStats(name)
+	r.etcdTransports.Forget(name)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for member := 0; member < resources.EtcdMaxClusterSize; member++ {
+		delete(r.lastDefragmentations, defragmentationKey(name, member))
+	}
+}
+
+func defragmentationKey(cluster string, member int) string {
+	return fmt.Sprintf("%s/%d", cluster, member)
+}
+
+func filterAlarms(alarms []etcdgateway.Alarm, name string) []etcdgateway.Alarm {
+	var filtered []etcdgateway.Alarm
+	for _, a := range alarms {
+		if a.Alarm == name {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
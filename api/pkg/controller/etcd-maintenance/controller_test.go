@@ -0,0 +1,287 @@
+package etcdmaintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
+	"github.com/kubermatic/kubermatic/api/pkg/collectors"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	mib = 1024 * 1024
+)
+
+type fakeMember struct {
+	id          string
+	dbSize      int64
+	dbSizeInUse int64
+	unreachable bool
+}
+
+// fakeEtcd serves the gRPC gateway endpoints the controller uses and records the maintenance requests
+type fakeEtcd struct {
+	lock     sync.Mutex
+	members  []*fakeMember
+	leader   string
+	alarms   []etcdgateway.Alarm
+	requests []string
+	// inUseAfterCompaction is the size in use of every member after a compaction
+	inUseAfterCompaction int64
+}
+
+func (e *fakeEtcd) handler(member *fakeMember) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.lock.Lock()
+		defer e.lock.Unlock()
+
+		if member.unreachable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var response interface{} = struct{}{}
+		switch r.URL.Path {
+		case "/v3beta/maintenance/status":
+			response = map[string]interface{}{
+				"header":      map[string]string{"member_id": member.id, "revision": "42"},
+				"leader":      e.leader,
+				"dbSize":      fmt.Sprint(member.dbSize),
+				"dbSizeInUse": fmt.Sprint(member.dbSizeInUse),
+			}
+		case "/v3beta/maintenance/alarm":
+			request := map[string]string{}
+			_ = json.NewDecoder(r.Body).Decode(&request)
+			if request["action"] == "DEACTIVATE" {
+				e.requests = append(e.requests, fmt.Sprintf("disarm %s %s", request["memberID"], request["alarm"]))
+				e.alarms = nil
+			}
+			response = map[string]interface{}{"alarms": e.alarms}
+		case "/v3beta/kv/compaction":
+			e.requests = append(e.requests, "compact")
+			for _, m := range e.members {
+				m.dbSizeInUse = e.inUseAfterCompaction
+			}
+		case "/v3beta/maintenance/defragment":
+			e.requests = append(e.requests, "defragment "+member.id)
+			if member.dbSizeInUse > 0 {
+				member.dbSize = member.dbSizeInUse
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
+
+type fakeStats struct {
+	members []collectors.EtcdMemberStats
+}
+
+func (s *fakeStats) SetClusterStats(_ string, _ int64, members []collectors.EtcdMemberStats) {
+	s.members = members
+}
+
+func (s *fakeStats) DeleteClusterStats(string) {
+	s.members = nil
+}
+
+func TestMaintain(t *testing.T) {
+	inWindow := time.Date(2019, 10, 1, 3, 0, 0, 0, time.UTC)
+	outsideWindow := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name                 string
+		now                  time.Time
+		members              []*fakeMember
+		alarms               []etcdgateway.Alarm
+		inUseAfterCompaction int64
+		lastDefragmentation  time.Time
+		expectedRequests     []string
+		expectedRequeue      time.Duration
+		expectedAlarms       []string
+	}{
+		{
+			name: "fragmented followers get defragmented before the leader",
+			now:  inWindow,
+			members: []*fakeMember{
+				{id: "1", dbSize: 1000 * mib, dbSizeInUse: 300 * mib},
+				{id: "2", dbSize: 1000 * mib, dbSizeInUse: 300 * mib},
+				{id: "3", dbSize: 1000 * mib, dbSizeInUse: 900 * mib},
+			},
+			expectedRequests: []string{"defragment 2"},
+			expectedRequeue:  nextMemberDelay,
+		},
+		{
+			name: "members are not defragmented outside the window",
+			now:  outsideWindow,
+			members: []*fakeMember{
+				{id: "1", dbSize: 1000 * mib, dbSizeInUse: 300 * mib},
+				{id: "2", dbSize: 1000 * mib, dbSizeInUse: 300 * mib},
+				{id: "3", dbSize: 1000 * mib, dbSizeInUse: 300 * mib},
+			},
+		},
+		{
+			name: "members are not defragmented while a member is unreachable",
+			now:  inWindow,
+			members: []*fakeMember{
+				{id: "1", dbSize: 1000 * mib, dbSizeInUse: 300 * mib},
+				{id: "2", dbSize: 1000 * mib, dbSizeInUse: 300 * mib},
+				{id: "3", unreachable: true},
+			},
+		},
+		{
+			name: "small databases are not defragmented",
+			now:  inWindow,
+			members: []*fakeMember{
+				{id: "1", dbSize: 100 * mib, dbSizeInUse: 10 * mib},
+				{id: "2", dbSize: 100 * mib, dbSizeInUse: 10 * mib},
+				{id: "3", dbSize: 100 * mib, dbSizeInUse: 10 * mib},
+			},
+		},
+		{
+			name: "members with unknown fragmentation are defragmented once per window",
+			now:  inWindow,
+			members: []*fakeMember{
+				{id: "1", dbSize: 1000 * mib},
+				{id: "2", dbSize: 1000 * mib},
+				{id: "3", dbSize: 1000 * mib},
+			},
+			lastDefragmentation: inWindow.Add(-time.Hour),
+		},
+		{
+			name: "the NOSPACE alarm gets cleared after compaction and defragmentation",
+			now:  outsideWindow,
+			members: []*fakeMember{
+				{id: "1", dbSize: QuotaBackendBytes, dbSizeInUse: QuotaBackendBytes},
+				{id: "2", dbSize: QuotaBackendBytes, dbSizeInUse: QuotaBackendBytes},
+				{id: "3", dbSize: QuotaBackendBytes, dbSizeInUse: QuotaBackendBytes},
+			},
+			alarms:               []etcdgateway.Alarm{{MemberID: "2", Alarm: alarmNoSpace}},
+			inUseAfterCompaction: 500 * mib,
+			expectedRequests:     []string{"compact", "defragment 1", "defragment 2", "defragment 3", "disarm 2 NOSPACE"},
+		},
+		{
+			name: "the NOSPACE alarm stays when the compaction didn't free enough space",
+			now:  outsideWindow,
+			members: []*fakeMember{
+				{id: "1", dbSize: QuotaBackendBytes, dbSizeInUse: QuotaBackendBytes},
+				{id: "2", dbSize: QuotaBackendBytes, dbSizeInUse: QuotaBackendBytes},
+				{id: "3", dbSize: QuotaBackendBytes, dbSizeInUse: QuotaBackendBytes},
+			},
+			alarms:               []etcdgateway.Alarm{{MemberID: "2", Alarm: alarmNoSpace}},
+			inUseAfterCompaction: QuotaBackendBytes,
+			expectedRequests:     []string{"compact", "defragment 1", "defragment 2", "defragment 3"},
+			expectedAlarms:       []string{alarmNoSpace},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fake := &fakeEtcd{members: tc.members, leader: "1", alarms: tc.alarms, inUseAfterCompaction: tc.inUseAfterCompaction}
+			var memberURLs []string
+			for _, member := range tc.members {
+				server := httptest.NewServer(fake.handler(member))
+				defer server.Close()
+				memberURLs = append(memberURLs, server.URL)
+			}
+
+			window, err := ParseDefragmentationWindow("02:00-05:00")
+			if err != nil {
+				t.Fatalf("failed to parse the window: %v", err)
+			}
+			stats := &fakeStats{}
+			r := &Reconciler{
+				log:                    kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar(),
+				recorder:               record.NewFakeRecorder(10),
+				stats:                  stats,
+				window:                 window,
+				fragmentationThreshold: 0.5,
+				now:                    func() time.Time { return tc.now },
+				lastDefragmentations:   map[string]time.Time{},
+			}
+			cluster := &kubermaticv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "henrik"}}
+			for member := range tc.members {
+				r.lastDefragmentations[defragmentationKey(cluster.Name, member)] = tc.lastDefragmentation
+			}
+
+			result, err := r.maintain(context.Background(), r.log, cluster, http.DefaultClient, memberURLs)
+			if err != nil {
+				t.Fatalf("maintenance failed: %v", err)
+			}
+
+			var requeue time.Duration
+			if result != nil {
+				requeue = result.RequeueAfter
+			}
+			if requeue != tc.expectedRequeue {
+				t.Errorf("expected to be requeued after %v, got %v", tc.expectedRequeue, requeue)
+			}
+			if diff := deep.Equal(fake.requests, tc.expectedRequests); diff != nil {
+				t.Errorf("requests differ from the expected ones, diff: %v", diff)
+			}
+
+			var alarms []string
+			for _, member := range stats.members {
+				alarms = append(alarms, member.Alarms...)
+			}
+			if diff := deep.Equal(alarms, tc.expectedAlarms); diff != nil {
+				t.Errorf("alarms in the metrics differ from the expected ones, diff: %v", diff)
+			}
+		})
+	}
+}
+
+func TestDefragmentationWindow(t *testing.T) {
+	testCases := []struct {
+		window    string
+		time      string
+		contained bool
+	}{
+		{window: "02:00-05:00", time: "03:30", contained: true},
+		{window: "02:00-05:00", time: "05:00", contained: false},
+		{window: "02:00-05:00", time: "01:59", contained: false},
+		{window: "22:00-04:00", time: "23:00", contained: true},
+		{window: "22:00-04:00", time: "01:00", contained: true},
+		{window: "22:00-04:00", time: "12:00", contained: false},
+		{window: "", time: "12:00", contained: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%s at %s", tc.window, tc.time), func(t *testing.T) {
+			window, err := ParseDefragmentationWindow(tc.window)
+			if err != nil {
+				t.Fatalf("failed to parse the window: %v", err)
+			}
+			parts := strings.Split(tc.time, ":")
+			now, err := time.Parse(time.RFC3339, fmt.Sprintf("2019-10-01T%s:%s:00Z", parts[0], parts[1]))
+			if err != nil {
+				t.Fatalf("failed to parse the time: %v", err)
+			}
+			if contained := window.Contains(now); contained != tc.contained {
+				t.Errorf("expected the window to contain the time: %v, got %v", tc.contained, contained)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"02:00", "2-5", "02:00-25:00"} {
+		if _, err := ParseDefragmentationWindow(invalid); err == nil {
+			t.Errorf("expected the window %q to be invalid", invalid)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package etcdmaintenance
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/etcdgateway"
+)
+
+// memberStatus is the status of an etcd member as returned by the gRPC gateway of etcd
+type memberStatus struct {
+	Header struct {
+		MemberID string `json:"member_id"`
+		Revision string `json:"revision"`
+	} `json:"header"`
+	Leader      string `json:"leader"`
+	DBSize      string `json:"dbSize"`
+	DBSizeInUse string `json:"dbSizeInUse"`
+}
+
+func (s *memberStatus) isLeader() bool {
+	return s.Leader != "" && s.Leader == s.Header.MemberID
+}
+
+func (s *memberStatus) revision() int64 {
+	revision, _ := strconv.ParseInt(s.Header.Revision, 10, 64)
+	return revision
+}
+
+func (s *memberStatus) dbSize() int64 {
+	size, _ := strconv.ParseInt(s.DBSize, 10, 64)
+	return size
+}
+
+// dbSizeInUse returns the size of the database without free pages, it is 0 for etcd versions before 3.4
+func (s *memberStatus) dbSizeInUse() int64 {
+	size, _ := strconv.ParseInt(s.DBSizeInUse, 10, 64)
+	return size
+}
+
+func getMemberStatus(ctx context.Context, client *http.Client, memberURL string) (*memberStatus, error) {
+	status := &memberStatus{}
+	if err := etcdgateway.Request(ctx, client, memberURL+"/v3beta/maintenance/status", struct{}{}, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func disarmAlarm(ctx context.Context, client *http.Client, memberURL string, a etcdgateway.Alarm) error {
+	request := map[string]string{"action": "DEACTIVATE", "memberID": a.MemberID, "alarm": a.Alarm}
+	return etcdgateway.Request(ctx, client, memberURL+"/v3beta/maintenance/alarm", request, nil)
+}
+
+// compact discards the history of the keys before the given revision and waits until the
+// space got freed in the database
+func compact(ctx context.Context, client *http.Client, memberURL string, revision int64) error {
+	request := map[string]interface{}{"revision": strconv.FormatInt(revision, 10), "physical": true}
+	err := etcdgateway.Request(ctx, client, memberURL+"/v3beta/kv/compaction", request, nil)
+	// The apiserver compacts the history periodically as well
+	if err != nil && strings.Contains(err.Error(), "required revision has been compacted") {
+		return nil
+	}
+	return err
+}
+
+// defragment releases the free pages of the database of a member, the member doesn't serve requests meanwhile
+func defragment(ctx context.Context, client *http.Client, memberURL string) error {
+	return etcdgateway.Request(ctx, client, memberURL+"/v3beta/maintenance/defragment", struct{}{}, nil)
+}
@@ -0,0 +1,47 @@
+package etcdmaintenance
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefragmentationWindow is the daily time range in UTC in which etcd members get defragmented.
+// The range may span midnight, an empty range disables the scheduled defragmentation.
+type DefragmentationWindow struct {
+	// Start and End are the offsets of the range from midnight
+	Start time.Duration
+	End   time.Duration
+}
+
+// ParseDefragmentationWindow parses a window in the format "HH:MM-HH:MM", e.g. "22:00-04:00".
+// An empty string results in an empty window.
+func ParseDefragmentationWindow(s string) (DefragmentationWindow, error) {
+	if s == "" {
+		return DefragmentationWindow{}, nil
+	}
+
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return DefragmentationWindow{}, fmt.Errorf("expected the format HH:MM-HH:MM, got %q", s)
+	}
+	var offsets [2]time.Duration
+	for i, part := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return DefragmentationWindow{}, fmt.Errorf("invalid time %q: %v", part, err)
+		}
+		offsets[i] = time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	}
+	return DefragmentationWindow{Start: offsets[0], End: offsets[1]}, nil
+}
+
+// Contains tells if the given time lies within the window
+func (w DefragmentationWindow) Contains(t time.Time) bool {
+	t = t.UTC()
+	offset := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.Start <= w.End {
+		return w.Start <= offset && offset < w.End
+	}
+	return w.Start <= offset || offset < w.End
+}
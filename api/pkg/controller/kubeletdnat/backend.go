@@ -0,0 +1,67 @@
+package kubeletdnat
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// BackendAuto picks nftables if the kernel supports it and iptables otherwise
+	BackendAuto = "auto"
+	// BackendIPTables installs the rules with iptables-save/iptables-restore
+	BackendIPTables = "iptables"
+	// BackendNFTables installs the rules in a dedicated nftables table
+	BackendNFTables = "nftables"
+)
+
+// AvailableBackends are the backends which can be configured
+var AvailableBackends = []string{BackendAuto, BackendIPTables, BackendNFTables}
+
+// backend installs the translation rules in the kernel.
+type backend interface {
+	// name returns the name of the backend, used for logging and metrics.
+	name() string
+	// renderRule returns the rule in the format used by installedRules.
+	renderRule(rule *dnatRule) string
+	// installedRules returns the translation rules which are currently installed. The returned
+	// boolean is false if the rules surrounding them, like the jump into the translation chain
+	// or the masquerading on the vpn interface, are missing.
+	installedRules() ([]string, bool, error)
+	// apply atomically replaces all installed translation rules with the given ones and ensures
+	// the surrounding rules exist.
+	apply(rules []string) error
+	// remove deletes the translation rules and the rules surrounding them, if they are installed.
+	remove() error
+}
+
+// newBackend returns the backend with the given name. For BackendAuto, it checks which backend
+// the kernel supports. The rules of the other backend get removed, as they would still translate
+// the traffic after switching the backend.
+func newBackend(backendName, chainName, vpnInterface string, log *zap.SugaredLogger) (backend, error) {
+	iptables := newIPTablesBackend(chainName, vpnInterface)
+	nftables := newNFTablesBackend(chainName, vpnInterface)
+	switch backendName {
+	case BackendIPTables:
+		return useBackend(iptables, nftables)
+	case BackendNFTables:
+		return useBackend(nftables, iptables)
+	case BackendAuto:
+		if _, err := listNFTTables(); err != nil {
+			// Without nftables support there is no table to remove
+			log.Infow("nftables not available, falling back to iptables", zap.Error(err))
+			return iptables, nil
+		}
+		return useBackend(nftables, iptables)
+	default:
+		return nil, fmt.Errorf("unknown backend %q, available are: %v", backendName, AvailableBackends)
+	}
+}
+
+// useBackend removes the rules of the unused backend and returns the selected one.
+func useBackend(selected, unused backend) (backend, error) {
+	if err := unused.remove(); err != nil {
+		return nil, fmt.Errorf("failed to remove the rules of the %s backend: %v", unused.name(), err)
+	}
+	return selected, nil
+}
@@ -0,0 +1,212 @@
+package kubeletdnat
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// iptablesBackend manages the translation rules in a chain of the nat table, using
+// iptables-save and iptables-restore.
+type iptablesBackend struct {
+	chainName    string
+	vpnInterface string
+}
+
+func newIPTablesBackend(chainName, vpnInterface string) *iptablesBackend {
+	return &iptablesBackend{
+		chainName:    chainName,
+		vpnInterface: vpnInterface,
+	}
+}
+
+func (b *iptablesBackend) name() string {
+	return BackendIPTables
+}
+
+func (b *iptablesBackend) renderRule(rule *dnatRule) string {
+	return rule.RestoreLine(b.chainName)
+}
+
+func (b *iptablesBackend) installedRules() ([]string, bool, error) {
+	allRules, err := execSave()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read iptable rules: %v", err)
+	}
+	// filter out everything that's not relevant for us
+	rules, haveJump, haveMasquerade := b.filterDnatRules(allRules)
+	return rules, haveJump && haveMasquerade, nil
+}
+
+// apply creates a iptables-save file and pipes it to stdin of
+// a iptables-restore process for atomically setting new rules.
+// This function replaces a complete chain (removing all pre-existing rules).
+func (b *iptablesBackend) apply(rules []string) error {
+	allRules, err := execSave()
+	if err != nil {
+		return fmt.Errorf("failed to read iptable rules: %v", err)
+	}
+	_, haveJump, haveMasquerade := b.filterDnatRules(allRules)
+
+	restore := []string{
+		"*nat",
+		fmt.Sprintf(":%s - [0:0]", b.chainName)}
+
+	if !haveJump {
+		restore = append(restore,
+			fmt.Sprintf("-I OUTPUT -j %s", b.chainName))
+	}
+
+	if !haveMasquerade {
+		restore = append(restore,
+			fmt.Sprintf("-I POSTROUTING -o %s -j MASQUERADE", b.vpnInterface))
+	}
+
+	restore = append(restore, rules...)
+	restore = append(restore, "COMMIT")
+
+	return execRestore(restore)
+}
+
+// remove deletes the chain and the jump into it. The masquerading on the vpn interface is kept.
+func (b *iptablesBackend) remove() error {
+	allRules, err := execSave()
+	if err != nil {
+		return fmt.Errorf("failed to read iptable rules: %v", err)
+	}
+	restore := b.removal(allRules)
+	if len(restore) == 0 {
+		return nil
+	}
+	return execRestore(restore)
+}
+
+// removal returns the iptables-restore lines which flush and delete the chain and delete all
+// jumps into it, or nil if the chain doesn't exist.
+func (b *iptablesBackend) removal(rules []string) []string {
+	chainPrefix := fmt.Sprintf(":%s ", b.chainName)
+	jumpPattern := fmt.Sprintf("-A OUTPUT -j %s", b.chainName)
+	haveChain := false
+	jumps := 0
+	for _, rule := range rules {
+		if strings.HasPrefix(rule, chainPrefix) {
+			haveChain = true
+		}
+		if rule == jumpPattern {
+			jumps++
+		}
+	}
+	if !haveChain {
+		return nil
+	}
+
+	restore := []string{"*nat"}
+	for i := 0; i < jumps; i++ {
+		restore = append(restore, fmt.Sprintf("-D OUTPUT -j %s", b.chainName))
+	}
+	// Declaring the chain flushes it, it can only be deleted once it's empty
+	return append(restore,
+		fmt.Sprintf(":%s - [0:0]", b.chainName),
+		fmt.Sprintf("-X %s", b.chainName),
+		"COMMIT")
+}
+
+// filterDnatRules enumerates through all given rules and returns all
+// rules matching the chain. It also returns two booleans to
+// indicate if the jump and the masquerade rule are present.
+func (b *iptablesBackend) filterDnatRules(rules []string) ([]string, bool, bool) {
+	out := []string{}
+	haveJump := false
+	haveMasquerade := false
+
+	rulePrefix := fmt.Sprintf("-A %s ", b.chainName)
+	jumpPattern := fmt.Sprintf("-A OUTPUT -j %s", b.chainName)
+	masqPattern := fmt.Sprintf("-A POSTROUTING -o %s -j MASQUERADE", b.vpnInterface)
+	for _, rule := range rules {
+		if rule == jumpPattern {
+			haveJump = true
+		}
+		if rule == masqPattern {
+			haveMasquerade = true
+		}
+		if !strings.HasPrefix(rule, rulePrefix) {
+			continue
+		}
+		out = append(out, rule)
+	}
+	return out, haveJump, haveMasquerade
+}
+
+func execSave() ([]string, error) {
+	cmd := exec.Command("iptables-save", []string{"-t", "nat"}...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %q: %v. Output: \n%s", strings.Join(cmd.Args, " "), err, out)
+	}
+	return strings.Split(string(out), "\n"), err
+}
+
+func execRestore(rules []string) error {
+	cmd := exec.Command("iptables-restore", []string{"--noflush", "-v", "-T", "nat"}...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(stdin, strings.Join(rules, "\n")+"\n"); err != nil {
+		return fmt.Errorf("failed to write to iptables-restore stdin: %v", err)
+	}
+	if err := stdin.Close(); err != nil {
+		return fmt.Errorf("failed to close iptables-restore stdin: %v", err)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if len(out) > 0 {
+		return fmt.Errorf("iptables-restore failed: %v (output: %s)", err, string(out))
+	}
+	return fmt.Errorf("iptables-restore failed: %v", err)
+}
+
+// GetMatchArgs returns iptables arguments to match for the
+// rule's originalTargetAddress and Port.
+func (rule *dnatRule) GetMatchArgs() []string {
+	return []string{
+		"-d", rule.originalTargetAddress + "/32",
+		"-p", "tcp",
+		"-m", "tcp",
+		"--dport", rule.originalTargetPort,
+	}
+}
+
+// GetTargetArgs returns iptables arguments to specify the
+// rule's target after translation.
+func (rule *dnatRule) GetTargetArgs() []string {
+	var target string
+	if len(rule.translatedAddress) > 0 {
+		target = rule.translatedAddress
+	}
+	target += ":"
+	if len(rule.translatedPort) > 0 {
+		target += rule.translatedPort
+	}
+	if len(target) == 0 {
+		return []string{}
+	}
+	return []string{
+		"-j", "DNAT",
+		"--to-destination", target,
+	}
+}
+
+// RestoreLine returns a line of `iptables-save`-file representing
+// the rule.
+func (rule *dnatRule) RestoreLine(chain string) string {
+	args := []string{"-A", chain}
+	args = append(args, rule.GetMatchArgs()...)
+	args = append(args, rule.GetTargetArgs()...)
+	return strings.Join(args, " ")
+}
@@ -0,0 +1,70 @@
+package kubeletdnat
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestIPTablesRemoval(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rules    []string
+		expected []string
+	}{
+		{
+			name: "chain and jump get removed, masquerading is kept",
+			rules: []string{
+				"*nat",
+				":OUTPUT ACCEPT [0:0]",
+				":POSTROUTING ACCEPT [0:0]",
+				":test-chain - [0:0]",
+				"-A OUTPUT -j test-chain",
+				"-A POSTROUTING -o tun0 -j MASQUERADE",
+				"-A test-chain -d 10.1.1.11/32 -p tcp -m tcp --dport 10250 -j DNAT --to-destination 10.254.1.11:10250",
+				"COMMIT",
+			},
+			expected: []string{
+				"*nat",
+				"-D OUTPUT -j test-chain",
+				":test-chain - [0:0]",
+				"-X test-chain",
+				"COMMIT",
+			},
+		},
+		{
+			name: "chain without jump gets removed",
+			rules: []string{
+				"*nat",
+				":OUTPUT ACCEPT [0:0]",
+				":test-chain - [0:0]",
+				"COMMIT",
+			},
+			expected: []string{
+				"*nat",
+				":test-chain - [0:0]",
+				"-X test-chain",
+				"COMMIT",
+			},
+		},
+		{
+			name: "nothing gets removed without the chain",
+			rules: []string{
+				"*nat",
+				":OUTPUT ACCEPT [0:0]",
+				":test-chain-other - [0:0]",
+				"COMMIT",
+			},
+			expected: nil,
+		},
+	}
+
+	b := newIPTablesBackend("test-chain", "tun0")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := deep.Equal(b.removal(tc.rules), tc.expected); diff != nil {
+				t.Errorf("unexpected restore lines: %v", diff)
+			}
+		})
+	}
+}
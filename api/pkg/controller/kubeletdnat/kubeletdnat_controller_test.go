@@ -14,8 +14,8 @@ func TestRuleGeneration(t *testing.T) {
 		t.Fatal(err)
 	}
 	ctrl := &Reconciler{
-		nodeTranslationChainName: "test-chain",
-		nodeAccessNetwork:        nodeAccessNetwork,
+		nodeAccessNetwork: nodeAccessNetwork,
+		backend:           newIPTablesBackend("test-chain", "tun0"),
 	}
 
 	nodes := []corev1.Node{
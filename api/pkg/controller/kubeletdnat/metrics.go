@@ -0,0 +1,52 @@
+package kubeletdnat
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	ctrlruntimemetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "kubermatic"
+	metricsSubsystem = "kubeletdnat"
+)
+
+var (
+	registerMetrics sync.Once
+	installedRules  = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "rules",
+			Help:      "The number of translation rules installed in the kernel",
+		},
+		[]string{"backend"},
+	)
+	syncErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "sync_errors_total",
+			Help:      "The number of failed synchronisations of the translation rules",
+		},
+		[]string{"backend"},
+	)
+	driftRepairs = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: metricsSubsystem,
+			Name:      "drift_repairs_total",
+			Help:      "The number of times the installed translation rules were changed by someone else and got restored",
+		},
+		[]string{"backend"},
+	)
+)
+
+func init() {
+	// The metrics get served by the manager
+	registerMetrics.Do(func() {
+		ctrlruntimemetrics.Registry.MustRegister(installedRules, syncErrors, driftRepairs)
+	})
+}
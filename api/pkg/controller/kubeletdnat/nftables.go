@@ -0,0 +1,156 @@
+package kubeletdnat
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	nftOutputChain      = "output"
+	nftPostroutingChain = "postrouting"
+)
+
+// nftablesBackend manages the translation rules in a dedicated table of the ip family. The table
+// gets replaced as a whole in a single nft transaction.
+type nftablesBackend struct {
+	tableName    string
+	vpnInterface string
+}
+
+func newNFTablesBackend(tableName, vpnInterface string) *nftablesBackend {
+	return &nftablesBackend{
+		tableName:    tableName,
+		vpnInterface: vpnInterface,
+	}
+}
+
+func (b *nftablesBackend) name() string {
+	return BackendNFTables
+}
+
+// renderRule returns the rule the way `nft list table` prints it.
+func (b *nftablesBackend) renderRule(rule *dnatRule) string {
+	return fmt.Sprintf("ip daddr %s tcp dport %s dnat to %s:%s",
+		rule.originalTargetAddress, rule.originalTargetPort, rule.translatedAddress, rule.translatedPort)
+}
+
+func (b *nftablesBackend) masqueradeRule() string {
+	return fmt.Sprintf("oifname %q masquerade", b.vpnInterface)
+}
+
+func (b *nftablesBackend) installedRules() ([]string, bool, error) {
+	cmd := exec.Command("nft", "list", "table", "ip", b.tableName)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		// The table gets created by the first apply
+		if strings.Contains(string(out), "No such file or directory") {
+			return []string{}, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to execute %q: %v. Output: \n%s", strings.Join(cmd.Args, " "), err, out)
+	}
+
+	chains := parseNFTTable(string(out))
+	rules := chains[nftOutputChain]
+	if rules == nil {
+		rules = []string{}
+	}
+	postrouting := chains[nftPostroutingChain]
+	haveMasquerade := len(postrouting) == 1 && postrouting[0] == b.masqueradeRule()
+	return rules, haveMasquerade, nil
+}
+
+func (b *nftablesBackend) apply(rules []string) error {
+	cmd := exec.Command("nft", "-f", "-")
+	cmd.Stdin = strings.NewReader(b.ruleset(rules))
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if len(out) > 0 {
+		return fmt.Errorf("nft failed: %v (output: %s)", err, string(out))
+	}
+	return fmt.Errorf("nft failed: %v", err)
+}
+
+func (b *nftablesBackend) remove() error {
+	tables, err := listNFTTables()
+	if err != nil {
+		// Without nftables support there is no table to remove
+		return nil
+	}
+	if !tables.Has(b.tableName) {
+		return nil
+	}
+	cmd := exec.Command("nft", "delete", "table", "ip", b.tableName)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to execute %q: %v. Output: \n%s", strings.Join(cmd.Args, " "), err, out)
+	}
+	return nil
+}
+
+// listNFTTables returns the names of the tables of the ip family. It fails if the nft binary or the
+// kernel support for nftables is missing.
+func listNFTTables() (sets.String, error) {
+	cmd := exec.Command("nft", "list", "tables", "ip")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute %q: %v. Output: \n%s", strings.Join(cmd.Args, " "), err, out)
+	}
+	return parseNFTTables(string(out)), nil
+}
+
+// parseNFTTables returns the names of the tables of the ip family listed by `nft list tables`.
+func parseNFTTables(listing string) sets.String {
+	tables := sets.NewString()
+	for _, line := range strings.Split(listing, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "table ip ") {
+			tables.Insert(strings.TrimPrefix(line, "table ip "))
+		}
+	}
+	return tables
+}
+
+// ruleset returns the nft script which replaces the table with one containing the given rules.
+// Declaring the table before deleting it makes the deletion work if the table doesn't exist yet.
+func (b *nftablesBackend) ruleset(rules []string) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "table ip %s\n", b.tableName)
+	fmt.Fprintf(&buf, "delete table ip %s\n", b.tableName)
+	fmt.Fprintf(&buf, "table ip %s {\n", b.tableName)
+	fmt.Fprintf(&buf, "\tchain %s {\n", nftOutputChain)
+	buf.WriteString("\t\ttype nat hook output priority -100; policy accept;\n")
+	for _, rule := range rules {
+		fmt.Fprintf(&buf, "\t\t%s\n", rule)
+	}
+	buf.WriteString("\t}\n")
+	fmt.Fprintf(&buf, "\tchain %s {\n", nftPostroutingChain)
+	buf.WriteString("\t\ttype nat hook postrouting priority 100; policy accept;\n")
+	fmt.Fprintf(&buf, "\t\t%s\n", b.masqueradeRule())
+	buf.WriteString("\t}\n")
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// parseNFTTable returns the rules of every chain of a table listed by `nft list table`.
+func parseNFTTable(listing string) map[string][]string {
+	chains := map[string][]string{}
+	chain := ""
+	for _, line := range strings.Split(listing, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "chain ") && strings.HasSuffix(line, "{"):
+			chain = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(line, "chain "), "{"))
+		case line == "}":
+			chain = ""
+		case chain == "", line == "", strings.HasPrefix(line, "type "):
+			// Outside of a chain, or the base chain definition
+		default:
+			chains[chain] = append(chains[chain], line)
+		}
+	}
+	return chains
+}
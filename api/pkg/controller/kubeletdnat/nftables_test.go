@@ -0,0 +1,101 @@
+package kubeletdnat
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+)
+
+func TestNFTablesRuleRendering(t *testing.T) {
+	b := newNFTablesBackend("test-table", "tun0")
+	rule := &dnatRule{
+		originalTargetAddress: "192.0.2.101",
+		originalTargetPort:    "10250",
+		translatedAddress:     "10.254.1.11",
+		translatedPort:        "10250",
+	}
+
+	expected := "ip daddr 192.0.2.101 tcp dport 10250 dnat to 10.254.1.11:10250"
+	if rendered := b.renderRule(rule); rendered != expected {
+		t.Errorf("unexpected rule, expected %q, got %q", expected, rendered)
+	}
+
+	expectedRuleset := `table ip test-table
+delete table ip test-table
+table ip test-table {
+	chain output {
+		type nat hook output priority -100; policy accept;
+		ip daddr 192.0.2.101 tcp dport 10250 dnat to 10.254.1.11:10250
+	}
+	chain postrouting {
+		type nat hook postrouting priority 100; policy accept;
+		oifname "tun0" masquerade
+	}
+}
+`
+	if ruleset := b.ruleset([]string{expected}); ruleset != expectedRuleset {
+		t.Errorf("unexpected ruleset, expected:\n%s\ngot:\n%s", expectedRuleset, ruleset)
+	}
+}
+
+func TestParseNFTTable(t *testing.T) {
+	testCases := []struct {
+		name     string
+		listing  string
+		expected map[string][]string
+	}{
+		{
+			name: "rules get returned per chain",
+			listing: `table ip node-access-dnat {
+	chain output {
+		type nat hook output priority dstnat; policy accept;
+		ip daddr 10.1.1.11 tcp dport 10250 dnat to 10.254.1.11:10250
+		ip daddr 192.0.2.101 tcp dport 10250 dnat to 10.254.1.11:10250
+	}
+
+	chain postrouting {
+		type nat hook postrouting priority srcnat; policy accept;
+		oifname "tun0" masquerade
+	}
+}
+`,
+			expected: map[string][]string{
+				"output": {
+					"ip daddr 10.1.1.11 tcp dport 10250 dnat to 10.254.1.11:10250",
+					"ip daddr 192.0.2.101 tcp dport 10250 dnat to 10.254.1.11:10250",
+				},
+				"postrouting": {
+					`oifname "tun0" masquerade`,
+				},
+			},
+		},
+		{
+			name: "empty chains are omitted",
+			listing: `table ip node-access-dnat {
+	chain output {
+		type nat hook output priority -100; policy accept;
+	}
+}
+`,
+			expected: map[string][]string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if diff := deep.Equal(parseNFTTable(tc.listing), tc.expected); diff != nil {
+				t.Errorf("unexpected chains: %v", diff)
+			}
+		})
+	}
+}
+
+func TestParseNFTTables(t *testing.T) {
+	listing := `table ip nat
+table ip node-access-dnat
+`
+	expected := []string{"nat", "node-access-dnat"}
+	if diff := deep.Equal(parseNFTTables(listing).List(), expected); diff != nil {
+		t.Errorf("unexpected tables: %v", diff)
+	}
+}
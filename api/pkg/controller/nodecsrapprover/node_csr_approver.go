@@ -2,25 +2,38 @@ package nodecsrapprover
 
 import (
 	"context"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
+	"strings"
 
 	"go.uber.org/zap"
 
 	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	certificatesv1beta1client "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 )
 
 const ControllerName = "node_csr_autoapprover"
 
+// nodeUserPrefix is the prefix of the user name and common name of node certificates
+const nodeUserPrefix = "system:node:"
+
 // Check if the Reconciler fullfills the interface
 // at compile time
 var _ reconcile.Reconciler = &reconciler{}
@@ -31,6 +44,7 @@ type reconciler struct {
 	// the dynamic client does not approve
 	certClient certificatesv1beta1client.CertificateSigningRequestInterface
 	log        *zap.SugaredLogger
+	recorder   record.EventRecorder
 }
 
 func Add(mgr manager.Manager, numWorkers int, cfg *rest.Config, log *zap.SugaredLogger) error {
@@ -39,7 +53,12 @@ func Add(mgr manager.Manager, numWorkers int, cfg *rest.Config, log *zap.Sugared
 		return fmt.Errorf("failed to create certificate client: %v", err)
 	}
 
-	r := &reconciler{Client: mgr.GetClient(), certClient: certClient.CertificateSigningRequests(), log: log}
+	r := &reconciler{
+		Client:     mgr.GetClient(),
+		certClient: certClient.CertificateSigningRequests(),
+		log:        log,
+		recorder:   mgr.GetRecorder(ControllerName),
+	}
 	c, err := controller.New(ControllerName, mgr,
 		controller.Options{Reconciler: r, MaxConcurrentReconciles: numWorkers})
 	if err != nil {
@@ -78,6 +97,10 @@ func (r *reconciler) reconcile(ctx context.Context, request reconcile.Request) e
 			log.Debug("already approved, skipping reconciling")
 			return nil
 		}
+		if condition.Type == certificatesv1beta1.CertificateDenied {
+			log.Debug("already denied, skipping reconciling")
+			return nil
+		}
 	}
 
 	if !sets.NewString(csr.Spec.Groups...).Has("system:nodes") {
@@ -98,6 +121,29 @@ func (r *reconciler) reconcile(ctx context.Context, request reconcile.Request) e
 		}
 	}
 
+	nodeName := strings.TrimPrefix(csr.Spec.Username, nodeUserPrefix)
+	if nodeName == csr.Spec.Username || nodeName == "" {
+		return r.deny(log, csr, fmt.Sprintf("the requesting user %q is not a node", csr.Spec.Username))
+	}
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+		// The node registers itself before it requests a serving certificate, retry until it shows up
+		return fmt.Errorf("failed to get node %q: %v", nodeName, err)
+	}
+	machine, err := r.getMachineForNode(ctx, nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to get machine for node %q: %v", nodeName, err)
+	}
+
+	certificateRequest, err := parseCSR(csr)
+	if err != nil {
+		return r.deny(log, csr, err.Error())
+	}
+	if err := validateServingCSR(certificateRequest, csr.Spec.Username, node, machine); err != nil {
+		return r.deny(log, csr, err.Error())
+	}
+
 	log.Debug("Approving")
 	approvalCondition := certificatesv1beta1.CertificateSigningRequestCondition{
 		Type:   certificatesv1beta1.CertificateApproved,
@@ -113,6 +159,108 @@ func (r *reconciler) reconcile(ctx context.Context, request reconcile.Request) e
 	return nil
 }
 
+// deny refuses the CSR and records the reason as event
+func (r *reconciler) deny(log *zap.SugaredLogger, csr *certificatesv1beta1.CertificateSigningRequest, reason string) error {
+	log.Infow("Denying", "reason", reason)
+	r.recorder.Eventf(csr, corev1.EventTypeWarning, "CSRDenied", "Refused to approve node serving cert: %s", reason)
+
+	csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1beta1.CertificateSigningRequestCondition{
+		Type:    certificatesv1beta1.CertificateDenied,
+		Reason:  "Kubermatic NodeCSRApprover controller denied node serving cert",
+		Message: reason,
+	})
+	if _, err := r.certClient.UpdateApproval(csr); err != nil {
+		return fmt.Errorf("failed to update approval for CSR %q: %v", csr.Name, err)
+	}
+	return nil
+}
+
+// getMachineForNode returns the machine that backs the given node or nil if the node is not
+// managed by the machine-controller. It fails if the machine of the node doesn't reference
+// the node yet, its addresses can't be checked before.
+func (r *reconciler) getMachineForNode(ctx context.Context, nodeName string) (*clusterv1alpha1.Machine, error) {
+	machines := &clusterv1alpha1.MachineList{}
+	if err := r.List(ctx, &client.ListOptions{Namespace: metav1.NamespaceSystem}, machines); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for i := range machines.Items {
+		if nodeRef := machines.Items[i].Status.NodeRef; nodeRef != nil && nodeRef.Name == nodeName {
+			return &machines.Items[i], nil
+		}
+	}
+	// The machine-controller names the nodes after their machines
+	for i := range machines.Items {
+		if machines.Items[i].Name == nodeName && machines.Items[i].Status.NodeRef == nil {
+			return nil, fmt.Errorf("node not yet joined, machine %q doesn't reference it yet", machines.Items[i].Name)
+		}
+	}
+	return nil, nil
+}
+
+func parseCSR(csr *certificatesv1beta1.CertificateSigningRequest) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode(csr.Spec.Request)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("the request does not contain a PEM encoded certificate request")
+	}
+	request, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the certificate request: %v", err)
+	}
+	return request, nil
+}
+
+// validateServingCSR checks that the certificate request was made by the node it is for and that
+// it only contains names the node owns. All names must be reported by the node and, if the node is
+// backed by a machine, by the machine as well.
+func validateServingCSR(request *x509.CertificateRequest, username string, node *corev1.Node, machine *clusterv1alpha1.Machine) error {
+	if request.Subject.CommonName != username {
+		return fmt.Errorf("the common name %q does not match the requesting user %q", request.Subject.CommonName, username)
+	}
+	if len(request.Subject.Organization) != 1 || request.Subject.Organization[0] != "system:nodes" {
+		return fmt.Errorf("the organization %v is not [system:nodes]", request.Subject.Organization)
+	}
+	if len(request.EmailAddresses) > 0 || len(request.URIs) > 0 {
+		return fmt.Errorf("email and URI SANs are not allowed")
+	}
+	if len(request.DNSNames) == 0 && len(request.IPAddresses) == 0 {
+		return fmt.Errorf("the request does not contain any DNS or IP SANs")
+	}
+
+	nodeAddresses := addressSet(node.Status.Addresses)
+	var machineAddresses sets.String
+	if machine != nil {
+		machineAddresses = addressSet(machine.Status.Addresses)
+		// The machine-controller doesn't always report host names, the node name is the machine's
+		machineAddresses.Insert(node.Name)
+	}
+
+	var names []string
+	names = append(names, request.DNSNames...)
+	for _, ip := range request.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, name := range names {
+		if !nodeAddresses.Has(name) {
+			return fmt.Errorf("the SAN %q is not an address of node %q", name, node.Name)
+		}
+		if machine != nil && !machineAddresses.Has(name) {
+			return fmt.Errorf("the SAN %q is not an address of machine %q", name, machine.Name)
+		}
+	}
+	return nil
+}
+
+func addressSet(addresses []corev1.NodeAddress) sets.String {
+	result := sets.NewString()
+	for _, address := range addresses {
+		result.Insert(address.Address)
+	}
+	return result
+}
+
 func isUsageInUsageList(usage certificatesv1beta1.KeyUsage, usageList []certificatesv1beta1.KeyUsage) bool {
 	for _, usageListItem := range usageList {
 		if usage == usageListItem {
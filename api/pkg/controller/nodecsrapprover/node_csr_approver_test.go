@@ -0,0 +1,202 @@
+package nodecsrapprover
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+func init() {
+	log := kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar()
+	if err := clusterv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		log.Fatalw("failed to add clusterv1alpha1 scheme to scheme.Scheme", zap.Error(err))
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node-1"},
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeExternalIP, Address: "192.168.0.1"},
+			},
+		},
+	}
+	machine := &clusterv1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{Name: "machine-1", Namespace: metav1.NamespaceSystem},
+		Status: clusterv1alpha1.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Kind: "Node", Name: "node-1"},
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		name             string
+		username         string
+		subject          pkix.Name
+		dnsNames         []string
+		ips              []string
+		objects          []runtime.Object
+		expectedApproved bool
+		expectedEvent    string
+		expectedErr      string
+	}{
+		{
+			name:             "Node addresses get approved",
+			username:         "system:node:node-1",
+			subject:          pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			dnsNames:         []string{"node-1"},
+			ips:              []string{"10.0.0.1", "192.168.0.1"},
+			objects:          []runtime.Object{node},
+			expectedApproved: true,
+		},
+		{
+			name:             "Addresses of the node and its machine get approved",
+			username:         "system:node:node-1",
+			subject:          pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			dnsNames:         []string{"node-1"},
+			ips:              []string{"10.0.0.1"},
+			objects:          []runtime.Object{node, machine},
+			expectedApproved: true,
+		},
+		{
+			name:          "Node address that the machine doesn't report gets denied",
+			username:      "system:node:node-1",
+			subject:       pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			ips:           []string{"192.168.0.1"},
+			objects:       []runtime.Object{node, machine},
+			expectedEvent: `the SAN "192.168.0.1" is not an address of machine "machine-1"`,
+		},
+		{
+			name:     "Node of a machine without node reference is not checked yet",
+			username: "system:node:node-1",
+			subject:  pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			ips:      []string{"10.0.0.1"},
+			objects: []runtime.Object{node, &clusterv1alpha1.Machine{
+				ObjectMeta: metav1.ObjectMeta{Name: "node-1", Namespace: metav1.NamespaceSystem},
+			}},
+			expectedErr: `node not yet joined, machine "node-1" doesn't reference it yet`,
+		},
+		{
+			name:          "Foreign name gets denied",
+			username:      "system:node:node-1",
+			subject:       pkix.Name{CommonName: "system:node:node-1", Organization: []string{"system:nodes"}},
+			dnsNames:      []string{"node-1", "kubernetes.default"},
+			objects:       []runtime.Object{node},
+			expectedEvent: `the SAN "kubernetes.default" is not an address of node "node-1"`,
+		},
+		{
+			name:          "Certificate for another node gets denied",
+			username:      "system:node:node-1",
+			subject:       pkix.Name{CommonName: "system:node:node-2", Organization: []string{"system:nodes"}},
+			ips:           []string{"10.0.0.1"},
+			objects:       []runtime.Object{node},
+			expectedEvent: `the common name "system:node:node-2" does not match the requesting user "system:node:node-1"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			csr := &certificatesv1beta1.CertificateSigningRequest{
+				ObjectMeta: metav1.ObjectMeta{Name: "csr"},
+				Spec: certificatesv1beta1.CertificateSigningRequestSpec{
+					Request:  genCSR(t, tc.subject, tc.dnsNames, tc.ips),
+					Username: tc.username,
+					Groups:   []string{"system:nodes", "system:authenticated"},
+					Usages:   allowedUsages,
+				},
+			}
+			certClient := fake.NewSimpleClientset(csr).CertificatesV1beta1().CertificateSigningRequests()
+			recorder := record.NewFakeRecorder(10)
+			r := &reconciler{
+				Client:     fakectrlruntimeclient.NewFakeClient(append(tc.objects, csr)...),
+				certClient: certClient,
+				log:        kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar(),
+				recorder:   recorder,
+			}
+
+			_, err := r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: csr.Name}})
+			updatedCSR, getErr := certClient.Get(csr.Name, metav1.GetOptions{})
+			if getErr != nil {
+				t.Fatalf("failed to get csr: %v", getErr)
+			}
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error to contain %q, got %v", tc.expectedErr, err)
+				}
+				if len(updatedCSR.Status.Conditions) != 0 {
+					t.Fatalf("expected the csr to be neither approved nor denied, got %v", updatedCSR.Status.Conditions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reconciling failed: %v", err)
+			}
+			if len(updatedCSR.Status.Conditions) != 1 {
+				t.Fatalf("expected exactly one condition, got %v", updatedCSR.Status.Conditions)
+			}
+			approved := updatedCSR.Status.Conditions[0].Type == certificatesv1beta1.CertificateApproved
+			if approved != tc.expectedApproved {
+				t.Fatalf("expected approved to be %v, got condition %v", tc.expectedApproved, updatedCSR.Status.Conditions[0])
+			}
+
+			if tc.expectedEvent == "" {
+				return
+			}
+			select {
+			case event := <-recorder.Events:
+				if !strings.Contains(event, tc.expectedEvent) {
+					t.Fatalf("expected event to contain %q, got %q", tc.expectedEvent, event)
+				}
+			default:
+				t.Fatal("expected an event")
+			}
+		})
+	}
+}
+
+func genCSR(t *testing.T, subject pkix.Name, dnsNames []string, ips []string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.CertificateRequest{
+		Subject:  subject,
+		DNSNames: dnsNames,
+	}
+	for _, ip := range ips {
+		template.IPAddresses = append(template.IPAddresses, net.ParseIP(ip))
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, template, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate request: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
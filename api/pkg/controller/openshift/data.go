@@ -97,8 +97,8 @@ func (od *openshiftData) parseRSA(cert, rawKey []byte) (*triple.KeyPair, error)
 		return nil, fmt.Errorf("got an invalid cert from the secret: %v", err)
 	}
 
-	if len(certs) != 1 {
-		return nil, fmt.Errorf("did not find exactly one but %v certificates in the secret", len(certs))
+	if len(certs) == 0 {
+		return nil, errors.New("did not find any certificate in the secret")
 	}
 
 	key, err := certutil.ParsePrivateKeyPEM(rawKey)
@@ -110,7 +110,7 @@ func (od *openshiftData) parseRSA(cert, rawKey []byte) (*triple.KeyPair, error)
 	if !isRSAKey {
 		return nil, errors.New("key is not a RSA key")
 	}
-	return &triple.KeyPair{Cert: certs[0], Key: rsaKey}, nil
+	return &triple.KeyPair{Cert: certs[0], Key: rsaKey, TrustedCerts: certs[1:]}, nil
 }
 
 func (od *openshiftData) GetFrontProxyCA() (*triple.KeyPair, error) {
@@ -236,6 +236,11 @@ func (od *openshiftData) GetOpenVPNServerPort() (int32, error) {
 	return service.Spec.Ports[0].NodePort, nil
 }
 
+// GetKonnectivityServerPort always fails, konnectivity is not supported for openshift clusters
+func (od *openshiftData) GetKonnectivityServerPort() (int32, error) {
+	return 0, fmt.Errorf("konnectivity is not supported for openshift cluster %s", od.cluster.Name)
+}
+
 // GetDexCA returns the chain of public certificates of the Dex
 func (od *openshiftData) GetDexCA() ([]*x509.Certificate, error) {
 	return kubernetesresources.GetDexCAFromFile(od.oidc.CAFile)
@@ -32,7 +32,6 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/resources/usercluster"
 
 	appsv1 "k8s.io/api/apps/v1"
-	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	rbacv1 "k8s.io/api/rbac/v1"
@@ -144,7 +143,6 @@ func Add(
 		&corev1.Namespace{},
 		&appsv1.StatefulSet{},
 		&appsv1.Deployment{},
-		&batchv1beta1.CronJob{},
 		&policyv1beta1.PodDisruptionBudget{},
 		&autoscalingv1beta2.VerticalPodAutoscaler{},
 	}
@@ -317,8 +315,9 @@ func (r *Reconciler) reconcile(ctx context.Context, log *zap.SugaredLogger, clus
 		}
 	}
 
-	if err := r.cronJobs(ctx, osData); err != nil {
-		return nil, fmt.Errorf("failed to reconcile CronJobs: %v", err)
+	// The etcd gets defragmented by the etcd maintenance controller
+	if err := etcd.DeleteDefraggerCronJob(ctx, r.Client, osData.Cluster().Status.NamespaceName); err != nil {
+		return nil, err
 	}
 
 	if err := r.podDisruptionBudgets(ctx, osData); err != nil {
@@ -412,7 +411,7 @@ func (r *Reconciler) syncHeath(ctx context.Context, osData *openshiftData) error
 		*healthMapping[name].healthStatus = status
 	}
 
-	status, err := resources.HealthyStatefulSet(ctx, r.Client, nn(osData.Cluster().Status.NamespaceName, resources.EtcdStatefulSetName), 2)
+	status, err := resources.HealthyEtcdStatefulSet(ctx, r.Client, nn(osData.Cluster().Status.NamespaceName, resources.EtcdStatefulSetName))
 	if err != nil {
 		return fmt.Errorf("failed to get etcd health: %v", err)
 	}
@@ -646,7 +645,7 @@ func (r *Reconciler) getAllDeploymentCreators(ctx context.Context, osData *opens
 		openshiftresources.CloudCredentialOperator(osData),
 		openshiftresources.RegistryOperatorFactory(osData)}
 
-	if osData.Cluster().Annotations[kubermaticv1.AnnotationNameClusterAutoscalerEnabled] != "" {
+	if kubermaticv1helper.IsClusterAutoscalerEnabled(osData.Cluster()) {
 		creators = append(creators, clusterautoscaler.DeploymentCreator(osData))
 	}
 
@@ -654,21 +653,12 @@ func (r *Reconciler) getAllDeploymentCreators(ctx context.Context, osData *opens
 }
 
 func (r *Reconciler) deployments(ctx context.Context, osData *openshiftData) error {
-	return reconciling.ReconcileDeployments(ctx, r.getAllDeploymentCreators(ctx, osData), osData.Cluster().Status.NamespaceName, r.Client)
-}
-
-func GetCronJobCreators(osData *openshiftData) []reconciling.NamedCronJobCreatorGetter {
-	return []reconciling.NamedCronJobCreatorGetter{
-		etcd.CronJobCreator(osData),
+	// The usercluster-controller runs with its own serviceaccount
+	serviceAccountCreators := []reconciling.NamedServiceAccountCreatorGetter{usercluster.ServiceAccountCreator()}
+	if err := reconciling.ReconcileServiceAccounts(ctx, serviceAccountCreators, osData.Cluster().Status.NamespaceName, r.Client); err != nil {
+		return fmt.Errorf("failed to reconcile ServiceAccounts: %v", err)
 	}
-}
-
-func (r *Reconciler) cronJobs(ctx context.Context, osData *openshiftData) error {
-	creators := GetCronJobCreators(osData)
-	if err := reconciling.ReconcileCronJobs(ctx, creators, osData.Cluster().Status.NamespaceName, r.Client); err != nil {
-		return fmt.Errorf("failed to ensure that the CronJobs exists: %v", err)
-	}
-	return nil
+	return reconciling.ReconcileDeployments(ctx, r.getAllDeploymentCreators(ctx, osData), osData.Cluster().Status.NamespaceName, r.Client)
 }
 
 func GetPodDisruptionBudgetCreators(osData *openshiftData) []reconciling.NamedPodDisruptionBudgetCreatorGetter {
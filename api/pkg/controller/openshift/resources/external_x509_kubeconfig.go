@@ -22,7 +22,7 @@ func ExternalX509KubeconfigCreator(data openshiftData) reconciling.NamedSecretCr
 			url := data.Cluster().Address.URL
 			cn := ExternalX509KubeconfigName
 			organizations := []string{"system:masters"}
-			valid, err := resources.IsValidKubeconfig(b, ca.Cert, url, cn, organizations, data.Cluster().Name)
+			valid, err := resources.IsValidKubeconfig(b, ca, url, cn, organizations, data.Cluster().Name)
 			if err != nil {
 				return nil, fmt.Errorf("failed to validate kubeconfig: %v", err)
 			}
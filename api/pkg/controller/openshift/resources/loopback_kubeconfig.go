@@ -47,7 +47,7 @@ func GetLoopbackKubeconfigCreator(ctx context.Context, data loopbackKubeconfigCr
 			url := fmt.Sprintf("https://127.0.0.1:%d", port)
 
 			b := se.Data[resources.KubeconfigSecretKey]
-			valid, err := resources.IsValidKubeconfig(b, ca.Cert, url, commonName, organizations, data.Cluster().Name)
+			valid, err := resources.IsValidKubeconfig(b, ca, url, commonName, organizations, data.Cluster().Name)
 			if err != nil || !valid {
 				if err != nil {
 					log.Infow("failed to validate existing kubeconfig. Regenerating it...", "secret-namespace", se.Namespace, "secret-name", se.Name, zap.Error(err))
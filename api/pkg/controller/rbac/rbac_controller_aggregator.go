@@ -87,6 +87,19 @@ func New(metrics *Metrics, allClusterProviders []*ClusterProvider, workerCount i
 			kind: kubermaticv1.UserProjectBindingKind,
 		},
 
+		{
+			gvr: schema.GroupVersionResource{
+				Group:    kubermaticv1.GroupName,
+				Version:  kubermaticv1.GroupVersion,
+				Resource: kubermaticv1.ClusterTemplateResourceName,
+			},
+			kind: kubermaticv1.ClusterTemplateKindName,
+			shouldEnqueue: func(obj metav1.Object) bool {
+				// global templates don't belong to any project and are readable by everybody
+				return len(obj.GetOwnerReferences()) > 0
+			},
+		},
+
 		{
 			gvr: schema.GroupVersionResource{
 				Group:    k8scorev1.GroupName,
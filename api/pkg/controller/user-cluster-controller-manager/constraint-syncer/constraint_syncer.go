@@ -0,0 +1,302 @@
+package constraintsyncer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/restmapper"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "constraint_syncer_controller"
+
+	// The synced objects only get reconciled on changes in the seed cluster, changes in the
+	// user cluster get reverted after this period
+	resyncPeriod = 5 * time.Minute
+	// Constraints can only be created once Gatekeeper established the CRD for their template
+	constraintCRDWaitPeriod = 10 * time.Second
+
+	managedByLabelKey   = "app.kubernetes.io/managed-by"
+	managedByLabelValue = "kubermatic"
+)
+
+type reconciler struct {
+	ctx        context.Context
+	log        *zap.SugaredLogger
+	seedClient ctrlruntimeclient.Client
+	// Gatekeeper creates a CRD for every template, the user cluster client must know about them
+	newUserClient func() (ctrlruntimeclient.Client, error)
+	projectID     string
+}
+
+// Add creates a controller which syncs the ConstraintTemplates and the Constraints of the seed cluster
+// into the user cluster as Gatekeeper resources. The controller gets triggered by the seedMgr, which
+// must have read access to both resources in the seed cluster.
+func Add(ctx context.Context, log *zap.SugaredLogger, seedMgr, userMgr manager.Manager, projectID string) error {
+	log = log.Named(controllerName)
+
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(userMgr.GetConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create discovery client: %v", err)
+	}
+	r := &reconciler{
+		ctx:        ctx,
+		log:        log,
+		seedClient: seedMgr.GetClient(),
+		newUserClient: func() (ctrlruntimeclient.Client, error) {
+			groupResources, err := restmapper.GetAPIGroupResources(discoveryClient)
+			if err != nil {
+				return nil, fmt.Errorf("failed to discover the API group resources: %v", err)
+			}
+			return ctrlruntimeclient.New(userMgr.GetConfig(), ctrlruntimeclient.Options{
+				Scheme: userMgr.GetScheme(),
+				Mapper: restmapper.NewDiscoveryRESTMapper(groupResources),
+			})
+		},
+		projectID: projectID,
+	}
+	c, err := controller.New(controllerName, seedMgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return fmt.Errorf("failed to create controller: %v", err)
+	}
+
+	// All templates and constraints get reconciled at once, as deleted ones must be removed from
+	// the user cluster. We use a static identifier to have only one reconcile running at a time.
+	mapFn := handler.ToRequestsFunc(func(o handler.MapObject) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "identifier"}}}
+	})
+	for _, t := range []runtime.Object{&kubermaticv1.ConstraintTemplate{}, &kubermaticv1.Constraint{}} {
+		if err := c.Watch(&source.Kind{Type: t}, &handler.EnqueueRequestsFromMapFunc{ToRequests: mapFn}); err != nil {
+			return fmt.Errorf("failed to create watch for %T: %v", t, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	result, err := r.reconcile()
+	if err != nil {
+		r.log.Errorw("Reconciling failed", zap.Error(err))
+	}
+	return result, err
+}
+
+func (r *reconciler) reconcile() (reconcile.Result, error) {
+	templates := &kubermaticv1.ConstraintTemplateList{}
+	if err := r.seedClient.List(r.ctx, &ctrlruntimeclient.ListOptions{}, templates); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list ConstraintTemplates: %v", err)
+	}
+	constraints := &kubermaticv1.ConstraintList{}
+	if err := r.seedClient.List(r.ctx, &ctrlruntimeclient.ListOptions{}, constraints); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list Constraints: %v", err)
+	}
+	userClient, err := r.newUserClient()
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to create user cluster client: %v", err)
+	}
+
+	var templateCreators []reconciling.NamedUnstructuredCreatorGetter
+	desiredTemplates := sets.NewString()
+	for i := range templates.Items {
+		creator := constraintTemplateCreator(&templates.Items[i])
+		name, _, _, _ := creator()
+		templateCreators = append(templateCreators, creator)
+		desiredTemplates.Insert(name)
+	}
+	if err := reconciling.ReconcileUnstructureds(r.ctx, templateCreators, "", userClient); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to reconcile ConstraintTemplates: %v", err)
+	}
+
+	result := reconcile.Result{RequeueAfter: resyncPeriod}
+	desiredConstraints := map[string]sets.String{}
+	for _, constraint := range filterConstraints(constraints.Items, r.projectID) {
+		creator := constraintCreator(constraint)
+		name, kind, _, _ := creator()
+		if desiredConstraints[kind] == nil {
+			desiredConstraints[kind] = sets.NewString()
+		}
+		desiredConstraints[kind].Insert(name)
+
+		crdExists, err := r.constraintCRDExists(userClient, kind)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !crdExists {
+			r.log.Debugw("CRD of constraint does not exist yet", "constraint", name, "kind", kind)
+			result.RequeueAfter = constraintCRDWaitPeriod
+			continue
+		}
+		if err := reconciling.ReconcileUnstructureds(r.ctx, []reconciling.NamedUnstructuredCreatorGetter{creator}, "", userClient); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to reconcile constraint %s: %v", name, err)
+		}
+	}
+
+	if err := r.cleanup(userClient, desiredTemplates, desiredConstraints); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return result, nil
+}
+
+// cleanup deletes the templates and constraints which were synced before but don't exist in the seed
+// cluster anymore. Gatekeeper deletes the constraints of deleted templates itself.
+func (r *reconciler) cleanup(userClient ctrlruntimeclient.Client, desiredTemplates sets.String, desiredConstraints map[string]sets.String) error {
+	templates, err := r.listManaged(userClient, gatekeeper.ConstraintTemplateAPIVersion, "ConstraintTemplateList")
+	if err != nil {
+		return fmt.Errorf("failed to list ConstraintTemplates in the user cluster: %v", err)
+	}
+	for i := range templates.Items {
+		template := &templates.Items[i]
+		if desiredTemplates.Has(template.GetName()) {
+			continue
+		}
+		if err := userClient.Delete(r.ctx, template); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete ConstraintTemplate %s: %v", template.GetName(), err)
+		}
+	}
+
+	for i := range templates.Items {
+		if !desiredTemplates.Has(templates.Items[i].GetName()) {
+			continue
+		}
+		kind, _, _ := unstructured.NestedString(templates.Items[i].Object, "spec", "crd", "spec", "names", "kind")
+		constraints, err := r.listManaged(userClient, gatekeeper.ConstraintAPIVersion, kind+"List")
+		if err != nil {
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+			return fmt.Errorf("failed to list %s constraints in the user cluster: %v", kind, err)
+		}
+		for j := range constraints.Items {
+			constraint := &constraints.Items[j]
+			if desiredConstraints[kind].Has(constraint.GetName()) {
+				continue
+			}
+			if err := userClient.Delete(r.ctx, constraint); err != nil && !kerrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete %s constraint %s: %v", kind, constraint.GetName(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *reconciler) constraintCRDExists(userClient ctrlruntimeclient.Client, kind string) (bool, error) {
+	crd := &apiextensionsv1beta1.CustomResourceDefinition{}
+	name := types.NamespacedName{Name: fmt.Sprintf("%s.constraints.gatekeeper.sh", strings.ToLower(kind))}
+	if err := userClient.Get(r.ctx, name, crd); err != nil {
+		if kerrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get CRD %s: %v", name.Name, err)
+	}
+	return true, nil
+}
+
+func (r *reconciler) listManaged(userClient ctrlruntimeclient.Client, apiVersion, listKind string) (*unstructured.UnstructuredList, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(apiVersion)
+	list.SetKind(listKind)
+	opts := (&ctrlruntimeclient.ListOptions{}).MatchingLabels(map[string]string{managedByLabelKey: managedByLabelValue})
+	if err := userClient.List(r.ctx, opts, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// filterConstraints returns the constraints of the whole seed and the ones of the given project
+func filterConstraints(constraints []kubermaticv1.Constraint, projectID string) []*kubermaticv1.Constraint {
+	var filtered []*kubermaticv1.Constraint
+	for i := range constraints {
+		if constraints[i].Spec.ProjectID == "" || constraints[i].Spec.ProjectID == projectID {
+			filtered = append(filtered, &constraints[i])
+		}
+	}
+	return filtered
+}
+
+func constraintTemplateCreator(template *kubermaticv1.ConstraintTemplate) reconciling.NamedUnstructuredCreatorGetter {
+	// Gatekeeper requires the name of a template to be the lowercased kind of its constraints
+	name := strings.ToLower(template.Spec.CRD.Spec.Names.Kind)
+	return func() (string, string, string, reconciling.UnstructuredCreator) {
+		return name, "ConstraintTemplate", gatekeeper.ConstraintTemplateAPIVersion, func(u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+			spec, err := toUnstructuredMap(template.Spec)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert spec of ConstraintTemplate %s: %v", template.Name, err)
+			}
+			setManagedByLabel(u)
+			u.Object["spec"] = spec
+			return u, nil
+		}
+	}
+}
+
+// gatekeeperConstraintSpec is the spec of a Gatekeeper constraint
+type gatekeeperConstraintSpec struct {
+	EnforcementAction string                       `json:"enforcementAction,omitempty"`
+	Match             kubermaticv1.ConstraintMatch `json:"match,omitempty"`
+	Parameters        *runtime.RawExtension        `json:"parameters,omitempty"`
+}
+
+func constraintCreator(constraint *kubermaticv1.Constraint) reconciling.NamedUnstructuredCreatorGetter {
+	return func() (string, string, string, reconciling.UnstructuredCreator) {
+		return constraint.Name, constraint.Spec.ConstraintType, gatekeeper.ConstraintAPIVersion, func(u *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+			spec, err := toUnstructuredMap(gatekeeperConstraintSpec{
+				EnforcementAction: constraint.Spec.EnforcementAction,
+				Match:             constraint.Spec.Match,
+				Parameters:        constraint.Spec.Parameters,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert spec of Constraint %s: %v", constraint.Name, err)
+			}
+			setManagedByLabel(u)
+			u.Object["spec"] = spec
+			return u, nil
+		}
+	}
+}
+
+func setManagedByLabel(u *unstructured.Unstructured) {
+	labels := u.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[managedByLabelKey] = managedByLabelValue
+	u.SetLabels(labels)
+}
+
+func toUnstructuredMap(in interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
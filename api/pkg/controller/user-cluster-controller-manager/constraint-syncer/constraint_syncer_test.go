@@ -0,0 +1,112 @@
+package constraintsyncer
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestConstraintTemplateCreator(t *testing.T) {
+	template := &kubermaticv1.ConstraintTemplate{
+		ObjectMeta: metav1.ObjectMeta{Name: "required-labels"},
+		Spec: kubermaticv1.ConstraintTemplateSpec{
+			CRD: kubermaticv1.ConstraintTemplateCRD{
+				Spec: kubermaticv1.ConstraintTemplateCRDSpec{
+					Names: kubermaticv1.ConstraintTemplateNames{Kind: "K8sRequiredLabels"},
+				},
+			},
+			Targets: []kubermaticv1.ConstraintTemplateTarget{
+				{Target: "admission.k8s.gatekeeper.sh", Rego: "package k8srequiredlabels"},
+			},
+		},
+	}
+
+	name, kind, apiVersion, create := constraintTemplateCreator(template)()
+	if name != "k8srequiredlabels" {
+		t.Errorf("expected the template to be named after its kind, got %q", name)
+	}
+	if kind != "ConstraintTemplate" || apiVersion != "templates.gatekeeper.sh/v1beta1" {
+		t.Errorf("expected a templates.gatekeeper.sh/v1beta1 ConstraintTemplate, got %s %s", apiVersion, kind)
+	}
+
+	existing := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	existing.SetLabels(map[string]string{"foo": "bar"})
+	obj, err := create(existing)
+	if err != nil {
+		t.Fatalf("failed to create template: %v", err)
+	}
+
+	expectedLabels := map[string]string{"foo": "bar", managedByLabelKey: managedByLabelValue}
+	if diff := deep.Equal(obj.GetLabels(), expectedLabels); diff != nil {
+		t.Errorf("labels differ from the expected ones: %v", diff)
+	}
+	kindInSpec, _, _ := unstructured.NestedString(obj.Object, "spec", "crd", "spec", "names", "kind")
+	if kindInSpec != "K8sRequiredLabels" {
+		t.Errorf("expected kind K8sRequiredLabels in the spec, got %q", kindInSpec)
+	}
+	targets, _, _ := unstructured.NestedSlice(obj.Object, "spec", "targets")
+	expectedTargets := []interface{}{
+		map[string]interface{}{"target": "admission.k8s.gatekeeper.sh", "rego": "package k8srequiredlabels"},
+	}
+	if diff := deep.Equal(targets, expectedTargets); diff != nil {
+		t.Errorf("targets differ from the expected ones: %v", diff)
+	}
+}
+
+func TestConstraintCreator(t *testing.T) {
+	constraint := &kubermaticv1.Constraint{
+		ObjectMeta: metav1.ObjectMeta{Name: "ns-must-have-owner"},
+		Spec: kubermaticv1.ConstraintSpec{
+			ConstraintType:    "K8sRequiredLabels",
+			EnforcementAction: kubermaticv1.ConstraintEnforcementActionDryRun,
+			Match: kubermaticv1.ConstraintMatch{
+				Kinds: []kubermaticv1.ConstraintMatchKind{{APIGroups: []string{""}, Kinds: []string{"Namespace"}}},
+			},
+			Parameters: &runtime.RawExtension{Raw: []byte(`{"labels":["owner"]}`)},
+		},
+	}
+
+	name, kind, apiVersion, create := constraintCreator(constraint)()
+	if name != "ns-must-have-owner" || kind != "K8sRequiredLabels" || apiVersion != "constraints.gatekeeper.sh/v1beta1" {
+		t.Errorf("expected constraints.gatekeeper.sh/v1beta1 K8sRequiredLabels ns-must-have-owner, got %s %s %s", apiVersion, kind, name)
+	}
+
+	obj, err := create(&unstructured.Unstructured{Object: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("failed to create constraint: %v", err)
+	}
+	expectedSpec := map[string]interface{}{
+		"enforcementAction": "dryrun",
+		"match": map[string]interface{}{
+			"kinds": []interface{}{
+				map[string]interface{}{"apiGroups": []interface{}{""}, "kinds": []interface{}{"Namespace"}},
+			},
+		},
+		"parameters": map[string]interface{}{"labels": []interface{}{"owner"}},
+	}
+	if diff := deep.Equal(obj.Object["spec"], expectedSpec); diff != nil {
+		t.Errorf("spec differs from the expected one: %v", diff)
+	}
+}
+
+func TestFilterConstraints(t *testing.T) {
+	constraints := []kubermaticv1.Constraint{
+		{ObjectMeta: metav1.ObjectMeta{Name: "seed"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "own-project"}, Spec: kubermaticv1.ConstraintSpec{ProjectID: "my-project"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-project"}, Spec: kubermaticv1.ConstraintSpec{ProjectID: "other-project"}},
+	}
+
+	var names []string
+	for _, constraint := range filterConstraints(constraints, "my-project") {
+		names = append(names, constraint.Name)
+	}
+	if diff := deep.Equal(names, []string{"seed", "own-project"}); diff != nil {
+		t.Errorf("filtered constraints differ from the expected ones: %v", diff)
+	}
+}
@@ -0,0 +1,325 @@
+package machinehealthcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	machineresource "github.com/kubermatic/kubermatic/api/pkg/resources/machine"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// This controller creates events on the machine deployments, so do not put the word Kubermatic in it
+	controllerName = "machine_health_check_controller"
+
+	defaultUnhealthyConditionTimeout = 5 * time.Minute
+	defaultNodeStartupTimeout        = 20 * time.Minute
+)
+
+var defaultMaxUnhealthy = intstr.FromString("40%")
+
+type reconciler struct {
+	ctx      context.Context
+	log      *zap.SugaredLogger
+	client   ctrlruntimeclient.Client
+	recorder record.EventRecorder
+	now      func() time.Time
+}
+
+// healthCheck is the health check of a MachineDeployment with all defaults applied
+type healthCheck struct {
+	unhealthyConditions []unhealthyCondition
+	maxUnhealthy        intstr.IntOrString
+	nodeStartupTimeout  time.Duration
+}
+
+type unhealthyCondition struct {
+	conditionType corev1.NodeConditionType
+	status        corev1.ConditionStatus
+	timeout       time.Duration
+}
+
+// Add creates a controller which deletes the machines of MachineDeployments with a health check
+// whose nodes are unhealthy, so they get replaced by the MachineSet.
+func Add(ctx context.Context, log *zap.SugaredLogger, mgr manager.Manager) error {
+	log = log.Named(controllerName)
+	r := &reconciler{
+		ctx:      ctx,
+		log:      log,
+		client:   mgr.GetClient(),
+		recorder: mgr.GetRecorder(controllerName),
+		now:      time.Now,
+	}
+	c, err := controller.New(controllerName, mgr, controller.Options{
+		Reconciler: r,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create controller: %v", err)
+	}
+
+	if err := c.Watch(&source.Kind{Type: &clusterv1alpha1.MachineDeployment{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return fmt.Errorf("failed to establish watch for machine deployments: %v", err)
+	}
+
+	enqueueMachineDeployments := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(func(a handler.MapObject) []reconcile.Request {
+		return r.machineDeploymentsWithHealthCheck(log)
+	})}
+	if err := c.Watch(&source.Kind{Type: &clusterv1alpha1.Machine{}}, enqueueMachineDeployments); err != nil {
+		return fmt.Errorf("failed to establish watch for machines: %v", err)
+	}
+
+	// The kubelet regularly updates the heartbeat of the node conditions, only react
+	// to actual changes of their status
+	conditionsChangedPredicate := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, oldOK := e.ObjectOld.(*corev1.Node)
+			newNode, newOK := e.ObjectNew.(*corev1.Node)
+			if !oldOK || !newOK {
+				return true
+			}
+			return !conditionStatusesEqual(oldNode.Status.Conditions, newNode.Status.Conditions)
+		},
+	}
+	if err := c.Watch(&source.Kind{Type: &corev1.Node{}}, enqueueMachineDeployments, conditionsChangedPredicate); err != nil {
+		return fmt.Errorf("failed to establish watch for nodes: %v", err)
+	}
+
+	return nil
+}
+
+func (r *reconciler) machineDeploymentsWithHealthCheck(log *zap.SugaredLogger) []reconcile.Request {
+	machineDeployments := &clusterv1alpha1.MachineDeploymentList{}
+	if err := r.client.List(r.ctx, &ctrlruntimeclient.ListOptions{}, machineDeployments); err != nil {
+		log.Errorw("Failed to list machine deployments", zap.Error(err))
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, md := range machineDeployments.Items {
+		if _, ok := md.Annotations[machineresource.HealthCheckAnnotation]; ok {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: md.Namespace, Name: md.Name}})
+		}
+	}
+	return requests
+}
+
+func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.With("MachineDeployment", request.NamespacedName)
+	log.Debug("Reconciling")
+
+	md := &clusterv1alpha1.MachineDeployment{}
+	if err := r.client.Get(r.ctx, request.NamespacedName, md); err != nil {
+		if kerrors.IsNotFound(err) {
+			log.Debug("MachineDeployment not found, returning")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get machine deployment: %v", err)
+	}
+
+	result, err := r.reconcile(log, md)
+	if err != nil {
+		log.Errorw("Reconciling failed", zap.Error(err))
+		r.recorder.Event(md, corev1.EventTypeWarning, "MachineHealthCheckFailed", err.Error())
+	}
+	return result, err
+}
+
+func (r *reconciler) reconcile(log *zap.SugaredLogger, md *clusterv1alpha1.MachineDeployment) (reconcile.Result, error) {
+	if md.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	apiHealthCheck, err := machineresource.HealthCheck(md)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if apiHealthCheck == nil {
+		log.Debug("MachineDeployment has no health check, skipping")
+		return reconcile.Result{}, nil
+	}
+	hc, err := withDefaults(apiHealthCheck)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(&md.Spec.Selector)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to parse the selector: %v", err)
+	}
+	machineList := &clusterv1alpha1.MachineList{}
+	if err := r.client.List(r.ctx, &ctrlruntimeclient.ListOptions{Namespace: md.Namespace, LabelSelector: selector}, machineList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list machines: %v", err)
+	}
+	nodeList := &corev1.NodeList{}
+	if err := r.client.List(r.ctx, &ctrlruntimeclient.ListOptions{}, nodeList); err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to list nodes: %v", err)
+	}
+	nodes := map[string]*corev1.Node{}
+	for i := range nodeList.Items {
+		nodes[nodeList.Items[i].Name] = &nodeList.Items[i]
+	}
+
+	now := r.now()
+	var total, deleting int
+	var unhealthy []*clusterv1alpha1.Machine
+	reasons := map[string]string{}
+	var recheckIn time.Duration
+	for i := range machineList.Items {
+		machine := &machineList.Items[i]
+		if !selector.Matches(labels.Set(machine.Labels)) {
+			continue
+		}
+		total++
+		// Machines which are being deleted are either remediated already or unavailable for another reason
+		if machine.DeletionTimestamp != nil {
+			deleting++
+			continue
+		}
+		reason, machineRecheckIn := hc.check(machine, nodes, now)
+		if reason != "" {
+			unhealthy = append(unhealthy, machine)
+			reasons[machine.Name] = reason
+			continue
+		}
+		recheckIn = minDuration(recheckIn, machineRecheckIn)
+	}
+	result := reconcile.Result{RequeueAfter: recheckIn}
+
+	if len(unhealthy) == 0 {
+		return result, nil
+	}
+
+	maxUnhealthy, err := intstr.GetValueFromIntOrPercent(&hc.maxUnhealthy, total, true)
+	if err != nil {
+		return reconcile.Result{}, fmt.Errorf("failed to calculate maxUnhealthy: %v", err)
+	}
+	if len(unhealthy)+deleting > maxUnhealthy {
+		// Most likely the nodes can't reach the control plane, replacing them wouldn't help
+		log.Infow("Too many unhealthy machines, not replacing any", "unhealthy", len(unhealthy), "deleting", deleting, "maxUnhealthy", maxUnhealthy)
+		r.recorder.Eventf(md, corev1.EventTypeWarning, "RemediationRestricted", "%d of %d machines are unhealthy and %d are being deleted, which is more than the %d allowed, not replacing any of them", len(unhealthy), total, deleting, maxUnhealthy)
+		return result, nil
+	}
+
+	for _, machine := range unhealthy {
+		if owner := metav1.GetControllerOf(machine); owner == nil || owner.Kind != "MachineSet" {
+			r.recorder.Eventf(md, corev1.EventTypeWarning, "RemediationSkipped", "Machine %s is unhealthy, but doesn't belong to a machine set which would replace it", machine.Name)
+			continue
+		}
+		log.Infow("Deleting unhealthy machine", "machine", machine.Name, "reason", reasons[machine.Name])
+		if err := r.client.Delete(r.ctx, machine); err != nil && !kerrors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to delete unhealthy machine %s: %v", machine.Name, err)
+		}
+		r.recorder.Eventf(md, corev1.EventTypeNormal, "MachineRemediated", "Deleted unhealthy machine %s, %s", machine.Name, reasons[machine.Name])
+	}
+
+	return result, nil
+}
+
+// check returns why the given machine is unhealthy. If it is healthy, it returns after which
+// time it could become unhealthy, or 0 if no condition which makes it unhealthy is present.
+func (hc *healthCheck) check(machine *clusterv1alpha1.Machine, nodes map[string]*corev1.Node, now time.Time) (string, time.Duration) {
+	if machine.Status.NodeRef == nil {
+		elapsed := now.Sub(machine.CreationTimestamp.Time)
+		if elapsed < hc.nodeStartupTimeout {
+			return "", hc.nodeStartupTimeout - elapsed
+		}
+		return fmt.Sprintf("its node didn't join the cluster within %v", hc.nodeStartupTimeout), 0
+	}
+
+	node, ok := nodes[machine.Status.NodeRef.Name]
+	if !ok {
+		return fmt.Sprintf("its node %s doesn't exist anymore", machine.Status.NodeRef.Name), 0
+	}
+
+	var recheckIn time.Duration
+	for _, unhealthyCondition := range hc.unhealthyConditions {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type != unhealthyCondition.conditionType || condition.Status != unhealthyCondition.status {
+				continue
+			}
+			elapsed := now.Sub(condition.LastTransitionTime.Time)
+			if elapsed >= unhealthyCondition.timeout {
+				return fmt.Sprintf("the %s condition of its node %s has been %s for more than %v", condition.Type, node.Name, condition.Status, unhealthyCondition.timeout), 0
+			}
+			recheckIn = minDuration(recheckIn, unhealthyCondition.timeout-elapsed)
+		}
+	}
+	return "", recheckIn
+}
+
+func withDefaults(apiHealthCheck *apiv1.MachineHealthCheck) (*healthCheck, error) {
+	hc := &healthCheck{
+		maxUnhealthy:       defaultMaxUnhealthy,
+		nodeStartupTimeout: defaultNodeStartupTimeout,
+	}
+	if apiHealthCheck.MaxUnhealthy != nil {
+		hc.maxUnhealthy = *apiHealthCheck.MaxUnhealthy
+	}
+	if apiHealthCheck.NodeStartupTimeout != "" {
+		timeout, err := time.ParseDuration(apiHealthCheck.NodeStartupTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse nodeStartupTimeout: %v", err)
+		}
+		hc.nodeStartupTimeout = timeout
+	}
+	for _, condition := range apiHealthCheck.UnhealthyConditions {
+		timeout, err := time.ParseDuration(condition.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the timeout of the unhealthy condition %s: %v", condition.Type, err)
+		}
+		hc.unhealthyConditions = append(hc.unhealthyConditions, unhealthyCondition{
+			conditionType: corev1.NodeConditionType(condition.Type),
+			status:        corev1.ConditionStatus(condition.Status),
+			timeout:       timeout,
+		})
+	}
+	if len(hc.unhealthyConditions) == 0 {
+		hc.unhealthyConditions = []unhealthyCondition{
+			{conditionType: corev1.NodeReady, status: corev1.ConditionFalse, timeout: defaultUnhealthyConditionTimeout},
+			{conditionType: corev1.NodeReady, status: corev1.ConditionUnknown, timeout: defaultUnhealthyConditionTimeout},
+		}
+	}
+	return hc, nil
+}
+
+func conditionStatusesEqual(a, b []corev1.NodeCondition) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	statuses := map[corev1.NodeConditionType]corev1.ConditionStatus{}
+	for _, condition := range a {
+		statuses[condition.Type] = condition.Status
+	}
+	for _, condition := range b {
+		if status, ok := statuses[condition.Type]; !ok || status != condition.Status {
+			return false
+		}
+	}
+	return true
+}
+
+// minDuration returns the smaller of both durations, where 0 means unset
+func minDuration(a, b time.Duration) time.Duration {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,230 @@
+package machinehealthcheck
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"go.uber.org/zap"
+
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+	machineresource "github.com/kubermatic/kubermatic/api/pkg/resources/machine"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func init() {
+	// We call this in init because even thought it is possible to register the same
+	// scheme multiple times it is an unprotected concurrent map access and these tests
+	// are very good at making that panic
+	log := kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar()
+	if err := clusterv1alpha1.SchemeBuilder.AddToScheme(scheme.Scheme); err != nil {
+		log.Fatalw("failed to add clusterv1alpha1 scheme to scheme.Scheme", zap.Error(err))
+	}
+}
+
+var testNow = time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+
+func genMachineDeployment(healthCheck string) *clusterv1alpha1.MachineDeployment {
+	md := &clusterv1alpha1.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: "workers"},
+		Spec: clusterv1alpha1.MachineDeploymentSpec{
+			Selector: metav1.LabelSelector{MatchLabels: map[string]string{"md": "workers"}},
+		},
+	}
+	if healthCheck != "" {
+		md.Annotations = map[string]string{machineresource.HealthCheckAnnotation: healthCheck}
+	}
+	return md
+}
+
+func genMachine(name string, created time.Time, withNode bool) *clusterv1alpha1.Machine {
+	isController := true
+	machine := &clusterv1alpha1.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         metav1.NamespaceSystem,
+			Name:              name,
+			Labels:            map[string]string{"md": "workers"},
+			CreationTimestamp: metav1.NewTime(created),
+			OwnerReferences:   []metav1.OwnerReference{{APIVersion: "cluster.k8s.io/v1alpha1", Kind: "MachineSet", Name: "workers-1", UID: "workers-1", Controller: &isController}},
+		},
+	}
+	if withNode {
+		machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: name}
+	}
+	return machine
+}
+
+func genDeletingMachine(name string, created time.Time) *clusterv1alpha1.Machine {
+	machine := genMachine(name, created, true)
+	deletionTimestamp := metav1.NewTime(testNow)
+	machine.DeletionTimestamp = &deletionTimestamp
+	machine.Finalizers = []string{"machine-delete-finalizer"}
+	return machine
+}
+
+func genNode(name string, conditions ...corev1.NodeCondition) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NodeStatus{Conditions: conditions},
+	}
+}
+
+func genCondition(conditionType corev1.NodeConditionType, status corev1.ConditionStatus, since time.Duration) corev1.NodeCondition {
+	return corev1.NodeCondition{Type: conditionType, Status: status, LastTransitionTime: metav1.NewTime(testNow.Add(-since))}
+}
+
+func TestReconcile(t *testing.T) {
+	ready := genCondition(corev1.NodeReady, corev1.ConditionTrue, time.Hour)
+	testCases := []struct {
+		name                string
+		objects             []runtime.Object
+		expectedMachines    []string
+		expectedRequeueTime time.Duration
+	}{
+		{
+			name: "machine deployment without health check",
+			objects: []runtime.Object{
+				genMachineDeployment(""),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genNode("a", genCondition(corev1.NodeReady, corev1.ConditionFalse, time.Hour)),
+			},
+			expectedMachines: []string{"a"},
+		},
+		{
+			name: "healthy machines are kept",
+			objects: []runtime.Object{
+				genMachineDeployment(`{}`),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genMachine("b", testNow.Add(-time.Hour), true),
+				genNode("a", ready),
+				genNode("b", ready),
+			},
+			expectedMachines: []string{"a", "b"},
+		},
+		{
+			name: "machine whose node isn't ready for longer than the default timeout gets deleted",
+			objects: []runtime.Object{
+				genMachineDeployment(`{}`),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genMachine("b", testNow.Add(-time.Hour), true),
+				genMachine("c", testNow.Add(-time.Hour), true),
+				genNode("a", ready),
+				genNode("b", ready),
+				genNode("c", genCondition(corev1.NodeReady, corev1.ConditionUnknown, 6*time.Minute)),
+			},
+			expectedMachines: []string{"a", "b"},
+		},
+		{
+			name: "machine whose node isn't ready for less than the timeout is kept and checked again",
+			objects: []runtime.Object{
+				genMachineDeployment(`{}`),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genNode("a", genCondition(corev1.NodeReady, corev1.ConditionFalse, 2*time.Minute)),
+			},
+			expectedMachines:    []string{"a"},
+			expectedRequeueTime: 3 * time.Minute,
+		},
+		{
+			name: "machine with a custom unhealthy condition gets deleted",
+			objects: []runtime.Object{
+				genMachineDeployment(`{"unhealthyConditions":[{"type":"DiskPressure","status":"True","timeout":"10m"}],"maxUnhealthy":1}`),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genMachine("b", testNow.Add(-time.Hour), true),
+				genNode("a", ready, genCondition(corev1.NodeDiskPressure, corev1.ConditionTrue, 15*time.Minute)),
+				genNode("b", genCondition(corev1.NodeReady, corev1.ConditionFalse, time.Hour)),
+			},
+			expectedMachines: []string{"b"},
+		},
+		{
+			name: "machine whose node didn't join within the startup timeout gets deleted",
+			objects: []runtime.Object{
+				genMachineDeployment(`{"nodeStartupTimeout":"10m"}`),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genMachine("b", testNow.Add(-15*time.Minute), false),
+				genMachine("c", testNow.Add(-5*time.Minute), false),
+				genNode("a", ready),
+			},
+			expectedMachines:    []string{"a", "c"},
+			expectedRequeueTime: 5 * time.Minute,
+		},
+		{
+			name: "no machine gets deleted when more than maxUnhealthy are unhealthy",
+			objects: []runtime.Object{
+				genMachineDeployment(`{"maxUnhealthy":"30%"}`),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genMachine("b", testNow.Add(-time.Hour), true),
+				genMachine("c", testNow.Add(-time.Hour), true),
+				genNode("a", ready),
+				genNode("b", genCondition(corev1.NodeReady, corev1.ConditionUnknown, time.Hour)),
+				genNode("c", genCondition(corev1.NodeReady, corev1.ConditionUnknown, time.Hour)),
+			},
+			expectedMachines: []string{"a", "b", "c"},
+		},
+		{
+			name: "machines which are being deleted count towards maxUnhealthy",
+			objects: []runtime.Object{
+				genMachineDeployment(`{"maxUnhealthy":"40%"}`),
+				genMachine("a", testNow.Add(-time.Hour), true),
+				genDeletingMachine("b", testNow.Add(-time.Hour)),
+				genMachine("c", testNow.Add(-time.Hour), true),
+				genMachine("d", testNow.Add(-time.Hour), true),
+				genMachine("e", testNow.Add(-time.Hour), true),
+				genNode("a", ready),
+				genNode("b", genCondition(corev1.NodeReady, corev1.ConditionUnknown, time.Hour)),
+				genNode("c", genCondition(corev1.NodeReady, corev1.ConditionUnknown, time.Hour)),
+				genNode("d", genCondition(corev1.NodeReady, corev1.ConditionUnknown, time.Hour)),
+				genNode("e", ready),
+			},
+			expectedMachines: []string{"a", "b", "c", "d", "e"},
+		},
+	}
+
+	for idx := range testCases {
+		tc := testCases[idx]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			client := fakectrlruntimeclient.NewFakeClient(tc.objects...)
+			r := &reconciler{
+				ctx:      context.Background(),
+				log:      kubermaticlog.Logger,
+				client:   client,
+				recorder: record.NewFakeRecorder(10),
+				now:      func() time.Time { return testNow },
+			}
+
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: metav1.NamespaceSystem, Name: "workers"}}
+			result, err := r.Reconcile(request)
+			if err != nil {
+				t.Fatalf("reconciling failed: %v", err)
+			}
+			if result.RequeueAfter != tc.expectedRequeueTime {
+				t.Errorf("expected to be requeued after %v, got %v", tc.expectedRequeueTime, result.RequeueAfter)
+			}
+
+			machines := &clusterv1alpha1.MachineList{}
+			if err := client.List(context.Background(), &ctrlruntimeclient.ListOptions{}, machines); err != nil {
+				t.Fatalf("failed to list machines: %v", err)
+			}
+			var machineNames []string
+			for _, machine := range machines.Items {
+				machineNames = append(machineNames, machine.Name)
+			}
+			sort.Strings(machineNames)
+			if diff := deep.Equal(machineNames, tc.expectedMachines); diff != nil {
+				t.Errorf("remaining machines differ from the expected ones, diff: %v", diff)
+			}
+		})
+	}
+}
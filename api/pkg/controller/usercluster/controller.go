@@ -10,6 +10,7 @@ import (
 	"github.com/heptiolabs/healthcheck"
 	"go.uber.org/zap"
 
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates/triple"
 
@@ -50,8 +51,12 @@ func Add(
 	userSSHKeys map[string][]byte,
 	registerReconciledCheck func(name string, check healthcheck.Check),
 	openVPNCA *resources.ECDSAKeyPair,
+	tunnelingMode kubermaticv1.TunnelingMode,
+	konnectivityServerPort int,
+	konnectivityCA *resources.ECDSAKeyPair,
 	userSSHKeyDirPath string,
 	cloudCredentialSecretTemplate *corev1.Secret,
+	opaIntegration bool,
 	log *zap.SugaredLogger) error {
 	reconciler := &reconciler{
 		Client:                        mgr.GetClient(),
@@ -64,8 +69,12 @@ func Add(
 		clusterURL:                    clusterURL,
 		openvpnServerPort:             openvpnServerPort,
 		openVPNCA:                     openVPNCA,
+		tunnelingMode:                 tunnelingMode,
+		konnectivityServerPort:        konnectivityServerPort,
+		konnectivityCA:                konnectivityCA,
 		userSSHKeyDirPath:             userSSHKeyDirPath,
 		cloudCredentialSecretTemplate: cloudCredentialSecretTemplate,
+		opaIntegration:                opaIntegration,
 		log:                           log,
 		platform:                      cloudProviderName,
 		userSSHKeys:                   userSSHKeys,
@@ -100,6 +109,7 @@ func Add(
 		&rbacv1.ClusterRole{},
 		&rbacv1.ClusterRoleBinding{},
 		&admissionregistrationv1beta1.MutatingWebhookConfiguration{},
+		&admissionregistrationv1beta1.ValidatingWebhookConfiguration{},
 		&apiextensionsv1beta1.CustomResourceDefinition{},
 	}
 
@@ -157,10 +167,14 @@ type reconciler struct {
 	clusterURL                    *url.URL
 	openvpnServerPort             int
 	openVPNCA                     *resources.ECDSAKeyPair
+	tunnelingMode                 kubermaticv1.TunnelingMode
+	konnectivityServerPort        int
+	konnectivityCA                *resources.ECDSAKeyPair
 	userSSHKeyDirPath             string
 	platform                      string
 	cloudCredentialSecretTemplate *corev1.Secret
 	userSSHKeys                   map[string][]byte
+	opaIntegration                bool
 
 	rLock                      *sync.Mutex
 	reconciledSuccessfullyOnce bool
@@ -168,6 +182,43 @@ type reconciler struct {
 	log *zap.SugaredLogger
 }
 
+// Render reconciles the resources of a Kubernetes user cluster once into the given client. Passing a fake
+// client allows rendering the manifests of a user cluster without connecting to it.
+func Render(
+	ctx context.Context,
+	client client.Client,
+	version string,
+	namespace string,
+	cloudProviderName string,
+	caCert *triple.KeyPair,
+	clusterURL *url.URL,
+	openvpnServerPort int,
+	openVPNCA *resources.ECDSAKeyPair,
+	tunnelingMode kubermaticv1.TunnelingMode,
+	konnectivityServerPort int,
+	konnectivityCA *resources.ECDSAKeyPair,
+	opaIntegration bool,
+	log *zap.SugaredLogger) error {
+	r := &reconciler{
+		Client:                 client,
+		version:                version,
+		rLock:                  &sync.Mutex{},
+		namespace:              namespace,
+		caCert:                 caCert,
+		clusterURL:             clusterURL,
+		openvpnServerPort:      openvpnServerPort,
+		openVPNCA:              openVPNCA,
+		tunnelingMode:          tunnelingMode,
+		konnectivityServerPort: konnectivityServerPort,
+		konnectivityCA:         konnectivityCA,
+		opaIntegration:         opaIntegration,
+		log:                    log,
+		platform:               cloudProviderName,
+		userSSHKeys:            map[string][]byte{},
+	}
+	return r.reconcile(ctx)
+}
+
 // Reconcile makes changes in response to objects in the user cluster.
 func (r *reconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	if err := r.reconcile(context.TODO()); err != nil {
@@ -8,6 +8,8 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/clusterautoscaler"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/controller-manager"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/dnat-controller"
+	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/gatekeeper"
+	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/konnectivity"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/kube-state-metrics"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/kubernetes-dashboard"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/machine-controller"
@@ -19,17 +21,31 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/system-basic-user"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/user-auth"
 	"github.com/kubermatic/kubermatic/api/pkg/controller/usercluster/resources/usersshkeys"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates/triple"
+	seedmetricsserver "github.com/kubermatic/kubermatic/api/pkg/resources/metrics-server"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
 
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	certutil "k8s.io/client-go/util/cert"
+	"k8s.io/apimachinery/pkg/runtime"
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // Reconcile creates, updates, or deletes Kubernetes resources to match the desired state.
 func (r *reconciler) reconcile(ctx context.Context) error {
-	// Must be first because of openshift
+	// The Gatekeeper webhook of a disabled OPA integration must be gone before anything else, Gatekeeper
+	// only gets removed from the control plane afterwards
+	if err := r.reconcileValidatingWebhookConfigurations(ctx); err != nil {
+		return err
+	}
+
+	// Must be next because of openshift
 	if err := r.ensureAPIServices(ctx); err != nil {
 		return err
 	}
@@ -88,20 +104,31 @@ func (r *reconciler) reconcile(ctx context.Context) error {
 		return err
 	}
 
+	if err := r.deleteUnusedTunnelingResources(ctx); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// metricsServerInCluster returns whether the metrics-server runs inside the user cluster. This is the case
+// when the control plane reaches the cluster through konnectivity, as the metrics-server in the seed
+// cluster has no way to reach the kubelets then.
+func (r *reconciler) metricsServerInCluster() bool {
+	return !r.openshift && r.tunnelingMode == kubermaticv1.TunnelingModeKonnectivity
+}
+
 func (r *reconciler) ensureAPIServices(ctx context.Context) error {
 	creators := []reconciling.NamedAPIServiceCreatorGetter{}
-	caCert := certutil.EncodeCertPEM(r.caCert.Cert)
+	caBundle := resources.EncodeCABundlePEM(r.caCert)
 	if r.openshift {
-		openshiftAPIServiceCreators, err := openshift.GetAPIServicesForOpenshiftVersion(r.version, caCert)
+		openshiftAPIServiceCreators, err := openshift.GetAPIServicesForOpenshiftVersion(r.version, caBundle)
 		if err != nil {
 			return fmt.Errorf("failed to get openshift apiservice creators: %v", err)
 		}
 		creators = append(creators, openshiftAPIServiceCreators...)
 	} else {
-		creators = append(creators, metricsserver.APIServiceCreator(caCert))
+		creators = append(creators, metricsserver.APIServiceCreator(caBundle))
 	}
 
 	if err := reconciling.ReconcileAPIServices(ctx, creators, metav1.NamespaceNone, r.Client); err != nil {
@@ -113,6 +140,7 @@ func (r *reconciler) ensureAPIServices(ctx context.Context) error {
 func (r *reconciler) reconcileServiceAcconts(ctx context.Context) error {
 	creators := []reconciling.NamedServiceAccountCreatorGetter{
 		userauth.ServiceAccountCreator(),
+		metricsserver.ServiceAccountCreator(),
 	}
 
 	if err := reconciling.ReconcileServiceAccounts(ctx, creators, metav1.NamespaceSystem, r.Client); err != nil {
@@ -304,6 +332,12 @@ func (r *reconciler) reconcileCRDs(ctx context.Context) error {
 		machinecontroller.MachineDeploymentCRDCreator(),
 		machinecontroller.ClusterCRDCreator(),
 	}
+	if r.opaIntegration {
+		creators = append(creators,
+			gatekeeper.ConstraintTemplateCRDCreator(),
+			gatekeeper.ConfigCRDCreator(),
+		)
+	}
 
 	if err := reconciling.ReconcileCustomResourceDefinitions(ctx, creators, "", r.Client); err != nil {
 		return fmt.Errorf("failed to reconcile CustomResourceDefinitions: %v", err)
@@ -313,7 +347,7 @@ func (r *reconciler) reconcileCRDs(ctx context.Context) error {
 
 func (r *reconciler) reconcileMutatingWebhookConfigurations(ctx context.Context) error {
 	creators := []reconciling.NamedMutatingWebhookConfigurationCreatorGetter{
-		machinecontroller.MutatingwebhookConfigurationCreator(r.caCert.Cert, r.namespace),
+		machinecontroller.MutatingwebhookConfigurationCreator(resources.EncodeCABundlePEM(r.caCert), r.namespace),
 	}
 
 	if err := reconciling.ReconcileMutatingWebhookConfigurations(ctx, creators, "", r.Client); err != nil {
@@ -322,9 +356,33 @@ func (r *reconciler) reconcileMutatingWebhookConfigurations(ctx context.Context)
 	return nil
 }
 
+func (r *reconciler) reconcileValidatingWebhookConfigurations(ctx context.Context) error {
+	if !r.opaIntegration {
+		// Remove the webhook of a previously enabled OPA integration
+		webhookConfiguration := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+		webhookConfiguration.Name = resources.GatekeeperValidatingWebhookConfigurationName
+		if err := r.Delete(ctx, webhookConfiguration); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete the Gatekeeper ValidatingWebhookConfiguration: %v", err)
+		}
+		return nil
+	}
+
+	creators := []reconciling.NamedValidatingWebhookConfigurationCreatorGetter{
+		gatekeeper.ValidatingWebhookConfigurationCreator(resources.EncodeCABundlePEM(r.caCert), r.namespace),
+	}
+
+	if err := reconciling.ReconcileValidatingWebhookConfigurations(ctx, creators, "", r.Client); err != nil {
+		return fmt.Errorf("failed to reconcile ValidatingWebhookConfigurations: %v", err)
+	}
+	return nil
+}
+
 func (r *reconciler) reconcileServices(ctx context.Context) error {
-	creatorsKubeSystem := []reconciling.NamedServiceCreatorGetter{
-		metricsserver.ExternalNameServiceCreator(r.namespace),
+	creatorsKubeSystem := []reconciling.NamedServiceCreatorGetter{}
+	if r.metricsServerInCluster() {
+		creatorsKubeSystem = append(creatorsKubeSystem, metricsserver.ServiceCreator())
+	} else {
+		creatorsKubeSystem = append(creatorsKubeSystem, metricsserver.ExternalNameServiceCreator(r.namespace))
 	}
 
 	if err := reconciling.ReconcileServices(ctx, creatorsKubeSystem, metav1.NamespaceSystem, r.Client); err != nil {
@@ -352,15 +410,16 @@ func (r *reconciler) reconcileServices(ctx context.Context) error {
 
 func (r *reconciler) reconcileConfigMaps(ctx context.Context) error {
 	creators := []reconciling.NamedConfigMapCreatorGetter{
-		machinecontroller.ClusterInfoConfigMapCreator(r.clusterURL.String(), r.caCert.Cert),
+		machinecontroller.ClusterInfoConfigMapCreator(r.clusterURL.String(), resources.EncodeCABundlePEM(r.caCert)),
 	}
 
 	if err := reconciling.ReconcileConfigMaps(ctx, creators, metav1.NamespacePublic, r.Client); err != nil {
 		return fmt.Errorf("failed to reconcile ConfigMaps in kube-public namespace: %v", err)
 	}
 
-	creators = []reconciling.NamedConfigMapCreatorGetter{
-		openvpn.ClientConfigConfigMapCreator(r.clusterURL.Hostname(), r.openvpnServerPort),
+	creators = []reconciling.NamedConfigMapCreatorGetter{}
+	if r.openvpnServerPort != 0 {
+		creators = append(creators, openvpn.ClientConfigConfigMapCreator(r.clusterURL.Hostname(), r.openvpnServerPort))
 	}
 	if r.openshift {
 		creators = append(creators, openshift.ControlplaneConfigCreator(r.platform))
@@ -374,9 +433,17 @@ func (r *reconciler) reconcileConfigMaps(ctx context.Context) error {
 
 func (r *reconciler) reconcileSecrets(ctx context.Context) error {
 	creators := []reconciling.NamedSecretCreatorGetter{
-		openvpn.ClientCertificate(r.openVPNCA),
 		usersshkeys.CreateUserSSHKeysSecrets(r.userSSHKeys),
 	}
+	if r.openvpnServerPort != 0 {
+		creators = append(creators, openvpn.ClientCertificate(r.openVPNCA))
+	}
+	if r.konnectivityServerPort != 0 {
+		creators = append(creators, konnectivity.AgentCertificateCreator(r.konnectivityCA))
+	}
+	if r.metricsServerInCluster() {
+		creators = append(creators, seedmetricsserver.TLSServingCertSecretCreator(func() (*triple.KeyPair, error) { return r.caCert, nil }))
+	}
 	if r.openshift {
 		if r.cloudCredentialSecretTemplate != nil {
 			creators = append(creators, openshift.CloudCredentialSecretCreator(*r.cloudCredentialSecretTemplate))
@@ -415,6 +482,9 @@ func (r *reconciler) reconcileNamespaces(ctx context.Context) error {
 		creators := []reconciling.NamedNamespaceCreatorGetter{
 			kubernetesdashboard.NamespaceCreator,
 		}
+		if r.opaIntegration {
+			creators = append(creators, gatekeeper.NamespaceCreator)
+		}
 		if err := reconciling.ReconcileNamespaces(ctx, creators, "", r.Client); err != nil {
 			return fmt.Errorf("failed to reconcile namespaces: %v", err)
 		}
@@ -451,11 +521,14 @@ func (r *reconciler) reconcileUnstructured(ctx context.Context) error {
 	// for unstructured.Unstructured: https://github.com/kubernetes-sigs/controller-runtime/issues/615
 	// Since using the API is very expensive as we get triggered by almost anything, we construct our
 	// own client that uses the cache as reader.
-	client := ctrlruntimeclientClient{
+	var client ctrlruntimeclient.Client = ctrlruntimeclientClient{
 		Reader:       r.cache,
 		Writer:       r.Client,
 		StatusClient: r.Client,
 	}
+	if dryRunClient, ok := r.Client.(*reconciling.DryRunClient); ok {
+		client = dryRunClient.WithReader(r.cache)
+	}
 	if err := reconciling.ReconcileUnstructureds(ctx, creators, "", client); err != nil {
 		return fmt.Errorf("failed to reconcile unstructureds: %v", err)
 	}
@@ -471,6 +544,17 @@ type ctrlruntimeclientClient struct {
 }
 
 func (r *reconciler) reconcileDeployments(ctx context.Context) error {
+	creators := []reconciling.NamedDeploymentCreatorGetter{}
+	if r.konnectivityServerPort != 0 {
+		creators = append(creators, konnectivity.DeploymentCreator(r.clusterURL.Hostname(), r.konnectivityServerPort))
+	}
+	if r.metricsServerInCluster() {
+		creators = append(creators, metricsserver.DeploymentCreator())
+	}
+	if err := reconciling.ReconcileDeployments(ctx, creators, metav1.NamespaceSystem, r.Client); err != nil {
+		return fmt.Errorf("failed to reconcile Deployments in kube-system namespace: %v", err)
+	}
+
 	if !r.openshift {
 		// Kubernetes Dashboard and related resources
 		creators := []reconciling.NamedDeploymentCreatorGetter{
@@ -484,3 +568,34 @@ func (r *reconciler) reconcileDeployments(ctx context.Context) error {
 
 	return nil
 }
+
+// deleteUnusedTunnelingResources removes the resources of the tunneling modes which are not deployed
+// for the cluster anymore
+func (r *reconciler) deleteUnusedTunnelingResources(ctx context.Context) error {
+	var unused []runtime.Object
+	if r.openvpnServerPort == 0 {
+		unused = append(unused,
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: resources.OpenVPNClientConfigConfigMapName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: resources.OpenVPNClientCertificatesSecretName}},
+		)
+	}
+	if r.konnectivityServerPort == 0 {
+		unused = append(unused,
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: resources.KonnectivityAgentDeploymentName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: resources.KonnectivityAgentCertificatesSecretName}},
+		)
+	}
+	if !r.metricsServerInCluster() {
+		unused = append(unused,
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: resources.MetricsServerDeploymentName}},
+			&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceSystem, Name: seedmetricsserver.ServingCertSecretName}},
+		)
+	}
+
+	for _, obj := range unused {
+		if err := r.Delete(ctx, obj); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %T: %v", obj, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package gatekeeper
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+)
+
+const (
+	constraintTemplateCRDName = "constrainttemplates.templates.gatekeeper.sh"
+	configCRDName             = "configs.config.gatekeeper.sh"
+)
+
+// ConstraintTemplateCRDCreator returns the CRD of the Gatekeeper ConstraintTemplates
+func ConstraintTemplateCRDCreator() reconciling.NamedCustomResourceDefinitionCreatorGetter {
+	return func() (string, reconciling.CustomResourceDefinitionCreator) {
+		return constraintTemplateCRDName, func(crd *apiextensionsv1beta1.CustomResourceDefinition) (*apiextensionsv1beta1.CustomResourceDefinition, error) {
+			crd.Spec.Group = "templates.gatekeeper.sh"
+			crd.Spec.Version = "v1beta1"
+			crd.Spec.Versions = []apiextensionsv1beta1.CustomResourceDefinitionVersion{
+				{Name: "v1beta1", Served: true, Storage: true},
+			}
+			crd.Spec.Scope = apiextensionsv1beta1.ClusterScoped
+			crd.Spec.Names.Kind = "ConstraintTemplate"
+			crd.Spec.Names.ListKind = "ConstraintTemplateList"
+			crd.Spec.Names.Plural = "constrainttemplates"
+			crd.Spec.Names.Singular = "constrainttemplate"
+			crd.Spec.Subresources = &apiextensionsv1beta1.CustomResourceSubresources{
+				Status: &apiextensionsv1beta1.CustomResourceSubresourceStatus{},
+			}
+
+			return crd, nil
+		}
+	}
+}
+
+// ConfigCRDCreator returns the CRD of the Gatekeeper config
+func ConfigCRDCreator() reconciling.NamedCustomResourceDefinitionCreatorGetter {
+	return func() (string, reconciling.CustomResourceDefinitionCreator) {
+		return configCRDName, func(crd *apiextensionsv1beta1.CustomResourceDefinition) (*apiextensionsv1beta1.CustomResourceDefinition, error) {
+			crd.Spec.Group = "config.gatekeeper.sh"
+			crd.Spec.Version = "v1alpha1"
+			crd.Spec.Versions = []apiextensionsv1beta1.CustomResourceDefinitionVersion{
+				{Name: "v1alpha1", Served: true, Storage: true},
+			}
+			crd.Spec.Scope = apiextensionsv1beta1.NamespaceScoped
+			crd.Spec.Names.Kind = "Config"
+			crd.Spec.Names.ListKind = "ConfigList"
+			crd.Spec.Names.Plural = "configs"
+			crd.Spec.Names.Singular = "config"
+
+			return crd, nil
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package gatekeeper
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NamespaceCreator creates the namespace which holds the Gatekeeper config
+func NamespaceCreator() (string, reconciling.NamespaceCreator) {
+	return gatekeeper.Namespace, func(ns *corev1.Namespace) (*corev1.Namespace, error) {
+		if ns.Labels == nil {
+			ns.Labels = map[string]string{}
+		}
+		ns.Labels[ignoreLabelKey] = ignoreLabelValue
+		return ns, nil
+	}
+}
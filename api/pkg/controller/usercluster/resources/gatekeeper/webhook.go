@@ -0,0 +1,64 @@
+package gatekeeper
+
+import (
+	"fmt"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Namespaces with this label are not validated by Gatekeeper
+	ignoreLabelKey   = "admission.gatekeeper.sh/ignore"
+	ignoreLabelValue = "no-self-managing"
+)
+
+// ValidatingWebhookConfigurationCreator returns the ValidatingWebhookConfiguration for Gatekeeper, which
+// runs in the given namespace of the seed cluster
+func ValidatingWebhookConfigurationCreator(caBundle []byte, namespace string) reconciling.NamedValidatingWebhookConfigurationCreatorGetter {
+	return func() (string, reconciling.ValidatingWebhookConfigurationCreator) {
+		return resources.GatekeeperValidatingWebhookConfigurationName, func(validatingWebhookConfiguration *admissionregistrationv1beta1.ValidatingWebhookConfiguration) (*admissionregistrationv1beta1.ValidatingWebhookConfiguration, error) {
+			// Requests must not fail while Gatekeeper is unavailable, e.g. while the cluster is hibernated or the
+			// single Gatekeeper replica restarts. The rules match cluster-scoped resources too, which no namespace
+			// selector exempts, so failing closed would block the node registration and the reconciling of the
+			// cluster. The audit still reports the violations created in that time.
+			failurePolicy := admissionregistrationv1beta1.Ignore
+			sideEffects := admissionregistrationv1beta1.SideEffectClassNone
+			url := fmt.Sprintf("https://%s.%s.svc.cluster.local.%s", resources.GatekeeperWebhookServiceName, namespace, gatekeeper.WebhookPath)
+
+			if len(validatingWebhookConfiguration.Webhooks) != 1 {
+				validatingWebhookConfiguration.Webhooks = []admissionregistrationv1beta1.Webhook{{}}
+			}
+
+			validatingWebhookConfiguration.Webhooks[0].Name = "validation.gatekeeper.sh"
+			validatingWebhookConfiguration.Webhooks[0].NamespaceSelector = &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      ignoreLabelKey,
+						Operator: metav1.LabelSelectorOpDoesNotExist,
+					},
+				},
+			}
+			validatingWebhookConfiguration.Webhooks[0].FailurePolicy = &failurePolicy
+			validatingWebhookConfiguration.Webhooks[0].SideEffects = &sideEffects
+			validatingWebhookConfiguration.Webhooks[0].Rules = []admissionregistrationv1beta1.RuleWithOperations{{
+				Operations: []admissionregistrationv1beta1.OperationType{admissionregistrationv1beta1.Create, admissionregistrationv1beta1.Update},
+				Rule: admissionregistrationv1beta1.Rule{
+					APIGroups:   []string{"*"},
+					APIVersions: []string{"*"},
+					Resources:   []string{"*"},
+				},
+			}}
+			validatingWebhookConfiguration.Webhooks[0].ClientConfig = admissionregistrationv1beta1.WebhookClientConfig{
+				URL:      &url,
+				CABundle: caBundle,
+			}
+
+			return validatingWebhookConfiguration, nil
+		}
+	}
+}
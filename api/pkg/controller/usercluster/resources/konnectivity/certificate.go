@@ -0,0 +1,22 @@
+package konnectivity
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+)
+
+// AgentCertificateCreator returns a function to create/update the secret with the client certificate
+// the konnectivity agent uses to connect to the konnectivity server
+func AgentCertificateCreator(ca *resources.ECDSAKeyPair) reconciling.NamedSecretCreatorGetter {
+	return func() (string, reconciling.SecretCreator) {
+		return resources.KonnectivityAgentCertificatesSecretName,
+			certificates.GetECDSAClientCertificateCreator(
+				resources.KonnectivityAgentCertificatesSecretName,
+				Name,
+				[]string{},
+				resources.KonnectivityClientCertSecretKey,
+				resources.KonnectivityClientKeySecretKey,
+				func() (*resources.ECDSAKeyPair, error) { return ca, nil })
+	}
+}
@@ -0,0 +1,96 @@
+package konnectivity
+
+import (
+	"fmt"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	konnectivityresources "github.com/kubermatic/kubermatic/api/pkg/resources/konnectivity"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	defaultResourceRequirements = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("16Mi"),
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+		},
+	}
+)
+
+const (
+	Name = resources.KonnectivityAgentDeploymentName
+
+	certsMountDir = "/etc/konnectivity/pki"
+)
+
+// DeploymentCreator returns the function to create and update the konnectivity agent deployment. The agents
+// connect to the konnectivity server in the seed cluster and forward the traffic of the apiserver into the cluster.
+func DeploymentCreator(serverHost string, serverPort int) reconciling.NamedDeploymentCreatorGetter {
+	return func() (string, reconciling.DeploymentCreator) {
+		return Name, func(dep *appsv1.Deployment) (*appsv1.Deployment, error) {
+			dep.Name = Name
+			dep.Labels = resources.BaseAppLabel(Name, nil)
+
+			dep.Spec.Replicas = resources.Int32(2)
+			dep.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: resources.BaseAppLabel(Name, nil),
+			}
+			dep.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+				Labels: resources.BaseAppLabel(Name, nil),
+			}
+
+			dep.Spec.Template.Spec.PriorityClassName = "system-cluster-critical"
+			dep.Spec.Template.Spec.Tolerations = []corev1.Toleration{
+				{
+					Key:      "CriticalAddonsOnly",
+					Operator: corev1.TolerationOpExists,
+				},
+			}
+			dep.Spec.Template.Spec.Volumes = []corev1.Volume{
+				{
+					Name: resources.KonnectivityAgentCertificatesSecretName,
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: resources.KonnectivityAgentCertificatesSecretName,
+						},
+					},
+				},
+			}
+			dep.Spec.Template.Spec.Containers = []corev1.Container{
+				{
+					Name:            Name,
+					Image:           resources.RegistryK8SArtifacts + "/k8s-artifacts-prod/kas-network-proxy/proxy-agent:" + konnectivityresources.Version,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/proxy-agent"},
+					Args: []string{
+						"--logtostderr=true",
+						fmt.Sprintf("--ca-cert=%s/%s", certsMountDir, resources.CACertSecretKey),
+						fmt.Sprintf("--agent-cert=%s/%s", certsMountDir, resources.KonnectivityClientCertSecretKey),
+						fmt.Sprintf("--agent-key=%s/%s", certsMountDir, resources.KonnectivityClientKeySecretKey),
+						fmt.Sprintf("--proxy-server-host=%s", serverHost),
+						fmt.Sprintf("--proxy-server-port=%d", serverPort),
+					},
+					Resources: defaultResourceRequirements,
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      resources.KonnectivityAgentCertificatesSecretName,
+							MountPath: certsMountDir,
+							ReadOnly:  true,
+						},
+					},
+				},
+			}
+
+			return dep, nil
+		}
+	}
+}
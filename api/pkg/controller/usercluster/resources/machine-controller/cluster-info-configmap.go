@@ -1,7 +1,6 @@
 package machinecontroller
 
 import (
-	"crypto/x509"
 	"fmt"
 
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
@@ -10,11 +9,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
-	certutil "k8s.io/client-go/util/cert"
 )
 
 // ClusterInfoConfigMapCreator returns the func to create/update the ConfigMap
-func ClusterInfoConfigMapCreator(url string, caCert *x509.Certificate) reconciling.NamedConfigMapCreatorGetter {
+func ClusterInfoConfigMapCreator(url string, caBundle []byte) reconciling.NamedConfigMapCreatorGetter {
 	return func() (string, reconciling.ConfigMapCreator) {
 		return resources.ClusterInfoConfigMapName, func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
 			if cm.Data == nil {
@@ -27,7 +25,7 @@ func ClusterInfoConfigMapCreator(url string, caCert *x509.Certificate) reconcili
 			kubeconfig.Clusters = map[string]*clientcmdapi.Cluster{
 				"": {
 					Server:                   url,
-					CertificateAuthorityData: certutil.EncodeCertPEM(caCert),
+					CertificateAuthorityData: caBundle,
 				},
 			}
 
@@ -1,7 +1,6 @@
 package machinecontroller
 
 import (
-	"crypto/x509"
 	"fmt"
 
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
@@ -9,11 +8,10 @@ import (
 
 	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	certutil "k8s.io/client-go/util/cert"
 )
 
 // MutatingwebhookConfigurationCreator returns the MutatingwebhookConfiguration for the machine controler
-func MutatingwebhookConfigurationCreator(caCert *x509.Certificate, namespace string) reconciling.NamedMutatingWebhookConfigurationCreatorGetter {
+func MutatingwebhookConfigurationCreator(caBundle []byte, namespace string) reconciling.NamedMutatingWebhookConfigurationCreatorGetter {
 	return func() (string, reconciling.MutatingWebhookConfigurationCreator) {
 		return resources.MachineControllerMutatingWebhookConfigurationName, func(mutatingWebhookConfiguration *admissionregistrationv1beta1.MutatingWebhookConfiguration) (*admissionregistrationv1beta1.MutatingWebhookConfiguration, error) {
 			failurePolicy := admissionregistrationv1beta1.Fail
@@ -37,7 +35,7 @@ func MutatingwebhookConfigurationCreator(caCert *x509.Certificate, namespace str
 			}}
 			mutatingWebhookConfiguration.Webhooks[0].ClientConfig = admissionregistrationv1beta1.WebhookClientConfig{
 				URL:      &mdURL,
-				CABundle: certutil.EncodeCertPEM(caCert),
+				CABundle: caBundle,
 			}
 
 			mutatingWebhookConfiguration.Webhooks[1].Name = fmt.Sprintf("%s-machines", resources.MachineControllerMutatingWebhookConfigurationName)
@@ -53,7 +51,7 @@ func MutatingwebhookConfigurationCreator(caCert *x509.Certificate, namespace str
 			}}
 			mutatingWebhookConfiguration.Webhooks[1].ClientConfig = admissionregistrationv1beta1.WebhookClientConfig{
 				URL:      &mURL,
-				CABundle: certutil.EncodeCertPEM(caCert),
+				CABundle: caBundle,
 			}
 
 			return mutatingWebhookConfiguration, nil
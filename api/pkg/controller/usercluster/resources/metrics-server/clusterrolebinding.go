@@ -5,6 +5,7 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // ClusterRoleBindingResourceReaderCreator returns the ClusterRoleBinding required for the metrics server to read all required resources
@@ -24,6 +25,11 @@ func ClusterRoleBindingResourceReaderCreator() reconciling.NamedClusterRoleBindi
 					Name:     resources.MetricsServerCertUsername,
 					APIGroup: rbacv1.GroupName,
 				},
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      resources.MetricsServerServiceAccountName,
+					Namespace: metav1.NamespaceSystem,
+				},
 			}
 			return crb, nil
 		}
@@ -33,5 +39,19 @@ func ClusterRoleBindingResourceReaderCreator() reconciling.NamedClusterRoleBindi
 
 // ClusterRoleBindingAuthDelegatorCreator returns the ClusterRoleBinding required for the metrics server to create token review requests
 func ClusterRoleBindingAuthDelegatorCreator() reconciling.NamedClusterRoleBindingCreatorGetter {
-	return resources.ClusterRoleBindingAuthDelegatorCreator(resources.MetricsServerCertUsername)
+	return func() (string, reconciling.ClusterRoleBindingCreator) {
+		name, creator := resources.ClusterRoleBindingAuthDelegatorCreator(resources.MetricsServerCertUsername)()
+		return name, func(crb *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error) {
+			crb, err := creator(crb)
+			if err != nil {
+				return nil, err
+			}
+			crb.Subjects = append(crb.Subjects, rbacv1.Subject{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      resources.MetricsServerServiceAccountName,
+				Namespace: metav1.NamespaceSystem,
+			})
+			return crb, nil
+		}
+	}
 }
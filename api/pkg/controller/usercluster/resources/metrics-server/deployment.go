@@ -0,0 +1,87 @@
+package metricsserver
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	seedmetricsserver "github.com/kubermatic/kubermatic/api/pkg/resources/metrics-server"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	defaultResourceRequirements = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+			corev1.ResourceCPU:    resource.MustParse("25m"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+			corev1.ResourceCPU:    resource.MustParse("150m"),
+		},
+	}
+)
+
+const servingCertMountFolder = "/etc/serving-cert"
+
+// DeploymentCreator returns the function to create and update the metrics-server deployment inside the
+// user cluster. It is used instead of the one in the seed cluster when the control plane has no VPN
+// into the cluster to reach the kubelets.
+func DeploymentCreator() reconciling.NamedDeploymentCreatorGetter {
+	return func() (string, reconciling.DeploymentCreator) {
+		return resources.MetricsServerDeploymentName, func(dep *appsv1.Deployment) (*appsv1.Deployment, error) {
+			dep.Name = resources.MetricsServerDeploymentName
+			dep.Labels = resources.BaseAppLabel(Name, nil)
+
+			dep.Spec.Replicas = resources.Int32(2)
+			dep.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: resources.BaseAppLabel(Name, nil),
+			}
+			dep.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+				Labels: resources.BaseAppLabel(Name, nil),
+			}
+
+			dep.Spec.Template.Spec.ServiceAccountName = resources.MetricsServerServiceAccountName
+			dep.Spec.Template.Spec.PriorityClassName = "system-cluster-critical"
+			dep.Spec.Template.Spec.Volumes = []corev1.Volume{
+				{
+					Name: seedmetricsserver.ServingCertSecretName,
+					VolumeSource: corev1.VolumeSource{
+						Secret: &corev1.SecretVolumeSource{
+							SecretName: seedmetricsserver.ServingCertSecretName,
+						},
+					},
+				},
+			}
+			dep.Spec.Template.Spec.Containers = []corev1.Container{
+				{
+					Name:            Name,
+					Image:           resources.RegistryGCR + "/google_containers/metrics-server-amd64:" + seedmetricsserver.Tag,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+					Command:         []string{"/metrics-server"},
+					Args: []string{
+						"--kubelet-port", "10250",
+						"--kubelet-insecure-tls",
+						"--kubelet-preferred-address-types", "InternalIP,ExternalIP",
+						"--v", "1",
+						"--logtostderr",
+						"--tls-cert-file", servingCertMountFolder + "/" + resources.ServingCertSecretKey,
+						"--tls-private-key-file", servingCertMountFolder + "/" + resources.ServingCertKeySecretKey,
+					},
+					Resources: defaultResourceRequirements,
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      seedmetricsserver.ServingCertSecretName,
+							MountPath: servingCertMountFolder,
+							ReadOnly:  true,
+						},
+					},
+				},
+			}
+
+			return dep, nil
+		}
+	}
+}
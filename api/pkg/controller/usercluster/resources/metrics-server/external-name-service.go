@@ -18,6 +18,10 @@ func ExternalNameServiceCreator(namespace string) reconciling.NamedServiceCreato
 			se.Labels = resources.BaseAppLabel(Name, nil)
 
 			se.Spec.Type = corev1.ServiceTypeExternalName
+			// Set when the service was used for the metrics-server inside the user cluster before
+			se.Spec.ClusterIP = ""
+			se.Spec.Selector = nil
+			se.Spec.Ports = nil
 			se.Spec.ExternalName = fmt.Sprintf("%s.%s.svc.cluster.local", resources.MetricsServerServiceName, namespace)
 
 			return se, nil
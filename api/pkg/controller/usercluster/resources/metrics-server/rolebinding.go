@@ -5,6 +5,7 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
 
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // RolebindingAuthReaderCreator returns a func to create/update the RoleBinding used by the metrics-server to get access to the token subject review API
@@ -24,6 +25,11 @@ func RolebindingAuthReaderCreator() reconciling.NamedRoleBindingCreatorGetter {
 					Name:     resources.MetricsServerCertUsername,
 					APIGroup: rbacv1.GroupName,
 				},
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      resources.MetricsServerServiceAccountName,
+					Namespace: metav1.NamespaceSystem,
+				},
 			}
 			return rb, nil
 		}
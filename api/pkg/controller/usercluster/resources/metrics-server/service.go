@@ -0,0 +1,35 @@
+package metricsserver
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ServiceCreator returns the function to reconcile the metrics-server service when the metrics-server runs
+// inside the user cluster. It replaces the ExternalName service pointing to the seed cluster.
+func ServiceCreator() reconciling.NamedServiceCreatorGetter {
+	return func() (string, reconciling.ServiceCreator) {
+		return resources.MetricsServerExternalNameServiceName, func(se *corev1.Service) (*corev1.Service, error) {
+			se.Namespace = metav1.NamespaceSystem
+			labels := resources.BaseAppLabel(Name, nil)
+			se.Labels = labels
+
+			se.Spec.Type = corev1.ServiceTypeClusterIP
+			se.Spec.ExternalName = ""
+			se.Spec.Selector = labels
+			se.Spec.Ports = []corev1.ServicePort{
+				{
+					Port:       443,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromInt(443),
+				},
+			}
+
+			return se, nil
+		}
+	}
+}
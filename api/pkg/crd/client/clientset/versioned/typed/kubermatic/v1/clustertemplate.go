@@ -0,0 +1,148 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"time"
+
+	scheme "github.com/kubermatic/kubermatic/api/pkg/crd/client/clientset/versioned/scheme"
+	v1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ClusterTemplatesGetter has a method to return a ClusterTemplateInterface.
+// A group's client should implement this interface.
+type ClusterTemplatesGetter interface {
+	ClusterTemplates() ClusterTemplateInterface
+}
+
+// ClusterTemplateInterface has methods to work with ClusterTemplate resources.
+type ClusterTemplateInterface interface {
+	Create(*v1.ClusterTemplate) (*v1.ClusterTemplate, error)
+	Update(*v1.ClusterTemplate) (*v1.ClusterTemplate, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
+	Get(name string, options metav1.GetOptions) (*v1.ClusterTemplate, error)
+	List(opts metav1.ListOptions) (*v1.ClusterTemplateList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.ClusterTemplate, err error)
+	ClusterTemplateExpansion
+}
+
+// clusterTemplates implements ClusterTemplateInterface
+type clusterTemplates struct {
+	client rest.Interface
+}
+
+// newClusterTemplates returns a ClusterTemplates
+func newClusterTemplates(c *KubermaticV1Client) *clusterTemplates {
+	return &clusterTemplates{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the clusterTemplate, and returns the corresponding clusterTemplate object, and an error if there is any.
+func (c *clusterTemplates) Get(name string, options metav1.GetOptions) (result *v1.ClusterTemplate, err error) {
+	result = &v1.ClusterTemplate{}
+	err = c.client.Get().
+		Resource("clustertemplates").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ClusterTemplates that match those selectors.
+func (c *clusterTemplates) List(opts metav1.ListOptions) (result *v1.ClusterTemplateList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.ClusterTemplateList{}
+	err = c.client.Get().
+		Resource("clustertemplates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested clusterTemplates.
+func (c *clusterTemplates) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("clustertemplates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a clusterTemplate and creates it.  Returns the server's representation of the clusterTemplate, and an error, if there is any.
+func (c *clusterTemplates) Create(clusterTemplate *v1.ClusterTemplate) (result *v1.ClusterTemplate, err error) {
+	result = &v1.ClusterTemplate{}
+	err = c.client.Post().
+		Resource("clustertemplates").
+		Body(clusterTemplate).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a clusterTemplate and updates it. Returns the server's representation of the clusterTemplate, and an error, if there is any.
+func (c *clusterTemplates) Update(clusterTemplate *v1.ClusterTemplate) (result *v1.ClusterTemplate, err error) {
+	result = &v1.ClusterTemplate{}
+	err = c.client.Put().
+		Resource("clustertemplates").
+		Name(clusterTemplate.Name).
+		Body(clusterTemplate).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the clusterTemplate and deletes it. Returns an error if one occurs.
+func (c *clusterTemplates) Delete(name string, options *metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("clustertemplates").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *clusterTemplates) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("clustertemplates").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched clusterTemplate.
+func (c *clusterTemplates) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.ClusterTemplate, err error) {
+	result = &v1.ClusterTemplate{}
+	err = c.client.Patch(pt).
+		Resource("clustertemplates").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,148 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"time"
+
+	scheme "github.com/kubermatic/kubermatic/api/pkg/crd/client/clientset/versioned/scheme"
+	v1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ConstraintsGetter has a method to return a ConstraintInterface.
+// A group's client should implement this interface.
+type ConstraintsGetter interface {
+	Constraints() ConstraintInterface
+}
+
+// ConstraintInterface has methods to work with Constraint resources.
+type ConstraintInterface interface {
+	Create(*v1.Constraint) (*v1.Constraint, error)
+	Update(*v1.Constraint) (*v1.Constraint, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
+	Get(name string, options metav1.GetOptions) (*v1.Constraint, error)
+	List(opts metav1.ListOptions) (*v1.ConstraintList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.Constraint, err error)
+	ConstraintExpansion
+}
+
+// constraints implements ConstraintInterface
+type constraints struct {
+	client rest.Interface
+}
+
+// newConstraints returns a Constraints
+func newConstraints(c *KubermaticV1Client) *constraints {
+	return &constraints{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the constraint, and returns the corresponding constraint object, and an error if there is any.
+func (c *constraints) Get(name string, options metav1.GetOptions) (result *v1.Constraint, err error) {
+	result = &v1.Constraint{}
+	err = c.client.Get().
+		Resource("constraints").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of Constraints that match those selectors.
+func (c *constraints) List(opts metav1.ListOptions) (result *v1.ConstraintList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.ConstraintList{}
+	err = c.client.Get().
+		Resource("constraints").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested constraints.
+func (c *constraints) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("constraints").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a constraint and creates it.  Returns the server's representation of the constraint, and an error, if there is any.
+func (c *constraints) Create(constraint *v1.Constraint) (result *v1.Constraint, err error) {
+	result = &v1.Constraint{}
+	err = c.client.Post().
+		Resource("constraints").
+		Body(constraint).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a constraint and updates it. Returns the server's representation of the constraint, and an error, if there is any.
+func (c *constraints) Update(constraint *v1.Constraint) (result *v1.Constraint, err error) {
+	result = &v1.Constraint{}
+	err = c.client.Put().
+		Resource("constraints").
+		Name(constraint.Name).
+		Body(constraint).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the constraint and deletes it. Returns an error if one occurs.
+func (c *constraints) Delete(name string, options *metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("constraints").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *constraints) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("constraints").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched constraint.
+func (c *constraints) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.Constraint, err error) {
+	result = &v1.Constraint{}
+	err = c.client.Patch(pt).
+		Resource("constraints").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
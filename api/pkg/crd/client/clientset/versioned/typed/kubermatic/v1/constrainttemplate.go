@@ -0,0 +1,148 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"time"
+
+	scheme "github.com/kubermatic/kubermatic/api/pkg/crd/client/clientset/versioned/scheme"
+	v1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ConstraintTemplatesGetter has a method to return a ConstraintTemplateInterface.
+// A group's client should implement this interface.
+type ConstraintTemplatesGetter interface {
+	ConstraintTemplates() ConstraintTemplateInterface
+}
+
+// ConstraintTemplateInterface has methods to work with ConstraintTemplate resources.
+type ConstraintTemplateInterface interface {
+	Create(*v1.ConstraintTemplate) (*v1.ConstraintTemplate, error)
+	Update(*v1.ConstraintTemplate) (*v1.ConstraintTemplate, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
+	Get(name string, options metav1.GetOptions) (*v1.ConstraintTemplate, error)
+	List(opts metav1.ListOptions) (*v1.ConstraintTemplateList, error)
+	Watch(opts metav1.ListOptions) (watch.Interface, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.ConstraintTemplate, err error)
+	ConstraintTemplateExpansion
+}
+
+// constraintTemplates implements ConstraintTemplateInterface
+type constraintTemplates struct {
+	client rest.Interface
+}
+
+// newConstraintTemplates returns a ConstraintTemplates
+func newConstraintTemplates(c *KubermaticV1Client) *constraintTemplates {
+	return &constraintTemplates{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the constraintTemplate, and returns the corresponding constraintTemplate object, and an error if there is any.
+func (c *constraintTemplates) Get(name string, options metav1.GetOptions) (result *v1.ConstraintTemplate, err error) {
+	result = &v1.ConstraintTemplate{}
+	err = c.client.Get().
+		Resource("constrainttemplates").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do().
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ConstraintTemplates that match those selectors.
+func (c *constraintTemplates) List(opts metav1.ListOptions) (result *v1.ConstraintTemplateList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.ConstraintTemplateList{}
+	err = c.client.Get().
+		Resource("constrainttemplates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do().
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested constraintTemplates.
+func (c *constraintTemplates) Watch(opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("constrainttemplates").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch()
+}
+
+// Create takes the representation of a constraintTemplate and creates it.  Returns the server's representation of the constraintTemplate, and an error, if there is any.
+func (c *constraintTemplates) Create(constraintTemplate *v1.ConstraintTemplate) (result *v1.ConstraintTemplate, err error) {
+	result = &v1.ConstraintTemplate{}
+	err = c.client.Post().
+		Resource("constrainttemplates").
+		Body(constraintTemplate).
+		Do().
+		Into(result)
+	return
+}
+
+// Update takes the representation of a constraintTemplate and updates it. Returns the server's representation of the constraintTemplate, and an error, if there is any.
+func (c *constraintTemplates) Update(constraintTemplate *v1.ConstraintTemplate) (result *v1.ConstraintTemplate, err error) {
+	result = &v1.ConstraintTemplate{}
+	err = c.client.Put().
+		Resource("constrainttemplates").
+		Name(constraintTemplate.Name).
+		Body(constraintTemplate).
+		Do().
+		Into(result)
+	return
+}
+
+// Delete takes name of the constraintTemplate and deletes it. Returns an error if one occurs.
+func (c *constraintTemplates) Delete(name string, options *metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("constrainttemplates").
+		Name(name).
+		Body(options).
+		Do().
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *constraintTemplates) DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOptions.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOptions.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("constrainttemplates").
+		VersionedParams(&listOptions, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(options).
+		Do().
+		Error()
+}
+
+// Patch applies the patch and returns the patched constraintTemplate.
+func (c *constraintTemplates) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *v1.ConstraintTemplate, err error) {
+	result = &v1.ConstraintTemplate{}
+	err = c.client.Patch(pt).
+		Resource("constrainttemplates").
+		SubResource(subresources...).
+		Name(name).
+		Body(data).
+		Do().
+		Into(result)
+	return
+}
@@ -0,0 +1,124 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeClusterMigrations implements ClusterMigrationInterface
+type FakeClusterMigrations struct {
+	Fake *FakeKubermaticV1
+	ns   string
+}
+
+var clustermigrationsResource = schema.GroupVersionResource{Group: "kubermatic.k8s.io", Version: "v1", Resource: "clustermigrations"}
+
+var clustermigrationsKind = schema.GroupVersionKind{Group: "kubermatic.k8s.io", Version: "v1", Kind: "ClusterMigration"}
+
+// Get takes name of the clusterMigration, and returns the corresponding clusterMigration object, and an error if there is any.
+func (c *FakeClusterMigrations) Get(name string, options v1.GetOptions) (result *kubermaticv1.ClusterMigration, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(clustermigrationsResource, c.ns, name), &kubermaticv1.ClusterMigration{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.ClusterMigration), err
+}
+
+// List takes label and field selectors, and returns the list of ClusterMigrations that match those selectors.
+func (c *FakeClusterMigrations) List(opts v1.ListOptions) (result *kubermaticv1.ClusterMigrationList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(clustermigrationsResource, clustermigrationsKind, c.ns, opts), &kubermaticv1.ClusterMigrationList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &kubermaticv1.ClusterMigrationList{ListMeta: obj.(*kubermaticv1.ClusterMigrationList).ListMeta}
+	for _, item := range obj.(*kubermaticv1.ClusterMigrationList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested clusterMigrations.
+func (c *FakeClusterMigrations) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(clustermigrationsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a clusterMigration and creates it.  Returns the server's representation of the clusterMigration, and an error, if there is any.
+func (c *FakeClusterMigrations) Create(clusterMigration *kubermaticv1.ClusterMigration) (result *kubermaticv1.ClusterMigration, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(clustermigrationsResource, c.ns, clusterMigration), &kubermaticv1.ClusterMigration{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.ClusterMigration), err
+}
+
+// Update takes the representation of a clusterMigration and updates it. Returns the server's representation of the clusterMigration, and an error, if there is any.
+func (c *FakeClusterMigrations) Update(clusterMigration *kubermaticv1.ClusterMigration) (result *kubermaticv1.ClusterMigration, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(clustermigrationsResource, c.ns, clusterMigration), &kubermaticv1.ClusterMigration{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.ClusterMigration), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeClusterMigrations) UpdateStatus(clusterMigration *kubermaticv1.ClusterMigration) (*kubermaticv1.ClusterMigration, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(clustermigrationsResource, "status", c.ns, clusterMigration), &kubermaticv1.ClusterMigration{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.ClusterMigration), err
+}
+
+// Delete takes name of the clusterMigration and deletes it. Returns an error if one occurs.
+func (c *FakeClusterMigrations) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(clustermigrationsResource, c.ns, name), &kubermaticv1.ClusterMigration{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeClusterMigrations) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(clustermigrationsResource, c.ns, listOptions)
+
+	_, err := c.Fake.Invokes(action, &kubermaticv1.ClusterMigrationList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched clusterMigration.
+func (c *FakeClusterMigrations) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *kubermaticv1.ClusterMigration, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(clustermigrationsResource, c.ns, name, pt, data, subresources...), &kubermaticv1.ClusterMigration{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.ClusterMigration), err
+}
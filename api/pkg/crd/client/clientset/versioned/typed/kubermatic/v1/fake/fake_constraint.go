@@ -0,0 +1,104 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeConstraints implements ConstraintInterface
+type FakeConstraints struct {
+	Fake *FakeKubermaticV1
+}
+
+var constraintsResource = schema.GroupVersionResource{Group: "kubermatic.k8s.io", Version: "v1", Resource: "constraints"}
+
+var constraintsKind = schema.GroupVersionKind{Group: "kubermatic.k8s.io", Version: "v1", Kind: "Constraint"}
+
+// Get takes name of the constraint, and returns the corresponding constraint object, and an error if there is any.
+func (c *FakeConstraints) Get(name string, options v1.GetOptions) (result *kubermaticv1.Constraint, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(constraintsResource, name), &kubermaticv1.Constraint{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.Constraint), err
+}
+
+// List takes label and field selectors, and returns the list of Constraints that match those selectors.
+func (c *FakeConstraints) List(opts v1.ListOptions) (result *kubermaticv1.ConstraintList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(constraintsResource, constraintsKind, opts), &kubermaticv1.ConstraintList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &kubermaticv1.ConstraintList{ListMeta: obj.(*kubermaticv1.ConstraintList).ListMeta}
+	for _, item := range obj.(*kubermaticv1.ConstraintList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested constraints.
+func (c *FakeConstraints) Watch(opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(constraintsResource, opts))
+}
+
+// Create takes the representation of a constraint and creates it.  Returns the server's representation of the constraint, and an error, if there is any.
+func (c *FakeConstraints) Create(constraint *kubermaticv1.Constraint) (result *kubermaticv1.Constraint, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(constraintsResource, constraint), &kubermaticv1.Constraint{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.Constraint), err
+}
+
+// Update takes the representation of a constraint and updates it. Returns the server's representation of the constraint, and an error, if there is any.
+func (c *FakeConstraints) Update(constraint *kubermaticv1.Constraint) (result *kubermaticv1.Constraint, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(constraintsResource, constraint), &kubermaticv1.Constraint{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.Constraint), err
+}
+
+// Delete takes name of the constraint and deletes it. Returns an error if one occurs.
+func (c *FakeConstraints) Delete(name string, options *v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(constraintsResource, name), &kubermaticv1.Constraint{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeConstraints) DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(constraintsResource, listOptions)
+
+	_, err := c.Fake.Invokes(action, &kubermaticv1.ConstraintList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched constraint.
+func (c *FakeConstraints) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (result *kubermaticv1.Constraint, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(constraintsResource, name, pt, data, subresources...), &kubermaticv1.Constraint{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kubermaticv1.Constraint), err
+}
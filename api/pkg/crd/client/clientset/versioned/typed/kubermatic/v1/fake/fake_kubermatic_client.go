@@ -20,6 +20,22 @@ func (c *FakeKubermaticV1) Clusters() v1.ClusterInterface {
 	return &FakeClusters{c}
 }
 
+func (c *FakeKubermaticV1) ClusterMigrations(namespace string) v1.ClusterMigrationInterface {
+	return &FakeClusterMigrations{c, namespace}
+}
+
+func (c *FakeKubermaticV1) ClusterTemplates() v1.ClusterTemplateInterface {
+	return &FakeClusterTemplates{c}
+}
+
+func (c *FakeKubermaticV1) Constraints() v1.ConstraintInterface {
+	return &FakeConstraints{c}
+}
+
+func (c *FakeKubermaticV1) ConstraintTemplates() v1.ConstraintTemplateInterface {
+	return &FakeConstraintTemplates{c}
+}
+
 func (c *FakeKubermaticV1) Projects() v1.ProjectInterface {
 	return &FakeProjects{c}
 }
@@ -6,6 +6,14 @@ type AddonExpansion interface{}
 
 type ClusterExpansion interface{}
 
+type ClusterMigrationExpansion interface{}
+
+type ClusterTemplateExpansion interface{}
+
+type ConstraintExpansion interface{}
+
+type ConstraintTemplateExpansion interface{}
+
 type ProjectExpansion interface{}
 
 type UserExpansion interface{}
@@ -13,6 +13,10 @@ type KubermaticV1Interface interface {
 	RESTClient() rest.Interface
 	AddonsGetter
 	ClustersGetter
+	ClusterMigrationsGetter
+	ClusterTemplatesGetter
+	ConstraintsGetter
+	ConstraintTemplatesGetter
 	ProjectsGetter
 	UsersGetter
 	UserProjectBindingsGetter
@@ -32,6 +36,22 @@ func (c *KubermaticV1Client) Clusters() ClusterInterface {
 	return newClusters(c)
 }
 
+func (c *KubermaticV1Client) ClusterMigrations(namespace string) ClusterMigrationInterface {
+	return newClusterMigrations(c, namespace)
+}
+
+func (c *KubermaticV1Client) ClusterTemplates() ClusterTemplateInterface {
+	return newClusterTemplates(c)
+}
+
+func (c *KubermaticV1Client) Constraints() ConstraintInterface {
+	return newConstraints(c)
+}
+
+func (c *KubermaticV1Client) ConstraintTemplates() ConstraintTemplateInterface {
+	return newConstraintTemplates(c)
+}
+
 func (c *KubermaticV1Client) Projects() ProjectInterface {
 	return newProjects(c)
 }
@@ -12,6 +12,14 @@ type Interface interface {
 	Addons() AddonInformer
 	// Clusters returns a ClusterInformer.
 	Clusters() ClusterInformer
+	// ClusterMigrations returns a ClusterMigrationInformer.
+	ClusterMigrations() ClusterMigrationInformer
+	// ClusterTemplates returns a ClusterTemplateInformer.
+	ClusterTemplates() ClusterTemplateInformer
+	// Constraints returns a ConstraintInformer.
+	Constraints() ConstraintInformer
+	// ConstraintTemplates returns a ConstraintTemplateInformer.
+	ConstraintTemplates() ConstraintTemplateInformer
 	// Projects returns a ProjectInformer.
 	Projects() ProjectInformer
 	// Users returns a UserInformer.
@@ -43,6 +51,26 @@ func (v *version) Clusters() ClusterInformer {
 	return &clusterInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// ClusterMigrations returns a ClusterMigrationInformer.
+func (v *version) ClusterMigrations() ClusterMigrationInformer {
+	return &clusterMigrationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ClusterTemplates returns a ClusterTemplateInformer.
+func (v *version) ClusterTemplates() ClusterTemplateInformer {
+	return &clusterTemplateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// Constraints returns a ConstraintInformer.
+func (v *version) Constraints() ConstraintInformer {
+	return &constraintInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// ConstraintTemplates returns a ConstraintTemplateInformer.
+func (v *version) ConstraintTemplates() ConstraintTemplateInformer {
+	return &constraintTemplateInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // Projects returns a ProjectInformer.
 func (v *version) Projects() ProjectInformer {
 	return &projectInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
@@ -0,0 +1,78 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ClusterMigrationLister helps list ClusterMigrations.
+type ClusterMigrationLister interface {
+	// List lists all ClusterMigrations in the indexer.
+	List(selector labels.Selector) (ret []*v1.ClusterMigration, err error)
+	// ClusterMigrations returns an object that can list and get ClusterMigrations.
+	ClusterMigrations(namespace string) ClusterMigrationNamespaceLister
+	ClusterMigrationListerExpansion
+}
+
+// clusterMigrationLister implements the ClusterMigrationLister interface.
+type clusterMigrationLister struct {
+	indexer cache.Indexer
+}
+
+// NewClusterMigrationLister returns a new ClusterMigrationLister.
+func NewClusterMigrationLister(indexer cache.Indexer) ClusterMigrationLister {
+	return &clusterMigrationLister{indexer: indexer}
+}
+
+// List lists all ClusterMigrations in the indexer.
+func (s *clusterMigrationLister) List(selector labels.Selector) (ret []*v1.ClusterMigration, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.ClusterMigration))
+	})
+	return ret, err
+}
+
+// ClusterMigrations returns an object that can list and get ClusterMigrations.
+func (s *clusterMigrationLister) ClusterMigrations(namespace string) ClusterMigrationNamespaceLister {
+	return clusterMigrationNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// ClusterMigrationNamespaceLister helps list and get ClusterMigrations.
+type ClusterMigrationNamespaceLister interface {
+	// List lists all ClusterMigrations in the indexer for a given namespace.
+	List(selector labels.Selector) (ret []*v1.ClusterMigration, err error)
+	// Get retrieves the ClusterMigration from the indexer for a given namespace and name.
+	Get(name string) (*v1.ClusterMigration, error)
+	ClusterMigrationNamespaceListerExpansion
+}
+
+// clusterMigrationNamespaceLister implements the ClusterMigrationNamespaceLister
+// interface.
+type clusterMigrationNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all ClusterMigrations in the indexer for a given namespace.
+func (s clusterMigrationNamespaceLister) List(selector labels.Selector) (ret []*v1.ClusterMigration, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.ClusterMigration))
+	})
+	return ret, err
+}
+
+// Get retrieves the ClusterMigration from the indexer for a given namespace and name.
+func (s clusterMigrationNamespaceLister) Get(name string) (*v1.ClusterMigration, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("clustermigration"), name)
+	}
+	return obj.(*v1.ClusterMigration), nil
+}
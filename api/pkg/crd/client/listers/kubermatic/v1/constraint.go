@@ -0,0 +1,49 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ConstraintLister helps list Constraints.
+type ConstraintLister interface {
+	// List lists all Constraints in the indexer.
+	List(selector labels.Selector) (ret []*v1.Constraint, err error)
+	// Get retrieves the Constraint from the index for a given name.
+	Get(name string) (*v1.Constraint, error)
+	ConstraintListerExpansion
+}
+
+// constraintLister implements the ConstraintLister interface.
+type constraintLister struct {
+	indexer cache.Indexer
+}
+
+// NewConstraintLister returns a new ConstraintLister.
+func NewConstraintLister(indexer cache.Indexer) ConstraintLister {
+	return &constraintLister{indexer: indexer}
+}
+
+// List lists all Constraints in the indexer.
+func (s *constraintLister) List(selector labels.Selector) (ret []*v1.Constraint, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.Constraint))
+	})
+	return ret, err
+}
+
+// Get retrieves the Constraint from the index for a given name.
+func (s *constraintLister) Get(name string) (*v1.Constraint, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("constraint"), name)
+	}
+	return obj.(*v1.Constraint), nil
+}
@@ -14,6 +14,26 @@ type AddonNamespaceListerExpansion interface{}
 // ClusterLister.
 type ClusterListerExpansion interface{}
 
+// ClusterMigrationListerExpansion allows custom methods to be added to
+// ClusterMigrationLister.
+type ClusterMigrationListerExpansion interface{}
+
+// ClusterMigrationNamespaceListerExpansion allows custom methods to be added to
+// ClusterMigrationNamespaceLister.
+type ClusterMigrationNamespaceListerExpansion interface{}
+
+// ClusterTemplateListerExpansion allows custom methods to be added to
+// ClusterTemplateLister.
+type ClusterTemplateListerExpansion interface{}
+
+// ConstraintListerExpansion allows custom methods to be added to
+// ConstraintLister.
+type ConstraintListerExpansion interface{}
+
+// ConstraintTemplateListerExpansion allows custom methods to be added to
+// ConstraintTemplateLister.
+type ConstraintTemplateListerExpansion interface{}
+
 // ProjectListerExpansion allows custom methods to be added to
 // ProjectLister.
 type ProjectListerExpansion interface{}
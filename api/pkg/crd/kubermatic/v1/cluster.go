@@ -25,6 +25,14 @@ const (
 	// enabled when this Annotation is set with any value
 	AnnotationNameClusterAutoscalerEnabled = "kubermatic.io/cluster-autoscaler-enabled"
 
+	// AnnotationNameRootCARotationRequested is the name of the annotation that requests a
+	// rotation of the root CA of the cluster. It gets removed once the rotation started.
+	AnnotationNameRootCARotationRequested = "kubermatic.io/root-ca-rotation-requested"
+
+	// AnnotationNameEncryptionKeyRotationRequested is the name of the annotation that requests a
+	// rotation of the key which encrypts the Secrets of the cluster. It gets removed once the rotation started.
+	AnnotationNameEncryptionKeyRotationRequested = "kubermatic.io/encryption-key-rotation-requested"
+
 	// CredentialPrefix is the prefix used for the secrets containing cloud provider crednentials.
 	CredentialPrefix = "credential"
 )
@@ -97,6 +105,29 @@ type ClusterSpec struct {
 	UsePodSecurityPolicyAdmissionPlugin bool `json:"usePodSecurityPolicyAdmissionPlugin,omitempty"`
 
 	AuditLogging *AuditLoggingSettings `json:"auditLogging,omitempty"`
+
+	// Hibernation allows to scale the cluster down to zero while it is not used
+	Hibernation *HibernationSettings `json:"hibernation,omitempty"`
+
+	// ClusterAutoscaler configures the cluster-autoscaler. The autoscaler is deployed if this
+	// is set or if the cluster has the cluster-autoscaler-enabled annotation.
+	ClusterAutoscaler *ClusterAutoscalerSettings `json:"clusterAutoscaler,omitempty"`
+
+	// EncryptionAtRest enables the encryption of the Secrets in the etcd of the cluster. It can't be
+	// disabled once it was enabled.
+	EncryptionAtRest *EncryptionAtRestSettings `json:"encryptionAtRest,omitempty"`
+
+	// AdmissionPlugins are enabled in addition to the admission plugins which are always enabled.
+	// The component flag policy of the datacenter can restrict them.
+	AdmissionPlugins []AdmissionPluginSettings `json:"admissionPlugins,omitempty"`
+
+	// ExtraFlags are passed to the control plane components. Only the flags which are allowed by
+	// the component flag policy of the datacenter can be set.
+	ExtraFlags *ComponentExtraFlags `json:"extraFlags,omitempty"`
+
+	// OPAIntegration deploys OPA Gatekeeper for the cluster and enforces the constraints of
+	// the seed and of the project in it.
+	OPAIntegration *OPAIntegrationSettings `json:"opaIntegration,omitempty"`
 }
 
 type ClusterConditionType string
@@ -117,10 +148,33 @@ const (
 	ClusterConditionMonitoringControllerReconcilingSuccess     ClusterConditionType = "MonitoringControllerReconciledSuccessfully"
 	ClusterConditionOpenshiftControllerReconcilingSuccess      ClusterConditionType = "OpenshiftControllerReconciledSuccessfully"
 
+	// The health probe conditions are maintained by the cluster health prober controller. Their
+	// messages contain the reasons why a probe failed.
+	ClusterConditionApiserverHealthy                  ClusterConditionType = "ApiserverHealthy"
+	ClusterConditionEtcdHealthy                       ClusterConditionType = "EtcdHealthy"
+	ClusterConditionMachineControllerWebhookReachable ClusterConditionType = "MachineControllerWebhookReachable"
+	ClusterConditionOpenVPNTunnelConnected            ClusterConditionType = "OpenVPNTunnelConnected"
+
+	// ClusterConditionCertificatesValid is false while certificates of the control plane are about
+	// to expire without being renewed, its message lists these certificates
+	ClusterConditionCertificatesValid ClusterConditionType = "CertificatesValid"
+
+	// ReasonReconcilingError is the reason of the reconciling conditions of controllers that failed,
+	// their messages contain the error
+	ReasonReconcilingError = "ReconcilingError"
+
 	ReasonClusterUpdateSuccessful = "ClusterUpdateSuccessful"
 	ReasonClusterUpadteInProgress = "ClusterUpdateInProgress"
 )
 
+// HealthProbeConditionTypes are the types of the conditions which hold the results of the health probes
+var HealthProbeConditionTypes = []ClusterConditionType{
+	ClusterConditionApiserverHealthy,
+	ClusterConditionEtcdHealthy,
+	ClusterConditionMachineControllerWebhookReachable,
+	ClusterConditionOpenVPNTunnelConnected,
+}
+
 type ClusterCondition struct {
 	// Type of cluster condition.
 	Type ClusterConditionType `json:"type"`
@@ -180,6 +234,196 @@ type ClusterStatus struct {
 	// CloudMigrationRevision describes the latest version of the migration that has been done
 	// It is used to avoid redundant and potentially costly migrations
 	CloudMigrationRevision int `json:"cloudMigrationRevision"`
+
+	// Creation tracks the creation of the node deployments and addons that were requested along with the cluster.
+	// It is only set for clusters that were created with initial resources.
+	Creation *ClusterCreationStatus `json:"creation,omitempty"`
+
+	// Hibernation tracks the hibernation of the cluster. It is only set while the cluster
+	// is hibernating, hibernated or resuming.
+	Hibernation *ClusterHibernationStatus `json:"hibernation,omitempty"`
+
+	// RootCARotation tracks the last rotation of the root CA of the cluster
+	RootCARotation *RootCARotationStatus `json:"rootCARotation,omitempty"`
+
+	// EncryptionAtRest tracks the encryption of the Secrets of the cluster. It is only set for clusters
+	// with encryption at rest.
+	EncryptionAtRest *EncryptionAtRestStatus `json:"encryptionAtRest,omitempty"`
+
+	// Etcd tracks the members of the etcd cluster while it gets scaled to the configured size
+	Etcd *EtcdClusterStatus `json:"etcd,omitempty"`
+
+	// Tunneling tracks the tunneling mode which is used by the control plane to reach the
+	// nodes of the cluster. It is unset for clusters created before the tunneling mode was
+	// configurable, they use OpenVPN.
+	Tunneling *TunnelingStatus `json:"tunneling,omitempty"`
+}
+
+// TunnelingPhase is the phase of the migration between two tunneling modes
+type TunnelingPhase string
+
+const (
+	// TunnelingEstablished means that the control plane uses the configured tunneling mode
+	TunnelingEstablished TunnelingPhase = "Established"
+	// TunnelingMigrating means that the configured tunneling mode gets deployed while the
+	// control plane still uses the previous one
+	TunnelingMigrating TunnelingPhase = "Migrating"
+)
+
+// TunnelingStatus is the status of the tunnel between the control plane and the nodes
+type TunnelingStatus struct {
+	// Mode is the tunneling mode which is currently used by the control plane
+	Mode  TunnelingMode  `json:"mode"`
+	Phase TunnelingPhase `json:"phase"`
+	// Message tells why the migration doesn't proceed, e.g. because the agents are not ready
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the tunnel entered the current phase
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// EtcdClusterPhase is the phase of the scaling of the etcd cluster
+type EtcdClusterPhase string
+
+const (
+	// EtcdClusterRunning means that the etcd cluster has the configured size
+	EtcdClusterRunning EtcdClusterPhase = "Running"
+	// EtcdClusterScalingUp means that members get added one at a time
+	EtcdClusterScalingUp EtcdClusterPhase = "ScalingUp"
+	// EtcdClusterScalingDown means that members get removed one at a time
+	EtcdClusterScalingDown EtcdClusterPhase = "ScalingDown"
+)
+
+// EtcdClusterStatus is the status of the membership of the etcd cluster
+type EtcdClusterStatus struct {
+	Phase EtcdClusterPhase `json:"phase"`
+	// Members are the names of the members of the etcd cluster, members which were added
+	// but didn't start yet are listed by their pod name
+	Members []string `json:"members,omitempty"`
+	// Message tells why the scaling doesn't proceed, e.g. because a member is unhealthy
+	Message string `json:"message,omitempty"`
+	// LastTransitionTime is the time the etcd cluster entered the current phase
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// EncryptionPhase is the phase of the encryption of the Secrets of a cluster
+type EncryptionPhase string
+
+const (
+	// EncryptionPreparing means that a new key was created and the apiservers are restarted to be able
+	// to decrypt with it, the Secrets are still encrypted with the previous key
+	EncryptionPreparing EncryptionPhase = "Preparing"
+	// EncryptionReencrypting means that the current key encrypts and all Secrets are being rewritten
+	EncryptionReencrypting EncryptionPhase = "Reencrypting"
+	// EncryptionEncrypted means that all Secrets are encrypted with the current key
+	EncryptionEncrypted EncryptionPhase = "Encrypted"
+)
+
+// EncryptionAtRestStatus is the status of the encryption of the Secrets of a cluster
+type EncryptionAtRestStatus struct {
+	Phase EncryptionPhase `json:"phase"`
+	// KeyName is the name of the key the Secrets are encrypted with once the phase is Encrypted
+	KeyName string `json:"keyName,omitempty"`
+	// ReencryptedSecrets is the number of Secrets which were rewritten in the Reencrypting phase
+	ReencryptedSecrets int `json:"reencryptedSecrets,omitempty"`
+	// LastTransitionTime is the time the encryption entered the current phase
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// InProgress returns whether not all Secrets are encrypted with the current key yet
+func (s *EncryptionAtRestStatus) InProgress() bool {
+	return s != nil && s.Phase != EncryptionEncrypted
+}
+
+// RootCARotationPhase is the phase of the rotation of the root CA of a cluster
+type RootCARotationPhase string
+
+const (
+	// RootCARotationTrusting means that a new CA was created and gets trusted in addition to the
+	// old one, certificates are still signed by the old CA
+	RootCARotationTrusting RootCARotationPhase = "Trusting"
+	// RootCARotationSigning means that certificates are re-issued by the new CA, the old CA is
+	// still trusted
+	RootCARotationSigning RootCARotationPhase = "Signing"
+	// RootCARotationCompleted means that only the new CA is trusted anymore
+	RootCARotationCompleted RootCARotationPhase = "Completed"
+)
+
+// RootCARotationStatus is the status of the rotation of the root CA of a cluster
+type RootCARotationStatus struct {
+	Phase RootCARotationPhase `json:"phase"`
+	// LastTransitionTime is the time the rotation entered the current phase
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// InProgress returns whether the rotation is not completed yet
+func (s *RootCARotationStatus) InProgress() bool {
+	return s != nil && s.Phase != RootCARotationCompleted
+}
+
+// ClusterHibernationPhase is the phase of the hibernation of a cluster
+type ClusterHibernationPhase string
+
+const (
+	// ClusterHibernating means that the MachineDeployments are being scaled to zero
+	ClusterHibernating ClusterHibernationPhase = "Hibernating"
+	// ClusterHibernated means that the control plane is scaled to zero and the cluster
+	// is not being reconciled anymore
+	ClusterHibernated ClusterHibernationPhase = "Hibernated"
+	// ClusterResuming means that the control plane and the MachineDeployments are being
+	// scaled back to their previous replicas
+	ClusterResuming ClusterHibernationPhase = "Resuming"
+)
+
+// ClusterHibernationStatus is the hibernation status of a cluster
+type ClusterHibernationStatus struct {
+	Phase ClusterHibernationPhase `json:"phase"`
+	// LastTransitionTime is the time the cluster entered the current phase
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ClusterCreationPhase is the phase of the creation of a cluster along with its initial resources
+type ClusterCreationPhase string
+
+const (
+	ClusterCreationInProgress ClusterCreationPhase = "InProgress"
+	ClusterCreationSucceeded  ClusterCreationPhase = "Succeeded"
+	ClusterCreationFailed     ClusterCreationPhase = "Failed"
+	// ClusterCreationRolledBack means that the creation failed and the cluster is being deleted
+	ClusterCreationRolledBack ClusterCreationPhase = "RolledBack"
+)
+
+// ClusterCreationResourcePhase is the phase of a single initial resource
+type ClusterCreationResourcePhase string
+
+const (
+	ClusterCreationResourcePending ClusterCreationResourcePhase = "Pending"
+	ClusterCreationResourceCreated ClusterCreationResourcePhase = "Created"
+	ClusterCreationResourceFailed  ClusterCreationResourcePhase = "Failed"
+)
+
+const (
+	ClusterCreationResourceKindNodeDeployment = "NodeDeployment"
+	ClusterCreationResourceKindAddon          = "Addon"
+)
+
+// ClusterCreationStatus tracks the creation of the initial resources of a cluster as one operation
+type ClusterCreationStatus struct {
+	Phase ClusterCreationPhase `json:"phase"`
+	// RollbackOnFailure tells whether the cluster gets deleted when any of the initial resources cannot be created
+	RollbackOnFailure bool                            `json:"rollbackOnFailure,omitempty"`
+	Resources         []ClusterCreationResourceStatus `json:"resources,omitempty"`
+	StartTime         metav1.Time                     `json:"startTime,omitempty"`
+	CompletionTime    *metav1.Time                    `json:"completionTime,omitempty"`
+	Message           string                          `json:"message,omitempty"`
+}
+
+// ClusterCreationResourceStatus is the status of a single initial resource
+type ClusterCreationResourceStatus struct {
+	// Kind is either NodeDeployment or Addon
+	Kind    string                       `json:"kind"`
+	Name    string                       `json:"name"`
+	Phase   ClusterCreationResourcePhase `json:"phase"`
+	Message string                       `json:"message,omitempty"`
 }
 
 // HasConditionValue returns true if the cluster status has the given condition with the given status.
@@ -221,12 +465,90 @@ type AuditLoggingSettings struct {
 	Enabled bool `json:"enabled,omitempty"`
 }
 
+// AdmissionPluginSettings enable an admission plugin of the apiserver
+type AdmissionPluginSettings struct {
+	Name string `json:"name"`
+	// Config is the configuration file of the plugin, for the plugins which read one
+	Config string `json:"config,omitempty"`
+}
+
+// ComponentExtraFlags are flags for the control plane components, by their name without the leading dashes
+type ComponentExtraFlags struct {
+	Apiserver         map[string]string `json:"apiserver,omitempty"`
+	ControllerManager map[string]string `json:"controllerManager,omitempty"`
+	Scheduler         map[string]string `json:"scheduler,omitempty"`
+}
+
+// OPAIntegrationSettings configures the OPA Gatekeeper integration of a cluster
+type OPAIntegrationSettings struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// EncryptionProvider is the provider which encrypts the Secrets of a cluster
+type EncryptionProvider string
+
+const (
+	// EncryptionProviderAESCBC encrypts with AES-CBC using a key stored in the cluster namespace
+	EncryptionProviderAESCBC EncryptionProvider = "aescbc"
+	// EncryptionProviderSecretbox encrypts with XSalsa20 and Poly1305 using a key stored in the cluster namespace
+	EncryptionProviderSecretbox EncryptionProvider = "secretbox"
+	// EncryptionProviderKMS encrypts with data encryption keys which are wrapped by the KMS stand-in, using
+	// a key stored in the cluster namespace
+	EncryptionProviderKMS EncryptionProvider = "kms"
+)
+
+// AllEncryptionProviders are all supported encryption providers
+var AllEncryptionProviders = []EncryptionProvider{EncryptionProviderAESCBC, EncryptionProviderSecretbox, EncryptionProviderKMS}
+
+// EncryptionAtRestSettings configure the encryption of the Secrets in the etcd of a cluster
+type EncryptionAtRestSettings struct {
+	Provider EncryptionProvider `json:"provider"`
+}
+
+// HibernationSettings define when a cluster is hibernated. While hibernated, its MachineDeployments
+// and its control plane are scaled to zero.
+type HibernationSettings struct {
+	// Enabled hibernates the cluster until it gets disabled again
+	Enabled bool `json:"enabled,omitempty"`
+	// Schedules hibernate the cluster during recurring time windows, e.g. nights and weekends
+	Schedules []HibernationSchedule `json:"schedules,omitempty"`
+	// TimeZone is the IANA time zone the schedules are defined in, defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// HibernationSchedule is a recurring time window in which the cluster is hibernated
+type HibernationSchedule struct {
+	// Days are the weekdays the window starts on, e.g. "Mon". The window starts every day if empty.
+	Days []string `json:"days,omitempty"`
+	// Start is the time of day the window starts at, formatted as "15:04"
+	Start string `json:"start"`
+	// End is the time of day the window ends at, formatted as "15:04". The window ends
+	// on the following day if End is not after Start.
+	End string `json:"end"`
+}
+
+// ClusterAutoscalerSettings configures the cluster-autoscaler. It only scales node deployments
+// which have minimum and maximum replicas set.
+type ClusterAutoscalerSettings struct {
+	// ScaleDownDelayAfterAdd is the time after a scale up after which scale downs are evaluated
+	// again, e.g. "10m"
+	ScaleDownDelayAfterAdd string `json:"scaleDownDelayAfterAdd,omitempty"`
+	// ScaleDownUnneededTime is the time a node must be unneeded before it gets removed, e.g. "10m"
+	ScaleDownUnneededTime string `json:"scaleDownUnneededTime,omitempty"`
+	// ScaleDownUtilizationThreshold is the share of its allocatable resources that must be requested
+	// on a node, below which the node gets removed, e.g. "0.5". Defaults to "0.7".
+	ScaleDownUtilizationThreshold string `json:"scaleDownUtilizationThreshold,omitempty"`
+	// Expander is the strategy that selects the node deployment that gets scaled up. It is one of
+	// random, most-pods, least-waste or priority and defaults to random.
+	Expander string `json:"expander,omitempty"`
+}
+
 type ComponentSettings struct {
-	Apiserver         APIServerSettings   `json:"apiserver"`
-	ControllerManager DeploymentSettings  `json:"controllerManager"`
-	Scheduler         DeploymentSettings  `json:"scheduler"`
-	Etcd              StatefulSetSettings `json:"etcd"`
-	Prometheus        StatefulSetSettings `json:"prometheus"`
+	Apiserver         APIServerSettings       `json:"apiserver"`
+	ControllerManager DeploymentSettings      `json:"controllerManager"`
+	Scheduler         DeploymentSettings      `json:"scheduler"`
+	Etcd              EtcdStatefulSetSettings `json:"etcd"`
+	Prometheus        StatefulSetSettings     `json:"prometheus"`
 }
 
 type APIServerSettings struct {
@@ -244,6 +566,13 @@ type StatefulSetSettings struct {
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
+type EtcdStatefulSetSettings struct {
+	// ClusterSize is the number of etcd members, it must be odd and defaults to 3. When it gets
+	// changed, members are added or removed one at a time while all members are healthy.
+	ClusterSize int                          `json:"clusterSize,omitempty"`
+	Resources   *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
 // ClusterNetworkingConfig specifies the different networking
 // parameters for a cluster.
 type ClusterNetworkingConfig struct {
@@ -259,6 +588,36 @@ type ClusterNetworkingConfig struct {
 	// ProxyMode defines the kube-proxy mode (ipvs/iptables).
 	// Defaults to ipvs.
 	ProxyMode string `json:"proxyMode"`
+
+	// TunnelingMode defines how the control plane reaches the nodes (openvpn/konnectivity).
+	// Defaults to openvpn.
+	TunnelingMode TunnelingMode `json:"tunnelingMode,omitempty"`
+}
+
+// TunnelingMode is the mechanism used by the control plane to reach the nodes, pods and
+// services of the cluster
+type TunnelingMode string
+
+const (
+	// TunnelingModeOpenVPN runs an OpenVPN server in the control plane and a client in the
+	// cluster, the control plane components get an OpenVPN sidecar
+	TunnelingModeOpenVPN TunnelingMode = "openvpn"
+	// TunnelingModeKonnectivity runs a konnectivity server in the control plane and agents in
+	// the cluster, the apiserver proxies its traffic to the cluster through the agents
+	TunnelingModeKonnectivity TunnelingMode = "konnectivity"
+)
+
+// AllTunnelingModes are all supported tunneling modes
+var AllTunnelingModes = []TunnelingMode{TunnelingModeOpenVPN, TunnelingModeKonnectivity}
+
+// IsValid returns whether the tunneling mode is supported
+func (m TunnelingMode) IsValid() bool {
+	for _, mode := range AllTunnelingModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
 }
 
 // MachineNetworkingConfig specifies the networking parameters used for IPAM.
@@ -460,11 +819,13 @@ const (
 
 // ExtendedClusterHealth stores health information of a cluster.
 type ExtendedClusterHealth struct {
-	Apiserver                    HealthStatus `json:"apiserver"`
-	Scheduler                    HealthStatus `json:"scheduler"`
-	Controller                   HealthStatus `json:"controller"`
-	MachineController            HealthStatus `json:"machineController"`
-	Etcd                         HealthStatus `json:"etcd"`
+	Apiserver         HealthStatus `json:"apiserver"`
+	Scheduler         HealthStatus `json:"scheduler"`
+	Controller        HealthStatus `json:"controller"`
+	MachineController HealthStatus `json:"machineController"`
+	Etcd              HealthStatus `json:"etcd"`
+	// OpenVPN is the health of the server of the tunneling mode the control plane currently uses,
+	// which is not necessarily OpenVPN
 	OpenVPN                      HealthStatus `json:"openvpn"`
 	CloudProviderInfrastructure  HealthStatus `json:"cloudProviderInfrastructure"`
 	UserClusterControllerManager HealthStatus `json:"userClusterControllerManager"`
@@ -553,3 +914,27 @@ func (cluster *Cluster) GetSecretName() string {
 	}
 	return ""
 }
+
+// TunnelingMode returns the configured tunneling mode of the cluster
+func (cluster *Cluster) TunnelingMode() TunnelingMode {
+	if cluster.Spec.ClusterNetwork.TunnelingMode == "" {
+		return TunnelingModeOpenVPN
+	}
+	return cluster.Spec.ClusterNetwork.TunnelingMode
+}
+
+// ActiveTunnelingMode returns the tunneling mode which is currently used by the control plane.
+// While a migration is in progress, it differs from the configured one.
+func (cluster *Cluster) ActiveTunnelingMode() TunnelingMode {
+	if cluster.Status.Tunneling == nil || cluster.Status.Tunneling.Mode == "" {
+		return TunnelingModeOpenVPN
+	}
+	return cluster.Status.Tunneling.Mode
+}
+
+// IsTunnelingModeDeployed returns whether the server and the agents of the given tunneling mode
+// must be deployed. This is the case for the active and the configured mode, so that the tunnel
+// of the configured mode can be established before the control plane switches over.
+func (cluster *Cluster) IsTunnelingModeDeployed(mode TunnelingMode) bool {
+	return cluster.ActiveTunnelingMode() == mode || cluster.TunnelingMode() == mode
+}
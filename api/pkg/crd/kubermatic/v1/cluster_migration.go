@@ -0,0 +1,100 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ClusterMigrationResourceName represents "Resource" defined in Kubernetes
+	ClusterMigrationResourceName = "clustermigrations"
+
+	// ClusterMigrationKindName represents "Kind" defined in Kubernetes
+	ClusterMigrationKindName = "ClusterMigration"
+)
+
+// ClusterMigrationPhase is the phase of a cluster migration
+type ClusterMigrationPhase string
+
+const (
+	// ClusterMigrationPending means that the migration has not been validated yet
+	ClusterMigrationPending ClusterMigrationPhase = "Pending"
+	// ClusterMigrationSnapshotting means that the source cluster is paused and its etcd gets snapshotted
+	ClusterMigrationSnapshotting ClusterMigrationPhase = "Snapshotting"
+	// ClusterMigrationProvisioning means that the cluster, its namespace and secrets get created on the target seed
+	ClusterMigrationProvisioning ClusterMigrationPhase = "Provisioning"
+	// ClusterMigrationRestoring means that the etcd volumes on the target seed get restored from the snapshot
+	ClusterMigrationRestoring ClusterMigrationPhase = "Restoring"
+	// ClusterMigrationSwitching means that the control plane on the target seed is started and the address gets switched
+	ClusterMigrationSwitching ClusterMigrationPhase = "Switching"
+	// ClusterMigrationRepointing means that the nodes and the openvpn clients get re-pointed to the target seed
+	ClusterMigrationRepointing ClusterMigrationPhase = "Repointing"
+	// ClusterMigrationCompleted means that the cluster runs on the target seed and got removed from the source seed
+	ClusterMigrationCompleted ClusterMigrationPhase = "Completed"
+	// ClusterMigrationRollingBack means that the migration failed and the cluster gets restored on the source seed
+	ClusterMigrationRollingBack ClusterMigrationPhase = "RollingBack"
+	// ClusterMigrationRolledBack means that the cluster runs on the source seed again
+	ClusterMigrationRolledBack ClusterMigrationPhase = "RolledBack"
+	// ClusterMigrationFailed means that the migration could not be validated or rolled back, manual intervention is required
+	ClusterMigrationFailed ClusterMigrationPhase = "Failed"
+)
+
+//+genclient
+
+// ClusterMigration moves the control plane of a cluster from one seed to another.
+// It lives in the same namespace as the seeds on the master cluster.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterMigrationSpec   `json:"spec"`
+	Status ClusterMigrationStatus `json:"status,omitempty"`
+}
+
+// ClusterMigrationSpec specifies the cluster to migrate and where to
+type ClusterMigrationSpec struct {
+	// ClusterName is the name of the cluster to migrate
+	ClusterName string `json:"clusterName"`
+	// SourceSeed is the name of the seed the cluster currently runs on
+	SourceSeed string `json:"sourceSeed"`
+	// TargetSeed is the name of the seed the cluster gets moved to
+	TargetSeed string `json:"targetSeed"`
+	// TargetDatacenter is the datacenter of the target seed the cluster gets assigned to,
+	// it must use the same cloud provider as the current datacenter of the cluster
+	TargetDatacenter string `json:"targetDatacenter"`
+	// Abort requests a rollback of a migration that is still in progress
+	Abort bool `json:"abort,omitempty"`
+}
+
+// ClusterMigrationStatus is the observed state of a cluster migration
+type ClusterMigrationStatus struct {
+	Phase ClusterMigrationPhase `json:"phase,omitempty"`
+	// FailedPhase is the phase in which the migration failed
+	FailedPhase ClusterMigrationPhase `json:"failedPhase,omitempty"`
+	Message     string                `json:"message,omitempty"`
+	// SourceAddress is the address of the cluster before the migration
+	SourceAddress ClusterAddress `json:"sourceAddress,omitempty"`
+	// TargetAddress is the address of the cluster on the target seed
+	TargetAddress  ClusterAddress `json:"targetAddress,omitempty"`
+	StartTime      *metav1.Time   `json:"startTime,omitempty"`
+	CompletionTime *metav1.Time   `json:"completionTime,omitempty"`
+}
+
+// IsFinished tells whether the migration reached a final phase
+func (s *ClusterMigrationStatus) IsFinished() bool {
+	switch s.Phase {
+	case ClusterMigrationCompleted, ClusterMigrationRolledBack, ClusterMigrationFailed:
+		return true
+	}
+	return false
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterMigrationList is a list of cluster migrations
+type ClusterMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterMigration `json:"items"`
+}
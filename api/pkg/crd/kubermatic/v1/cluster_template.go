@@ -0,0 +1,83 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// ClusterTemplateResourceName represents "Resource" defined in Kubernetes
+	ClusterTemplateResourceName = "clustertemplates"
+
+	// ClusterTemplateKindName represents "Kind" defined in Kubernetes
+	ClusterTemplateKindName = "ClusterTemplate"
+)
+
+const (
+	// ClusterTemplateParameterTypeString substitutes the parameter value as a string, this is the default
+	ClusterTemplateParameterTypeString = "string"
+	// ClusterTemplateParameterTypeInteger substitutes the parameter value as a JSON number
+	ClusterTemplateParameterTypeInteger = "integer"
+	// ClusterTemplateParameterTypeBoolean substitutes the parameter value as a JSON boolean
+	ClusterTemplateParameterTypeBoolean = "boolean"
+)
+
+//+genclient
+//+genclient:nonNamespaced
+
+// ClusterTemplate describes a cluster together with its initial node deployments, addons and SSH keys
+// so that it can be instantiated repeatedly. A template that is owned by a project is only visible to
+// that project, a template without a project owner is global and visible to all projects.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ClusterTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterTemplateSpec `json:"spec"`
+}
+
+// ClusterTemplateSpec specifies a cluster template
+type ClusterTemplateSpec struct {
+	HumanReadableName string `json:"humanReadableName"`
+	Description       string `json:"description,omitempty"`
+
+	// Parameters can be referenced from any string value of the cluster, the node deployments
+	// and the addon variables as ${name}.
+	Parameters []ClusterTemplateParameter `json:"parameters,omitempty"`
+
+	// Cluster is the cluster in the format accepted by the cluster creation endpoint of the API.
+	Cluster runtime.RawExtension `json:"cluster"`
+	// NodeDeployments are the node deployments in the format accepted by the API.
+	NodeDeployments []runtime.RawExtension `json:"nodeDeployments,omitempty"`
+	// Addons are installed once the cluster is up and running.
+	Addons []ClusterTemplateAddon `json:"addons,omitempty"`
+	// SSHKeys are the IDs of the project SSH keys that get assigned to new clusters.
+	SSHKeys []string `json:"sshKeys,omitempty"`
+}
+
+// ClusterTemplateParameter is a value that is provided when a template gets instantiated
+type ClusterTemplateParameter struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Type is one of string, integer or boolean. Non-string parameters must be the only content
+	// of the string value referencing them.
+	Type     string `json:"type,omitempty"`
+	Default  string `json:"default,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// ClusterTemplateAddon is an addon that gets installed into the instantiated cluster
+type ClusterTemplateAddon struct {
+	Name      string                `json:"name"`
+	Variables *runtime.RawExtension `json:"variables,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterTemplateList specifies a list of cluster templates
+type ClusterTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ClusterTemplate `json:"items"`
+}
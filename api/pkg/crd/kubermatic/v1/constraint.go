@@ -0,0 +1,139 @@
+package v1
+
+import (
+	apiextensionsv1beta1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// ConstraintTemplateResourceName represents "Resource" defined in Kubernetes
+	ConstraintTemplateResourceName = "constrainttemplates"
+
+	// ConstraintTemplateKindName represents "Kind" defined in Kubernetes
+	ConstraintTemplateKindName = "ConstraintTemplate"
+
+	// ConstraintResourceName represents "Resource" defined in Kubernetes
+	ConstraintResourceName = "constraints"
+
+	// ConstraintKindName represents "Kind" defined in Kubernetes
+	ConstraintKindName = "Constraint"
+)
+
+const (
+	// ConstraintEnforcementActionDeny rejects requests that violate the constraint, this is the default
+	ConstraintEnforcementActionDeny = "deny"
+	// ConstraintEnforcementActionDryRun only reports violations of the constraint
+	ConstraintEnforcementActionDryRun = "dryrun"
+)
+
+//+genclient
+//+genclient:nonNamespaced
+
+// ConstraintTemplate is a Gatekeeper constraint template that gets synced into every user cluster
+// of the seed that has the OPA integration enabled. Its spec mirrors the spec of the Gatekeeper
+// ConstraintTemplate, so templates from the Gatekeeper library can be used as they are.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type ConstraintTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConstraintTemplateSpec `json:"spec"`
+}
+
+// ConstraintTemplateSpec specifies the constraint kind a template defines and the Rego code enforcing it
+type ConstraintTemplateSpec struct {
+	CRD     ConstraintTemplateCRD      `json:"crd"`
+	Targets []ConstraintTemplateTarget `json:"targets"`
+}
+
+// ConstraintTemplateCRD describes the CRD Gatekeeper creates for the constraints of a template
+type ConstraintTemplateCRD struct {
+	Spec ConstraintTemplateCRDSpec `json:"spec"`
+}
+
+// ConstraintTemplateCRDSpec specifies the names and the parameter schema of the constraint kind
+type ConstraintTemplateCRDSpec struct {
+	Names      ConstraintTemplateNames       `json:"names"`
+	Validation *ConstraintTemplateValidation `json:"validation,omitempty"`
+}
+
+// ConstraintTemplateNames specifies the kind of the constraints of a template
+type ConstraintTemplateNames struct {
+	Kind       string   `json:"kind"`
+	ShortNames []string `json:"shortNames,omitempty"`
+}
+
+// ConstraintTemplateValidation is the schema of the constraint parameters
+type ConstraintTemplateValidation struct {
+	OpenAPIV3Schema *apiextensionsv1beta1.JSONSchemaProps `json:"openAPIV3Schema,omitempty"`
+}
+
+// ConstraintTemplateTarget is the Rego code that gets evaluated for a target
+type ConstraintTemplateTarget struct {
+	// Target is the Gatekeeper target, e.g. admission.k8s.gatekeeper.sh
+	Target string   `json:"target"`
+	Rego   string   `json:"rego"`
+	Libs   []string `json:"libs,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ConstraintTemplateList specifies a list of constraint templates
+type ConstraintTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []ConstraintTemplate `json:"items"`
+}
+
+//+genclient
+//+genclient:nonNamespaced
+
+// Constraint is an instance of a ConstraintTemplate that gets enforced in user clusters. A constraint
+// with a project ID only applies to the clusters of that project, a constraint without a project ID
+// applies to all clusters of the seed that have the OPA integration enabled.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type Constraint struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ConstraintSpec `json:"spec"`
+}
+
+// ConstraintSpec specifies a constraint
+type ConstraintSpec struct {
+	// ConstraintType is the kind defined by the ConstraintTemplate this constraint instantiates.
+	ConstraintType string `json:"constraintType"`
+	// ProjectID limits the constraint to the clusters of a project.
+	ProjectID string `json:"projectID,omitempty"`
+	// EnforcementAction is either deny or dryrun, defaults to deny.
+	EnforcementAction string          `json:"enforcementAction,omitempty"`
+	Match             ConstraintMatch `json:"match,omitempty"`
+	// Parameters are passed to the Rego code of the template and must match its validation schema.
+	Parameters *runtime.RawExtension `json:"parameters,omitempty"`
+}
+
+// ConstraintMatch selects the objects a constraint applies to
+type ConstraintMatch struct {
+	Kinds              []ConstraintMatchKind `json:"kinds,omitempty"`
+	Namespaces         []string              `json:"namespaces,omitempty"`
+	ExcludedNamespaces []string              `json:"excludedNamespaces,omitempty"`
+	LabelSelector      *metav1.LabelSelector `json:"labelSelector,omitempty"`
+}
+
+// ConstraintMatchKind selects objects by their API group and kind
+type ConstraintMatchKind struct {
+	APIGroups []string `json:"apiGroups,omitempty"`
+	Kinds     []string `json:"kinds,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ConstraintList specifies a list of constraints
+type ConstraintList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Constraint `json:"items"`
+}
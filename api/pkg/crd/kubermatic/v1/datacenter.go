@@ -2,6 +2,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/kubermatic/machine-controller/pkg/providerconfig"
@@ -65,6 +66,24 @@ type SeedSpec struct {
 	// Optional: ProxySettings can be used to configure HTTP proxy settings on the
 	// worker nodes in user clusters. However, proxy settings on nodes take precedence.
 	ProxySettings *ProxySettings `json:"proxy_settings,omitempty"`
+	// Optional: Capacity limits how many cluster control planes get placed on this seed.
+	// Clusters requested for a datacenter of a full or cordoned seed get redirected to an
+	// equivalent datacenter of another seed, or refused if there is none.
+	Capacity *SeedCapacity `json:"capacity,omitempty"`
+}
+
+// SeedCapacity describes how many cluster control planes a seed can host
+type SeedCapacity struct {
+	// Optional: The maximum number of clusters whose control planes run on this seed.
+	MaxClusters *int `json:"max_clusters,omitempty"`
+	// Optional: The CPU budget for all control planes on this seed. It is compared
+	// against the sum of the CPU requests of all pods in cluster namespaces.
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+	// Optional: The memory budget for all control planes on this seed. It is compared
+	// against the sum of the memory requests of all pods in cluster namespaces.
+	Memory *resource.Quantity `json:"memory,omitempty"`
+	// Optional: Cordoned seeds don't accept new clusters, existing clusters are not affected.
+	Cordoned bool `json:"cordoned,omitempty"`
 }
 
 type Datacenter struct {
@@ -104,6 +123,23 @@ type DatacenterSpec struct {
 	// one domain, e.g. "example.com", which must match the email domain
 	// exactly (i.e. "example.com" will not match "user@test.example.com").
 	RequiredEmailDomain string `json:"requiredEmailDomain,omitempty"`
+
+	// Optional: ComponentFlagPolicy restricts the admission plugins and the extra flags
+	// of the control plane components which users can set for their clusters.
+	ComponentFlagPolicy *ComponentFlagPolicy `json:"componentFlagPolicy,omitempty"`
+}
+
+// ComponentFlagPolicy restricts the customisation of the control plane components of the clusters in a
+// datacenter. Flags which Kubermatic manages or which weaken the security of a cluster can never be set.
+type ComponentFlagPolicy struct {
+	// Optional: AllowedAdmissionPlugins restricts the admission plugins which can be enabled.
+	// All supported admission plugins can be enabled if this is empty.
+	AllowedAdmissionPlugins []string `json:"allowedAdmissionPlugins,omitempty"`
+	// Optional: The flags, without the leading dashes, which can be set for the components.
+	// No extra flags can be set for a component without allowed flags.
+	AllowedApiserverFlags         []string `json:"allowedApiserverFlags,omitempty"`
+	AllowedControllerManagerFlags []string `json:"allowedControllerManagerFlags,omitempty"`
+	AllowedSchedulerFlags         []string `json:"allowedSchedulerFlags,omitempty"`
 }
 
 // ImageList defines a map of operating system and the image to use
@@ -19,6 +19,7 @@ import (
 // ClusterReconcileWrapper is a wrapper that should be used around
 // any cluster reconciliaton. It:
 // * Checks if the cluster is paused
+// * Checks if the cluster is hibernated
 // * Checks if the worker-name matches
 // * Sets the ReconcileSuccess condition for the controller
 func ClusterReconcileWrapper(
@@ -35,16 +36,24 @@ func ClusterReconcileWrapper(
 	if cluster.Spec.Pause {
 		return nil, nil
 	}
+	if IsClusterHibernated(cluster) {
+		return nil, nil
+	}
 
 	reconcilingStatus := corev1.ConditionFalse
+	var reason, message string
 	result, err := reconcile()
 	// Only set to true if we had no error and don't want to reqeue the cluster
 	if err == nil && (result == nil || (!result.Requeue && result.RequeueAfter == 0)) {
 		reconcilingStatus = corev1.ConditionTrue
 	}
+	// Keep the error, e.g. notifications about failed upgrades include it
+	if err != nil {
+		reason, message = kubermaticv1.ReasonReconcilingError, err.Error()
+	}
 	errs := []error{err}
 	errs = append(errs, clusterUpdater(ctx, client, cluster.Name, func(c *kubermaticv1.Cluster) {
-		SetClusterCondition(c, conditionType, reconcilingStatus, "", "")
+		SetClusterCondition(c, conditionType, reconcilingStatus, reason, message)
 	}))
 	return result, utilerrors.NewAggregate(errs)
 }
@@ -68,6 +77,18 @@ func clusterUpdater(
 	})
 }
 
+// IsClusterHibernated returns whether the control plane of the cluster is scaled down
+// by the hibernation. Hibernated clusters must not be reconciled, as this would scale
+// the control plane up again.
+func IsClusterHibernated(c *kubermaticv1.Cluster) bool {
+	return c.Status.Hibernation != nil && c.Status.Hibernation.Phase == kubermaticv1.ClusterHibernated
+}
+
+// IsClusterAutoscalerEnabled returns whether the cluster-autoscaler must be deployed for the cluster
+func IsClusterAutoscalerEnabled(c *kubermaticv1.Cluster) bool {
+	return c.Annotations[kubermaticv1.AnnotationNameClusterAutoscalerEnabled] != "" || c.Spec.ClusterAutoscaler != nil
+}
+
 // GetClusterCondition returns the index of the given condition or -1 and the condition itself
 // or a nilpointer.
 func GetClusterCondition(c *kubermaticv1.Cluster, conditionType kubermaticv1.ClusterConditionType) (int, *kubermaticv1.ClusterCondition) {
@@ -40,6 +40,64 @@ type Project struct {
 // ProjectSpec is a specification of a project.
 type ProjectSpec struct {
 	Name string `json:"name"`
+
+	// NotificationChannels receive notifications about events of the clusters in the project
+	NotificationChannels []NotificationChannel `json:"notificationChannels,omitempty"`
+}
+
+// NotificationEventType is the type of a cluster event for which notifications are sent
+type NotificationEventType string
+
+const (
+	// NotificationEventClusterHealthChanged is sent when a cluster becomes unhealthy or healthy again
+	NotificationEventClusterHealthChanged NotificationEventType = "ClusterHealthChanged"
+	// NotificationEventClusterUpgradeFailed is sent when the automatic update of a cluster fails
+	NotificationEventClusterUpgradeFailed NotificationEventType = "ClusterUpgradeFailed"
+	// NotificationEventAddonFailed is sent when the addons of a cluster can't be installed
+	NotificationEventAddonFailed NotificationEventType = "AddonFailed"
+	// NotificationEventCertificateExpiring is sent when certificates of a cluster are about to expire
+	NotificationEventCertificateExpiring NotificationEventType = "CertificateExpiring"
+	// NotificationEventClusterDeleted is sent when a cluster gets deleted
+	NotificationEventClusterDeleted NotificationEventType = "ClusterDeleted"
+)
+
+// AllNotificationEventTypes are all events for which notifications are sent
+var AllNotificationEventTypes = []NotificationEventType{
+	NotificationEventClusterHealthChanged,
+	NotificationEventClusterUpgradeFailed,
+	NotificationEventAddonFailed,
+	NotificationEventCertificateExpiring,
+	NotificationEventClusterDeleted,
+}
+
+// NotificationChannel describes where notifications get sent to. Exactly one of
+// Webhook, Slack and Email must be set.
+type NotificationChannel struct {
+	Name string `json:"name"`
+
+	Webhook *WebhookNotificationChannel `json:"webhook,omitempty"`
+	Slack   *SlackNotificationChannel   `json:"slack,omitempty"`
+	Email   *EmailNotificationChannel   `json:"email,omitempty"`
+
+	// Events are the events for which notifications are sent, all events if empty
+	Events []NotificationEventType `json:"events,omitempty"`
+}
+
+// WebhookNotificationChannel posts the notifications as JSON to the URL
+type WebhookNotificationChannel struct {
+	URL string `json:"url"`
+}
+
+// SlackNotificationChannel posts the notifications to a Slack compatible incoming webhook
+type SlackNotificationChannel struct {
+	URL string `json:"url"`
+	// Channel overrides the default channel of the webhook
+	Channel string `json:"channel,omitempty"`
+}
+
+// EmailNotificationChannel sends the notifications as email through the configured SMTP server
+type EmailNotificationChannel struct {
+	To []string `json:"to"`
 }
 
 // ProjectStatus represents the current status of a project.
@@ -49,6 +49,14 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&UserProjectBindingList{},
 		&Seed{},
 		&SeedList{},
+		&ClusterTemplate{},
+		&ClusterTemplateList{},
+		&ClusterMigration{},
+		&ClusterMigrationList{},
+		&ConstraintTemplate{},
+		&ConstraintTemplateList{},
+		&Constraint{},
+		&ConstraintList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/kubermatic/kubermatic/api/pkg/serviceaccount"
 	k8cerrors "github.com/kubermatic/kubermatic/api/pkg/util/errors"
 )
 
@@ -14,6 +15,8 @@ type TokenClaims struct {
 	Email   string
 	Subject string
 	Groups  []string
+	// Scopes restrict the requests a service account token is allowed to make, a token without scopes is unrestricted
+	Scopes []serviceaccount.Scope
 }
 
 // TokenExtractorVerifier combines TokenVerifier and TokenExtractor interfaces
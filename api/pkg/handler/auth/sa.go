@@ -50,7 +50,8 @@ func (s *ServiceAccountAuthClient) Verify(ctx context.Context, token string) (To
 	if !ok {
 		return TokenClaims{}, fmt.Errorf("sa: cannot verify the token (%s) because the corresponding token in the database is invalid", customClaims.TokenID)
 	}
-	if string(tokenFromDB) != token {
+	// the token that was replaced by a rotation stays valid during the grace period
+	if string(tokenFromDB) != token && !serviceaccount.PreviousTokenValid(rawToken, token) {
 		return TokenClaims{}, fmt.Errorf("sa: the token %s has been revoked for %s", customClaims.TokenID, customClaims.Email)
 	}
 
@@ -58,5 +59,6 @@ func (s *ServiceAccountAuthClient) Verify(ctx context.Context, token string) (To
 		Name:    customClaims.TokenID,
 		Email:   customClaims.Email,
 		Subject: customClaims.Email,
+		Scopes:  customClaims.Scopes,
 	}, nil
 }
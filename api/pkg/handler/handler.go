@@ -3,9 +3,12 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"reflect"
+	"time"
 
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
 	"github.com/kubermatic/kubermatic/api/pkg/log"
 	"github.com/kubermatic/kubermatic/api/pkg/util/errors"
 )
@@ -13,9 +16,13 @@ import (
 const (
 	headerContentType = "Content-Type"
 
-	contentTypeJSON = "application/json"
+	contentTypeJSON        = "application/json"
+	contentTypeEventStream = "text/event-stream"
 )
 
+// eventStreamKeepAlivePeriod is the interval of the comments which keep idle event streams open
+var eventStreamKeepAlivePeriod = 30 * time.Second
+
 // ErrorResponse is the default representation of an error
 // swagger:model errorResponse
 type ErrorResponse struct {
@@ -100,6 +107,51 @@ func encodeJSON(c context.Context, w http.ResponseWriter, response interface{})
 	return json.NewEncoder(w).Encode(response)
 }
 
+// encodeEventStream sends the messages of a common.EventStream as server-sent events
+// until the client disconnects
+func encodeEventStream(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	stream, ok := response.(*common.EventStream)
+	if !ok {
+		return fmt.Errorf("expected an event stream, got %T", response)
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return fmt.Errorf("streaming is not supported by the response writer %T", w)
+	}
+
+	w.Header().Set(headerContentType, contentTypeEventStream)
+	w.Header().Set("Cache-Control", "no-cache")
+	// Disables the response buffering of nginx based ingress controllers
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlivePeriod)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return err
+			}
+		case message, ok := <-stream.Messages:
+			if !ok {
+				return nil
+			}
+			data, err := json.Marshal(message.Data)
+			if err != nil {
+				return err
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", message.Type, data); err != nil {
+				return err
+			}
+		}
+		flusher.Flush()
+	}
+}
+
 // statusOK returns the status code 200
 func statusOK(res http.ResponseWriter, _ *http.Request) {
 	res.WriteHeader(http.StatusOK)
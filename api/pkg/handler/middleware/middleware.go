@@ -14,6 +14,7 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/handler/auth"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
 	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/kubermatic/kubermatic/api/pkg/serviceaccount"
 	k8cerrors "github.com/kubermatic/kubermatic/api/pkg/util/errors"
 	"github.com/kubermatic/kubermatic/api/pkg/util/hash"
 
@@ -41,6 +42,9 @@ const (
 	// AuthenticatedUserContextKey key under which the current User (from OIDC provider) is kept in the ctx
 	AuthenticatedUserContextKey contextKey = "authenticated-user"
 
+	// TokenScopesContextKey key under which the scopes of the current token are kept in the ctx, they are empty for unrestricted tokens
+	TokenScopesContextKey contextKey = "token-scopes"
+
 	// rawTokenContextKey key under which the current token (OpenID ID Token) is kept in the ctx
 	rawTokenContextKey contextKey = "raw-auth-token"
 
@@ -205,6 +209,13 @@ func TokenVerifier(tokenVerifier auth.TokenVerifier) endpoint.Middleware {
 				return nil, k8cerrors.NewNotAuthorized()
 			}
 
+			// the request method and path are populated by transporthttp.PopulateRequestContext
+			method, _ := ctx.Value(transporthttp.ContextKeyRequestMethod).(string)
+			path, _ := ctx.Value(transporthttp.ContextKeyRequestPath).(string)
+			if !serviceaccount.ScopesAllow(claims.Scopes, method, path) {
+				return nil, k8cerrors.New(http.StatusForbidden, fmt.Sprintf("forbidden: the token is not allowed to %s %s", method, path))
+			}
+
 			id, err := hash.GetUserID(claims.Subject)
 			if err != nil {
 				return nil, k8cerrors.NewNotAuthorized()
@@ -222,6 +233,7 @@ func TokenVerifier(tokenVerifier auth.TokenVerifier) endpoint.Middleware {
 				return nil, k8cerrors.NewNotAuthorized()
 			}
 
+			ctx = context.WithValue(ctx, TokenScopesContextKey, claims.Scopes)
 			return next(context.WithValue(ctx, AuthenticatedUserContextKey, user), request)
 		}
 	}
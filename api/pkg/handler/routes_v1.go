@@ -12,11 +12,13 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/addon"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/cluster"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/clustertemplate"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/dc"
 	kubernetesdashboard "github.com/kubermatic/kubermatic/api/pkg/handler/v1/kubernetes-dashboard"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/label"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/node"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/notificationchannel"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/openshift"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/presets"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/project"
@@ -158,6 +160,42 @@ func (r Routing) RegisterV1(mux *mux.Router, metrics common.ServerMetrics) {
 		Path("/projects/{project_id}/sshkeys").
 		Handler(r.listSSHKeys())
 
+	//
+	// Defines a set of HTTP endpoints for cluster templates that belong to a project
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/clustertemplates").
+		Handler(r.listClusterTemplates())
+
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/clustertemplates").
+		Handler(r.createClusterTemplate())
+
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/clustertemplates/{template_id}").
+		Handler(r.getClusterTemplate())
+
+	mux.Methods(http.MethodDelete).
+		Path("/projects/{project_id}/clustertemplates/{template_id}").
+		Handler(r.deleteClusterTemplate())
+
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/dc/{dc}/clustertemplates/{template_id}/instances").
+		Handler(r.createClusterFromTemplate(metrics.InitNodeDeploymentFailures))
+
+	//
+	// Defines a set of HTTP endpoints for the notification channels of a project
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/notificationchannels").
+		Handler(r.listNotificationChannels())
+
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/notificationchannels").
+		Handler(r.createNotificationChannel())
+
+	mux.Methods(http.MethodDelete).
+		Path("/projects/{project_id}/notificationchannels/{channel_name}").
+		Handler(r.deleteNotificationChannel())
+
 	//
 	// Defines a set of HTTP endpoints for cluster that belong to a project.
 	mux.Methods(http.MethodGet).
@@ -184,6 +222,10 @@ func (r Routing) RegisterV1(mux *mux.Router, metrics common.ServerMetrics) {
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/events").
 		Handler(r.getClusterEvents())
 
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/events/watch").
+		Handler(r.watchClusterEvents())
+
 	mux.Methods(http.MethodGet).
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/kubeconfig").
 		Handler(r.getClusterKubeconfig())
@@ -208,6 +250,22 @@ func (r Routing) RegisterV1(mux *mux.Router, metrics common.ServerMetrics) {
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/upgrades").
 		Handler(r.upgradeClusterNodeDeployments())
 
+	mux.Methods(http.MethodPut).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/cordon").
+		Handler(r.cordonNode())
+
+	mux.Methods(http.MethodPut).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/uncordon").
+		Handler(r.uncordonNode())
+
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/drain").
+		Handler(r.drainNode())
+
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/drain").
+		Handler(r.getNodeDrainStatus())
+
 	mux.Methods(http.MethodGet).
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/metrics").
 		Handler(r.getClusterMetrics())
@@ -216,6 +274,10 @@ func (r Routing) RegisterV1(mux *mux.Router, metrics common.ServerMetrics) {
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/namespaces").
 		Handler(r.listNamespace())
 
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/autoscaler").
+		Handler(r.getClusterAutoscalerStatus())
+
 	mux.Methods(http.MethodPost).
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/clusterroles").
 		Handler(r.createClusterRole())
@@ -318,6 +380,18 @@ func (r Routing) RegisterV1(mux *mux.Router, metrics common.ServerMetrics) {
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/viewertoken").
 		Handler(r.revokeClusterViewerToken())
 
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/rotaterootca").
+		Handler(r.rotateClusterRootCA())
+
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/rotateencryptionkey").
+		Handler(r.rotateClusterEncryptionKey())
+
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/compliance").
+		Handler(r.getClusterCompliance())
+
 	//
 	// Defines a set of HTTP endpoint for node deployments that belong to a cluster
 	mux.Methods(http.MethodPost).
@@ -344,10 +418,22 @@ func (r Routing) RegisterV1(mux *mux.Router, metrics common.ServerMetrics) {
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}/nodes/events").
 		Handler(r.listNodeDeploymentNodesEvents())
 
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}/nodes/events/watch").
+		Handler(r.watchNodeDeploymentNodesEvents())
+
 	mux.Methods(http.MethodPatch).
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}").
 		Handler(r.patchNodeDeployment())
 
+	mux.Methods(http.MethodGet).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}/revisions").
+		Handler(r.listNodeDeploymentRevisions())
+
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}/rollback").
+		Handler(r.rollbackNodeDeployment())
+
 	mux.Methods(http.MethodDelete).
 		Path("/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}").
 		Handler(r.deleteNodeDeployment())
@@ -512,6 +598,9 @@ func (r Routing) RegisterV1(mux *mux.Router, metrics common.ServerMetrics) {
 	mux.Methods(http.MethodDelete).
 		Path("/projects/{project_id}/serviceaccounts/{serviceaccount_id}/tokens/{token_id}").
 		Handler(r.deleteServiceAccountToken())
+	mux.Methods(http.MethodPost).
+		Path("/projects/{project_id}/serviceaccounts/{serviceaccount_id}/tokens/{token_id}/rotate").
+		Handler(r.rotateServiceAccountToken())
 
 	//
 	// Defines set of HTTP endpoints for control plane and kubelet versions
@@ -613,6 +702,218 @@ func (r Routing) deleteSSHKey() http.Handler {
 	)
 }
 
+// swagger:route GET /api/v1/projects/{project_id}/clustertemplates project listClusterTemplates
+//
+//     Lists cluster templates that belong to the given project along with the global ones.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: ClusterTemplateList
+//       401: empty
+//       403: empty
+func (r Routing) listClusterTemplates() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(clustertemplate.ListEndpoint(r.clusterTemplateProvider, r.projectProvider)),
+		clustertemplate.DecodeListReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route POST /api/v1/projects/{project_id}/clustertemplates project createClusterTemplate
+//
+//     Creates a cluster template for the given project.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       201: ClusterTemplate
+//       401: empty
+//       403: empty
+func (r Routing) createClusterTemplate() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(clustertemplate.CreateEndpoint(r.clusterTemplateProvider, r.sshKeyProvider, r.projectProvider)),
+		clustertemplate.DecodeCreateReq,
+		setStatusCreatedHeader(encodeJSON),
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route GET /api/v1/projects/{project_id}/clustertemplates/{template_id} project getClusterTemplate
+//
+//     Gets the given cluster template.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: ClusterTemplate
+//       401: empty
+//       403: empty
+func (r Routing) getClusterTemplate() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(clustertemplate.GetEndpoint(r.clusterTemplateProvider, r.projectProvider)),
+		clustertemplate.DecodeTemplateReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route DELETE /api/v1/projects/{project_id}/clustertemplates/{template_id} project deleteClusterTemplate
+//
+//     Deletes the given cluster template, global templates cannot be deleted.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: empty
+//       401: empty
+//       403: empty
+func (r Routing) deleteClusterTemplate() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(clustertemplate.DeleteEndpoint(r.clusterTemplateProvider, r.projectProvider)),
+		clustertemplate.DecodeTemplateReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route GET /api/v1/projects/{project_id}/notificationchannels project listNotificationChannels
+//
+//     Lists the channels which receive notifications about events of the clusters in the given project.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: NotificationChannelList
+//       401: empty
+//       403: empty
+func (r Routing) listNotificationChannels() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(notificationchannel.ListEndpoint(r.projectProvider)),
+		notificationchannel.DecodeListReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route POST /api/v1/projects/{project_id}/notificationchannels project createNotificationChannel
+//
+//     Adds a channel which receives notifications about events of the clusters in the given project.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       201: NotificationChannel
+//       401: empty
+//       403: empty
+func (r Routing) createNotificationChannel() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(notificationchannel.CreateEndpoint(r.projectProvider)),
+		notificationchannel.DecodeCreateReq,
+		setStatusCreatedHeader(encodeJSON),
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route DELETE /api/v1/projects/{project_id}/notificationchannels/{channel_name} project deleteNotificationChannel
+//
+//     Removes the given notification channel from the project.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: empty
+//       401: empty
+//       403: empty
+func (r Routing) deleteNotificationChannel() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(notificationchannel.DeleteEndpoint(r.projectProvider)),
+		notificationchannel.DecodeChannelReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route POST /api/v1/projects/{project_id}/dc/{dc}/clustertemplates/{template_id}/instances project createClusterFromTemplate
+//
+//     Creates a cluster along with its node deployments, addons and SSH keys from the given template.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       201: Cluster
+//       401: empty
+//       403: empty
+func (r Routing) createClusterFromTemplate(initNodeDeploymentFailures *prometheus.CounterVec) http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.SetPrivilegedClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.Addons(r.addonProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(clustertemplate.InstantiateEndpoint(r.clusterTemplateProvider, r.sshKeyProvider, r.projectProvider, r.seedsGetter, r.clusterProviderGetter, r.addonProviderGetter, initNodeDeploymentFailures, r.eventRecorderProvider, r.presetsManager, r.exposeStrategy)),
+		clustertemplate.DecodeInstantiateReq,
+		setStatusCreatedHeader(encodeJSON),
+		r.defaultServerOptions()...,
+	)
+}
+
 // swagger:route GET /api/v1/providers/{provider_name}/presets/credentials credentials listCredentials
 //
 // Lists credential names for the provider
@@ -1293,8 +1594,9 @@ func (r Routing) createCluster(initNodeDeploymentFailures *prometheus.CounterVec
 			middleware.UserSaver(r.userProvider),
 			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
 			middleware.SetPrivilegedClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.Addons(r.addonProviderGetter, r.seedsGetter),
 			middleware.UserInfoExtractor(r.userProjectMapper),
-		)(cluster.CreateEndpoint(r.sshKeyProvider, r.projectProvider, r.seedsGetter, initNodeDeploymentFailures, r.eventRecorderProvider, r.presetsManager, r.exposeStrategy)),
+		)(cluster.CreateEndpoint(r.sshKeyProvider, r.projectProvider, r.seedsGetter, r.clusterProviderGetter, r.addonProviderGetter, initNodeDeploymentFailures, r.eventRecorderProvider, r.presetsManager, r.exposeStrategy)),
 		cluster.DecodeCreateReq,
 		setStatusCreatedHeader(encodeJSON),
 		r.defaultServerOptions()...,
@@ -1433,6 +1735,38 @@ func (r Routing) getClusterEvents() http.Handler {
 	)
 }
 
+// watchClusterEvents streams events related to the cluster.
+// swagger:route GET /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/events/watch project watchClusterEvents
+//
+//     Streams the events of the specified cluster, its machines and nodes as server-sent events. The existing
+//     events are sent first, followed by new and updated ones. Messages of type `event` contain an Event,
+//     messages of type `cluster` and `health` contain the Cluster and ClusterHealth whenever they change and
+//     messages of type `error` describe failed watches, which are retried. The query parameter `type` filters
+//     the events like for getClusterEvents.
+//
+//     Produces:
+//     - text/event-stream
+//
+//     Responses:
+//       default: errorResponse
+//       200: []Event
+//       401: empty
+//       403: empty
+func (r Routing) watchClusterEvents() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.SetPrivilegedClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(cluster.WatchClusterEventsEndpoint(r.projectProvider)),
+		cluster.DecodeGetClusterEvents,
+		encodeEventStream,
+		r.defaultServerOptions()...,
+	)
+}
+
 // getClusterKubeconfig returns the kubeconfig for the cluster.
 // swagger:route GET /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/kubeconfig project getClusterKubeconfig
 //
@@ -1654,6 +1988,91 @@ func (r Routing) revokeClusterAdminToken() http.Handler {
 	)
 }
 
+// swagger:route POST /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/rotaterootca project rotateClusterRootCA
+//
+//     Starts a rotation of the root CA of the cluster. The new CA is trusted in addition to the old one
+//     for a while, nodes must be replaced during that time.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: Cluster
+//       401: empty
+//       403: empty
+//       409: errorResponse
+func (r Routing) rotateClusterRootCA() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(cluster.RotateRootCAEndpoint(r.projectProvider)),
+		common.DecodeGetClusterReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route POST /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/rotateencryptionkey project rotateClusterEncryptionKey
+//
+//     Starts a rotation of the key which encrypts the Secrets of the cluster. All Secrets get
+//     re-encrypted with the new key.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: Cluster
+//       400: errorResponse
+//       401: empty
+//       403: empty
+//       409: errorResponse
+func (r Routing) rotateClusterEncryptionKey() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(cluster.RotateEncryptionKeyEndpoint(r.projectProvider)),
+		common.DecodeGetClusterReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route GET /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/compliance project getClusterCompliance
+//
+//     Returns the violations of the OPA Gatekeeper constraints found by the audit of the cluster.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: ClusterComplianceReport
+//       400: errorResponse
+//       401: empty
+//       403: empty
+func (r Routing) getClusterCompliance() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.SetPrivilegedClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(cluster.GetComplianceEndpoint(r.projectProvider)),
+		common.DecodeGetClusterReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
 // swagger:route PUT /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/viewertoken project revokeClusterViewerToken
 //
 //     Revokes the current viewer token
@@ -1756,6 +2175,115 @@ func (r Routing) upgradeClusterNodeDeployments() http.Handler {
 	)
 }
 
+// swagger:route PUT /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/cordon project cordonNode
+//
+//     Marks the node as unschedulable, the pods running on it are not affected.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: NodeDrainStatus
+//       401: empty
+//       403: empty
+func (r Routing) cordonNode() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(node.CordonNodeEndpoint(r.projectProvider)),
+		node.DecodeNodeReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route PUT /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/uncordon project uncordonNode
+//
+//     Marks the node as schedulable again.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: NodeDrainStatus
+//       401: empty
+//       403: empty
+func (r Routing) uncordonNode() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(node.UncordonNodeEndpoint(r.projectProvider)),
+		node.DecodeNodeReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route POST /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/drain project drainNode
+//
+//     Cordons the node and evicts its pods, respecting their PodDisruptionBudgets. Returns when all
+//     pods are gone or the timeout passed, in which case the drain can be continued with another request.
+//     Optionally the machine of the node gets replaced afterwards.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: NodeDrainStatus
+//       401: empty
+//       403: empty
+func (r Routing) drainNode() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(node.DrainNodeEndpoint(r.projectProvider)),
+		node.DecodeDrainNode,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route GET /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodes/{node_id}/drain project getNodeDrainStatus
+//
+//     Reports the progress of draining the node.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: NodeDrainStatus
+//       401: empty
+//       403: empty
+func (r Routing) getNodeDrainStatus() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(node.GetNodeDrainStatusEndpoint(r.projectProvider)),
+		node.DecodeNodeReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
 // swagger:route POST /api/v1/projects/{project_id}/users users addUserToProject
 //
 //     Adds the given user to the given project
@@ -2079,6 +2607,34 @@ func (r Routing) updateServiceAccountToken() http.Handler {
 	)
 }
 
+// swagger:route POST /api/v1/projects/{project_id}/serviceaccounts/{serviceaccount_id}/tokens/{token_id}/rotate tokens rotateServiceAccountToken
+//
+//     Replaces the token, the old token stays valid during the grace period
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: ServiceAccountToken
+//       401: empty
+//       403: empty
+func (r Routing) rotateServiceAccountToken() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(serviceaccount.RotateTokenEndpoint(r.projectProvider, r.serviceAccountProvider, r.serviceAccountTokenProvider, r.saTokenAuthenticator, r.saTokenGenerator)),
+		serviceaccount.DecodeRotateTokenReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
 // swagger:route PATCH /api/v1/projects/{project_id}/serviceaccounts/{serviceaccount_id}/tokens/{token_id} tokens patchServiceAccountToken
 //
 //     Patches the token name
@@ -2652,6 +3208,35 @@ func (r Routing) listNodeDeploymentNodesEvents() http.Handler {
 	)
 }
 
+// swagger:route GET /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}/nodes/events/watch project watchNodeDeploymentNodesEvents
+//
+//     Streams the events of the node deployment, its node sets and nodes as server-sent events. The existing
+//     events are sent first, followed by new and updated ones. Messages of type `event` contain an Event and
+//     messages of type `error` describe failed watches, which are retried. The query parameter `type` filters
+//     the events like for listNodeDeploymentNodesEvents.
+//
+//     Produces:
+//     - text/event-stream
+//
+//     Responses:
+//       default: errorResponse
+//       200: []Event
+//       401: empty
+//       403: empty
+func (r Routing) watchNodeDeploymentNodesEvents() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(node.WatchNodeDeploymentNodesEvents()),
+		node.DecodeListNodeDeploymentNodesEvents,
+		encodeEventStream,
+		r.defaultServerOptions()...,
+	)
+}
+
 // swagger:route PATCH /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id} project patchNodeDeployment
 //
 //     Patches a node deployment that is assigned to the given cluster. Please note that at the moment only
@@ -2682,6 +3267,62 @@ func (r Routing) patchNodeDeployment() http.Handler {
 	)
 }
 
+// swagger:route GET /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}/revisions project listNodeDeploymentRevisions
+//
+//     Lists the revisions of the template of a node deployment, the newest first.
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: []NodeDeploymentRevision
+//       401: empty
+//       403: empty
+func (r Routing) listNodeDeploymentRevisions() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(node.ListNodeDeploymentRevisions(r.projectProvider)),
+		node.DecodeGetNodeDeployment,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
+// swagger:route POST /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id}/rollback project rollbackNodeDeployment
+//
+//     Rolls the template of a node deployment back to a previous revision. The nodes get replaced
+//     like on any other change of the template.
+//
+//     Consumes:
+//     - application/json
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: NodeDeployment
+//       401: empty
+//       403: empty
+func (r Routing) rollbackNodeDeployment() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(node.RollbackNodeDeployment(r.projectProvider)),
+		node.DecodeRollbackNodeDeployment,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
 // swagger:route DELETE /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments/{nodedeployment_id} project deleteNodeDeployment
 //
 //    Deletes the given node deployment that belongs to the cluster.
@@ -3223,6 +3864,33 @@ func (r Routing) listNamespace() http.Handler {
 	)
 }
 
+// swagger:route GET /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/autoscaler project getClusterAutoscalerStatus
+//
+//     Returns the status of the cluster-autoscaler, the scale ups it triggered and the pods that
+//     can't be scheduled
+//
+//     Produces:
+//     - application/json
+//
+//     Responses:
+//       default: errorResponse
+//       200: ClusterAutoscalerStatus
+//       401: empty
+//       403: empty
+func (r Routing) getClusterAutoscalerStatus() http.Handler {
+	return httptransport.NewServer(
+		endpoint.Chain(
+			middleware.TokenVerifier(r.tokenVerifiers),
+			middleware.UserSaver(r.userProvider),
+			middleware.SetClusterProvider(r.clusterProviderGetter, r.seedsGetter),
+			middleware.UserInfoExtractor(r.userProjectMapper),
+		)(cluster.GetAutoscalerStatusEndpoint()),
+		common.DecodeGetClusterReq,
+		encodeJSON,
+		r.defaultServerOptions()...,
+	)
+}
+
 // swagger:route PATCH /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/roles/{namespace}/{role_id} project patchRole
 //
 //     Patch the role with the given name
@@ -22,6 +22,7 @@ type Routing struct {
 	log                         *zap.SugaredLogger
 	seedsGetter                 provider.SeedsGetter
 	sshKeyProvider              provider.SSHKeyProvider
+	clusterTemplateProvider     provider.ClusterTemplateProvider
 	userProvider                provider.UserProvider
 	serviceAccountProvider      provider.ServiceAccountProvider
 	serviceAccountTokenProvider provider.ServiceAccountTokenProvider
@@ -52,6 +53,7 @@ func NewRouting(
 	clusterProviderGetter provider.ClusterProviderGetter,
 	addonProviderGetter provider.AddonProviderGetter,
 	newSSHKeyProvider provider.SSHKeyProvider,
+	clusterTemplateProvider provider.ClusterTemplateProvider,
 	userProvider provider.UserProvider,
 	serviceAccountProvider provider.ServiceAccountProvider,
 	serviceAccountTokenProvider provider.ServiceAccountTokenProvider,
@@ -77,6 +79,7 @@ func NewRouting(
 		clusterProviderGetter:       clusterProviderGetter,
 		addonProviderGetter:         addonProviderGetter,
 		sshKeyProvider:              newSSHKeyProvider,
+		clusterTemplateProvider:     clusterTemplateProvider,
 		userProvider:                userProvider,
 		serviceAccountProvider:      serviceAccountProvider,
 		serviceAccountTokenProvider: serviceAccountTokenProvider,
@@ -104,5 +107,6 @@ func (r Routing) defaultServerOptions() []httptransport.ServerOption {
 		httptransport.ServerErrorLogger(r.logger),
 		httptransport.ServerErrorEncoder(errorEncoder),
 		httptransport.ServerBefore(middleware.TokenExtractor(r.tokenExtractors)),
+		httptransport.ServerBefore(httptransport.PopulateRequestContext),
 	}
 }
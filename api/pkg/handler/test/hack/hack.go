@@ -28,6 +28,7 @@ func NewTestRouting(
 	clusterProvidersGetter provider.ClusterProviderGetter,
 	addonProviderGetter provider.AddonProviderGetter,
 	sshKeyProvider provider.SSHKeyProvider,
+	clusterTemplateProvider provider.ClusterTemplateProvider,
 	userProvider provider.UserProvider,
 	serviceAccountProvider provider.ServiceAccountProvider,
 	serviceAccountTokenProvider provider.ServiceAccountTokenProvider,
@@ -52,6 +53,7 @@ func NewTestRouting(
 		clusterProvidersGetter,
 		addonProviderGetter,
 		sshKeyProvider,
+		clusterTemplateProvider,
 		userProvider,
 		serviceAccountProvider,
 		serviceAccountTokenProvider,
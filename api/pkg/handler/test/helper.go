@@ -47,6 +47,7 @@ import (
 	kubernetesclientset "k8s.io/client-go/kubernetes"
 	fakerestclient "k8s.io/client-go/kubernetes/fake"
 	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
 
 	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
@@ -125,6 +126,7 @@ type newRoutingFunc func(
 	clusterProviderGetter provider.ClusterProviderGetter,
 	addonProviderGetter provider.AddonProviderGetter,
 	newSSHKeyProvider provider.SSHKeyProvider,
+	clusterTemplateProvider provider.ClusterTemplateProvider,
 	userProvider provider.UserProvider,
 	serviceAccountProvider provider.ServiceAccountProvider,
 	serviceAccountTokenProvider provider.ServiceAccountTokenProvider,
@@ -163,6 +165,7 @@ func initTestEndpoint(user apiv1.User, seedsGetter provider.SeedsGetter, kubeObj
 
 	userLister := kubermaticInformerFactory.Kubermatic().V1().Users().Lister()
 	sshKeyProvider := kubernetes.NewSSHKeyProvider(fakeKubermaticImpersonationClient, kubermaticInformerFactory.Kubermatic().V1().UserSSHKeys().Lister())
+	clusterTemplateProvider := kubernetes.NewClusterTemplateProvider(fakeKubermaticImpersonationClient, kubermaticInformerFactory.Kubermatic().V1().ClusterTemplates().Lister())
 	userProvider := kubernetes.NewUserProvider(kubermaticClient, userLister, kubernetes.IsServiceAccount)
 
 	tokenGenerator, err := serviceaccount.JWTTokenGenerator([]byte(TestServiceAccountHashKey))
@@ -213,7 +216,7 @@ func initTestEndpoint(user apiv1.User, seedsGetter provider.SeedsGetter, kubeObj
 		return nil, nil, err
 	}
 
-	fUserClusterConnection := &fakeUserClusterConnection{fakeClient}
+	fUserClusterConnection := &fakeUserClusterConnection{fakeClient, kubernetesClient}
 	clusterProvider := kubernetes.NewClusterProvider(
 		&restclient.Config{},
 		fakeKubermaticImpersonationClient,
@@ -249,7 +252,7 @@ func initTestEndpoint(user apiv1.User, seedsGetter provider.SeedsGetter, kubeObj
 	kubermaticInformerFactory.Start(wait.NeverStop)
 	kubermaticInformerFactory.WaitForCacheSync(wait.NeverStop)
 
-	eventRecorderProvider := kubernetes.NewEventRecorder()
+	eventRecorderProvider := &fakeEventRecorderProvider{}
 
 	// Disable the metrics endpoint in tests
 	var prometheusClient prometheusapi.Client
@@ -259,6 +262,7 @@ func initTestEndpoint(user apiv1.User, seedsGetter provider.SeedsGetter, kubeObj
 		clusterProviderGetter,
 		addonProviderGetter,
 		sshKeyProvider,
+		clusterTemplateProvider,
 		userProvider,
 		serviceAccountProvider,
 		serviceAccountTokenProvider,
@@ -368,12 +372,17 @@ func buildSeeds() provider.SeedsGetter {
 
 type fakeUserClusterConnection struct {
 	fakeDynamicClient ctrlruntimeclient.Client
+	fakeK8sClient     kubernetesclientset.Interface
 }
 
 func (f *fakeUserClusterConnection) GetClient(_ *kubermaticv1.Cluster, _ ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error) {
 	return f.fakeDynamicClient, nil
 }
 
+func (f *fakeUserClusterConnection) GetK8sClient(_ *kubermaticv1.Cluster, _ ...k8cuserclusterclient.ConfigOption) (kubernetesclientset.Interface, error) {
+	return f.fakeK8sClient, nil
+}
+
 func (f *fakeUserClusterConnection) GetAdminKubeconfig(c *kubermaticv1.Cluster) ([]byte, error) {
 	return []byte(generateTestKubeconfig(ClusterID, IDToken)), nil
 }
@@ -396,6 +405,13 @@ type ClientsSets struct {
 	TokenGenerator     serviceaccount.TokenGenerator
 }
 
+// fakeEventRecorderProvider hands out in-memory recorders as the fake clientsets cannot be used to build real ones
+type fakeEventRecorderProvider struct{}
+
+func (f *fakeEventRecorderProvider) ClusterRecorderFor(_ kubernetesclientset.Interface) record.EventRecorder {
+	return record.NewFakeRecorder(100)
+}
+
 // generateTestKubeconfig returns test kubeconfig yaml structure
 func generateTestKubeconfig(clusterID, token string) string {
 	return fmt.Sprintf(`
@@ -782,7 +798,7 @@ func GenDefaultSaToken(projectID, saID, name, id string) *corev1.Secret {
 
 func GenDefaultExpiry() (apiv1.Time, error) {
 	authenticator := serviceaccount.JWTTokenAuthenticator([]byte(TestServiceAccountHashKey))
-	claim, _, err := authenticator.Authenticate(TestFakeToken)
+	claim, _, err := authenticator.Parse(TestFakeToken)
 	if err != nil {
 		return apiv1.Time{}, err
 	}
@@ -0,0 +1,123 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// autoscalerStatusConfigMapName is the name of the ConfigMap the cluster-autoscaler writes its status report to
+	autoscalerStatusConfigMapName = "cluster-autoscaler-status"
+	// autoscalerLastUpdatedAnnotation holds the time the cluster-autoscaler wrote its status report
+	autoscalerLastUpdatedAnnotation = "cluster-autoscaler.kubernetes.io/last-updated"
+	// autoscalerEventSource is the component the cluster-autoscaler emits its events as
+	autoscalerEventSource = "cluster-autoscaler"
+)
+
+// autoscalerScaleUpReasons are the reasons of the events the cluster-autoscaler emits on scale ups
+var autoscalerScaleUpReasons = map[string]bool{
+	"TriggeredScaleUp": true,
+	"ScaledUpGroup":    true,
+}
+
+// GetAutoscalerStatusEndpoint returns the status of the cluster-autoscaler of the cluster
+func GetAutoscalerStatusEndpoint() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(common.GetClusterReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+
+		cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		status := &apiv1.ClusterAutoscalerStatus{
+			Enabled:           kubermaticv1helper.IsClusterAutoscalerEnabled(cluster),
+			ScaleUpEvents:     []apiv1.Event{},
+			UnschedulablePods: []string{},
+		}
+		if !status.Enabled {
+			return status, nil
+		}
+
+		client, err := clusterProvider.GetClientForCustomerCluster(userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		if err := getAutoscalerStatusReport(ctx, client, status); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		events := &corev1.EventList{}
+		if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, events); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		for _, event := range events.Items {
+			if event.Source.Component == autoscalerEventSource && autoscalerScaleUpReasons[event.Reason] {
+				status.ScaleUpEvents = append(status.ScaleUpEvents, common.ConvertInternalEventToExternal(event))
+			}
+		}
+		sort.Slice(status.ScaleUpEvents, func(i, j int) bool {
+			return status.ScaleUpEvents[i].LastTimestamp.Time.Before(status.ScaleUpEvents[j].LastTimestamp.Time)
+		})
+
+		pods := &corev1.PodList{}
+		if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, pods); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		for _, pod := range pods.Items {
+			if isPodUnschedulable(&pod) {
+				status.UnschedulablePods = append(status.UnschedulablePods, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+			}
+		}
+		sort.Strings(status.UnschedulablePods)
+
+		return status, nil
+	}
+}
+
+// getAutoscalerStatusReport fills in the status report, which doesn't exist before the autoscaler ran
+func getAutoscalerStatusReport(ctx context.Context, client ctrlruntimeclient.Client, status *apiv1.ClusterAutoscalerStatus) error {
+	configMap := &corev1.ConfigMap{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: metav1.NamespaceSystem, Name: autoscalerStatusConfigMapName}, configMap); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	status.Status = configMap.Data["status"]
+	// The annotation is formatted like "2019-10-18 09:21:48.567574 +0000 UTC"
+	if lastUpdated, err := time.Parse("2006-01-02 15:04:05.999999999 -0700 MST", configMap.Annotations[autoscalerLastUpdatedAnnotation]); err == nil {
+		t := apiv1.NewTime(lastUpdated)
+		status.LastUpdated = &t
+	}
+	return nil
+}
+
+func isPodUnschedulable(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package cluster_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGetClusterAutoscalerStatus(t *testing.T) {
+	t.Parallel()
+
+	eventTime := metav1.NewTime(time.Date(2019, 10, 18, 9, 21, 0, 0, time.UTC))
+	userClusterObjs := []runtime.Object{
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   metav1.NamespaceSystem,
+				Name:        "cluster-autoscaler-status",
+				Annotations: map[string]string{"cluster-autoscaler.kubernetes.io/last-updated": "2019-10-18 09:21:48.567574 +0000 UTC"},
+			},
+			Data: map[string]string{"status": "Cluster-autoscaler status at 2019-10-18 09:21:48: Health: Healthy"},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "web.1", UID: "scale-up"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web"},
+			Source:         corev1.EventSource{Component: "cluster-autoscaler"},
+			Reason:         "TriggeredScaleUp",
+			Message:        "pod triggered scale-up: [{kube-system/workers 2 to 3 (max: 5)}]",
+			Type:           corev1.EventTypeNormal,
+			LastTimestamp:  eventTime,
+			Count:          1,
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "default", Name: "web.2"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "default", Name: "web"},
+			Source:         corev1.EventSource{Component: "default-scheduler"},
+			Reason:         "FailedScheduling",
+			Type:           corev1.EventTypeWarning,
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable},
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "db"},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+			}},
+		},
+	}
+
+	testcases := []struct {
+		name             string
+		cluster          *kubermaticv1.Cluster
+		expectedResponse string
+	}{
+		{
+			name:             "scenario 1: the autoscaler is not enabled",
+			cluster:          test.GenDefaultCluster(),
+			expectedResponse: `{"enabled":false,"scaleUpEvents":[],"unschedulablePods":[]}`,
+		},
+		{
+			name: "scenario 2: the status of the enabled autoscaler is returned",
+			cluster: func() *kubermaticv1.Cluster {
+				cluster := test.GenDefaultCluster()
+				cluster.Spec.ClusterAutoscaler = &kubermaticv1.ClusterAutoscalerSettings{}
+				return cluster
+			}(),
+			expectedResponse: `{"enabled":true,"status":"Cluster-autoscaler status at 2019-10-18 09:21:48: Health: Healthy","lastUpdated":"2019-10-18T09:21:48Z","scaleUpEvents":[{"id":"scale-up","name":"web.1","creationTimestamp":"0001-01-01T00:00:00Z","message":"pod triggered scale-up: [{kube-system/workers 2 to 3 (max: 5)}]","type":"Normal","involvedObject":{"type":"Pod","namespace":"default","name":"web"},"lastTimestamp":"2019-10-18T09:21:00Z","count":1}],"unschedulablePods":["default/web"]}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/autoscaler", test.ProjectName, tc.cluster.Name), nil)
+			res := httptest.NewRecorder()
+			ep, _, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, userClusterObjs, []runtime.Object{}, test.GenDefaultKubermaticObjects(tc.cluster), nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(http.StatusOK, res, t)
+			test.CompareWithResult(t, res, tc.expectedResponse)
+		})
+	}
+}
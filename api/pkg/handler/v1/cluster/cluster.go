@@ -7,8 +7,6 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
-	"strings"
-	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/go-kit/kit/endpoint"
@@ -17,6 +15,7 @@ import (
 
 	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	kubermaticv1helper "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1/helper"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/label"
@@ -24,7 +23,6 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/provider"
 	kubernetesprovider "github.com/kubermatic/kubermatic/api/pkg/provider/kubernetes"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/cluster"
-	machineresource "github.com/kubermatic/kubermatic/api/pkg/resources/machine"
 	"github.com/kubermatic/kubermatic/api/pkg/util/errors"
 	kubermaticerrors "github.com/kubermatic/kubermatic/api/pkg/util/errors"
 	"github.com/kubermatic/kubermatic/api/pkg/validation"
@@ -32,8 +30,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/rand"
-	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
-	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/klog"
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
@@ -41,15 +38,6 @@ import (
 	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-// NodeDeploymentEvent represents type of events related to Node Deployment
-type NodeDeploymentEvent string
-
-const (
-	nodeDeploymentCreationStart   NodeDeploymentEvent = "NodeDeploymentCreationStart"
-	nodeDeploymentCreationSuccess NodeDeploymentEvent = "NodeDeploymentCreationSuccess"
-	nodeDeploymentCreationFail    NodeDeploymentEvent = "NodeDeploymentCreationFail"
-)
-
 // clusterTypes holds a list of supported cluster types
 var clusterTypes = []string{
 	apiv1.OpenShiftClusterType,
@@ -57,186 +45,219 @@ var clusterTypes = []string{
 }
 
 func CreateEndpoint(sshKeyProvider provider.SSHKeyProvider, projectProvider provider.ProjectProvider, seedsGetter provider.SeedsGetter,
-	initNodeDeploymentFailures *prometheus.CounterVec, eventRecorderProvider provider.EventRecorderProvider, credentialManager common.PresetsManager, exposeStrategy corev1.ServiceType) endpoint.Endpoint {
+	clusterProviderGetter provider.ClusterProviderGetter, addonProviderGetter provider.AddonProviderGetter, initNodeDeploymentFailures *prometheus.CounterVec, eventRecorderProvider provider.EventRecorderProvider, credentialManager common.PresetsManager, exposeStrategy corev1.ServiceType) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(CreateReq)
-		err := req.Validate()
-		if err != nil {
-			return nil, errors.NewBadRequest(err.Error())
+		initialResources := InitialResources{RollbackOnFailure: req.Body.RollbackOnFailure}
+		if req.Body.NodeDeployment != nil {
+			initialResources.NodeDeployments = append(initialResources.NodeDeployments, req.Body.NodeDeployment)
 		}
-		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
-		privilegedClusterProvider := ctx.Value(middleware.PrivilegedClusterProviderContextKey).(provider.PrivilegedClusterProvider)
-		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
-		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
-		k8sClient := privilegedClusterProvider.GetSeedClusterAdminClient()
-		if err != nil {
-			return nil, common.KubernetesErrorToHTTPError(err)
+		for i := range req.Body.NodeDeployments {
+			initialResources.NodeDeployments = append(initialResources.NodeDeployments, &req.Body.NodeDeployments[i])
 		}
-
-		if req.Body.Cluster.ID != "" {
-			return nil, errors.New(int(http.StatusBadRequest), "cluster.ID is read-only")
+		for i := range req.Body.Addons {
+			initialResources.Addons = append(initialResources.Addons, &req.Body.Addons[i])
 		}
+		return CreateCluster(ctx, req, initialResources, sshKeyProvider, projectProvider, seedsGetter, clusterProviderGetter, addonProviderGetter, initNodeDeploymentFailures, eventRecorderProvider, credentialManager, exposeStrategy)
+	}
+}
 
-		_, dc, err := provider.DatacenterFromSeedMap(userInfo, seedsGetter, req.Body.Cluster.Spec.Cloud.DatacenterName)
-		if err != nil {
-			return nil, common.KubernetesErrorToHTTPError(err)
-		}
+// CreateCluster creates the cluster described by the given request along with the given initial resources.
+// The SSH keys are assigned right away, the node deployments and the addons are created in the background
+// and the progress is tracked in the creation status of the cluster.
+// If the seed of the requested datacenter has no capacity left, the cluster gets created on another seed.
+func CreateCluster(ctx context.Context, req CreateReq, initialResources InitialResources, sshKeyProvider provider.SSHKeyProvider, projectProvider provider.ProjectProvider, seedsGetter provider.SeedsGetter,
+	clusterProviderGetter provider.ClusterProviderGetter, addonProviderGetter provider.AddonProviderGetter, initNodeDeploymentFailures *prometheus.CounterVec, eventRecorderProvider provider.EventRecorderProvider, credentialManager common.PresetsManager, exposeStrategy corev1.ServiceType) (*apiv1.Cluster, error) {
+	err := req.Validate()
+	if err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+	privilegedClusterProvider := ctx.Value(middleware.PrivilegedClusterProviderContextKey).(provider.PrivilegedClusterProvider)
+	userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+	project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+	k8sClient := privilegedClusterProvider.GetSeedClusterAdminClient()
+	if err != nil {
+		return nil, common.KubernetesErrorToHTTPError(err)
+	}
 
-		credentialName := req.Body.Cluster.Credential
-		if len(credentialName) > 0 {
-			cloudSpec, err := credentialManager.SetCloudCredentials(userInfo, credentialName, req.Body.Cluster.Spec.Cloud, dc)
-			if err != nil {
-				return nil, errors.NewBadRequest("invalid credentials: %v", err)
-			}
-			req.Body.Cluster.Spec.Cloud = *cloudSpec
-		}
+	if req.Body.Cluster.ID != "" {
+		return nil, errors.New(int(http.StatusBadRequest), "cluster.ID is read-only")
+	}
 
-		// Create the cluster.
-		secretKeyGetter := provider.SecretKeySelectorValueFuncFactory(ctx, privilegedClusterProvider.GetSeedClusterAdminRuntimeClient())
-		spec, err := cluster.Spec(req.Body.Cluster, dc, secretKeyGetter)
-		if err != nil {
-			return nil, errors.NewBadRequest("invalid cluster: %v", err)
-		}
-		spec.ExposeStrategy = exposeStrategy
+	sshKeys, err := getProjectSSHKeys(userInfo, project, initialResources.SSHKeys, sshKeyProvider)
+	if err != nil {
+		return nil, err
+	}
 
-		existingClusters, err := clusterProvider.List(project, &provider.ClusterListOptions{ClusterSpecName: spec.HumanReadableName})
-		if err != nil {
-			return nil, common.KubernetesErrorToHTTPError(err)
-		}
+	seed, dc, err := provider.DatacenterFromSeedMap(userInfo, seedsGetter, req.Body.Cluster.Spec.Cloud.DatacenterName)
+	if err != nil {
+		return nil, common.KubernetesErrorToHTTPError(err)
+	}
 
-		if len(existingClusters.Items) > 0 {
-			return nil, errors.NewAlreadyExists("cluster", spec.HumanReadableName)
+	var addonProvider provider.AddonProvider
+	if len(initialResources.Addons) > 0 {
+		var ok bool
+		addonProvider, ok = ctx.Value(middleware.AddonProviderContextKey).(provider.AddonProvider)
+		if !ok {
+			return nil, errors.New(http.StatusInternalServerError, "no addon provider in request")
 		}
+	}
 
-		partialCluster := &kubermaticv1.Cluster{}
-		partialCluster.Labels = req.Body.Cluster.Labels
-		partialCluster.Spec = *spec
-		if req.Body.Cluster.Type == "openshift" {
-			if req.Body.Cluster.Spec.Openshift == nil || req.Body.Cluster.Spec.Openshift.ImagePullSecret == "" {
-				return nil, errors.NewBadRequest("openshift clusters must be configured with an imagePullSecret")
-			}
-			partialCluster.Annotations = map[string]string{
-				"kubermatic.io/openshift": "true",
+	redirectedSeed, err := placeCluster(&req, seed, seedsGetter, clusterProviderGetter)
+	if err != nil {
+		return nil, err
+	}
+	if redirectedSeed != nil {
+		datacenter := redirectedSeed.Spec.Datacenters[req.Body.Cluster.Spec.Cloud.DatacenterName]
+		dc = &datacenter
+		if clusterProvider, err = clusterProviderGetter(redirectedSeed); err != nil {
+			return nil, errors.New(http.StatusInternalServerError, fmt.Sprintf("failed to get cluster provider for seed %s: %v", redirectedSeed.Name, err))
+		}
+		privilegedClusterProvider = clusterProvider.(provider.PrivilegedClusterProvider)
+		k8sClient = privilegedClusterProvider.GetSeedClusterAdminClient()
+		if addonProvider != nil {
+			if addonProvider, err = addonProviderGetter(redirectedSeed); err != nil {
+				return nil, errors.New(http.StatusInternalServerError, fmt.Sprintf("failed to get addon provider for seed %s: %v", redirectedSeed.Name, err))
 			}
 		}
-		// generate the name here so that it can be used in the secretName below
-		partialCluster.Name = rand.String(10)
-
-		if err := kubernetesprovider.CreateCredentialSecretForCluster(ctx, privilegedClusterProvider.GetSeedClusterAdminRuntimeClient(), partialCluster, req.ProjectID); err != nil {
-			return nil, err
-		}
-		kuberneteshelper.AddFinalizer(partialCluster, apiv1.CredentialsSecretsCleanupFinalizer)
+	}
 
-		newCluster, err := clusterProvider.New(project, userInfo, partialCluster)
+	credentialName := req.Body.Cluster.Credential
+	if len(credentialName) > 0 {
+		cloudSpec, err := credentialManager.SetCloudCredentials(userInfo, credentialName, req.Body.Cluster.Spec.Cloud, dc)
 		if err != nil {
-			return nil, common.KubernetesErrorToHTTPError(err)
+			return nil, errors.NewBadRequest("invalid credentials: %v", err)
 		}
+		req.Body.Cluster.Spec.Cloud = *cloudSpec
+	}
 
-		// Create the initial node deployment in the background.
-		if req.Body.NodeDeployment != nil && req.Body.NodeDeployment.Spec.Replicas > 0 {
-			// for BringYourOwn provider we don't create ND
-			isBYO, err := common.IsBringYourOwnProvider(spec.Cloud)
-			if err != nil {
-				return nil, errors.NewBadRequest("failed to create an initial node deployment due to an invalid spec: %v", err)
-			}
-			if isBYO {
-				klog.V(5).Infof("KubeAdm provider detected an initial node deployment won't be created for cluster %s", newCluster.Name)
-				return convertInternalClusterToExternal(newCluster), nil
-			}
+	// Create the cluster.
+	secretKeyGetter := provider.SecretKeySelectorValueFuncFactory(ctx, privilegedClusterProvider.GetSeedClusterAdminRuntimeClient())
+	spec, err := cluster.Spec(req.Body.Cluster, dc, secretKeyGetter)
+	if err != nil {
+		return nil, errors.NewBadRequest("invalid cluster: %v", err)
+	}
+	spec.ExposeStrategy = exposeStrategy
 
-			go func() {
-				defer utilruntime.HandleCrash()
-				ndName := getNodeDeploymentDisplayName(req.Body.NodeDeployment)
-				eventRecorderProvider.ClusterRecorderFor(k8sClient).Eventf(newCluster, corev1.EventTypeNormal, string(nodeDeploymentCreationStart), "Started creation of initial node deployment %s", ndName)
-				err := createInitialNodeDeploymentWithRetries(req.Body.NodeDeployment, newCluster, project, sshKeyProvider, seedsGetter, clusterProvider, userInfo)
-				if err != nil {
-					eventRecorderProvider.ClusterRecorderFor(k8sClient).Eventf(newCluster, corev1.EventTypeWarning, string(nodeDeploymentCreationFail), "Failed to create initial node deployment %s: %v", ndName, err)
-					klog.Errorf("failed to create initial node deployment for cluster %s: %v", newCluster.Name, err)
-					initNodeDeploymentFailures.With(prometheus.Labels{"cluster": newCluster.Name, "datacenter": req.Body.Cluster.Spec.Cloud.DatacenterName}).Add(1)
-				} else {
-					eventRecorderProvider.ClusterRecorderFor(k8sClient).Eventf(newCluster, corev1.EventTypeNormal, string(nodeDeploymentCreationSuccess), "Successfully created initial node deployment %s", ndName)
-					klog.V(5).Infof("created initial node deployment for cluster %s", newCluster.Name)
-				}
-			}()
+	nodeDeployments := []*apiv1.NodeDeployment{}
+	for _, nodeDeployment := range initialResources.NodeDeployments {
+		if nodeDeployment.Spec.Replicas > 0 {
+			nodeDeployments = append(nodeDeployments, nodeDeployment)
 		}
-
-		return convertInternalClusterToExternal(newCluster), nil
 	}
-}
-
-func createInitialNodeDeploymentWithRetries(nodeDeployment *apiv1.NodeDeployment, cluster *kubermaticv1.Cluster,
-	project *kubermaticv1.Project, sshKeyProvider provider.SSHKeyProvider,
-	seedsGetter provider.SeedsGetter, clusterProvider provider.ClusterProvider, userInfo *provider.UserInfo) error {
-	return wait.Poll(5*time.Second, 30*time.Minute, func() (bool, error) {
-		err := createInitialNodeDeployment(nodeDeployment, cluster, project, sshKeyProvider, seedsGetter, clusterProvider, userInfo)
+	if len(nodeDeployments) > 0 {
+		// for BringYourOwn provider we don't create ND
+		isBYO, err := common.IsBringYourOwnProvider(spec.Cloud)
 		if err != nil {
-			// unrecoverable
-			if strings.Contains(err.Error(), `admission webhook "machine-controller.kubermatic.io-machinedeployments" denied the request`) {
-				klog.V(4).Infof("giving up creating initial Node Deployments for cluster %s (%s) due to an unrecoverabl err %#v", cluster.Name, cluster.Spec.HumanReadableName, err)
-				return false, err
-			}
-			// Likely recoverable
-			klog.V(4).Infof("retrying creating initial Node Deployments for cluster %s (%s) due to %v", cluster.Name, cluster.Spec.HumanReadableName, err)
-			return false, nil
+			return nil, errors.NewBadRequest("failed to create an initial node deployment due to an invalid spec: %v", err)
 		}
-		return true, nil
-	})
-}
-
-func createInitialNodeDeployment(nodeDeployment *apiv1.NodeDeployment, cluster *kubermaticv1.Cluster,
-	project *kubermaticv1.Project, sshKeyProvider provider.SSHKeyProvider,
-	seedsGetter provider.SeedsGetter, clusterProvider provider.ClusterProvider, userInfo *provider.UserInfo) error {
-	ctx, cancelCtx := context.WithCancel(context.Background())
-	defer cancelCtx()
+		if isBYO {
+			klog.V(5).Infof("KubeAdm provider detected an initial node deployment won't be created for cluster %s", spec.HumanReadableName)
+			nodeDeployments = nil
+		}
+	}
+	initialResources.NodeDeployments = nodeDeployments
+	if err := initialResources.validate(spec.Version.Semver()); err != nil {
+		return nil, errors.NewBadRequest("%v", err)
+	}
 
-	nd, err := machineresource.Validate(nodeDeployment, cluster.Spec.Version.Semver())
+	existingClusters, err := clusterProvider.List(project, &provider.ClusterListOptions{ClusterSpecName: spec.HumanReadableName})
 	if err != nil {
-		return fmt.Errorf("node deployment is not valid: %v", err)
+		return nil, common.KubernetesErrorToHTTPError(err)
 	}
 
-	cluster, err = clusterProvider.Get(userInfo, cluster.Name, &provider.ClusterGetOptions{CheckInitStatus: true})
-	if err != nil {
-		return err
+	if len(existingClusters.Items) > 0 {
+		return nil, errors.NewAlreadyExists("cluster", spec.HumanReadableName)
 	}
 
-	keys, err := sshKeyProvider.List(project, &provider.SSHKeyListOptions{ClusterName: cluster.Name})
-	if err != nil {
-		return err
+	partialCluster := &kubermaticv1.Cluster{}
+	partialCluster.Labels = req.Body.Cluster.Labels
+	partialCluster.Spec = *spec
+	if req.Body.Cluster.Type == "openshift" {
+		if req.Body.Cluster.Spec.Openshift == nil || req.Body.Cluster.Spec.Openshift.ImagePullSecret == "" {
+			return nil, errors.NewBadRequest("openshift clusters must be configured with an imagePullSecret")
+		}
+		partialCluster.Annotations = map[string]string{
+			"kubermatic.io/openshift": "true",
+		}
 	}
+	// generate the name here so that it can be used in the secretName below
+	partialCluster.Name = rand.String(10)
 
-	client, err := clusterProvider.GetClientForCustomerCluster(userInfo, cluster)
-	if err != nil {
-		return err
+	if err := kubernetesprovider.CreateCredentialSecretForCluster(ctx, privilegedClusterProvider.GetSeedClusterAdminRuntimeClient(), partialCluster, req.ProjectID); err != nil {
+		return nil, err
+	}
+	kuberneteshelper.AddFinalizer(partialCluster, apiv1.CredentialsSecretsCleanupFinalizer)
+	if len(initialResources.NodeDeployments) > 0 || len(initialResources.Addons) > 0 {
+		partialCluster.Status.Creation = newClusterCreationStatus(initialResources.NodeDeployments, initialResources.Addons, initialResources.RollbackOnFailure)
 	}
 
-	_, dc, err := provider.DatacenterFromSeedMap(userInfo, seedsGetter, cluster.Spec.Cloud.DatacenterName)
+	newCluster, err := clusterProvider.New(project, userInfo, partialCluster)
 	if err != nil {
-		return fmt.Errorf("error getting dc: %v", err)
+		return nil, common.KubernetesErrorToHTTPError(err)
 	}
 
-	assertedClusterProvider, ok := clusterProvider.(*kubernetesprovider.ClusterProvider)
-	if !ok {
-		return errors.New(http.StatusInternalServerError, "clusterprovider is not a kubernetesprovider.Clusterprovider, can not create secret")
-	}
-	data := common.CredentialsData{
-		Ctx:               ctx,
-		KubermaticCluster: cluster,
-		Client:            assertedClusterProvider.GetSeedClusterAdminRuntimeClient(),
+	// Assign the SSH keys before the node deployments are created so that the machines get them.
+	for _, sshKey := range sshKeys {
+		if sshKey.IsUsedByCluster(newCluster.Name) {
+			continue
+		}
+		sshKey.AddToCluster(newCluster.Name)
+		if _, err := sshKeyProvider.Update(userInfo, sshKey); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
 	}
-	md, err := machineresource.Deployment(cluster, nd, dc, keys, data)
-	if err != nil {
-		return err
+
+	// Create the initial node deployments and addons in the background.
+	if len(initialResources.NodeDeployments) > 0 || len(initialResources.Addons) > 0 {
+		creator := &initialResourcesCreator{
+			cluster:                    newCluster,
+			project:                    project,
+			userInfo:                   userInfo,
+			nodeDeployments:            initialResources.NodeDeployments,
+			addons:                     initialResources.Addons,
+			rollbackOnFailure:          initialResources.RollbackOnFailure,
+			sshKeyProvider:             sshKeyProvider,
+			seedsGetter:                seedsGetter,
+			clusterProvider:            clusterProvider,
+			privilegedClusterProvider:  privilegedClusterProvider,
+			addonProvider:              addonProvider,
+			recorder:                   eventRecorderProvider.ClusterRecorderFor(k8sClient),
+			initNodeDeploymentFailures: initNodeDeploymentFailures,
+		}
+		go creator.run()
 	}
 
-	return client.Create(ctx, md)
+	return convertInternalClusterToExternal(newCluster), nil
 }
 
-func getNodeDeploymentDisplayName(nd *apiv1.NodeDeployment) string {
-	if len(nd.Name) != 0 {
-		return " " + nd.Name
+// getProjectSSHKeys returns the keys with the given IDs, it fails if any of them doesn't belong to the given project
+func getProjectSSHKeys(userInfo *provider.UserInfo, project *kubermaticv1.Project, keyIDs []string, sshKeyProvider provider.SSHKeyProvider) ([]*kubermaticv1.UserSSHKey, error) {
+	if len(keyIDs) == 0 {
+		return nil, nil
+	}
+	projectSSHKeys, err := sshKeyProvider.List(project, nil)
+	if err != nil {
+		return nil, common.KubernetesErrorToHTTPError(err)
+	}
+	projectSSHKeyNames := sets.NewString()
+	for _, projectSSHKey := range projectSSHKeys {
+		projectSSHKeyNames.Insert(projectSSHKey.Name)
 	}
 
-	return ""
+	keys := []*kubermaticv1.UserSSHKey{}
+	for _, keyID := range keyIDs {
+		if !projectSSHKeyNames.Has(keyID) {
+			return nil, errors.NewBadRequest("the given ssh key %s does not belong to the given project %s (%s)", keyID, project.Spec.Name, project.Name)
+		}
+		sshKey, err := sshKeyProvider.Get(userInfo, keyID)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		keys = append(keys, sshKey)
+	}
+	return keys, nil
 }
 
 func GetEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
@@ -356,6 +377,13 @@ func PatchEndpoint(projectProvider provider.ProjectProvider, seedsGetter provide
 		newInternalCluster.Spec.OIDC = patchedCluster.Spec.OIDC
 		newInternalCluster.Spec.UsePodSecurityPolicyAdmissionPlugin = patchedCluster.Spec.UsePodSecurityPolicyAdmissionPlugin
 		newInternalCluster.Spec.AuditLogging = patchedCluster.Spec.AuditLogging
+		newInternalCluster.Spec.Hibernation = patchedCluster.Spec.Hibernation
+		newInternalCluster.Spec.ClusterAutoscaler = patchedCluster.Spec.ClusterAutoscaler
+		newInternalCluster.Spec.EncryptionAtRest = patchedCluster.Spec.EncryptionAtRest
+		newInternalCluster.Spec.AdmissionPlugins = patchedCluster.Spec.AdmissionPlugins
+		newInternalCluster.Spec.ExtraFlags = patchedCluster.Spec.ExtraFlags
+		newInternalCluster.Spec.OPAIntegration = patchedCluster.Spec.OPAIntegration
+		newInternalCluster.Spec.ClusterNetwork.TunnelingMode = patchedCluster.Spec.TunnelingMode
 		newInternalCluster.Spec.Openshift = patchedCluster.Spec.Openshift
 
 		incompatibleKubelets, err := common.CheckClusterVersionSkew(ctx, userInfo, clusterProvider, newInternalCluster)
@@ -529,18 +557,34 @@ func HealthEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint
 		if err != nil {
 			return nil, common.KubernetesErrorToHTTPError(err)
 		}
-		return apiv1.ClusterHealth{
-			Apiserver:                    existingCluster.Status.ExtendedHealth.Apiserver,
-			Scheduler:                    existingCluster.Status.ExtendedHealth.Scheduler,
-			Controller:                   existingCluster.Status.ExtendedHealth.Controller,
-			MachineController:            existingCluster.Status.ExtendedHealth.MachineController,
-			Etcd:                         existingCluster.Status.ExtendedHealth.Etcd,
-			CloudProviderInfrastructure:  existingCluster.Status.ExtendedHealth.CloudProviderInfrastructure,
-			UserClusterControllerManager: existingCluster.Status.ExtendedHealth.UserClusterControllerManager,
-		}, nil
+		return convertInternalHealthToExternal(existingCluster), nil
 	}
 }
 
+func convertInternalHealthToExternal(cluster *kubermaticv1.Cluster) apiv1.ClusterHealth {
+	health := apiv1.ClusterHealth{
+		Apiserver:                    cluster.Status.ExtendedHealth.Apiserver,
+		Scheduler:                    cluster.Status.ExtendedHealth.Scheduler,
+		Controller:                   cluster.Status.ExtendedHealth.Controller,
+		MachineController:            cluster.Status.ExtendedHealth.MachineController,
+		Etcd:                         cluster.Status.ExtendedHealth.Etcd,
+		CloudProviderInfrastructure:  cluster.Status.ExtendedHealth.CloudProviderInfrastructure,
+		UserClusterControllerManager: cluster.Status.ExtendedHealth.UserClusterControllerManager,
+	}
+	for _, conditionType := range kubermaticv1.HealthProbeConditionTypes {
+		if _, condition := kubermaticv1helper.GetClusterCondition(cluster, conditionType); condition != nil {
+			health.Probes = append(health.Probes, apiv1.ClusterHealthProbe{
+				Name:               string(condition.Type),
+				Status:             string(condition.Status),
+				Reason:             condition.Reason,
+				Message:            condition.Message,
+				LastTransitionTime: apiv1.NewTime(condition.LastTransitionTime.Time),
+			})
+		}
+	}
+	return health
+}
+
 func AssignSSHKeyEndpoint(sshKeyProvider provider.SSHKeyProvider, projectProvider provider.ProjectProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(AssignSSHKeysReq)
@@ -650,14 +694,35 @@ func convertInternalClusterToExternal(internalCluster *kubermaticv1.Cluster) *ap
 			OIDC:                                internalCluster.Spec.OIDC,
 			AuditLogging:                        internalCluster.Spec.AuditLogging,
 			UsePodSecurityPolicyAdmissionPlugin: internalCluster.Spec.UsePodSecurityPolicyAdmissionPlugin,
+			Hibernation:                         internalCluster.Spec.Hibernation,
+			ClusterAutoscaler:                   internalCluster.Spec.ClusterAutoscaler,
+			EncryptionAtRest:                    internalCluster.Spec.EncryptionAtRest,
+			AdmissionPlugins:                    internalCluster.Spec.AdmissionPlugins,
+			ExtraFlags:                          internalCluster.Spec.ExtraFlags,
+			OPAIntegration:                      internalCluster.Spec.OPAIntegration,
+			TunnelingMode:                       internalCluster.Spec.ClusterNetwork.TunnelingMode,
 		},
 		Status: apiv1.ClusterStatus{
-			Version: internalCluster.Spec.Version,
-			URL:     internalCluster.Address.URL,
+			Version:  internalCluster.Spec.Version,
+			URL:      internalCluster.Address.URL,
+			Creation: convertInternalCreationStatusToExternal(internalCluster.Status.Creation),
 		},
 		Type: apiv1.KubernetesClusterType,
 	}
 
+	if internalCluster.Status.Hibernation != nil {
+		cluster.Status.HibernationPhase = string(internalCluster.Status.Hibernation.Phase)
+	}
+	if internalCluster.Status.RootCARotation != nil {
+		cluster.Status.RootCARotationPhase = string(internalCluster.Status.RootCARotation.Phase)
+	}
+	if internalCluster.Status.EncryptionAtRest != nil {
+		cluster.Status.EncryptionPhase = string(internalCluster.Status.EncryptionAtRest.Phase)
+	}
+	if internalCluster.Status.Tunneling != nil {
+		cluster.Status.TunnelingPhase = string(internalCluster.Status.Tunneling.Phase)
+	}
+
 	isOpenShift, ok := internalCluster.Annotations["kubermatic.io/openshift"]
 	if ok && isOpenShift == "true" {
 		cluster.Type = apiv1.OpenShiftClusterType
@@ -1068,6 +1133,81 @@ func RevokeAdminTokenEndpoint(projectProvider provider.ProjectProvider) endpoint
 	}
 }
 
+// RotateRootCAEndpoint requests a rotation of the root CA of the cluster
+func RotateRootCAEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(common.GetClusterReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		if _, isOpenShift := cluster.Annotations["kubermatic.io/openshift"]; isOpenShift {
+			return nil, errors.NewBadRequest("rotating the root CA is not supported for openshift clusters")
+		}
+		if _, requested := cluster.Annotations[kubermaticv1.AnnotationNameRootCARotationRequested]; requested || cluster.Status.RootCARotation.InProgress() {
+			return nil, errors.New(http.StatusConflict, "a rotation of the root CA is in progress already")
+		}
+
+		if cluster.Annotations == nil {
+			cluster.Annotations = map[string]string{}
+		}
+		cluster.Annotations[kubermaticv1.AnnotationNameRootCARotationRequested] = "true"
+
+		updatedCluster, err := clusterProvider.Update(project, userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return convertInternalClusterToExternal(updatedCluster), nil
+	}
+}
+
+// RotateEncryptionKeyEndpoint requests a rotation of the key which encrypts the Secrets of the cluster
+func RotateEncryptionKeyEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(common.GetClusterReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		if cluster.Spec.EncryptionAtRest == nil {
+			return nil, errors.NewBadRequest("the encryption at rest is not enabled for this cluster")
+		}
+		if _, requested := cluster.Annotations[kubermaticv1.AnnotationNameEncryptionKeyRotationRequested]; requested ||
+			cluster.Status.EncryptionAtRest == nil || cluster.Status.EncryptionAtRest.InProgress() {
+			return nil, errors.New(http.StatusConflict, "the Secrets of the cluster are being encrypted already")
+		}
+
+		if cluster.Annotations == nil {
+			cluster.Annotations = map[string]string{}
+		}
+		cluster.Annotations[kubermaticv1.AnnotationNameEncryptionKeyRotationRequested] = "true"
+
+		updatedCluster, err := clusterProvider.Update(project, userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return convertInternalClusterToExternal(updatedCluster), nil
+	}
+}
+
 func RevokeViewerTokenEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(AdminTokenReq)
@@ -1140,8 +1280,8 @@ func getClusters(clusterProvider provider.ClusterProvider, userInfo *provider.Us
 	return apiClusters, nil
 }
 
-// EventsReq defines HTTP request for getClusterEvents endpoint
-// swagger:parameters getClusterEvents
+// EventsReq defines HTTP request for getClusterEvents and watchClusterEvents endpoints
+// swagger:parameters getClusterEvents watchClusterEvents
 type EventsReq struct {
 	common.GetClusterReq
 
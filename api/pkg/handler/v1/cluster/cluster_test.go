@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"sort"
 	"strings"
 	"testing"
@@ -809,6 +810,84 @@ func TestCreateClusterEndpoint(t *testing.T) {
 	}
 }
 
+func TestCreateClusterWithInitialResourcesEndpoint(t *testing.T) {
+	t.Parallel()
+	nodeDeployment := `{"name":"%s","spec":{"replicas":1,"template":{"cloud":{"digitalocean":{"size":"s-1vcpu-1gb"}},"operatingSystem":{"ubuntu":{}}}}}`
+	testcases := []struct {
+		Name             string
+		Body             string
+		HTTPStatus       int
+		ExpectedResponse string
+		ExpectedCreation *apiv1.ClusterCreationStatus
+	}{
+		{
+			Name: "scenario 1: the creation of the node deployments and addons is tracked in the cluster status",
+			Body: fmt.Sprintf(`{"cluster":{"name":"keen-snyder","spec":{"version":"1.15.5","cloud":{"fake":{"token":"dummy_token"},"dc":"fake-dc"}}},"nodeDeployment":%s,"nodeDeployments":[%s,%s],"addons":[{"name":"dashboard"}],"rollbackOnFailure":true}`,
+				fmt.Sprintf(nodeDeployment, "legacy"), fmt.Sprintf(nodeDeployment, "workers"), fmt.Sprintf(nodeDeployment, "")),
+			HTTPStatus: http.StatusCreated,
+			ExpectedCreation: &apiv1.ClusterCreationStatus{
+				Phase:             "InProgress",
+				RollbackOnFailure: true,
+				Resources: []apiv1.ClusterCreationResourceStatus{
+					{Kind: "NodeDeployment", Name: "legacy", Phase: "Pending"},
+					{Kind: "NodeDeployment", Name: "workers", Phase: "Pending"},
+					{Kind: "NodeDeployment", Name: "", Phase: "Pending"},
+					{Kind: "Addon", Name: "dashboard", Phase: "Pending"},
+				},
+			},
+		},
+		{
+			Name:             "scenario 2: the cluster is rejected when an addon is requested more than once",
+			Body:             `{"cluster":{"name":"keen-snyder","spec":{"version":"1.15.5","cloud":{"fake":{"token":"dummy_token"},"dc":"fake-dc"}}},"addons":[{"name":"dashboard"},{"name":"dashboard"}]}`,
+			HTTPStatus:       http.StatusBadRequest,
+			ExpectedResponse: `{"error":{"code":400,"message":"addon dashboard is defined more than once"}}`,
+		},
+		{
+			Name:             "scenario 3: the cluster is rejected when a node deployment is not valid",
+			Body:             `{"cluster":{"name":"keen-snyder","spec":{"version":"1.15.5","cloud":{"fake":{"token":"dummy_token"},"dc":"fake-dc"}}},"nodeDeployments":[{"name":"workers","spec":{"replicas":1,"template":{"cloud":{},"operatingSystem":{}}}}]}`,
+			HTTPStatus:       http.StatusBadRequest,
+			ExpectedResponse: `{"error":{"code":400,"message":"node deployment workers is not valid: node deployment needs to have cloud provider data"}}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters", test.GenDefaultProject().Name), strings.NewReader(tc.Body))
+			res := httptest.NewRecorder()
+			ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, test.GenDefaultKubermaticObjects(), nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			if res.Code != tc.HTTPStatus {
+				t.Fatalf("Expected HTTP status code %d, got %d: %s", tc.HTTPStatus, res.Code, res.Body.String())
+			}
+			if len(tc.ExpectedResponse) > 0 {
+				test.CompareWithResult(t, res, tc.ExpectedResponse)
+				return
+			}
+
+			actualCluster := &apiv1.Cluster{}
+			if err := json.Unmarshal(res.Body.Bytes(), actualCluster); err != nil {
+				t.Fatal(err)
+			}
+			creation := actualCluster.Status.Creation
+			if creation == nil {
+				t.Fatal("expected the creation status to be set")
+			}
+			if creation.StartTime.IsZero() {
+				t.Error("expected the start time of the creation to be set")
+			}
+			creation.StartTime = apiv1.Time{}
+			if !reflect.DeepEqual(creation, tc.ExpectedCreation) {
+				t.Fatalf("expected creation status %+v, got %+v", tc.ExpectedCreation, creation)
+			}
+		})
+	}
+}
+
 func TestGetClusterHealth(t *testing.T) {
 	t.Parallel()
 	testcases := []struct {
@@ -850,6 +929,42 @@ func TestGetClusterHealth(t *testing.T) {
 			),
 			ExistingAPIUser: test.GenDefaultAPIUser(),
 		},
+		// scenario 2
+		{
+			Name:             "scenario 2: get the health probe results with the reasons of failures",
+			Body:             ``,
+			ExpectedResponse: `{"apiserver":1,"scheduler":1,"controller":1,"machineController":1,"etcd":1,"cloudProviderInfrastructure":1,"userClusterControllerManager":1,"probes":[{"name":"ApiserverHealthy","status":"True","reason":"ProbeSucceeded","message":"All healthz and readyz checks passed","lastTransitionTime":"2013-02-03T19:54:00Z"},{"name":"EtcdHealthy","status":"False","reason":"AlarmsRaised","message":"alarm NOSPACE raised by member 1234","lastTransitionTime":"2013-02-03T20:54:00Z"}]}`,
+			HTTPStatus:       http.StatusOK,
+			ClusterToGet:     "keen-snyder",
+			ProjectToSync:    test.GenDefaultProject().Name,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				func() *kubermaticv1.Cluster {
+					cluster := test.GenCluster("keen-snyder", "clusterAbc", test.GenDefaultProject().Name, time.Date(2013, 02, 03, 19, 54, 0, 0, time.UTC))
+					cluster.Status.Conditions = []kubermaticv1.ClusterCondition{
+						{
+							Type:   kubermaticv1.ClusterConditionSeedResourcesUpToDate,
+							Status: corev1.ConditionTrue,
+						},
+						{
+							Type:               kubermaticv1.ClusterConditionEtcdHealthy,
+							Status:             corev1.ConditionFalse,
+							Reason:             "AlarmsRaised",
+							Message:            "alarm NOSPACE raised by member 1234",
+							LastTransitionTime: metav1.NewTime(time.Date(2013, 02, 03, 20, 54, 0, 0, time.UTC)),
+						},
+						{
+							Type:               kubermaticv1.ClusterConditionApiserverHealthy,
+							Status:             corev1.ConditionTrue,
+							Reason:             "ProbeSucceeded",
+							Message:            "All healthz and readyz checks passed",
+							LastTransitionTime: metav1.NewTime(time.Date(2013, 02, 03, 19, 54, 0, 0, time.UTC)),
+						},
+					}
+					return cluster
+				}(),
+			),
+			ExistingAPIUser: test.GenDefaultAPIUser(),
+		},
 	}
 
 	for _, tc := range testcases {
@@ -1322,6 +1437,154 @@ func TestRevokeClusterAdminTokenEndpoint(t *testing.T) {
 	}
 }
 
+func TestRotateClusterRootCAEndpoint(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		Name              string
+		ModifyCluster     func(*kubermaticv1.Cluster)
+		HTTPStatus        int
+		ExpectedRequested bool
+	}{
+		{
+			Name:              "scenario 1: the rotation gets requested",
+			ModifyCluster:     func(*kubermaticv1.Cluster) {},
+			HTTPStatus:        http.StatusOK,
+			ExpectedRequested: true,
+		},
+		{
+			Name: "scenario 2: a completed rotation can be followed by another one",
+			ModifyCluster: func(c *kubermaticv1.Cluster) {
+				c.Status.RootCARotation = &kubermaticv1.RootCARotationStatus{Phase: kubermaticv1.RootCARotationCompleted}
+			},
+			HTTPStatus:        http.StatusOK,
+			ExpectedRequested: true,
+		},
+		{
+			Name: "scenario 3: a rotation can't be requested while another one is in progress",
+			ModifyCluster: func(c *kubermaticv1.Cluster) {
+				c.Status.RootCARotation = &kubermaticv1.RootCARotationStatus{Phase: kubermaticv1.RootCARotationSigning}
+			},
+			HTTPStatus: http.StatusConflict,
+		},
+		{
+			Name: "scenario 4: the root CA of openshift clusters can't be rotated",
+			ModifyCluster: func(c *kubermaticv1.Cluster) {
+				c.Annotations = map[string]string{"kubermatic.io/openshift": "true"}
+			},
+			HTTPStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			kubermaticObjs := test.GenDefaultKubermaticObjects()
+			cluster := test.GenDefaultCluster()
+			tc.ModifyCluster(cluster)
+			kubermaticObjs = append(kubermaticObjs, cluster)
+			ep, clientsSets, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, []runtime.Object{}, []runtime.Object{}, kubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/rotaterootca", test.GenDefaultProject().Name, cluster.Name), nil)
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(tc.HTTPStatus, res, t)
+			requested := false
+			for _, action := range clientsSets.FakeKubermaticClient.Actions() {
+				if action.Matches("update", "clusters") {
+					updatedCluster := action.(clienttesting.UpdateAction).GetObject().(*kubermaticv1.Cluster)
+					_, requested = updatedCluster.Annotations[kubermaticv1.AnnotationNameRootCARotationRequested]
+				}
+			}
+			if requested != tc.ExpectedRequested {
+				t.Errorf("expected the rotation to be requested: %v, got: %v", tc.ExpectedRequested, requested)
+			}
+		})
+	}
+}
+
+func TestRotateClusterEncryptionKeyEndpoint(t *testing.T) {
+	t.Parallel()
+	enable := func(phase kubermaticv1.EncryptionPhase) func(*kubermaticv1.Cluster) {
+		return func(c *kubermaticv1.Cluster) {
+			c.Spec.EncryptionAtRest = &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderAESCBC}
+			c.Status.EncryptionAtRest = &kubermaticv1.EncryptionAtRestStatus{Phase: phase}
+		}
+	}
+	testcases := []struct {
+		Name              string
+		ModifyCluster     func(*kubermaticv1.Cluster)
+		HTTPStatus        int
+		ExpectedRequested bool
+	}{
+		{
+			Name:              "scenario 1: the rotation gets requested",
+			ModifyCluster:     enable(kubermaticv1.EncryptionEncrypted),
+			HTTPStatus:        http.StatusOK,
+			ExpectedRequested: true,
+		},
+		{
+			Name:          "scenario 2: a rotation can't be requested while the Secrets are being encrypted",
+			ModifyCluster: enable(kubermaticv1.EncryptionReencrypting),
+			HTTPStatus:    http.StatusConflict,
+		},
+		{
+			Name:          "scenario 3: the key can't be rotated if the encryption at rest is disabled",
+			ModifyCluster: func(*kubermaticv1.Cluster) {},
+			HTTPStatus:    http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			kubermaticObjs := test.GenDefaultKubermaticObjects()
+			cluster := test.GenDefaultCluster()
+			tc.ModifyCluster(cluster)
+			kubermaticObjs = append(kubermaticObjs, cluster)
+			ep, clientsSets, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, []runtime.Object{}, []runtime.Object{}, kubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/rotateencryptionkey", test.GenDefaultProject().Name, cluster.Name), nil)
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(tc.HTTPStatus, res, t)
+			requested := false
+			for _, action := range clientsSets.FakeKubermaticClient.Actions() {
+				if action.Matches("update", "clusters") {
+					updatedCluster := action.(clienttesting.UpdateAction).GetObject().(*kubermaticv1.Cluster)
+					_, requested = updatedCluster.Annotations[kubermaticv1.AnnotationNameEncryptionKeyRotationRequested]
+				}
+			}
+			if requested != tc.ExpectedRequested {
+				t.Errorf("expected the rotation to be requested: %v, got: %v", tc.ExpectedRequested, requested)
+			}
+		})
+	}
+}
+
+func TestGetClusterComplianceEndpoint(t *testing.T) {
+	t.Parallel()
+	kubermaticObjs := test.GenDefaultKubermaticObjects()
+	cluster := test.GenDefaultCluster()
+	kubermaticObjs = append(kubermaticObjs, cluster)
+	ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, kubermaticObjs, nil, nil, hack.NewTestRouting)
+	if err != nil {
+		t.Fatalf("failed to create test endpoint due to %v", err)
+	}
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/compliance", test.GenDefaultProject().Name, cluster.Name), nil)
+	ep.ServeHTTP(res, req)
+
+	test.CheckStatusCode(http.StatusBadRequest, res, t)
+	test.CompareWithResult(t, res, `{"error":{"code":400,"message":"the OPA integration is not enabled for this cluster"}}`)
+}
+
 func TestGetClusterEventsEndpoint(t *testing.T) {
 	t.Parallel()
 	testcases := []struct {
@@ -0,0 +1,117 @@
+package cluster
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-kit/kit/endpoint"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
+	"github.com/kubermatic/kubermatic/api/pkg/util/errors"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetComplianceEndpoint reports the violations of the OPA Gatekeeper constraints in the cluster
+func GetComplianceEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(common.GetClusterReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+
+		cluster, err := GetCluster(ctx, req, projectProvider)
+		if err != nil {
+			return nil, err
+		}
+		if !gatekeeper.IsEnabled(cluster) {
+			return nil, errors.NewBadRequest("the OPA integration is not enabled for this cluster")
+		}
+
+		client, err := clusterProvider.GetClientForCustomerCluster(userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		templates := &unstructured.UnstructuredList{}
+		templates.SetAPIVersion(gatekeeper.ConstraintTemplateAPIVersion)
+		templates.SetKind("ConstraintTemplateList")
+		if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, templates); err != nil {
+			// The usercluster-controller didn't create the CRD yet
+			if meta.IsNoMatchError(err) {
+				return convertConstraintsToComplianceReport(nil), nil
+			}
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		var constraints []unstructured.Unstructured
+		for _, template := range templates.Items {
+			kind, _, _ := unstructured.NestedString(template.Object, "spec", "crd", "spec", "names", "kind")
+			if kind == "" {
+				continue
+			}
+
+			list := &unstructured.UnstructuredList{}
+			list.SetAPIVersion(gatekeeper.ConstraintAPIVersion)
+			list.SetKind(kind + "List")
+			if err := client.List(ctx, &ctrlruntimeclient.ListOptions{}, list); err != nil {
+				// Gatekeeper didn't create the CRD for the template yet
+				if meta.IsNoMatchError(err) {
+					continue
+				}
+				return nil, common.KubernetesErrorToHTTPError(err)
+			}
+			constraints = append(constraints, list.Items...)
+		}
+
+		return convertConstraintsToComplianceReport(constraints), nil
+	}
+}
+
+// convertConstraintsToComplianceReport builds the report from the status the Gatekeeper audit sets on the constraints
+func convertConstraintsToComplianceReport(constraints []unstructured.Unstructured) *apiv1.ClusterComplianceReport {
+	report := &apiv1.ClusterComplianceReport{Constraints: []apiv1.ConstraintCompliance{}}
+
+	for _, constraint := range constraints {
+		compliance := apiv1.ConstraintCompliance{
+			Name: constraint.GetName(),
+			Kind: constraint.GetKind(),
+		}
+		compliance.EnforcementAction, _, _ = unstructured.NestedString(constraint.Object, "spec", "enforcementAction")
+		compliance.AuditTimestamp, _, _ = unstructured.NestedString(constraint.Object, "status", "auditTimestamp")
+		compliance.TotalViolations, _, _ = unstructured.NestedInt64(constraint.Object, "status", "totalViolations")
+
+		violations, _, _ := unstructured.NestedSlice(constraint.Object, "status", "violations")
+		for _, v := range violations {
+			violation, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			apiViolation := apiv1.ConstraintViolation{}
+			apiViolation.Kind, _, _ = unstructured.NestedString(violation, "kind")
+			apiViolation.Name, _, _ = unstructured.NestedString(violation, "name")
+			apiViolation.Namespace, _, _ = unstructured.NestedString(violation, "namespace")
+			apiViolation.Message, _, _ = unstructured.NestedString(violation, "message")
+			apiViolation.EnforcementAction, _, _ = unstructured.NestedString(violation, "enforcementAction")
+			compliance.Violations = append(compliance.Violations, apiViolation)
+		}
+
+		report.TotalViolations += compliance.TotalViolations
+		report.Constraints = append(report.Constraints, compliance)
+	}
+
+	sort.Slice(report.Constraints, func(i, j int) bool {
+		if report.Constraints[i].Kind != report.Constraints[j].Kind {
+			return report.Constraints[i].Kind < report.Constraints[j].Kind
+		}
+		return report.Constraints[i].Name < report.Constraints[j].Name
+	})
+
+	return report
+}
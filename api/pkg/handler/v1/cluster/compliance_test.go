@@ -0,0 +1,68 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/go-test/deep"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestConvertConstraintsToComplianceReport(t *testing.T) {
+	constraints := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"kind":     "K8sRequiredLabels",
+			"metadata": map[string]interface{}{"name": "ns-must-have-owner"},
+			"spec":     map[string]interface{}{"enforcementAction": "dryrun"},
+			"status": map[string]interface{}{
+				"auditTimestamp":  "2020-01-30T10:00:00Z",
+				"totalViolations": int64(2),
+				"violations": []interface{}{
+					map[string]interface{}{
+						"kind":              "Namespace",
+						"name":              "default",
+						"message":           "you must provide labels: {\"owner\"}",
+						"enforcementAction": "dryrun",
+					},
+					map[string]interface{}{
+						"kind":              "Namespace",
+						"name":              "kube-public",
+						"message":           "you must provide labels: {\"owner\"}",
+						"enforcementAction": "dryrun",
+					},
+				},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"kind":     "K8sAllowedRepos",
+			"metadata": map[string]interface{}{"name": "trusted-registries"},
+		}},
+	}
+
+	expected := &apiv1.ClusterComplianceReport{
+		TotalViolations: 2,
+		Constraints: []apiv1.ConstraintCompliance{
+			{
+				Name: "trusted-registries",
+				Kind: "K8sAllowedRepos",
+			},
+			{
+				Name:              "ns-must-have-owner",
+				Kind:              "K8sRequiredLabels",
+				EnforcementAction: "dryrun",
+				AuditTimestamp:    "2020-01-30T10:00:00Z",
+				TotalViolations:   2,
+				Violations: []apiv1.ConstraintViolation{
+					{Kind: "Namespace", Name: "default", Message: "you must provide labels: {\"owner\"}", EnforcementAction: "dryrun"},
+					{Kind: "Namespace", Name: "kube-public", Message: "you must provide labels: {\"owner\"}", EnforcementAction: "dryrun"},
+				},
+			},
+		},
+	}
+
+	if diff := deep.Equal(convertConstraintsToComplianceReport(constraints), expected); diff != nil {
+		t.Errorf("report differs from the expected one: %v", diff)
+	}
+}
@@ -0,0 +1,9 @@
+package cluster
+
+import "time"
+
+func init() {
+	// The fake clusters never become ready, so the background creation of the initial resources
+	// must give up before the tests end
+	initialResourcesTimeout = time.Second
+}
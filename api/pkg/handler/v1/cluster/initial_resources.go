@@ -0,0 +1,411 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver"
+	"github.com/prometheus/client_golang/prometheus"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	kubernetesprovider "github.com/kubermatic/kubermatic/api/pkg/provider/kubernetes"
+	machineresource "github.com/kubermatic/kubermatic/api/pkg/resources/machine"
+	"github.com/kubermatic/kubermatic/api/pkg/util/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog"
+
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+// NodeDeploymentEvent represents type of events related to Node Deployment
+type NodeDeploymentEvent string
+
+const (
+	nodeDeploymentCreationStart   NodeDeploymentEvent = "NodeDeploymentCreationStart"
+	nodeDeploymentCreationSuccess NodeDeploymentEvent = "NodeDeploymentCreationSuccess"
+	nodeDeploymentCreationFail    NodeDeploymentEvent = "NodeDeploymentCreationFail"
+)
+
+// AddonEvent represents type of events related to addons installed along with a new cluster
+type AddonEvent string
+
+const (
+	addonCreationSuccess AddonEvent = "AddonCreationSuccess"
+	addonCreationFail    AddonEvent = "AddonCreationFail"
+)
+
+// ClusterCreationEvent represents type of events related to the creation of a cluster along with its initial resources
+type ClusterCreationEvent string
+
+const (
+	clusterCreationRollback ClusterCreationEvent = "ClusterCreationRollback"
+)
+
+// InitialResources holds the resources that are created along with a new cluster
+type InitialResources struct {
+	// NodeDeployments are created in the background as soon as the cluster is up and running
+	NodeDeployments []*apiv1.NodeDeployment
+	// Addons are installed in the background as soon as the cluster is up and running
+	Addons []*apiv1.Addon
+	// SSHKeys are the IDs of the project SSH keys that get assigned to the cluster right away
+	SSHKeys []string
+	// RollbackOnFailure deletes the cluster when any of the node deployments or addons cannot be created
+	RollbackOnFailure bool
+}
+
+// validate checks the node deployments and addons before the cluster gets created,
+// so that obvious mistakes are reported right away instead of failing in the background
+func (r InitialResources) validate(version *semver.Version) error {
+	nodeDeploymentNames := sets.NewString()
+	for _, nodeDeployment := range r.NodeDeployments {
+		// validate a copy as the validation defaults the kubelet version
+		nd := *nodeDeployment
+		if _, err := machineresource.Validate(&nd, version); err != nil {
+			return fmt.Errorf("node deployment%s is not valid: %v", getNodeDeploymentDisplayName(nodeDeployment), err)
+		}
+		if len(nodeDeployment.Name) == 0 {
+			continue
+		}
+		if nodeDeploymentNames.Has(nodeDeployment.Name) {
+			return fmt.Errorf("node deployment %s is defined more than once", nodeDeployment.Name)
+		}
+		nodeDeploymentNames.Insert(nodeDeployment.Name)
+	}
+
+	addonNames := sets.NewString()
+	for _, addon := range r.Addons {
+		if len(addon.Name) == 0 {
+			return fmt.Errorf("the addon name cannot be empty")
+		}
+		if addonNames.Has(addon.Name) {
+			return fmt.Errorf("addon %s is defined more than once", addon.Name)
+		}
+		addonNames.Insert(addon.Name)
+	}
+	return nil
+}
+
+// newClusterCreationStatus returns the status of a creation that has just been started, all resources are pending
+func newClusterCreationStatus(nodeDeployments []*apiv1.NodeDeployment, addons []*apiv1.Addon, rollbackOnFailure bool) *kubermaticv1.ClusterCreationStatus {
+	status := &kubermaticv1.ClusterCreationStatus{
+		Phase:             kubermaticv1.ClusterCreationInProgress,
+		RollbackOnFailure: rollbackOnFailure,
+		StartTime:         metav1.Now(),
+	}
+	for _, nodeDeployment := range nodeDeployments {
+		status.Resources = append(status.Resources, kubermaticv1.ClusterCreationResourceStatus{
+			Kind:  kubermaticv1.ClusterCreationResourceKindNodeDeployment,
+			Name:  nodeDeployment.Name,
+			Phase: kubermaticv1.ClusterCreationResourcePending,
+		})
+	}
+	for _, addon := range addons {
+		status.Resources = append(status.Resources, kubermaticv1.ClusterCreationResourceStatus{
+			Kind:  kubermaticv1.ClusterCreationResourceKindAddon,
+			Name:  addon.Name,
+			Phase: kubermaticv1.ClusterCreationResourcePending,
+		})
+	}
+	return status
+}
+
+// initialResourcesTimeout is how long the creation of each initial node deployment and addon gets retried
+var initialResourcesTimeout = 30 * time.Minute
+
+// initialResourcesCreator creates the initial node deployments and addons of a cluster
+// and keeps track of the progress in the creation status of the cluster
+type initialResourcesCreator struct {
+	cluster           *kubermaticv1.Cluster
+	project           *kubermaticv1.Project
+	userInfo          *provider.UserInfo
+	nodeDeployments   []*apiv1.NodeDeployment
+	addons            []*apiv1.Addon
+	rollbackOnFailure bool
+
+	sshKeyProvider             provider.SSHKeyProvider
+	seedsGetter                provider.SeedsGetter
+	clusterProvider            provider.ClusterProvider
+	privilegedClusterProvider  provider.PrivilegedClusterProvider
+	addonProvider              provider.AddonProvider
+	recorder                   record.EventRecorder
+	initNodeDeploymentFailures *prometheus.CounterVec
+}
+
+// run creates the node deployments first and the addons afterwards. On the first failure the cluster
+// is deleted if a rollback was requested, otherwise the remaining resources are still created.
+func (c *initialResourcesCreator) run() {
+	defer utilruntime.HandleCrash()
+
+	// Stops the retries of all resources once the timeout is reached
+	ctx, cancel := context.WithTimeout(context.Background(), initialResourcesTimeout)
+	defer cancel()
+
+	failed := false
+	for i, nodeDeployment := range c.nodeDeployments {
+		ndName := getNodeDeploymentDisplayName(nodeDeployment)
+		c.recorder.Eventf(c.cluster, corev1.EventTypeNormal, string(nodeDeploymentCreationStart), "Started creation of initial node deployment%s", ndName)
+		md, err := createInitialNodeDeploymentWithRetries(ctx, nodeDeployment, c.cluster, c.project, c.sshKeyProvider, c.seedsGetter, c.clusterProvider, c.userInfo)
+		if err != nil {
+			c.recorder.Eventf(c.cluster, corev1.EventTypeWarning, string(nodeDeploymentCreationFail), "Failed to create initial node deployment%s: %v", ndName, err)
+			klog.Errorf("failed to create initial node deployment for cluster %s: %v", c.cluster.Name, err)
+			c.initNodeDeploymentFailures.With(prometheus.Labels{"cluster": c.cluster.Name, "datacenter": c.cluster.Spec.Cloud.DatacenterName}).Add(1)
+			c.setResourcePhase(i, "", kubermaticv1.ClusterCreationResourceFailed, err.Error())
+			failed = true
+			if c.rollbackOnFailure {
+				c.rollback(fmt.Sprintf("failed to create node deployment%s: %v", ndName, err))
+				return
+			}
+			continue
+		}
+		c.recorder.Eventf(c.cluster, corev1.EventTypeNormal, string(nodeDeploymentCreationSuccess), "Successfully created initial node deployment%s", ndName)
+		klog.V(5).Infof("created initial node deployment for cluster %s", c.cluster.Name)
+		c.setResourcePhase(i, md.Name, kubermaticv1.ClusterCreationResourceCreated, "")
+	}
+
+	for i, addon := range c.addons {
+		index := len(c.nodeDeployments) + i
+		if err := createInitialAddonWithRetries(ctx, addon, c.cluster, c.addonProvider, c.clusterProvider, c.userInfo); err != nil {
+			c.recorder.Eventf(c.cluster, corev1.EventTypeWarning, string(addonCreationFail), "Failed to install initial addon %s: %v", addon.Name, err)
+			klog.Errorf("failed to install initial addon %s for cluster %s: %v", addon.Name, c.cluster.Name, err)
+			c.setResourcePhase(index, "", kubermaticv1.ClusterCreationResourceFailed, err.Error())
+			failed = true
+			if c.rollbackOnFailure {
+				c.rollback(fmt.Sprintf("failed to install addon %s: %v", addon.Name, err))
+				return
+			}
+			continue
+		}
+		c.recorder.Eventf(c.cluster, corev1.EventTypeNormal, string(addonCreationSuccess), "Successfully installed initial addon %s", addon.Name)
+		klog.V(5).Infof("installed initial addon %s for cluster %s", addon.Name, c.cluster.Name)
+		c.setResourcePhase(index, "", kubermaticv1.ClusterCreationResourceCreated, "")
+	}
+
+	if failed {
+		c.complete(kubermaticv1.ClusterCreationFailed, "some of the node deployments or addons could not be created")
+		return
+	}
+	c.complete(kubermaticv1.ClusterCreationSucceeded, "")
+}
+
+// rollback marks the creation as rolled back and deletes the cluster
+func (c *initialResourcesCreator) rollback(reason string) {
+	c.recorder.Eventf(c.cluster, corev1.EventTypeWarning, string(clusterCreationRollback), "Deleting the cluster as the creation failed: %s", reason)
+	klog.Errorf("rolling back the creation of cluster %s: %s", c.cluster.Name, reason)
+	c.complete(kubermaticv1.ClusterCreationRolledBack, reason)
+
+	clusterSSHKeys, err := c.sshKeyProvider.List(c.project, &provider.SSHKeyListOptions{ClusterName: c.cluster.Name})
+	if err != nil {
+		klog.Errorf("failed to list the ssh keys of cluster %s during the rollback: %v", c.cluster.Name, err)
+	}
+	for _, clusterSSHKey := range clusterSSHKeys {
+		clusterSSHKey.RemoveFromCluster(c.cluster.Name)
+		if _, err := c.sshKeyProvider.Update(c.userInfo, clusterSSHKey); err != nil {
+			klog.Errorf("failed to detach the ssh key %s from cluster %s during the rollback: %v", clusterSSHKey.Name, c.cluster.Name, err)
+		}
+	}
+
+	if err := c.clusterProvider.Delete(c.userInfo, c.cluster.Name); err != nil && !kerrors.IsNotFound(err) {
+		klog.Errorf("failed to delete cluster %s during the rollback: %v", c.cluster.Name, err)
+	}
+}
+
+func (c *initialResourcesCreator) setResourcePhase(index int, name string, phase kubermaticv1.ClusterCreationResourcePhase, message string) {
+	c.updateCreationStatus(func(status *kubermaticv1.ClusterCreationStatus) {
+		if index >= len(status.Resources) {
+			return
+		}
+		if len(name) > 0 {
+			status.Resources[index].Name = name
+		}
+		status.Resources[index].Phase = phase
+		status.Resources[index].Message = message
+	})
+}
+
+func (c *initialResourcesCreator) complete(phase kubermaticv1.ClusterCreationPhase, message string) {
+	c.updateCreationStatus(func(status *kubermaticv1.ClusterCreationStatus) {
+		now := metav1.Now()
+		status.Phase = phase
+		status.Message = message
+		status.CompletionTime = &now
+	})
+}
+
+// updateCreationStatus applies the given modification to the latest version of the creation status.
+// Failures are only logged, they must not interrupt the creation of the remaining resources.
+func (c *initialResourcesCreator) updateCreationStatus(modify func(*kubermaticv1.ClusterCreationStatus)) {
+	ctx := context.Background()
+	client := c.privilegedClusterProvider.GetSeedClusterAdminRuntimeClient()
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		cluster := &kubermaticv1.Cluster{}
+		if err := client.Get(ctx, types.NamespacedName{Name: c.cluster.Name}, cluster); err != nil {
+			return err
+		}
+		if cluster.Status.Creation == nil {
+			cluster.Status.Creation = newClusterCreationStatus(c.nodeDeployments, c.addons, c.rollbackOnFailure)
+		}
+		modify(cluster.Status.Creation)
+		return client.Update(ctx, cluster)
+	})
+	if err != nil {
+		klog.Errorf("failed to update the creation status of cluster %s: %v", c.cluster.Name, err)
+	}
+}
+
+func convertInternalCreationStatusToExternal(status *kubermaticv1.ClusterCreationStatus) *apiv1.ClusterCreationStatus {
+	if status == nil {
+		return nil
+	}
+	result := &apiv1.ClusterCreationStatus{
+		Phase:             string(status.Phase),
+		RollbackOnFailure: status.RollbackOnFailure,
+		Resources:         []apiv1.ClusterCreationResourceStatus{},
+		StartTime:         apiv1.NewTime(status.StartTime.Time),
+		Message:           status.Message,
+	}
+	if status.CompletionTime != nil {
+		completionTime := apiv1.NewTime(status.CompletionTime.Time)
+		result.CompletionTime = &completionTime
+	}
+	for _, resource := range status.Resources {
+		result.Resources = append(result.Resources, apiv1.ClusterCreationResourceStatus{
+			Kind:    resource.Kind,
+			Name:    resource.Name,
+			Phase:   string(resource.Phase),
+			Message: resource.Message,
+		})
+	}
+	return result
+}
+
+func createInitialAddonWithRetries(ctx context.Context, addon *apiv1.Addon, cluster *kubermaticv1.Cluster, addonProvider provider.AddonProvider,
+	clusterProvider provider.ClusterProvider, userInfo *provider.UserInfo) error {
+	rawVars := &runtime.RawExtension{}
+	if len(addon.Spec.Variables) > 0 {
+		raw, err := json.Marshal(addon.Spec.Variables)
+		if err != nil {
+			return err
+		}
+		rawVars.Raw = raw
+	}
+
+	return wait.PollUntil(5*time.Second, func() (bool, error) {
+		readyCluster, err := clusterProvider.Get(userInfo, cluster.Name, &provider.ClusterGetOptions{CheckInitStatus: true})
+		if err != nil {
+			// Likely recoverable, the cluster is not up yet
+			klog.V(4).Infof("retrying installing initial addon %s for cluster %s (%s) due to %v", addon.Name, cluster.Name, cluster.Spec.HumanReadableName, err)
+			return false, nil
+		}
+		if _, err := addonProvider.New(userInfo, readyCluster, addon.Name, rawVars); err != nil {
+			if kerrors.IsAlreadyExists(err) {
+				return true, nil
+			}
+			// unrecoverable
+			if kerrors.IsUnauthorized(err) {
+				return false, err
+			}
+			klog.V(4).Infof("retrying installing initial addon %s for cluster %s (%s) due to %v", addon.Name, cluster.Name, cluster.Spec.HumanReadableName, err)
+			return false, nil
+		}
+		return true, nil
+	}, ctx.Done())
+}
+
+func createInitialNodeDeploymentWithRetries(ctx context.Context, nodeDeployment *apiv1.NodeDeployment, cluster *kubermaticv1.Cluster,
+	project *kubermaticv1.Project, sshKeyProvider provider.SSHKeyProvider,
+	seedsGetter provider.SeedsGetter, clusterProvider provider.ClusterProvider, userInfo *provider.UserInfo) (*clusterv1alpha1.MachineDeployment, error) {
+	var md *clusterv1alpha1.MachineDeployment
+	err := wait.PollUntil(5*time.Second, func() (bool, error) {
+		var err error
+		md, err = createInitialNodeDeployment(ctx, nodeDeployment, cluster, project, sshKeyProvider, seedsGetter, clusterProvider, userInfo)
+		if err != nil {
+			// unrecoverable
+			if strings.Contains(err.Error(), `admission webhook "machine-controller.kubermatic.io-machinedeployments" denied the request`) {
+				klog.V(4).Infof("giving up creating initial Node Deployments for cluster %s (%s) due to an unrecoverabl err %#v", cluster.Name, cluster.Spec.HumanReadableName, err)
+				return false, err
+			}
+			// Likely recoverable
+			klog.V(4).Infof("retrying creating initial Node Deployments for cluster %s (%s) due to %v", cluster.Name, cluster.Spec.HumanReadableName, err)
+			return false, nil
+		}
+		return true, nil
+	}, ctx.Done())
+	return md, err
+}
+
+func createInitialNodeDeployment(ctx context.Context, nodeDeployment *apiv1.NodeDeployment, cluster *kubermaticv1.Cluster,
+	project *kubermaticv1.Project, sshKeyProvider provider.SSHKeyProvider,
+	seedsGetter provider.SeedsGetter, clusterProvider provider.ClusterProvider, userInfo *provider.UserInfo) (*clusterv1alpha1.MachineDeployment, error) {
+	nd, err := machineresource.Validate(nodeDeployment, cluster.Spec.Version.Semver())
+	if err != nil {
+		return nil, fmt.Errorf("node deployment is not valid: %v", err)
+	}
+
+	cluster, err = clusterProvider.Get(userInfo, cluster.Name, &provider.ClusterGetOptions{CheckInitStatus: true})
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := sshKeyProvider.List(project, &provider.SSHKeyListOptions{ClusterName: cluster.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := clusterProvider.GetClientForCustomerCluster(userInfo, cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	_, dc, err := provider.DatacenterFromSeedMap(userInfo, seedsGetter, cluster.Spec.Cloud.DatacenterName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting dc: %v", err)
+	}
+
+	assertedClusterProvider, ok := clusterProvider.(*kubernetesprovider.ClusterProvider)
+	if !ok {
+		return nil, errors.New(http.StatusInternalServerError, "clusterprovider is not a kubernetesprovider.Clusterprovider, can not create secret")
+	}
+	data := common.CredentialsData{
+		Ctx:               ctx,
+		KubermaticCluster: cluster,
+		Client:            assertedClusterProvider.GetSeedClusterAdminRuntimeClient(),
+	}
+	md, err := machineresource.Deployment(cluster, nd, dc, keys, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.Create(ctx, md); err != nil {
+		// A previous attempt may have created it before failing
+		if kerrors.IsAlreadyExists(err) {
+			return md, nil
+		}
+		return nil, err
+	}
+	return md, nil
+}
+
+func getNodeDeploymentDisplayName(nd *apiv1.NodeDeployment) string {
+	if len(nd.Name) != 0 {
+		return " " + nd.Name
+	}
+
+	return ""
+}
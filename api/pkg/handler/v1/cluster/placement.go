@@ -0,0 +1,64 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/placement"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/kubermatic/kubermatic/api/pkg/util/errors"
+)
+
+const (
+	// utilisationMaxAge is the age after which the utilisation snapshot of a seed gets refreshed
+	utilisationMaxAge = time.Minute
+	// utilisationTimeout bounds the time to take a snapshot of the utilisation of a seed
+	utilisationTimeout = 30 * time.Second
+)
+
+// seedUtilisation caches the utilisation of the seeds, so creating a cluster doesn't need to list
+// all clusters and pods of every candidate seed
+var seedUtilisation = placement.NewUtilisationCache(utilisationMaxAge)
+
+// placeCluster makes sure the cluster gets created on a seed with capacity for it. If the seed of the
+// requested datacenter is full or cordoned, the request gets redirected to an equivalent datacenter of another seed.
+// It returns the seed the request got redirected to or nil if the seed of the requested datacenter accepts the cluster.
+func placeCluster(req *CreateReq, seed *kubermaticv1.Seed, seedsGetter provider.SeedsGetter, clusterProviderGetter provider.ClusterProviderGetter) (*kubermaticv1.Seed, error) {
+	seeds, err := seedsGetter()
+	if err != nil {
+		return nil, errors.New(http.StatusInternalServerError, fmt.Sprintf("failed to list seeds: %v", err))
+	}
+
+	// The snapshots get refreshed in the background, so they must not be bound to the request context
+	utilisationGetter := seedUtilisation.Getter(func(seed *kubermaticv1.Seed) (*placement.Utilisation, error) {
+		clusterProvider, err := clusterProviderGetter(seed)
+		if err != nil {
+			return nil, err
+		}
+		privilegedClusterProvider, ok := clusterProvider.(provider.PrivilegedClusterProvider)
+		if !ok {
+			return nil, fmt.Errorf("cluster provider of seed %s is not privileged", seed.Name)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), utilisationTimeout)
+		defer cancel()
+		return placement.GetUtilisation(ctx, privilegedClusterProvider.GetSeedClusterAdminRuntimeClient())
+	})
+
+	placedSeed, datacenterName, err := placement.Place(seeds, seed, req.Body.Cluster.Spec.Cloud.DatacenterName, utilisationGetter)
+	if err != nil {
+		if _, ok := err.(*placement.NoCapacityError); ok {
+			return nil, errors.New(http.StatusServiceUnavailable, err.Error())
+		}
+		return nil, errors.New(http.StatusInternalServerError, fmt.Sprintf("failed to place cluster: %v", err))
+	}
+	seedUtilisation.Placed(placedSeed)
+	if placedSeed.Name == seed.Name {
+		return nil, nil
+	}
+
+	req.Body.Cluster.Spec.Cloud.DatacenterName = datacenterName
+	return placedSeed, nil
+}
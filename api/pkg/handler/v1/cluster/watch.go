@@ -0,0 +1,122 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// streamMessageCluster is the type of messages which contain the apiv1.Cluster whenever it changes
+	streamMessageCluster = "cluster"
+	// streamMessageHealth is the type of messages which contain the apiv1.ClusterHealth whenever it changes
+	streamMessageHealth = "health"
+)
+
+// clusterPollInterval is the interval in which the cluster is fetched to stream its status and health.
+// Fetching it through the cluster provider checks on every poll that the user still has access to it.
+var clusterPollInterval = 5 * time.Second
+
+// userClusterEventKinds are the kinds of the objects in the user cluster whose events are streamed
+var userClusterEventKinds = sets.NewString("Machine", "MachineSet", "MachineDeployment", "Node")
+
+// WatchClusterEventsEndpoint streams the events of the cluster from the seed and the events of the
+// machines and nodes from the user cluster, as well as the changes of the cluster status and health
+func WatchClusterEventsEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(EventsReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+		privilegedClusterProvider := ctx.Value(middleware.PrivilegedClusterProviderContextKey).(provider.PrivilegedClusterProvider)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		if _, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{}); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		userClusterClient, err := clusterProvider.GetK8sClientForCustomerCluster(userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		eventType := ""
+		switch req.Type {
+		case "warning":
+			eventType = corev1.EventTypeWarning
+		case "normal":
+			eventType = corev1.EventTypeNormal
+		}
+		matchesType := func(event *corev1.Event) bool {
+			return eventType == "" || event.Type == eventType
+		}
+
+		messages := make(chan common.StreamMessage)
+		// The API server of the seed only returns the events of the cluster, the other events on
+		// the seed are never sent to the API
+		clusterEvents := fields.OneTermEqualSelector("involvedObject.uid", string(cluster.UID))
+		go common.WatchEvents(ctx, privilegedClusterProvider.GetSeedClusterAdminClient(), "", clusterEvents, func(event *corev1.Event) bool {
+			return event.InvolvedObject.UID == cluster.UID && matchesType(event)
+		}, messages)
+		go common.WatchEvents(ctx, userClusterClient, "", fields.Everything(), func(event *corev1.Event) bool {
+			return userClusterEventKinds.Has(event.InvolvedObject.Kind) && matchesType(event)
+		}, messages)
+		go watchClusterStatus(ctx, clusterProvider, userInfo, cluster, messages)
+
+		return &common.EventStream{Messages: messages}, nil
+	}
+}
+
+// watchClusterStatus sends the cluster and its health initially and then whenever they change
+func watchClusterStatus(ctx context.Context, clusterProvider provider.ClusterProvider, userInfo *provider.UserInfo, cluster *kubermaticv1.Cluster, messages chan<- common.StreamMessage) {
+	sent := map[string][]byte{}
+	sendChanged := func(messageType string, data interface{}) bool {
+		raw, err := json.Marshal(data)
+		if err != nil || string(raw) == string(sent[messageType]) {
+			return true
+		}
+		sent[messageType] = raw
+		return common.SendStreamMessage(ctx, messages, messageType, data)
+	}
+
+	for {
+		if !sendChanged(streamMessageCluster, convertInternalClusterToExternal(cluster)) {
+			return
+		}
+		if !sendChanged(streamMessageHealth, convertInternalHealthToExternal(cluster)) {
+			return
+		}
+
+		select {
+		case <-time.After(clusterPollInterval):
+		case <-ctx.Done():
+			return
+		}
+
+		updatedCluster, err := clusterProvider.Get(userInfo, cluster.Name, &provider.ClusterGetOptions{})
+		if err != nil {
+			if !common.SendStreamMessage(ctx, messages, common.StreamMessageError, common.StreamError{Message: err.Error()}) {
+				return
+			}
+			// There is nothing left to watch once the cluster is gone or the user lost access to it
+			if kerrors.IsNotFound(err) || kerrors.IsForbidden(err) {
+				return
+			}
+			continue
+		}
+		cluster = updatedCluster
+	}
+}
@@ -0,0 +1,67 @@
+package cluster_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestWatchClusterEventsEndpoint(t *testing.T) {
+	t.Parallel()
+	cluster := test.GenCluster(test.DefaultClusterID, test.DefaultClusterName, test.GenDefaultProject().Name, time.Date(2013, 02, 03, 19, 54, 0, 0, time.UTC), func(c *kubermaticv1.Cluster) {
+		c.UID = "cluster-uid"
+	})
+	kubernetesObj := []runtime.Object{
+		test.GenTestEvent("event-1", corev1.EventTypeNormal, "Started", "message started", "Cluster", "cluster-uid"),
+		test.GenTestEvent("event-2", corev1.EventTypeWarning, "Killed", "message killed", "Machine", "venus-1-machine"),
+		test.GenTestEvent("event-3", corev1.EventTypeWarning, "BackOff", "message back-off", "Pod", "pod"),
+		test.GenTestEvent("event-4", corev1.EventTypeWarning, "Failed", "message failed", "Cluster", "other-cluster-uid"),
+	}
+
+	ep, _, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, kubernetesObj, nil, test.GenDefaultKubermaticObjects(cluster), nil, nil, hack.NewTestRouting)
+	if err != nil {
+		t.Fatalf("failed to create test endpoint due to %v", err)
+	}
+
+	// The stream only ends when the client disconnects
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/events/watch", test.GenDefaultProject().Name, cluster.Name), nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	ep.ServeHTTP(res, req)
+
+	test.CheckStatusCode(http.StatusOK, res, t)
+	body := res.Body.String()
+	// The seed, the user cluster and the cluster status are watched concurrently, so only
+	// the messages but not their order are checked
+	expectedMessages := []string{
+		"event: event\ndata: {\"name\":\"event-1\",",
+		"event: event\ndata: {\"name\":\"event-2\",",
+		"event: cluster\ndata: {\"id\":\"defClusterID\",",
+		"event: health\ndata: {\"apiserver\":",
+	}
+	for _, message := range expectedMessages {
+		if !strings.Contains(body, message) {
+			t.Errorf("expected the stream to contain %q, got\n%s", message, body)
+		}
+	}
+	for _, event := range []string{"event-3", "event-4"} {
+		if strings.Contains(body, fmt.Sprintf(`"name":%q`, event)) {
+			t.Errorf("expected the stream to not contain %s, got\n%s", event, body)
+		}
+	}
+	if count := strings.Count(body, "event: "); count != len(expectedMessages) {
+		t.Errorf("expected %d messages, got %d:\n%s", len(expectedMessages), count, body)
+	}
+}
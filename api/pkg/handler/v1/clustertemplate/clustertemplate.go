@@ -0,0 +1,482 @@
+package clustertemplate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/cluster"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	kubernetesprovider "github.com/kubermatic/kubermatic/api/pkg/provider/kubernetes"
+	"github.com/kubermatic/kubermatic/api/pkg/util/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func CreateEndpoint(templateProvider provider.ClusterTemplateProvider, sshKeyProvider provider.SSHKeyProvider, projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(createReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		template, err := convertExternalClusterTemplateToInternal(&req.Body)
+		if err != nil {
+			return nil, errors.NewBadRequest("invalid cluster template: %v", err)
+		}
+		if err := validateClusterTemplate(template); err != nil {
+			return nil, errors.NewBadRequest("invalid cluster template: %v", err)
+		}
+
+		if len(template.Spec.SSHKeys) > 0 {
+			projectSSHKeys, err := sshKeyProvider.List(project, nil)
+			if err != nil {
+				return nil, common.KubernetesErrorToHTTPError(err)
+			}
+			projectSSHKeyNames := sets.NewString()
+			for _, projectSSHKey := range projectSSHKeys {
+				projectSSHKeyNames.Insert(projectSSHKey.Name)
+			}
+			for _, keyID := range template.Spec.SSHKeys {
+				if !projectSSHKeyNames.Has(keyID) {
+					return nil, errors.NewBadRequest("the given ssh key %s does not belong to the given project %s (%s)", keyID, project.Spec.Name, project.Name)
+				}
+			}
+		}
+
+		existingTemplates, err := templateProvider.List(project)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		for _, existingTemplate := range existingTemplates {
+			if existingTemplate.Spec.HumanReadableName == template.Spec.HumanReadableName && !kubernetesprovider.IsGlobalClusterTemplate(existingTemplate) {
+				return nil, errors.NewAlreadyExists("cluster template", template.Spec.HumanReadableName)
+			}
+		}
+
+		createdTemplate, err := templateProvider.New(userInfo, project, template)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return convertInternalClusterTemplateToExternal(createdTemplate)
+	}
+}
+
+func ListEndpoint(templateProvider provider.ClusterTemplateProvider, projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(listReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		templates, err := templateProvider.List(project)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		apiTemplates := apiv1.ClusterTemplateList{}
+		for _, template := range templates {
+			apiTemplate, err := convertInternalClusterTemplateToExternal(template)
+			if err != nil {
+				return nil, common.KubernetesErrorToHTTPError(err)
+			}
+			apiTemplates = append(apiTemplates, *apiTemplate)
+		}
+		sort.SliceStable(apiTemplates, func(i, j int) bool {
+			return apiTemplates[i].ID < apiTemplates[j].ID
+		})
+		return apiTemplates, nil
+	}
+}
+
+func GetEndpoint(templateProvider provider.ClusterTemplateProvider, projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(templateReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		template, err := templateProvider.Get(userInfo, project, req.TemplateID)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return convertInternalClusterTemplateToExternal(template)
+	}
+}
+
+func DeleteEndpoint(templateProvider provider.ClusterTemplateProvider, projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(templateReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		template, err := templateProvider.Get(userInfo, project, req.TemplateID)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		if kubernetesprovider.IsGlobalClusterTemplate(template) {
+			return nil, errors.New(http.StatusForbidden, "global cluster templates cannot be deleted")
+		}
+
+		if err := templateProvider.Delete(userInfo, req.TemplateID); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return nil, nil
+	}
+}
+
+// InstantiateEndpoint creates a cluster along with its node deployments, addons and SSH keys from the given template
+func InstantiateEndpoint(templateProvider provider.ClusterTemplateProvider, sshKeyProvider provider.SSHKeyProvider, projectProvider provider.ProjectProvider, seedsGetter provider.SeedsGetter,
+	clusterProviderGetter provider.ClusterProviderGetter, addonProviderGetter provider.AddonProviderGetter, initNodeDeploymentFailures *prometheus.CounterVec, eventRecorderProvider provider.EventRecorderProvider, credentialManager common.PresetsManager, exposeStrategy corev1.ServiceType) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(instantiateReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		template, err := templateProvider.Get(userInfo, project, req.TemplateID)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		createReq, initialResources, err := instantiate(template, req.Body.Parameters)
+		if err != nil {
+			return nil, errors.NewBadRequest("unable to instantiate cluster template %s: %v", template.Spec.HumanReadableName, err)
+		}
+		createReq.DCReq = req.DCReq
+		initialResources.RollbackOnFailure = req.Body.RollbackOnFailure
+
+		return cluster.CreateCluster(ctx, *createReq, *initialResources, sshKeyProvider, projectProvider, seedsGetter, clusterProviderGetter, addonProviderGetter, initNodeDeploymentFailures, eventRecorderProvider, credentialManager, exposeStrategy)
+	}
+}
+
+// instantiate renders the given template with the given parameter values
+func instantiate(template *kubermaticv1.ClusterTemplate, values map[string]string) (*cluster.CreateReq, *cluster.InitialResources, error) {
+	resolvedValues, err := resolveParameters(template.Spec.Parameters, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	createReq := &cluster.CreateReq{}
+	rawCluster, err := render(template.Spec.Cluster.Raw, resolvedValues)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cluster: %v", err)
+	}
+	if err := json.Unmarshal(rawCluster, &createReq.Body.Cluster); err != nil {
+		return nil, nil, fmt.Errorf("invalid cluster: %v", err)
+	}
+	if len(createReq.Body.Cluster.Type) == 0 {
+		createReq.Body.Cluster.Type = apiv1.KubernetesClusterType
+	}
+
+	initialResources := &cluster.InitialResources{SSHKeys: template.Spec.SSHKeys}
+	for i, rawExtension := range template.Spec.NodeDeployments {
+		rawNodeDeployment, err := render(rawExtension.Raw, resolvedValues)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid node deployment %d: %v", i, err)
+		}
+		nodeDeployment := &apiv1.NodeDeployment{}
+		if err := json.Unmarshal(rawNodeDeployment, nodeDeployment); err != nil {
+			return nil, nil, fmt.Errorf("invalid node deployment %d: %v", i, err)
+		}
+		initialResources.NodeDeployments = append(initialResources.NodeDeployments, nodeDeployment)
+	}
+
+	for _, templateAddon := range template.Spec.Addons {
+		addon := &apiv1.Addon{ObjectMeta: apiv1.ObjectMeta{Name: templateAddon.Name}}
+		if templateAddon.Variables != nil && len(templateAddon.Variables.Raw) > 0 {
+			rawVariables, err := render(templateAddon.Variables.Raw, resolvedValues)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid variables of addon %s: %v", templateAddon.Name, err)
+			}
+			if err := json.Unmarshal(rawVariables, &addon.Spec.Variables); err != nil {
+				return nil, nil, fmt.Errorf("invalid variables of addon %s: %v", templateAddon.Name, err)
+			}
+		}
+		initialResources.Addons = append(initialResources.Addons, addon)
+	}
+
+	return createReq, initialResources, nil
+}
+
+// validateClusterTemplate checks the parameters of the given template and makes sure that only declared parameters are referenced
+func validateClusterTemplate(template *kubermaticv1.ClusterTemplate) error {
+	if len(template.Spec.HumanReadableName) == 0 {
+		return fmt.Errorf("the name is missing but required")
+	}
+	if len(template.Spec.Cluster.Raw) == 0 {
+		return fmt.Errorf("the cluster is missing but required")
+	}
+	if err := validateParameters(template.Spec.Parameters); err != nil {
+		return err
+	}
+
+	documents := [][]byte{template.Spec.Cluster.Raw}
+	for _, nodeDeployment := range template.Spec.NodeDeployments {
+		documents = append(documents, nodeDeployment.Raw)
+	}
+	addonNames := sets.NewString()
+	for _, addon := range template.Spec.Addons {
+		if len(addon.Name) == 0 {
+			return fmt.Errorf("the addon name is missing but required")
+		}
+		if addonNames.Has(addon.Name) {
+			return fmt.Errorf("addon %s is specified more than once", addon.Name)
+		}
+		addonNames.Insert(addon.Name)
+		if addon.Variables != nil {
+			documents = append(documents, addon.Variables.Raw)
+		}
+	}
+
+	declared := sets.NewString()
+	for _, parameter := range template.Spec.Parameters {
+		declared.Insert(parameter.Name)
+	}
+	for _, document := range documents {
+		referenced, err := referencedParameters(document)
+		if err != nil {
+			return err
+		}
+		if undeclared := referenced.Difference(declared); undeclared.Len() > 0 {
+			return fmt.Errorf("undeclared parameters referenced: %v", undeclared.List())
+		}
+	}
+	return nil
+}
+
+func convertExternalClusterTemplateToInternal(template *apiv1.ClusterTemplate) (*kubermaticv1.ClusterTemplate, error) {
+	internalTemplate := &kubermaticv1.ClusterTemplate{
+		Spec: kubermaticv1.ClusterTemplateSpec{
+			HumanReadableName: template.Name,
+			Description:       template.Spec.Description,
+			SSHKeys:           template.Spec.SSHKeys,
+		},
+	}
+
+	if len(template.Spec.Cluster) > 0 {
+		raw, err := json.Marshal(template.Spec.Cluster)
+		if err != nil {
+			return nil, err
+		}
+		internalTemplate.Spec.Cluster.Raw = raw
+	}
+	for _, nodeDeployment := range template.Spec.NodeDeployments {
+		raw, err := json.Marshal(nodeDeployment)
+		if err != nil {
+			return nil, err
+		}
+		internalTemplate.Spec.NodeDeployments = append(internalTemplate.Spec.NodeDeployments, runtime.RawExtension{Raw: raw})
+	}
+	for _, addon := range template.Spec.Addons {
+		internalAddon := kubermaticv1.ClusterTemplateAddon{Name: addon.Name}
+		if len(addon.Variables) > 0 {
+			raw, err := json.Marshal(addon.Variables)
+			if err != nil {
+				return nil, err
+			}
+			internalAddon.Variables = &runtime.RawExtension{Raw: raw}
+		}
+		internalTemplate.Spec.Addons = append(internalTemplate.Spec.Addons, internalAddon)
+	}
+	for _, parameter := range template.Spec.Parameters {
+		internalTemplate.Spec.Parameters = append(internalTemplate.Spec.Parameters, kubermaticv1.ClusterTemplateParameter{
+			Name:        parameter.Name,
+			Description: parameter.Description,
+			Type:        parameter.Type,
+			Default:     parameter.Default,
+			Required:    parameter.Required,
+		})
+	}
+	return internalTemplate, nil
+}
+
+func convertInternalClusterTemplateToExternal(template *kubermaticv1.ClusterTemplate) (*apiv1.ClusterTemplate, error) {
+	apiTemplate := &apiv1.ClusterTemplate{
+		ObjectMeta: apiv1.ObjectMeta{
+			ID:                template.Name,
+			Name:              template.Spec.HumanReadableName,
+			CreationTimestamp: apiv1.NewTime(template.CreationTimestamp.Time),
+		},
+		Global: kubernetesprovider.IsGlobalClusterTemplate(template),
+		Spec: apiv1.ClusterTemplateSpec{
+			Description: template.Spec.Description,
+			SSHKeys:     template.Spec.SSHKeys,
+		},
+	}
+
+	if len(template.Spec.Cluster.Raw) > 0 {
+		if err := json.Unmarshal(template.Spec.Cluster.Raw, &apiTemplate.Spec.Cluster); err != nil {
+			return nil, err
+		}
+	}
+	for _, nodeDeployment := range template.Spec.NodeDeployments {
+		apiNodeDeployment := map[string]interface{}{}
+		if err := json.Unmarshal(nodeDeployment.Raw, &apiNodeDeployment); err != nil {
+			return nil, err
+		}
+		apiTemplate.Spec.NodeDeployments = append(apiTemplate.Spec.NodeDeployments, apiNodeDeployment)
+	}
+	for _, addon := range template.Spec.Addons {
+		apiAddon := apiv1.ClusterTemplateAddon{Name: addon.Name}
+		if addon.Variables != nil && len(addon.Variables.Raw) > 0 {
+			if err := json.Unmarshal(addon.Variables.Raw, &apiAddon.Variables); err != nil {
+				return nil, err
+			}
+		}
+		apiTemplate.Spec.Addons = append(apiTemplate.Spec.Addons, apiAddon)
+	}
+	for _, parameter := range template.Spec.Parameters {
+		apiTemplate.Spec.Parameters = append(apiTemplate.Spec.Parameters, apiv1.ClusterTemplateParameter{
+			Name:        parameter.Name,
+			Description: parameter.Description,
+			Type:        parameter.Type,
+			Default:     parameter.Default,
+			Required:    parameter.Required,
+		})
+	}
+	return apiTemplate, nil
+}
+
+// createReq defines HTTP request for createClusterTemplate endpoint
+// swagger:parameters createClusterTemplate
+type createReq struct {
+	common.ProjectReq
+	// in: body
+	Body apiv1.ClusterTemplate
+}
+
+func DecodeCreateReq(c context.Context, r *http.Request) (interface{}, error) {
+	var req createReq
+
+	pr, err := common.DecodeProjectRequest(c, r)
+	if err != nil {
+		return nil, err
+	}
+	req.ProjectReq = pr.(common.ProjectReq)
+
+	if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+		return nil, errors.NewBadRequest("unable to parse the input, err = %v", err.Error())
+	}
+	return req, nil
+}
+
+// listReq defines HTTP request for listClusterTemplates endpoint
+// swagger:parameters listClusterTemplates
+type listReq struct {
+	common.ProjectReq
+}
+
+func DecodeListReq(c context.Context, r *http.Request) (interface{}, error) {
+	pr, err := common.DecodeProjectRequest(c, r)
+	if err != nil {
+		return nil, err
+	}
+	return listReq{ProjectReq: pr.(common.ProjectReq)}, nil
+}
+
+// templateReq defines HTTP request for getClusterTemplate and deleteClusterTemplate endpoints
+// swagger:parameters getClusterTemplate deleteClusterTemplate
+type templateReq struct {
+	common.ProjectReq
+	// in: path
+	// required: true
+	TemplateID string `json:"template_id"`
+}
+
+func DecodeTemplateReq(c context.Context, r *http.Request) (interface{}, error) {
+	var req templateReq
+
+	pr, err := common.DecodeProjectRequest(c, r)
+	if err != nil {
+		return nil, err
+	}
+	req.ProjectReq = pr.(common.ProjectReq)
+
+	templateID, err := decodeTemplateID(r)
+	if err != nil {
+		return nil, err
+	}
+	req.TemplateID = templateID
+	return req, nil
+}
+
+// instantiateReq defines HTTP request for createClusterFromTemplate endpoint
+// swagger:parameters createClusterFromTemplate
+type instantiateReq struct {
+	common.DCReq
+	// in: path
+	// required: true
+	TemplateID string `json:"template_id"`
+	// in: body
+	Body apiv1.ClusterTemplateInstance
+}
+
+func DecodeInstantiateReq(c context.Context, r *http.Request) (interface{}, error) {
+	var req instantiateReq
+
+	dcr, err := common.DecodeDcReq(c, r)
+	if err != nil {
+		return nil, err
+	}
+	req.DCReq = dcr.(common.DCReq)
+
+	templateID, err := decodeTemplateID(r)
+	if err != nil {
+		return nil, err
+	}
+	req.TemplateID = templateID
+
+	// the body is optional when the defaults of all parameters are good enough
+	if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil && err != io.EOF {
+		return nil, errors.NewBadRequest("unable to parse the input, err = %v", err.Error())
+	}
+	return req, nil
+}
+
+func decodeTemplateID(r *http.Request) (string, error) {
+	templateID := mux.Vars(r)["template_id"]
+	if templateID == "" {
+		return "", fmt.Errorf("'template_id' parameter is required but was not provided")
+	}
+	return templateID, nil
+}
@@ -0,0 +1,317 @@
+package clustertemplate_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestCreateClusterTemplateEndpoint(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		Name                   string
+		Body                   string
+		ExpectedResponse       string
+		HTTPStatus             int
+		RewriteTemplateID      bool
+		ExistingKubermaticObjs []runtime.Object
+	}{
+		{
+			Name:                   "scenario 1: a cluster template is created",
+			Body:                   `{"name":"production","spec":{"parameters":[{"name":"name","required":true}],"cluster":{"name":"${name}","spec":{"version":"1.15.5","cloud":{"fake":{},"dc":"fake-dc"}}}}}`,
+			ExpectedResponse:       `{"id":"%s","name":"production","creationTimestamp":"0001-01-01T00:00:00Z","global":false,"spec":{"parameters":[{"name":"name","required":true}],"cluster":{"name":"${name}","spec":{"cloud":{"dc":"fake-dc","fake":{}},"version":"1.15.5"}}}}`,
+			HTTPStatus:             http.StatusCreated,
+			RewriteTemplateID:      true,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(),
+		},
+		{
+			Name:                   "scenario 2: a template that references undeclared parameters is rejected",
+			Body:                   `{"name":"production","spec":{"cluster":{"name":"${name}","spec":{"version":"1.15.5","cloud":{"fake":{},"dc":"fake-dc"}}}}}`,
+			ExpectedResponse:       `{"error":{"code":400,"message":"invalid cluster template: undeclared parameters referenced: [name]"}}`,
+			HTTPStatus:             http.StatusBadRequest,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(),
+		},
+		{
+			Name:                   "scenario 3: a template with an SSH key of a different project is rejected",
+			Body:                   `{"name":"production","spec":{"cluster":{"name":"prod","spec":{"version":"1.15.5","cloud":{"fake":{},"dc":"fake-dc"}}},"sshKeys":["key-abc"]}}`,
+			ExpectedResponse:       `{"error":{"code":400,"message":"the given ssh key key-abc does not belong to the given project my-first-project (my-first-project-ID)"}}`,
+			HTTPStatus:             http.StatusBadRequest,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(),
+		},
+		{
+			Name:             "scenario 4: the name of a template must be unique within a project",
+			Body:             `{"name":"production","spec":{"cluster":{"name":"prod","spec":{"version":"1.15.5","cloud":{"fake":{},"dc":"fake-dc"}}}}}`,
+			ExpectedResponse: `{"error":{"code":409,"message":"cluster template \"production\" already exists"}}`,
+			HTTPStatus:       http.StatusConflict,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				genClusterTemplate("tmpl-1", "production", test.GenDefaultProject().Name),
+			),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/projects/%s/clustertemplates", test.GenDefaultProject().Name), strings.NewReader(tc.Body))
+			res := httptest.NewRecorder()
+			ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, tc.ExistingKubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			if res.Code != tc.HTTPStatus {
+				t.Fatalf("Expected HTTP status code %d, got %d: %s", tc.HTTPStatus, res.Code, res.Body.String())
+			}
+
+			expectedResponse := tc.ExpectedResponse
+			// since the template ID is automatically generated by the system just rewrite it.
+			if tc.RewriteTemplateID {
+				actualTemplate := &apiv1.ClusterTemplate{}
+				if err := json.Unmarshal(res.Body.Bytes(), actualTemplate); err != nil {
+					t.Fatal(err)
+				}
+				expectedResponse = fmt.Sprintf(tc.ExpectedResponse, actualTemplate.ID)
+			}
+			test.CompareWithResult(t, res, expectedResponse)
+		})
+	}
+}
+
+func TestListClusterTemplatesEndpoint(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		Name                   string
+		ExpectedResponse       string
+		HTTPStatus             int
+		ExistingKubermaticObjs []runtime.Object
+	}{
+		{
+			Name:             "scenario 1: the templates of the project and the global ones are listed",
+			ExpectedResponse: `[{"id":"tmpl-1","name":"production","creationTimestamp":"0001-01-01T00:00:00Z","global":false,"spec":{"cluster":{"name":"prod"}}},{"id":"tmpl-3","name":"default","creationTimestamp":"0001-01-01T00:00:00Z","global":true,"spec":{"cluster":{"name":"prod"}}}]`,
+			HTTPStatus:       http.StatusOK,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				genClusterTemplate("tmpl-1", "production", test.GenDefaultProject().Name),
+				genClusterTemplate("tmpl-2", "staging", "other-project-ID"),
+				genClusterTemplate("tmpl-3", "default", ""),
+			),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/projects/%s/clustertemplates", test.GenDefaultProject().Name), nil)
+			res := httptest.NewRecorder()
+			ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, tc.ExistingKubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			if res.Code != tc.HTTPStatus {
+				t.Fatalf("Expected HTTP status code %d, got %d: %s", tc.HTTPStatus, res.Code, res.Body.String())
+			}
+			test.CompareWithResult(t, res, tc.ExpectedResponse)
+		})
+	}
+}
+
+func TestDeleteClusterTemplateEndpoint(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		Name                   string
+		TemplateToDelete       string
+		ExpectedResponse       string
+		HTTPStatus             int
+		ExistingKubermaticObjs []runtime.Object
+	}{
+		{
+			Name:             "scenario 1: a project template is deleted",
+			TemplateToDelete: "tmpl-1",
+			ExpectedResponse: `{}`,
+			HTTPStatus:       http.StatusOK,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				genClusterTemplate("tmpl-1", "production", test.GenDefaultProject().Name),
+			),
+		},
+		{
+			Name:             "scenario 2: a global template cannot be deleted",
+			TemplateToDelete: "tmpl-3",
+			ExpectedResponse: `{"error":{"code":403,"message":"global cluster templates cannot be deleted"}}`,
+			HTTPStatus:       http.StatusForbidden,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				genClusterTemplate("tmpl-3", "default", ""),
+			),
+		},
+		{
+			Name:             "scenario 3: a template of a different project cannot be deleted",
+			TemplateToDelete: "tmpl-2",
+			ExpectedResponse: `{"error":{"code":404,"message":"clustertemplates.kubermatic.k8s.io \"tmpl-2\" not found"}}`,
+			HTTPStatus:       http.StatusNotFound,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				genClusterTemplate("tmpl-2", "staging", "other-project-ID"),
+			),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/v1/projects/%s/clustertemplates/%s", test.GenDefaultProject().Name, tc.TemplateToDelete), nil)
+			res := httptest.NewRecorder()
+			ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, tc.ExistingKubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			if res.Code != tc.HTTPStatus {
+				t.Fatalf("Expected HTTP status code %d, got %d: %s", tc.HTTPStatus, res.Code, res.Body.String())
+			}
+			test.CompareWithResult(t, res, tc.ExpectedResponse)
+		})
+	}
+}
+
+func TestCreateClusterFromTemplateEndpoint(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		Name                   string
+		Body                   string
+		ExpectedResponse       string
+		HTTPStatus             int
+		RewriteClusterID       bool
+		ExpectedSSHKeyUpdates  int
+		ExistingKubermaticObjs []runtime.Object
+	}{
+		{
+			Name:                  "scenario 1: a cluster is created from a template and the SSH keys are assigned",
+			Body:                  `{"parameters":{"name":"prod-1"}}`,
+			ExpectedResponse:      `{"id":"%s","name":"prod-1","creationTimestamp":"0001-01-01T00:00:00Z","type":"kubernetes","spec":{"cloud":{"dc":"fake-dc","fake":{}},"version":"1.15.5","oidc":{}},"status":{"version":"1.15.5","url":""}}`,
+			HTTPStatus:            http.StatusCreated,
+			RewriteClusterID:      true,
+			ExpectedSSHKeyUpdates: 1,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				genSSHKey("key-abc", test.GenDefaultProject().Name),
+				genClusterTemplate("tmpl-1", "production", test.GenDefaultProject().Name, func(template *kubermaticv1.ClusterTemplate) {
+					template.Spec.Parameters = []kubermaticv1.ClusterTemplateParameter{{Name: "name", Required: true}, {Name: "version", Default: "1.15.5"}}
+					template.Spec.Cluster.Raw = []byte(`{"name":"${name}","spec":{"version":"${version}","cloud":{"fake":{"token":"dummy_token"},"dc":"fake-dc"}}}`)
+					template.Spec.SSHKeys = []string{"key-abc"}
+				}),
+			),
+		},
+		{
+			Name:             "scenario 2: required parameters must be provided",
+			Body:             `{}`,
+			ExpectedResponse: `{"error":{"code":400,"message":"unable to instantiate cluster template production: parameter name is required"}}`,
+			HTTPStatus:       http.StatusBadRequest,
+			ExistingKubermaticObjs: test.GenDefaultKubermaticObjects(
+				genClusterTemplate("tmpl-1", "production", test.GenDefaultProject().Name, func(template *kubermaticv1.ClusterTemplate) {
+					template.Spec.Parameters = []kubermaticv1.ClusterTemplateParameter{{Name: "name", Required: true}}
+				}),
+			),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clustertemplates/tmpl-1/instances", test.GenDefaultProject().Name), strings.NewReader(tc.Body))
+			res := httptest.NewRecorder()
+			ep, clients, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, []runtime.Object{}, []runtime.Object{}, tc.ExistingKubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			if res.Code != tc.HTTPStatus {
+				t.Fatalf("Expected HTTP status code %d, got %d: %s", tc.HTTPStatus, res.Code, res.Body.String())
+			}
+
+			expectedResponse := tc.ExpectedResponse
+			if tc.RewriteClusterID {
+				actualCluster := &apiv1.Cluster{}
+				if err := json.Unmarshal(res.Body.Bytes(), actualCluster); err != nil {
+					t.Fatal(err)
+				}
+				expectedResponse = fmt.Sprintf(tc.ExpectedResponse, actualCluster.ID)
+
+				sshKeyUpdates := 0
+				for _, action := range clients.FakeKubermaticClient.Actions() {
+					if action.Matches("update", "usersshkeies") {
+						updateAction, ok := action.(clienttesting.UpdateAction)
+						if !ok {
+							t.Fatalf("unexpected action %#v", action)
+						}
+						sshKey := updateAction.GetObject().(*kubermaticv1.UserSSHKey)
+						if !sshKey.IsUsedByCluster(actualCluster.ID) {
+							t.Fatalf("expected ssh key %s to be assigned to cluster %s", sshKey.Name, actualCluster.ID)
+						}
+						sshKeyUpdates++
+					}
+				}
+				if sshKeyUpdates != tc.ExpectedSSHKeyUpdates {
+					t.Fatalf("expected %d ssh key updates, got %d", tc.ExpectedSSHKeyUpdates, sshKeyUpdates)
+				}
+			}
+			test.CompareWithResult(t, res, expectedResponse)
+		})
+	}
+}
+
+func genClusterTemplate(name, humanReadableName, projectID string, modifiers ...func(*kubermaticv1.ClusterTemplate)) *kubermaticv1.ClusterTemplate {
+	template := &kubermaticv1.ClusterTemplate{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: kubermaticv1.ClusterTemplateSpec{
+			HumanReadableName: humanReadableName,
+			Cluster:           runtime.RawExtension{Raw: []byte(`{"name":"prod"}`)},
+		},
+	}
+	if len(projectID) > 0 {
+		template.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: kubermaticv1.SchemeGroupVersion.String(),
+				Kind:       kubermaticv1.ProjectKindName,
+				Name:       projectID,
+			},
+		}
+	}
+	for _, modifier := range modifiers {
+		modifier(template)
+	}
+	return template
+}
+
+func genSSHKey(name, projectID string) *kubermaticv1.UserSSHKey {
+	return &kubermaticv1.UserSSHKey{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: kubermaticv1.SchemeGroupVersion.String(),
+					Kind:       kubermaticv1.ProjectKindName,
+					Name:       projectID,
+				},
+			},
+		},
+		Spec: kubermaticv1.SSHKeySpec{
+			Name:     name,
+			Clusters: []string{},
+		},
+	}
+}
@@ -0,0 +1,200 @@
+package clustertemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var (
+	// parameterReferenceRegexp matches references to template parameters, e.g. ${version}
+	parameterReferenceRegexp = regexp.MustCompile(`\$\{([a-zA-Z_][a-zA-Z0-9_]*)\}`)
+	parameterNameRegexp      = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// validateParameters checks that the given parameters are well formed
+func validateParameters(parameters []kubermaticv1.ClusterTemplateParameter) error {
+	names := sets.NewString()
+	for _, parameter := range parameters {
+		if !parameterNameRegexp.MatchString(parameter.Name) {
+			return fmt.Errorf("invalid parameter name %q", parameter.Name)
+		}
+		if names.Has(parameter.Name) {
+			return fmt.Errorf("parameter %s is defined more than once", parameter.Name)
+		}
+		names.Insert(parameter.Name)
+
+		if !isSupportedParameterType(parameter.Type) {
+			return fmt.Errorf("parameter %s has an unsupported type %q", parameter.Name, parameter.Type)
+		}
+		if _, err := convertParameterValue(parameter, parameter.Default); err != nil {
+			return fmt.Errorf("invalid default value of parameter %s: %v", parameter.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolveParameters merges the given values with the defaults of the template parameters
+// and converts them to the declared types
+func resolveParameters(parameters []kubermaticv1.ClusterTemplateParameter, values map[string]string) (map[string]interface{}, error) {
+	declared := sets.NewString()
+	resolved := map[string]interface{}{}
+	for _, parameter := range parameters {
+		declared.Insert(parameter.Name)
+
+		value, ok := values[parameter.Name]
+		if !ok {
+			if parameter.Required && len(parameter.Default) == 0 {
+				return nil, fmt.Errorf("parameter %s is required", parameter.Name)
+			}
+			value = parameter.Default
+		}
+		converted, err := convertParameterValue(parameter, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of parameter %s: %v", parameter.Name, err)
+		}
+		resolved[parameter.Name] = converted
+	}
+
+	for name := range values {
+		if !declared.Has(name) {
+			return nil, fmt.Errorf("unknown parameter %s", name)
+		}
+	}
+	return resolved, nil
+}
+
+func isSupportedParameterType(parameterType string) bool {
+	switch parameterType {
+	case "", kubermaticv1.ClusterTemplateParameterTypeString, kubermaticv1.ClusterTemplateParameterTypeInteger, kubermaticv1.ClusterTemplateParameterTypeBoolean:
+		return true
+	}
+	return false
+}
+
+func convertParameterValue(parameter kubermaticv1.ClusterTemplateParameter, value string) (interface{}, error) {
+	switch parameter.Type {
+	case "", kubermaticv1.ClusterTemplateParameterTypeString:
+		return value, nil
+	case kubermaticv1.ClusterTemplateParameterTypeInteger:
+		if len(value) == 0 {
+			return int64(0), nil
+		}
+		return strconv.ParseInt(value, 10, 64)
+	case kubermaticv1.ClusterTemplateParameterTypeBoolean:
+		if len(value) == 0 {
+			return false, nil
+		}
+		return strconv.ParseBool(value)
+	default:
+		return nil, fmt.Errorf("unsupported parameter type %q", parameter.Type)
+	}
+}
+
+// referencedParameters returns the names of all parameters that are referenced in the given JSON document
+func referencedParameters(raw []byte) (sets.String, error) {
+	referenced := sets.NewString()
+	if len(raw) == 0 {
+		return referenced, nil
+	}
+	var document interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	walkStrings(document, func(s string) {
+		for _, match := range parameterReferenceRegexp.FindAllStringSubmatch(s, -1) {
+			referenced.Insert(match[1])
+		}
+	})
+	return referenced, nil
+}
+
+func walkStrings(value interface{}, fn func(string)) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, fn)
+		}
+	case string:
+		fn(v)
+	}
+}
+
+// render replaces the parameter references in all string values of the given JSON document.
+// A string that consists of a single reference is replaced by the typed value of the parameter,
+// which allows to parametrize numbers and booleans.
+func render(raw []byte, values map[string]interface{}) ([]byte, error) {
+	if len(raw) == 0 {
+		return raw, nil
+	}
+	var document interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return nil, err
+	}
+	rendered, err := renderValue(document, values)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rendered)
+}
+
+func renderValue(value interface{}, values map[string]interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			rendered, err := renderValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = rendered
+		}
+		return v, nil
+	case []interface{}:
+		for i, item := range v {
+			rendered, err := renderValue(item, values)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = rendered
+		}
+		return v, nil
+	case string:
+		return renderString(v, values)
+	default:
+		return v, nil
+	}
+}
+
+func renderString(s string, values map[string]interface{}) (interface{}, error) {
+	if match := parameterReferenceRegexp.FindStringSubmatch(s); match != nil && match[0] == s {
+		value, ok := values[match[1]]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %s", match[1])
+		}
+		return value, nil
+	}
+
+	var err error
+	rendered := parameterReferenceRegexp.ReplaceAllStringFunc(s, func(reference string) string {
+		name := parameterReferenceRegexp.FindStringSubmatch(reference)[1]
+		value, ok := values[name]
+		if !ok {
+			err = fmt.Errorf("unknown parameter %s", name)
+			return reference
+		}
+		return fmt.Sprint(value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
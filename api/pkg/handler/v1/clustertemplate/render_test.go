@@ -0,0 +1,167 @@
+package clustertemplate
+
+import (
+	"testing"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestRender(t *testing.T) {
+	testcases := []struct {
+		name           string
+		document       string
+		values         map[string]interface{}
+		expectedResult string
+		expectedError  string
+	}{
+		{
+			name:           "scenario 1: references embedded in strings are replaced",
+			document:       `{"name":"${env}-cluster","labels":["team-${team}"]}`,
+			values:         map[string]interface{}{"env": "prod", "team": "a"},
+			expectedResult: `{"labels":["team-a"],"name":"prod-cluster"}`,
+		},
+		{
+			name:           "scenario 2: a sole reference is replaced by the typed value",
+			document:       `{"spec":{"replicas":"${replicas}","paused":"${paused}","name":"${replicas}"}}`,
+			values:         map[string]interface{}{"replicas": int64(3), "paused": true},
+			expectedResult: `{"spec":{"name":3,"paused":true,"replicas":3}}`,
+		},
+		{
+			name:           "scenario 3: strings without references are left untouched",
+			document:       `{"spec":{"replicas":1,"name":"$name {x}"}}`,
+			values:         map[string]interface{}{},
+			expectedResult: `{"spec":{"name":"$name {x}","replicas":1}}`,
+		},
+		{
+			name:          "scenario 4: unknown parameters are rejected",
+			document:      `{"name":"${env}-cluster"}`,
+			values:        map[string]interface{}{},
+			expectedError: "unknown parameter env",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := render([]byte(tc.document), tc.values)
+			if len(tc.expectedError) > 0 {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Fatalf("expected error %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(result) != tc.expectedResult {
+				t.Fatalf("expected %s, got %s", tc.expectedResult, string(result))
+			}
+		})
+	}
+}
+
+func TestResolveParameters(t *testing.T) {
+	parameters := []kubermaticv1.ClusterTemplateParameter{
+		{Name: "name", Required: true},
+		{Name: "version", Default: "1.15.5"},
+		{Name: "replicas", Type: kubermaticv1.ClusterTemplateParameterTypeInteger, Default: "3"},
+	}
+
+	testcases := []struct {
+		name           string
+		values         map[string]string
+		expectedResult map[string]interface{}
+		expectedError  string
+	}{
+		{
+			name:           "scenario 1: defaults are used for missing values",
+			values:         map[string]string{"name": "prod"},
+			expectedResult: map[string]interface{}{"name": "prod", "version": "1.15.5", "replicas": int64(3)},
+		},
+		{
+			name:           "scenario 2: given values take precedence over the defaults",
+			values:         map[string]string{"name": "prod", "replicas": "5"},
+			expectedResult: map[string]interface{}{"name": "prod", "version": "1.15.5", "replicas": int64(5)},
+		},
+		{
+			name:          "scenario 3: required parameters must be given",
+			values:        map[string]string{},
+			expectedError: "parameter name is required",
+		},
+		{
+			name:          "scenario 4: values must match the parameter type",
+			values:        map[string]string{"name": "prod", "replicas": "many"},
+			expectedError: `invalid value of parameter replicas: strconv.ParseInt: parsing "many": invalid syntax`,
+		},
+		{
+			name:          "scenario 5: undeclared parameters are rejected",
+			values:        map[string]string{"name": "prod", "region": "eu"},
+			expectedError: "unknown parameter region",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := resolveParameters(parameters, tc.values)
+			if len(tc.expectedError) > 0 {
+				if err == nil || err.Error() != tc.expectedError {
+					t.Fatalf("expected error %q, got %v", tc.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(result, tc.expectedResult) {
+				t.Fatalf("expected %v, got %v", tc.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestInstantiate(t *testing.T) {
+	template := &kubermaticv1.ClusterTemplate{
+		Spec: kubermaticv1.ClusterTemplateSpec{
+			HumanReadableName: "production",
+			Parameters: []kubermaticv1.ClusterTemplateParameter{
+				{Name: "name", Required: true},
+				{Name: "replicas", Type: kubermaticv1.ClusterTemplateParameterTypeInteger, Default: "3"},
+			},
+			Cluster: runtime.RawExtension{Raw: []byte(`{"name":"${name}","spec":{"version":"1.15.5","cloud":{"fake":{"token":"dummy_token"},"dc":"fake-dc"}}}`)},
+			NodeDeployments: []runtime.RawExtension{
+				{Raw: []byte(`{"name":"${name}-workers","spec":{"replicas":"${replicas}","template":{"cloud":{},"operatingSystem":{}}}}`)},
+			},
+			Addons: []kubermaticv1.ClusterTemplateAddon{
+				{Name: "addon1", Variables: &runtime.RawExtension{Raw: []byte(`{"owner":"${name}"}`)}},
+			},
+			SSHKeys: []string{"key-abc"},
+		},
+	}
+
+	createReq, initialResources, err := instantiate(template, map[string]string{"name": "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if createReq.Body.Cluster.Name != "prod" {
+		t.Errorf("expected cluster name prod, got %s", createReq.Body.Cluster.Name)
+	}
+	if createReq.Body.Cluster.Type != apiv1.KubernetesClusterType {
+		t.Errorf("expected cluster type %s, got %s", apiv1.KubernetesClusterType, createReq.Body.Cluster.Type)
+	}
+	if len(initialResources.NodeDeployments) != 1 {
+		t.Fatalf("expected a single node deployment, got %d", len(initialResources.NodeDeployments))
+	}
+	if nd := initialResources.NodeDeployments[0]; nd.Name != "prod-workers" || nd.Spec.Replicas != 3 {
+		t.Errorf("expected node deployment prod-workers with 3 replicas, got %s with %d replicas", nd.Name, nd.Spec.Replicas)
+	}
+	if len(initialResources.Addons) != 1 || initialResources.Addons[0].Spec.Variables["owner"] != "prod" {
+		t.Errorf("expected addon1 with rendered variables, got %v", initialResources.Addons)
+	}
+	if !equality.Semantic.DeepEqual(initialResources.SSHKeys, []string{"key-abc"}) {
+		t.Errorf("expected SSH keys [key-abc], got %v", initialResources.SSHKeys)
+	}
+}
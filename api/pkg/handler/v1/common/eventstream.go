@@ -0,0 +1,179 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// StreamMessageEvent is the type of messages which contain an apiv1.Event
+	StreamMessageEvent = "event"
+	// StreamMessageError is the type of messages which contain a StreamError, the stream
+	// continues after errors unless the client disconnects
+	StreamMessageError = "error"
+)
+
+// watchRetryPeriod is the time after which failed watches are restarted
+var watchRetryPeriod = 5 * time.Second
+
+// StreamMessage is a single message of an EventStream
+type StreamMessage struct {
+	// Type is sent as the event name of the server-sent event
+	Type string
+	// Data is sent as JSON
+	Data interface{}
+}
+
+// StreamError describes why a part of an event stream failed
+type StreamError struct {
+	Message string `json:"message"`
+}
+
+// EventStream is returned by the watch endpoints, its messages are sent to the client as
+// server-sent events until the client disconnects. The producers of the messages must stop
+// when the context of the request is done.
+type EventStream struct {
+	Messages <-chan StreamMessage
+}
+
+// SendStreamMessage sends the message unless the context is done first
+func SendStreamMessage(ctx context.Context, messages chan<- StreamMessage, messageType string, data interface{}) bool {
+	select {
+	case messages <- StreamMessage{Type: messageType, Data: data}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// WatchEvents sends the Kubernetes events in the given namespace which match the field selector and
+// are accepted by the filter to the messages channel. The field selector is evaluated by the API server
+// and should restrict the events as far as possible. It sends the existing events first and then
+// watches for new and updated ones until the context is done. Failed watches are restarted and reported
+// as error messages.
+func WatchEvents(ctx context.Context, client kubernetes.Interface, namespace string, fieldSelector fields.Selector, filter func(*corev1.Event) bool, messages chan<- StreamMessage) {
+	// The count of repeated events increases, they are sent again whenever it changes. The counts of
+	// deleted events are forgotten, so the map doesn't outgrow the events which still exist.
+	sentCounts := map[types.UID]int32{}
+	send := func(event *corev1.Event) bool {
+		if !filter(event) || sentCounts[event.UID] == event.Count && event.Count != 0 {
+			return true
+		}
+		sentCounts[event.UID] = event.Count
+		return SendStreamMessage(ctx, messages, StreamMessageEvent, ConvertInternalEventToExternal(*event))
+	}
+	forget := func(event *corev1.Event) {
+		delete(sentCounts, event.UID)
+	}
+	retry := func(err error) bool {
+		if !SendStreamMessage(ctx, messages, StreamMessageError, StreamError{Message: err.Error()}) {
+			return false
+		}
+		select {
+		case <-time.After(watchRetryPeriod):
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		events, err := client.CoreV1().Events(namespace).List(metav1.ListOptions{FieldSelector: fieldSelector.String()})
+		if err != nil {
+			if !retry(fmt.Errorf("failed to list events: %v", err)) {
+				return
+			}
+			continue
+		}
+		// Events which were deleted while no watch was running are not listed anymore
+		listed := sets.NewString()
+		for i := range events.Items {
+			listed.Insert(string(events.Items[i].UID))
+		}
+		for uid := range sentCounts {
+			if !listed.Has(string(uid)) {
+				delete(sentCounts, uid)
+			}
+		}
+		for i := range events.Items {
+			if !send(&events.Items[i]) {
+				return
+			}
+		}
+
+		resourceVersion := events.ResourceVersion
+		for {
+			watcher, err := client.CoreV1().Events(namespace).Watch(metav1.ListOptions{FieldSelector: fieldSelector.String(), ResourceVersion: resourceVersion})
+			if err != nil {
+				if !retry(fmt.Errorf("failed to watch events: %v", err)) {
+					return
+				}
+				break
+			}
+			var expired bool
+			resourceVersion, expired, err = consumeEventWatch(ctx, watcher, resourceVersion, send, forget)
+			watcher.Stop()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if !retry(err) {
+					return
+				}
+			}
+			// The events must be listed again when the resource version is too old
+			if expired {
+				break
+			}
+		}
+	}
+}
+
+// consumeEventWatch passes the watched events to send, and the deleted ones to forget, until the watch
+// ends. It returns the resource version from which the watch can be continued and whether it expired.
+func consumeEventWatch(ctx context.Context, watcher watch.Interface, resourceVersion string, send func(*corev1.Event) bool, forget func(*corev1.Event)) (string, bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return resourceVersion, false, nil
+		case watchEvent, ok := <-watcher.ResultChan():
+			if !ok {
+				return resourceVersion, false, nil
+			}
+			switch watchEvent.Type {
+			case watch.Added, watch.Modified:
+				event, ok := watchEvent.Object.(*corev1.Event)
+				if !ok {
+					continue
+				}
+				resourceVersion = event.ResourceVersion
+				if !send(event) {
+					return resourceVersion, false, nil
+				}
+			case watch.Deleted:
+				event, ok := watchEvent.Object.(*corev1.Event)
+				if !ok {
+					continue
+				}
+				resourceVersion = event.ResourceVersion
+				forget(event)
+			case watch.Error:
+				status := kerrors.FromObject(watchEvent.Object)
+				if kerrors.IsResourceExpired(status) || kerrors.IsGone(status) {
+					return resourceVersion, true, nil
+				}
+				return resourceVersion, true, fmt.Errorf("failed to watch events: %v", status)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	kubermaticapiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func genEvent(uid string, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{Name: uid, Namespace: metav1.NamespaceDefault, UID: types.UID(uid)},
+		Count:      count,
+	}
+}
+
+func TestWatchEvents(t *testing.T) {
+	client := fake.NewSimpleClientset(genEvent("event-1", 1))
+	watcher := watch.NewFake()
+	client.PrependWatchReactor("events", clienttesting.DefaultWatchReactor(watcher, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	messages := make(chan StreamMessage)
+	selector := fields.OneTermEqualSelector("involvedObject.uid", "cluster-uid")
+	go WatchEvents(ctx, client, "", selector, func(*corev1.Event) bool { return true }, messages)
+
+	receive := func() string {
+		select {
+		case message := <-messages:
+			return message.Data.(kubermaticapiv1.Event).Name
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for a message")
+			return ""
+		}
+	}
+
+	if name := receive(); name != "event-1" {
+		t.Fatalf("expected the listed event-1, got %s", name)
+	}
+	// Unchanged events are not sent again, deleted ones are forgotten and sent again once they reappear
+	watcher.Modify(genEvent("event-1", 1))
+	watcher.Delete(genEvent("event-1", 1))
+	watcher.Add(genEvent("event-1", 1))
+	if name := receive(); name != "event-1" {
+		t.Fatalf("expected the recreated event-1, got %s", name)
+	}
+	watcher.Modify(genEvent("event-1", 2))
+	if name := receive(); name != "event-1" {
+		t.Fatalf("expected the repeated event-1, got %s", name)
+	}
+
+	for _, action := range client.Actions() {
+		var restriction fields.Selector
+		switch action := action.(type) {
+		case clienttesting.ListAction:
+			restriction = action.GetListRestrictions().Fields
+		case clienttesting.WatchAction:
+			restriction = action.GetWatchRestrictions().Fields
+		default:
+			continue
+		}
+		if restriction.String() != selector.String() {
+			t.Errorf("expected the %s of events to use the field selector %q, got %q", action.GetVerb(), selector, restriction)
+		}
+	}
+}
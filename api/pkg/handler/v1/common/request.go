@@ -71,7 +71,7 @@ func DecodeDcReq(c context.Context, r *http.Request) (interface{}, error) {
 }
 
 // GetClusterReq defines HTTP request for deleteCluster and getClusterKubeconfig endpoints
-// swagger:parameters getCluster deleteCluster getClusterKubeconfig getOidcClusterKubeconfig listAWSSizesNoCredentials getClusterHealth getClusterUpgrades getClusterMetrics getClusterNodeUpgrades listGCPZonesNoCredentials listAWSZonesNoCredentials listAWSSubnetsNoCredentials listNamespace
+// swagger:parameters getCluster deleteCluster getClusterKubeconfig getOidcClusterKubeconfig listAWSSizesNoCredentials getClusterHealth getClusterUpgrades getClusterMetrics getClusterNodeUpgrades rotateClusterRootCA rotateClusterEncryptionKey getClusterCompliance getClusterAutoscalerStatus listGCPZonesNoCredentials listAWSZonesNoCredentials listAWSSubnetsNoCredentials listNamespace
 type GetClusterReq struct {
 	DCReq
 	// in: path
@@ -214,6 +214,7 @@ func apiSpec(dc *kubermaticv1.Datacenter) (*apiv1.DatacenterSpec, error) {
 	}
 
 	spec.RequiredEmailDomain = dc.Spec.RequiredEmailDomain
+	spec.ComponentFlagPolicy = dc.Spec.ComponentFlagPolicy
 
 	return spec, nil
 }
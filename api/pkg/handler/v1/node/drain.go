@@ -0,0 +1,367 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/gorilla/mux"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	k8cerrors "github.com/kubermatic/kubermatic/api/pkg/util/errors"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultDrainTimeout is used when the drain request doesn't specify a timeout
+	defaultDrainTimeout = time.Minute
+	// maxDrainTimeout limits how long a drain request may block
+	maxDrainTimeout = 5 * time.Minute
+	// drainRetryInterval is the interval in which pods which weren't evicted yet are retried
+	drainRetryInterval = 5 * time.Second
+)
+
+// nodeReq defines HTTP request for the operations on a single node
+// swagger:parameters cordonNode uncordonNode getNodeDrainStatus
+type nodeReq struct {
+	common.GetClusterReq
+	// in: path
+	NodeID string `json:"node_id"`
+}
+
+func DecodeNodeReq(c context.Context, r *http.Request) (interface{}, error) {
+	var req nodeReq
+
+	clusterID, err := common.DecodeClusterID(c, r)
+	if err != nil {
+		return nil, err
+	}
+
+	dcr, err := common.DecodeDcReq(c, r)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeID := mux.Vars(r)["node_id"]
+	if nodeID == "" {
+		return nil, fmt.Errorf("'node_id' parameter is required but was not provided")
+	}
+
+	req.ClusterID = clusterID
+	req.NodeID = nodeID
+	req.DCReq = dcr.(common.DCReq)
+
+	return req, nil
+}
+
+// drainNodeReq defines HTTP request for drainNode
+// swagger:parameters drainNode
+type drainNodeReq struct {
+	nodeReq
+
+	// in: body
+	Body DrainNodeBody
+}
+
+// DrainNodeBody configures the drain of a node
+type DrainNodeBody struct {
+	// TimeoutSeconds is the time to wait for all pods to be evicted, it defaults
+	// to 60 and must not exceed 300. The drain can be continued after a timeout.
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// ReplaceMachine deletes the machine after the drain completed, so that its node deployment creates a new one
+	ReplaceMachine bool `json:"replaceMachine,omitempty"`
+}
+
+func DecodeDrainNode(c context.Context, r *http.Request) (interface{}, error) {
+	var req drainNodeReq
+
+	nReq, err := DecodeNodeReq(c, r)
+	if err != nil {
+		return nil, err
+	}
+	req.nodeReq = nReq.(nodeReq)
+
+	if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+		return nil, k8cerrors.NewBadRequest("unable to parse the request body: %v", err)
+	}
+
+	return req, nil
+}
+
+// CordonNodeEndpoint marks a node as unschedulable and returns its drain status
+func CordonNodeEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return setNodeUnschedulableEndpoint(projectProvider, true)
+}
+
+// UncordonNodeEndpoint marks a node as schedulable and returns its drain status
+func UncordonNodeEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return setNodeUnschedulableEndpoint(projectProvider, false)
+}
+
+func setNodeUnschedulableEndpoint(projectProvider provider.ProjectProvider, unschedulable bool) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(nodeReq)
+		_, client, _, node, err := getNodeForRequest(ctx, projectProvider, req)
+		if err != nil {
+			return nil, err
+		}
+
+		node, err = setNodeUnschedulable(ctx, client, node.Name, unschedulable)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		status, err := getNodeDrainStatus(ctx, client, node)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return status, nil
+	}
+}
+
+// GetNodeDrainStatusEndpoint reports the progress of draining a node without evicting any pods
+func GetNodeDrainStatusEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(nodeReq)
+		_, client, _, node, err := getNodeForRequest(ctx, projectProvider, req)
+		if err != nil {
+			return nil, err
+		}
+
+		status, err := getNodeDrainStatus(ctx, client, node)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return status, nil
+	}
+}
+
+func getNodeDrainStatus(ctx context.Context, client ctrlruntimeclient.Client, node *corev1.Node) (*apiv1.NodeDrainStatus, error) {
+	pods, err := getPodsToEvict(ctx, client, node.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &apiv1.NodeDrainStatus{NodeName: node.Name, RemainingPods: []apiv1.NodeDrainPod{}}
+	for _, pod := range pods {
+		remaining := apiv1.NodeDrainPod{Namespace: pod.Namespace, Name: pod.Name}
+		if pod.DeletionTimestamp != nil {
+			remaining.Reason = "waiting for the pod to terminate"
+		}
+		status.RemainingPods = append(status.RemainingPods, remaining)
+	}
+
+	switch {
+	case !node.Spec.Unschedulable:
+		status.Phase = apiv1.NodeDrainNotStarted
+	case len(status.RemainingPods) > 0:
+		status.Phase = apiv1.NodeDrainInProgress
+	default:
+		status.Phase = apiv1.NodeDrainCompleted
+	}
+	return status, nil
+}
+
+// DrainNodeEndpoint cordons a node and evicts its pods, respecting their PodDisruptionBudgets.
+// It returns once all pods are gone or the timeout passed.
+func DrainNodeEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(drainNodeReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+
+		timeout := defaultDrainTimeout
+		if req.Body.TimeoutSeconds != 0 {
+			timeout = time.Duration(req.Body.TimeoutSeconds) * time.Second
+		}
+		if timeout < 0 || timeout > maxDrainTimeout {
+			return nil, k8cerrors.NewBadRequest("timeoutSeconds must be between 1 and %d", int(maxDrainTimeout.Seconds()))
+		}
+
+		cluster, client, machine, node, err := getNodeForRequest(ctx, projectProvider, req.nodeReq)
+		if err != nil {
+			return nil, err
+		}
+		if req.Body.ReplaceMachine && (machine == nil || metav1.GetControllerOf(machine) == nil) {
+			return nil, k8cerrors.NewBadRequest("node %s doesn't belong to a node deployment, its machine would not be replaced", node.Name)
+		}
+
+		k8sClient, err := clusterProvider.GetK8sClientForCustomerCluster(userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		drainer := &nodeDrainer{
+			client: client,
+			evict: func(pod *corev1.Pod) error {
+				return k8sClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(&policyv1beta1.Eviction{
+					ObjectMeta: metav1.ObjectMeta{Namespace: pod.Namespace, Name: pod.Name},
+				})
+			},
+			retryInterval: drainRetryInterval,
+		}
+		status, err := drainer.drain(ctx, node.Name, timeout)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		if status.Phase == apiv1.NodeDrainCompleted && req.Body.ReplaceMachine {
+			if err := client.Delete(ctx, machine); err != nil && !kerrors.IsNotFound(err) {
+				return nil, common.KubernetesErrorToHTTPError(err)
+			}
+			status.MachineReplaced = true
+		}
+		return status, nil
+	}
+}
+
+func getNodeForRequest(ctx context.Context, projectProvider provider.ProjectProvider, req nodeReq) (*kubermaticv1.Cluster, ctrlruntimeclient.Client, *clusterv1alpha1.Machine, *corev1.Node, error) {
+	clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+	userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+
+	_, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+	if err != nil {
+		return nil, nil, nil, nil, common.KubernetesErrorToHTTPError(err)
+	}
+
+	cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+	if err != nil {
+		return nil, nil, nil, nil, common.KubernetesErrorToHTTPError(err)
+	}
+
+	client, err := clusterProvider.GetClientForCustomerCluster(userInfo, cluster)
+	if err != nil {
+		return nil, nil, nil, nil, common.KubernetesErrorToHTTPError(err)
+	}
+
+	machine, node, err := findMachineAndNode(ctx, req.NodeID, client)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if node == nil {
+		return nil, nil, nil, nil, k8cerrors.NewNotFound("Node", req.NodeID)
+	}
+
+	return cluster, client, machine, node, nil
+}
+
+// nodeDrainer evicts all pods from a node
+type nodeDrainer struct {
+	client ctrlruntimeclient.Client
+	// evict creates an eviction for the pod, which fails if a PodDisruptionBudget doesn't allow it
+	evict         func(*corev1.Pod) error
+	retryInterval time.Duration
+}
+
+// drain cordons the node and evicts its pods until all of them are gone or the timeout passed
+func (d *nodeDrainer) drain(ctx context.Context, nodeName string, timeout time.Duration) (*apiv1.NodeDrainStatus, error) {
+	if _, err := setNodeUnschedulable(ctx, d.client, nodeName, true); err != nil {
+		return nil, fmt.Errorf("failed to cordon node: %v", err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		pods, err := getPodsToEvict(ctx, d.client, nodeName)
+		if err != nil {
+			return nil, err
+		}
+
+		status := &apiv1.NodeDrainStatus{NodeName: nodeName, RemainingPods: []apiv1.NodeDrainPod{}}
+		for i := range pods {
+			pod := &pods[i]
+			remaining := apiv1.NodeDrainPod{Namespace: pod.Namespace, Name: pod.Name}
+			if pod.DeletionTimestamp != nil {
+				remaining.Reason = "waiting for the pod to terminate"
+			} else if err := d.evict(pod); err != nil {
+				switch {
+				case kerrors.IsNotFound(err):
+					continue
+				case kerrors.IsTooManyRequests(err):
+					remaining.Reason = fmt.Sprintf("the eviction is blocked by a PodDisruptionBudget: %v", err)
+				default:
+					remaining.Reason = fmt.Sprintf("failed to evict the pod: %v", err)
+				}
+			} else {
+				remaining.Reason = "evicted, waiting for the pod to terminate"
+			}
+			status.RemainingPods = append(status.RemainingPods, remaining)
+		}
+
+		if len(status.RemainingPods) == 0 {
+			status.Phase = apiv1.NodeDrainCompleted
+			return status, nil
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			status.Phase = apiv1.NodeDrainTimedOut
+			return status, nil
+		}
+		if wait > d.retryInterval {
+			wait = d.retryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// getPodsToEvict returns the pods of the node which must be evicted to drain it. Like kubectl drain,
+// it skips the pods of DaemonSets, which tolerate the unschedulable taint, and static pods.
+func getPodsToEvict(ctx context.Context, client ctrlruntimeclient.Client, nodeName string) ([]corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName)}, pods); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %v", err)
+	}
+
+	var podsToEvict []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName {
+			continue
+		}
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if controllerRef := metav1.GetControllerOf(&pod); controllerRef != nil && controllerRef.Kind == "DaemonSet" {
+			continue
+		}
+		if _, isMirrorPod := pod.Annotations[corev1.MirrorPodAnnotationKey]; isMirrorPod {
+			continue
+		}
+		podsToEvict = append(podsToEvict, pod)
+	}
+	return podsToEvict, nil
+}
+
+func setNodeUnschedulable(ctx context.Context, client ctrlruntimeclient.Client, nodeName string, unschedulable bool) (*corev1.Node, error) {
+	node := &corev1.Node{}
+	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		if err := client.Get(ctx, types.NamespacedName{Name: nodeName}, node); err != nil {
+			return err
+		}
+		if node.Spec.Unschedulable == unschedulable {
+			return nil
+		}
+		node.Spec.Unschedulable = unschedulable
+		return client.Update(ctx, node)
+	})
+	return node, err
+}
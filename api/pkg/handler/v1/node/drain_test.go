@@ -0,0 +1,236 @@
+package node_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func genDrainTestNode(unschedulable bool, machine *clusterv1alpha1.Machine) *corev1.Node {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "venus", UID: "venus-node"},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+	}
+	if machine != nil {
+		isController := true
+		node.OwnerReferences = []metav1.OwnerReference{{APIVersion: "cluster.k8s.io/v1alpha1", Kind: "Machine", Name: machine.Name, UID: machine.UID, Controller: &isController}}
+	}
+	return node
+}
+
+func genDrainTestPod(name, nodeName, ownerKind string) *corev1.Pod {
+	isController := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            name,
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: ownerKind, Name: name, UID: types.UID(name), Controller: &isController}},
+		},
+		Spec:   corev1.PodSpec{NodeName: nodeName},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+func genDrainTestMachine() *clusterv1alpha1.Machine {
+	isController := true
+	machine := test.GenTestMachine("venus-machine", `{"cloudProvider":"digitalocean","cloudProviderSpec":{"token":"dummy-token","region":"fra1","size":"2GB"}, "operatingSystem":"ubuntu", "operatingSystemSpec":{"distUpgradeOnBoot":true}}`, nil, []metav1.OwnerReference{{APIVersion: "cluster.k8s.io/v1alpha1", Kind: "MachineSet", Name: "venus-1", UID: "venus-1", Controller: &isController}})
+	machine.Status.NodeRef = &corev1.ObjectReference{Kind: "Node", Name: "venus", UID: "venus-node"}
+	return machine
+}
+
+func TestCordonAndUncordonNode(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		operation        string
+		node             *corev1.Node
+		expectedResponse string
+	}{
+		{
+			name:             "scenario 1: cordon a node",
+			operation:        "cordon",
+			node:             genDrainTestNode(false, nil),
+			expectedResponse: `{"nodeName":"venus","phase":"InProgress","remainingPods":[{"namespace":"default","name":"web"}]}`,
+		},
+		{
+			name:             "scenario 2: uncordon a node",
+			operation:        "uncordon",
+			node:             genDrainTestNode(true, nil),
+			expectedResponse: `{"nodeName":"venus","phase":"NotStarted","remainingPods":[{"namespace":"default","name":"web"}]}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			kubeObjs := []runtime.Object{
+				tc.node,
+				genDrainTestPod("web", "venus", "ReplicaSet"),
+				genDrainTestPod("logging", "venus", "DaemonSet"),
+				genDrainTestPod("db", "mars", "StatefulSet"),
+			}
+			req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/nodes/venus/%s", test.GenDefaultProject().Name, test.GenDefaultCluster().Name, tc.operation), nil)
+			res := httptest.NewRecorder()
+			ep, _, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, kubeObjs, []runtime.Object{}, test.GenDefaultKubermaticObjects(genTestCluster(true)), nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(http.StatusOK, res, t)
+			test.CompareWithResult(t, res, tc.expectedResponse)
+		})
+	}
+}
+
+func TestGetNodeDrainStatus(t *testing.T) {
+	t.Parallel()
+
+	terminatingPod := genDrainTestPod("cache", "venus", "ReplicaSet")
+	now := metav1.Now()
+	terminatingPod.DeletionTimestamp = &now
+	completedPod := genDrainTestPod("job", "venus", "Job")
+	completedPod.Status.Phase = corev1.PodSucceeded
+
+	testcases := []struct {
+		name             string
+		nodeID           string
+		kubeObjs         []runtime.Object
+		machineObjs      []runtime.Object
+		httpStatus       int
+		expectedResponse string
+	}{
+		{
+			name:             "scenario 1: the drain is in progress",
+			nodeID:           "venus",
+			kubeObjs:         []runtime.Object{genDrainTestNode(true, nil), genDrainTestPod("web", "venus", "ReplicaSet"), terminatingPod, completedPod},
+			httpStatus:       http.StatusOK,
+			expectedResponse: `{"nodeName":"venus","phase":"InProgress","remainingPods":[{"namespace":"default","name":"web"},{"namespace":"default","name":"cache","reason":"waiting for the pod to terminate"}]}`,
+		},
+		{
+			name:             "scenario 2: the drain completed, the node is found by its machine",
+			nodeID:           "venus-machine",
+			kubeObjs:         []runtime.Object{genDrainTestNode(true, genDrainTestMachine()), genDrainTestPod("logging", "venus", "DaemonSet")},
+			machineObjs:      []runtime.Object{genDrainTestMachine()},
+			httpStatus:       http.StatusOK,
+			expectedResponse: `{"nodeName":"venus","phase":"Completed","remainingPods":[]}`,
+		},
+		{
+			name:             "scenario 3: the node doesn't exist",
+			nodeID:           "mars",
+			kubeObjs:         []runtime.Object{genDrainTestNode(true, nil)},
+			httpStatus:       http.StatusNotFound,
+			expectedResponse: `{"error":{"code":404,"message":"Node \"mars\" not found"}}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/nodes/%s/drain", test.GenDefaultProject().Name, test.GenDefaultCluster().Name, tc.nodeID), nil)
+			res := httptest.NewRecorder()
+			ep, _, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, tc.kubeObjs, tc.machineObjs, test.GenDefaultKubermaticObjects(genTestCluster(true)), nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(tc.httpStatus, res, t)
+			test.CompareWithResult(t, res, tc.expectedResponse)
+		})
+	}
+}
+
+func TestDrainNode(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name                    string
+		body                    string
+		kubeObjs                []runtime.Object
+		machineObjs             []runtime.Object
+		httpStatus              int
+		expectedResponse        string
+		expectedMachineReplaced bool
+	}{
+		{
+			name:                    "scenario 1: drain a node without pods to evict and replace its machine",
+			body:                    `{"replaceMachine":true}`,
+			kubeObjs:                []runtime.Object{genDrainTestNode(false, genDrainTestMachine()), genDrainTestPod("logging", "venus", "DaemonSet")},
+			machineObjs:             []runtime.Object{genDrainTestMachine()},
+			httpStatus:              http.StatusOK,
+			expectedResponse:        `{"nodeName":"venus","phase":"Completed","remainingPods":[],"machineReplaced":true}`,
+			expectedMachineReplaced: true,
+		},
+		{
+			name:             "scenario 2: the evicted pods don't terminate before the timeout",
+			body:             `{"timeoutSeconds":1,"replaceMachine":true}`,
+			kubeObjs:         []runtime.Object{genDrainTestNode(false, genDrainTestMachine()), genDrainTestPod("web", "venus", "ReplicaSet")},
+			machineObjs:      []runtime.Object{genDrainTestMachine()},
+			httpStatus:       http.StatusOK,
+			expectedResponse: `{"nodeName":"venus","phase":"TimedOut","remainingPods":[{"namespace":"default","name":"web","reason":"evicted, waiting for the pod to terminate"}]}`,
+		},
+		{
+			name:             "scenario 3: the machine of a node without node deployment can't be replaced",
+			body:             `{"replaceMachine":true}`,
+			kubeObjs:         []runtime.Object{genDrainTestNode(false, nil)},
+			httpStatus:       http.StatusBadRequest,
+			expectedResponse: `{"error":{"code":400,"message":"node venus doesn't belong to a node deployment, its machine would not be replaced"}}`,
+		},
+		{
+			name:             "scenario 4: the timeout is too long",
+			body:             `{"timeoutSeconds":3600}`,
+			kubeObjs:         []runtime.Object{genDrainTestNode(false, nil)},
+			httpStatus:       http.StatusBadRequest,
+			expectedResponse: `{"error":{"code":400,"message":"timeoutSeconds must be between 1 and 300"}}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/nodes/venus/drain", test.GenDefaultProject().Name, test.GenDefaultCluster().Name), strings.NewReader(tc.body))
+			res := httptest.NewRecorder()
+			ep, clients, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, tc.kubeObjs, tc.machineObjs, test.GenDefaultKubermaticObjects(genTestCluster(true)), nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(tc.httpStatus, res, t)
+			test.CompareWithResult(t, res, tc.expectedResponse)
+
+			if tc.httpStatus != http.StatusOK {
+				return
+			}
+			node := &corev1.Node{}
+			if err := clients.FakeClient.Get(context.Background(), types.NamespacedName{Name: "venus"}, node); err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+			if !node.Spec.Unschedulable {
+				t.Errorf("expected the node to be cordoned")
+			}
+			machines := &clusterv1alpha1.MachineList{}
+			if err := clients.FakeClient.List(context.Background(), &ctrlruntimeclient.ListOptions{}, machines); err != nil {
+				t.Fatalf("failed to list machines: %v", err)
+			}
+			if machineReplaced := len(machines.Items) == 0; machineReplaced != tc.expectedMachineReplaced {
+				t.Errorf("expected the machine to be replaced: %v, but it was: %v", tc.expectedMachineReplaced, machineReplaced)
+			}
+		})
+	}
+}
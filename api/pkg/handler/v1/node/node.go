@@ -149,23 +149,16 @@ func outputMachineDeployment(md *clusterv1alpha1.MachineDeployment) (*apiv1.Node
 		deletionTimestamp = &dt
 	}
 
-	operatingSystemSpec, err := machineconversions.GetAPIV1OperatingSystemSpec(md.Spec.Template.Spec)
+	template, err := outputMachineTemplate(&md.Spec.Template.Spec)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get operating system spec from machine deployment: %v", err)
+		return nil, fmt.Errorf("failed to convert the template of the machine deployment: %v", err)
 	}
 
-	cloudSpec, err := machineconversions.GetAPIV2NodeCloudSpec(md.Spec.Template.Spec)
+	minReplicas, maxReplicas := machineresource.AutoscalingBounds(md)
+	maxSurge, maxUnavailable, minReadySeconds := machineresource.RolloutStrategy(md)
+	healthCheck, err := machineresource.HealthCheck(md)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node cloud spec from machine deployment: %v", err)
-	}
-
-	taints := make([]apiv1.TaintSpec, len(md.Spec.Template.Spec.Taints))
-	for i, taint := range md.Spec.Template.Spec.Taints {
-		taints[i] = apiv1.TaintSpec{
-			Effect: string(taint.Effect),
-			Key:    taint.Key,
-			Value:  taint.Value,
-		}
+		return nil, err
 	}
 
 	return &apiv1.NodeDeployment{
@@ -176,22 +169,52 @@ func outputMachineDeployment(md *clusterv1alpha1.MachineDeployment) (*apiv1.Node
 			CreationTimestamp: apiv1.NewTime(md.CreationTimestamp.Time),
 		},
 		Spec: apiv1.NodeDeploymentSpec{
-			Replicas: *md.Spec.Replicas,
-			Template: apiv1.NodeSpec{
-				Labels: label.FilterLabels(label.NodeDeploymentResourceType, md.Spec.Template.Spec.Labels),
-				Taints: taints,
-				Versions: apiv1.NodeVersionInfo{
-					Kubelet: md.Spec.Template.Spec.Versions.Kubelet,
-				},
-				OperatingSystem: *operatingSystemSpec,
-				Cloud:           *cloudSpec,
-			},
-			Paused: &md.Spec.Paused,
+			Replicas:        *md.Spec.Replicas,
+			MinReplicas:     minReplicas,
+			MaxReplicas:     maxReplicas,
+			MaxSurge:        maxSurge,
+			MaxUnavailable:  maxUnavailable,
+			MinReadySeconds: minReadySeconds,
+			HealthCheck:     healthCheck,
+			Template:        *template,
+			Paused:          &md.Spec.Paused,
 		},
 		Status: md.Status,
 	}, nil
 }
 
+// outputMachineTemplate converts the spec of the machines of a MachineDeployment or MachineSet
+func outputMachineTemplate(spec *clusterv1alpha1.MachineSpec) (*apiv1.NodeSpec, error) {
+	operatingSystemSpec, err := machineconversions.GetAPIV1OperatingSystemSpec(*spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operating system spec: %v", err)
+	}
+
+	cloudSpec, err := machineconversions.GetAPIV2NodeCloudSpec(*spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node cloud spec: %v", err)
+	}
+
+	taints := make([]apiv1.TaintSpec, len(spec.Taints))
+	for i, taint := range spec.Taints {
+		taints[i] = apiv1.TaintSpec{
+			Effect: string(taint.Effect),
+			Key:    taint.Key,
+			Value:  taint.Value,
+		}
+	}
+
+	return &apiv1.NodeSpec{
+		Labels: label.FilterLabels(label.NodeDeploymentResourceType, spec.Labels),
+		Taints: taints,
+		Versions: apiv1.NodeVersionInfo{
+			Kubelet: spec.Versions.Kubelet,
+		},
+		OperatingSystem: *operatingSystemSpec,
+		Cloud:           *cloudSpec,
+	}, nil
+}
+
 // listNodeDeploymentsReq defines HTTP request for listNodeDeployments
 // swagger:parameters listNodeDeployments
 type listNodeDeploymentsReq struct {
@@ -258,7 +281,7 @@ func ListNodeDeployments(projectProvider provider.ProjectProvider) endpoint.Endp
 }
 
 // nodeDeploymentReq defines HTTP request for getNodeDeployment
-// swagger:parameters getNodeDeployment
+// swagger:parameters getNodeDeployment listNodeDeploymentRevisions
 type nodeDeploymentReq struct {
 	common.GetClusterReq
 	// in: path
@@ -686,6 +709,15 @@ func PatchNodeDeployment(sshKeyProvider provider.SSHKeyProvider, projectProvider
 		if err = nodeupdate.EnsureVersionCompatible(cluster.Spec.Version.Semver(), kversion); err != nil {
 			return nil, k8cerrors.NewBadRequest(err.Error())
 		}
+		if err := machineresource.ValidateAutoscalingBounds(&patchedNodeDeployment.Spec); err != nil {
+			return nil, k8cerrors.NewBadRequest("invalid autoscaling bounds: %v", err)
+		}
+		if err := machineresource.ValidateRolloutStrategy(&patchedNodeDeployment.Spec); err != nil {
+			return nil, k8cerrors.NewBadRequest("invalid rollout strategy: %v", err)
+		}
+		if err := machineresource.ValidateHealthCheck(&patchedNodeDeployment.Spec); err != nil {
+			return nil, k8cerrors.NewBadRequest("invalid health check: %v", err)
+		}
 
 		_, dc, err := provider.DatacenterFromSeedMap(userInfo, seedsGetter, cluster.Spec.Cloud.DatacenterName)
 		if err != nil {
@@ -715,6 +747,18 @@ func PatchNodeDeployment(sshKeyProvider provider.SSHKeyProvider, projectProvider
 		machineDeployment.Spec.Template.Spec = patchedMachineDeployment.Spec.Template.Spec
 		machineDeployment.Spec.Replicas = patchedMachineDeployment.Spec.Replicas
 		machineDeployment.Spec.Paused = patchedMachineDeployment.Spec.Paused
+		machineDeployment.Spec.Strategy = patchedMachineDeployment.Spec.Strategy
+		machineDeployment.Spec.MinReadySeconds = patchedMachineDeployment.Spec.MinReadySeconds
+		for _, annotation := range []string{machineresource.AutoscalerMinSizeAnnotation, machineresource.AutoscalerMaxSizeAnnotation, machineresource.HealthCheckAnnotation} {
+			if value, ok := patchedMachineDeployment.Annotations[annotation]; ok {
+				if machineDeployment.Annotations == nil {
+					machineDeployment.Annotations = map[string]string{}
+				}
+				machineDeployment.Annotations[annotation] = value
+			} else {
+				delete(machineDeployment.Annotations, annotation)
+			}
+		}
 
 		if err := client.Update(ctx, machineDeployment); err != nil {
 			return nil, fmt.Errorf("failed to update machine deployment: %v", err)
@@ -787,8 +831,8 @@ const (
 	normalType  = "normal"
 )
 
-// nodeDeploymentNodesEventsReq defines HTTP request for listNodeDeploymentNodesEvents endpoint
-// swagger:parameters listNodeDeploymentNodesEvents
+// nodeDeploymentNodesEventsReq defines HTTP request for listNodeDeploymentNodesEvents and watchNodeDeploymentNodesEvents endpoints
+// swagger:parameters listNodeDeploymentNodesEvents watchNodeDeploymentNodesEvents
 type nodeDeploymentNodesEventsReq struct {
 	common.GetClusterReq
 	// in: query
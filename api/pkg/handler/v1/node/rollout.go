@@ -0,0 +1,207 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/Masterminds/semver"
+	"github.com/go-kit/kit/endpoint"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	machineresource "github.com/kubermatic/kubermatic/api/pkg/resources/machine"
+	k8cerrors "github.com/kubermatic/kubermatic/api/pkg/util/errors"
+	"github.com/kubermatic/kubermatic/api/pkg/validation/nodeupdate"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ListNodeDeploymentRevisions returns the revisions of the template of a node deployment, the newest first
+func ListNodeDeploymentRevisions(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(nodeDeploymentReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+
+		_, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		client, err := clusterProvider.GetClientForCustomerCluster(userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		machineDeployment, revisions, err := getRevisions(ctx, client, req.NodeDeploymentID)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		currentRevision := getRevision(&machineDeployment.ObjectMeta)
+		result := []apiv1.NodeDeploymentRevision{}
+		for _, machineSet := range revisions {
+			template, err := outputMachineTemplate(&machineSet.Spec.Template.Spec)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert the template of machine set %s: %v", machineSet.Name, err)
+			}
+			revision := getRevision(&machineSet.ObjectMeta)
+			result = append(result, apiv1.NodeDeploymentRevision{
+				Revision:          revision,
+				CreationTimestamp: apiv1.NewTime(machineSet.CreationTimestamp.Time),
+				Current:           revision == currentRevision,
+				Template:          *template,
+			})
+		}
+
+		return result, nil
+	}
+}
+
+// rollbackNodeDeploymentReq defines HTTP request for rollbackNodeDeployment
+// swagger:parameters rollbackNodeDeployment
+type rollbackNodeDeploymentReq struct {
+	nodeDeploymentReq
+
+	// in: body
+	Body RollbackNodeDeploymentBody
+}
+
+// RollbackNodeDeploymentBody selects the revision a node deployment is rolled back to
+type RollbackNodeDeploymentBody struct {
+	// Revision to roll back to, 0 rolls back to the previous revision
+	Revision int64 `json:"revision"`
+}
+
+func DecodeRollbackNodeDeployment(c context.Context, r *http.Request) (interface{}, error) {
+	var req rollbackNodeDeploymentReq
+
+	ndReq, err := DecodeGetNodeDeployment(c, r)
+	if err != nil {
+		return nil, err
+	}
+	req.nodeDeploymentReq = ndReq.(nodeDeploymentReq)
+
+	if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+		return nil, k8cerrors.NewBadRequest("unable to parse the request body: %v", err)
+	}
+
+	return req, nil
+}
+
+// RollbackNodeDeployment replaces the template of a node deployment with the one of a previous revision,
+// which replaces the nodes like any other change of the template
+func RollbackNodeDeployment(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rollbackNodeDeploymentReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+
+		if req.Body.Revision < 0 {
+			return nil, k8cerrors.NewBadRequest("revision must not be negative")
+		}
+
+		_, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		client, err := clusterProvider.GetClientForCustomerCluster(userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		machineDeployment, revisions, err := getRevisions(ctx, client, req.NodeDeploymentID)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		currentRevision := getRevision(&machineDeployment.ObjectMeta)
+		var target *clusterv1alpha1.MachineSet
+		for i := range revisions {
+			revision := getRevision(&revisions[i].ObjectMeta)
+			// The revisions are sorted newest first, so the first older one is the previous revision
+			if (req.Body.Revision == 0 && revision < currentRevision) || (req.Body.Revision != 0 && revision == req.Body.Revision) {
+				target = &revisions[i]
+				break
+			}
+		}
+		if target == nil {
+			if req.Body.Revision == 0 {
+				return nil, k8cerrors.NewBadRequest("node deployment %s has no previous revision", req.NodeDeploymentID)
+			}
+			return nil, k8cerrors.New(http.StatusNotFound, fmt.Sprintf("revision %d of node deployment %s not found", req.Body.Revision, req.NodeDeploymentID))
+		}
+
+		kubeletVersion, err := semver.NewVersion(target.Spec.Template.Spec.Versions.Kubelet)
+		if err != nil {
+			return nil, k8cerrors.NewBadRequest("failed to parse kubelet version of revision %d: %v", getRevision(&target.ObjectMeta), err)
+		}
+		if err := nodeupdate.EnsureVersionCompatible(cluster.Spec.Version.Semver(), kubeletVersion); err != nil {
+			return nil, k8cerrors.NewBadRequest("cannot roll back to revision %d: %v", getRevision(&target.ObjectMeta), err)
+		}
+
+		// The machine-controller creates a new revision from the restored template, just like the
+		// Deployment controller does for rollbacks
+		machineDeployment.Spec.Template.Spec = target.Spec.Template.Spec
+		if err := client.Update(ctx, machineDeployment); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		return outputMachineDeployment(machineDeployment)
+	}
+}
+
+// getRevisions returns the MachineDeployment and its MachineSets which have a revision, the newest first
+func getRevisions(ctx context.Context, client ctrlruntimeclient.Client, nodeDeploymentID string) (*clusterv1alpha1.MachineDeployment, []clusterv1alpha1.MachineSet, error) {
+	machineDeployment := &clusterv1alpha1.MachineDeployment{}
+	if err := client.Get(ctx, types.NamespacedName{Namespace: metav1.NamespaceSystem, Name: nodeDeploymentID}, machineDeployment); err != nil {
+		return nil, nil, err
+	}
+
+	machineSets := &clusterv1alpha1.MachineSetList{}
+	if err := client.List(ctx, &ctrlruntimeclient.ListOptions{Namespace: metav1.NamespaceSystem, LabelSelector: labels.SelectorFromSet(machineDeployment.Spec.Selector.MatchLabels)}, machineSets); err != nil {
+		return nil, nil, err
+	}
+
+	var revisions []clusterv1alpha1.MachineSet
+	for _, machineSet := range machineSets.Items {
+		if getRevision(&machineSet.ObjectMeta) > 0 {
+			revisions = append(revisions, machineSet)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return getRevision(&revisions[i].ObjectMeta) > getRevision(&revisions[j].ObjectMeta)
+	})
+
+	return machineDeployment, revisions, nil
+}
+
+// getRevision returns the revision of a MachineDeployment or MachineSet, or 0 if it has none
+func getRevision(meta *metav1.ObjectMeta) int64 {
+	revision, err := strconv.ParseInt(meta.Annotations[machineresource.RevisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
@@ -0,0 +1,144 @@
+package node_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
+)
+
+const rolloutTestProviderSpec = `{"cloudProvider":"digitalocean","cloudProviderSpec":{"token":"dummy-token","region":"fra1","size":"2GB"}, "operatingSystem":"ubuntu", "operatingSystemSpec":{"distUpgradeOnBoot":true}}`
+
+func genRolloutTestMachineDeployment(revision string) *clusterv1alpha1.MachineDeployment {
+	md := genTestMachineDeployment("venus", rolloutTestProviderSpec, map[string]string{"md-id": "venus"})
+	md.Annotations = map[string]string{"machinedeployment.clusters.k8s.io/revision": revision}
+	return md
+}
+
+func genRolloutTestMachineSet(name, revision, kubeletVersion string) *clusterv1alpha1.MachineSet {
+	ms := &clusterv1alpha1.MachineSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   metav1.NamespaceSystem,
+			Labels:      map[string]string{"md-id": "venus"},
+			Annotations: map[string]string{"machinedeployment.clusters.k8s.io/revision": revision},
+		},
+	}
+	ms.Spec.Template.Spec = genTestMachineDeployment("venus", rolloutTestProviderSpec, nil).Spec.Template.Spec
+	ms.Spec.Template.Spec.Versions.Kubelet = kubeletVersion
+	return ms
+}
+
+func TestListNodeDeploymentRevisions(t *testing.T) {
+	t.Parallel()
+
+	machineObjs := []runtime.Object{
+		genRolloutTestMachineDeployment("2"),
+		genRolloutTestMachineSet("venus-1", "1", "v9.8.0"),
+		genRolloutTestMachineSet("venus-2", "2", "v9.9.9"),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/nodedeployments/venus/revisions", test.GenDefaultProject().Name, test.GenDefaultCluster().Name), nil)
+	res := httptest.NewRecorder()
+	ep, _, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, []runtime.Object{}, machineObjs, test.GenDefaultKubermaticObjects(genTestCluster(true)), nil, nil, hack.NewTestRouting)
+	if err != nil {
+		t.Fatalf("failed to create test endpoint due to %v", err)
+	}
+
+	ep.ServeHTTP(res, req)
+
+	test.CheckStatusCode(http.StatusOK, res, t)
+	test.CompareWithResult(t, res, `[{"revision":2,"creationTimestamp":"0001-01-01T00:00:00Z","current":true,"template":{"cloud":{"digitalocean":{"size":"2GB","backups":false,"ipv6":false,"monitoring":false,"tags":null}},"operatingSystem":{"ubuntu":{"distUpgradeOnBoot":true}},"versions":{"kubelet":"v9.9.9"}}},{"revision":1,"creationTimestamp":"0001-01-01T00:00:00Z","current":false,"template":{"cloud":{"digitalocean":{"size":"2GB","backups":false,"ipv6":false,"monitoring":false,"tags":null}},"operatingSystem":{"ubuntu":{"distUpgradeOnBoot":true}},"versions":{"kubelet":"v9.8.0"}}}]`)
+}
+
+func TestRollbackNodeDeployment(t *testing.T) {
+	t.Parallel()
+
+	testcases := []struct {
+		name             string
+		body             string
+		machineObjs      []runtime.Object
+		httpStatus       int
+		expectedResponse string
+	}{
+		{
+			name: "scenario 1: roll back to the previous revision",
+			body: `{}`,
+			machineObjs: []runtime.Object{
+				genRolloutTestMachineDeployment("3"),
+				genRolloutTestMachineSet("venus-1", "1", "v9.7.0"),
+				genRolloutTestMachineSet("venus-2", "2", "v9.8.0"),
+				genRolloutTestMachineSet("venus-3", "3", "v9.9.9"),
+			},
+			httpStatus:       http.StatusOK,
+			expectedResponse: `{"id":"venus","name":"venus","creationTimestamp":"0001-01-01T00:00:00Z","spec":{"replicas":1,"template":{"cloud":{"digitalocean":{"size":"2GB","backups":false,"ipv6":false,"monitoring":false,"tags":null}},"operatingSystem":{"ubuntu":{"distUpgradeOnBoot":true}},"versions":{"kubelet":"v9.8.0"}},"paused":false},"status":{}}`,
+		},
+		{
+			name: "scenario 2: roll back to a given revision",
+			body: `{"revision":1}`,
+			machineObjs: []runtime.Object{
+				genRolloutTestMachineDeployment("3"),
+				genRolloutTestMachineSet("venus-1", "1", "v9.8.1"),
+				genRolloutTestMachineSet("venus-2", "2", "v9.8.0"),
+				genRolloutTestMachineSet("venus-3", "3", "v9.9.9"),
+			},
+			httpStatus:       http.StatusOK,
+			expectedResponse: `{"id":"venus","name":"venus","creationTimestamp":"0001-01-01T00:00:00Z","spec":{"replicas":1,"template":{"cloud":{"digitalocean":{"size":"2GB","backups":false,"ipv6":false,"monitoring":false,"tags":null}},"operatingSystem":{"ubuntu":{"distUpgradeOnBoot":true}},"versions":{"kubelet":"v9.8.1"}},"paused":false},"status":{}}`,
+		},
+		{
+			name: "scenario 3: the revision doesn't exist",
+			body: `{"revision":5}`,
+			machineObjs: []runtime.Object{
+				genRolloutTestMachineDeployment("1"),
+				genRolloutTestMachineSet("venus-1", "1", "v9.9.9"),
+			},
+			httpStatus:       http.StatusNotFound,
+			expectedResponse: `{"error":{"code":404,"message":"revision 5 of node deployment venus not found"}}`,
+		},
+		{
+			name: "scenario 4: there is no previous revision",
+			body: `{}`,
+			machineObjs: []runtime.Object{
+				genRolloutTestMachineDeployment("1"),
+				genRolloutTestMachineSet("venus-1", "1", "v9.9.9"),
+			},
+			httpStatus:       http.StatusBadRequest,
+			expectedResponse: `{"error":{"code":400,"message":"node deployment venus has no previous revision"}}`,
+		},
+		{
+			name: "scenario 5: the kubelet of the revision is too old for the control plane",
+			body: `{}`,
+			machineObjs: []runtime.Object{
+				genRolloutTestMachineDeployment("2"),
+				genRolloutTestMachineSet("venus-1", "1", "v9.6.0"),
+				genRolloutTestMachineSet("venus-2", "2", "v9.9.9"),
+			},
+			httpStatus:       http.StatusBadRequest,
+			expectedResponse: `{"error":{"code":400,"message":"cannot roll back to revision 1: kubelet version 9.6.0 is not compatible with control plane version 9.9.9"}}`,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/nodedeployments/venus/rollback", test.GenDefaultProject().Name, test.GenDefaultCluster().Name), strings.NewReader(tc.body))
+			res := httptest.NewRecorder()
+			ep, _, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, []runtime.Object{}, tc.machineObjs, test.GenDefaultKubermaticObjects(genTestCluster(true)), nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(tc.httpStatus, res, t)
+			test.CompareWithResult(t, res, tc.expectedResponse)
+		})
+	}
+}
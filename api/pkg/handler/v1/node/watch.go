@@ -0,0 +1,129 @@
+package node
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// WatchNodeDeploymentNodesEvents streams the events of the node deployment and of its node sets and nodes
+func WatchNodeDeploymentNodesEvents() endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(nodeDeploymentNodesEventsReq)
+		clusterProvider := ctx.Value(middleware.ClusterProviderContextKey).(provider.ClusterProvider)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+
+		cluster, err := clusterProvider.Get(userInfo, req.ClusterID, &provider.ClusterGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		client, err := clusterProvider.GetK8sClientForCustomerCluster(userInfo, cluster)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		objects := &nodeDeploymentObjects{
+			ctx:              ctx,
+			clusterProvider:  clusterProvider,
+			userInfo:         userInfo,
+			cluster:          cluster,
+			nodeDeploymentID: req.NodeDeploymentID,
+		}
+		if err := objects.refresh(); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		eventType := ""
+		switch req.Type {
+		case warningType:
+			eventType = corev1.EventTypeWarning
+		case normalType:
+			eventType = corev1.EventTypeNormal
+		}
+
+		messages := make(chan common.StreamMessage)
+		go common.WatchEvents(ctx, client, metav1.NamespaceSystem, fields.Everything(), func(event *corev1.Event) bool {
+			return (eventType == "" || event.Type == eventType) && objects.owns(event.InvolvedObject)
+		}, messages)
+
+		return &common.EventStream{Messages: messages}, nil
+	}
+}
+
+// nodeDeploymentObjects keeps track of the UIDs of the machine deployment, machine sets and machines
+// which belong to a node deployment. It is only used by a single event watch and thus not synchronized.
+type nodeDeploymentObjects struct {
+	ctx              context.Context
+	clusterProvider  provider.ClusterProvider
+	userInfo         *provider.UserInfo
+	cluster          *kubermaticv1.Cluster
+	nodeDeploymentID string
+
+	uids sets.String
+	// foreignUIDs are the UIDs of machines and machine sets which belong to other node deployments,
+	// they are remembered to not list the objects again for each of their events
+	foreignUIDs sets.String
+}
+
+// owns returns whether the object belongs to the node deployment. Machines and machine sets are
+// created after the watch started, so the objects are listed again when an unknown one shows up.
+func (o *nodeDeploymentObjects) owns(object corev1.ObjectReference) bool {
+	uid := string(object.UID)
+	if o.uids.Has(uid) {
+		return true
+	}
+	if o.foreignUIDs.Has(uid) || (object.Kind != "Machine" && object.Kind != "MachineSet") {
+		return false
+	}
+	if err := o.refresh(); err != nil {
+		return false
+	}
+	if o.uids.Has(uid) {
+		return true
+	}
+	o.foreignUIDs.Insert(uid)
+	return false
+}
+
+func (o *nodeDeploymentObjects) refresh() error {
+	machineDeployment, err := getMachineDeploymentForNodeDeployment(o.ctx, o.clusterProvider, o.userInfo, o.cluster, o.nodeDeploymentID)
+	if err != nil {
+		return err
+	}
+	machineSets, err := getMachineSetsForNodeDeployment(o.ctx, o.clusterProvider, o.userInfo, o.cluster, o.nodeDeploymentID)
+	if err != nil {
+		return err
+	}
+	machines, err := getMachinesForNodeDeployment(o.ctx, o.clusterProvider, o.userInfo, o.cluster, o.nodeDeploymentID)
+	if err != nil {
+		return err
+	}
+
+	uids := []types.UID{machineDeployment.UID}
+	for _, machineSet := range machineSets.Items {
+		uids = append(uids, machineSet.UID)
+	}
+	for _, machine := range machines.Items {
+		uids = append(uids, machine.UID)
+	}
+	o.uids = sets.NewString()
+	if o.foreignUIDs == nil {
+		o.foreignUIDs = sets.NewString()
+	}
+	for _, uid := range uids {
+		o.uids.Insert(string(uid))
+	}
+	return nil
+}
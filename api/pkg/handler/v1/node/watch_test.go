@@ -0,0 +1,55 @@
+package node_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestWatchNodeDeploymentNodesEvents(t *testing.T) {
+	t.Parallel()
+	kubernetesObj := []runtime.Object{
+		genTestEvent("event-1", corev1.EventTypeNormal, "Started", "message started", "Machine", "venus-1-machine"),
+		genTestEvent("event-2", corev1.EventTypeWarning, "Killed", "message killed", "Machine", "venus-1-machine"),
+		genTestEvent("event-3", corev1.EventTypeWarning, "Killed", "message killed", "Machine", "mars-1-machine"),
+		genTestEvent("event-4", corev1.EventTypeWarning, "BackOff", "message back-off", "Pod", "pod"),
+	}
+	machineObj := []runtime.Object{
+		genTestMachineDeployment("venus", `{"cloudProvider":"digitalocean","cloudProviderSpec":{"token":"dummy-token","region":"fra1","size":"2GB"}, "operatingSystem":"ubuntu", "operatingSystemSpec":{"distUpgradeOnBoot":true}}`, map[string]string{"md-id": "123"}),
+		genTestMachine("venus-1", `{"cloudProvider":"digitalocean","cloudProviderSpec":{"token":"dummy-token","region":"fra1","size":"2GB"},"operatingSystem":"ubuntu","containerRuntimeInfo":{"name":"docker","version":"1.13"},"operatingSystemSpec":{"distUpgradeOnBoot":true}}`, map[string]string{"md-id": "123"}, nil),
+	}
+	kubermaticObj := test.GenDefaultKubermaticObjects(test.GenDefaultCluster())
+
+	ep, _, err := test.CreateTestEndpointAndGetClients(*test.GenDefaultAPIUser(), nil, kubernetesObj, machineObj, kubermaticObj, nil, nil, hack.NewTestRouting)
+	if err != nil {
+		t.Fatalf("failed to create test endpoint due to %v", err)
+	}
+
+	// The stream only ends when the client disconnects
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/projects/%s/dc/us-central1/clusters/%s/nodedeployments/venus/nodes/events/watch?type=warning", test.GenDefaultProject().Name, test.GenDefaultCluster().Name), nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	ep.ServeHTTP(res, req)
+
+	test.CheckStatusCode(http.StatusOK, res, t)
+	if contentType := res.Header().Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("expected the content type text/event-stream, got %q", contentType)
+	}
+	expected := `event: event
+data: {"name":"event-2","creationTimestamp":"0001-01-01T00:00:00Z","message":"message killed","type":"Warning","involvedObject":{"type":"Node","namespace":"kube-system","name":"testMachine"},"lastTimestamp":"0001-01-01T00:00:00Z","count":1}
+
+`
+	if body := res.Body.String(); body != expected {
+		t.Errorf("expected the stream\n%s\ngot\n%s", expected, body)
+	}
+}
@@ -0,0 +1,251 @@
+package notificationchannel
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/gorilla/mux"
+
+	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/middleware"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/v1/common"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+	"github.com/kubermatic/kubermatic/api/pkg/util/errors"
+	"github.com/kubermatic/kubermatic/api/pkg/util/network"
+)
+
+func ListEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(listReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		apiChannels := apiv1.NotificationChannelList{}
+		for _, channel := range project.Spec.NotificationChannels {
+			apiChannels = append(apiChannels, convertInternalNotificationChannelToExternal(channel))
+		}
+		return apiChannels, nil
+	}
+}
+
+func CreateEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(createReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		channel := convertExternalNotificationChannelToInternal(req.Body)
+		if err := validateNotificationChannel(channel); err != nil {
+			return nil, errors.NewBadRequest("invalid notification channel: %v", err)
+		}
+
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		for _, existingChannel := range project.Spec.NotificationChannels {
+			if existingChannel.Name == channel.Name {
+				return nil, errors.NewAlreadyExists("notification channel", channel.Name)
+			}
+		}
+
+		project.Spec.NotificationChannels = append(project.Spec.NotificationChannels, channel)
+		if _, err := projectProvider.Update(userInfo, project); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return convertInternalNotificationChannelToExternal(channel), nil
+	}
+}
+
+func DeleteEndpoint(projectProvider provider.ProjectProvider) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req, ok := request.(channelReq)
+		if !ok {
+			return nil, errors.NewBadRequest("invalid request")
+		}
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		var channels []kubermaticv1.NotificationChannel
+		for _, channel := range project.Spec.NotificationChannels {
+			if channel.Name != req.ChannelName {
+				channels = append(channels, channel)
+			}
+		}
+		if len(channels) == len(project.Spec.NotificationChannels) {
+			return nil, errors.NewNotFound("notification channel", req.ChannelName)
+		}
+
+		project.Spec.NotificationChannels = channels
+		if _, err := projectProvider.Update(userInfo, project); err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		return nil, nil
+	}
+}
+
+func validateNotificationChannel(channel kubermaticv1.NotificationChannel) error {
+	if channel.Name == "" {
+		return fmt.Errorf("the name is required")
+	}
+
+	targets := 0
+	if channel.Webhook != nil {
+		targets++
+		if err := validateURL(channel.Webhook.URL); err != nil {
+			return fmt.Errorf("invalid webhook URL: %v", err)
+		}
+	}
+	if channel.Slack != nil {
+		targets++
+		if err := validateURL(channel.Slack.URL); err != nil {
+			return fmt.Errorf("invalid Slack webhook URL: %v", err)
+		}
+	}
+	if channel.Email != nil {
+		targets++
+		if len(channel.Email.To) == 0 {
+			return fmt.Errorf("at least one email recipient is required")
+		}
+		for _, to := range channel.Email.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("invalid email recipient %q: %v", to, err)
+			}
+		}
+	}
+	if targets != 1 {
+		return fmt.Errorf("exactly one of webhook, slack and email must be set")
+	}
+
+	for _, event := range channel.Events {
+		if !isKnownEvent(event) {
+			return fmt.Errorf("unknown event %q, must be one of %v", event, kubermaticv1.AllNotificationEventTypes)
+		}
+	}
+	return nil
+}
+
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http or https URL", rawURL)
+	}
+	// Host names get checked again by the cluster notifier after resolving them
+	host := u.Hostname()
+	if ip := net.ParseIP(host); strings.EqualFold(host, "localhost") || (ip != nil && network.IsInternalIP(ip)) {
+		return fmt.Errorf("%q points to an internal address", rawURL)
+	}
+	return nil
+}
+
+func isKnownEvent(event kubermaticv1.NotificationEventType) bool {
+	for _, knownEvent := range kubermaticv1.AllNotificationEventTypes {
+		if event == knownEvent {
+			return true
+		}
+	}
+	return false
+}
+
+func convertInternalNotificationChannelToExternal(channel kubermaticv1.NotificationChannel) apiv1.NotificationChannel {
+	return apiv1.NotificationChannel{
+		Name:    channel.Name,
+		Webhook: channel.Webhook,
+		Slack:   channel.Slack,
+		Email:   channel.Email,
+		Events:  channel.Events,
+	}
+}
+
+func convertExternalNotificationChannelToInternal(channel apiv1.NotificationChannel) kubermaticv1.NotificationChannel {
+	return kubermaticv1.NotificationChannel{
+		Name:    channel.Name,
+		Webhook: channel.Webhook,
+		Slack:   channel.Slack,
+		Email:   channel.Email,
+		Events:  channel.Events,
+	}
+}
+
+// listReq defines HTTP request for listNotificationChannels endpoint
+// swagger:parameters listNotificationChannels
+type listReq struct {
+	common.ProjectReq
+}
+
+func DecodeListReq(c context.Context, r *http.Request) (interface{}, error) {
+	pr, err := common.DecodeProjectRequest(c, r)
+	if err != nil {
+		return nil, err
+	}
+	return listReq{ProjectReq: pr.(common.ProjectReq)}, nil
+}
+
+// createReq defines HTTP request for createNotificationChannel endpoint
+// swagger:parameters createNotificationChannel
+type createReq struct {
+	common.ProjectReq
+	// in: body
+	Body apiv1.NotificationChannel
+}
+
+func DecodeCreateReq(c context.Context, r *http.Request) (interface{}, error) {
+	var req createReq
+
+	pr, err := common.DecodeProjectRequest(c, r)
+	if err != nil {
+		return nil, err
+	}
+	req.ProjectReq = pr.(common.ProjectReq)
+
+	if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil {
+		return nil, errors.NewBadRequest("unable to parse the input, err = %v", err.Error())
+	}
+	return req, nil
+}
+
+// channelReq defines HTTP request for deleteNotificationChannel endpoint
+// swagger:parameters deleteNotificationChannel
+type channelReq struct {
+	common.ProjectReq
+	// in: path
+	// required: true
+	ChannelName string `json:"channel_name"`
+}
+
+func DecodeChannelReq(c context.Context, r *http.Request) (interface{}, error) {
+	var req channelReq
+
+	pr, err := common.DecodeProjectRequest(c, r)
+	if err != nil {
+		return nil, err
+	}
+	req.ProjectReq = pr.(common.ProjectReq)
+
+	req.ChannelName = mux.Vars(r)["channel_name"]
+	if req.ChannelName == "" {
+		return nil, errors.NewBadRequest("'channel_name' parameter is required but was not provided")
+	}
+	return req, nil
+}
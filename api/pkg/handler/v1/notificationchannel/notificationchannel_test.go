@@ -0,0 +1,147 @@
+package notificationchannel_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func genProjectWithChannels(channels ...kubermaticv1.NotificationChannel) *kubermaticv1.Project {
+	project := test.GenDefaultProject()
+	project.Spec.NotificationChannels = channels
+	return project
+}
+
+var slackChannel = kubermaticv1.NotificationChannel{
+	Name:   "ops",
+	Slack:  &kubermaticv1.SlackNotificationChannel{URL: "https://hooks.slack.com/services/abc", Channel: "#ops"},
+	Events: []kubermaticv1.NotificationEventType{kubermaticv1.NotificationEventClusterHealthChanged},
+}
+
+func TestListNotificationChannelsEndpoint(t *testing.T) {
+	t.Parallel()
+	req := httptest.NewRequest("GET", fmt.Sprintf("/api/v1/projects/%s/notificationchannels", test.GenDefaultProject().Name), nil)
+	res := httptest.NewRecorder()
+	kubermaticObjs := []runtime.Object{genProjectWithChannels(slackChannel), test.GenDefaultUser(), test.GenDefaultOwnerBinding()}
+	ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, kubermaticObjs, nil, nil, hack.NewTestRouting)
+	if err != nil {
+		t.Fatalf("failed to create test endpoint due to %v", err)
+	}
+
+	ep.ServeHTTP(res, req)
+
+	test.CheckStatusCode(http.StatusOK, res, t)
+	test.CompareWithResult(t, res, `[{"name":"ops","slack":{"url":"https://hooks.slack.com/services/abc","channel":"#ops"},"events":["ClusterHealthChanged"]}]`)
+}
+
+func TestCreateNotificationChannelEndpoint(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		Name             string
+		Body             string
+		ExpectedResponse string
+		HTTPStatus       int
+	}{
+		{
+			Name:             "scenario 1: an email channel is created",
+			Body:             `{"name":"oncall","email":{"to":["oncall@acme.com"]},"events":["ClusterDeleted","CertificateExpiring"]}`,
+			ExpectedResponse: `{"name":"oncall","email":{"to":["oncall@acme.com"]},"events":["ClusterDeleted","CertificateExpiring"]}`,
+			HTTPStatus:       http.StatusCreated,
+		},
+		{
+			Name:             "scenario 2: the name of a channel must be unique within a project",
+			Body:             `{"name":"ops","webhook":{"url":"https://example.com/hook"}}`,
+			ExpectedResponse: `{"error":{"code":409,"message":"notification channel \"ops\" already exists"}}`,
+			HTTPStatus:       http.StatusConflict,
+		},
+		{
+			Name:             "scenario 3: a channel needs exactly one target",
+			Body:             `{"name":"both","webhook":{"url":"https://example.com/hook"},"slack":{"url":"https://example.com/slack"}}`,
+			ExpectedResponse: `{"error":{"code":400,"message":"invalid notification channel: exactly one of webhook, slack and email must be set"}}`,
+			HTTPStatus:       http.StatusBadRequest,
+		},
+		{
+			Name:             "scenario 4: webhook URLs must be absolute",
+			Body:             `{"name":"hook","webhook":{"url":"example.com/hook"}}`,
+			ExpectedResponse: `{"error":{"code":400,"message":"invalid notification channel: invalid webhook URL: \"example.com/hook\" is not an absolute http or https URL"}}`,
+			HTTPStatus:       http.StatusBadRequest,
+		},
+		{
+			Name:             "scenario 5: webhook URLs must not point to internal addresses",
+			Body:             `{"name":"hook","webhook":{"url":"http://169.254.169.254/latest/meta-data"}}`,
+			ExpectedResponse: `{"error":{"code":400,"message":"invalid notification channel: invalid webhook URL: \"http://169.254.169.254/latest/meta-data\" points to an internal address"}}`,
+			HTTPStatus:       http.StatusBadRequest,
+		},
+		{
+			Name:             "scenario 6: unknown events are rejected",
+			Body:             `{"name":"hook","webhook":{"url":"https://example.com/hook"},"events":["NodeDeleted"]}`,
+			ExpectedResponse: `{"error":{"code":400,"message":"invalid notification channel: unknown event \"NodeDeleted\", must be one of [ClusterHealthChanged ClusterUpgradeFailed AddonFailed CertificateExpiring ClusterDeleted]"}}`,
+			HTTPStatus:       http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/projects/%s/notificationchannels", test.GenDefaultProject().Name), strings.NewReader(tc.Body))
+			res := httptest.NewRecorder()
+			kubermaticObjs := []runtime.Object{genProjectWithChannels(slackChannel), test.GenDefaultUser(), test.GenDefaultOwnerBinding()}
+			ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, kubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(tc.HTTPStatus, res, t)
+			test.CompareWithResult(t, res, tc.ExpectedResponse)
+		})
+	}
+}
+
+func TestDeleteNotificationChannelEndpoint(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		Name             string
+		ChannelName      string
+		ExpectedResponse string
+		HTTPStatus       int
+	}{
+		{
+			Name:             "scenario 1: a channel is deleted",
+			ChannelName:      "ops",
+			ExpectedResponse: `{}`,
+			HTTPStatus:       http.StatusOK,
+		},
+		{
+			Name:             "scenario 2: deleting an unknown channel fails",
+			ChannelName:      "unknown",
+			ExpectedResponse: `{"error":{"code":404,"message":"notification channel \"unknown\" not found"}}`,
+			HTTPStatus:       http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req := httptest.NewRequest("DELETE", fmt.Sprintf("/api/v1/projects/%s/notificationchannels/%s", test.GenDefaultProject().Name, tc.ChannelName), nil)
+			res := httptest.NewRecorder()
+			kubermaticObjs := []runtime.Object{genProjectWithChannels(slackChannel), test.GenDefaultUser(), test.GenDefaultOwnerBinding()}
+			ep, err := test.CreateTestEndpoint(*test.GenDefaultAPIUser(), []runtime.Object{}, kubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			ep.ServeHTTP(res, req)
+
+			test.CheckStatusCode(tc.HTTPStatus, res, t)
+			test.CompareWithResult(t, res, tc.ExpectedResponse)
+		})
+	}
+}
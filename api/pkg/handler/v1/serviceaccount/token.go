@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"time"
 	"unicode/utf8"
 
 	"github.com/go-kit/kit/endpoint"
@@ -22,6 +24,13 @@ import (
 	"k8s.io/apimachinery/pkg/util/rand"
 )
 
+const (
+	// defaultRotationGracePeriod is how long a token stays valid after it got rotated
+	defaultRotationGracePeriod = time.Hour
+	// maxRotationGracePeriod is the maximum time a token stays valid after it got rotated
+	maxRotationGracePeriod = 7 * 24 * time.Hour
+)
+
 // CreateTokenEndpoint creates a token for the given service account
 func CreateTokenEndpoint(projectProvider provider.ProjectProvider, serviceAccountProvider provider.ServiceAccountProvider, serviceAccountTokenProvider provider.ServiceAccountTokenProvider, tokenAuthenticator serviceaccount.TokenAuthenticator, tokenGenerator serviceaccount.TokenGenerator) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -32,6 +41,12 @@ func CreateTokenEndpoint(projectProvider provider.ProjectProvider, serviceAccoun
 			return nil, errors.NewBadRequest(err.Error())
 		}
 
+		callerScopes, _ := ctx.Value(middleware.TokenScopesContextKey).([]serviceaccount.Scope)
+		scopes := convertExternalScopesToInternal(req.Body.Scopes)
+		if !serviceaccount.ScopesCover(callerScopes, scopes) {
+			return nil, errors.New(http.StatusForbidden, "forbidden: the requested scopes exceed the scopes of the token making the request")
+		}
+
 		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
 		if err != nil {
 			return nil, common.KubernetesErrorToHTTPError(err)
@@ -53,7 +68,11 @@ func CreateTokenEndpoint(projectProvider provider.ProjectProvider, serviceAccoun
 
 		tokenID := rand.String(10)
 
-		token, err := tokenGenerator.Generate(serviceaccount.Claims(sa.Spec.Email, project.Name, tokenID))
+		expiry := serviceaccount.DefaultExpiry(serviceaccount.Now())
+		if !req.Body.Expiry.IsZero() {
+			expiry = req.Body.Expiry.Time
+		}
+		token, err := tokenGenerator.Generate(serviceaccount.ScopedClaims(sa.Spec.Email, project.Name, tokenID, expiry, scopes))
 		if err != nil {
 			return nil, errors.New(http.StatusInternalServerError, "can not generate token data")
 		}
@@ -127,7 +146,8 @@ func UpdateTokenEndpoint(projectProvider provider.ProjectProvider, serviceAccoun
 			return nil, errors.NewBadRequest(err.Error())
 		}
 
-		secret, err := updateToken(projectProvider, serviceAccountProvider, serviceAccountTokenProvider, userInfo, tokenGenerator, req.ProjectID, req.ServiceAccountID, req.TokenID, req.Body.Name, true)
+		callerScopes, _ := ctx.Value(middleware.TokenScopesContextKey).([]serviceaccount.Scope)
+		secret, err := updateToken(projectProvider, serviceAccountProvider, serviceAccountTokenProvider, userInfo, tokenAuthenticator, tokenGenerator, req.ProjectID, req.ServiceAccountID, req.TokenID, req.Body.Name, callerScopes, true)
 		if err != nil {
 			return nil, common.KubernetesErrorToHTTPError(err)
 		}
@@ -158,7 +178,7 @@ func PatchTokenEndpoint(projectProvider provider.ProjectProvider, serviceAccount
 			return nil, errors.NewBadRequest("new name can not be empty")
 		}
 
-		secret, err := updateToken(projectProvider, serviceAccountProvider, serviceAccountTokenProvider, userInfo, tokenGenerator, req.ProjectID, req.ServiceAccountID, req.TokenID, tokenReq.Name, false)
+		secret, err := updateToken(projectProvider, serviceAccountProvider, serviceAccountTokenProvider, userInfo, tokenAuthenticator, tokenGenerator, req.ProjectID, req.ServiceAccountID, req.TokenID, tokenReq.Name, nil, false)
 		if err != nil {
 			return nil, common.KubernetesErrorToHTTPError(err)
 		}
@@ -199,9 +219,93 @@ func DeleteTokenEndpoint(projectProvider provider.ProjectProvider, serviceAccoun
 	}
 }
 
+// RotateTokenEndpoint replaces the token of the given service account with a new one. The replaced token stays valid
+// during the grace period, which allows to roll out the new token without downtime.
+func RotateTokenEndpoint(projectProvider provider.ProjectProvider, serviceAccountProvider provider.ServiceAccountProvider, serviceAccountTokenProvider provider.ServiceAccountTokenProvider, tokenAuthenticator serviceaccount.TokenAuthenticator, tokenGenerator serviceaccount.TokenGenerator) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(rotateTokenReq)
+		userInfo := ctx.Value(middleware.UserInfoContextKey).(*provider.UserInfo)
+		err := req.Validate()
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+
+		project, err := projectProvider.Get(userInfo, req.ProjectID, &provider.ProjectGetOptions{})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		sa, err := serviceAccountProvider.Get(userInfo, req.ServiceAccountID, &provider.ServiceAccountGetOptions{RemovePrefix: false})
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		existingSecret, err := serviceAccountTokenProvider.Get(userInfo, req.TokenID)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+		existingToken, ok := existingSecret.Data["token"]
+		if !ok {
+			return nil, errors.New(http.StatusInternalServerError, fmt.Sprintf("can not find token data in secret %s", existingSecret.Name))
+		}
+
+		callerScopes, _ := ctx.Value(middleware.TokenScopesContextKey).([]serviceaccount.Scope)
+		token, err := regenerateToken(tokenAuthenticator, tokenGenerator, sa.Spec.Email, project.Name, existingSecret.Name, existingToken, callerScopes)
+		if err != nil {
+			if _, ok := err.(errors.HTTPError); ok {
+				return nil, err
+			}
+			return nil, errors.New(http.StatusInternalServerError, err.Error())
+		}
+
+		gracePeriod := defaultRotationGracePeriod
+		if req.Body.GracePeriodSeconds != nil {
+			gracePeriod = time.Duration(*req.Body.GracePeriodSeconds) * time.Second
+		}
+		serviceaccount.SetPreviousToken(existingSecret, existingToken, gracePeriod)
+		existingSecret.Data["token"] = []byte(token)
+
+		secret, err := serviceAccountTokenProvider.Update(userInfo, existingSecret)
+		if err != nil {
+			return nil, common.KubernetesErrorToHTTPError(err)
+		}
+
+		externalToken, err := convertInternalTokenToPrivateExternal(secret, tokenAuthenticator)
+		if err != nil {
+			return nil, errors.New(http.StatusInternalServerError, err.Error())
+		}
+
+		return externalToken, nil
+	}
+}
+
+// regenerateToken generates a replacement for the given token with the same scopes and lifetime.
+// The scopes of the token must not exceed the scopes of the token making the request.
+func regenerateToken(tokenAuthenticator serviceaccount.TokenAuthenticator, tokenGenerator serviceaccount.TokenGenerator, email, projectID, tokenID string, existingToken []byte, callerScopes []serviceaccount.Scope) (string, error) {
+	now := serviceaccount.Now()
+	lifetime := serviceaccount.DefaultExpiry(now).Sub(now)
+	var scopes []serviceaccount.Scope
+	// tokens that cannot be parsed anymore, e.g. because the signing key changed, get the defaults
+	if publicClaims, customClaims, err := tokenAuthenticator.Parse(string(existingToken)); err == nil {
+		if publicClaims.Expiry > publicClaims.IssuedAt && publicClaims.IssuedAt != 0 {
+			lifetime = publicClaims.Expiry.Time().Sub(publicClaims.IssuedAt.Time())
+		}
+		scopes = customClaims.Scopes
+	}
+	if !serviceaccount.ScopesCover(callerScopes, scopes) {
+		return "", errors.New(http.StatusForbidden, "forbidden: the scopes of the token exceed the scopes of the token making the request")
+	}
+
+	token, err := tokenGenerator.Generate(serviceaccount.ScopedClaims(email, projectID, tokenID, now.Add(lifetime), scopes))
+	if err != nil {
+		return "", fmt.Errorf("can not generate token data")
+	}
+	return token, nil
+}
+
 func updateToken(projectProvider provider.ProjectProvider, serviceAccountProvider provider.ServiceAccountProvider,
-	serviceAccountTokenProvider provider.ServiceAccountTokenProvider, userInfo *provider.UserInfo, tokenGenerator serviceaccount.TokenGenerator,
-	projectID, saID, tokenID, newName string, regenerateToken bool) (*v1.Secret, error) {
+	serviceAccountTokenProvider provider.ServiceAccountTokenProvider, userInfo *provider.UserInfo, tokenAuthenticator serviceaccount.TokenAuthenticator, tokenGenerator serviceaccount.TokenGenerator,
+	projectID, saID, tokenID, newName string, callerScopes []serviceaccount.Scope, regenerate bool) (*v1.Secret, error) {
 
 	project, err := projectProvider.Get(userInfo, projectID, &provider.ProjectGetOptions{})
 	if err != nil {
@@ -222,7 +326,7 @@ func updateToken(projectProvider provider.ProjectProvider, serviceAccountProvide
 		return nil, fmt.Errorf("can not find token name in secret %s", existingSecret.Name)
 	}
 
-	if newName == existingName && !regenerateToken {
+	if newName == existingName && !regenerate {
 		return existingSecret, nil
 	}
 
@@ -238,13 +342,16 @@ func updateToken(projectProvider provider.ProjectProvider, serviceAccountProvide
 		existingSecret.Labels["name"] = newName
 	}
 
-	if regenerateToken {
-		token, err := tokenGenerator.Generate(serviceaccount.Claims(sa.Spec.Email, project.Name, existingSecret.Name))
+	if regenerate {
+		token, err := regenerateToken(tokenAuthenticator, tokenGenerator, sa.Spec.Email, project.Name, existingSecret.Name, existingSecret.Data["token"], callerScopes)
 		if err != nil {
-			return nil, fmt.Errorf("can not generate token data")
+			return nil, err
 		}
 
 		existingSecret.Data["token"] = []byte(token)
+		// regenerating revokes the token immediately, unlike a rotation
+		delete(existingSecret.Data, serviceaccount.PreviousTokenKey)
+		delete(existingSecret.Annotations, serviceaccount.PreviousTokenExpiryAnnotation)
 	}
 
 	secret, err := serviceAccountTokenProvider.Update(userInfo, existingSecret)
@@ -294,6 +401,15 @@ type patchTokenReq struct {
 	Body []byte
 }
 
+// rotateTokenReq defines HTTP request for rotateServiceAccountToken
+// swagger:parameters rotateServiceAccountToken
+type rotateTokenReq struct {
+	commonTokenReq
+	tokenIDReq
+	// in: body
+	Body apiv1.ServiceAccountTokenRotation
+}
+
 // deleteTokenReq defines HTTP request for deleteServiceAccountToken
 // swagger:parameters deleteServiceAccountToken
 type deleteTokenReq struct {
@@ -309,6 +425,20 @@ func (r addTokenReq) Validate() error {
 	if utf8.RuneCountInString(r.Body.Name) > 50 {
 		return fmt.Errorf("the name is too long, max 50 chars")
 	}
+	if !r.Body.Expiry.IsZero() {
+		now := serviceaccount.Now()
+		if !r.Body.Expiry.After(now) {
+			return fmt.Errorf("the expiry must be in the future")
+		}
+		if r.Body.Expiry.After(serviceaccount.DefaultExpiry(now)) {
+			return fmt.Errorf("the expiry must not be more than three years in the future")
+		}
+	}
+	for i, scope := range convertExternalScopesToInternal(r.Body.Scopes) {
+		if err := scope.Validate(); err != nil {
+			return fmt.Errorf("invalid scope %d: %v", i, err)
+		}
+	}
 
 	return nil
 }
@@ -355,6 +485,24 @@ func (r patchTokenReq) Validate() error {
 	return nil
 }
 
+// Validate validates rotateTokenReq request
+func (r rotateTokenReq) Validate() error {
+	if err := r.commonTokenReq.Validate(); err != nil {
+		return err
+	}
+	if len(r.TokenID) == 0 {
+		return fmt.Errorf("token ID cannot be empty")
+	}
+	if r.Body.GracePeriodSeconds != nil {
+		gracePeriod := time.Duration(*r.Body.GracePeriodSeconds) * time.Second
+		if gracePeriod < 0 || gracePeriod > maxRotationGracePeriod {
+			return fmt.Errorf("the grace period must be between 0 and %d seconds", int64(maxRotationGracePeriod/time.Second))
+		}
+	}
+
+	return nil
+}
+
 // Validate validates updateTokenReq request
 func (r deleteTokenReq) Validate() error {
 	if err := r.commonTokenReq.Validate(); err != nil {
@@ -481,6 +629,35 @@ func DecodeDeleteTokenReq(c context.Context, r *http.Request) (interface{}, erro
 	return req, nil
 }
 
+// DecodeRotateTokenReq  decodes an HTTP request into rotateTokenReq
+func DecodeRotateTokenReq(c context.Context, r *http.Request) (interface{}, error) {
+	var req rotateTokenReq
+
+	rawReq, err := DecodeTokenReq(c, r)
+	if err != nil {
+		return nil, err
+	}
+	tokenReq := rawReq.(commonTokenReq)
+	req.ServiceAccountID = tokenReq.ServiceAccountID
+	req.ProjectID = tokenReq.ProjectID
+
+	// the body is optional
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req.Body); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	tokenID, err := decodeTokenIDReq(c, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.TokenID = tokenID.TokenID
+
+	return req, nil
+}
+
 func decodeTokenIDReq(c context.Context, r *http.Request) (tokenIDReq, error) {
 	var req tokenIDReq
 
@@ -515,12 +692,14 @@ func convertInternalTokenToPublicExternal(internal *v1.Secret, authenticator ser
 		return nil, fmt.Errorf("can not find token data")
 	}
 
-	publicClaim, _, err := authenticator.Authenticate(string(token))
+	// expired tokens are listed as well, so they can be rotated or deleted
+	publicClaim, customClaim, err := authenticator.Parse(string(token))
 	if err != nil {
 		return nil, fmt.Errorf("unable to create a token for %s due to %v", internal.Name, err)
 	}
 
 	externalToken.Expiry = apiv1.NewTime(publicClaim.Expiry.Time())
+	externalToken.Scopes = convertInternalScopesToExternal(customClaim.Scopes)
 	externalToken.ID = internal.Name
 	name, ok := internal.Labels["name"]
 	if !ok {
@@ -531,3 +710,27 @@ func convertInternalTokenToPublicExternal(internal *v1.Secret, authenticator ser
 	externalToken.CreationTimestamp = apiv1.NewTime(internal.CreationTimestamp.Time)
 	return externalToken, nil
 }
+
+func convertExternalScopesToInternal(scopes []apiv1.ServiceAccountTokenScope) []serviceaccount.Scope {
+	var result []serviceaccount.Scope
+	for _, scope := range scopes {
+		result = append(result, serviceaccount.Scope{
+			ReadOnly:  scope.ReadOnly,
+			Resource:  scope.Resource,
+			ClusterID: scope.ClusterID,
+		})
+	}
+	return result
+}
+
+func convertInternalScopesToExternal(scopes []serviceaccount.Scope) []apiv1.ServiceAccountTokenScope {
+	var result []apiv1.ServiceAccountTokenScope
+	for _, scope := range scopes {
+		result = append(result, apiv1.ServiceAccountTokenScope{
+			ReadOnly:  scope.ReadOnly,
+			Resource:  scope.Resource,
+			ClusterID: scope.ClusterID,
+		})
+	}
+	return result
+}
@@ -7,12 +7,15 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	apiv1 "github.com/kubermatic/kubermatic/api/pkg/api/v1"
 	kubermaticapiv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
 	"github.com/kubermatic/kubermatic/api/pkg/handler/test/hack"
+	"github.com/kubermatic/kubermatic/api/pkg/serviceaccount"
 
+	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -27,6 +30,7 @@ func TestCreateTokenProject(t *testing.T) {
 		existingKubernetesObjs []runtime.Object
 		expectedErrorResponse  string
 		expectedName           string
+		expectedScopes         []serviceaccount.Scope
 		projectToSync          string
 		saToSync               string
 		httpStatus             int
@@ -80,6 +84,67 @@ func TestCreateTokenProject(t *testing.T) {
 			saToSync:              "1",
 			expectedErrorResponse: `{"error":{"code":409,"message":"token \"test\" already exists"}}`,
 		},
+		{
+			name:       "scenario 3: create a read only service account token for the node deployments of a cluster",
+			body:       `{"name":"test","scopes":[{"readOnly":true,"resource":"nodedeployments","clusterID":"abcd"}]}`,
+			httpStatus: http.StatusCreated,
+			existingKubermaticObjs: []runtime.Object{
+				/*add projects*/
+				test.GenProject("plan9", kubermaticapiv1.ProjectActive, test.DefaultCreationTimestamp()),
+				/*add bindings*/
+				test.GenBinding("plan9-ID", "john@acme.com", "owners"),
+				test.GenBinding("plan9-ID", "serviceaccount-1@sa.kubermatic.io", "editors"),
+				/*add users*/
+				test.GenUser("", "john", "john@acme.com"),
+				test.GenServiceAccount("1", "test-1", "editors", "plan9-ID"),
+			},
+			existingKubernetesObjs: []runtime.Object{},
+			existingAPIUser:        *test.GenAPIUser("john", "john@acme.com"),
+			projectToSync:          "plan9-ID",
+			saToSync:               "1",
+			expectedName:           "test",
+			expectedScopes:         []serviceaccount.Scope{{ReadOnly: true, Resource: "nodedeployments", ClusterID: "abcd"}},
+		},
+		{
+			name:       "scenario 4: create service account token that expired already",
+			body:       `{"name":"test","expiry":"2013-02-03T19:54:00Z"}`,
+			httpStatus: http.StatusBadRequest,
+			existingKubermaticObjs: []runtime.Object{
+				/*add projects*/
+				test.GenProject("plan9", kubermaticapiv1.ProjectActive, test.DefaultCreationTimestamp()),
+				/*add bindings*/
+				test.GenBinding("plan9-ID", "john@acme.com", "owners"),
+				test.GenBinding("plan9-ID", "serviceaccount-1@sa.kubermatic.io", "editors"),
+				/*add users*/
+				test.GenUser("", "john", "john@acme.com"),
+				test.GenServiceAccount("1", "test-1", "editors", "plan9-ID"),
+			},
+			existingKubernetesObjs: []runtime.Object{},
+			existingAPIUser:        *test.GenAPIUser("john", "john@acme.com"),
+			projectToSync:          "plan9-ID",
+			saToSync:               "1",
+			expectedErrorResponse:  `{"error":{"code":400,"message":"the expiry must be in the future"}}`,
+		},
+		{
+			name:       "scenario 5: create service account token with a scope that restricts nothing",
+			body:       `{"name":"test","scopes":[{}]}`,
+			httpStatus: http.StatusBadRequest,
+			existingKubermaticObjs: []runtime.Object{
+				/*add projects*/
+				test.GenProject("plan9", kubermaticapiv1.ProjectActive, test.DefaultCreationTimestamp()),
+				/*add bindings*/
+				test.GenBinding("plan9-ID", "john@acme.com", "owners"),
+				test.GenBinding("plan9-ID", "serviceaccount-1@sa.kubermatic.io", "editors"),
+				/*add users*/
+				test.GenUser("", "john", "john@acme.com"),
+				test.GenServiceAccount("1", "test-1", "editors", "plan9-ID"),
+			},
+			existingKubernetesObjs: []runtime.Object{},
+			existingAPIUser:        *test.GenAPIUser("john", "john@acme.com"),
+			projectToSync:          "plan9-ID",
+			saToSync:               "1",
+			expectedErrorResponse:  `{"error":{"code":400,"message":"invalid scope 0: scope does not restrict anything, set at least one of read_only, resource or cluster_id"}}`,
+		},
 	}
 
 	for _, tc := range testcases {
@@ -124,6 +189,9 @@ func TestCreateTokenProject(t *testing.T) {
 				if saTokenClaim.Email != fmt.Sprintf("serviceaccount-%s@sa.kubermatic.io", tc.saToSync) {
 					t.Fatalf("expected email %s@sa.kubermatic.io got %s", tc.saToSync, saTokenClaim.Email)
 				}
+				if !equality.Semantic.DeepEqual(saTokenClaim.Scopes, tc.expectedScopes) {
+					t.Fatalf("expected scopes %v got %v", tc.expectedScopes, saTokenClaim.Scopes)
+				}
 			}
 		})
 	}
@@ -613,6 +681,248 @@ func TestDeleteToken(t *testing.T) {
 	}
 }
 
+func TestServiceAccountTokenScopes(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name             string
+		scopes           []serviceaccount.Scope
+		method           string
+		path             string
+		body             string
+		httpStatus       int
+		expectedResponse string
+	}{
+		{
+			name:       "scenario 1: a read only token can get the project",
+			scopes:     []serviceaccount.Scope{{ReadOnly: true}},
+			method:     http.MethodGet,
+			path:       "/api/v1/projects/plan9-ID",
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:             "scenario 2: a read only token cannot update the project",
+			scopes:           []serviceaccount.Scope{{ReadOnly: true}},
+			method:           http.MethodPut,
+			path:             "/api/v1/projects/plan9-ID",
+			body:             `{"name":"plan10"}`,
+			httpStatus:       http.StatusForbidden,
+			expectedResponse: `{"error":{"code":403,"message":"forbidden: the token is not allowed to PUT /api/v1/projects/plan9-ID"}}`,
+		},
+		{
+			name:             "scenario 3: a token for the node deployments of a cluster cannot get the project",
+			scopes:           []serviceaccount.Scope{{Resource: "nodedeployments", ClusterID: "abcd"}},
+			method:           http.MethodGet,
+			path:             "/api/v1/projects/plan9-ID",
+			httpStatus:       http.StatusForbidden,
+			expectedResponse: `{"error":{"code":403,"message":"forbidden: the token is not allowed to GET /api/v1/projects/plan9-ID"}}`,
+		},
+		{
+			name:             "scenario 4: a read only token cannot get the kubeconfig of a cluster",
+			scopes:           []serviceaccount.Scope{{ReadOnly: true}},
+			method:           http.MethodGet,
+			path:             "/api/v1/projects/plan9-ID/dc/us-central1/clusters/abcd/kubeconfig",
+			httpStatus:       http.StatusForbidden,
+			expectedResponse: `{"error":{"code":403,"message":"forbidden: the token is not allowed to GET /api/v1/projects/plan9-ID/dc/us-central1/clusters/abcd/kubeconfig"}}`,
+		},
+		{
+			name:             "scenario 5: a token for tokens cannot create an unrestricted token",
+			scopes:           []serviceaccount.Scope{{Resource: "tokens"}},
+			method:           http.MethodPost,
+			path:             "/api/v1/projects/plan9-ID/serviceaccounts/serviceaccount-1/tokens",
+			body:             `{"name":"unrestricted"}`,
+			httpStatus:       http.StatusForbidden,
+			expectedResponse: `{"error":{"code":403,"message":"forbidden: the requested scopes exceed the scopes of the token making the request"}}`,
+		},
+		{
+			name:       "scenario 6: a token for tokens can create a read only token for tokens",
+			scopes:     []serviceaccount.Scope{{Resource: "tokens"}},
+			method:     http.MethodPost,
+			path:       "/api/v1/projects/plan9-ID/serviceaccounts/serviceaccount-1/tokens",
+			body:       `{"name":"restricted","scopes":[{"readOnly":true,"resource":"tokens"}]}`,
+			httpStatus: http.StatusCreated,
+		},
+		{
+			name:             "scenario 7: a token for tokens cannot rotate an unrestricted token",
+			scopes:           []serviceaccount.Scope{{Resource: "tokens"}},
+			method:           http.MethodPost,
+			path:             "/api/v1/projects/plan9-ID/serviceaccounts/serviceaccount-1/tokens/sa-token-2/rotate",
+			body:             `{}`,
+			httpStatus:       http.StatusForbidden,
+			expectedResponse: `{"error":{"code":403,"message":"forbidden: the scopes of the token exceed the scopes of the token making the request"}}`,
+		},
+		{
+			name:       "scenario 8: a token for tokens can rotate itself",
+			scopes:     []serviceaccount.Scope{{Resource: "tokens"}},
+			method:     http.MethodPost,
+			path:       "/api/v1/projects/plan9-ID/serviceaccounts/serviceaccount-1/tokens/sa-token-1/rotate",
+			body:       `{}`,
+			httpStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokenGenerator, err := serviceaccount.JWTTokenGenerator([]byte(test.TestServiceAccountHashKey))
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := tokenGenerator.Generate(serviceaccount.ScopedClaims("serviceaccount-1@sa.kubermatic.io", "plan9-ID", "1", serviceaccount.Now().Add(time.Hour), tc.scopes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			secret := test.GenDefaultSaToken("plan9-ID", "serviceaccount-1", "ci", "1")
+			secret.Data["token"] = []byte(token)
+			unrestrictedSecret := test.GenDefaultSaToken("plan9-ID", "serviceaccount-1", "unrestricted", "2")
+
+			existingKubermaticObjs := []runtime.Object{
+				/*add projects*/
+				test.GenProject("plan9", kubermaticapiv1.ProjectActive, test.DefaultCreationTimestamp()),
+				/*add bindings*/
+				test.GenBinding("plan9-ID", "john@acme.com", "owners"),
+				test.GenBinding("plan9-ID", "serviceaccount-1@sa.kubermatic.io", "editors"),
+				/*add users*/
+				test.GenUser("", "john", "john@acme.com"),
+				test.GenServiceAccount("1", "test-1", "editors", "plan9-ID"),
+			}
+			ep, err := test.CreateTestEndpoint(*test.GenAPIUser("serviceaccount-1", "serviceaccount-1@sa.kubermatic.io"), []runtime.Object{secret, unrestrictedSecret}, existingKubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+			res := httptest.NewRecorder()
+			ep.ServeHTTP(res, req)
+
+			if res.Code != tc.httpStatus {
+				t.Fatalf("expected HTTP status code %d, got %d: %s", tc.httpStatus, res.Code, res.Body.String())
+			}
+			if len(tc.expectedResponse) > 0 {
+				test.CompareWithResult(t, res, tc.expectedResponse)
+			}
+		})
+	}
+}
+
+func TestRotateToken(t *testing.T) {
+	t.Parallel()
+	testcases := []struct {
+		name                string
+		tokenBody           string
+		rotateBody          string
+		httpStatus          int
+		expectedScopes      []serviceaccount.Scope
+		expectOldTokenValid bool
+	}{
+		{
+			name:                "scenario 1: rotate a scoped token, the old token stays valid during the grace period",
+			tokenBody:           `{"name":"ci","scopes":[{"readOnly":true}]}`,
+			rotateBody:          `{"gracePeriodSeconds":600}`,
+			httpStatus:          http.StatusOK,
+			expectedScopes:      []serviceaccount.Scope{{ReadOnly: true}},
+			expectOldTokenValid: true,
+		},
+		{
+			name:                "scenario 2: rotate a token without grace period",
+			tokenBody:           `{"name":"ci"}`,
+			rotateBody:          `{"gracePeriodSeconds":0}`,
+			httpStatus:          http.StatusOK,
+			expectOldTokenValid: false,
+		},
+		{
+			name:       "scenario 3: rotate a token with a too long grace period",
+			tokenBody:  `{"name":"ci"}`,
+			rotateBody: `{"gracePeriodSeconds":6048000}`,
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			existingKubermaticObjs := []runtime.Object{
+				/*add projects*/
+				test.GenProject("plan9", kubermaticapiv1.ProjectActive, test.DefaultCreationTimestamp()),
+				/*add bindings*/
+				test.GenBinding("plan9-ID", "john@acme.com", "owners"),
+				test.GenBinding("plan9-ID", "serviceaccount-1@sa.kubermatic.io", "editors"),
+				/*add users*/
+				test.GenUser("", "john", "john@acme.com"),
+				test.GenServiceAccount("1", "test-1", "editors", "plan9-ID"),
+			}
+			ep, clientset, err := test.CreateTestEndpointAndGetClients(*test.GenAPIUser("john", "john@acme.com"), nil, []runtime.Object{}, []runtime.Object{}, existingKubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+
+			// act 1 - create a service account token
+			req := httptest.NewRequest("POST", "/api/v1/projects/plan9-ID/serviceaccounts/1/tokens", strings.NewReader(tc.tokenBody))
+			res := httptest.NewRecorder()
+			ep.ServeHTTP(res, req)
+			if res.Code != http.StatusCreated {
+				t.Fatalf("expected HTTP status code %d, got %d: %s", http.StatusCreated, res.Code, res.Body.String())
+			}
+			oldToken := &apiv1.ServiceAccountToken{}
+			if err := json.Unmarshal(res.Body.Bytes(), oldToken); err != nil {
+				t.Fatalf("unable to read the token from the response, err %v", err)
+			}
+
+			// act 2 - rotate the token
+			req = httptest.NewRequest("POST", fmt.Sprintf("/api/v1/projects/plan9-ID/serviceaccounts/1/tokens/%s/rotate", oldToken.ID), strings.NewReader(tc.rotateBody))
+			res = httptest.NewRecorder()
+			ep.ServeHTTP(res, req)
+			if res.Code != tc.httpStatus {
+				t.Fatalf("expected HTTP status code %d, got %d: %s", tc.httpStatus, res.Code, res.Body.String())
+			}
+			if tc.httpStatus != http.StatusOK {
+				return
+			}
+			newToken := &apiv1.ServiceAccountToken{}
+			if err := json.Unmarshal(res.Body.Bytes(), newToken); err != nil {
+				t.Fatalf("unable to read the token from the response, err %v", err)
+			}
+
+			// validate
+			if newToken.ID != oldToken.ID {
+				t.Fatalf("expected the rotated token to keep the ID %s, got %s", oldToken.ID, newToken.ID)
+			}
+			if newToken.Token == oldToken.Token {
+				t.Fatal("expected the rotated token to differ from the old one")
+			}
+			_, claim, err := clientset.TokenAuthenticator.Authenticate(newToken.Token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equality.Semantic.DeepEqual(claim.Scopes, tc.expectedScopes) {
+				t.Fatalf("expected scopes %v got %v", tc.expectedScopes, claim.Scopes)
+			}
+
+			// act 3 - use both tokens as the service account
+			secret, err := clientset.FakeKubernetesCoreClient.CoreV1().Secrets("kubermatic").Get("sa-token-"+newToken.ID, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			saEp, err := test.CreateTestEndpoint(*test.GenAPIUser("serviceaccount-1", "serviceaccount-1@sa.kubermatic.io"), []runtime.Object{secret}, existingKubermaticObjs, nil, nil, hack.NewTestRouting)
+			if err != nil {
+				t.Fatalf("failed to create test endpoint due to %v", err)
+			}
+			for _, token := range []string{newToken.Token, oldToken.Token} {
+				req = httptest.NewRequest("GET", "/api/v1/projects/plan9-ID", strings.NewReader(""))
+				req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+				res = httptest.NewRecorder()
+				saEp.ServeHTTP(res, req)
+
+				expectedStatus := http.StatusOK
+				if token == oldToken.Token && !tc.expectOldTokenValid {
+					expectedStatus = http.StatusUnauthorized
+				}
+				if res.Code != expectedStatus {
+					t.Fatalf("expected HTTP status code %d, got %d: %s", expectedStatus, res.Code, res.Body.String())
+				}
+			}
+		})
+	}
+}
+
 func genPublicServiceAccountToken(id, name string, expiry apiv1.Time) apiv1.PublicServiceAccountToken {
 	token := apiv1.PublicServiceAccountToken{}
 	token.ID = id
@@ -148,6 +148,18 @@ func ValidateSeed(seed *kubermaticv1.Seed) error {
 		}
 	}
 
+	if capacity := seed.Spec.Capacity; capacity != nil {
+		if capacity.MaxClusters != nil && *capacity.MaxClusters < 0 {
+			return fmt.Errorf("maximum number of clusters must not be negative, got %d", *capacity.MaxClusters)
+		}
+		if capacity.CPU != nil && capacity.CPU.Sign() < 0 {
+			return fmt.Errorf("CPU budget must not be negative, got %s", capacity.CPU.String())
+		}
+		if capacity.Memory != nil && capacity.Memory.Sign() < 0 {
+			return fmt.Errorf("memory budget must not be negative, got %s", capacity.Memory.String())
+		}
+	}
+
 	return nil
 }
 
@@ -31,6 +31,7 @@ import (
 // UserClusterConnectionProvider offers functions to interact with an user cluster
 type UserClusterConnectionProvider interface {
 	GetClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (ctrlruntimeclient.Client, error)
+	GetK8sClient(*kubermaticv1.Cluster, ...k8cuserclusterclient.ConfigOption) (kubernetes.Interface, error)
 	GetAdminKubeconfig(c *kubermaticv1.Cluster) ([]byte, error)
 	GetViewerKubeconfig(c *kubermaticv1.Cluster) ([]byte, error)
 	RevokeViewerKubeconfig(c *kubermaticv1.Cluster) error
@@ -112,6 +113,7 @@ func (p *ClusterProvider) New(project *kubermaticv1.Project, userInfo *provider.
 			NamespaceName:          NamespaceName(name),
 			CloudMigrationRevision: cloud.CurrentMigrationRevision,
 			KubermaticVersion:      resources.KUBERMATICCOMMIT,
+			Creation:               cluster.Status.Creation,
 		},
 		Address: kubermaticv1.ClusterAddress{},
 	}
@@ -270,6 +272,13 @@ func (p *ClusterProvider) GetClientForCustomerCluster(userInfo *provider.UserInf
 	return p.userClusterConnProvider.GetClient(c, p.withImpersonation(userInfo))
 }
 
+// GetK8sClientForCustomerCluster returns a kubernetes client to interact with the given cluster
+//
+// Note that the client doesn't use admin account instead it authn/authz as userInfo(email, group)
+func (p *ClusterProvider) GetK8sClientForCustomerCluster(userInfo *provider.UserInfo, c *kubermaticv1.Cluster) (kubernetes.Interface, error) {
+	return p.userClusterConnProvider.GetK8sClient(c, p.withImpersonation(userInfo))
+}
+
 // GetSeedClusterAdminRuntimeClient returns a runtime client to interact with the seed cluster resources.
 //
 // Note that this client has admin privileges in the seed cluster.
@@ -0,0 +1,132 @@
+package kubernetes
+
+import (
+	"errors"
+
+	kubermaticv1lister "github.com/kubermatic/kubermatic/api/pkg/crd/client/listers/kubermatic/v1"
+	kubermaticapiv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/provider"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+// NewClusterTemplateProvider returns a new cluster template provider that respects RBAC policies
+// it uses createMasterImpersonatedClient to create a connection that uses User Impersonation
+func NewClusterTemplateProvider(createMasterImpersonatedClient kubermaticImpersonationClient, templateLister kubermaticv1lister.ClusterTemplateLister) *ClusterTemplateProvider {
+	return &ClusterTemplateProvider{createMasterImpersonatedClient: createMasterImpersonatedClient, templateLister: templateLister}
+}
+
+// ClusterTemplateProvider struct that holds required components in order to provide
+// cluster template provider that is RBAC compliant
+type ClusterTemplateProvider struct {
+	// createMasterImpersonatedClient is used as a ground for impersonation
+	// whenever a connection to Master API server is required
+	createMasterImpersonatedClient kubermaticImpersonationClient
+
+	// templateLister provide access to local cache that stores cluster template objects
+	templateLister kubermaticv1lister.ClusterTemplateLister
+}
+
+// New creates a cluster template that will belong to the given project
+func (p *ClusterTemplateProvider) New(userInfo *provider.UserInfo, project *kubermaticapiv1.Project, template *kubermaticapiv1.ClusterTemplate) (*kubermaticapiv1.ClusterTemplate, error) {
+	if userInfo == nil {
+		return nil, errors.New("a userInfo is missing but required")
+	}
+	if project == nil {
+		return nil, errors.New("a project is missing but required")
+	}
+
+	template = template.DeepCopy()
+	template.Name = rand.String(10)
+	template.OwnerReferences = []metav1.OwnerReference{
+		{
+			APIVersion: kubermaticapiv1.SchemeGroupVersion.String(),
+			Kind:       kubermaticapiv1.ProjectKindName,
+			UID:        project.GetUID(),
+			Name:       project.Name,
+		},
+	}
+
+	masterImpersonatedClient, err := createKubermaticImpersonationClientWrapperFromUserInfo(userInfo, p.createMasterImpersonatedClient)
+	if err != nil {
+		return nil, err
+	}
+	return masterImpersonatedClient.ClusterTemplates().Create(template)
+}
+
+// List gets all cluster templates that belong to the given project along with the global ones
+//
+// Note:
+// Like for ssh keys we assume that if the user was able to get the project (argument) it has to have at least read access.
+func (p *ClusterTemplateProvider) List(project *kubermaticapiv1.Project) ([]*kubermaticapiv1.ClusterTemplate, error) {
+	if project == nil {
+		return nil, errors.New("a project is missing but required")
+	}
+	allTemplates, err := p.templateLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []*kubermaticapiv1.ClusterTemplate{}
+	for _, template := range allTemplates {
+		if IsGlobalClusterTemplate(template) || isOwnedByProject(template, project.Name) {
+			templates = append(templates, template.DeepCopy())
+		}
+	}
+	return templates, nil
+}
+
+// Get returns the template with the given name if it belongs to the given project or if it is global.
+// Global templates are read from the cache as they are readable by everybody.
+func (p *ClusterTemplateProvider) Get(userInfo *provider.UserInfo, project *kubermaticapiv1.Project, templateName string) (*kubermaticapiv1.ClusterTemplate, error) {
+	if project == nil {
+		return nil, errors.New("a project is missing but required")
+	}
+	template, err := p.templateLister.Get(templateName)
+	if err != nil {
+		return nil, err
+	}
+	if IsGlobalClusterTemplate(template) {
+		return template.DeepCopy(), nil
+	}
+	if !isOwnedByProject(template, project.Name) {
+		return nil, kerrors.NewNotFound(kubermaticapiv1.Resource(kubermaticapiv1.ClusterTemplateResourceName), templateName)
+	}
+
+	masterImpersonatedClient, err := createKubermaticImpersonationClientWrapperFromUserInfo(userInfo, p.createMasterImpersonatedClient)
+	if err != nil {
+		return nil, err
+	}
+	return masterImpersonatedClient.ClusterTemplates().Get(templateName, metav1.GetOptions{})
+}
+
+// Delete simply deletes the given template
+func (p *ClusterTemplateProvider) Delete(userInfo *provider.UserInfo, templateName string) error {
+	masterImpersonatedClient, err := createKubermaticImpersonationClientWrapperFromUserInfo(userInfo, p.createMasterImpersonatedClient)
+	if err != nil {
+		return err
+	}
+	return masterImpersonatedClient.ClusterTemplates().Delete(templateName, &metav1.DeleteOptions{})
+}
+
+// IsGlobalClusterTemplate tells if the given template is not owned by any project
+func IsGlobalClusterTemplate(template *kubermaticapiv1.ClusterTemplate) bool {
+	for _, owner := range template.GetOwnerReferences() {
+		if owner.APIVersion == kubermaticapiv1.SchemeGroupVersion.String() && owner.Kind == kubermaticapiv1.ProjectKindName {
+			return false
+		}
+	}
+	return true
+}
+
+func isOwnedByProject(obj metav1.Object, projectName string) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.APIVersion == kubermaticapiv1.SchemeGroupVersion.String() && owner.Kind == kubermaticapiv1.ProjectKindName && owner.Name == projectName {
+			return true
+		}
+	}
+	return false
+}
@@ -152,6 +152,12 @@ type ClusterProvider interface {
 	//
 	// Note that the client doesn't use admin account instead it authn/authz as userInfo(email, group)
 	GetClientForCustomerCluster(*UserInfo, *kubermaticv1.Cluster) (ctrlruntimeclient.Client, error)
+
+	// GetK8sClientForCustomerCluster returns a kubernetes client to interact with the given cluster,
+	// which is needed for subresources like pod evictions
+	//
+	// Note that the client doesn't use admin account instead it authn/authz as userInfo(email, group)
+	GetK8sClientForCustomerCluster(*UserInfo, *kubermaticv1.Cluster) (kubernetes.Interface, error)
 }
 
 // PrivilegedClusterProvider declares the set of methods for interacting with the seed clusters
@@ -473,3 +479,18 @@ type AddonProvider interface {
 	// Delete deletes the given addon
 	Delete(userInfo *UserInfo, cluster *kubermaticv1.Cluster, addonName string) error
 }
+
+// ClusterTemplateProvider declares the set of methods for interacting with cluster templates
+type ClusterTemplateProvider interface {
+	// New creates a new cluster template that belongs to the given project
+	New(userInfo *UserInfo, project *kubermaticv1.Project, template *kubermaticv1.ClusterTemplate) (*kubermaticv1.ClusterTemplate, error)
+
+	// List gets all cluster templates that belong to the given project along with the global ones
+	List(project *kubermaticv1.Project) ([]*kubermaticv1.ClusterTemplate, error)
+
+	// Get returns the template with the given name if it belongs to the given project or if it is global
+	Get(userInfo *UserInfo, project *kubermaticv1.Project, templateName string) (*kubermaticv1.ClusterTemplate, error)
+
+	// Delete deletes the given cluster template
+	Delete(userInfo *UserInfo, templateName string) error
+}
@@ -22,10 +22,7 @@ func SyncClusterAddress(ctx context.Context,
 	seed *kubermaticv1.Seed) ([]func(*kubermaticv1.Cluster), error) {
 	var modifiers []func(*kubermaticv1.Cluster)
 
-	subdomain := seed.Name
-	if seed.Spec.SeedDNSOverwrite != "" {
-		subdomain = seed.Spec.SeedDNSOverwrite
-	}
+	subdomain := Subdomain(seed)
 
 	frontProxyLoadBalancerServiceIP := ""
 	if cluster.Spec.ExposeStrategy == corev1.ServiceTypeLoadBalancer {
@@ -117,6 +114,15 @@ func SyncClusterAddress(ctx context.Context,
 	return modifiers, nil
 }
 
+// Subdomain returns the DNS subdomain under which the clusters of the given seed are exposed.
+// As the external name of a cluster depends on its seed, moving a cluster to another seed switches its address.
+func Subdomain(seed *kubermaticv1.Seed) string {
+	if seed.Spec.SeedDNSOverwrite != "" {
+		return seed.Spec.SeedDNSOverwrite
+	}
+	return seed.Name
+}
+
 func getExternalIPv4(hostname string) (string, error) {
 	resolvedIPs, err := net.LookupIP(hostname)
 	if err != nil {
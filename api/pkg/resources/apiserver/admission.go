@@ -0,0 +1,80 @@
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/componentflags"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const admissionConfigDir = "/etc/kubernetes/admission"
+
+// AdmissionConfigurationCreator returns a function to create/update the configmap with the admission
+// configuration of the apiserver and the configuration files of the admission plugins
+func AdmissionConfigurationCreator(data *resources.TemplateData) reconciling.NamedConfigMapCreatorGetter {
+	return func() (string, reconciling.ConfigMapCreator) {
+		return resources.AdmissionConfigurationConfigMapName, func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+			config := &bytes.Buffer{}
+			fmt.Fprint(config, "apiVersion: apiserver.k8s.io/v1alpha1\nkind: AdmissionConfiguration\nplugins:")
+			cm.Data = map[string]string{}
+			for _, plugin := range componentflags.AdmissionPlugins(data.Cluster(), data.DC()) {
+				if plugin.Config == "" {
+					continue
+				}
+				fileName := plugin.Name + ".yaml"
+				cm.Data[fileName] = plugin.Config
+				fmt.Fprintf(config, "\n- name: %s\n  path: %s/%s", plugin.Name, admissionConfigDir, fileName)
+			}
+			if len(cm.Data) == 0 {
+				fmt.Fprint(config, " []")
+			}
+			fmt.Fprint(config, "\n")
+			cm.Data[resources.AdmissionConfigurationConfigMapKey] = config.String()
+			return cm, nil
+		}
+	}
+}
+
+func admissionConfigurationEnabled(data *resources.TemplateData) bool {
+	for _, plugin := range componentflags.AdmissionPlugins(data.Cluster(), data.DC()) {
+		if plugin.Config != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func getAdmissionVolumes(data *resources.TemplateData) []corev1.Volume {
+	if !admissionConfigurationEnabled(data) {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: resources.AdmissionConfigurationConfigMapName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: resources.AdmissionConfigurationConfigMapName,
+					},
+				},
+			},
+		},
+	}
+}
+
+func getAdmissionVolumeMounts(data *resources.TemplateData) []corev1.VolumeMount {
+	if !admissionConfigurationEnabled(data) {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{
+			Name:      resources.AdmissionConfigurationConfigMapName,
+			MountPath: admissionConfigDir,
+			ReadOnly:  true,
+		},
+	}
+}
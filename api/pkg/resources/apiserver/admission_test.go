@@ -0,0 +1,53 @@
+package apiserver
+
+import (
+	"context"
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAdmissionConfigurationCreator(t *testing.T) {
+	cluster := &kubermaticv1.Cluster{
+		Spec: kubermaticv1.ClusterSpec{
+			AdmissionPlugins: []kubermaticv1.AdmissionPluginSettings{
+				{Name: "AlwaysPullImages"},
+				{Name: "EventRateLimit", Config: "kind: Configuration\n"},
+				// Not supported, the cluster was changed without validation
+				{Name: "AlwaysAdmit"},
+			},
+		},
+	}
+	data := resources.NewTemplateData(context.Background(), nil, cluster, &kubermaticv1.Datacenter{}, nil, "", "", "", resource.Quantity{}, "", "", false, false, "", "", "", "", false, "", "", false)
+
+	_, create := AdmissionConfigurationCreator(data)()
+	cm, err := create(&corev1.ConfigMap{})
+	if err != nil {
+		t.Fatalf("failed to create the configmap: %v", err)
+	}
+
+	expected := map[string]string{
+		resources.AdmissionConfigurationConfigMapKey: `apiVersion: apiserver.k8s.io/v1alpha1
+kind: AdmissionConfiguration
+plugins:
+- name: EventRateLimit
+  path: /etc/kubernetes/admission/EventRateLimit.yaml
+`,
+		"EventRateLimit.yaml": "kind: Configuration\n",
+	}
+	if len(cm.Data) != len(expected) {
+		t.Fatalf("expected the keys %v, got %v", expected, cm.Data)
+	}
+	for key, value := range expected {
+		if cm.Data[key] != value {
+			t.Errorf("expected %s to be\n%s\ngot\n%s", key, value, cm.Data[key])
+		}
+	}
+	if !admissionConfigurationEnabled(data) {
+		t.Errorf("expected the admission configuration to be enabled")
+	}
+}
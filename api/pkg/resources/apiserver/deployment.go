@@ -6,6 +6,7 @@ import (
 
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/componentflags"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd/etcdrunning"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
@@ -71,6 +72,12 @@ func DeploymentCreator(data *resources.TemplateData, enableDexCA bool) reconcili
 			dep.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: resources.ImagePullSecretName}}
 
 			volumes := getVolumes()
+			if !vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				volumes = vpnsidecar.WithoutOpenVPNVolumes(volumes)
+			}
+			volumes = append(volumes, getEncryptionVolumes(data)...)
+			volumes = append(volumes, getAdmissionVolumes(data)...)
+			volumes = append(volumes, getEgressSelectorVolumes(data.Cluster())...)
 
 			if len(data.OIDCCAFile()) > 0 {
 				volumes = append(volumes, getDexCASecretVolume())
@@ -90,7 +97,7 @@ func DeploymentCreator(data *resources.TemplateData, enableDexCA bool) reconcili
 				},
 			}
 
-			etcdEndpoints := etcd.GetClientEndpoints(data.Cluster().Status.NamespaceName)
+			etcdEndpoints := etcd.GetClientEndpoints(data.Cluster())
 
 			// Configure user cluster DNS resolver for this pod.
 			dep.Spec.Template.Spec.DNSPolicy, dep.Spec.Template.Spec.DNSConfig, err = resources.UserClusterDNSPolicyAndConfig(data)
@@ -102,18 +109,22 @@ func DeploymentCreator(data *resources.TemplateData, enableDexCA bool) reconcili
 				etcdrunning.Container(etcdEndpoints, data),
 			}
 
-			openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
-			if err != nil {
-				return nil, fmt.Errorf("failed to get openvpn-client sidecar: %v", err)
-			}
-
-			dnatControllerSidecar, err := vpnsidecar.DnatControllerContainer(
-				data,
-				"dnat-controller",
-				fmt.Sprintf("https://127.0.0.1:%d", data.Cluster().Address.Port),
-			)
-			if err != nil {
-				return nil, fmt.Errorf("failed to get dnat-controller sidecar: %v", err)
+			var sidecars []corev1.Container
+			if vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
+				if err != nil {
+					return nil, fmt.Errorf("failed to get openvpn-client sidecar: %v", err)
+				}
+
+				dnatControllerSidecar, err := vpnsidecar.DnatControllerContainer(
+					data,
+					"dnat-controller",
+					fmt.Sprintf("https://127.0.0.1:%d", data.Cluster().Address.Port),
+				)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get dnat-controller sidecar: %v", err)
+				}
+				sidecars = append(sidecars, *openvpnSidecar, *dnatControllerSidecar)
 			}
 			auditLogEnabled := data.Cluster().Spec.AuditLogging != nil && data.Cluster().Spec.AuditLogging.Enabled
 			endpointReconcilingDisabled := false
@@ -135,10 +146,8 @@ func DeploymentCreator(data *resources.TemplateData, enableDexCA bool) reconcili
 				return nil, err
 			}
 
-			dep.Spec.Template.Spec.Containers = []corev1.Container{
-				*openvpnSidecar,
-				*dnatControllerSidecar,
-				{
+			dep.Spec.Template.Spec.Containers = append(sidecars,
+				corev1.Container{
 					Name:      name,
 					Image:     data.ImageRegistry(resources.RegistryGCR) + "/google_containers/hyperkube-amd64:v" + data.Cluster().Spec.Version.String(),
 					Command:   []string{"/hyperkube", "kube-apiserver"},
@@ -178,8 +187,12 @@ func DeploymentCreator(data *resources.TemplateData, enableDexCA bool) reconcili
 						SuccessThreshold:    1,
 						TimeoutSeconds:      15,
 					},
-					VolumeMounts: getVolumeMounts(enableDexCA),
+					VolumeMounts: append(append(append(getVolumeMounts(enableDexCA), getEncryptionVolumeMounts(data)...), getAdmissionVolumeMounts(data)...), getEgressSelectorVolumeMounts(data.Cluster())...),
 				},
+			)
+
+			if kmsPluginEnabled(data) {
+				dep.Spec.Template.Spec.Containers = append(dep.Spec.Template.Spec.Containers, KMSPluginContainer(data))
 			}
 
 			if data.Cluster().Spec.AuditLogging != nil && data.Cluster().Spec.AuditLogging.Enabled {
@@ -237,6 +250,9 @@ func getApiserverFlags(data *resources.TemplateData, etcdEndpoints []string, ena
 	if data.Cluster().Spec.UsePodSecurityPolicyAdmissionPlugin {
 		admissionPlugins = append(admissionPlugins, "PodSecurityPolicy")
 	}
+	for _, plugin := range componentflags.AdmissionPlugins(data.Cluster(), data.DC()) {
+		admissionPlugins = append(admissionPlugins, plugin.Name)
+	}
 
 	flags := []string{
 		"--advertise-address", data.Cluster().Address.IP,
@@ -279,12 +295,27 @@ func getApiserverFlags(data *resources.TemplateData, etcdEndpoints []string, ena
 		flags = append(flags, "--audit-policy-file", "/etc/kubernetes/audit/policy.yaml")
 	}
 
+	if encryptionAtRestEnabled(data) {
+		flags = append(flags, "--encryption-provider-config", encryptionConfigDir+"/"+resources.EncryptionConfigurationSecretKey)
+	}
+
+	if admissionConfigurationEnabled(data) {
+		flags = append(flags, "--admission-control-config-file", admissionConfigDir+"/"+resources.AdmissionConfigurationConfigMapKey)
+	}
+
 	if endpointReconcilingDisabled {
 		flags = append(flags, "--endpoint-reconciler-type=none")
 	}
 
+	if egressSelectorEnabled(data.Cluster()) {
+		flags = append(flags, "--egress-selector-config-file", egressSelectorConfigDir+"/"+resources.EgressSelectorConfigKey)
+	}
+
 	if data.Cluster().Spec.Cloud.GCP != nil {
 		flags = append(flags, "--kubelet-preferred-address-types", "InternalIP")
+	} else if egressSelectorEnabled(data.Cluster()) {
+		// The agents resolve the address inside the cluster, where the internal IP is reachable
+		flags = append(flags, "--kubelet-preferred-address-types", "InternalIP,ExternalIP")
 	} else {
 		flags = append(flags, "--kubelet-preferred-address-types", "ExternalIP,InternalIP")
 	}
@@ -294,6 +325,10 @@ func getApiserverFlags(data *resources.TemplateData, etcdEndpoints []string, ena
 	if data.Cluster().Spec.Version.Semver().Minor() == 10 {
 		featureGates = append(featureGates, "CustomResourceSubresources=true")
 	}
+	// The egress selector is beta and enabled by default since 1.18
+	if egressSelectorEnabled(data.Cluster()) && data.Cluster().Spec.Version.Semver().Minor() < 18 {
+		featureGates = append(featureGates, "APIServerNetworkProxy=true")
+	}
 	if len(featureGates) > 0 {
 		flags = append(flags, "--feature-gates")
 		flags = append(flags, strings.Join(featureGates, ","))
@@ -330,7 +365,7 @@ func getApiserverFlags(data *resources.TemplateData, etcdEndpoints []string, ena
 		}
 	}
 
-	return flags, nil
+	return componentflags.AppendExtraFlags(flags, componentflags.Apiserver, data.Cluster(), data.DC()), nil
 }
 
 func getVolumeMounts(enableDexCA bool) []corev1.VolumeMount {
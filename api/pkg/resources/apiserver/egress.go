@@ -0,0 +1,99 @@
+package apiserver
+
+import (
+	"fmt"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	egressSelectorConfigDir    = "/etc/kubernetes/egress-selector"
+	konnectivityClientCertsDir = "/etc/kubernetes/pki/konnectivity-client"
+	konnectivityServerPort     = 8131
+)
+
+type egressSelectorConfigCreatorData interface {
+	Cluster() *kubermaticv1.Cluster
+}
+
+// EgressSelectorConfigCreator returns a function to create/update the configmap with the egress selector
+// configuration of the apiserver. It sends all traffic to the nodes, pods and services of the cluster
+// through the konnectivity server.
+func EgressSelectorConfigCreator(data egressSelectorConfigCreatorData) reconciling.NamedConfigMapCreatorGetter {
+	return func() (string, reconciling.ConfigMapCreator) {
+		return resources.EgressSelectorConfigConfigMapName, func(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+			cm.Data = map[string]string{
+				resources.EgressSelectorConfigKey: fmt.Sprintf(`apiVersion: apiserver.k8s.io/v1alpha1
+kind: EgressSelectorConfiguration
+egressSelections:
+- name: cluster
+  connection:
+    type: http-connect
+    httpConnect:
+      url: https://%s.%s.svc.cluster.local:%d
+      caBundle: %s/%s
+      clientKey: %s/%s
+      clientCert: %s/%s
+`,
+					resources.KonnectivityServerInternalServiceName, data.Cluster().Status.NamespaceName, konnectivityServerPort,
+					konnectivityClientCertsDir, resources.CACertSecretKey,
+					konnectivityClientCertsDir, resources.KonnectivityClientKeySecretKey,
+					konnectivityClientCertsDir, resources.KonnectivityClientCertSecretKey),
+			}
+			return cm, nil
+		}
+	}
+}
+
+// egressSelectorEnabled returns whether the apiserver reaches the cluster through konnectivity
+func egressSelectorEnabled(cluster *kubermaticv1.Cluster) bool {
+	return cluster.ActiveTunnelingMode() == kubermaticv1.TunnelingModeKonnectivity
+}
+
+func getEgressSelectorVolumes(cluster *kubermaticv1.Cluster) []corev1.Volume {
+	if !egressSelectorEnabled(cluster) {
+		return nil
+	}
+	return []corev1.Volume{
+		{
+			Name: resources.EgressSelectorConfigConfigMapName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{
+						Name: resources.EgressSelectorConfigConfigMapName,
+					},
+				},
+			},
+		},
+		{
+			Name: resources.KonnectivityApiserverClientCertificateSecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: resources.KonnectivityApiserverClientCertificateSecretName,
+				},
+			},
+		},
+	}
+}
+
+func getEgressSelectorVolumeMounts(cluster *kubermaticv1.Cluster) []corev1.VolumeMount {
+	if !egressSelectorEnabled(cluster) {
+		return nil
+	}
+	return []corev1.VolumeMount{
+		{
+			Name:      resources.EgressSelectorConfigConfigMapName,
+			MountPath: egressSelectorConfigDir,
+			ReadOnly:  true,
+		},
+		{
+			Name:      resources.KonnectivityApiserverClientCertificateSecretName,
+			MountPath: konnectivityClientCertsDir,
+			ReadOnly:  true,
+		},
+	}
+}
@@ -0,0 +1,198 @@
+package apiserver
+
+import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// encryptionKeySize is the size of the generated keys, aescbc and secretbox both use 32 byte keys
+	encryptionKeySize = 32
+
+	// KMSPluginSocketDir is the directory in which the KMS plugin listens on a unix socket
+	KMSPluginSocketDir = "/var/run/kmsplugin"
+	// KMSPluginSocket is the unix socket the apiserver connects to the KMS plugin with
+	KMSPluginSocket = KMSPluginSocketDir + "/socket.sock"
+	// KMSStandinKeysDir is the directory from which the KMS stand-in reads the keys
+	KMSStandinKeysDir = "/etc/kubernetes/encryption-keys"
+
+	kmsPluginName             = "kms-plugin"
+	kmsPluginSocketVolumeName = "kms-plugin-socket"
+	encryptionConfigDir       = "/etc/kubernetes/encryption"
+)
+
+// EncryptionConfigurationCreator returns a function to create/update the secret with the keys which
+// encrypt the Secrets of the user cluster and the encryption configuration of the apiserver. The
+// keys get rotated by the cluster encryption controller, this only creates the initial key.
+func EncryptionConfigurationCreator(data *resources.TemplateData) reconciling.NamedSecretCreatorGetter {
+	return func() (string, reconciling.SecretCreator) {
+		return resources.EncryptionConfigurationSecretName, func(se *corev1.Secret) (*corev1.Secret, error) {
+			if se.Data == nil {
+				se.Data = map[string][]byte{}
+			}
+			if _, exists := se.Data[resources.EncryptionKeySecretKey]; !exists {
+				key, err := NewEncryptionKey()
+				if err != nil {
+					return nil, err
+				}
+				se.Data[resources.EncryptionKeySecretKey] = key
+			}
+
+			config, err := EncryptionConfiguration(data.Cluster().Spec.EncryptionAtRest, se.Data)
+			if err != nil {
+				return nil, err
+			}
+			se.Data[resources.EncryptionConfigurationSecretKey] = config
+			return se, nil
+		}
+	}
+}
+
+// NewEncryptionKey returns a new random key for the aescbc and secretbox providers and the KMS stand-in
+func NewEncryptionKey() ([]byte, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := cryptorand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate an encryption key: %v", err)
+	}
+	return key, nil
+}
+
+// EncryptionKeyName returns the name under which the key is known to the apiserver and the KMS stand-in.
+// It is derived from the key, so that the same key always gets the same name.
+func EncryptionKeyName(key []byte) string {
+	return fmt.Sprintf("key-%x", sha256.Sum256(key))[:12]
+}
+
+// EncryptionConfiguration returns the encryption configuration of the apiserver for the keys in the
+// given secret data. The current key encrypts, the next and the previous key of a rotation only decrypt.
+// Secrets which were written before the encryption was enabled remain readable until they are rewritten.
+func EncryptionConfiguration(settings *kubermaticv1.EncryptionAtRestSettings, secretData map[string][]byte) ([]byte, error) {
+	if settings == nil {
+		return nil, fmt.Errorf("encryption at rest is not enabled")
+	}
+
+	config := &bytes.Buffer{}
+	fmt.Fprint(config, `apiVersion: apiserver.config.k8s.io/v1
+kind: EncryptionConfiguration
+resources:
+- resources:
+  - secrets
+  providers:
+`)
+	switch settings.Provider {
+	case kubermaticv1.EncryptionProviderAESCBC, kubermaticv1.EncryptionProviderSecretbox:
+		fmt.Fprintf(config, "  - %s:\n      keys:\n", settings.Provider)
+		for _, secretKey := range []string{resources.EncryptionKeySecretKey, resources.NextEncryptionKeySecretKey, resources.PreviousEncryptionKeySecretKey} {
+			key, exists := secretData[secretKey]
+			if !exists {
+				continue
+			}
+			fmt.Fprintf(config, "      - name: %s\n        secret: %s\n", EncryptionKeyName(key), base64.StdEncoding.EncodeToString(key))
+		}
+	case kubermaticv1.EncryptionProviderKMS:
+		fmt.Fprintf(config, "  - kms:\n      name: %s\n      endpoint: unix://%s\n      cachesize: 1000\n      timeout: 3s\n", kmsPluginName, KMSPluginSocket)
+	default:
+		return nil, fmt.Errorf("unknown encryption provider %q", settings.Provider)
+	}
+	fmt.Fprint(config, "  - identity: {}\n")
+
+	return config.Bytes(), nil
+}
+
+// KMSPluginContainer returns the KMS stand-in, which runs as sidecar of the apiserver and wraps the
+// data encryption keys of the kms provider
+func KMSPluginContainer(data *resources.TemplateData) corev1.Container {
+	return corev1.Container{
+		Name:  kmsPluginName,
+		Image: data.KubermaticAPIImage() + ":" + resources.KUBERMATICCOMMIT,
+		Command: []string{
+			"/usr/local/bin/kms-standin",
+			"-listen", KMSPluginSocket,
+			"-keys-dir", KMSStandinKeysDir,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      kmsPluginSocketVolumeName,
+				MountPath: KMSPluginSocketDir,
+			},
+			{
+				Name:      resources.EncryptionConfigurationSecretName,
+				MountPath: KMSStandinKeysDir,
+				ReadOnly:  true,
+			},
+		},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("16Mi"),
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+			},
+		},
+	}
+}
+
+func encryptionAtRestEnabled(data *resources.TemplateData) bool {
+	return data.Cluster().Spec.EncryptionAtRest != nil
+}
+
+func kmsPluginEnabled(data *resources.TemplateData) bool {
+	return encryptionAtRestEnabled(data) && data.Cluster().Spec.EncryptionAtRest.Provider == kubermaticv1.EncryptionProviderKMS
+}
+
+func getEncryptionVolumes(data *resources.TemplateData) []corev1.Volume {
+	if !encryptionAtRestEnabled(data) {
+		return nil
+	}
+	volumes := []corev1.Volume{
+		{
+			Name: resources.EncryptionConfigurationSecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: resources.EncryptionConfigurationSecretName,
+				},
+			},
+		},
+	}
+	if kmsPluginEnabled(data) {
+		volumes = append(volumes, corev1.Volume{
+			Name: kmsPluginSocketVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	return volumes
+}
+
+func getEncryptionVolumeMounts(data *resources.TemplateData) []corev1.VolumeMount {
+	if !encryptionAtRestEnabled(data) {
+		return nil
+	}
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      resources.EncryptionConfigurationSecretName,
+			MountPath: encryptionConfigDir,
+			ReadOnly:  true,
+		},
+	}
+	if kmsPluginEnabled(data) {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      kmsPluginSocketVolumeName,
+			MountPath: KMSPluginSocketDir,
+		})
+	}
+	return volumeMounts
+}
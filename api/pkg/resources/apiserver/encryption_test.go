@@ -0,0 +1,70 @@
+package apiserver
+
+import (
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+)
+
+func TestEncryptionConfiguration(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	nextKey := []byte("fedcba9876543210fedcba9876543210")
+
+	tests := []struct {
+		name       string
+		settings   *kubermaticv1.EncryptionAtRestSettings
+		secretData map[string][]byte
+		expected   string
+	}{
+		{
+			name:       "current and next key",
+			settings:   &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderAESCBC},
+			secretData: map[string][]byte{resources.NextEncryptionKeySecretKey: nextKey, resources.EncryptionKeySecretKey: key},
+			expected: `apiVersion: apiserver.config.k8s.io/v1
+kind: EncryptionConfiguration
+resources:
+- resources:
+  - secrets
+  providers:
+  - aescbc:
+      keys:
+      - name: ` + EncryptionKeyName(key) + `
+        secret: MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=
+      - name: ` + EncryptionKeyName(nextKey) + `
+        secret: ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA=
+  - identity: {}
+`,
+		},
+		{
+			name:       "kms",
+			settings:   &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderKMS},
+			secretData: map[string][]byte{resources.EncryptionKeySecretKey: key},
+			expected: `apiVersion: apiserver.config.k8s.io/v1
+kind: EncryptionConfiguration
+resources:
+- resources:
+  - secrets
+  providers:
+  - kms:
+      name: kms-plugin
+      endpoint: unix:///var/run/kmsplugin/socket.sock
+      cachesize: 1000
+      timeout: 3s
+  - identity: {}
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := EncryptionConfiguration(test.settings, test.secretData)
+			if err != nil {
+				t.Fatalf("failed to render the configuration: %v", err)
+			}
+			if string(config) != test.expected {
+				t.Errorf("expected the configuration\n%s\ngot\n%s", test.expected, config)
+			}
+		})
+	}
+}
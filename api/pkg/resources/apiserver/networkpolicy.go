@@ -0,0 +1,36 @@
+package apiserver
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NetworkPolicyCreator returns a func to create/update the apiserver NetworkPolicy. The apiserver is reachable
+// by the controllers in the cluster namespace, the kubermatic-controller-manager and through the nodeport-proxy.
+func NetworkPolicyCreator() reconciling.NamedNetworkPolicyCreatorGetter {
+	return func() (string, reconciling.NetworkPolicyCreator) {
+		return resources.ApiserverNetworkPolicyName, func(np *networkingv1.NetworkPolicy) (*networkingv1.NetworkPolicy, error) {
+			np.Spec = networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{
+					MatchLabels: resources.BaseAppLabel(name, nil),
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						From: []networkingv1.NetworkPolicyPeer{
+							// Includes the nodeport-proxy of the LoadBalancer expose strategy
+							resources.SameNamespaceNetworkPolicyPeer(),
+							resources.NodePortProxyNetworkPolicyPeer(),
+							resources.SeedControllerManagerNetworkPolicyPeer(),
+						},
+					},
+				},
+			}
+
+			return np, nil
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package certificates
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"sort"
+	"time"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// StoredCertificate is a certificate that is stored in a secret
+type StoredCertificate struct {
+	SecretName string
+	Key        string
+	Cert       *x509.Certificate
+}
+
+// DaysUntilExpiry returns the days until the certificate expires, negative values mean it expired already
+func (c StoredCertificate) DaysUntilExpiry(now time.Time) float64 {
+	return c.Cert.NotAfter.Sub(now).Hours() / 24
+}
+
+// SecretCertificates returns the certificates that are stored in the given secret, sorted by key.
+// Besides PEM encoded certificates the client certificates of kubeconfigs are returned. Of a bundle
+// only the first certificate is returned, as the others are only trusted in addition to it.
+func SecretCertificates(secret *corev1.Secret) []StoredCertificate {
+	var keys []string
+	for key := range secret.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var result []StoredCertificate
+	for _, key := range keys {
+		data := secret.Data[key]
+		if key == resources.KubeconfigSecretKey {
+			data = kubeconfigClientCertificate(data)
+		}
+
+		block, _ := pem.Decode(data)
+		if block == nil || block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		result = append(result, StoredCertificate{SecretName: secret.Name, Key: key, Cert: cert})
+	}
+	return result
+}
+
+func kubeconfigClientCertificate(data []byte) []byte {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil
+	}
+	authInfo := config.AuthInfos[resources.KubeconfigDefaultContextKey]
+	if authInfo == nil {
+		return nil
+	}
+	return authInfo.ClientCertificateData
+}
@@ -19,7 +19,15 @@ func GetCACreator(commonName string) reconciling.SecretCreator {
 			se.Data = map[string][]byte{}
 		}
 
-		if _, exists := se.Data[resources.CACertSecretKey]; exists {
+		if existing, exists := se.Data[resources.CACertSecretKey]; exists {
+			// The CA might have been created before the signing cert got stored separately
+			if _, exists := se.Data[resources.SigningCACertSecretKey]; !exists {
+				certs, err := certutil.ParseCertsPEM(existing)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse the existing CA: %v", err)
+				}
+				se.Data[resources.SigningCACertSecretKey] = certutil.EncodeCertPEM(certs[0])
+			}
 			return se, nil
 		}
 
@@ -30,6 +38,7 @@ func GetCACreator(commonName string) reconciling.SecretCreator {
 
 		se.Data[resources.CAKeySecretKey] = certutil.EncodePrivateKeyPEM(caKp.Key)
 		se.Data[resources.CACertSecretKey] = certutil.EncodeCertPEM(caKp.Cert)
+		se.Data[resources.SigningCACertSecretKey] = certutil.EncodeCertPEM(caKp.Cert)
 
 		return se, nil
 	}
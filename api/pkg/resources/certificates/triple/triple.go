@@ -27,6 +27,9 @@ import (
 type KeyPair struct {
 	Key  *rsa.PrivateKey
 	Cert *x509.Certificate
+	// TrustedCerts are CA certificates that are trusted in addition to Cert,
+	// e.g. while a CA gets rotated
+	TrustedCerts []*x509.Certificate
 }
 
 func NewCA(name string) (*KeyPair, error) {
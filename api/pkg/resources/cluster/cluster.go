@@ -22,8 +22,15 @@ func Spec(apiCluster apiv1.Cluster, dc *kubermaticv1.Datacenter, secretKeyGetter
 		Version:                             apiCluster.Spec.Version,
 		UsePodSecurityPolicyAdmissionPlugin: apiCluster.Spec.UsePodSecurityPolicyAdmissionPlugin,
 		AuditLogging:                        apiCluster.Spec.AuditLogging,
+		Hibernation:                         apiCluster.Spec.Hibernation,
+		ClusterAutoscaler:                   apiCluster.Spec.ClusterAutoscaler,
+		EncryptionAtRest:                    apiCluster.Spec.EncryptionAtRest,
+		AdmissionPlugins:                    apiCluster.Spec.AdmissionPlugins,
+		ExtraFlags:                          apiCluster.Spec.ExtraFlags,
+		OPAIntegration:                      apiCluster.Spec.OPAIntegration,
 		Openshift:                           apiCluster.Spec.Openshift,
 	}
+	spec.ClusterNetwork.TunnelingMode = apiCluster.Spec.TunnelingMode
 
 	providerName, err := provider.ClusterCloudProviderName(spec.Cloud)
 	if err != nil {
@@ -71,17 +71,7 @@ func DeploymentCreator(data clusterautoscalerData) reconciling.NamedDeploymentCr
 					Name:    resources.ClusterAutoscalerDeploymentName,
 					Image:   data.ImageRegistry(resources.RegistryQuay) + "/kubermatic/kubernetes-cluster-autoscaler:" + tag,
 					Command: []string{"/cluster-autoscaler"},
-					Args: []string{
-						"--kubeconfig", "/etc/kubernetes/kubeconfig/kubeconfig",
-						"--leader-elect-resource-lock", "configmaps",
-						// PercentageUsed treshold. If the current utilization of a node is above this, the CA will never
-						// scale it down. Default is 0.5. Increased, because otherwise small nodes never get scaled down
-						// because the DS pods on them alone manage to get the utilization above the 0.5 threshold.
-						"--scale-down-utilization-threshold", "0.7",
-						// For debugging you can add the following to increase verbosity and make scale down kick in without
-						// delay:
-						// -v=4 --scale-down-delay-after-failure=1s --scale-down-delay-after-add=1s
-					},
+					Args:    getFlags(data.Cluster().Spec.ClusterAutoscaler),
 					// This likely won't be enough for bigger clusters, see https://github.com/kubermatic/kubermatic/issues/3568
 					// for details on how we want to fix this: https://github.com/kubermatic/kubermatic/issues/3568
 					Resources: corev1.ResourceRequirements{
@@ -129,6 +119,43 @@ func DeploymentCreator(data clusterautoscalerData) reconciling.NamedDeploymentCr
 	}
 }
 
+// DefaultScaleDownUtilizationThreshold is the PercentageUsed treshold. If the current utilization of a node
+// is above this, the CA will never scale it down. The upstream default is 0.5. Increased, because otherwise
+// small nodes never get scaled down because the DS pods on them alone manage to get the utilization above
+// the 0.5 threshold.
+const DefaultScaleDownUtilizationThreshold = "0.7"
+
+func getFlags(settings *kubermaticv1.ClusterAutoscalerSettings) []string {
+	if settings == nil {
+		settings = &kubermaticv1.ClusterAutoscalerSettings{}
+	}
+
+	utilizationThreshold := settings.ScaleDownUtilizationThreshold
+	if utilizationThreshold == "" {
+		utilizationThreshold = DefaultScaleDownUtilizationThreshold
+	}
+
+	flags := []string{
+		"--kubeconfig", "/etc/kubernetes/kubeconfig/kubeconfig",
+		"--leader-elect-resource-lock", "configmaps",
+		"--scale-down-utilization-threshold", utilizationThreshold,
+	}
+	// For debugging you can add the following to increase verbosity and make scale down kick in without
+	// delay:
+	// -v=4 --scale-down-delay-after-failure=1s --scale-down-delay-after-add=1s
+	if settings.ScaleDownDelayAfterAdd != "" {
+		flags = append(flags, "--scale-down-delay-after-add", settings.ScaleDownDelayAfterAdd)
+	}
+	if settings.ScaleDownUnneededTime != "" {
+		flags = append(flags, "--scale-down-unneeded-time", settings.ScaleDownUnneededTime)
+	}
+	if settings.Expander != "" {
+		flags = append(flags, "--expander", settings.Expander)
+	}
+
+	return flags
+}
+
 // getTag returns the correct tag for the cluster version. We need to have a distinct CA
 // version for each Kubernetes version, because the CA imports the scheduler code and the
 // behaviour of that imported code has to match with what the actual scheduler does
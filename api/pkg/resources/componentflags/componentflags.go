@@ -0,0 +1,203 @@
+// Package componentflags checks the admission plugins and the extra flags which users set for the control
+// plane components of their clusters against the component flag policy of the datacenter.
+package componentflags
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Component is a control plane component which accepts extra flags
+type Component string
+
+const (
+	Apiserver         Component = "apiserver"
+	ControllerManager Component = "controllerManager"
+	Scheduler         Component = "scheduler"
+)
+
+var (
+	// SupportedAdmissionPlugins can be enabled in addition to the admission plugins which are always enabled
+	SupportedAdmissionPlugins = sets.NewString(
+		"AlwaysPullImages",
+		"DenyEscalatingExec",
+		"EventRateLimit",
+		"ExtendedResourceToleration",
+		"LimitPodHardAntiAffinityTopology",
+		"NodeRestriction",
+		"PodNodeSelector",
+		"PodTolerationRestriction",
+	)
+	// configurableAdmissionPlugins read a configuration file
+	configurableAdmissionPlugins = sets.NewString("EventRateLimit", "PodNodeSelector", "PodTolerationRestriction")
+	// admissionPluginsRequiringConfig refuse to start without a configuration file
+	admissionPluginsRequiringConfig = sets.NewString("EventRateLimit")
+
+	// protectedFlags are managed by Kubermatic or would weaken the security of the cluster, they can't be
+	// allowed by a policy. A trailing * matches all flags with the prefix.
+	protectedFlags = map[Component][]string{
+		Apiserver: {
+			"admission-control", "admission-control-config-file", "advertise-address", "allow-privileged",
+			"anonymous-auth", "audit-*", "authentication-token-webhook-*", "authorization-*", "basic-auth-file",
+			"bind-address", "client-ca-file", "cloud-config", "cloud-provider", "disable-admission-plugins",
+			"enable-admission-plugins", "enable-bootstrap-token-auth", "encryption-provider-config",
+			"endpoint-reconciler-type", "etcd-*", "external-hostname", "feature-gates", "insecure-*",
+			"kubelet-*", "kubernetes-service-node-port", "oidc-*", "proxy-client-*", "requestheader-*",
+			"secure-port", "service-account-*", "service-cluster-ip-range", "service-node-port-range",
+			"storage-backend", "tls-*", "token-auth-file",
+		},
+		ControllerManager: {
+			"address", "allocate-node-cidrs", "authentication-*", "authorization-*", "bind-address",
+			"client-ca-file", "cloud-config", "cloud-provider", "cluster-cidr", "cluster-name", "cluster-signing-*",
+			"configure-cloud-routes", "controllers", "feature-gates", "kubeconfig", "port", "root-ca-file",
+			"secure-port", "service-account-private-key-file", "tls-*", "use-service-account-credentials",
+		},
+		Scheduler: {
+			"address", "authentication-*", "authorization-*", "bind-address", "client-ca-file", "feature-gates",
+			"kubeconfig", "port", "secure-port", "tls-*",
+		},
+	}
+)
+
+// ValidateAdmissionPlugins checks that the admission plugins are supported and allowed by the policy
+func ValidateAdmissionPlugins(plugins []kubermaticv1.AdmissionPluginSettings, policy *kubermaticv1.ComponentFlagPolicy) error {
+	seen := sets.NewString()
+	for _, plugin := range plugins {
+		if err := validateAdmissionPlugin(plugin, policy); err != nil {
+			return err
+		}
+		if seen.Has(plugin.Name) {
+			return fmt.Errorf("admission plugin %s is enabled twice", plugin.Name)
+		}
+		seen.Insert(plugin.Name)
+	}
+	return nil
+}
+
+func validateAdmissionPlugin(plugin kubermaticv1.AdmissionPluginSettings, policy *kubermaticv1.ComponentFlagPolicy) error {
+	if !SupportedAdmissionPlugins.Has(plugin.Name) {
+		return fmt.Errorf("admission plugin %q is not supported, supported are %s", plugin.Name, strings.Join(SupportedAdmissionPlugins.List(), ", "))
+	}
+	if policy != nil && len(policy.AllowedAdmissionPlugins) > 0 && !sets.NewString(policy.AllowedAdmissionPlugins...).Has(plugin.Name) {
+		return fmt.Errorf("admission plugin %s is not allowed in this datacenter", plugin.Name)
+	}
+	if plugin.Config != "" && !configurableAdmissionPlugins.Has(plugin.Name) {
+		return fmt.Errorf("admission plugin %s doesn't read a configuration", plugin.Name)
+	}
+	if plugin.Config == "" && admissionPluginsRequiringConfig.Has(plugin.Name) {
+		return fmt.Errorf("admission plugin %s requires a configuration", plugin.Name)
+	}
+	return nil
+}
+
+// ValidateExtraFlags checks that the extra flags of the component are allowed by the policy
+func ValidateExtraFlags(component Component, flags map[string]string, policy *kubermaticv1.ComponentFlagPolicy) error {
+	for _, name := range sortedNames(flags) {
+		if err := validateExtraFlag(component, name, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateExtraFlag(component Component, name string, policy *kubermaticv1.ComponentFlagPolicy) error {
+	if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, "= ") {
+		return fmt.Errorf("invalid %s flag %q, flags must be given without the leading dashes", component, name)
+	}
+	for _, protected := range protectedFlags[component] {
+		if name == protected || (strings.HasSuffix(protected, "*") && strings.HasPrefix(name, strings.TrimSuffix(protected, "*"))) {
+			return fmt.Errorf("the %s flag %s is managed by Kubermatic and can't be set", component, name)
+		}
+	}
+	if !sets.NewString(allowedFlags(component, policy)...).Has(name) {
+		return fmt.Errorf("the %s flag %s is not allowed in this datacenter", component, name)
+	}
+	return nil
+}
+
+func allowedFlags(component Component, policy *kubermaticv1.ComponentFlagPolicy) []string {
+	if policy == nil {
+		return nil
+	}
+	switch component {
+	case Apiserver:
+		return policy.AllowedApiserverFlags
+	case ControllerManager:
+		return policy.AllowedControllerManagerFlags
+	case Scheduler:
+		return policy.AllowedSchedulerFlags
+	}
+	return nil
+}
+
+// AdmissionPlugins returns the admission plugins of the cluster which are allowed in the datacenter.
+// The cluster might have been modified without validation, so plugins which aren't allowed are skipped.
+func AdmissionPlugins(cluster *kubermaticv1.Cluster, dc *kubermaticv1.Datacenter) []kubermaticv1.AdmissionPluginSettings {
+	policy := policyOf(dc)
+	var plugins []kubermaticv1.AdmissionPluginSettings
+	seen := sets.NewString()
+	for _, plugin := range cluster.Spec.AdmissionPlugins {
+		if validateAdmissionPlugin(plugin, policy) != nil || seen.Has(plugin.Name) {
+			continue
+		}
+		seen.Insert(plugin.Name)
+		plugins = append(plugins, plugin)
+	}
+	return plugins
+}
+
+// AppendExtraFlags appends the extra flags of the cluster for the component to the flags which Kubermatic
+// sets. Flags which aren't allowed in the datacenter or which are set already are skipped.
+func AppendExtraFlags(flags []string, component Component, cluster *kubermaticv1.Cluster, dc *kubermaticv1.Datacenter) []string {
+	if cluster.Spec.ExtraFlags == nil {
+		return flags
+	}
+	var extraFlags map[string]string
+	switch component {
+	case Apiserver:
+		extraFlags = cluster.Spec.ExtraFlags.Apiserver
+	case ControllerManager:
+		extraFlags = cluster.Spec.ExtraFlags.ControllerManager
+	case Scheduler:
+		extraFlags = cluster.Spec.ExtraFlags.Scheduler
+	}
+
+	policy := policyOf(dc)
+	for _, name := range sortedNames(extraFlags) {
+		if validateExtraFlag(component, name, policy) != nil || isSet(flags, name) {
+			continue
+		}
+		flags = append(flags, fmt.Sprintf("--%s=%s", name, extraFlags[name]))
+	}
+	return flags
+}
+
+func isSet(flags []string, name string) bool {
+	for _, flag := range flags {
+		if flag == "--"+name || strings.HasPrefix(flag, "--"+name+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+func policyOf(dc *kubermaticv1.Datacenter) *kubermaticv1.ComponentFlagPolicy {
+	if dc == nil {
+		return nil
+	}
+	return dc.Spec.ComponentFlagPolicy
+}
+
+func sortedNames(flags map[string]string) []string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,138 @@
+package componentflags
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+)
+
+func TestValidateAdmissionPlugins(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins []kubermaticv1.AdmissionPluginSettings
+		policy  *kubermaticv1.ComponentFlagPolicy
+		err     error
+	}{
+		{
+			name:    "supported plugins without policy",
+			plugins: []kubermaticv1.AdmissionPluginSettings{{Name: "AlwaysPullImages"}, {Name: "PodNodeSelector", Config: "podNodeSelectorPluginConfig: {}"}},
+		},
+		{
+			name:    "unsupported plugin",
+			plugins: []kubermaticv1.AdmissionPluginSettings{{Name: "AlwaysAdmit"}},
+			err:     fmt.Errorf("admission plugin \"AlwaysAdmit\" is not supported, supported are %s", "AlwaysPullImages, DenyEscalatingExec, EventRateLimit, ExtendedResourceToleration, LimitPodHardAntiAffinityTopology, NodeRestriction, PodNodeSelector, PodTolerationRestriction"),
+		},
+		{
+			name:    "plugin not allowed by the policy",
+			plugins: []kubermaticv1.AdmissionPluginSettings{{Name: "AlwaysPullImages"}},
+			policy:  &kubermaticv1.ComponentFlagPolicy{AllowedAdmissionPlugins: []string{"PodNodeSelector"}},
+			err:     fmt.Errorf("admission plugin AlwaysPullImages is not allowed in this datacenter"),
+		},
+		{
+			name:    "missing configuration",
+			plugins: []kubermaticv1.AdmissionPluginSettings{{Name: "EventRateLimit"}},
+			err:     fmt.Errorf("admission plugin EventRateLimit requires a configuration"),
+		},
+		{
+			name:    "configuration for a plugin without configuration",
+			plugins: []kubermaticv1.AdmissionPluginSettings{{Name: "AlwaysPullImages", Config: "foo: bar"}},
+			err:     fmt.Errorf("admission plugin AlwaysPullImages doesn't read a configuration"),
+		},
+		{
+			name:    "plugin enabled twice",
+			plugins: []kubermaticv1.AdmissionPluginSettings{{Name: "AlwaysPullImages"}, {Name: "AlwaysPullImages"}},
+			err:     fmt.Errorf("admission plugin AlwaysPullImages is enabled twice"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateAdmissionPlugins(test.plugins, test.policy)
+			if fmt.Sprint(err) != fmt.Sprint(test.err) {
+				t.Errorf("Expected err to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateExtraFlags(t *testing.T) {
+	policy := &kubermaticv1.ComponentFlagPolicy{
+		AllowedApiserverFlags: []string{"max-requests-inflight", "anonymous-auth"},
+	}
+	tests := []struct {
+		name      string
+		component Component
+		flags     map[string]string
+		err       error
+	}{
+		{
+			name:      "allowed flag",
+			component: Apiserver,
+			flags:     map[string]string{"max-requests-inflight": "800"},
+		},
+		{
+			name:      "flag not allowed by the policy",
+			component: Apiserver,
+			flags:     map[string]string{"max-mutating-requests-inflight": "400"},
+			err:       fmt.Errorf("the apiserver flag max-mutating-requests-inflight is not allowed in this datacenter"),
+		},
+		{
+			name:      "protected flags can't be allowed",
+			component: Apiserver,
+			flags:     map[string]string{"anonymous-auth": "true"},
+			err:       fmt.Errorf("the apiserver flag anonymous-auth is managed by Kubermatic and can't be set"),
+		},
+		{
+			name:      "protected flag prefix",
+			component: Scheduler,
+			flags:     map[string]string{"tls-cipher-suites": "TLS_RSA_WITH_RC4_128_SHA"},
+			err:       fmt.Errorf("the scheduler flag tls-cipher-suites is managed by Kubermatic and can't be set"),
+		},
+		{
+			name:      "leading dashes",
+			component: ControllerManager,
+			flags:     map[string]string{"--node-monitor-period": "10s"},
+			err:       fmt.Errorf("invalid controllerManager flag \"--node-monitor-period\", flags must be given without the leading dashes"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateExtraFlags(test.component, test.flags, policy)
+			if fmt.Sprint(err) != fmt.Sprint(test.err) {
+				t.Errorf("Expected err to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestAppendExtraFlags(t *testing.T) {
+	cluster := &kubermaticv1.Cluster{
+		Spec: kubermaticv1.ClusterSpec{
+			ExtraFlags: &kubermaticv1.ComponentExtraFlags{
+				Apiserver: map[string]string{
+					"max-requests-inflight": "800",
+					"event-ttl":             "2h",
+					"profiling":             "false",
+					"authorization-mode":    "AlwaysAllow",
+				},
+			},
+		},
+	}
+	dc := &kubermaticv1.Datacenter{
+		Spec: kubermaticv1.DatacenterSpec{
+			ComponentFlagPolicy: &kubermaticv1.ComponentFlagPolicy{
+				AllowedApiserverFlags: []string{"max-requests-inflight", "event-ttl", "authorization-mode"},
+			},
+		},
+	}
+
+	// Flags which aren't allowed are skipped, as the cluster might have been changed without validation
+	flags := AppendExtraFlags([]string{"--event-ttl", "1h"}, Apiserver, cluster, dc)
+	expected := []string{"--event-ttl", "1h", "--max-requests-inflight=800"}
+	if !reflect.DeepEqual(flags, expected) {
+		t.Errorf("expected the flags %v, got %v", expected, flags)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/cloudconfig"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/componentflags"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/vpnsidecar"
 
@@ -48,6 +49,7 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 			if err != nil {
 				return nil, err
 			}
+			flags = componentflags.AppendExtraFlags(flags, componentflags.ControllerManager, data.Cluster(), data.DC())
 
 			dep.Spec.Replicas = resources.Int32(1)
 			if data.Cluster().Spec.ComponentsOverride.ControllerManager.Replicas != nil {
@@ -60,6 +62,9 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 			dep.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: resources.ImagePullSecretName}}
 
 			volumes := getVolumes()
+			if !vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				volumes = vpnsidecar.WithoutOpenVPNVolumes(volumes)
+			}
 			if data.Cluster().Spec.Cloud.GCP != nil {
 				serviceAccountVolume := corev1.Volume{
 					Name: resources.GoogleServiceAccountVolumeName,
@@ -90,11 +95,6 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 
 			dep.Spec.Template.Spec.Volumes = volumes
 
-			openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
-			if err != nil {
-				return nil, fmt.Errorf("failed to get openvpn sidecar: %v", err)
-			}
-
 			controllerManagerMounts := []corev1.VolumeMount{
 				{
 					Name:      resources.CASecretName,
@@ -147,7 +147,6 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 			}
 
 			dep.Spec.Template.Spec.Containers = []corev1.Container{
-				*openvpnSidecar,
 				{
 					Name:      name,
 					Image:     data.ImageRegistry(resources.RegistryGCR) + "/google_containers/hyperkube-amd64:v" + data.Cluster().Spec.Version.String(),
@@ -178,6 +177,14 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 				},
 			}
 
+			if vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
+				if err != nil {
+					return nil, fmt.Errorf("failed to get openvpn sidecar: %v", err)
+				}
+				dep.Spec.Template.Spec.Containers = append([]corev1.Container{*openvpnSidecar}, dep.Spec.Template.Spec.Containers...)
+			}
+
 			dep.Spec.Template.Spec.Affinity = resources.HostnameAntiAffinity(name, data.Cluster().Name)
 
 			wrappedPodSpec, err := apiserver.IsRunningWrapper(data, dep.Spec.Template.Spec, sets.NewString(name))
@@ -196,7 +203,7 @@ func getFlags(cluster *kubermaticv1.Cluster) ([]string, error) {
 		"--kubeconfig", "/etc/kubernetes/kubeconfig/kubeconfig",
 		"--service-account-private-key-file", "/etc/kubernetes/service-account-key/sa.key",
 		"--root-ca-file", "/etc/kubernetes/pki/ca/ca.crt",
-		"--cluster-signing-cert-file", "/etc/kubernetes/pki/ca/ca.crt",
+		"--cluster-signing-cert-file", "/etc/kubernetes/pki/ca/signing-ca.crt",
 		"--cluster-signing-key-file", "/etc/kubernetes/pki/ca/ca.key",
 		"--cluster-cidr", cluster.Spec.ClusterNetwork.Pods.CIDRBlocks[0],
 		"--allocate-node-cidrs=true",
@@ -237,6 +237,11 @@ func (d *TemplateData) GetOpenVPNCA() (*ECDSAKeyPair, error) {
 	return GetOpenVPNCA(d.ctx, d.cluster, d.client)
 }
 
+// GetKonnectivityCA returns the root ca for konnectivity
+func (d *TemplateData) GetKonnectivityCA() (*ECDSAKeyPair, error) {
+	return GetKonnectivityCA(d.ctx, d.cluster, d.client)
+}
+
 // GetPodTemplateLabels returns a set of labels for a Pod including the revisions of depending secrets and configmaps.
 // This will force pods being restarted as soon as one of the secrets/configmaps get updated.
 func (d *TemplateData) GetPodTemplateLabels(appName string, volumes []corev1.Volume, additionalLabels map[string]string) (map[string]string, error) {
@@ -268,6 +273,17 @@ func (d *TemplateData) GetOpenVPNServerPort() (int32, error) {
 	return service.Spec.Ports[0].NodePort, nil
 }
 
+// GetKonnectivityServerPort returns the nodeport of the konnectivity server service the agents connect to
+func (d *TemplateData) GetKonnectivityServerPort() (int32, error) {
+	service := &corev1.Service{}
+	key := types.NamespacedName{Namespace: d.cluster.Status.NamespaceName, Name: KonnectivityServerServiceName}
+	if err := d.client.Get(d.ctx, key, service); err != nil {
+		return 0, fmt.Errorf("failed to get NodePort for konnectivity server service: %v", err)
+	}
+
+	return service.Spec.Ports[0].NodePort, nil
+}
+
 func (d *TemplateData) NodeLocalDNSCacheEnabled() bool {
 	return d.nodeLocalDNSCacheEnabled
 }
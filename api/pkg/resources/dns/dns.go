@@ -69,6 +69,9 @@ func DeploymentCreator(data deploymentCreatorData) reconciling.NamedDeploymentCr
 			dep.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: resources.ImagePullSecretName}}
 
 			volumes := getVolumes()
+			if !vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				volumes = vpnsidecar.WithoutOpenVPNVolumes(volumes)
+			}
 			podLabels, err := data.GetPodTemplateLabels(resources.DNSResolverDeploymentName, volumes, nil)
 			if err != nil {
 				return nil, fmt.Errorf("failed to get pod labels: %v", err)
@@ -84,13 +87,7 @@ func DeploymentCreator(data deploymentCreatorData) reconciling.NamedDeploymentCr
 			dep.Spec.Template.ObjectMeta.Annotations["prometheus.io/path"] = "/metrics"
 			dep.Spec.Template.ObjectMeta.Annotations["prometheus.io/port"] = "9253"
 
-			openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
-			if err != nil {
-				return nil, fmt.Errorf("failed to get openvpn sidecar for dns resolver: %v", err)
-			}
-
 			dep.Spec.Template.Spec.Containers = []corev1.Container{
-				*openvpnSidecar,
 				{
 					Name:      resources.DNSResolverDeploymentName,
 					Image:     data.ImageRegistry(resources.RegistryGCR) + "/google_containers/coredns:1.1.3",
@@ -120,6 +117,14 @@ func DeploymentCreator(data deploymentCreatorData) reconciling.NamedDeploymentCr
 				},
 			}
 
+			if vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
+				if err != nil {
+					return nil, fmt.Errorf("failed to get openvpn sidecar for dns resolver: %v", err)
+				}
+				dep.Spec.Template.Spec.Containers = append([]corev1.Container{*openvpnSidecar}, dep.Spec.Template.Spec.Containers...)
+			}
+
 			dep.Spec.Template.Spec.Volumes = volumes
 
 			dep.Spec.Template.Spec.Affinity = resources.HostnameAntiAffinity(resources.DNSResolverDeploymentName, data.Cluster().Name)
@@ -0,0 +1,48 @@
+package etcd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDeleteDefraggerCronJob(t *testing.T) {
+	const namespace = "cluster-lg69pmx8wf"
+
+	testCases := []struct {
+		name    string
+		objects []runtime.Object
+	}{
+		{
+			name: "CronJob gets deleted",
+			objects: []runtime.Object{
+				&batchv1beta1.CronJob{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: resources.EtcdDefragCronJobName}},
+			},
+		},
+		{
+			name: "missing CronJob is no error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := ctrlruntimefake.NewFakeClient(tc.objects...)
+			if err := DeleteDefraggerCronJob(context.Background(), client, namespace); err != nil {
+				t.Fatalf("failed to delete the CronJob: %v", err)
+			}
+
+			err := client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: resources.EtcdDefragCronJobName}, &batchv1beta1.CronJob{})
+			if !kerrors.IsNotFound(err) {
+				t.Errorf("expected the CronJob to be gone, got %v", err)
+			}
+		})
+	}
+}
@@ -52,9 +52,10 @@ func ServiceCreator(data serviceCreatorData) reconciling.NamedServiceCreatorGett
 }
 
 // GetClientEndpoints returns the slice with the etcd endpoints for client communication
-func GetClientEndpoints(namespace string) []string {
+func GetClientEndpoints(cluster *kubermaticv1.Cluster) []string {
+	namespace := cluster.Status.NamespaceName
 	var endpoints []string
-	for i := 0; i < 3; i++ {
+	for i := 0; i < ClusterSize(cluster); i++ {
 		// Pod DNS name
 		serviceDNSName := resources.GetAbsoluteServiceDNSName(resources.EtcdServiceName, namespace)
 		absolutePodDNSName := fmt.Sprintf("https://etcd-%d.%s:2379", i, serviceDNSName)
@@ -0,0 +1,66 @@
+package etcd
+
+import (
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	clientPort = 2379
+	peerPort   = 2380
+)
+
+type networkPolicyData interface {
+	Cluster() *kubermaticv1.Cluster
+}
+
+// NetworkPolicyCreator returns a func to create/update the etcd NetworkPolicy. Only the apiserver, the etcd
+// backup and migration snapshot jobs, the prometheus and the kubermatic-controller-manager reach the clients
+// port, the peers port is reserved for the other members.
+func NetworkPolicyCreator(data networkPolicyData) reconciling.NamedNetworkPolicyCreatorGetter {
+	return func() (string, reconciling.NetworkPolicyCreator) {
+		return resources.EtcdNetworkPolicyName, func(np *networkingv1.NetworkPolicy) (*networkingv1.NetworkPolicy, error) {
+			tcp := corev1.ProtocolTCP
+			clientPortValue := intstr.FromInt(clientPort)
+			peerPortValue := intstr.FromInt(peerPort)
+
+			np.Spec = networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{
+					MatchLabels: getBasePodLabels(data.Cluster()),
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						From: []networkingv1.NetworkPolicyPeer{
+							resources.AppNetworkPolicyPeer(resources.ApiserverDeploymentName),
+							resources.AppNetworkPolicyPeer(resources.PrometheusStatefulSetName),
+							resources.EtcdBackupNetworkPolicyPeer(data.Cluster().Name),
+							resources.AppNetworkPolicyPeer(resources.EtcdMigrationSnapshotAppLabelValue),
+							resources.SeedControllerManagerNetworkPolicyPeer(),
+						},
+						Ports: []networkingv1.NetworkPolicyPort{
+							{Protocol: &tcp, Port: &clientPortValue},
+						},
+					},
+					{
+						From: []networkingv1.NetworkPolicyPeer{
+							resources.AppNetworkPolicyPeer(resources.EtcdStatefulSetName),
+						},
+						Ports: []networkingv1.NetworkPolicyPort{
+							{Protocol: &tcp, Port: &clientPortValue},
+							{Protocol: &tcp, Port: &peerPortValue},
+						},
+					},
+				},
+			}
+
+			return np, nil
+		}
+	}
+}
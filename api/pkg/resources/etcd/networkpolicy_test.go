@@ -0,0 +1,162 @@
+package etcd
+
+import (
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type testNetworkPolicyData struct {
+	cluster *kubermaticv1.Cluster
+}
+
+func (d *testNetworkPolicyData) Cluster() *kubermaticv1.Cluster {
+	return d.cluster
+}
+
+func TestNetworkPolicyAllowsEtcdClients(t *testing.T) {
+	cluster := &kubermaticv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "lg69pmx8wf"},
+		Status:     kubermaticv1.ClusterStatus{NamespaceName: "cluster-lg69pmx8wf"},
+	}
+	_, create := NetworkPolicyCreator(&testNetworkPolicyData{cluster: cluster})()
+	np, err := create(&networkingv1.NetworkPolicy{})
+	if err != nil {
+		t.Fatalf("failed to create the NetworkPolicy: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		namespace string
+		podLabels map[string]string
+		port      int
+		expected  bool
+	}{
+		{
+			name:      "apiserver",
+			namespace: cluster.Status.NamespaceName,
+			podLabels: resources.BaseAppLabel(resources.ApiserverDeploymentName, nil),
+			port:      clientPort,
+			expected:  true,
+		},
+		{
+			name:      "prometheus",
+			namespace: cluster.Status.NamespaceName,
+			podLabels: resources.BaseAppLabel(resources.PrometheusStatefulSetName, nil),
+			port:      clientPort,
+			expected:  true,
+		},
+		{
+			name:      "etcd backup job",
+			namespace: metav1.NamespaceSystem,
+			podLabels: resources.AppClusterLabel(resources.EtcdBackupAppLabelValue, cluster.Name, nil),
+			port:      clientPort,
+			expected:  true,
+		},
+		{
+			name:      "etcd backup job of another cluster",
+			namespace: metav1.NamespaceSystem,
+			podLabels: resources.AppClusterLabel(resources.EtcdBackupAppLabelValue, "other", nil),
+			port:      clientPort,
+			expected:  false,
+		},
+		{
+			name:      "cluster migration snapshot job",
+			namespace: cluster.Status.NamespaceName,
+			podLabels: resources.BaseAppLabel(resources.EtcdMigrationSnapshotAppLabelValue, nil),
+			port:      clientPort,
+			expected:  true,
+		},
+		{
+			name:      "kubermatic-controller-manager for the maintenance and the health probes",
+			namespace: resources.KubermaticNamespace,
+			podLabels: map[string]string{"role": "controller-manager"},
+			port:      clientPort,
+			expected:  true,
+		},
+		{
+			name:      "etcd member on the clients port",
+			namespace: cluster.Status.NamespaceName,
+			podLabels: getBasePodLabels(cluster),
+			port:      clientPort,
+			expected:  true,
+		},
+		{
+			name:      "etcd member on the peers port",
+			namespace: cluster.Status.NamespaceName,
+			podLabels: getBasePodLabels(cluster),
+			port:      peerPort,
+			expected:  true,
+		},
+		{
+			name:      "apiserver on the peers port",
+			namespace: cluster.Status.NamespaceName,
+			podLabels: resources.BaseAppLabel(resources.ApiserverDeploymentName, nil),
+			port:      peerPort,
+			expected:  false,
+		},
+		{
+			name:      "other pod of the cluster namespace",
+			namespace: cluster.Status.NamespaceName,
+			podLabels: resources.BaseAppLabel(resources.OpenVPNServerDeploymentName, nil),
+			port:      clientPort,
+			expected:  false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, err := allowsIngress(np, cluster.Status.NamespaceName, tc.namespace, tc.podLabels, tc.port)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed != tc.expected {
+				t.Errorf("expected the ingress to be allowed: %t, got %t", tc.expected, allowed)
+			}
+		})
+	}
+}
+
+// allowsIngress evaluates the ingress rules of the policy for a pod. Namespaces carry no labels, so only
+// empty namespace selectors match.
+func allowsIngress(np *networkingv1.NetworkPolicy, policyNamespace, podNamespace string, podLabels map[string]string, port int) (bool, error) {
+	for _, rule := range np.Spec.Ingress {
+		portMatches := false
+		for _, p := range rule.Ports {
+			if p.Port.IntValue() == port {
+				portMatches = true
+			}
+		}
+		if !portMatches {
+			continue
+		}
+
+		for _, peer := range rule.From {
+			if peer.NamespaceSelector == nil && podNamespace != policyNamespace {
+				continue
+			}
+			if peer.NamespaceSelector != nil {
+				namespaceSelector, err := metav1.LabelSelectorAsSelector(peer.NamespaceSelector)
+				if err != nil {
+					return false, err
+				}
+				if !namespaceSelector.Matches(labels.Set{}) {
+					continue
+				}
+			}
+			podSelector, err := metav1.LabelSelectorAsSelector(peer.PodSelector)
+			if err != nil {
+				return false, err
+			}
+			if podSelector.Matches(labels.Set(podLabels)) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -18,7 +18,12 @@ type pdbData interface {
 func PodDisruptionBudgetCreator(data pdbData) reconciling.NamedPodDisruptionBudgetCreatorGetter {
 	return func() (string, reconciling.PodDisruptionBudgetCreator) {
 		return resources.EtcdPodDisruptionBudgetName, func(pdb *policyv1beta1.PodDisruptionBudget) (*policyv1beta1.PodDisruptionBudget, error) {
-			minAvailable := intstr.FromInt((resources.EtcdClusterSize / 2) + 1)
+			// While the etcd gets scaled down, the quorum is based on the members it still has
+			size := ClusterSize(data.Cluster())
+			if status := data.Cluster().Status.Etcd; status != nil && len(status.Members) > size {
+				size = len(status.Members)
+			}
+			minAvailable := intstr.FromInt(Quorum(size))
 			pdb.Spec = policyv1beta1.PodDisruptionBudgetSpec{
 				Selector: &metav1.LabelSelector{
 					MatchLabels: getBasePodLabels(data.Cluster()),
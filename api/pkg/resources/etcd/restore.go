@@ -0,0 +1,66 @@
+package etcd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// DataVolumeClaimSpec returns the spec of the volume claims the etcd members store their data in
+func DataVolumeClaimSpec(diskSize resource.Quantity) corev1.PersistentVolumeClaimSpec {
+	return corev1.PersistentVolumeClaimSpec{
+		StorageClassName: resources.String("kubermatic-fast"),
+		AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceStorage: diskSize},
+		},
+	}
+}
+
+// MemberName returns the name of the etcd member with the given index, it matches the name of its pod
+func MemberName(member int) string {
+	return fmt.Sprintf("%s-%d", resources.EtcdStatefulSetName, member)
+}
+
+// DataVolumeClaimName returns the name of the volume claim the StatefulSet uses for the given member.
+// Claims that already exist under this name are adopted by the StatefulSet.
+func DataVolumeClaimName(member int) string {
+	return fmt.Sprintf("%s-%s", dataVolumeName, MemberName(member))
+}
+
+// DataVolumeMountPath is the path the data volume has to be mounted at for RestoreCommand
+func DataVolumeMountPath() string {
+	return dataVolumeMountPath
+}
+
+// RestoreCommand returns the command that restores the data directory of the given member from a snapshot.
+// The flags match the ones the members get started with, so that a StatefulSet started on top of the
+// restored volumes forms a cluster with the data of the snapshot.
+func RestoreCommand(clusterName, namespace string, clusterSize, member int, snapshotPath string) []string {
+	var initialCluster []string
+	for i := 0; i < clusterSize; i++ {
+		initialCluster = append(initialCluster, fmt.Sprintf("%s=%s", MemberName(i), peerURL(namespace, i)))
+	}
+
+	return []string{
+		"/usr/local/bin/etcdctl",
+		"snapshot", "restore", snapshotPath,
+		"--name", MemberName(member),
+		"--data-dir", strings.Replace(dataDir, "${POD_NAME}", MemberName(member), 1),
+		"--initial-cluster", strings.Join(initialCluster, ","),
+		"--initial-cluster-token", clusterName,
+		"--initial-advertise-peer-urls", peerURL(namespace, member),
+	}
+}
+
+func peerURL(namespace string, member int) string {
+	return fmt.Sprintf("http://%s.%s.%s.svc.cluster.local:2380", MemberName(member), resources.EtcdServiceName, namespace)
+}
+
+func clientURL(namespace string, member int) string {
+	return fmt.Sprintf("https://%s.%s.%s.svc.cluster.local:2379", MemberName(member), resources.EtcdServiceName, namespace)
+}
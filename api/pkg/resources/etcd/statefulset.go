@@ -3,6 +3,8 @@ package etcd
 import (
 	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/Masterminds/sprig"
@@ -15,6 +17,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 var (
@@ -31,8 +34,12 @@ var (
 )
 
 const (
-	name    = "etcd"
-	dataDir = "/var/run/etcd/pod_${POD_NAME}/"
+	name                = "etcd"
+	dataVolumeName      = "data"
+	dataVolumeMountPath = "/var/run/etcd"
+	dataDir             = dataVolumeMountPath + "/pod_${POD_NAME}/"
+	// initialClusterSizeEnvName is the variable that holds the number of members the cluster was bootstrapped with
+	initialClusterSizeEnvName = "INITIAL_CLUSTER_SIZE"
 	// ImageTag defines the image tag to use for the etcd image
 	ImageTag = "v3.3.15"
 )
@@ -53,7 +60,9 @@ func StatefulSetCreator(data etcdStatefulSetCreatorData, enableDataCorruptionChe
 		return resources.EtcdStatefulSetName, func(set *appsv1.StatefulSet) (*appsv1.StatefulSet, error) {
 			set.Name = resources.EtcdStatefulSetName
 
-			set.Spec.Replicas = resources.Int32(resources.EtcdClusterSize)
+			// Both need to be determined before the existing StatefulSet gets overwritten
+			initialSize := initialClusterSize(data.Cluster(), set)
+			set.Spec.Replicas = resources.Int32(computeReplicas(data.Cluster(), set))
 			set.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
 			set.Spec.PodManagementPolicy = appsv1.ParallelPodManagement
 			set.Spec.ServiceName = resources.EtcdServiceName
@@ -118,6 +127,10 @@ func StatefulSetCreator(data etcdStatefulSetCreatorData, enableDataCorruptionChe
 							Name:  "ETCDCTL_API",
 							Value: "3",
 						},
+						{
+							Name:  initialClusterSizeEnvName,
+							Value: strconv.Itoa(initialSize),
+						},
 					},
 					Ports: []corev1.ContainerPort{
 						{
@@ -153,8 +166,8 @@ func StatefulSetCreator(data etcdStatefulSetCreatorData, enableDataCorruptionChe
 					},
 					VolumeMounts: []corev1.VolumeMount{
 						{
-							Name:      "data",
-							MountPath: "/var/run/etcd",
+							Name:      dataVolumeName,
+							MountPath: dataVolumeMountPath,
 						},
 						{
 							Name:      resources.EtcdTLSCertificateSecretName,
@@ -189,16 +202,10 @@ func StatefulSetCreator(data etcdStatefulSetCreatorData, enableDataCorruptionChe
 				set.Spec.VolumeClaimTemplates = []corev1.PersistentVolumeClaim{
 					{
 						ObjectMeta: metav1.ObjectMeta{
-							Name:            "data",
+							Name:            dataVolumeName,
 							OwnerReferences: []metav1.OwnerReference{data.GetClusterRef()},
 						},
-						Spec: corev1.PersistentVolumeClaimSpec{
-							StorageClassName: resources.String("kubermatic-fast"),
-							AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{corev1.ResourceStorage: diskSize},
-							},
-						},
+						Spec: DataVolumeClaimSpec(diskSize),
 					},
 				}
 			}
@@ -208,6 +215,80 @@ func StatefulSetCreator(data etcdStatefulSetCreatorData, enableDataCorruptionChe
 	}
 }
 
+// ClusterSize returns the number of etcd members configured for the cluster, it falls back to
+// the default size if the configured one is invalid
+func ClusterSize(cluster *kubermaticv1.Cluster) int {
+	size := cluster.Spec.ComponentsOverride.Etcd.ClusterSize
+	if size == 0 || ValidateClusterSize(size) != nil {
+		return resources.EtcdClusterSize
+	}
+	return size
+}
+
+// ValidateClusterSize checks that the etcd can be scaled to the given size. Even sizes are rejected,
+// they don't tolerate more member failures than the next smaller size.
+func ValidateClusterSize(size int) error {
+	if size == 0 {
+		return nil
+	}
+	if size < 0 || size%2 == 0 || size > resources.EtcdMaxClusterSize {
+		return fmt.Errorf("the etcd cluster size must be an odd number between 1 and %d, got %d", resources.EtcdMaxClusterSize, size)
+	}
+	return nil
+}
+
+// Quorum returns the number of members an etcd cluster of the given size needs to stay available
+func Quorum(size int) int {
+	return size/2 + 1
+}
+
+// computeReplicas returns the replicas of the etcd StatefulSet. New StatefulSets get the configured size
+// right away, existing ones get scaled by one member at a time: Up while all members are healthy, down
+// once the cluster controller removed the member of the last pod from the etcd cluster.
+func computeReplicas(cluster *kubermaticv1.Cluster, set *appsv1.StatefulSet) int32 {
+	size := int32(ClusterSize(cluster))
+	if set.Spec.Replicas == nil {
+		return size
+	}
+
+	replicas := *set.Spec.Replicas
+	status := cluster.Status.Etcd
+	if status == nil {
+		return replicas
+	}
+	switch {
+	case size > replicas:
+		if cluster.Status.ExtendedHealth.Etcd == kubermaticv1.HealthStatusUp && len(status.Members) == int(replicas) {
+			return replicas + 1
+		}
+	case size < replicas:
+		if !sets.NewString(status.Members...).Has(MemberName(int(replicas) - 1)) {
+			return replicas - 1
+		}
+	}
+	return replicas
+}
+
+// initialClusterSize returns the number of members the etcd cluster was bootstrapped with, the launch script
+// of members beyond them always joins the existing cluster. StatefulSets that were created before the size
+// became configurable were bootstrapped with the default size.
+func initialClusterSize(cluster *kubermaticv1.Cluster, set *appsv1.StatefulSet) int {
+	if set.Spec.Replicas == nil {
+		return ClusterSize(cluster)
+	}
+	for _, container := range set.Spec.Template.Spec.Containers {
+		for _, env := range container.Env {
+			if env.Name != initialClusterSizeEnvName {
+				continue
+			}
+			if size, err := strconv.Atoi(env.Value); err == nil {
+				return size
+			}
+		}
+	}
+	return resources.EtcdClusterSize
+}
+
 func getVolumes() []corev1.Volume {
 	return []corev1.Volume{
 		{
@@ -255,6 +336,7 @@ type commandTplData struct {
 	Namespace             string
 	Token                 string
 	DataDir               string
+	Endpoints             string
 	Migrate               bool
 	EnableCorruptionCheck bool
 }
@@ -265,11 +347,19 @@ func getEtcdCommand(name, namespace string, migrate, enableCorruptionCheck bool)
 		return nil, fmt.Errorf("failed to parse etcd command template: %v", err)
 	}
 
+	// The launch script can't know the current size of the cluster, so it tries all members
+	// the cluster can have. The ones that don't exist are skipped by etcdctl.
+	var endpoints []string
+	for i := 0; i < resources.EtcdMaxClusterSize; i++ {
+		endpoints = append(endpoints, clientURL(namespace, i))
+	}
+
 	tplData := commandTplData{
 		ServiceName:           resources.EtcdServiceName,
 		Token:                 name,
 		Namespace:             namespace,
 		DataDir:               dataDir,
+		Endpoints:             strings.Join(endpoints, ","),
 		Migrate:               migrate,
 		EnableCorruptionCheck: enableCorruptionCheck,
 	}
@@ -338,8 +428,57 @@ else
 fi
 
 {{ else }}
-export INITIAL_STATE="new"
-export INITIAL_CLUSTER="etcd-0=http://etcd-0.{{ .ServiceName }}.{{ .Namespace }}.svc.cluster.local:2380,etcd-1=http://etcd-1.{{ .ServiceName }}.{{ .Namespace }}.svc.cluster.local:2380,etcd-2=http://etcd-2.{{ .ServiceName }}.{{ .Namespace }}.svc.cluster.local:2380"
+ETCDCTL_ARGS="--cacert /etc/etcd/pki/ca/ca.crt --cert /etc/etcd/pki/client/apiserver-etcd-client.crt --key /etc/etcd/pki/client/apiserver-etcd-client.key --endpoints {{ .Endpoints }} --dial-timeout 2s --command-timeout 10s"
+PEER_URL="http://${POD_NAME}.{{ .ServiceName }}.{{ .Namespace }}.svc.cluster.local:2380"
+
+# initial_cluster prints the members of the cluster in the format of --initial-cluster.
+# Members which didn't start yet have no name, so the name is taken from the peer URL.
+initial_cluster() {
+    etcdctl ${ETCDCTL_ARGS} member list | awk -F ', ' '{ split($4, host, "[/.]"); printf "%s%s=%s", (NR > 1 ? "," : ""), host[3], $4 }'
+}
+
+if etcdctl ${ETCDCTL_ARGS} get --keys-only --limit 1 health > /dev/null 2>&1; then
+    # The cluster has a quorum, so it exists and we join it
+    MEMBER="$(etcdctl ${ETCDCTL_ARGS} member list | grep -F "${PEER_URL}" || true)"
+    if [ -n "${MEMBER}" ] && [ ! -d "{{ .DataDir }}" ] && echo "${MEMBER}" | grep -q ", started, "; then
+        echo "${POD_NAME} lost its data, replacing its member"
+        etcdctl ${ETCDCTL_ARGS} member remove "${MEMBER%%,*}"
+        MEMBER=""
+    fi
+    if [ -z "${MEMBER}" ]; then
+        if [ -d "{{ .DataDir }}" ]; then
+            # The member got removed to scale the cluster down, the pod and its volume get deleted next
+            echo "${POD_NAME} was removed from the cluster, waiting to be deleted"
+            while true; do sleep 3600; done
+        fi
+        etcdctl ${ETCDCTL_ARGS} member add "${POD_NAME}" --peer-urls="${PEER_URL}"
+        echo "added ${POD_NAME} to members"
+    fi
+    export INITIAL_STATE="existing"
+    export INITIAL_CLUSTER="$(initial_cluster)"
+elif [ -d "{{ .DataDir }}" ]; then
+    echo "the cluster has no quorum, ${POD_NAME} restarts with its data"
+    export INITIAL_STATE="existing"
+    export INITIAL_CLUSTER="${POD_NAME}=${PEER_URL}"
+elif etcdctl ${ETCDCTL_ARGS} member list 2> /dev/null | grep -qF "${PEER_URL}"; then
+    # Adding the member took away the quorum until it starts
+    echo "${POD_NAME} was added to members, joining the cluster"
+    export INITIAL_STATE="existing"
+    export INITIAL_CLUSTER="$(initial_cluster)"
+elif [ "${POD_NAME##*-}" -lt "${INITIAL_CLUSTER_SIZE}" ]; then
+    echo "bootstrapping a new cluster of ${INITIAL_CLUSTER_SIZE} members"
+    export INITIAL_STATE="new"
+    export INITIAL_CLUSTER=""
+    i=0
+    while [ "${i}" -lt "${INITIAL_CLUSTER_SIZE}" ]; do
+        INITIAL_CLUSTER="${INITIAL_CLUSTER:+${INITIAL_CLUSTER},}etcd-${i}=http://etcd-${i}.{{ .ServiceName }}.{{ .Namespace }}.svc.cluster.local:2380"
+        i=$((i + 1))
+    done
+else
+    echo "waiting for the cluster to have a quorum before ${POD_NAME} joins it"
+    sleep 5
+    exit 1
+fi
 {{ end }}
 
 echo "initial-state: ${INITIAL_STATE}"
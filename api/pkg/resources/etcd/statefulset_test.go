@@ -5,7 +5,11 @@ import (
 	"fmt"
 	"testing"
 
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	testhelper "github.com/kubermatic/kubermatic/api/pkg/test"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/utils/pointer"
 )
 
 var update = flag.Bool("update", false, "update .golden files")
@@ -56,3 +60,89 @@ func TestGetEtcdCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestComputeReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		replicas *int32
+		health   kubermaticv1.HealthStatus
+		members  []string
+		expected int32
+	}{
+		{
+			name:     "new statefulset gets the configured size",
+			size:     5,
+			expected: 5,
+		},
+		{
+			name:     "new statefulset gets the default size",
+			expected: 3,
+		},
+		{
+			name:     "scale up by one member when healthy",
+			size:     5,
+			replicas: pointer.Int32Ptr(3),
+			health:   kubermaticv1.HealthStatusUp,
+			members:  []string{"etcd-0", "etcd-1", "etcd-2"},
+			expected: 4,
+		},
+		{
+			name:     "no scale up when unhealthy",
+			size:     5,
+			replicas: pointer.Int32Ptr(3),
+			health:   kubermaticv1.HealthStatusDown,
+			members:  []string{"etcd-0", "etcd-1", "etcd-2"},
+			expected: 3,
+		},
+		{
+			name:     "no scale up before the last member joined",
+			size:     5,
+			replicas: pointer.Int32Ptr(4),
+			health:   kubermaticv1.HealthStatusUp,
+			members:  []string{"etcd-0", "etcd-1", "etcd-2"},
+			expected: 4,
+		},
+		{
+			name:     "no scale down before the last member was removed",
+			size:     3,
+			replicas: pointer.Int32Ptr(5),
+			health:   kubermaticv1.HealthStatusUp,
+			members:  []string{"etcd-0", "etcd-1", "etcd-2", "etcd-3", "etcd-4"},
+			expected: 5,
+		},
+		{
+			name:     "scale down once the last member was removed",
+			size:     3,
+			replicas: pointer.Int32Ptr(5),
+			health:   kubermaticv1.HealthStatusUp,
+			members:  []string{"etcd-0", "etcd-1", "etcd-2", "etcd-3"},
+			expected: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := &kubermaticv1.Cluster{}
+			cluster.Spec.ComponentsOverride.Etcd.ClusterSize = test.size
+			cluster.Status.ExtendedHealth.Etcd = test.health
+			if test.members != nil {
+				cluster.Status.Etcd = &kubermaticv1.EtcdClusterStatus{Members: test.members}
+			}
+			set := &appsv1.StatefulSet{}
+			set.Spec.Replicas = test.replicas
+
+			if replicas := computeReplicas(cluster, set); replicas != test.expected {
+				t.Errorf("expected %d replicas, got %d", test.expected, replicas)
+			}
+		})
+	}
+}
+
+func TestValidateClusterSize(t *testing.T) {
+	for size, valid := range map[int]bool{0: true, 1: true, 2: false, 3: true, 5: true, 9: true, 11: false, -1: false} {
+		if err := ValidateClusterSize(size); (err == nil) != valid {
+			t.Errorf("expected size %d to be valid=%t, got err %v", size, valid, err)
+		}
+	}
+}
@@ -1,8 +1,57 @@
 export MASTER_ENDPOINT="https://etcd-0.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379"
 
 
-export INITIAL_STATE="new"
-export INITIAL_CLUSTER="etcd-0=http://etcd-0.etcd.cluster-lg69pmx8wf.svc.cluster.local:2380,etcd-1=http://etcd-1.etcd.cluster-lg69pmx8wf.svc.cluster.local:2380,etcd-2=http://etcd-2.etcd.cluster-lg69pmx8wf.svc.cluster.local:2380"
+ETCDCTL_ARGS="--cacert /etc/etcd/pki/ca/ca.crt --cert /etc/etcd/pki/client/apiserver-etcd-client.crt --key /etc/etcd/pki/client/apiserver-etcd-client.key --endpoints https://etcd-0.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-1.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-2.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-3.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-4.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-5.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-6.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-7.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379,https://etcd-8.etcd.cluster-lg69pmx8wf.svc.cluster.local:2379 --dial-timeout 2s --command-timeout 10s"
+PEER_URL="http://${POD_NAME}.etcd.cluster-lg69pmx8wf.svc.cluster.local:2380"
+
+# initial_cluster prints the members of the cluster in the format of --initial-cluster.
+# Members which didn't start yet have no name, so the name is taken from the peer URL.
+initial_cluster() {
+    etcdctl ${ETCDCTL_ARGS} member list | awk -F ', ' '{ split($4, host, "[/.]"); printf "%s%s=%s", (NR > 1 ? "," : ""), host[3], $4 }'
+}
+
+if etcdctl ${ETCDCTL_ARGS} get --keys-only --limit 1 health > /dev/null 2>&1; then
+    # The cluster has a quorum, so it exists and we join it
+    MEMBER="$(etcdctl ${ETCDCTL_ARGS} member list | grep -F "${PEER_URL}" || true)"
+    if [ -n "${MEMBER}" ] && [ ! -d "/var/run/etcd/pod_${POD_NAME}/" ] && echo "${MEMBER}" | grep -q ", started, "; then
+        echo "${POD_NAME} lost its data, replacing its member"
+        etcdctl ${ETCDCTL_ARGS} member remove "${MEMBER%%,*}"
+        MEMBER=""
+    fi
+    if [ -z "${MEMBER}" ]; then
+        if [ -d "/var/run/etcd/pod_${POD_NAME}/" ]; then
+            # The member got removed to scale the cluster down, the pod and its volume get deleted next
+            echo "${POD_NAME} was removed from the cluster, waiting to be deleted"
+            while true; do sleep 3600; done
+        fi
+        etcdctl ${ETCDCTL_ARGS} member add "${POD_NAME}" --peer-urls="${PEER_URL}"
+        echo "added ${POD_NAME} to members"
+    fi
+    export INITIAL_STATE="existing"
+    export INITIAL_CLUSTER="$(initial_cluster)"
+elif [ -d "/var/run/etcd/pod_${POD_NAME}/" ]; then
+    echo "the cluster has no quorum, ${POD_NAME} restarts with its data"
+    export INITIAL_STATE="existing"
+    export INITIAL_CLUSTER="${POD_NAME}=${PEER_URL}"
+elif etcdctl ${ETCDCTL_ARGS} member list 2> /dev/null | grep -qF "${PEER_URL}"; then
+    # Adding the member took away the quorum until it starts
+    echo "${POD_NAME} was added to members, joining the cluster"
+    export INITIAL_STATE="existing"
+    export INITIAL_CLUSTER="$(initial_cluster)"
+elif [ "${POD_NAME##*-}" -lt "${INITIAL_CLUSTER_SIZE}" ]; then
+    echo "bootstrapping a new cluster of ${INITIAL_CLUSTER_SIZE} members"
+    export INITIAL_STATE="new"
+    export INITIAL_CLUSTER=""
+    i=0
+    while [ "${i}" -lt "${INITIAL_CLUSTER_SIZE}" ]; do
+        INITIAL_CLUSTER="${INITIAL_CLUSTER:+${INITIAL_CLUSTER},}etcd-${i}=http://etcd-${i}.etcd.cluster-lg69pmx8wf.svc.cluster.local:2380"
+        i=$((i + 1))
+    done
+else
+    echo "waiting for the cluster to have a quorum before ${POD_NAME} joins it"
+    sleep 5
+    exit 1
+fi
 
 
 echo "initial-state: ${INITIAL_STATE}"
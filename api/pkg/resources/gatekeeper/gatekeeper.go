@@ -0,0 +1,274 @@
+package gatekeeper
+
+import (
+	"fmt"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates/triple"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+const (
+	name = "gatekeeper"
+	tag  = "v3.1.0-beta.8"
+
+	// Namespace is the namespace in the user cluster that holds the Gatekeeper config
+	Namespace = "gatekeeper-system"
+	// WebhookPath is the path on which Gatekeeper serves admission requests
+	WebhookPath = "/v1/admit"
+	// ConstraintTemplateAPIVersion is the apiVersion of the Gatekeeper ConstraintTemplates
+	ConstraintTemplateAPIVersion = "templates.gatekeeper.sh/v1beta1"
+	// ConstraintAPIVersion is the apiVersion of the constraints Gatekeeper creates CRDs for
+	ConstraintAPIVersion = "constraints.gatekeeper.sh/v1beta1"
+
+	webhookPort   = 8443
+	healthPort    = 9090
+	certDir       = "/certs"
+	kubeconfigDir = "/etc/kubernetes/kubeconfig"
+)
+
+var (
+	defaultResourceRequirements = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+			corev1.ResourceCPU:    resource.MustParse("1"),
+		},
+	}
+)
+
+type gatekeeperData interface {
+	GetPodTemplateLabels(string, []corev1.Volume, map[string]string) (map[string]string, error)
+	ImageRegistry(string) string
+	Cluster() *kubermaticv1.Cluster
+}
+
+// IsEnabled tells if the OPA integration is enabled for the given cluster
+func IsEnabled(cluster *kubermaticv1.Cluster) bool {
+	return cluster.Spec.OPAIntegration != nil && cluster.Spec.OPAIntegration.Enabled
+}
+
+// DeploymentCreator returns the function to create and update the Gatekeeper deployment. Gatekeeper runs
+// in the seed cluster and serves the admission webhook and the audit of the user cluster.
+func DeploymentCreator(data gatekeeperData) reconciling.NamedDeploymentCreatorGetter {
+	return func() (string, reconciling.DeploymentCreator) {
+		return resources.GatekeeperDeploymentName, func(dep *appsv1.Deployment) (*appsv1.Deployment, error) {
+			dep.Name = resources.GatekeeperDeploymentName
+			dep.Labels = resources.BaseAppLabel(name, nil)
+			dep.Spec.Replicas = resources.Int32(1)
+			dep.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: resources.BaseAppLabel(name, nil),
+			}
+			dep.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: resources.ImagePullSecretName}}
+
+			volumes := getVolumes()
+			dep.Spec.Template.Spec.Volumes = volumes
+			podLabels, err := data.GetPodTemplateLabels(name, volumes, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create pod labels: %v", err)
+			}
+			dep.Spec.Template.ObjectMeta = metav1.ObjectMeta{Labels: podLabels}
+
+			dep.Spec.Template.Spec.Containers = []corev1.Container{
+				{
+					Name:    name,
+					Image:   data.ImageRegistry(resources.RegistryDocker) + "/openpolicyagent/gatekeeper:" + tag,
+					Command: []string{"/manager"},
+					Args: []string{
+						"--kubeconfig", kubeconfigDir + "/kubeconfig",
+						"--logtostderr",
+						"--port", fmt.Sprint(webhookPort),
+						"--health-addr", fmt.Sprintf(":%d", healthPort),
+						"--cert-dir", certDir,
+						// The serving certificate is signed by the cluster CA, which the webhook configuration
+						// in the user cluster trusts
+						"--disable-cert-rotation",
+						"--audit-interval", "60",
+						"--constraint-violations-limit", "20",
+					},
+					Env: []corev1.EnvVar{
+						{
+							// Gatekeeper reads its config from this namespace of the user cluster
+							Name:  "POD_NAMESPACE",
+							Value: Namespace,
+						},
+						{
+							Name: "POD_NAME",
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{
+									FieldPath:  "metadata.name",
+									APIVersion: "v1",
+								},
+							},
+						},
+					},
+					Resources: defaultResourceRequirements,
+					ReadinessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							HTTPGet: &corev1.HTTPGetAction{
+								Path:   "/readyz",
+								Port:   intstr.FromInt(healthPort),
+								Scheme: corev1.URISchemeHTTP,
+							},
+						},
+						FailureThreshold: 3,
+						PeriodSeconds:    10,
+						SuccessThreshold: 1,
+						TimeoutSeconds:   15,
+					},
+					LivenessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							HTTPGet: &corev1.HTTPGetAction{
+								Path:   "/healthz",
+								Port:   intstr.FromInt(healthPort),
+								Scheme: corev1.URISchemeHTTP,
+							},
+						},
+						FailureThreshold:    8,
+						InitialDelaySeconds: 15,
+						PeriodSeconds:       10,
+						SuccessThreshold:    1,
+						TimeoutSeconds:      15,
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      resources.InternalUserClusterAdminKubeconfigSecretName,
+							MountPath: kubeconfigDir,
+							ReadOnly:  true,
+						},
+						{
+							Name:      resources.GatekeeperWebhookServingCertSecretName,
+							MountPath: certDir,
+							ReadOnly:  true,
+						},
+					},
+				},
+			}
+
+			// The ConstraintTemplate CRD gets created by the usercluster-controller
+			wrappedPodSpec, err := apiserver.IsRunningWrapper(data, dep.Spec.Template.Spec, sets.NewString(name), "ConstraintTemplate,templates.gatekeeper.sh/v1beta1")
+			if err != nil {
+				return nil, fmt.Errorf("failed to add apiserver.IsRunningWrapper: %v", err)
+			}
+			dep.Spec.Template.Spec = *wrappedPodSpec
+
+			return dep, nil
+		}
+	}
+}
+
+func getVolumes() []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: resources.InternalUserClusterAdminKubeconfigSecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: resources.InternalUserClusterAdminKubeconfigSecretName,
+				},
+			},
+		},
+		{
+			Name: resources.GatekeeperWebhookServingCertSecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: resources.GatekeeperWebhookServingCertSecretName,
+				},
+			},
+		},
+	}
+}
+
+// ServiceCreator returns the function to reconcile the Gatekeeper webhook service
+func ServiceCreator() reconciling.NamedServiceCreatorGetter {
+	return func() (string, reconciling.ServiceCreator) {
+		return resources.GatekeeperWebhookServiceName, func(se *corev1.Service) (*corev1.Service, error) {
+			se.Name = resources.GatekeeperWebhookServiceName
+			se.Labels = resources.BaseAppLabel(name, nil)
+
+			se.Spec.Type = corev1.ServiceTypeClusterIP
+			se.Spec.Selector = map[string]string{
+				resources.AppLabelKey: name,
+			}
+			se.Spec.Ports = []corev1.ServicePort{
+				{
+					Name:       "",
+					Port:       443,
+					Protocol:   corev1.ProtocolTCP,
+					TargetPort: intstr.FromInt(webhookPort),
+				},
+			}
+
+			return se, nil
+		}
+	}
+}
+
+type tlsServingCertCreatorData interface {
+	GetRootCA() (*triple.KeyPair, error)
+	Cluster() *kubermaticv1.Cluster
+}
+
+// TLSServingCertificateCreator returns a function to create/update the secret with the Gatekeeper webhook tls certificate
+func TLSServingCertificateCreator(data tlsServingCertCreatorData) reconciling.NamedSecretCreatorGetter {
+	return func() (string, reconciling.SecretCreator) {
+		return resources.GatekeeperWebhookServingCertSecretName, func(se *corev1.Secret) (*corev1.Secret, error) {
+			if se.Data == nil {
+				se.Data = map[string][]byte{}
+			}
+
+			ca, err := data.GetRootCA()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get root ca: %v", err)
+			}
+			namespace := data.Cluster().Status.NamespaceName
+			commonName := fmt.Sprintf("%s.%s.svc.cluster.local.", resources.GatekeeperWebhookServiceName, namespace)
+			altNames := certutil.AltNames{
+				DNSNames: []string{
+					resources.GatekeeperWebhookServiceName,
+					fmt.Sprintf("%s.%s", resources.GatekeeperWebhookServiceName, namespace),
+					commonName,
+					fmt.Sprintf("%s.%s.svc", resources.GatekeeperWebhookServiceName, namespace),
+					fmt.Sprintf("%s.%s.svc.", resources.GatekeeperWebhookServiceName, namespace),
+				},
+			}
+			if b, exists := se.Data[corev1.TLSCertKey]; exists {
+				certs, err := certutil.ParseCertsPEM(b)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse certificate (key=%s) from existing secret: %v", corev1.TLSCertKey, err)
+				}
+				if resources.IsServerCertificateValidForAllOf(certs[0], commonName, altNames, ca.Cert) {
+					return se, nil
+				}
+			}
+
+			newKP, err := triple.NewServerKeyPair(ca,
+				commonName,
+				resources.GatekeeperWebhookServiceName,
+				namespace,
+				"",
+				nil,
+				// The name the APIServer validates against must be in the SANs
+				[]string{commonName})
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate serving cert: %v", err)
+			}
+			se.Data[corev1.TLSCertKey] = certutil.EncodeCertPEM(newKP.Cert)
+			se.Data[corev1.TLSPrivateKeyKey] = certutil.EncodePrivateKeyPEM(newKP.Key)
+
+			return se, nil
+		}
+	}
+}
@@ -41,11 +41,30 @@ func HealthyStatefulSet(ctx context.Context, client client.Client, nn types.Name
 		return kubermaticv1.HealthStatusDown, err
 	}
 
+	return statefulSetHealth(statefulSet, minReady), nil
+}
+
+// HealthyEtcdStatefulSet tells if the members of the etcd StatefulSet have a quorum. Other than
+// HealthyStatefulSet, the number of replicas which must be ready follows the size of the StatefulSet,
+// which changes while the etcd gets scaled.
+func HealthyEtcdStatefulSet(ctx context.Context, client client.Client, nn types.NamespacedName) (kubermaticv1.HealthStatus, error) {
+	statefulSet := &appsv1.StatefulSet{}
+	if err := client.Get(ctx, nn, statefulSet); err != nil {
+		if kerrors.IsNotFound(err) {
+			return kubermaticv1.HealthStatusDown, nil
+		}
+		return kubermaticv1.HealthStatusDown, err
+	}
+
+	return statefulSetHealth(statefulSet, *statefulSet.Spec.Replicas/2+1), nil
+}
+
+func statefulSetHealth(statefulSet *appsv1.StatefulSet, minReady int32) kubermaticv1.HealthStatus {
 	if statefulSet.Status.ReadyReplicas < minReady {
-		return kubermaticv1.HealthStatusDown, nil
+		return kubermaticv1.HealthStatusDown
 	}
 	if statefulSet.Status.UpdatedReplicas != *statefulSet.Spec.Replicas || statefulSet.Status.ReadyReplicas != *statefulSet.Spec.Replicas || statefulSet.Status.Replicas != *statefulSet.Spec.Replicas {
-		return kubermaticv1.HealthStatusProvisioning, nil
+		return kubermaticv1.HealthStatusProvisioning
 	}
-	return kubermaticv1.HealthStatusUp, nil
+	return kubermaticv1.HealthStatusUp
 }
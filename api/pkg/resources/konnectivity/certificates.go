@@ -0,0 +1,126 @@
+package konnectivity
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+type certificateCreatorData interface {
+	Cluster() *kubermaticv1.Cluster
+	GetKonnectivityCA() (*resources.ECDSAKeyPair, error)
+}
+
+// CACreator returns a function to create the ECDSA-based CA to be used for konnectivity
+func CACreator() reconciling.NamedSecretCreatorGetter {
+	return func() (string, reconciling.SecretCreator) {
+		return resources.KonnectivityCASecretName, func(se *corev1.Secret) (*corev1.Secret, error) {
+			if se.Data == nil {
+				se.Data = map[string][]byte{}
+			}
+
+			if data, exists := se.Data[resources.KonnectivityCACertKey]; exists {
+				certs, err := certutil.ParseCertsPEM(data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse certificate %s from existing secret %s: %v",
+						resources.KonnectivityCACertKey, resources.KonnectivityCASecretName, err)
+				}
+				if !resources.CertWillExpireSoon(certs[0]) {
+					return se, nil
+				}
+			}
+
+			cert, key, err := certificates.GetECDSACACertAndKey()
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate konnectivity CA: %v", err)
+			}
+			se.Data[resources.KonnectivityCACertKey] = cert
+			se.Data[resources.KonnectivityCAKeyKey] = key
+
+			return se, nil
+		}
+	}
+}
+
+// TLSServingCertificateCreator returns a function to create/update a secret with the konnectivity server tls certificate.
+// It is used for both, the connections of the apiserver and of the agents.
+func TLSServingCertificateCreator(data certificateCreatorData) reconciling.NamedSecretCreatorGetter {
+	return func() (string, reconciling.SecretCreator) {
+		return resources.KonnectivityServerCertificatesSecretName, func(se *corev1.Secret) (*corev1.Secret, error) {
+			if se.Data == nil {
+				se.Data = map[string][]byte{}
+			}
+
+			ca, err := data.GetKonnectivityCA()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get konnectivity ca: %v", err)
+			}
+
+			externalIP := net.ParseIP(data.Cluster().Address.IP)
+			if externalIP == nil {
+				return nil, errors.New("no external IP")
+			}
+			namespace := data.Cluster().Status.NamespaceName
+			altNames := certutil.AltNames{
+				DNSNames: []string{
+					// ExternalName, used by the agents
+					data.Cluster().Address.ExternalName,
+					// Internal, used by the apiserver
+					resources.KonnectivityServerInternalServiceName,
+					fmt.Sprintf("%s.%s", resources.KonnectivityServerInternalServiceName, namespace),
+					fmt.Sprintf("%s.%s.svc", resources.KonnectivityServerInternalServiceName, namespace),
+					fmt.Sprintf("%s.%s.svc.cluster.local", resources.KonnectivityServerInternalServiceName, namespace),
+				},
+				IPs: []net.IP{externalIP},
+			}
+
+			if b, exists := se.Data[resources.KonnectivityServerCertSecretKey]; exists {
+				certs, err := certutil.ParseCertsPEM(b)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse certificate (key=%s) from existing secret: %v", resources.KonnectivityServerCertSecretKey, err)
+				}
+				if resources.IsServerCertificateValidForAllOf(certs[0], name, altNames, ca.Cert) {
+					return se, nil
+				}
+			}
+			config := certutil.Config{
+				CommonName: name,
+				AltNames:   altNames,
+				Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}
+			cert, key, err := certificates.GetSignedECDSACertAndKey(certificates.Duration365d, config, ca.Cert, ca.Key)
+			if err != nil {
+				return nil, fmt.Errorf("unable to sign the server certificate: %v", err)
+			}
+
+			se.Data[resources.KonnectivityServerCertSecretKey] = cert
+			se.Data[resources.KonnectivityServerKeySecretKey] = key
+
+			return se, nil
+		}
+	}
+}
+
+// ApiserverClientCertificateCreator returns a function to create/update the secret with the client certificate
+// the apiserver uses to connect to the konnectivity server
+func ApiserverClientCertificateCreator(data certificateCreatorData) reconciling.NamedSecretCreatorGetter {
+	return func() (string, reconciling.SecretCreator) {
+		return resources.KonnectivityApiserverClientCertificateSecretName, certificates.GetECDSAClientCertificateCreator(
+			resources.KonnectivityApiserverClientCertificateSecretName,
+			"apiserver",
+			[]string{},
+			resources.KonnectivityClientCertSecretKey,
+			resources.KonnectivityClientKeySecretKey,
+			data.GetKonnectivityCA,
+		)
+	}
+}
@@ -0,0 +1,91 @@
+package konnectivity
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"testing"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/certificates"
+
+	corev1 "k8s.io/api/core/v1"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+type fakeCertificateCreatorData struct {
+	cluster *kubermaticv1.Cluster
+	ca      *resources.ECDSAKeyPair
+}
+
+func (d *fakeCertificateCreatorData) Cluster() *kubermaticv1.Cluster {
+	return d.cluster
+}
+
+func (d *fakeCertificateCreatorData) GetKonnectivityCA() (*resources.ECDSAKeyPair, error) {
+	return d.ca, nil
+}
+
+func parseCA(t *testing.T, cert, key []byte) *resources.ECDSAKeyPair {
+	certs, err := certutil.ParseCertsPEM(cert)
+	if err != nil {
+		t.Fatalf("failed to parse the CA certificate: %v", err)
+	}
+	privateKey, err := certutil.ParsePrivateKeyPEM(key)
+	if err != nil {
+		t.Fatalf("failed to parse the CA key: %v", err)
+	}
+	return &resources.ECDSAKeyPair{Cert: certs[0], Key: privateKey.(*ecdsa.PrivateKey)}
+}
+
+func TestTLSServingCertificateCreator(t *testing.T) {
+	_, create := CACreator()()
+	caSecret, err := create(&corev1.Secret{})
+	if err != nil {
+		t.Fatalf("failed to create the CA: %v", err)
+	}
+	ca := parseCA(t, caSecret.Data[resources.KonnectivityCACertKey], caSecret.Data[resources.KonnectivityCAKeyKey])
+
+	cluster := &kubermaticv1.Cluster{}
+	cluster.Status.NamespaceName = "cluster-test"
+	cluster.Address.ExternalName = "test.europe-west3-c.dev.kubermatic.io"
+	cluster.Address.IP = "35.198.93.90"
+	data := &fakeCertificateCreatorData{cluster: cluster, ca: ca}
+
+	_, create = TLSServingCertificateCreator(data)()
+	secret, err := create(&corev1.Secret{})
+	if err != nil {
+		t.Fatalf("failed to create the serving certificate: %v", err)
+	}
+	certs, err := certutil.ParseCertsPEM(secret.Data[resources.KonnectivityServerCertSecretKey])
+	if err != nil {
+		t.Fatalf("failed to parse the serving certificate: %v", err)
+	}
+	for _, name := range []string{cluster.Address.ExternalName, "konnectivity-server-internal.cluster-test.svc.cluster.local"} {
+		if err := certs[0].VerifyHostname(name); err != nil {
+			t.Errorf("expected the serving certificate to be valid for %s: %v", name, err)
+		}
+	}
+
+	// A valid certificate must be kept
+	cert := secret.Data[resources.KonnectivityServerCertSecretKey]
+	if secret, err = create(secret); err != nil {
+		t.Fatalf("failed to update the serving certificate: %v", err)
+	}
+	if !bytes.Equal(cert, secret.Data[resources.KonnectivityServerCertSecretKey]) {
+		t.Errorf("expected the valid serving certificate to be kept")
+	}
+
+	// A new CA must lead to a new certificate
+	caCert, caKey, err := certificates.GetECDSACACertAndKey()
+	if err != nil {
+		t.Fatalf("failed to create a new CA: %v", err)
+	}
+	data.ca = parseCA(t, caCert, caKey)
+	if secret, err = create(secret); err != nil {
+		t.Fatalf("failed to update the serving certificate: %v", err)
+	}
+	if bytes.Equal(cert, secret.Data[resources.KonnectivityServerCertSecretKey]) {
+		t.Errorf("expected the serving certificate to be replaced after the CA changed")
+	}
+}
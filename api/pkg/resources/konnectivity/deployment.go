@@ -0,0 +1,178 @@
+package konnectivity
+
+import (
+	"fmt"
+
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var (
+	defaultResourceRequirements = corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("16Mi"),
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+			corev1.ResourceCPU:    resource.MustParse("200m"),
+		},
+	}
+)
+
+const (
+	name = "konnectivity-server"
+	// Version is the version of the konnectivity server and agent images
+	Version = "v0.0.8"
+
+	// serverPort is the port the apiserver connects to
+	serverPort = 8131
+	// agentPort is the port the agents connect to
+	agentPort = 8132
+	adminPort = 8133
+
+	serverCertsMountDir = "/etc/konnectivity/pki/server"
+	caMountDir          = "/etc/konnectivity/pki/ca"
+)
+
+type deploymentCreatorData interface {
+	GetPodTemplateLabels(string, []corev1.Volume, map[string]string) (map[string]string, error)
+	ImageRegistry(string) string
+}
+
+// DeploymentCreator returns the function to create and update the konnectivity server deployment
+func DeploymentCreator(data deploymentCreatorData) reconciling.NamedDeploymentCreatorGetter {
+	return func() (string, reconciling.DeploymentCreator) {
+		return resources.KonnectivityServerDeploymentName, func(dep *appsv1.Deployment) (*appsv1.Deployment, error) {
+			dep.Name = resources.KonnectivityServerDeploymentName
+			dep.Labels = resources.BaseAppLabel(name, nil)
+
+			// The agents connect to every server they know of, they only learn about
+			// additional servers from the server count, so we run a single instance
+			dep.Spec.Replicas = resources.Int32(1)
+			dep.Spec.Selector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					resources.AppLabelKey: name,
+				},
+			}
+			dep.Spec.Strategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+			dep.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{
+				MaxSurge: &intstr.IntOrString{
+					Type:   intstr.Int,
+					IntVal: 1,
+				},
+				MaxUnavailable: &intstr.IntOrString{
+					Type:   intstr.Int,
+					IntVal: 1,
+				},
+			}
+			dep.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: resources.ImagePullSecretName}}
+
+			volumes := getVolumes()
+			podLabels, err := data.GetPodTemplateLabels(name, volumes, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create pod labels: %v", err)
+			}
+
+			dep.Spec.Template.ObjectMeta = metav1.ObjectMeta{
+				Labels: podLabels,
+			}
+			dep.Spec.Template.Spec.Volumes = volumes
+
+			dep.Spec.Template.Spec.Containers = []corev1.Container{
+				{
+					Name:    name,
+					Image:   data.ImageRegistry(resources.RegistryK8SArtifacts) + "/k8s-artifacts-prod/kas-network-proxy/proxy-server:" + Version,
+					Command: []string{"/proxy-server"},
+					Args: []string{
+						"--logtostderr=true",
+						"--mode=http-connect",
+						fmt.Sprintf("--server-port=%d", serverPort),
+						fmt.Sprintf("--agent-port=%d", agentPort),
+						fmt.Sprintf("--admin-port=%d", adminPort),
+						// The apiserver and the agents both authenticate with a client certificate of the konnectivity CA
+						fmt.Sprintf("--server-ca-cert=%s/%s", caMountDir, resources.KonnectivityCACertKey),
+						fmt.Sprintf("--server-cert=%s/%s", serverCertsMountDir, resources.KonnectivityServerCertSecretKey),
+						fmt.Sprintf("--server-key=%s/%s", serverCertsMountDir, resources.KonnectivityServerKeySecretKey),
+						fmt.Sprintf("--cluster-ca-cert=%s/%s", caMountDir, resources.KonnectivityCACertKey),
+						fmt.Sprintf("--cluster-cert=%s/%s", serverCertsMountDir, resources.KonnectivityServerCertSecretKey),
+						fmt.Sprintf("--cluster-key=%s/%s", serverCertsMountDir, resources.KonnectivityServerKeySecretKey),
+					},
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "server",
+							ContainerPort: serverPort,
+							Protocol:      corev1.ProtocolTCP,
+						},
+						{
+							Name:          "agent",
+							ContainerPort: agentPort,
+							Protocol:      corev1.ProtocolTCP,
+						},
+					},
+					Resources: defaultResourceRequirements,
+					ReadinessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							TCPSocket: &corev1.TCPSocketAction{
+								Port: intstr.FromInt(serverPort),
+							},
+						},
+						FailureThreshold:    3,
+						InitialDelaySeconds: 5,
+						PeriodSeconds:       5,
+						SuccessThreshold:    1,
+						TimeoutSeconds:      1,
+					},
+					VolumeMounts: []corev1.VolumeMount{
+						{
+							Name:      resources.KonnectivityServerCertificatesSecretName,
+							MountPath: serverCertsMountDir,
+							ReadOnly:  true,
+						},
+						{
+							Name:      resources.KonnectivityCASecretName,
+							MountPath: caMountDir,
+							ReadOnly:  true,
+						},
+					},
+				},
+			}
+
+			return dep, nil
+		}
+	}
+}
+
+func getVolumes() []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: resources.KonnectivityCASecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: resources.KonnectivityCASecretName,
+					// Only the certificate, the key stays with the controllers which sign the certificates
+					Items: []corev1.KeyToPath{
+						{
+							Path: resources.KonnectivityCACertKey,
+							Key:  resources.KonnectivityCACertKey,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name: resources.KonnectivityServerCertificatesSecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: resources.KonnectivityServerCertificatesSecretName,
+				},
+			},
+		},
+	}
+}
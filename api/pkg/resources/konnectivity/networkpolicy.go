@@ -0,0 +1,36 @@
+package konnectivity
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NetworkPolicyCreator returns a func to create/update the konnectivity server NetworkPolicy. The server is
+// reachable by the apiserver in the cluster namespace and by the agents in the user cluster through the
+// nodeport-proxy.
+func NetworkPolicyCreator() reconciling.NamedNetworkPolicyCreatorGetter {
+	return func() (string, reconciling.NetworkPolicyCreator) {
+		return resources.KonnectivityServerNetworkPolicyName, func(np *networkingv1.NetworkPolicy) (*networkingv1.NetworkPolicy, error) {
+			np.Spec = networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{
+					MatchLabels: resources.BaseAppLabel(name, nil),
+				},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						From: []networkingv1.NetworkPolicyPeer{
+							// Includes the nodeport-proxy of the LoadBalancer expose strategy
+							resources.SameNamespaceNetworkPolicyPeer(),
+							resources.NodePortProxyNetworkPolicyPeer(),
+						},
+					},
+				},
+			}
+
+			return np, nil
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package konnectivity
+
+import (
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/nodeportproxy"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ServiceCreator returns the function to reconcile the external konnectivity service the agents connect to
+func ServiceCreator(exposeStrategy corev1.ServiceType) reconciling.NamedServiceCreatorGetter {
+	return func() (string, reconciling.ServiceCreator) {
+		return resources.KonnectivityServerServiceName, func(se *corev1.Service) (*corev1.Service, error) {
+			se.Name = resources.KonnectivityServerServiceName
+			se.Labels = resources.BaseAppLabel(name, nil)
+
+			if se.Annotations == nil {
+				se.Annotations = map[string]string{}
+			}
+			if exposeStrategy == corev1.ServiceTypeNodePort {
+				se.Annotations["nodeport-proxy.k8s.io/expose"] = "true"
+				delete(se.Annotations, nodeportproxy.NodePortProxyExposeNamespacedAnnotationKey)
+			} else {
+				se.Annotations[nodeportproxy.NodePortProxyExposeNamespacedAnnotationKey] = "true"
+				delete(se.Annotations, "nodeport-proxy.k8s.io/expose")
+			}
+			se.Spec.Selector = map[string]string{
+				resources.AppLabelKey: name,
+			}
+			se.Spec.Type = corev1.ServiceTypeNodePort
+			if len(se.Spec.Ports) == 0 {
+				se.Spec.Ports = make([]corev1.ServicePort, 1)
+			}
+
+			se.Spec.Ports[0].Name = "agent"
+			se.Spec.Ports[0].Port = agentPort
+			se.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+			se.Spec.Ports[0].TargetPort = intstr.FromInt(agentPort)
+
+			return se, nil
+		}
+	}
+}
+
+// InternalServiceCreator returns the function to reconcile the internal konnectivity service the apiserver connects to
+func InternalServiceCreator() reconciling.NamedServiceCreatorGetter {
+	return func() (string, reconciling.ServiceCreator) {
+		return resources.KonnectivityServerInternalServiceName, func(se *corev1.Service) (*corev1.Service, error) {
+			se.Name = resources.KonnectivityServerInternalServiceName
+			se.Labels = resources.BaseAppLabel(name, nil)
+
+			se.Spec.Selector = map[string]string{
+				resources.AppLabelKey: name,
+			}
+			se.Spec.Type = corev1.ServiceTypeClusterIP
+			if len(se.Spec.Ports) == 0 {
+				se.Spec.Ports = make([]corev1.ServicePort, 1)
+			}
+
+			se.Spec.Ports[0].Name = "server"
+			se.Spec.Ports[0].Port = serverPort
+			se.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+			se.Spec.Ports[0].TargetPort = intstr.FromInt(serverPort)
+
+			return se, nil
+		}
+	}
+}
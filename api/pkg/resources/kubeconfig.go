@@ -1,7 +1,6 @@
 package resources
 
 import (
-	"crypto/x509"
 	"fmt"
 
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
@@ -34,7 +33,7 @@ func AdminKubeconfigCreator(data adminKubeconfigCreatorData, modifier ...func(*c
 				return nil, fmt.Errorf("failed to get cluster ca: %v", err)
 			}
 
-			config := getBaseKubeconfig(ca.Cert, data.Cluster().Address.URL, data.Cluster().Name)
+			config := getBaseKubeconfig(EncodeCABundlePEM(ca), data.Cluster().Address.URL, data.Cluster().Name)
 			config.AuthInfos = map[string]*clientcmdapi.AuthInfo{
 				KubeconfigDefaultContextKey: {
 					Token: data.Cluster().Address.AdminToken,
@@ -70,7 +69,7 @@ func ViewerKubeconfigCreator(data *TemplateData) reconciling.NamedSecretCreatorG
 				return nil, fmt.Errorf("failed to get cluster ca: %v", err)
 			}
 
-			config := getBaseKubeconfig(ca.Cert, data.Cluster().Address.URL, data.Cluster().Name)
+			config := getBaseKubeconfig(EncodeCABundlePEM(ca), data.Cluster().Address.URL, data.Cluster().Name)
 			token, err := data.GetViewerToken()
 			if err != nil {
 				return nil, fmt.Errorf("failed to get token: %v", err)
@@ -113,7 +112,7 @@ func GetInternalKubeconfigCreator(name, commonName string, organizations []strin
 
 			b := se.Data[KubeconfigSecretKey]
 			apiserverURL := fmt.Sprintf("https://%s:%d", data.Cluster().Address.InternalName, data.Cluster().Address.Port)
-			valid, err := IsValidKubeconfig(b, ca.Cert, apiserverURL, commonName, organizations, data.Cluster().Name)
+			valid, err := IsValidKubeconfig(b, ca, apiserverURL, commonName, organizations, data.Cluster().Name)
 			if err != nil || !valid {
 				if err != nil {
 					klog.V(2).Infof("failed to validate existing kubeconfig from %s/%s %v. Regenerating it...", se.Namespace, se.Name, err)
@@ -143,7 +142,7 @@ func BuildNewKubeconfigAsByte(ca *triple.KeyPair, server, commonName string, org
 }
 
 func buildNewKubeconfig(ca *triple.KeyPair, server, commonName string, organizations []string, clusterName string) (*clientcmdapi.Config, error) {
-	baseKubconfig := getBaseKubeconfig(ca.Cert, server, clusterName)
+	baseKubconfig := getBaseKubeconfig(EncodeCABundlePEM(ca), server, clusterName)
 
 	kp, err := triple.NewClientKeyPair(ca, commonName, organizations)
 	if err != nil {
@@ -160,13 +159,13 @@ func buildNewKubeconfig(ca *triple.KeyPair, server, commonName string, organizat
 	return baseKubconfig, nil
 }
 
-func getBaseKubeconfig(caCert *x509.Certificate, server, clusterName string) *clientcmdapi.Config {
+func getBaseKubeconfig(caBundle []byte, server, clusterName string) *clientcmdapi.Config {
 	return &clientcmdapi.Config{
 		Clusters: map[string]*clientcmdapi.Cluster{
 			// We use the actual cluster name here. It is later used in encodeKubeconfig()
 			// to set the filename of the kubeconfig downloaded from API to `kubeconfig-clusterName`.
 			clusterName: {
-				CertificateAuthorityData: certutil.EncodeCertPEM(caCert),
+				CertificateAuthorityData: caBundle,
 				Server:                   server,
 			},
 		},
@@ -180,7 +179,7 @@ func getBaseKubeconfig(caCert *x509.Certificate, server, clusterName string) *cl
 	}
 }
 
-func IsValidKubeconfig(kubeconfigBytes []byte, caCert *x509.Certificate, server, commonName string, organizations []string, clusterName string) (bool, error) {
+func IsValidKubeconfig(kubeconfigBytes []byte, ca *triple.KeyPair, server, commonName string, organizations []string, clusterName string) (bool, error) {
 	if len(kubeconfigBytes) == 0 {
 		return false, nil
 	}
@@ -190,7 +189,7 @@ func IsValidKubeconfig(kubeconfigBytes []byte, caCert *x509.Certificate, server,
 		return false, err
 	}
 
-	baseKubeconfig := getBaseKubeconfig(caCert, server, clusterName)
+	baseKubeconfig := getBaseKubeconfig(EncodeCABundlePEM(ca), server, clusterName)
 
 	authInfo := existingKubeconfig.AuthInfos[KubeconfigDefaultContextKey]
 	if authInfo == nil {
@@ -210,7 +209,7 @@ func IsValidKubeconfig(kubeconfigBytes []byte, caCert *x509.Certificate, server,
 		return false, err
 	}
 
-	if !IsClientCertificateValidForAllOf(certs[0], commonName, organizations, caCert) {
+	if !IsClientCertificateValidForAllOf(certs[0], commonName, organizations, ca.Cert) {
 		return false, nil
 	}
 
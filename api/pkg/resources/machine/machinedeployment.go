@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver"
 
@@ -19,11 +21,28 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/sets"
+	clustercommon "sigs.k8s.io/cluster-api/pkg/apis/cluster/common"
 	clusterv1alpha1 "sigs.k8s.io/cluster-api/pkg/apis/cluster/v1alpha1"
 )
 
+const (
+	// AutoscalerMinSizeAnnotation is read by the cluster-autoscaler and defines to how many
+	// replicas a MachineDeployment may be scaled down at most
+	AutoscalerMinSizeAnnotation = "cluster.k8s.io/cluster-api-autoscaler-node-group-min-size"
+	// AutoscalerMaxSizeAnnotation is read by the cluster-autoscaler and defines to how many
+	// replicas a MachineDeployment may be scaled up at most
+	AutoscalerMaxSizeAnnotation = "cluster.k8s.io/cluster-api-autoscaler-node-group-max-size"
+	// RevisionAnnotation is set by the machine-controller on MachineDeployments and their
+	// MachineSets and holds the revision of the template
+	RevisionAnnotation = "machinedeployment.clusters.k8s.io/revision"
+	// HealthCheckAnnotation holds the JSON encoded health check of a MachineDeployment, which the
+	// machine health check controller of the user-cluster-controller-manager remediates by
+	HealthCheckAnnotation = "kubermatic.io/machine-health-check"
+)
+
 // Deployment returns a Machine Deployment object for the given Node Deployment spec.
 func Deployment(c *kubermaticv1.Cluster, nd *apiv1.NodeDeployment, dc *kubermaticv1.Datacenter, keys []*kubermaticv1.UserSSHKey, data resources.CredentialsData) (*clusterv1alpha1.MachineDeployment, error) {
 	md := &clusterv1alpha1.MachineDeployment{}
@@ -80,6 +99,19 @@ func Deployment(c *kubermaticv1.Cluster, nd *apiv1.NodeDeployment, dc *kubermati
 		md.Spec.Paused = *nd.Spec.Paused
 	}
 
+	SetRolloutStrategy(md, &nd.Spec)
+
+	if nd.Spec.MinReplicas != nil && nd.Spec.MaxReplicas != nil {
+		md.Annotations = map[string]string{
+			AutoscalerMinSizeAnnotation: strconv.Itoa(int(*nd.Spec.MinReplicas)),
+			AutoscalerMaxSizeAnnotation: strconv.Itoa(int(*nd.Spec.MaxReplicas)),
+		}
+	}
+
+	if err := SetHealthCheck(md, &nd.Spec); err != nil {
+		return nil, err
+	}
+
 	config, err := getProviderConfig(c, nd, dc, keys, data)
 	if err != nil {
 		return nil, err
@@ -275,5 +307,190 @@ func Validate(nd *apiv1.NodeDeployment, controlPlaneVersion *semver.Version) (*a
 		}
 	}
 
+	if err := ValidateAutoscalingBounds(&nd.Spec); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRolloutStrategy(&nd.Spec); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateHealthCheck(&nd.Spec); err != nil {
+		return nil, err
+	}
+
 	return nd, nil
 }
+
+// ValidateAutoscalingBounds validates the bounds within which the cluster-autoscaler may scale a node deployment
+func ValidateAutoscalingBounds(spec *apiv1.NodeDeploymentSpec) error {
+	if spec.MinReplicas == nil && spec.MaxReplicas == nil {
+		return nil
+	}
+	if spec.MinReplicas == nil || spec.MaxReplicas == nil {
+		return errors.New("minReplicas and maxReplicas must be set together")
+	}
+	if *spec.MinReplicas < 1 {
+		return errors.New("minReplicas must be at least 1")
+	}
+	if *spec.MaxReplicas < *spec.MinReplicas {
+		return errors.New("maxReplicas must not be lower than minReplicas")
+	}
+	if spec.Replicas < *spec.MinReplicas || spec.Replicas > *spec.MaxReplicas {
+		return fmt.Errorf("replicas must be between minReplicas (%d) and maxReplicas (%d)", *spec.MinReplicas, *spec.MaxReplicas)
+	}
+	return nil
+}
+
+// AutoscalingBounds returns the bounds within which the cluster-autoscaler may scale the given
+// MachineDeployment, or nil if it doesn't get autoscaled
+func AutoscalingBounds(md *clusterv1alpha1.MachineDeployment) (min, max *int32) {
+	minSize, err := strconv.ParseInt(md.Annotations[AutoscalerMinSizeAnnotation], 10, 32)
+	if err != nil {
+		return nil, nil
+	}
+	maxSize, err := strconv.ParseInt(md.Annotations[AutoscalerMaxSizeAnnotation], 10, 32)
+	if err != nil {
+		return nil, nil
+	}
+	min, max = new(int32), new(int32)
+	*min, *max = int32(minSize), int32(maxSize)
+	return min, max
+}
+
+// SetRolloutStrategy sets the rollout strategy of the node deployment on the MachineDeployment.
+// Unset values are defaulted by the machine-controller.
+func SetRolloutStrategy(md *clusterv1alpha1.MachineDeployment, spec *apiv1.NodeDeploymentSpec) {
+	md.Spec.Strategy = nil
+	if spec.MaxSurge != nil || spec.MaxUnavailable != nil {
+		md.Spec.Strategy = &clusterv1alpha1.MachineDeploymentStrategy{
+			Type: clustercommon.RollingUpdateMachineDeploymentStrategyType,
+			RollingUpdate: &clusterv1alpha1.MachineRollingUpdateDeployment{
+				MaxSurge:       spec.MaxSurge,
+				MaxUnavailable: spec.MaxUnavailable,
+			},
+		}
+	}
+	md.Spec.MinReadySeconds = spec.MinReadySeconds
+}
+
+// RolloutStrategy returns the rollout strategy of the given MachineDeployment
+func RolloutStrategy(md *clusterv1alpha1.MachineDeployment) (maxSurge, maxUnavailable *intstr.IntOrString, minReadySeconds *int32) {
+	if md.Spec.Strategy != nil && md.Spec.Strategy.RollingUpdate != nil {
+		maxSurge = md.Spec.Strategy.RollingUpdate.MaxSurge
+		maxUnavailable = md.Spec.Strategy.RollingUpdate.MaxUnavailable
+	}
+	return maxSurge, maxUnavailable, md.Spec.MinReadySeconds
+}
+
+// ValidateRolloutStrategy validates how the nodes of a node deployment get replaced
+func ValidateRolloutStrategy(spec *apiv1.NodeDeploymentSpec) error {
+	if err := validateIntOrPercent("maxSurge", spec.MaxSurge); err != nil {
+		return err
+	}
+	if err := validateIntOrPercent("maxUnavailable", spec.MaxUnavailable); err != nil {
+		return err
+	}
+	// The machine-controller defaults maxSurge to 1 and maxUnavailable to 0
+	maxSurge := intstr.ValueOrDefault(spec.MaxSurge, intstr.FromInt(1))
+	maxUnavailable := intstr.ValueOrDefault(spec.MaxUnavailable, intstr.FromInt(0))
+	if isZeroIntOrPercent(maxSurge) && isZeroIntOrPercent(maxUnavailable) {
+		return errors.New("maxSurge and maxUnavailable must not both be 0, the rollout could never make progress")
+	}
+	if spec.MinReadySeconds != nil && *spec.MinReadySeconds < 0 {
+		return errors.New("minReadySeconds must not be negative")
+	}
+	return nil
+}
+
+func validateIntOrPercent(name string, value *intstr.IntOrString) error {
+	if value == nil {
+		return nil
+	}
+	if value.Type == intstr.String && !strings.HasSuffix(value.StrVal, "%") {
+		return fmt.Errorf("%s must be a number or a percentage", name)
+	}
+	v, err := intstr.GetValueFromIntOrPercent(value, 100, false)
+	if err != nil {
+		return fmt.Errorf("%s must be a number or a percentage: %v", name, err)
+	}
+	if v < 0 {
+		return fmt.Errorf("%s must not be negative", name)
+	}
+	if value.Type == intstr.String && v > 100 {
+		return fmt.Errorf("%s must not be above 100%%", name)
+	}
+	return nil
+}
+
+func isZeroIntOrPercent(value *intstr.IntOrString) bool {
+	v, err := intstr.GetValueFromIntOrPercent(value, 100, false)
+	return err == nil && v == 0
+}
+
+// SetHealthCheck stores the health check of the node deployment as annotation on the MachineDeployment
+func SetHealthCheck(md *clusterv1alpha1.MachineDeployment, spec *apiv1.NodeDeploymentSpec) error {
+	if spec.HealthCheck == nil {
+		delete(md.Annotations, HealthCheckAnnotation)
+		return nil
+	}
+	b, err := json.Marshal(spec.HealthCheck)
+	if err != nil {
+		return fmt.Errorf("failed to encode the health check: %v", err)
+	}
+	if md.Annotations == nil {
+		md.Annotations = map[string]string{}
+	}
+	md.Annotations[HealthCheckAnnotation] = string(b)
+	return nil
+}
+
+// HealthCheck returns the health check of the given MachineDeployment, or nil if it has none
+func HealthCheck(md *clusterv1alpha1.MachineDeployment) (*apiv1.MachineHealthCheck, error) {
+	value, ok := md.Annotations[HealthCheckAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	healthCheck := &apiv1.MachineHealthCheck{}
+	if err := json.Unmarshal([]byte(value), healthCheck); err != nil {
+		return nil, fmt.Errorf("failed to decode the health check: %v", err)
+	}
+	return healthCheck, nil
+}
+
+// ValidateHealthCheck validates when the machines of a node deployment get replaced because of unhealthy nodes
+func ValidateHealthCheck(spec *apiv1.NodeDeploymentSpec) error {
+	healthCheck := spec.HealthCheck
+	if healthCheck == nil {
+		return nil
+	}
+	allowedStatuses := sets.NewString(string(corev1.ConditionTrue), string(corev1.ConditionFalse), string(corev1.ConditionUnknown))
+	for _, condition := range healthCheck.UnhealthyConditions {
+		if condition.Type == "" {
+			return errors.New("the type of an unhealthy condition must be set")
+		}
+		if !allowedStatuses.Has(condition.Status) {
+			return fmt.Errorf("the status of the unhealthy condition %s must be one of %s", condition.Type, strings.Join(allowedStatuses.List(), ", "))
+		}
+		if err := validatePositiveDuration(fmt.Sprintf("the timeout of the unhealthy condition %s", condition.Type), condition.Timeout); err != nil {
+			return err
+		}
+	}
+	if healthCheck.NodeStartupTimeout != "" {
+		if err := validatePositiveDuration("nodeStartupTimeout", healthCheck.NodeStartupTimeout); err != nil {
+			return err
+		}
+	}
+	return validateIntOrPercent("maxUnhealthy", healthCheck.MaxUnhealthy)
+}
+
+func validatePositiveDuration(name, value string) error {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("%s must be a duration like 5m: %v", name, err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("%s must be positive", name)
+	}
+	return nil
+}
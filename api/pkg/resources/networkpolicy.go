@@ -0,0 +1,57 @@
+package resources
+
+import (
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The peers below are the components outside of the cluster namespace which need to reach the control plane.
+// Namespaces don't carry their name as label, so they get matched by their pod labels in all namespaces.
+var (
+	// seedControllerManagerPodLabels are the labels of the kubermatic-controller-manager, which manages and
+	// probes the control plane of every cluster on the seed
+	seedControllerManagerPodLabels = map[string]string{"role": "controller-manager"}
+	// nodePortProxyPodLabels are the labels of the seed-wide nodeport-proxy, which forwards the traffic from
+	// outside of the seed for the NodePort expose strategy
+	nodePortProxyPodLabels = map[string]string{AppLabelKey: "nodeport-proxy"}
+)
+
+// SameNamespaceNetworkPolicyPeer returns the peer for all pods of the cluster namespace
+func SameNamespaceNetworkPolicyPeer() networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{},
+	}
+}
+
+// AppNetworkPolicyPeer returns the peer for the pods of the given app in the cluster namespace
+func AppNetworkPolicyPeer(app string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: BaseAppLabel(app, nil),
+		},
+	}
+}
+
+// SeedControllerManagerNetworkPolicyPeer returns the peer for the kubermatic-controller-manager
+func SeedControllerManagerNetworkPolicyPeer() networkingv1.NetworkPolicyPeer {
+	return allNamespacesNetworkPolicyPeer(seedControllerManagerPodLabels)
+}
+
+// NodePortProxyNetworkPolicyPeer returns the peer for the seed-wide nodeport-proxy
+func NodePortProxyNetworkPolicyPeer() networkingv1.NetworkPolicyPeer {
+	return allNamespacesNetworkPolicyPeer(nodePortProxyPodLabels)
+}
+
+// EtcdBackupNetworkPolicyPeer returns the peer for the pods of the etcd backup jobs of the given cluster
+func EtcdBackupNetworkPolicyPeer(clusterName string) networkingv1.NetworkPolicyPeer {
+	return allNamespacesNetworkPolicyPeer(AppClusterLabel(EtcdBackupAppLabelValue, clusterName, nil))
+}
+
+func allNamespacesNetworkPolicyPeer(podLabels map[string]string) networkingv1.NetworkPolicyPeer {
+	return networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{},
+		PodSelector: &metav1.LabelSelector{
+			MatchLabels: podLabels,
+		},
+	}
+}
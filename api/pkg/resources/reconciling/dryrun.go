@@ -0,0 +1,284 @@
+package reconciling
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DryRunOperationCreate means the object does not exist yet
+	DryRunOperationCreate = "create"
+	// DryRunOperationUpdate means the existing object differs from the desired one
+	DryRunOperationUpdate = "update"
+	// DryRunOperationRecreate means the existing object differs from the desired one and
+	// gets deleted and created again, as it can not be updated
+	DryRunOperationRecreate = "recreate"
+)
+
+// DryRunChange is a change EnsureNamedObject would have made
+type DryRunChange struct {
+	// Target is the cluster the object lives in, e.g. "seed"
+	Target    string `json:"target"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Operation string `json:"operation"`
+	// Diff is an unified diff between the existing and the desired object as YAML.
+	// The values of Secrets are replaced by their checksum.
+	Diff     string    `json:"diff"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+func (c *DryRunChange) key() string {
+	return fmt.Sprintf("%s/%s/%s/%s", c.Target, c.Kind, c.Namespace, c.Name)
+}
+
+// DryRunRecorder keeps the latest pending change of every object. It exposes the changes as
+// metrics and serves them as JSON report.
+type DryRunRecorder struct {
+	log *zap.SugaredLogger
+
+	lock    sync.RWMutex
+	changes map[string]*DryRunChange
+
+	changesDesc *prometheus.Desc
+}
+
+// NewDryRunRecorder returns a new DryRunRecorder
+func NewDryRunRecorder(log *zap.SugaredLogger) *DryRunRecorder {
+	return &DryRunRecorder{
+		log:     log.Named("dry-run"),
+		changes: map[string]*DryRunChange{},
+		changesDesc: prometheus.NewDesc(
+			"kubermatic_reconciling_dry_run_changes",
+			"The number of objects the reconciling would change if the dry-run mode was disabled",
+			[]string{"target", "kind", "operation"},
+			nil,
+		),
+	}
+}
+
+// record stores the change, it gets logged if it is new or differs from the previously recorded one
+func (r *DryRunRecorder) record(change *DryRunChange) {
+	change.LastSeen = time.Now()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	previous, found := r.changes[change.key()]
+	r.changes[change.key()] = change
+	if found && previous.Operation == change.Operation && previous.Diff == change.Diff {
+		return
+	}
+	r.log.Infow("Object would be changed",
+		"target", change.Target,
+		"kind", change.Kind,
+		"namespace", change.Namespace,
+		"name", change.Name,
+		"operation", change.Operation,
+		"diff", change.Diff,
+	)
+}
+
+// forget removes the change of an object which is up to date again
+func (r *DryRunRecorder) forget(change *DryRunChange) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.changes, change.key())
+}
+
+// Changes returns all pending changes, sorted by target, kind, namespace and name
+func (r *DryRunRecorder) Changes() []DryRunChange {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	changes := make([]DryRunChange, 0, len(r.changes))
+	for _, change := range r.changes {
+		changes = append(changes, *change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].key() < changes[j].key() })
+	return changes
+}
+
+// ServeHTTP serves the pending changes as JSON report
+func (r *DryRunRecorder) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Changes()); err != nil {
+		r.log.Errorw("Failed to write the report", zap.Error(err))
+	}
+}
+
+// Describe implements the prometheus.Collector interface
+func (r *DryRunRecorder) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.changesDesc
+}
+
+// Collect implements the prometheus.Collector interface
+func (r *DryRunRecorder) Collect(ch chan<- prometheus.Metric) {
+	counts := map[[3]string]int{}
+	for _, change := range r.Changes() {
+		counts[[3]string{change.Target, change.Kind, change.Operation}]++
+	}
+	for labels, count := range counts {
+		ch <- prometheus.MustNewConstMetric(r.changesDesc, prometheus.GaugeValue, float64(count), labels[0], labels[1], labels[2])
+	}
+}
+
+// DryRunClient is a client which discards all writes. EnsureNamedObject records the changes
+// it would have made in the DryRunRecorder of the client instead of applying them.
+// Events are not written through the client, they still get emitted.
+type DryRunClient struct {
+	ctrlruntimeclient.Client
+
+	target   string
+	recorder *DryRunRecorder
+}
+
+// NewDryRunClient returns a client which reads from the given client and discards all writes.
+// target identifies the cluster the client talks to in the recorded changes.
+func NewDryRunClient(client ctrlruntimeclient.Client, target string, recorder *DryRunRecorder) *DryRunClient {
+	return &DryRunClient{Client: client, target: target, recorder: recorder}
+}
+
+// NewDryRunClientFunc returns a manager.NewClientFunc which creates the default client of the
+// manager, but wrapped in a DryRunClient
+func NewDryRunClientFunc(target string, recorder *DryRunRecorder) manager.NewClientFunc {
+	return func(cache cache.Cache, config *rest.Config, options ctrlruntimeclient.Options) (ctrlruntimeclient.Client, error) {
+		c, err := ctrlruntimeclient.New(config, options)
+		if err != nil {
+			return nil, err
+		}
+		return NewDryRunClient(&ctrlruntimeclient.DelegatingClient{
+			Reader: &ctrlruntimeclient.DelegatingReader{
+				CacheReader:  cache,
+				ClientReader: c,
+			},
+			Writer:       c,
+			StatusClient: c,
+		}, target, recorder), nil
+	}
+}
+
+// WithReader returns a copy of the client which reads from the given reader
+func (c *DryRunClient) WithReader(reader ctrlruntimeclient.Reader) *DryRunClient {
+	return NewDryRunClient(&ctrlruntimeclient.DelegatingClient{
+		Reader:       reader,
+		Writer:       c.Client,
+		StatusClient: c.Client,
+	}, c.target, c.recorder)
+}
+
+// Create discards the object
+func (c *DryRunClient) Create(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+// Update discards the object
+func (c *DryRunClient) Update(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+// Delete discards the deletion
+func (c *DryRunClient) Delete(_ context.Context, _ runtime.Object, _ ...ctrlruntimeclient.DeleteOptionFunc) error {
+	return nil
+}
+
+// Status returns a StatusWriter which discards all updates
+func (c *DryRunClient) Status() ctrlruntimeclient.StatusWriter {
+	return dryRunStatusWriter{}
+}
+
+type dryRunStatusWriter struct{}
+
+func (dryRunStatusWriter) Update(_ context.Context, _ runtime.Object) error {
+	return nil
+}
+
+func (c *DryRunClient) newChange(obj runtime.Object, operation string) *DryRunChange {
+	metaObject := obj.(metav1.Object)
+	return &DryRunChange{
+		Target:    c.target,
+		Kind:      kindOf(obj),
+		Namespace: metaObject.GetNamespace(),
+		Name:      metaObject.GetName(),
+		Operation: operation,
+	}
+}
+
+// forgetChange removes a previously recorded change of an object which is up to date now
+func (c *DryRunClient) forgetChange(obj runtime.Object) {
+	c.recorder.forget(c.newChange(obj, ""))
+}
+
+// recordChange records the difference between the existing and the desired object, existing is nil
+// when the object doesn't exist
+func (c *DryRunClient) recordChange(existing, desired runtime.Object, operation string) {
+	change := c.newChange(desired, operation)
+
+	var from string
+	if existing != nil {
+		from = toDiffableYAML(existing)
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(from),
+		B:        difflib.SplitLines(toDiffableYAML(desired)),
+		FromFile: "existing",
+		ToFile:   "desired",
+		Context:  3,
+	})
+	if err != nil {
+		diff = fmt.Sprintf("failed to compute the diff: %v", err)
+	}
+	change.Diff = diff
+	c.recorder.record(change)
+}
+
+func kindOf(obj runtime.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+}
+
+// toDiffableYAML marshals the object, the values of Secrets get replaced by their checksum
+// so changes are visible without revealing them
+func toDiffableYAML(obj runtime.Object) string {
+	if secret, ok := obj.(*corev1.Secret); ok {
+		secret = secret.DeepCopy()
+		checksums := map[string]string{}
+		for key, value := range secret.Data {
+			checksums[key] = fmt.Sprintf("sha256:%x", sha256.Sum256(value))
+		}
+		for key, value := range secret.StringData {
+			checksums[key] = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(value)))
+		}
+		secret.Data = nil
+		secret.StringData = checksums
+		obj = secret
+	}
+
+	raw, err := yaml.Marshal(obj)
+	if err != nil {
+		return fmt.Sprintf("failed to marshal the object: %v", err)
+	}
+	return string(raw)
+}
@@ -0,0 +1,109 @@
+package reconciling
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	kubermaticlog "github.com/kubermatic/kubermatic/api/pkg/log"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	controllerruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func configMapCreator(data map[string]string) ConfigMapCreator {
+	return func(existing *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+		existing.Data = data
+		return existing, nil
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	existing := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "existing"},
+		Data:       map[string]string{"foo": "bar"},
+	}
+	fakeClient := controllerruntimefake.NewFakeClient(existing.DeepCopy())
+	recorder := NewDryRunRecorder(kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar())
+	client := NewDryRunClient(fakeClient, "seed", recorder)
+
+	creators := []NamedConfigMapCreatorGetter{
+		func() (string, ConfigMapCreator) {
+			return "existing", configMapCreator(map[string]string{"foo": "baz"})
+		},
+		func() (string, ConfigMapCreator) {
+			return "new", configMapCreator(map[string]string{"foo": "bar"})
+		},
+	}
+	if err := ReconcileConfigMaps(ctx, creators, "default", client); err != nil {
+		t.Fatalf("failed to reconcile the ConfigMaps: %v", err)
+	}
+
+	current := &corev1.ConfigMap{}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "existing"}, current); err != nil {
+		t.Fatalf("failed to get the ConfigMap: %v", err)
+	}
+	if current.Data["foo"] != "bar" {
+		t.Errorf("expected the dry-run to leave the ConfigMap unchanged, got data %v", current.Data)
+	}
+	if err := fakeClient.Get(ctx, types.NamespacedName{Namespace: "default", Name: "new"}, &corev1.ConfigMap{}); err == nil {
+		t.Errorf("expected the dry-run to not create the ConfigMap")
+	}
+
+	changes := recorder.Changes()
+	if len(changes) != 2 {
+		t.Fatalf("expected two changes, got %d", len(changes))
+	}
+	update, create := changes[0], changes[1]
+	if update.Kind != "ConfigMap" || update.Name != "existing" || update.Operation != DryRunOperationUpdate {
+		t.Errorf("expected an update of the ConfigMap existing, got %s %s %s", update.Operation, update.Kind, update.Name)
+	}
+	if !strings.Contains(update.Diff, "-  foo: bar") || !strings.Contains(update.Diff, "+  foo: baz") {
+		t.Errorf("expected the diff to contain the changed value, got:\n%s", update.Diff)
+	}
+	if create.Name != "new" || create.Operation != DryRunOperationCreate {
+		t.Errorf("expected a creation of the ConfigMap new, got %s %s", create.Operation, create.Name)
+	}
+
+	// Once the object is up to date, its change gets removed
+	creators = []NamedConfigMapCreatorGetter{
+		func() (string, ConfigMapCreator) {
+			return "existing", configMapCreator(map[string]string{"foo": "bar"})
+		},
+	}
+	if err := ReconcileConfigMaps(ctx, creators, "default", client); err != nil {
+		t.Fatalf("failed to reconcile the ConfigMaps: %v", err)
+	}
+	changes = recorder.Changes()
+	if len(changes) != 1 || changes[0].Name != "new" {
+		t.Errorf("expected only the creation of the ConfigMap new to be left, got %v", changes)
+	}
+}
+
+func TestDryRunRedactsSecrets(t *testing.T) {
+	recorder := NewDryRunRecorder(kubermaticlog.New(true, kubermaticlog.FormatConsole).Sugar())
+	client := NewDryRunClient(controllerruntimefake.NewFakeClient(), "seed", recorder)
+
+	creators := []NamedSecretCreatorGetter{
+		func() (string, SecretCreator) {
+			return "token", func(existing *corev1.Secret) (*corev1.Secret, error) {
+				existing.Data = map[string][]byte{"token": []byte("very-secret")}
+				return existing, nil
+			}
+		},
+	}
+	if err := ReconcileSecrets(context.Background(), creators, "default", client); err != nil {
+		t.Fatalf("failed to reconcile the Secrets: %v", err)
+	}
+
+	changes := recorder.Changes()
+	if len(changes) != 1 {
+		t.Fatalf("expected one change, got %d", len(changes))
+	}
+	if strings.Contains(changes[0].Diff, "very-secret") || !strings.Contains(changes[0].Diff, "token: sha256:") {
+		t.Errorf("expected the value of the Secret to be replaced by its checksum, got:\n%s", changes[0].Diff)
+	}
+}
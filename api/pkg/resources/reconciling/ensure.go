@@ -60,12 +60,18 @@ func EnsureNamedObject(ctx context.Context, namespacedName types.NamespacedName,
 		exists = false
 	}
 
+	dryRunClient, dryRun := client.(*DryRunClient)
+
 	// Object does not exist in lister -> Create the Object
 	if !exists {
 		obj, err := create(emptyObject)
 		if err != nil {
 			return fmt.Errorf("failed to generate object: %v", err)
 		}
+		if dryRun {
+			dryRunClient.recordChange(nil, obj, DryRunOperationCreate)
+			return nil
+		}
 		if err := client.Create(ctx, obj); err != nil {
 			return fmt.Errorf("failed to create %T '%s': %v", obj, namespacedName.String(), err)
 		}
@@ -88,6 +94,18 @@ func EnsureNamedObject(ctx context.Context, namespacedName types.NamespacedName,
 	}
 
 	if DeepEqual(obj.(metav1.Object), existingObject.(metav1.Object)) {
+		if dryRun {
+			dryRunClient.forgetChange(obj)
+		}
+		return nil
+	}
+
+	if dryRun {
+		operation := DryRunOperationUpdate
+		if requiresRecreate {
+			operation = DryRunOperationRecreate
+		}
+		dryRunClient.recordChange(existingObject, obj, operation)
 		return nil
 	}
 
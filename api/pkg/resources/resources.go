@@ -52,6 +52,8 @@ const (
 	MetricsServerDeploymentName = "metrics-server"
 	//OpenVPNServerDeploymentName is the name for the openvpn server deployment
 	OpenVPNServerDeploymentName = "openvpn-server"
+	//KonnectivityServerDeploymentName is the name for the konnectivity server deployment
+	KonnectivityServerDeploymentName = "konnectivity-server"
 	//DNSResolverDeploymentName is the name of the dns resolver deployment
 	DNSResolverDeploymentName = "dns-resolver"
 	//DNSResolverConfigMapName is the name of the dns resolvers configmap
@@ -68,6 +70,8 @@ const (
 	ClusterAutoscalerDeploymentName = "cluster-autoscaler"
 	// KubernetesDashboardDeploymentName is the name of the Kubernetes Dashboard deployment
 	KubernetesDashboardDeploymentName = "kubernetes-dashboard"
+	// GatekeeperDeploymentName is the name of the OPA Gatekeeper deployment
+	GatekeeperDeploymentName = "gatekeeper"
 	// MetricsScraperDeploymentName is the name of dashboard-metrics-scraper deployment
 	MetricsScraperDeploymentName = "dashboard-metrics-scraper"
 	// MetricsScraperServiceName is the name of dashboard-metrics-scraper service
@@ -91,12 +95,18 @@ const (
 	MetricsServerExternalNameServiceName = "metrics-server"
 	//EtcdServiceName is the name for the etcd service
 	EtcdServiceName = "etcd"
-	//EtcdDefragCronJobName is the name for the defrag cronjob deployment
+	//EtcdDefragCronJobName is the name of the former defrag cronjob, which gets removed from existing clusters
 	EtcdDefragCronJobName = "etcd-defragger"
 	//OpenVPNServerServiceName is the name for the openvpn server service
 	OpenVPNServerServiceName = "openvpn-server"
+	//KonnectivityServerServiceName is the name for the konnectivity server service the agents connect to
+	KonnectivityServerServiceName = "konnectivity-server"
+	//KonnectivityServerInternalServiceName is the name for the konnectivity server service the apiserver connects to
+	KonnectivityServerInternalServiceName = "konnectivity-server-internal"
 	//MachineControllerWebhookServiceName is the name of the machine-controller webhook service
 	MachineControllerWebhookServiceName = "machine-controller-webhook"
+	// GatekeeperWebhookServiceName is the name of the OPA Gatekeeper webhook service
+	GatekeeperWebhookServiceName = "gatekeeper-webhook"
 
 	// MetricsServerAPIServiceName is the name for the metrics-server APIService
 	MetricsServerAPIServiceName = "v1beta1.metrics.k8s.io"
@@ -124,6 +134,9 @@ const (
 	MachineControllerWebhookServingCertCertKeyName = "cert.pem"
 	//MachineControllerWebhookServingCertKeyKeyName is the name for the key that contains the key
 	MachineControllerWebhookServingCertKeyKeyName = "key.pem"
+	// GatekeeperWebhookServingCertSecretName is the name for the secret containing the serving cert for the
+	// OPA Gatekeeper webhook
+	GatekeeperWebhookServingCertSecretName = "gatekeeper-webhook-serving-cert"
 	//PrometheusApiserverClientCertificateSecretName is the name for the secret containing the client certificate used by prometheus to access the apiserver
 	PrometheusApiserverClientCertificateSecretName = "prometheus-apiserver-certificate"
 	// ClusterAutoscalerKubeconfigSecretName is the name of the kubeconfig secret used for
@@ -145,6 +158,9 @@ const (
 	KubeletClientCertificatesSecretName = "kubelet-client-certificates"
 	//ServiceAccountKeySecretName is the name for the secret containing the service account key
 	ServiceAccountKeySecretName = "service-account-key"
+	// EncryptionConfigurationSecretName is the name of the secret containing the keys which encrypt the Secrets
+	// of the user cluster and the encryption configuration of the apiserver
+	EncryptionConfigurationSecretName = "apiserver-encryption-configuration"
 	//TokensSecretName is the name for the secret containing the user tokens
 	TokensSecretName = "tokens"
 	//ViewerTokenSecretName is the name for the secret containing the viewer token
@@ -155,6 +171,21 @@ const (
 	OpenVPNServerCertificatesSecretName = "openvpn-server-certificates"
 	//OpenVPNClientCertificatesSecretName is the name for the secret containing the openvpn client certificates
 	OpenVPNClientCertificatesSecretName = "openvpn-client-certificates"
+	// KonnectivityCASecretName is the name of the secret that contains the konnectivity CA
+	KonnectivityCASecretName = "konnectivity-ca"
+	//KonnectivityServerCertificatesSecretName is the name for the secret containing the konnectivity server certificates
+	KonnectivityServerCertificatesSecretName = "konnectivity-server-certificates"
+	//KonnectivityApiserverClientCertificateSecretName is the name for the secret containing the client certificate
+	//used by the apiserver for connecting to the konnectivity server
+	KonnectivityApiserverClientCertificateSecretName = "konnectivity-apiserver-client-certificate"
+	//KonnectivityAgentCertificatesSecretName is the name for the secret containing the certificates of the konnectivity
+	//agents inside the user cluster
+	KonnectivityAgentCertificatesSecretName = "konnectivity-agent-certificates"
+	//KonnectivityAgentDeploymentName is the name for the konnectivity agent deployment inside the user cluster
+	KonnectivityAgentDeploymentName = "konnectivity-agent"
+	//OpenVPNClientDeploymentName is the name for the openvpn client deployment inside the user cluster, it gets
+	//deployed by the openvpn addon
+	OpenVPNClientDeploymentName = "openvpn-client"
 	//EtcdTLSCertificateSecretName is the name for the secret containing the etcd tls certificate used for transport security
 	EtcdTLSCertificateSecretName = "etcd-tls-certificate"
 	//ApiserverEtcdClientCertificateSecretName is the name for the secret containing the client certificate used by the apiserver for authenticating against etcd
@@ -184,16 +215,30 @@ const (
 	OpenVPNClientConfigsConfigMapName = "openvpn-client-configs"
 	//OpenVPNClientConfigConfigMapName is the name for the ConfigMap containing the OpenVPN client config used by the client inside the user cluster
 	OpenVPNClientConfigConfigMapName = "openvpn-client-config"
+	//EgressSelectorConfigConfigMapName is the name for the ConfigMap containing the egress selector config of the apiserver
+	EgressSelectorConfigConfigMapName = "egress-selector-config"
 	//ClusterInfoConfigMapName is the name for the ConfigMap containing the cluster-info used by the bootstrap token machanism
 	ClusterInfoConfigMapName = "cluster-info"
 	//PrometheusConfigConfigMapName is the name for the configmap containing the prometheus config
 	PrometheusConfigConfigMapName = "prometheus"
 	//AuditConfigMapName is the name for the configmap that contains the content of the file that will be passed to the apiserver with the flag "--audit-policy-file".
 	AuditConfigMapName = "audit-config"
+	// AdmissionConfigurationConfigMapName is the name of the configmap with the admission configuration of the apiserver
+	// and the configuration files of the admission plugins
+	AdmissionConfigurationConfigMapName = "apiserver-admission-configuration"
+	// AdmissionConfigurationConfigMapKey is the key of the admission configuration in its configmap
+	AdmissionConfigurationConfigMapKey = "admission-configuration.yaml"
 
 	//PrometheusServiceAccountName is the name for the Prometheus serviceaccount
 	PrometheusServiceAccountName = "prometheus"
 
+	// UserClusterControllerServiceAccountName is the name of the serviceaccount of the usercluster-controller
+	// in the seed cluster
+	UserClusterControllerServiceAccountName = "usercluster-controller"
+	// UserClusterControllerSeedReaderClusterRoleName is the name of the ClusterRole which allows the
+	// usercluster-controller to read the resources it syncs from the seed cluster
+	UserClusterControllerSeedReaderClusterRoleName = "kubermatic:usercluster-controller:seed-reader"
+
 	//PrometheusRoleName is the name for the Prometheus role
 	PrometheusRoleName = "prometheus"
 
@@ -220,6 +265,8 @@ const (
 	KubernetesDashboardCertUsername = "kubermatic:kubernetes-dashboard"
 	// MetricsScraperServiceAccountUsername is the name of the user coming from kubeconfig cert
 	MetricsScraperServiceAccountUsername = "dashboard-metrics-scraper"
+	// MetricsServerServiceAccountName is the name of the service account of the metrics-server when it runs inside the user cluster
+	MetricsServerServiceAccountName = "metrics-server"
 
 	// KubeletDnatControllerClusterRoleName is the name for the KubeletDnatController cluster role
 	KubeletDnatControllerClusterRoleName = "system:kubermatic-kubeletdnat-controller"
@@ -272,6 +319,21 @@ const (
 	// MetricsServerPodDisruptionBudgetName is the name of the PDB for the metrics-server deployment
 	MetricsServerPodDisruptionBudgetName = "metrics-server"
 
+	// EtcdNetworkPolicyName is the name of the NetworkPolicy for the etcd StatefulSet
+	EtcdNetworkPolicyName = "etcd"
+	// ApiserverNetworkPolicyName is the name of the NetworkPolicy for the apiserver deployment
+	ApiserverNetworkPolicyName = "apiserver"
+	// OpenVPNServerNetworkPolicyName is the name of the NetworkPolicy for the openvpn server deployment
+	OpenVPNServerNetworkPolicyName = "openvpn-server"
+	// KonnectivityServerNetworkPolicyName is the name of the NetworkPolicy for the konnectivity server deployment
+	KonnectivityServerNetworkPolicyName = "konnectivity-server"
+
+	// EtcdBackupAppLabelValue is the app label of the etcd backup job pods
+	EtcdBackupAppLabelValue = "etcd-backup"
+	// EtcdMigrationSnapshotAppLabelValue is the app label of the pods of the cluster migration job
+	// which takes the etcd snapshot of the source cluster
+	EtcdMigrationSnapshotAppLabelValue = "etcd-migration-snapshot"
+
 	// KubermaticNamespace is the main kubermatic namespace
 	KubermaticNamespace = "kubermatic"
 
@@ -286,8 +348,10 @@ const (
 	// ClusterLabelKey defines the label key for the cluster name
 	ClusterLabelKey = "cluster"
 
-	// EtcdClusterSize defines the size of the etcd to use
+	// EtcdClusterSize defines the default size of the etcd
 	EtcdClusterSize = 3
+	// EtcdMaxClusterSize defines the largest size the etcd can be scaled to
+	EtcdMaxClusterSize = 9
 
 	// RegistryGCR defines the kubernetes docker registry at google
 	RegistryGCR = "gcr.io"
@@ -295,6 +359,8 @@ const (
 	RegistryDocker = "docker.io"
 	// RegistryQuay defines the image registry from coreos/redhat - quay
 	RegistryQuay = "quay.io"
+	// RegistryK8SArtifacts defines the registry of the images built by the kubernetes project
+	RegistryK8SArtifacts = "us.gcr.io"
 
 	// TopologyKeyHostname defines the topology key for the node hostname
 	TopologyKeyHostname = "kubernetes.io/hostname"
@@ -313,6 +379,9 @@ const (
 	// MachineControllerMutatingWebhookConfigurationName is the name of the machine-controllers mutating webhook
 	// configuration
 	MachineControllerMutatingWebhookConfigurationName = "machine-controller.kubermatic.io"
+	// GatekeeperValidatingWebhookConfigurationName is the name of the validating webhook configuration of
+	// OPA Gatekeeper
+	GatekeeperValidatingWebhookConfigurationName = "gatekeeper-validating-webhook-configuration"
 
 	// InternalUserClusterAdminKubeconfigSecretName is the name of the secret containing an admin kubeconfig that can only be used from
 	// within the seed cluster
@@ -337,6 +406,13 @@ const (
 	CAKeySecretKey = "ca.key"
 	// CACertSecretKey ca.crt
 	CACertSecretKey = "ca.crt"
+	// SigningCACertSecretKey signing-ca.crt, holds only the certificate that belongs to ca.key, while
+	// ca.crt may contain further trusted certificates
+	SigningCACertSecretKey = "signing-ca.crt"
+	// NextCAKeySecretKey next-ca.key, holds the key of the new CA while the root CA gets rotated
+	NextCAKeySecretKey = "next-ca.key"
+	// NextCACertSecretKey next-ca.crt, holds the certificate of the new CA while the root CA gets rotated
+	NextCACertSecretKey = "next-ca.crt"
 	// ApiserverTLSKeySecretKey apiserver-tls.key
 	ApiserverTLSKeySecretKey = "apiserver-tls.key"
 	// ApiserverTLSCertSecretKey apiserver-tls.crt
@@ -349,6 +425,15 @@ const (
 	ServiceAccountKeySecretKey = "sa.key"
 	// ServiceAccountKeyPublicKey is the public key for the service account signer key
 	ServiceAccountKeyPublicKey = "sa.pub"
+	// EncryptionConfigurationSecretKey encryption-configuration.yaml, holds the file passed to the apiserver
+	// with the flag "--encryption-provider-config"
+	EncryptionConfigurationSecretKey = "encryption-configuration.yaml"
+	// EncryptionKeySecretKey key, holds the key which encrypts the Secrets of the user cluster
+	EncryptionKeySecretKey = "key"
+	// NextEncryptionKeySecretKey next-key, holds the new key while the encryption key gets rotated
+	NextEncryptionKeySecretKey = "next-key"
+	// PreviousEncryptionKeySecretKey previous-key, holds the old key until all Secrets are encrypted with the new one
+	PreviousEncryptionKeySecretKey = "previous-key"
 	// KubeconfigSecretKey kubeconfig
 	KubeconfigSecretKey = "kubeconfig"
 	// TokensSecretKey tokens.csv
@@ -369,6 +454,22 @@ const (
 	OpenVPNInternalClientKeySecretKey = "client.key"
 	// OpenVPNInternalClientCertSecretKey client.crt
 	OpenVPNInternalClientCertSecretKey = "client.crt"
+	// KonnectivityCACertKey cert.pem, must match CACertSecretKey, otherwise getClusterCAFromLister doesnt work as it has
+	// the key hardcoded
+	KonnectivityCACertKey = CACertSecretKey
+	// KonnectivityCAKeyKey key.pem, must match CAKeySecretKey, otherwise getClusterCAFromLister doesnt work as it has
+	// the key hardcoded
+	KonnectivityCAKeyKey = CAKeySecretKey
+	// KonnectivityServerCertSecretKey server.crt
+	KonnectivityServerCertSecretKey = "server.crt"
+	// KonnectivityServerKeySecretKey server.key
+	KonnectivityServerKeySecretKey = "server.key"
+	// KonnectivityClientCertSecretKey client.crt
+	KonnectivityClientCertSecretKey = "client.crt"
+	// KonnectivityClientKeySecretKey client.key
+	KonnectivityClientKeySecretKey = "client.key"
+	// EgressSelectorConfigKey egress-selector-config.yaml
+	EgressSelectorConfigKey = "egress-selector-config.yaml"
 	// EtcdTLSCertSecretKey etcd-tls.crt
 	EtcdTLSCertSecretKey = "etcd-tls.crt"
 	// EtcdTLSKeySecretKey etcd-tls.key
@@ -404,7 +505,8 @@ const (
 )
 
 const (
-	minimumCertValidity30d = 30 * 24 * time.Hour
+	// CertificateRenewalWindow is the time before their expiry in which certificates get renewed
+	CertificateRenewalWindow = 30 * 24 * time.Hour
 )
 
 const (
@@ -576,9 +678,19 @@ func AppClusterLabel(appName, clusterName string, additionalLabels map[string]st
 	return podLabels
 }
 
-// CertWillExpireSoon returns if the certificate will expire in the next 30 days
+// EncodeCABundlePEM returns the PEM encoded certificates of the given CA, including the ones
+// that are trusted in addition to its own certificate
+func EncodeCABundlePEM(ca *triple.KeyPair) []byte {
+	bundle := certutil.EncodeCertPEM(ca.Cert)
+	for _, cert := range ca.TrustedCerts {
+		bundle = append(bundle, certutil.EncodeCertPEM(cert)...)
+	}
+	return bundle
+}
+
+// CertWillExpireSoon returns if the certificate will expire within the CertificateRenewalWindow
 func CertWillExpireSoon(cert *x509.Certificate) bool {
-	return time.Until(cert.NotAfter) < minimumCertValidity30d
+	return time.Until(cert.NotAfter) < CertificateRenewalWindow
 }
 
 // IsServerCertificateValidForAllOf validates if the given data is present in the given server certificate
@@ -660,7 +772,7 @@ func IsClientCertificateValidForAllOf(cert *x509.Certificate, commonName string,
 }
 
 func getECDSAClusterCAFromLister(ctx context.Context, name string, cluster *kubermaticv1.Cluster, client ctrlruntimeclient.Client) (*ECDSAKeyPair, error) {
-	cert, key, err := getClusterCAFromLister(ctx, name, cluster, client)
+	certs, key, err := getClusterCAFromLister(ctx, name, cluster, client)
 	if err != nil {
 		return nil, err
 	}
@@ -668,11 +780,11 @@ func getECDSAClusterCAFromLister(ctx context.Context, name string, cluster *kube
 	if !isECDSAKey {
 		return nil, errors.New("key is not a ECDSA key")
 	}
-	return &ECDSAKeyPair{Cert: cert, Key: ecdsaKey}, nil
+	return &ECDSAKeyPair{Cert: certs[0], Key: ecdsaKey}, nil
 }
 
 func getRSAClusterCAFromLister(ctx context.Context, name string, cluster *kubermaticv1.Cluster, client ctrlruntimeclient.Client) (*triple.KeyPair, error) {
-	cert, key, err := getClusterCAFromLister(ctx, name, cluster, client)
+	certs, key, err := getClusterCAFromLister(ctx, name, cluster, client)
 	if err != nil {
 		return nil, err
 	}
@@ -680,11 +792,13 @@ func getRSAClusterCAFromLister(ctx context.Context, name string, cluster *kuberm
 	if !isRSAKey {
 		return nil, errors.New("key is not a RSA key")
 	}
-	return &triple.KeyPair{Cert: cert, Key: rsaKey}, nil
+	return &triple.KeyPair{Cert: certs[0], Key: rsaKey, TrustedCerts: certs[1:]}, nil
 }
 
-// getClusterCAFromLister returns the CA of the cluster from the lister
-func getClusterCAFromLister(ctx context.Context, name string, cluster *kubermaticv1.Cluster, client ctrlruntimeclient.Client) (*x509.Certificate, interface{}, error) {
+// getClusterCAFromLister returns the certificates and the key of a CA of the cluster from the lister.
+// The first certificate belongs to the key, further certificates are trusted in addition, e.g. during
+// a rotation of the CA.
+func getClusterCAFromLister(ctx context.Context, name string, cluster *kubermaticv1.Cluster, client ctrlruntimeclient.Client) ([]*x509.Certificate, interface{}, error) {
 	caSecret := &corev1.Secret{}
 	caSecretKey := types.NamespacedName{Namespace: cluster.Status.NamespaceName, Name: name}
 	if err := client.Get(ctx, caSecretKey, caSecret); err != nil {
@@ -696,8 +810,8 @@ func getClusterCAFromLister(ctx context.Context, name string, cluster *kubermati
 		return nil, nil, fmt.Errorf("got an invalid cert from the CA secret %s: %v", caSecretKey, err)
 	}
 
-	if len(certs) != 1 {
-		return nil, nil, fmt.Errorf("did not find exactly one but %v certificates in the CA secret", len(certs))
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("did not find any certificate in the CA secret %s", caSecretKey)
 	}
 
 	key, err := certutil.ParsePrivateKeyPEM(caSecret.Data[CAKeySecretKey])
@@ -705,7 +819,7 @@ func getClusterCAFromLister(ctx context.Context, name string, cluster *kubermati
 		return nil, nil, fmt.Errorf("got an invalid private key from the CA secret %s: %v", caSecretKey, err)
 	}
 
-	return certs[0], key, nil
+	return certs, key, nil
 }
 
 // GetDexCAFromFile returns the Dex CA from the lister
@@ -750,6 +864,11 @@ func GetOpenVPNCA(ctx context.Context, cluster *kubermaticv1.Cluster, client ctr
 	return getECDSAClusterCAFromLister(ctx, OpenVPNCASecretName, cluster, client)
 }
 
+// GetKonnectivityCA returns the konnectivity CA of the cluster from the lister
+func GetKonnectivityCA(ctx context.Context, cluster *kubermaticv1.Cluster, client ctrlruntimeclient.Client) (*ECDSAKeyPair, error) {
+	return getECDSAClusterCAFromLister(ctx, KonnectivityCASecretName, cluster, client)
+}
+
 // ClusterIPForService returns the cluster ip for the given service
 func ClusterIPForService(name, namespace string, serviceLister corev1lister.ServiceLister) (*net.IP, error) {
 	service, err := serviceLister.Services(namespace).Get(name)
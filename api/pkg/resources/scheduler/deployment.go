@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/componentflags"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
 
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
@@ -47,6 +48,7 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 			if err != nil {
 				return nil, err
 			}
+			flags = componentflags.AppendExtraFlags(flags, componentflags.Scheduler, data.Cluster(), data.DC())
 
 			dep.Spec.Replicas = resources.Int32(1)
 			if data.Cluster().Spec.ComponentsOverride.Scheduler.Replicas != nil {
@@ -58,6 +60,9 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 			}
 
 			volumes := getVolumes()
+			if !vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				volumes = vpnsidecar.WithoutOpenVPNVolumes(volumes)
+			}
 			podLabels, err := data.GetPodTemplateLabels(name, volumes, nil)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create pod labels: %v", err)
@@ -70,11 +75,6 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 				Annotations: getPodAnnotations(data),
 			}
 
-			openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
-			if err != nil {
-				return nil, fmt.Errorf("failed to get openvpn sidecar: %v", err)
-			}
-
 			// Configure user cluster DNS resolver for this pod.
 			dep.Spec.Template.Spec.DNSPolicy, dep.Spec.Template.Spec.DNSConfig, err = resources.UserClusterDNSPolicyAndConfig(data)
 			if err != nil {
@@ -88,7 +88,6 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 				resourceRequirements = *data.Cluster().Spec.ComponentsOverride.Scheduler.Resources
 			}
 			dep.Spec.Template.Spec.Containers = []corev1.Container{
-				*openvpnSidecar,
 				{
 					Name:    name,
 					Image:   data.ImageRegistry(resources.RegistryGCR) + "/google_containers/hyperkube-amd64:v" + data.Cluster().Spec.Version.String(),
@@ -129,6 +128,14 @@ func DeploymentCreator(data *resources.TemplateData) reconciling.NamedDeployment
 				},
 			}
 
+			if vpnsidecar.OpenVPNSidecarEnabled(data.Cluster()) {
+				openvpnSidecar, err := vpnsidecar.OpenVPNSidecarContainer(data, "openvpn-client")
+				if err != nil {
+					return nil, fmt.Errorf("failed to get openvpn sidecar: %v", err)
+				}
+				dep.Spec.Template.Spec.Containers = append([]corev1.Container{*openvpnSidecar}, dep.Spec.Template.Spec.Containers...)
+			}
+
 			dep.Spec.Template.Spec.Affinity = resources.HostnameAntiAffinity(name, data.Cluster().Name)
 
 			wrappedPodSpec, err := apiserver.IsRunningWrapper(data, dep.Spec.Template.Spec, sets.NewString(name))
@@ -29,8 +29,8 @@ import (
 	"github.com/kubermatic/machine-controller/pkg/providerconfig"
 
 	appsv1 "k8s.io/api/apps/v1"
-	batchv1beta1 "k8s.io/api/batch/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -654,23 +654,14 @@ func TestLoadFiles(t *testing.T) {
 					checkTestResult(t, fixturePath, res)
 				}
 
-				for _, creatorGetter := range clustercontroller.GetCronJobCreators(data) {
-					_, create := creatorGetter()
-					res, err := create(&batchv1beta1.CronJob{})
-					if err != nil {
-						t.Fatalf("failed to create CronJob: %v", err)
-					}
-
-					fixturePath := fmt.Sprintf("cronjob-%s-%s-%s", prov, ver.Version.String(), res.Name)
+				for _, creatorGetter := range clustercontroller.GetNetworkPolicyCreators(data) {
+					name, create := creatorGetter()
+					res, err := create(&networkingv1.NetworkPolicy{})
 					if err != nil {
-						t.Fatalf("failed to create CronJob for %s: %v", fixturePath, err)
-					}
-
-					// Verify that every CronJob has the ImagePullSecret set
-					if len(res.Spec.JobTemplate.Spec.Template.Spec.ImagePullSecrets) == 0 {
-						t.Errorf("CronJob %s is missing the ImagePullSecret on the PodTemplate", res.Name)
+						t.Fatalf("failed to create NetworkPolicy: %v", err)
 					}
 
+					fixturePath := fmt.Sprintf("networkpolicy-%s-%s-%s", prov, ver.Version.String(), name)
 					checkTestResult(t, fixturePath, res)
 				}
 			})
@@ -8,6 +8,7 @@ import (
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/apiserver"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/gatekeeper"
 	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -32,9 +33,10 @@ var (
 )
 
 const (
-	name                = "usercluster-controller"
-	openvpnCAMountDir   = "/etc/kubernetes/pki/openvpn"
-	userSSHKeysMountDir = "/etc/kubernetes/usersshkeys"
+	name                   = "usercluster-controller"
+	openvpnCAMountDir      = "/etc/kubernetes/pki/openvpn"
+	konnectivityCAMountDir = "/etc/kubernetes/pki/konnectivity"
+	userSSHKeysMountDir    = "/etc/kubernetes/usersshkeys"
 )
 
 // userclusterControllerData is the subet of the deploymentData interface
@@ -46,6 +48,7 @@ type userclusterControllerData interface {
 	ImageRegistry(string) string
 	Cluster() *kubermaticv1.Cluster
 	GetOpenVPNServerPort() (int32, error)
+	GetKonnectivityServerPort() (int32, error)
 	KubermaticAPIImage() string
 	GetKubernetesCloudProviderName() string
 	CloudCredentialSecretTemplate() ([]byte, error)
@@ -77,12 +80,7 @@ func DeploymentCreator(data userclusterControllerData, openshift bool) reconcili
 			}
 			dep.Spec.Template.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: resources.ImagePullSecretName}}
 
-			openvpnServerPort, err := data.GetOpenVPNServerPort()
-			if err != nil {
-				return nil, err
-			}
-
-			volumes := getVolumes()
+			volumes := getVolumes(data.Cluster())
 			podLabels, err := data.GetPodTemplateLabels(name, volumes, nil)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create pod labels: %v", err)
@@ -99,7 +97,10 @@ func DeploymentCreator(data userclusterControllerData, openshift bool) reconcili
 
 			dep.Spec.Template.Spec.Volumes = volumes
 
-			args := append([]string{
+			// While the tunneling mode gets migrated, the agents of both modes are deployed
+			// into the user cluster
+			openvpnDeployed := data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN)
+			args := []string{
 				"-kubeconfig", "/etc/kubernetes/kubeconfig/kubeconfig",
 				"-metrics-listen-address", "0.0.0.0:8085",
 				"-health-listen-address", "0.0.0.0:8086",
@@ -107,15 +108,28 @@ func DeploymentCreator(data userclusterControllerData, openshift bool) reconcili
 				"-ca-cert", "/etc/kubernetes/pki/ca/ca.crt",
 				"-ca-key", "/etc/kubernetes/pki/ca/ca.key",
 				"-cluster-url", data.Cluster().Address.URL,
-				"-openvpn-server-port", fmt.Sprint(openvpnServerPort),
+			}
+			if openvpnDeployed {
+				openvpnServerPort, err := data.GetOpenVPNServerPort()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, "-openvpn-server-port", fmt.Sprint(openvpnServerPort))
+			}
+			args = append(args,
 				"-overwrite-registry", data.ImageRegistry(""),
 				fmt.Sprintf("-openshift=%t", openshift),
 				"-version", data.Cluster().Spec.Version.String(),
 				"-cloud-provider-name", data.GetKubernetesCloudProviderName(),
-				fmt.Sprintf("-openvpn-ca-cert-file=%s/%s", openvpnCAMountDir, resources.OpenVPNCACertKey),
-				fmt.Sprintf("-openvpn-ca-key-file=%s/%s", openvpnCAMountDir, resources.OpenVPNCAKeyKey),
-				fmt.Sprintf("-user-ssh-keys-dir-path=%s", userSSHKeysMountDir),
-			}, getNetworkArgs(data)...)
+			)
+			if openvpnDeployed {
+				args = append(args,
+					fmt.Sprintf("-openvpn-ca-cert-file=%s/%s", openvpnCAMountDir, resources.OpenVPNCACertKey),
+					fmt.Sprintf("-openvpn-ca-key-file=%s/%s", openvpnCAMountDir, resources.OpenVPNCAKeyKey),
+				)
+			}
+			args = append(args, fmt.Sprintf("-user-ssh-keys-dir-path=%s", userSSHKeysMountDir))
+			args = append(args, getNetworkArgs(data)...)
 
 			labelArgsValue, err := getLabelsArgValue(data.Cluster())
 			if err != nil {
@@ -133,6 +147,29 @@ func DeploymentCreator(data userclusterControllerData, openshift bool) reconcili
 				args = append(args, "-cloud-credential-secret-template", string(cloudCredentialSecretTemplate))
 			}
 
+			// The constraints get synced from the seed cluster, the serviceaccount only gets read
+			// access to them while the OPA integration is enabled
+			if gatekeeper.IsEnabled(data.Cluster()) {
+				args = append(args,
+					"-opa-integration",
+					"-project-id", data.Cluster().Labels[kubermaticv1.ProjectIDLabelKey],
+				)
+			}
+
+			args = append(args, fmt.Sprintf("-tunneling-mode=%s", data.Cluster().ActiveTunnelingMode()))
+			if data.Cluster().IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+				konnectivityServerPort, err := data.GetKonnectivityServerPort()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args,
+					"-konnectivity-server-port", fmt.Sprint(konnectivityServerPort),
+					fmt.Sprintf("-konnectivity-ca-cert-file=%s/%s", konnectivityCAMountDir, resources.KonnectivityCACertKey),
+					fmt.Sprintf("-konnectivity-ca-key-file=%s/%s", konnectivityCAMountDir, resources.KonnectivityCAKeyKey),
+				)
+			}
+			dep.Spec.Template.Spec.ServiceAccountName = resources.UserClusterControllerServiceAccountName
+
 			dep.Spec.Template.Spec.Containers = []corev1.Container{
 				{
 					Name:    name,
@@ -164,28 +201,7 @@ func DeploymentCreator(data userclusterControllerData, openshift bool) reconcili
 						SuccessThreshold: 1,
 						TimeoutSeconds:   15,
 					},
-					VolumeMounts: []corev1.VolumeMount{
-						{
-							Name:      resources.InternalUserClusterAdminKubeconfigSecretName,
-							MountPath: "/etc/kubernetes/kubeconfig",
-							ReadOnly:  true,
-						},
-						{
-							Name:      resources.CASecretName,
-							MountPath: "/etc/kubernetes/pki/ca",
-							ReadOnly:  true,
-						},
-						{
-							Name:      resources.OpenVPNCASecretName,
-							MountPath: openvpnCAMountDir,
-							ReadOnly:  true,
-						},
-						{
-							Name:      resources.UserSSHKeys,
-							MountPath: userSSHKeysMountDir,
-							ReadOnly:  true,
-						},
-					},
+					VolumeMounts: getVolumeMounts(data.Cluster()),
 				},
 			}
 
@@ -200,8 +216,43 @@ func DeploymentCreator(data userclusterControllerData, openshift bool) reconcili
 	}
 }
 
-func getVolumes() []corev1.Volume {
-	return []corev1.Volume{
+func getVolumeMounts(cluster *kubermaticv1.Cluster) []corev1.VolumeMount {
+	mounts := []corev1.VolumeMount{
+		{
+			Name:      resources.InternalUserClusterAdminKubeconfigSecretName,
+			MountPath: "/etc/kubernetes/kubeconfig",
+			ReadOnly:  true,
+		},
+		{
+			Name:      resources.CASecretName,
+			MountPath: "/etc/kubernetes/pki/ca",
+			ReadOnly:  true,
+		},
+	}
+	if cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      resources.OpenVPNCASecretName,
+			MountPath: openvpnCAMountDir,
+			ReadOnly:  true,
+		})
+	}
+	mounts = append(mounts, corev1.VolumeMount{
+		Name:      resources.UserSSHKeys,
+		MountPath: userSSHKeysMountDir,
+		ReadOnly:  true,
+	})
+	if cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		mounts = append(mounts, corev1.VolumeMount{
+			Name:      resources.KonnectivityCASecretName,
+			MountPath: konnectivityCAMountDir,
+			ReadOnly:  true,
+		})
+	}
+	return mounts
+}
+
+func getVolumes(cluster *kubermaticv1.Cluster) []corev1.Volume {
+	volumes := []corev1.Volume{
 		{
 			Name: resources.InternalUserClusterAdminKubeconfigSecretName,
 			VolumeSource: corev1.VolumeSource{
@@ -218,23 +269,36 @@ func getVolumes() []corev1.Volume {
 				},
 			},
 		},
-		{
+	}
+	if cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeOpenVPN) {
+		volumes = append(volumes, corev1.Volume{
 			Name: resources.OpenVPNCASecretName,
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
 					SecretName: resources.OpenVPNCASecretName,
 				},
 			},
+		})
+	}
+	volumes = append(volumes, corev1.Volume{
+		Name: resources.UserSSHKeys,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: resources.UserSSHKeys,
+			},
 		},
-		{
-			Name: resources.UserSSHKeys,
+	})
+	if cluster.IsTunnelingModeDeployed(kubermaticv1.TunnelingModeKonnectivity) {
+		volumes = append(volumes, corev1.Volume{
+			Name: resources.KonnectivityCASecretName,
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
-					SecretName: resources.UserSSHKeys,
+					SecretName: resources.KonnectivityCASecretName,
 				},
 			},
-		},
+		})
 	}
+	return volumes
 }
 
 func getNetworkArgs(data userclusterControllerData) []string {
@@ -0,0 +1,70 @@
+package usercluster
+
+import (
+	"fmt"
+
+	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/reconciling"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// ServiceAccountCreator returns the function to create the serviceaccount the usercluster-controller
+// uses to access the seed cluster
+func ServiceAccountCreator() reconciling.NamedServiceAccountCreatorGetter {
+	return func() (string, reconciling.ServiceAccountCreator) {
+		return resources.UserClusterControllerServiceAccountName, func(sa *corev1.ServiceAccount) (*corev1.ServiceAccount, error) {
+			return sa, nil
+		}
+	}
+}
+
+// SeedReaderClusterRoleCreator returns the function to create the ClusterRole which allows the
+// usercluster-controller to read the constraints it syncs into the user cluster
+func SeedReaderClusterRoleCreator() reconciling.NamedClusterRoleCreatorGetter {
+	return func() (string, reconciling.ClusterRoleCreator) {
+		return resources.UserClusterControllerSeedReaderClusterRoleName, func(cr *rbacv1.ClusterRole) (*rbacv1.ClusterRole, error) {
+			cr.Rules = []rbacv1.PolicyRule{
+				{
+					APIGroups: []string{kubermaticv1.GroupName},
+					Resources: []string{
+						kubermaticv1.ConstraintTemplateResourceName,
+						kubermaticv1.ConstraintResourceName,
+					},
+					Verbs: []string{"get", "list", "watch"},
+				},
+			}
+			return cr, nil
+		}
+	}
+}
+
+// SeedReaderClusterRoleBindingName returns the name of the ClusterRoleBinding of the usercluster-controller
+// in the given cluster namespace
+func SeedReaderClusterRoleBindingName(namespace string) string {
+	return fmt.Sprintf("%s:%s", resources.UserClusterControllerSeedReaderClusterRoleName, namespace)
+}
+
+// SeedReaderClusterRoleBindingCreator returns the function to bind the seed reader ClusterRole to the
+// serviceaccount of the usercluster-controller in the given cluster namespace
+func SeedReaderClusterRoleBindingCreator(namespace string) reconciling.NamedClusterRoleBindingCreatorGetter {
+	return func() (string, reconciling.ClusterRoleBindingCreator) {
+		return SeedReaderClusterRoleBindingName(namespace), func(crb *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error) {
+			crb.RoleRef = rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     resources.UserClusterControllerSeedReaderClusterRoleName,
+			}
+			crb.Subjects = []rbacv1.Subject{
+				{
+					Kind:      rbacv1.ServiceAccountKind,
+					Name:      resources.UserClusterControllerServiceAccountName,
+					Namespace: namespace,
+				},
+			}
+			return crb, nil
+		}
+	}
+}
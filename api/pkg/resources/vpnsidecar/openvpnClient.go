@@ -70,3 +70,23 @@ func OpenVPNSidecarContainer(data openvpnData, name string) (*corev1.Container,
 		},
 	}, nil
 }
+
+// OpenVPNSidecarEnabled returns whether the master components reach the user cluster
+// networks through the openvpn-client sidecar. With konnectivity only the apiserver
+// reaches the user cluster, through its egress selector.
+func OpenVPNSidecarEnabled(cluster *kubermaticv1.Cluster) bool {
+	return cluster.ActiveTunnelingMode() == kubermaticv1.TunnelingModeOpenVPN
+}
+
+// WithoutOpenVPNVolumes removes the volumes of the openvpn-client and dnat-controller sidecars
+// from the given volumes, for pods which don't get the sidecars
+func WithoutOpenVPNVolumes(volumes []corev1.Volume) []corev1.Volume {
+	var result []corev1.Volume
+	for _, volume := range volumes {
+		if volume.Name == resources.OpenVPNClientCertificatesSecretName || volume.Name == resources.KubeletDnatControllerKubeconfigSecretName {
+			continue
+		}
+		result = append(result, volume)
+	}
+	return result
+}
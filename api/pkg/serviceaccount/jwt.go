@@ -6,11 +6,19 @@ import (
 
 	"gopkg.in/square/go-jose.v2"
 	"gopkg.in/square/go-jose.v2/jwt"
+
+	"k8s.io/apimachinery/pkg/util/rand"
 )
 
 // Now stubbed out to allow testing
 var Now = time.Now
 
+// DefaultExpiry returns the expiry of a token issued at the given time without an explicit expiry,
+// it is also the latest expiry a token may have
+func DefaultExpiry(issuedAt time.Time) time.Time {
+	return issuedAt.AddDate(3, 0, 0)
+}
+
 // TokenGenerator declares the method to generate JWT token
 type TokenGenerator interface {
 	// Generate generates a token which will identify the given
@@ -26,6 +34,9 @@ type TokenGenerator interface {
 type TokenAuthenticator interface {
 	// Authenticate checks given token and transform it to custom claim object
 	Authenticate(tokenData string) (*jwt.Claims, *CustomTokenClaim, error)
+	// Parse checks the signature of the given token and transform it to custom claim object,
+	// unlike Authenticate it accepts expired tokens
+	Parse(tokenData string) (*jwt.Claims, *CustomTokenClaim, error)
 }
 
 // CustomTokenClaim represents authenticated user
@@ -33,19 +44,30 @@ type CustomTokenClaim struct {
 	Email     string `json:"email,omitempty"`
 	ProjectID string `json:"project_id,omitempty"`
 	TokenID   string `json:"token_id,omitempty"`
+	// Scopes restrict the requests the token is allowed to make, see Scope
+	Scopes []Scope `json:"scopes,omitempty"`
 }
 
+// Claims returns the claims of a token that expires after three years and has the full rights of the service account
 func Claims(email, projectID, tokenID string) (*jwt.Claims, *CustomTokenClaim) {
+	return ScopedClaims(email, projectID, tokenID, DefaultExpiry(Now()), nil)
+}
+
+// ScopedClaims returns the claims of a token that expires at the given time and is restricted to the given scopes
+func ScopedClaims(email, projectID, tokenID string, expiry time.Time, scopes []Scope) (*jwt.Claims, *CustomTokenClaim) {
 
 	sc := &jwt.Claims{
+		// a random ID makes tokens that were issued in the same second distinct, e.g. after a rotation
+		ID:        rand.String(10),
 		IssuedAt:  jwt.NewNumericDate(Now()),
 		NotBefore: jwt.NewNumericDate(Now()),
-		Expiry:    jwt.NewNumericDate(Now().AddDate(3, 0, 0)),
+		Expiry:    jwt.NewNumericDate(expiry),
 	}
 	pc := &CustomTokenClaim{
 		Email:     email,
 		ProjectID: projectID,
 		TokenID:   tokenID,
+		Scopes:    scopes,
 	}
 
 	return sc, pc
@@ -92,18 +114,11 @@ func JWTTokenAuthenticator(privateKey []byte) TokenAuthenticator {
 // Authenticate decrypts signed token data to CustomTokenClaim object and checks if token expired
 func (a *jwtTokenAuthenticator) Authenticate(tokenData string) (*jwt.Claims, *CustomTokenClaim, error) {
 
-	tok, err := jwt.ParseSigned(tokenData)
+	public, customClaims, err := a.Parse(tokenData)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	public := &jwt.Claims{}
-	customClaims := &CustomTokenClaim{}
-
-	if err := tok.Claims(a.key, customClaims, public); err != nil {
-		return nil, nil, err
-	}
-
 	err = public.Validate(jwt.Expected{
 		Time: Now(),
 	})
@@ -118,6 +133,24 @@ func (a *jwtTokenAuthenticator) Authenticate(tokenData string) (*jwt.Claims, *Cu
 	return public, customClaims, nil
 }
 
+// Parse decrypts signed token data to CustomTokenClaim object without checking if token expired
+func (a *jwtTokenAuthenticator) Parse(tokenData string) (*jwt.Claims, *CustomTokenClaim, error) {
+
+	tok, err := jwt.ParseSigned(tokenData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	public := &jwt.Claims{}
+	customClaims := &CustomTokenClaim{}
+
+	if err := tok.Claims(a.key, customClaims, public); err != nil {
+		return nil, nil, err
+	}
+
+	return public, customClaims, nil
+}
+
 func ValidateKey(privateKey []byte) error {
 	if len(privateKey) == 0 {
 		return fmt.Errorf("the signing key can not be empty")
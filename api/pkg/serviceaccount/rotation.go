@@ -0,0 +1,41 @@
+package serviceaccount
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// PreviousTokenKey is the key of the replaced token in the secret of a rotated token
+	PreviousTokenKey = "previousToken"
+	// PreviousTokenExpiryAnnotation holds the time until which the replaced token of a rotated token stays valid
+	PreviousTokenExpiryAnnotation = "kubermatic.io/previous-token-expiry"
+)
+
+// SetPreviousToken keeps the given replaced token in the secret of a rotated token, so it stays valid
+// until the end of the grace period
+func SetPreviousToken(secret *corev1.Secret, token []byte, gracePeriod time.Duration) {
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Data[PreviousTokenKey] = token
+	secret.Annotations[PreviousTokenExpiryAnnotation] = Now().Add(gracePeriod).UTC().Format(time.RFC3339)
+}
+
+// PreviousTokenValid returns whether the given token was replaced by the token in the secret
+// and its grace period has not ended yet
+func PreviousTokenValid(secret *corev1.Secret, token string) bool {
+	previousToken, ok := secret.Data[PreviousTokenKey]
+	if !ok || string(previousToken) != token {
+		return false
+	}
+	expiry, err := time.Parse(time.RFC3339, secret.Annotations[PreviousTokenExpiryAnnotation])
+	if err != nil {
+		return false
+	}
+	return Now().Before(expiry)
+}
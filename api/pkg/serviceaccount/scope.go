@@ -0,0 +1,144 @@
+package serviceaccount
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// credentialResources are the endpoints which hand out credentials that are not restricted by the
+// scopes of the token, like the kubeconfig of a cluster. Scoped tokens are never allowed to use them.
+var credentialResources = sets.NewString("kubeconfig", "oidckubeconfig", "token", "viewertoken")
+
+// Scope restricts the requests a token is allowed to make. A token without scopes has all the
+// rights of its service account, a token with scopes may only make the requests at least one of
+// its scopes allows. The rights of the service account always apply in addition.
+type Scope struct {
+	// ReadOnly only allows requests that don't modify anything
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Resource only allows requests for the given resource and its subresources, e.g. "nodedeployments"
+	Resource string `json:"resource,omitempty"`
+	// ClusterID only allows requests for the given cluster and its subresources
+	ClusterID string `json:"cluster_id,omitempty"`
+}
+
+// Validate validates the scope
+func (s Scope) Validate() error {
+	if s.Resource != "" && strings.Contains(s.Resource, "/") {
+		return fmt.Errorf("invalid resource %q, must be a single path segment like \"nodedeployments\"", s.Resource)
+	}
+	if !s.ReadOnly && s.Resource == "" && s.ClusterID == "" {
+		return fmt.Errorf("scope does not restrict anything, set at least one of read_only, resource or cluster_id")
+	}
+	return nil
+}
+
+// Allows returns whether the scope allows a request with the given HTTP method and URL path
+func (s Scope) Allows(method, path string) bool {
+	if s.ReadOnly && method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	if s.Resource == "" && s.ClusterID == "" {
+		return true
+	}
+
+	resources, clusterID := parsePath(path)
+	if s.ClusterID != "" && s.ClusterID != clusterID {
+		return false
+	}
+	if s.Resource != "" {
+		for _, resource := range resources {
+			if resource == s.Resource {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Covers returns whether the scope allows every request the other scope allows
+func (s Scope) Covers(other Scope) bool {
+	if s.ReadOnly && !other.ReadOnly {
+		return false
+	}
+	if s.Resource != "" && s.Resource != other.Resource {
+		return false
+	}
+	if s.ClusterID != "" && s.ClusterID != other.ClusterID {
+		return false
+	}
+	return true
+}
+
+// ScopesAllow returns whether a token with the given scopes is allowed to make a request with
+// the given HTTP method and URL path
+func ScopesAllow(scopes []Scope, method, path string) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if credentialResources.Has(segments[len(segments)-1]) {
+		return false
+	}
+	for _, scope := range scopes {
+		if scope.Allows(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesCover returns whether a token with the given scopes may issue a token with the requested scopes,
+// i.e. whether every request the requested scopes allow is allowed by the given scopes as well
+func ScopesCover(scopes, requested []Scope) bool {
+	if len(scopes) == 0 {
+		return true
+	}
+	// a token without scopes is unrestricted
+	if len(requested) == 0 {
+		return false
+	}
+	for _, r := range requested {
+		covered := false
+		for _, scope := range scopes {
+			if scope.Covers(r) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+// parsePath returns the resources of a path like /api/v1/projects/{project_id}/dc/{dc}/clusters/{cluster_id}/nodedeployments
+// and the ID of the cluster it belongs to, if any. The resources are the path segments starting at "projects"
+// that are followed by an ID, plus the last segment.
+func parsePath(path string) ([]string, string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	start := -1
+	for i, segment := range segments {
+		if segment == "projects" {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return nil, ""
+	}
+
+	var resources []string
+	var clusterID string
+	for i := start; i < len(segments); i += 2 {
+		resources = append(resources, segments[i])
+		if segments[i] == "clusters" && i+1 < len(segments) {
+			clusterID = segments[i+1]
+		}
+	}
+	return resources, clusterID
+}
@@ -0,0 +1,194 @@
+package serviceaccount_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kubermatic/kubermatic/api/pkg/handler/test"
+	"github.com/kubermatic/kubermatic/api/pkg/serviceaccount"
+)
+
+func TestScopesAllow(t *testing.T) {
+	const nodeDeploymentsPath = "/api/v1/projects/my-project/dc/us-central1/clusters/abcd/nodedeployments"
+
+	testcases := []struct {
+		name     string
+		scopes   []serviceaccount.Scope
+		method   string
+		path     string
+		expected bool
+	}{
+		{
+			name:     "scenario 1: no scopes allow everything",
+			method:   http.MethodDelete,
+			path:     "/api/v1/projects/my-project",
+			expected: true,
+		},
+		{
+			name:     "scenario 2: read only scope allows GET",
+			scopes:   []serviceaccount.Scope{{ReadOnly: true}},
+			method:   http.MethodGet,
+			path:     nodeDeploymentsPath,
+			expected: true,
+		},
+		{
+			name:     "scenario 3: read only scope denies POST",
+			scopes:   []serviceaccount.Scope{{ReadOnly: true}},
+			method:   http.MethodPost,
+			path:     nodeDeploymentsPath,
+			expected: false,
+		},
+		{
+			name:     "scenario 4: node deployments of the cluster are allowed",
+			scopes:   []serviceaccount.Scope{{Resource: "nodedeployments", ClusterID: "abcd"}},
+			method:   http.MethodPost,
+			path:     nodeDeploymentsPath,
+			expected: true,
+		},
+		{
+			name:     "scenario 5: node deployments of another cluster are denied",
+			scopes:   []serviceaccount.Scope{{Resource: "nodedeployments", ClusterID: "efgh"}},
+			method:   http.MethodPost,
+			path:     nodeDeploymentsPath,
+			expected: false,
+		},
+		{
+			name:     "scenario 6: other resources of the cluster are denied",
+			scopes:   []serviceaccount.Scope{{Resource: "nodedeployments", ClusterID: "abcd"}},
+			method:   http.MethodGet,
+			path:     "/api/v1/projects/my-project/dc/us-central1/clusters/abcd/kubeconfig",
+			expected: false,
+		},
+		{
+			name:     "scenario 7: a resource ID is not mistaken for a resource",
+			scopes:   []serviceaccount.Scope{{Resource: "nodedeployments"}},
+			method:   http.MethodGet,
+			path:     "/api/v1/projects/nodedeployments/dc/us-central1/clusters",
+			expected: false,
+		},
+		{
+			name:     "scenario 8: one of the scopes must allow the request",
+			scopes:   []serviceaccount.Scope{{ClusterID: "efgh"}, {ReadOnly: true}},
+			method:   http.MethodGet,
+			path:     nodeDeploymentsPath,
+			expected: true,
+		},
+		{
+			name:     "scenario 9: read only scope denies the kubeconfig of a cluster",
+			scopes:   []serviceaccount.Scope{{ReadOnly: true}},
+			method:   http.MethodGet,
+			path:     "/api/v1/projects/my-project/dc/us-central1/clusters/abcd/kubeconfig",
+			expected: false,
+		},
+		{
+			name:     "scenario 10: cluster scope denies the admin token of the cluster",
+			scopes:   []serviceaccount.Scope{{ClusterID: "abcd"}},
+			method:   http.MethodPut,
+			path:     "/api/v1/projects/my-project/dc/us-central1/clusters/abcd/token",
+			expected: false,
+		},
+		{
+			name:     "scenario 11: no scopes allow the kubeconfig of a cluster",
+			method:   http.MethodGet,
+			path:     "/api/v1/projects/my-project/dc/us-central1/clusters/abcd/kubeconfig",
+			expected: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if allowed := serviceaccount.ScopesAllow(tc.scopes, tc.method, tc.path); allowed != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, allowed)
+			}
+		})
+	}
+}
+
+func TestScopesCover(t *testing.T) {
+	testcases := []struct {
+		name      string
+		scopes    []serviceaccount.Scope
+		requested []serviceaccount.Scope
+		expected  bool
+	}{
+		{
+			name:     "scenario 1: no scopes cover an unrestricted token",
+			expected: true,
+		},
+		{
+			name:     "scenario 2: scopes don't cover an unrestricted token",
+			scopes:   []serviceaccount.Scope{{ReadOnly: true}},
+			expected: false,
+		},
+		{
+			name:      "scenario 3: a read only scope covers a read only scope for a cluster",
+			scopes:    []serviceaccount.Scope{{ReadOnly: true}},
+			requested: []serviceaccount.Scope{{ReadOnly: true, ClusterID: "abcd"}},
+			expected:  true,
+		},
+		{
+			name:      "scenario 4: a read only scope doesn't cover a scope for a cluster",
+			scopes:    []serviceaccount.Scope{{ReadOnly: true}},
+			requested: []serviceaccount.Scope{{ClusterID: "abcd"}},
+			expected:  false,
+		},
+		{
+			name:      "scenario 5: a scope for a resource doesn't cover another resource",
+			scopes:    []serviceaccount.Scope{{Resource: "nodedeployments"}},
+			requested: []serviceaccount.Scope{{Resource: "clusters"}},
+			expected:  false,
+		},
+		{
+			name:      "scenario 6: every requested scope must be covered",
+			scopes:    []serviceaccount.Scope{{ClusterID: "abcd"}, {ReadOnly: true}},
+			requested: []serviceaccount.Scope{{ClusterID: "abcd", Resource: "nodedeployments"}, {ClusterID: "efgh"}},
+			expected:  false,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if covered := serviceaccount.ScopesCover(tc.scopes, tc.requested); covered != tc.expected {
+				t.Fatalf("expected %v, got %v", tc.expected, covered)
+			}
+		})
+	}
+}
+
+func TestScopedClaims(t *testing.T) {
+	tokenGenerator, err := serviceaccount.JWTTokenGenerator([]byte(test.TestServiceAccountHashKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenAuthenticator := serviceaccount.JWTTokenAuthenticator([]byte(test.TestServiceAccountHashKey))
+
+	expiry := serviceaccount.Now().Add(time.Hour)
+	scopes := []serviceaccount.Scope{{ReadOnly: true, ClusterID: "abcd"}}
+	token, err := tokenGenerator.Generate(serviceaccount.ScopedClaims("test@example.com", "testProject", "testToken", expiry, scopes))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, custom, err := tokenAuthenticator.Authenticate(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if public.Expiry.Time().Unix() != expiry.Unix() {
+		t.Fatalf("expected expiry %v, got %v", expiry, public.Expiry.Time())
+	}
+	if len(custom.Scopes) != 1 || custom.Scopes[0] != scopes[0] {
+		t.Fatalf("expected scopes %v, got %v", scopes, custom.Scopes)
+	}
+
+	expiredToken, err := tokenGenerator.Generate(serviceaccount.ScopedClaims("test@example.com", "testProject", "testToken", serviceaccount.Now().Add(-time.Hour), nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tokenAuthenticator.Authenticate(expiredToken); err == nil {
+		t.Fatal("expected an expired token to be rejected")
+	}
+	if _, _, err := tokenAuthenticator.Parse(expiredToken); err != nil {
+		t.Fatalf("expected an expired token to be parsed, got %v", err)
+	}
+}
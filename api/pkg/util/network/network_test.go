@@ -0,0 +1,26 @@
+package network
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIsInternalIP(t *testing.T) {
+	for address, expected := range map[string]bool{
+		"127.0.0.1":       true,
+		"10.10.0.1":       true,
+		"172.20.1.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fe80::1":         true,
+		"fd00::1":         true,
+		"93.184.216.34":   false,
+		"2606:2800::1":    false,
+	} {
+		if internal := IsInternalIP(net.ParseIP(address)); internal != expected {
+			t.Errorf("expected %s to be internal: %v, got %v", address, expected, internal)
+		}
+	}
+}
@@ -5,21 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/kubermatic/kubermatic/api/pkg/cluster/hibernation"
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
 	kuberneteshelper "github.com/kubermatic/kubermatic/api/pkg/kubernetes"
 	"github.com/kubermatic/kubermatic/api/pkg/provider"
 	"github.com/kubermatic/kubermatic/api/pkg/provider/cloud"
 	kubernetesprovider "github.com/kubermatic/kubermatic/api/pkg/provider/kubernetes"
 	"github.com/kubermatic/kubermatic/api/pkg/resources"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/componentflags"
+	"github.com/kubermatic/kubermatic/api/pkg/resources/etcd"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	utilerror "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 var (
 	// ErrCloudChangeNotAllowed describes that it is not allowed to change the cloud provider
 	ErrCloudChangeNotAllowed = errors.New("not allowed to change the cloud provider")
+
+	errOPAIntegrationOpenshift = errors.New("the OPA integration is not supported for openshift clusters")
 )
 
 // ValidateCreateClusterSpec validates the given cluster spec
@@ -44,6 +53,142 @@ func ValidateCreateClusterSpec(spec *kubermaticv1.ClusterSpec, dc *kubermaticv1.
 		return fmt.Errorf("machine network validation failed, see: %v", err)
 	}
 
+	if err := hibernation.Validate(spec.Hibernation); err != nil {
+		return fmt.Errorf("invalid hibernation settings: %v", err)
+	}
+
+	if err := validateClusterAutoscalerSettings(spec.ClusterAutoscaler); err != nil {
+		return fmt.Errorf("invalid cluster-autoscaler settings: %v", err)
+	}
+
+	if spec.EncryptionAtRest != nil && spec.Openshift != nil {
+		return errors.New("encryption at rest is not supported for openshift clusters")
+	}
+	if err := validateEncryptionAtRestSettings(spec.EncryptionAtRest); err != nil {
+		return fmt.Errorf("invalid encryption at rest settings: %v", err)
+	}
+
+	if err := validateComponentCustomisation(spec, dc, spec.Openshift != nil); err != nil {
+		return err
+	}
+	if err := validateEtcdClusterSize(spec, spec.Openshift != nil); err != nil {
+		return err
+	}
+	if err := validateTunnelingMode(spec, spec.Openshift != nil); err != nil {
+		return err
+	}
+
+	if spec.OPAIntegration != nil && spec.OPAIntegration.Enabled && spec.Openshift != nil {
+		return errOPAIntegrationOpenshift
+	}
+
+	return nil
+}
+
+// validateComponentCustomisation checks the admission plugins and the extra flags of the control plane
+// components against the component flag policy of the datacenter
+func validateComponentCustomisation(spec *kubermaticv1.ClusterSpec, dc *kubermaticv1.Datacenter, isOpenshift bool) error {
+	if len(spec.AdmissionPlugins) == 0 && spec.ExtraFlags == nil {
+		return nil
+	}
+	if isOpenshift {
+		return errors.New("admission plugins and extra flags are not supported for openshift clusters")
+	}
+
+	var policy *kubermaticv1.ComponentFlagPolicy
+	if dc != nil {
+		policy = dc.Spec.ComponentFlagPolicy
+	}
+	if err := componentflags.ValidateAdmissionPlugins(spec.AdmissionPlugins, policy); err != nil {
+		return fmt.Errorf("invalid admission plugins: %v", err)
+	}
+	if spec.ExtraFlags == nil {
+		return nil
+	}
+	for component, flags := range map[componentflags.Component]map[string]string{
+		componentflags.Apiserver:         spec.ExtraFlags.Apiserver,
+		componentflags.ControllerManager: spec.ExtraFlags.ControllerManager,
+		componentflags.Scheduler:         spec.ExtraFlags.Scheduler,
+	} {
+		if err := componentflags.ValidateExtraFlags(component, flags, policy); err != nil {
+			return fmt.Errorf("invalid extra flags: %v", err)
+		}
+	}
+	return nil
+}
+
+// validateEtcdClusterSize checks the configured number of etcd members, openshift clusters
+// always have the default size
+func validateEtcdClusterSize(spec *kubermaticv1.ClusterSpec, isOpenshift bool) error {
+	size := spec.ComponentsOverride.Etcd.ClusterSize
+	if size != 0 && isOpenshift {
+		return errors.New("the etcd cluster size can't be configured for openshift clusters")
+	}
+	return etcd.ValidateClusterSize(size)
+}
+
+func validateEncryptionAtRestSettings(settings *kubermaticv1.EncryptionAtRestSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	known := false
+	var providers []string
+	for _, p := range kubermaticv1.AllEncryptionProviders {
+		known = known || settings.Provider == p
+		providers = append(providers, string(p))
+	}
+	if !known {
+		return fmt.Errorf("provider must be one of %s", strings.Join(providers, ", "))
+	}
+
+	return nil
+}
+
+// validateEncryptionAtRestUpdate prevents changes which would make the Secrets which are encrypted already unreadable
+func validateEncryptionAtRestUpdate(newSettings, oldSettings *kubermaticv1.EncryptionAtRestSettings) error {
+	if oldSettings == nil {
+		return nil
+	}
+	if newSettings == nil {
+		return errors.New("disabling the encryption at rest is not allowed")
+	}
+	if newSettings.Provider != oldSettings.Provider {
+		return errors.New("changing the encryption provider is not allowed")
+	}
+	return nil
+}
+
+var clusterAutoscalerExpanders = sets.NewString("random", "most-pods", "least-waste", "priority")
+
+func validateClusterAutoscalerSettings(settings *kubermaticv1.ClusterAutoscalerSettings) error {
+	if settings == nil {
+		return nil
+	}
+
+	for name, value := range map[string]string{
+		"scaleDownDelayAfterAdd": settings.ScaleDownDelayAfterAdd,
+		"scaleDownUnneededTime":  settings.ScaleDownUnneededTime,
+	} {
+		if value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			return fmt.Errorf("%s must be a positive duration, e.g. 10m", name)
+		}
+	}
+
+	if settings.ScaleDownUtilizationThreshold != "" {
+		threshold, err := strconv.ParseFloat(settings.ScaleDownUtilizationThreshold, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			return errors.New("scaleDownUtilizationThreshold must be a number between 0 and 1")
+		}
+	}
+
+	if settings.Expander != "" && !clusterAutoscalerExpanders.Has(settings.Expander) {
+		return fmt.Errorf("expander must be one of %s", strings.Join(clusterAutoscalerExpanders.List(), ", "))
+	}
+
 	return nil
 }
 
@@ -167,6 +312,39 @@ func ValidateUpdateCluster(ctx context.Context, newCluster, oldCluster *kubermat
 		return fmt.Errorf("invalid cloud spec: %v", err)
 	}
 
+	if err := hibernation.Validate(newCluster.Spec.Hibernation); err != nil {
+		return fmt.Errorf("invalid hibernation settings: %v", err)
+	}
+
+	if err := validateClusterAutoscalerSettings(newCluster.Spec.ClusterAutoscaler); err != nil {
+		return fmt.Errorf("invalid cluster-autoscaler settings: %v", err)
+	}
+
+	if _, isOpenshift := newCluster.Annotations["kubermatic.io/openshift"]; isOpenshift && newCluster.Spec.EncryptionAtRest != nil {
+		return errors.New("encryption at rest is not supported for openshift clusters")
+	}
+	if err := validateEncryptionAtRestSettings(newCluster.Spec.EncryptionAtRest); err != nil {
+		return fmt.Errorf("invalid encryption at rest settings: %v", err)
+	}
+	if err := validateEncryptionAtRestUpdate(newCluster.Spec.EncryptionAtRest, oldCluster.Spec.EncryptionAtRest); err != nil {
+		return fmt.Errorf("invalid encryption at rest settings: %v", err)
+	}
+
+	_, isOpenshift := newCluster.Annotations["kubermatic.io/openshift"]
+	if err := validateComponentCustomisation(&newCluster.Spec, dc, isOpenshift); err != nil {
+		return err
+	}
+	if err := validateEtcdClusterSize(&newCluster.Spec, isOpenshift); err != nil {
+		return err
+	}
+	if err := validateTunnelingMode(&newCluster.Spec, isOpenshift); err != nil {
+		return err
+	}
+
+	if newCluster.Spec.OPAIntegration != nil && newCluster.Spec.OPAIntegration.Enabled && isOpenshift {
+		return errOPAIntegrationOpenshift
+	}
+
 	// We ignore the error, since we're here to check the new config, not the old one.
 	oldProviderName, _ := provider.ClusterCloudProviderName(oldCluster.Spec.Cloud)
 
@@ -196,6 +374,25 @@ func ValidateUpdateCluster(ctx context.Context, newCluster, oldCluster *kubermat
 	return nil
 }
 
+// validateTunnelingMode checks the tunneling mode the control plane uses to reach the cluster. Konnectivity
+// requires the egress selector of the apiserver, which is only available with Kubernetes 1.16+
+func validateTunnelingMode(spec *kubermaticv1.ClusterSpec, isOpenshift bool) error {
+	mode := spec.ClusterNetwork.TunnelingMode
+	if mode == "" || mode == kubermaticv1.TunnelingModeOpenVPN {
+		return nil
+	}
+	if !mode.IsValid() {
+		return fmt.Errorf("invalid tunneling mode %q, must be one of %v", mode, kubermaticv1.AllTunnelingModes)
+	}
+	if isOpenshift {
+		return fmt.Errorf("the tunneling mode %q is not supported for openshift clusters", mode)
+	}
+	if v := spec.Version.Semver(); v != nil && v.Minor() < 16 {
+		return fmt.Errorf("the tunneling mode %q requires Kubernetes 1.16 or newer", mode)
+	}
+	return nil
+}
+
 // ValidateCloudSpec validates if the cloud spec is valid
 func ValidateCloudSpec(spec kubermaticv1.CloudSpec, dc *kubermaticv1.Datacenter) error {
 	if spec.DatacenterName == "" {
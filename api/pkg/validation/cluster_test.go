@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	kubermaticv1 "github.com/kubermatic/kubermatic/api/pkg/crd/kubermatic/v1"
+	"github.com/kubermatic/kubermatic/api/pkg/semver"
 )
 
 var (
@@ -95,3 +96,243 @@ func TestValidateCloudSpec(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateClusterAutoscalerSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings *kubermaticv1.ClusterAutoscalerSettings
+		err      error
+	}{
+		{
+			name: "no settings",
+		},
+		{
+			name: "valid settings",
+			settings: &kubermaticv1.ClusterAutoscalerSettings{
+				ScaleDownDelayAfterAdd:        "10m",
+				ScaleDownUnneededTime:         "5m",
+				ScaleDownUtilizationThreshold: "0.5",
+				Expander:                      "least-waste",
+			},
+		},
+		{
+			name:     "invalid duration",
+			settings: &kubermaticv1.ClusterAutoscalerSettings{ScaleDownUnneededTime: "10 minutes"},
+			err:      errors.New("scaleDownUnneededTime must be a positive duration, e.g. 10m"),
+		},
+		{
+			name:     "threshold out of range",
+			settings: &kubermaticv1.ClusterAutoscalerSettings{ScaleDownUtilizationThreshold: "70"},
+			err:      errors.New("scaleDownUtilizationThreshold must be a number between 0 and 1"),
+		},
+		{
+			name:     "unknown expander",
+			settings: &kubermaticv1.ClusterAutoscalerSettings{Expander: "cheapest"},
+			err:      errors.New("expander must be one of least-waste, most-pods, priority, random"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateClusterAutoscalerSettings(test.settings)
+			if fmt.Sprint(err) != fmt.Sprint(test.err) {
+				t.Errorf("Expected err to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateEncryptionAtRestSettings(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    *kubermaticv1.EncryptionAtRestSettings
+		oldSettings *kubermaticv1.EncryptionAtRestSettings
+		err         error
+	}{
+		{
+			name: "disabled",
+		},
+		{
+			name:     "enabled",
+			settings: &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderSecretbox},
+		},
+		{
+			name:     "unknown provider",
+			settings: &kubermaticv1.EncryptionAtRestSettings{Provider: "aesgcm"},
+			err:      errors.New("provider must be one of aescbc, secretbox, kms"),
+		},
+		{
+			name:        "disabling",
+			oldSettings: &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderAESCBC},
+			err:         errors.New("disabling the encryption at rest is not allowed"),
+		},
+		{
+			name:        "changing the provider",
+			settings:    &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderKMS},
+			oldSettings: &kubermaticv1.EncryptionAtRestSettings{Provider: kubermaticv1.EncryptionProviderAESCBC},
+			err:         errors.New("changing the encryption provider is not allowed"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateEncryptionAtRestSettings(test.settings)
+			if err == nil {
+				err = validateEncryptionAtRestUpdate(test.settings, test.oldSettings)
+			}
+			if fmt.Sprint(err) != fmt.Sprint(test.err) {
+				t.Errorf("Expected err to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateComponentCustomisation(t *testing.T) {
+	dc := &kubermaticv1.Datacenter{
+		Spec: kubermaticv1.DatacenterSpec{
+			ComponentFlagPolicy: &kubermaticv1.ComponentFlagPolicy{
+				AllowedAdmissionPlugins: []string{"PodNodeSelector"},
+				AllowedSchedulerFlags:   []string{"v"},
+			},
+		},
+	}
+	tests := []struct {
+		name        string
+		spec        *kubermaticv1.ClusterSpec
+		isOpenshift bool
+		err         error
+	}{
+		{
+			name: "nothing customised",
+			spec: &kubermaticv1.ClusterSpec{},
+		},
+		{
+			name: "allowed customisation",
+			spec: &kubermaticv1.ClusterSpec{
+				AdmissionPlugins: []kubermaticv1.AdmissionPluginSettings{{Name: "PodNodeSelector"}},
+				ExtraFlags:       &kubermaticv1.ComponentExtraFlags{Scheduler: map[string]string{"v": "4"}},
+			},
+		},
+		{
+			name: "flag not allowed",
+			spec: &kubermaticv1.ClusterSpec{
+				ExtraFlags: &kubermaticv1.ComponentExtraFlags{ControllerManager: map[string]string{"v": "4"}},
+			},
+			err: errors.New("invalid extra flags: the controllerManager flag v is not allowed in this datacenter"),
+		},
+		{
+			name: "openshift",
+			spec: &kubermaticv1.ClusterSpec{
+				AdmissionPlugins: []kubermaticv1.AdmissionPluginSettings{{Name: "PodNodeSelector"}},
+			},
+			isOpenshift: true,
+			err:         errors.New("admission plugins and extra flags are not supported for openshift clusters"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateComponentCustomisation(test.spec, dc, test.isOpenshift)
+			if fmt.Sprint(err) != fmt.Sprint(test.err) {
+				t.Errorf("Expected err to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateEtcdClusterSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		isOpenshift bool
+		err         error
+	}{
+		{
+			name: "default size",
+		},
+		{
+			name: "five members",
+			size: 5,
+		},
+		{
+			name: "even size",
+			size: 4,
+			err:  errors.New("the etcd cluster size must be an odd number between 1 and 9, got 4"),
+		},
+		{
+			name:        "openshift",
+			size:        5,
+			isOpenshift: true,
+			err:         errors.New("the etcd cluster size can't be configured for openshift clusters"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := &kubermaticv1.ClusterSpec{}
+			spec.ComponentsOverride.Etcd.ClusterSize = test.size
+			err := validateEtcdClusterSize(spec, test.isOpenshift)
+			if fmt.Sprint(err) != fmt.Sprint(test.err) {
+				t.Errorf("Expected err to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateTunnelingMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        kubermaticv1.TunnelingMode
+		version     string
+		isOpenshift bool
+		err         error
+	}{
+		{
+			name:    "default mode",
+			version: "1.15.0",
+		},
+		{
+			name:        "openvpn on openshift",
+			mode:        kubermaticv1.TunnelingModeOpenVPN,
+			version:     "4.1.9",
+			isOpenshift: true,
+		},
+		{
+			name:    "konnectivity",
+			mode:    kubermaticv1.TunnelingModeKonnectivity,
+			version: "1.17.0",
+		},
+		{
+			name:    "unknown mode",
+			mode:    "wireguard",
+			version: "1.17.0",
+			err:     errors.New(`invalid tunneling mode "wireguard", must be one of [openvpn konnectivity]`),
+		},
+		{
+			name:        "konnectivity on openshift",
+			mode:        kubermaticv1.TunnelingModeKonnectivity,
+			version:     "4.1.9",
+			isOpenshift: true,
+			err:         errors.New(`the tunneling mode "konnectivity" is not supported for openshift clusters`),
+		},
+		{
+			name:    "konnectivity on old version",
+			mode:    kubermaticv1.TunnelingModeKonnectivity,
+			version: "1.15.0",
+			err:     errors.New(`the tunneling mode "konnectivity" requires Kubernetes 1.16 or newer`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spec := &kubermaticv1.ClusterSpec{
+				Version: *semver.NewSemverOrDie(test.version),
+			}
+			spec.ClusterNetwork.TunnelingMode = test.mode
+			err := validateTunnelingMode(spec, test.isOpenshift)
+			if fmt.Sprint(err) != fmt.Sprint(test.err) {
+				t.Errorf("Expected err to be %v, got %v", test.err, err)
+			}
+		})
+	}
+}